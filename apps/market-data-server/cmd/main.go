@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"dizzycode.xyz/shared/health"
+	"dizzycode.xyz/shared/rediskeys"
 	"dizzycoder.xyz/market-data-service/internal/config"
 	"dizzycoder.xyz/market-data-service/internal/handler"
 	"dizzycoder.xyz/market-data-service/internal/logger"
@@ -44,7 +47,25 @@ func main() {
 
 	// 4. 創建 Storage 實現（可替換！）
 	// 這裡使用 Redis，未來可以輕鬆替換為 Kafka, RabbitMQ 等
-	marketStorage := storage.NewRedisStorage(redisClient, log)
+	// Redis 短暫不可用時，失敗的寫入進入緩衝區並在背景重試
+	// Key 命名空間與策略服務共用，多個環境共用一個 Redis 時互不覆蓋 ⭐
+	keys, err := rediskeys.New(cfg.Redis.KeyNamespace)
+	if err != nil {
+		log.Error("Invalid Redis key namespace", map[string]any{
+			"error": err,
+		})
+		os.Exit(1)
+	}
+	redisStorage := storage.NewRedisStorage(redisClient, keys, log)
+	redisStorage.SetCandleTTLConfig(storage.CandleTTLConfig{
+		Multiplier: cfg.Redis.CandleTTLMultiplier,
+		Overrides:  cfg.Redis.CandleTTLOverrides,
+	})
+	marketStorage := storage.NewBufferedStorage(redisStorage, storage.DefaultBufferedStorageConfig(), log)
+
+	retryCtx, stopRetry := context.WithCancel(context.Background())
+	defer stopRetry()
+	go marketStorage.Run(retryCtx)
 
 	// 5. 創建數據保留策略
 	retention := config.DefaultRetentionPolicy()
@@ -53,10 +74,11 @@ func main() {
 	// 將依賴注入放在 main.go，讓依賴關係更清晰
 	tickerHandler := handler.NewTickerHandler(marketStorage, log)
 	candleHandler := handler.NewCandleHandler(marketStorage, retention, log)
+	tradeHandler := handler.NewTradeHandler(marketStorage, retention, log)
 
 	// 7. 設置 WebSocket 管理器（注入 handlers）
-	// 返回 Managers 結構，包含 Ticker 和 Candle 兩個 Manager
-	wsManagers, err := websocket.Setup(cfg, log, tickerHandler, candleHandler)
+	// 返回 Managers 結構，包含 Ticker、Candle 和 Trade Manager
+	wsManagers, err := websocket.Setup(cfg, log, tickerHandler, candleHandler, tradeHandler)
 	if err != nil {
 		log.Error("Failed to setup WebSocket managers", map[string]any{
 			"error": err,
@@ -65,25 +87,48 @@ func main() {
 	}
 	defer wsManagers.Close()
 
+	// 8. 啟動健康檢查服務（/healthz, /readyz）
+	healthServer := health.NewServer(cfg.Port, []health.Checker{
+		{Name: "websocket", Check: func(ctx context.Context) error {
+			if !wsManagers.IsConnected() {
+				return errors.New("websocket disconnected")
+			}
+			return nil
+		}},
+		{Name: "redis", Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}},
+	}, log)
+	healthServer.Start()
+
 	log.Info("Market Data Service started successfully", map[string]any{
 		"instruments": cfg.OKX.Instruments,
 		"ticker":      cfg.OKX.Subscription.Ticker,
 		"candles":     len(cfg.OKX.Subscription.Candles) > 0,
+		"trades":      cfg.OKX.Subscription.Trades,
+		"port":        cfg.Port,
 	})
 
-	// 8. 等待退出信號
+	// 9. 等待退出信號
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("Shutting down Market Data Service...")
 
-	// 9. 關閉前清理 Redis 中的市場數據
+	// 10. 關閉前清理 Redis 中的市場數據
 	// 防止策略服務讀到過時的價格數據
 	log.Info("Cleaning up market data...")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// 先停止健康檢查服務，讓編排器停止轉發流量
+	if err := healthServer.Shutdown(ctx); err != nil {
+		log.Error("Failed to shutdown health server", map[string]any{
+			"error": err,
+		})
+	}
+
 	if err := marketStorage.Cleanup(ctx); err != nil {
 		log.Error("Failed to cleanup market data", map[string]any{
 			"error": err,
@@ -4,6 +4,7 @@ go 1.25.1
 
 require (
 	dizzycode.xyz/logger v0.0.0
+	dizzycode.xyz/shared v0.0.0
 	dizzycode.xyz/websocket v0.0.0
 	github.com/joho/godotenv v1.5.1
 	github.com/redis/go-redis/v9 v9.14.0
@@ -19,4 +20,6 @@ require (
 
 replace dizzycode.xyz/logger => ../../go-packages/logger
 
+replace dizzycode.xyz/shared => ../../go-packages/shared
+
 replace dizzycode.xyz/websocket => ../../go-packages/websocket
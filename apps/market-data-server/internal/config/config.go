@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +13,7 @@ import (
 type Config struct {
 	Environment string
 	LogLevel    string
+	Port        string // 健康檢查 HTTP 端口（/healthz, /readyz）
 	OKX         OKXConfig
 	Redis       RedisConfig
 }
@@ -18,12 +21,29 @@ type Config struct {
 type OKXConfig struct {
 	Instruments  []string              // 要訂閱的交易對列表，例如: BTC-USDT,ETH-USDT
 	Subscription SubscriptionSelection // 訂閱選擇器
+
+	// 每個連接獨立的心跳和超時設置
+	Ticker ConnectionTuning
+	Candle ConnectionTuning
+	Trade  ConnectionTuning
+}
+
+// ConnectionTuning 單個 WebSocket 連接的心跳和超時設置 ⭐
+//
+// 零值表示使用 WebSocket client 默認值（ping 20s / pong 30s / write 10s）。
+// OKX 30 秒內沒有收到任何消息會主動斷開連接，建議 PingInterval < 30s 且 PongWait ≤ 30s；
+// 低頻 K 線（例如 1H）推送間隔長，主要靠 ping 保活，可以按連接分別調整
+type ConnectionTuning struct {
+	PingInterval time.Duration // OKX_{TICKER,CANDLE,TRADE}_PING_INTERVAL，例如 15s
+	PongWait     time.Duration // OKX_{TICKER,CANDLE,TRADE}_PONG_WAIT（讀超時）
+	WriteWait    time.Duration // OKX_{TICKER,CANDLE,TRADE}_WRITE_WAIT
 }
 
 // SubscriptionSelection 訂閱選擇器，控制要啟用哪些頻道
 type SubscriptionSelection struct {
 	Ticker  bool            // 是否訂閱 Ticker（即時價格）
 	Candles map[string]bool // K線訂閱，key 為週期（例如: "1m", "5m", "1H"）
+	Trades  bool            // 是否訂閱 Trades（逐筆成交）
 }
 
 type RedisConfig struct {
@@ -31,6 +51,14 @@ type RedisConfig struct {
 	Password string
 	DB       int
 	PoolSize int
+
+	// KeyNamespace 所有 key 的前綴（例: "prod" → prod.price.latest.{instId}，空 = 無前綴）⭐
+	// market-data-service 和 trading-strategy-server 必須設置相同的值
+	KeyNamespace string
+
+	// 最新 K 線的 TTL（不設置時按週期使用默認值，例如 5m = 600s）⭐
+	CandleTTLMultiplier float64                  // REDIS_CANDLE_TTL_MULTIPLIER，默認 TTL 的倍數（0 = 不放大）
+	CandleTTLOverrides  map[string]time.Duration // REDIS_CANDLE_TTL_OVERRIDES，例如 5m=30m,1m=10m
 }
 
 var AppConfig *Config
@@ -52,15 +80,24 @@ func Load() *Config {
 	cfg := &Config{
 		Environment: requireEnv("ENVIRONMENT"),
 		LogLevel:    getEnvOrDefault("LOG_LEVEL", "info"),
+		Port:        getEnvOrDefault("PORT", "8080"),
 		OKX: OKXConfig{
 			Instruments:  instList,
 			Subscription: subscription,
+			Ticker:       parseConnectionTuning("OKX_TICKER"),
+			Candle:       parseConnectionTuning("OKX_CANDLE"),
+			Trade:        parseConnectionTuning("OKX_TRADE"),
 		},
 		Redis: RedisConfig{
 			Addr:     requireEnv("REDIS_ADDR"),
 			Password: getEnvOrDefault("REDIS_PASSWORD", ""),
 			DB:       getEnvIntOrDefault("REDIS_DB", 0),
 			PoolSize: getEnvIntOrDefault("REDIS_POOL_SIZE", 10),
+
+			KeyNamespace: getEnvOrDefault("REDIS_KEY_NAMESPACE", ""),
+
+			CandleTTLMultiplier: getEnvFloatOrDefault("REDIS_CANDLE_TTL_MULTIPLIER", 0),
+			CandleTTLOverrides:  parseDurationMap(getEnvOrDefault("REDIS_CANDLE_TTL_OVERRIDES", "")),
 		},
 	}
 
@@ -97,10 +134,67 @@ func getEnvIntOrDefault(key string, defaultValue int) int {
 	return intValue
 }
 
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil || floatValue < 0 {
+		log.Printf("⚠️  Invalid float value for %s, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+	return floatValue
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Printf("⚠️  Invalid duration value for %s, using default: %s", key, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// parseDurationMap 解析 "5m=30m,1m=10m" 格式（key=duration，逗號分隔），格式錯誤的項目會被忽略
+func parseDurationMap(value string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, entry := range splitByComma(value) {
+		entry = trimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(entry, "=")
+		duration, err := time.ParseDuration(trimSpace(raw))
+		if !ok || trimSpace(key) == "" || err != nil || duration <= 0 {
+			log.Printf("⚠️  Invalid duration entry %q, ignored", entry)
+			continue
+		}
+		result[trimSpace(key)] = duration
+	}
+	return result
+}
+
+// parseConnectionTuning 讀取 {prefix}_PING_INTERVAL / _PONG_WAIT / _WRITE_WAIT
+func parseConnectionTuning(prefix string) ConnectionTuning {
+	return ConnectionTuning{
+		PingInterval: getEnvDurationOrDefault(prefix+"_PING_INTERVAL", 0),
+		PongWait:     getEnvDurationOrDefault(prefix+"_PONG_WAIT", 0),
+		WriteWait:    getEnvDurationOrDefault(prefix+"_WRITE_WAIT", 0),
+	}
+}
+
 func parseSubscriptionSelection() SubscriptionSelection {
 	// 解析 Ticker 訂閱
 	enableTicker := getEnvOrDefault("OKX_SUBSCRIBE_TICKER", "false") == "true"
 
+	// 解析 Trades 訂閱
+	enableTrades := getEnvOrDefault("OKX_SUBSCRIBE_TRADES", "false") == "true"
+
 	// 解析 Candle 訂閱
 	// 格式: OKX_SUBSCRIBE_CANDLES=1m,5m,1H
 	candlesStr := getEnvOrDefault("OKX_SUBSCRIBE_CANDLES", "")
@@ -119,6 +213,7 @@ func parseSubscriptionSelection() SubscriptionSelection {
 	return SubscriptionSelection{
 		Ticker:  enableTicker,
 		Candles: candles,
+		Trades:  enableTrades,
 	}
 }
 
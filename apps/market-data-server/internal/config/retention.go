@@ -5,6 +5,7 @@ package config
 // 定义不同周期的 K 线应该保留多少根历史数据
 type RetentionPolicy struct {
 	CandleHistoryLength map[string]int // bar -> 保留数量
+	RecentTradesLength  int            // 最近成交保留笔数
 }
 
 // DefaultRetentionPolicy 默认保留策略
@@ -15,6 +16,7 @@ type RetentionPolicy struct {
 // - 5m: 200根（16.6小时）
 // - 1H: 200根（8.3天）
 // - 1D: 365根（1年）
+// - 最近成交: 500笔
 func DefaultRetentionPolicy() *RetentionPolicy {
 	return &RetentionPolicy{
 		CandleHistoryLength: map[string]int{
@@ -31,6 +33,7 @@ func DefaultRetentionPolicy() *RetentionPolicy {
 			"1W":  104, // 2年
 			"1M":  60,  // 5年
 		},
+		RecentTradesLength: 500,
 	}
 }
 
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycoder.xyz/market-data-service/internal/config"
+	"dizzycoder.xyz/market-data-service/internal/okx"
+	"dizzycoder.xyz/market-data-service/internal/storage"
+)
+
+// TradeHandler Trade 成交數據處理器
+//
+// 職責：
+// - 接收 OKX 公共成交數據
+// - 追加到最近成交列表（用於微觀結構分析和滑點建模）
+// - 應用 RetentionPolicy 決定保留多少筆成交
+type TradeHandler struct {
+	storage   storage.MarketDataStorage // 依賴抽象接口
+	retention *config.RetentionPolicy   // 數據保留策略
+	logger    logger.Logger
+}
+
+// NewTradeHandler 創建 Trade 處理器
+func NewTradeHandler(
+	storage storage.MarketDataStorage,
+	retention *config.RetentionPolicy,
+	logger logger.Logger,
+) *TradeHandler {
+	return &TradeHandler{
+		storage:   storage,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Handle 處理 Trade 數據
+func (h *TradeHandler) Handle(trade okx.Trade) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := h.storage.AppendRecentTrade(ctx, trade, h.retention.RecentTradesLength); err != nil {
+		h.logger.Error("Failed to append recent trade", map[string]any{
+			"error":  err,
+			"instId": trade.InstID,
+		})
+		return err
+	}
+
+	return nil
+}
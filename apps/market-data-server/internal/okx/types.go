@@ -133,6 +133,35 @@ func (c *Candle) IsConfirmed() bool {
 	return c.Confirm == "1"
 }
 
+// ============ Trade 成交數據結構 ============
+
+// Trade 公共成交數據（逐筆成交）
+// 文檔: https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-trades-channel
+type Trade struct {
+	InstID  string `json:"instId"`  // 產品ID，如 BTC-USDT
+	TradeID string `json:"tradeId"` // 成交ID
+	Px      string `json:"px"`      // 成交價格
+	Sz      string `json:"sz"`      // 成交數量
+	Side    string `json:"side"`    // 吃單方向: buy, sell
+	Ts      string `json:"ts"`      // 成交時間（毫秒時間戳）
+	Count   string `json:"count"`   // 聚合的成交筆數
+}
+
+// GetTimestamp 將 ts 字符串轉換為 time.Time
+func (t *Trade) GetTimestamp() (time.Time, error) {
+	var ts int64
+	_, err := fmt.Sscanf(t.Ts, "%d", &ts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse timestamp: %w", err)
+	}
+	return time.UnixMilli(ts), nil
+}
+
+// IsBuy 返回是否為主動買入成交
+func (t *Trade) IsBuy() bool {
+	return t.Side == "buy"
+}
+
 // ============ 輔助函數 ============
 
 // NewSubscribeRequest 創建訂閱請求
@@ -161,6 +190,11 @@ func NewCandleSubscribeRequest(instID, bar string) SubscribeRequest {
 	}
 }
 
+// NewTradeSubscribeRequest 創建成交訂閱請求（trades 頻道，使用 Public URL）
+func NewTradeSubscribeRequest(instID string) SubscribeRequest {
+	return NewSubscribeRequest("trades", instID)
+}
+
 // NewUnsubscribeRequest 創建取消訂閱請求
 func NewUnsubscribeRequest(channel, instID string) SubscribeRequest {
 	return SubscribeRequest{
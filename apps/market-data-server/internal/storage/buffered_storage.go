@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/rediskeys"
+	"dizzycoder.xyz/market-data-service/internal/okx"
+)
+
+// BufferedStorageConfig 重試緩衝配置
+type BufferedStorageConfig struct {
+	MaxPending     int           // 緩衝區最大待重試寫入數（超過時丟棄最舊的）
+	InitialBackoff time.Duration // 首次重試間隔
+	MaxBackoff     time.Duration // 最大重試間隔（指數退避上限）
+}
+
+// DefaultBufferedStorageConfig 默認重試緩衝配置
+func DefaultBufferedStorageConfig() BufferedStorageConfig {
+	return BufferedStorageConfig{
+		MaxPending:     1000,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// pendingWrite 待重試的寫入操作
+type pendingWrite struct {
+	seq   uint64 // 入隊序號
+	key   string // 去重 key（最新值類寫入只保留最後一次；空字符串表示不去重；不含命名空間，只在緩衝區內使用）
+	write func(ctx context.Context) error
+}
+
+// BufferedStorage 帶重試緩衝的存儲裝飾器（實現 MarketDataStorage 接口）
+//
+// 設計目的：
+// - Redis 短暫不可用時，寫入失敗不再直接丟失
+// - 失敗的 Set/LPush 寫入進入有界緩衝區，按指數退避重試
+// - 緩衝區滿時丟棄最舊的寫入並計數告警
+//
+// 寫入順序：
+// - 緩衝區非空時，新的寫入排在隊尾（保證歷史列表的 LPush 順序）
+// - 最新價格 / 最新 K 線按 key 去重，只保留最後一次（保持緩存新鮮）
+//
+// Pub/Sub 推送和 Cleanup 不緩衝，直接透傳
+type BufferedStorage struct {
+	next   MarketDataStorage
+	config BufferedStorageConfig
+	logger logger.Logger
+
+	mu      sync.Mutex
+	pending []pendingWrite
+	nextSeq uint64
+	dropped int64 // 因緩衝區滿被丟棄的寫入數
+}
+
+// NewBufferedStorage 創建帶重試緩衝的存儲裝飾器
+func NewBufferedStorage(next MarketDataStorage, config BufferedStorageConfig, logger logger.Logger) *BufferedStorage {
+	defaults := DefaultBufferedStorageConfig()
+	if config.MaxPending <= 0 {
+		config.MaxPending = defaults.MaxPending
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+
+	return &BufferedStorage{
+		next:    next,
+		config:  config,
+		logger:  logger,
+		pending: make([]pendingWrite, 0),
+	}
+}
+
+// SaveLatestPrice 保存最新價格（失敗時緩衝重試）
+func (s *BufferedStorage) SaveLatestPrice(ctx context.Context, ticker okx.Ticker) error {
+	key := rediskeys.Default.TickerLatest(ticker.InstID)
+	return s.write(ctx, key, func(ctx context.Context) error {
+		return s.next.SaveLatestPrice(ctx, ticker)
+	})
+}
+
+// SaveLatestCandle 保存最新 K 線（失敗時緩衝重試）
+func (s *BufferedStorage) SaveLatestCandle(ctx context.Context, candle okx.Candle) error {
+	key := rediskeys.Default.CandleLatest(candle.Bar, candle.InstID)
+	return s.write(ctx, key, func(ctx context.Context) error {
+		return s.next.SaveLatestCandle(ctx, candle)
+	})
+}
+
+// AppendCandleHistory 追加 K 線歷史（失敗時緩衝重試，不去重）
+func (s *BufferedStorage) AppendCandleHistory(ctx context.Context, candle okx.Candle, maxLength int) error {
+	return s.write(ctx, "", func(ctx context.Context) error {
+		return s.next.AppendCandleHistory(ctx, candle, maxLength)
+	})
+}
+
+// AppendRecentTrade 追加最近成交（失敗時緩衝重試，不去重）
+func (s *BufferedStorage) AppendRecentTrade(ctx context.Context, trade okx.Trade, maxLength int) error {
+	return s.write(ctx, "", func(ctx context.Context) error {
+		return s.next.AppendRecentTrade(ctx, trade, maxLength)
+	})
+}
+
+// PublishPrice 推送價格（不緩衝，過時的推送沒有意義）
+func (s *BufferedStorage) PublishPrice(ctx context.Context, ticker okx.Ticker) error {
+	return s.next.PublishPrice(ctx, ticker)
+}
+
+// PublishCandle 推送 K 線（不緩衝）
+func (s *BufferedStorage) PublishCandle(ctx context.Context, candle okx.Candle) error {
+	return s.next.PublishCandle(ctx, candle)
+}
+
+// Cleanup 清理所有市場數據（同時清空緩衝區，避免關機後重新寫入）
+func (s *BufferedStorage) Cleanup(ctx context.Context) error {
+	s.mu.Lock()
+	s.pending = s.pending[:0]
+	s.mu.Unlock()
+
+	return s.next.Cleanup(ctx)
+}
+
+// write 執行寫入，失敗時加入緩衝區
+//
+// 返回：寫入成功或已緩衝時返回 nil（數據不會丟失）
+func (s *BufferedStorage) write(ctx context.Context, key string, write func(ctx context.Context) error) error {
+	s.mu.Lock()
+	hasPending := len(s.pending) > 0
+	s.mu.Unlock()
+
+	// 緩衝區非空時直接排隊，保證寫入順序
+	if !hasPending {
+		err := write(ctx)
+		if err == nil {
+			return nil
+		}
+		s.logger.Warn("Storage write failed, buffering for retry", map[string]any{
+			"error": err,
+			"key":   key,
+		})
+	}
+
+	s.enqueue(pendingWrite{key: key, write: write})
+	return nil
+}
+
+// enqueue 加入緩衝區（按 key 去重，超過上限時丟棄最舊的）
+func (s *BufferedStorage) enqueue(pw pendingWrite) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pw.key != "" {
+		for i, existing := range s.pending {
+			if existing.key == pw.key {
+				s.pending = append(s.pending[:i], s.pending[i+1:]...)
+				break
+			}
+		}
+	}
+
+	s.nextSeq++
+	pw.seq = s.nextSeq
+	s.pending = append(s.pending, pw)
+
+	if overflow := len(s.pending) - s.config.MaxPending; overflow > 0 {
+		s.pending = s.pending[overflow:]
+		s.dropped += int64(overflow)
+		s.logger.Warn("Storage retry buffer full, dropped oldest writes", map[string]any{
+			"dropped":      overflow,
+			"totalDropped": s.dropped,
+			"maxPending":   s.config.MaxPending,
+		})
+	}
+}
+
+// Flush 按順序重試緩衝區中的寫入，遇到第一個失敗即停止
+//
+// 返回：
+//   - int: 成功寫入的數量
+//   - error: 第一個失敗的錯誤（nil 表示緩衝區已清空）
+func (s *BufferedStorage) Flush(ctx context.Context) (int, error) {
+	flushed := 0
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return flushed, nil
+		}
+		pw := s.pending[0]
+		s.mu.Unlock()
+
+		if err := pw.write(ctx); err != nil {
+			return flushed, err
+		}
+
+		s.mu.Lock()
+		// 寫入期間可能被去重或丟棄，只移除仍在隊首的同一項
+		if len(s.pending) > 0 && s.pending[0].seq == pw.seq {
+			s.pending = s.pending[1:]
+		}
+		s.mu.Unlock()
+		flushed++
+	}
+}
+
+// Run 在背景按指數退避重試緩衝區，直到 ctx 取消
+func (s *BufferedStorage) Run(ctx context.Context) {
+	backoff := s.config.InitialBackoff
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if s.Pending() > 0 {
+			flushCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			flushed, err := s.Flush(flushCtx)
+			cancel()
+
+			if err != nil {
+				backoff *= 2
+				if backoff > s.config.MaxBackoff {
+					backoff = s.config.MaxBackoff
+				}
+				s.logger.Warn("Storage retry failed", map[string]any{
+					"error":     err,
+					"flushed":   flushed,
+					"pending":   s.Pending(),
+					"nextRetry": backoff.String(),
+				})
+			} else {
+				backoff = s.config.InitialBackoff
+				if flushed > 0 {
+					s.logger.Info("Storage recovered, flushed buffered writes", map[string]any{
+						"flushed": flushed,
+					})
+				}
+			}
+		}
+
+		timer.Reset(backoff)
+	}
+}
+
+// Pending 返回緩衝區中待重試的寫入數
+func (s *BufferedStorage) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Dropped 返回因緩衝區滿被丟棄的寫入數
+func (s *BufferedStorage) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
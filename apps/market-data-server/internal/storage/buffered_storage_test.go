@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dizzycode.xyz/logger"
+	"dizzycoder.xyz/market-data-service/internal/okx"
+)
+
+// flakyStorage 模擬暫時不可用的 Redis：down 為 true 時所有寫入失敗
+type flakyStorage struct {
+	down    bool
+	prices  map[string]string // instId -> last
+	history []string          // 按寫入順序記錄的 K 線時間戳
+}
+
+func newFlakyStorage() *flakyStorage {
+	return &flakyStorage{prices: make(map[string]string)}
+}
+
+var errRedisDown = errors.New("redis: connection refused")
+
+func (f *flakyStorage) SaveLatestPrice(ctx context.Context, ticker okx.Ticker) error {
+	if f.down {
+		return errRedisDown
+	}
+	f.prices[ticker.InstID] = ticker.Last
+	return nil
+}
+
+func (f *flakyStorage) SaveLatestCandle(ctx context.Context, candle okx.Candle) error {
+	if f.down {
+		return errRedisDown
+	}
+	return nil
+}
+
+func (f *flakyStorage) AppendCandleHistory(ctx context.Context, candle okx.Candle, maxLength int) error {
+	if f.down {
+		return errRedisDown
+	}
+	f.history = append(f.history, candle.Ts)
+	return nil
+}
+
+func (f *flakyStorage) AppendRecentTrade(ctx context.Context, trade okx.Trade, maxLength int) error {
+	if f.down {
+		return errRedisDown
+	}
+	return nil
+}
+
+func (f *flakyStorage) PublishPrice(ctx context.Context, ticker okx.Ticker) error  { return nil }
+func (f *flakyStorage) PublishCandle(ctx context.Context, candle okx.Candle) error { return nil }
+func (f *flakyStorage) Cleanup(ctx context.Context) error                          { return nil }
+
+// TestBufferedStorage_FlushesOnRecovery 測試 Redis 恢復後緩衝的寫入按順序補寫
+func TestBufferedStorage_FlushesOnRecovery(t *testing.T) {
+	ctx := context.Background()
+	backend := newFlakyStorage()
+	buffered := NewBufferedStorage(backend, BufferedStorageConfig{MaxPending: 10}, logger.NewMulti())
+
+	// Redis 故障期間寫入
+	backend.down = true
+	for _, ts := range []string{"1000", "2000", "3000"} {
+		if err := buffered.AppendCandleHistory(ctx, okx.Candle{Ts: ts, InstID: "ETH-USDT", Bar: "5m"}, 200); err != nil {
+			t.Fatalf("Expected buffered write to return nil, got %v", err)
+		}
+	}
+	buffered.SaveLatestPrice(ctx, okx.Ticker{InstID: "ETH-USDT", Last: "2500"})
+	buffered.SaveLatestPrice(ctx, okx.Ticker{InstID: "ETH-USDT", Last: "2501"}) // 同 key 去重
+
+	if got := buffered.Pending(); got != 4 {
+		t.Fatalf("Expected 4 pending writes (3 history + 1 deduped price), got %d", got)
+	}
+
+	// 仍故障時重試失敗，緩衝保留
+	if _, err := buffered.Flush(ctx); err == nil {
+		t.Fatal("Expected flush to fail while redis is down")
+	}
+	if got := buffered.Pending(); got != 4 {
+		t.Fatalf("Expected pending writes to be kept, got %d", got)
+	}
+
+	// Redis 恢復
+	backend.down = false
+	flushed, err := buffered.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush failed after recovery: %v", err)
+	}
+	if flushed != 4 || buffered.Pending() != 0 {
+		t.Errorf("Expected 4 flushed and none pending, got flushed=%d pending=%d", flushed, buffered.Pending())
+	}
+
+	// 歷史按原順序寫入，最新價格為最後一次
+	want := []string{"1000", "2000", "3000"}
+	if len(backend.history) != len(want) {
+		t.Fatalf("Expected history %v, got %v", want, backend.history)
+	}
+	for i := range want {
+		if backend.history[i] != want[i] {
+			t.Errorf("Expected history %v, got %v", want, backend.history)
+			break
+		}
+	}
+	if backend.prices["ETH-USDT"] != "2501" {
+		t.Errorf("Expected latest price 2501, got %s", backend.prices["ETH-USDT"])
+	}
+
+	// 恢復後直接寫入
+	buffered.SaveLatestPrice(ctx, okx.Ticker{InstID: "ETH-USDT", Last: "2502"})
+	if backend.prices["ETH-USDT"] != "2502" || buffered.Pending() != 0 {
+		t.Errorf("Expected direct write after recovery")
+	}
+}
+
+// TestBufferedStorage_DropsOldestWhenFull 測試緩衝區滿時丟棄最舊的寫入並計數
+func TestBufferedStorage_DropsOldestWhenFull(t *testing.T) {
+	ctx := context.Background()
+	backend := newFlakyStorage()
+	buffered := NewBufferedStorage(backend, BufferedStorageConfig{MaxPending: 2}, logger.NewMulti())
+
+	backend.down = true
+	for _, ts := range []string{"1000", "2000", "3000", "4000"} {
+		buffered.AppendCandleHistory(ctx, okx.Candle{Ts: ts}, 200)
+	}
+
+	if buffered.Pending() != 2 {
+		t.Errorf("Expected 2 pending writes, got %d", buffered.Pending())
+	}
+	if buffered.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped writes, got %d", buffered.Dropped())
+	}
+
+	backend.down = false
+	if _, err := buffered.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(backend.history) != 2 || backend.history[0] != "3000" || backend.history[1] != "4000" {
+		t.Errorf("Expected newest writes [3000 4000] to survive, got %v", backend.history)
+	}
+}
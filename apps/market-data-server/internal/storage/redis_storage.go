@@ -9,26 +9,58 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/rediskeys"
 	"dizzycoder.xyz/market-data-service/internal/okx"
 )
 
+// CandleTTLConfig 最新 K 線（candle.latest）的 TTL 配置 ⭐
+//
+// 零值沿用 calculateCandleTTL 的默認 TTL。讀取長歷史的策略在清淡行情下可能讀不到最新 K 線，
+// 可以整體放大 TTL，或按週期單獨指定
+type CandleTTLConfig struct {
+	Multiplier float64                  // 默認 TTL 的倍數（<= 0 = 1）
+	Overrides  map[string]time.Duration // 按週期指定 TTL（例: "5m" → 30m），優先於 Multiplier
+}
+
+// TTL 返回指定週期的最新 K 線 TTL
+func (c CandleTTLConfig) TTL(bar string) time.Duration {
+	if ttl, ok := c.Overrides[bar]; ok && ttl > 0 {
+		return ttl
+	}
+	ttl := calculateCandleTTL(bar)
+	if c.Multiplier > 0 {
+		ttl = time.Duration(float64(ttl) * c.Multiplier)
+	}
+	return ttl
+}
+
 // RedisStorage Redis 存儲實現（實現 MarketDataStorage 接口）
 type RedisStorage struct {
-	client *redis.Client
-	logger logger.Logger
+	client    *redis.Client
+	keys      rediskeys.Keys  // key 格式（含命名空間，與策略服務共用）⭐
+	candleTTL CandleTTLConfig // 最新 K 線的 TTL 配置 ⭐
+	logger    logger.Logger
 }
 
 // NewRedisStorage 創建 Redis 存儲實例
-func NewRedisStorage(client *redis.Client, logger logger.Logger) *RedisStorage {
+//
+// keys 決定寫入的 key 和頻道名稱，命名空間必須與讀取方（策略服務）一致
+func NewRedisStorage(client *redis.Client, keys rediskeys.Keys, logger logger.Logger) *RedisStorage {
 	return &RedisStorage{
 		client: client,
+		keys:   keys,
 		logger: logger,
 	}
 }
 
+// SetCandleTTLConfig 設置最新 K 線的 TTL 配置（零值 = 默認 TTL）
+func (s *RedisStorage) SetCandleTTLConfig(config CandleTTLConfig) {
+	s.candleTTL = config
+}
+
 // SaveLatestPrice 保存最新價格到 Redis
 func (s *RedisStorage) SaveLatestPrice(ctx context.Context, ticker okx.Ticker) error {
-	key := fmt.Sprintf(KeyPatternTickerLatest, ticker.InstID)
+	key := s.keys.TickerLatest(ticker.InstID)
 
 	// 序列化為 JSON
 	data, err := json.Marshal(ticker)
@@ -50,7 +82,7 @@ func (s *RedisStorage) SaveLatestPrice(ctx context.Context, ticker okx.Ticker) e
 
 // SaveLatestCandle 保存最新 K 線到 Redis
 func (s *RedisStorage) SaveLatestCandle(ctx context.Context, candle okx.Candle) error {
-	key := fmt.Sprintf(KeyPatternCandleLatest, candle.Bar, candle.InstID)
+	key := s.keys.CandleLatest(candle.Bar, candle.InstID)
 
 	// 序列化為 JSON
 	data, err := json.Marshal(candle)
@@ -58,8 +90,8 @@ func (s *RedisStorage) SaveLatestCandle(ctx context.Context, candle okx.Candle)
 		return fmt.Errorf("failed to marshal candle: %w", err)
 	}
 
-	// 根據 bar 計算 TTL
-	ttl := calculateCandleTTL(candle.Bar)
+	// 根據 bar 計算 TTL（可按配置放大或覆蓋）
+	ttl := s.candleTTL.TTL(candle.Bar)
 
 	// 寫入 Redis 並設置 TTL
 	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
@@ -76,7 +108,7 @@ func (s *RedisStorage) SaveLatestCandle(ctx context.Context, candle okx.Candle)
 
 // AppendCandleHistory 追加 K 線到歷史列表
 func (s *RedisStorage) AppendCandleHistory(ctx context.Context, candle okx.Candle, maxLength int) error {
-	key := fmt.Sprintf(KeyPatternCandleHistory, candle.Bar, candle.InstID)
+	key := s.keys.CandleHistory(candle.Bar, candle.InstID)
 
 	// 序列化為 JSON
 	data, err := json.Marshal(candle)
@@ -112,6 +144,37 @@ func (s *RedisStorage) AppendCandleHistory(ctx context.Context, candle okx.Candl
 	return nil
 }
 
+// AppendRecentTrade 追加成交到最近成交列表
+func (s *RedisStorage) AppendRecentTrade(ctx context.Context, trade okx.Trade, maxLength int) error {
+	key := s.keys.TradesRecent(trade.InstID)
+
+	// 序列化為 JSON
+	data, err := json.Marshal(trade)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trade: %w", err)
+	}
+
+	// 使用 Pipeline 提高性能
+	pipe := s.client.Pipeline()
+
+	// 1. 將新成交推入列表頭部（最新的在前）
+	pipe.LPush(ctx, key, data)
+
+	// 2. 只保留最近 maxLength 筆成交
+	pipe.LTrim(ctx, key, 0, int64(maxLength-1))
+
+	// 執行 Pipeline
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Error("Failed to append recent trade",
+			"error", err,
+			"key", key,
+			"instId", trade.InstID)
+		return fmt.Errorf("failed to append recent trade: %w", err)
+	}
+
+	return nil
+}
+
 // ========== Pub/Sub 推送（Push 模式，保留接口）==========
 
 // PublishPrice 推送價格到 Pub/Sub 頻道
@@ -119,7 +182,7 @@ func (s *RedisStorage) AppendCandleHistory(ctx context.Context, candle okx.Candl
 // channel 格式: market.ticker.{instId}
 // 目前未啟用，保留接口供未來使用
 func (s *RedisStorage) PublishPrice(ctx context.Context, ticker okx.Ticker) error {
-	channel := fmt.Sprintf(ChannelPatternTicker, ticker.InstID)
+	channel := s.keys.TickerChannel(ticker.InstID)
 
 	// 序列化為 JSON
 	data, err := json.Marshal(ticker)
@@ -149,7 +212,7 @@ func (s *RedisStorage) PublishPrice(ctx context.Context, ticker okx.Ticker) erro
 // channel 格式: market.candle.{bar}.{instId}
 // 目前未啟用，保留接口供未來使用
 func (s *RedisStorage) PublishCandle(ctx context.Context, candle okx.Candle) error {
-	channel := fmt.Sprintf(ChannelPatternCandle, candle.Bar, candle.InstID)
+	channel := s.keys.CandleChannel(candle.Bar, candle.InstID)
 
 	// 序列化為 JSON
 	data, err := json.Marshal(candle)
@@ -178,14 +241,15 @@ func (s *RedisStorage) PublishCandle(ctx context.Context, candle okx.Candle) err
 
 // Cleanup 清理所有市場數據（關機時調用）
 //
-// 清理以下 key pattern：
-// - price:latest:*       (Ticker 數據)
-// - candle:latest:*      (最新 K 線)
-// - candle:history:*     (歷史 K 線)
+// 清理以下 key pattern（只清理本命名空間，不影響共用 Redis 的其他環境）：
+// - price.latest.*       (Ticker 數據)
+// - candle.latest.*      (最新 K 線)
+// - candle.history.*     (歷史 K 線)
+// - trades.recent.*      (最近成交)
 //
 // 防止策略服務讀到過時的價格數據
 func (s *RedisStorage) Cleanup(ctx context.Context) error {
-	patterns := CleanupPatterns()
+	patterns := s.keys.CleanupPatterns()
 
 	var deletedCount int64
 
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/rediskeys"
+	"dizzycoder.xyz/market-data-service/internal/okx"
+)
+
+// keyRecorder 攔截 Redis 命令並記錄寫入的 key / 頻道（不連接 Redis，所有命令直接成功）
+type keyRecorder struct {
+	keys []string
+}
+
+func (h *keyRecorder) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *keyRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.keys = append(h.keys, fmt.Sprint(cmd.Args()[1]))
+		return nil
+	}
+}
+
+func (h *keyRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.keys = append(h.keys, fmt.Sprint(cmd.Args()[1]))
+		}
+		return nil
+	}
+}
+
+func newRecordingStorage(t *testing.T, namespace string) (*RedisStorage, *keyRecorder) {
+	t.Helper()
+	keys, err := rediskeys.New(namespace)
+	if err != nil {
+		t.Fatalf("Failed to create keys: %v", err)
+	}
+	recorder := &keyRecorder{}
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	client.AddHook(recorder)
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStorage(client, keys, logger.NewMulti()), recorder
+}
+
+// TestRedisStorage_NamespacedKeys 測試寫入的 key 帶命名空間，且與策略服務讀取的格式一致 ⭐
+//
+// trading-strategy-server 的 MarketDataReader 測試斷言相同的 key 字面值
+func TestRedisStorage_NamespacedKeys(t *testing.T) {
+	storage, recorder := newRecordingStorage(t, "staging")
+	ctx := context.Background()
+	candle := okx.Candle{InstID: "ETH-USDT", Bar: "5m", Confirm: "1"}
+
+	if err := storage.SaveLatestPrice(ctx, okx.Ticker{InstID: "ETH-USDT", Last: "2500"}); err != nil {
+		t.Fatalf("SaveLatestPrice failed: %v", err)
+	}
+	if err := storage.SaveLatestCandle(ctx, candle); err != nil {
+		t.Fatalf("SaveLatestCandle failed: %v", err)
+	}
+	if err := storage.AppendCandleHistory(ctx, candle, 100); err != nil {
+		t.Fatalf("AppendCandleHistory failed: %v", err)
+	}
+	if err := storage.PublishCandle(ctx, candle); err != nil {
+		t.Fatalf("PublishCandle failed: %v", err)
+	}
+
+	want := []string{
+		"staging.price.latest.ETH-USDT",
+		"staging.candle.latest.5m.ETH-USDT",
+		"staging.candle.history.5m.ETH-USDT", // LPUSH
+		"staging.candle.history.5m.ETH-USDT", // LTRIM
+		"staging.market.candle.5m.ETH-USDT",
+	}
+	if !reflect.DeepEqual(recorder.keys, want) {
+		t.Errorf("Expected writer keys %v, got %v", want, recorder.keys)
+	}
+}
+
+// TestRedisStorage_DefaultKeysUnchanged 測試未設置命名空間時沿用原有的 key 格式
+func TestRedisStorage_DefaultKeysUnchanged(t *testing.T) {
+	storage, recorder := newRecordingStorage(t, "")
+
+	if err := storage.SaveLatestPrice(context.Background(), okx.Ticker{InstID: "BTC-USDT", Last: "60000"}); err != nil {
+		t.Fatalf("SaveLatestPrice failed: %v", err)
+	}
+	if len(recorder.keys) != 1 || recorder.keys[0] != "price.latest.BTC-USDT" {
+		t.Errorf("Expected price.latest.BTC-USDT, got %v", recorder.keys)
+	}
+}
+
+// TestCandleTTLConfig 測試倍數和按週期覆蓋改變 TTL，未配置的週期沿用默認值
+func TestCandleTTLConfig(t *testing.T) {
+	var defaults CandleTTLConfig
+	if got := defaults.TTL("5m"); got != 600*time.Second {
+		t.Errorf("Expected default 5m TTL 600s, got %s", got)
+	}
+
+	config := CandleTTLConfig{
+		Multiplier: 3,
+		Overrides:  map[string]time.Duration{"1H": 6 * time.Hour},
+	}
+	tests := map[string]time.Duration{
+		"5m": 1800 * time.Second, // 600s × 3
+		"1m": 360 * time.Second,  // 120s × 3
+		"1H": 6 * time.Hour,      // 覆蓋優先於倍數
+	}
+	for bar, want := range tests {
+		if got := config.TTL(bar); got != want {
+			t.Errorf("bar %s: expected TTL %s, got %s", bar, want, got)
+		}
+	}
+
+	// 只覆蓋部分週期時，其他週期保持默認
+	overrideOnly := CandleTTLConfig{Overrides: map[string]time.Duration{"5m": 30 * time.Minute}}
+	if got := overrideOnly.TTL("5m"); got != 30*time.Minute {
+		t.Errorf("Expected overridden 5m TTL 30m, got %s", got)
+	}
+	if got := overrideOnly.TTL("15m"); got != calculateCandleTTL("15m") {
+		t.Errorf("Expected default 15m TTL %s, got %s", calculateCandleTTL("15m"), got)
+	}
+}
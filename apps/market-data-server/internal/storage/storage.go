@@ -26,6 +26,11 @@ type MarketDataStorage interface {
 	// maxLength: 保留的最大 K 線數量
 	AppendCandleHistory(ctx context.Context, candle okx.Candle, maxLength int) error
 
+	// AppendRecentTrade 追加成交到最近成交列表
+	// key 格式: trades.recent.{instId}
+	// maxLength: 保留的最大成交筆數
+	AppendRecentTrade(ctx context.Context, trade okx.Trade, maxLength int) error
+
 	// ========== Pub/Sub 推送（Push 模式，保留接口）==========
 
 	// PublishPrice 推送價格到 Pub/Sub 頻道（可選，目前未啟用）
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"encoding/json"
+	"time"
 
 	"dizzycode.xyz/logger"
 	ws "dizzycode.xyz/websocket"
@@ -14,19 +15,36 @@ type TickerHandler func(ticker okx.Ticker) error
 // CandleHandler 處理 Candle K線數據的回調
 type CandleHandler func(candle okx.Candle) error
 
+// TradeHandler 處理 Trade 成交數據的回調
+type TradeHandler func(trade okx.Trade) error
+
 // Manager WebSocket 管理器，封裝業務邏輯
 type Manager struct {
 	client         *ws.Client
 	logger         logger.Logger
 	tickerHandlers []TickerHandler
 	candleHandlers []CandleHandler
+	tradeHandlers  []TradeHandler
 	subscriptions  map[string]bool // 記錄已訂閱的交易對
+	rttWarn        time.Duration   // ping-pong 往返延遲超過此值時記錄警告
 }
 
+// DefaultRTTWarnThreshold 默認的往返延遲警告門檻
+const DefaultRTTWarnThreshold = time.Second
+
 // Config 管理器配置
 type Config struct {
 	URL    string
 	Logger logger.Logger
+
+	// 心跳和超時（0 = 使用 WebSocket client 默認值 20s / 30s / 10s）
+	// OKX 30 秒內沒有任何消息會斷開連接，PongWait 不應超過 30s，PingInterval 應小於 PongWait
+	PingInterval time.Duration
+	PongWait     time.Duration
+	WriteWait    time.Duration
+
+	// ping-pong 往返延遲超過此值時記錄警告，連接斷開前提早發現網絡變差（0 = DefaultRTTWarnThreshold）
+	RTTWarnThreshold time.Duration
 }
 
 // NewManager 創建新的 WebSocket 管理器
@@ -34,19 +52,30 @@ func NewManager(config Config) *Manager {
 	// 直接傳入 logger，不需要 adapter！
 	// WebSocket client 會自動 fallback 到 console 如果 logger 是 nil
 	wsClient := ws.NewClient(ws.Config{
-		URL: config.URL,
+		URL:          config.URL,
+		PingInterval: config.PingInterval,
+		PongWait:     config.PongWait,
+		WriteWait:    config.WriteWait,
 	}, config.Logger)
 
+	rttWarn := config.RTTWarnThreshold
+	if rttWarn == 0 {
+		rttWarn = DefaultRTTWarnThreshold
+	}
+
 	manager := &Manager{
 		client:         wsClient,
 		logger:         config.Logger,
 		tickerHandlers: make([]TickerHandler, 0),
 		candleHandlers: make([]CandleHandler, 0),
+		tradeHandlers:  make([]TradeHandler, 0),
 		subscriptions:  make(map[string]bool),
+		rttWarn:        rttWarn,
 	}
 
 	// 設置消息處理器
 	wsClient.SetMessageHandler(manager.handleMessage)
+	wsClient.SetRTTHandler(manager.handleRTT)
 
 	return manager
 }
@@ -61,6 +90,11 @@ func (m *Manager) AddCandleHandler(handler CandleHandler) {
 	m.candleHandlers = append(m.candleHandlers, handler)
 }
 
+// AddTradeHandler 添加 Trade 成交數據處理器
+func (m *Manager) AddTradeHandler(handler TradeHandler) {
+	m.tradeHandlers = append(m.tradeHandlers, handler)
+}
+
 // Connect 連接到 OKX WebSocket
 func (m *Manager) Connect() error {
 	return m.client.Connect()
@@ -125,6 +159,21 @@ func (m *Manager) UnsubscribeCandle(instID, bar string) error {
 	return nil
 }
 
+// SubscribeTrades 訂閱 Trades 成交頻道
+func (m *Manager) SubscribeTrades(instID string) error {
+	req := okx.NewTradeSubscribeRequest(instID)
+
+	if err := m.client.SendJSON(req); err != nil {
+		return err
+	}
+
+	key := "trades:" + instID
+	m.subscriptions[key] = true
+	m.logger.Info("Subscribed to trades", "instId", instID)
+
+	return nil
+}
+
 // handleMessage 處理接收到的 WebSocket 消息
 func (m *Manager) handleMessage(messageType int, data []byte) error {
 	// Debug: 打印所有原始消息（只在 LOG_LEVEL=debug 時顯示）
@@ -207,6 +256,32 @@ func (m *Manager) handleMessage(messageType int, data []byte) error {
 			}
 		}
 
+		// 處理 Trade 成交數據
+		if channel == "trades" {
+			var trades []okx.Trade
+			if err := json.Unmarshal(baseResp.Data, &trades); err != nil {
+				m.logger.Error("Failed to unmarshal trade data", "error", err)
+				return err
+			}
+
+			for _, trade := range trades {
+				// 成交數據量大，只在 debug 級別打印
+				m.logger.Debug("Received trade",
+					"instId", trade.InstID,
+					"side", trade.Side,
+					"px", trade.Px,
+					"sz", trade.Sz)
+
+				for _, handler := range m.tradeHandlers {
+					if err := handler(trade); err != nil {
+						m.logger.Error("Trade handler error",
+							"error", err,
+							"instId", trade.InstID)
+					}
+				}
+			}
+		}
+
 		// 處理 Candle 數據（channel 格式: candle1m, candle5m, etc）
 		if len(channel) > 6 && channel[:6] == "candle" {
 			// OKX 返回的 Candle 數據是數組格式: [[ts, o, h, l, c, vol, volCcy, volCcyQuote, confirm], ...]
@@ -251,6 +326,18 @@ func (m *Manager) handleMessage(messageType int, data []byte) error {
 	return nil
 }
 
+// handleRTT 往返延遲超過門檻時記錄警告
+func (m *Manager) handleRTT(rtt, avg time.Duration) {
+	if rtt >= m.rttWarn {
+		m.logger.Warn("WebSocket ping RTT spike", "rtt", rtt.String(), "avgRtt", avg.String(), "threshold", m.rttWarn.String())
+	}
+}
+
+// PingRTT 返回最近一次和移動平均的 ping-pong 往返延遲（尚未收到 pong 時為 0）
+func (m *Manager) PingRTT() (last, avg time.Duration) {
+	return m.client.LastPingRTT(), m.client.AvgPingRTT()
+}
+
 // Close 關閉 WebSocket 連接
 func (m *Manager) Close() error {
 	return m.client.Close()
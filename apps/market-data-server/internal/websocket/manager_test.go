@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	ws "dizzycode.xyz/websocket"
+	"dizzycoder.xyz/market-data-service/internal/okx"
+)
+
+// TestManager_HandleMessage_Trades 測試解析 OKX trades 頻道推送
+func TestManager_HandleMessage_Trades(t *testing.T) {
+	manager := NewManager(Config{
+		URL:    okx.PublicWSURL,
+		Logger: logger.NewMulti(),
+	})
+
+	var received []okx.Trade
+	manager.AddTradeHandler(func(trade okx.Trade) error {
+		received = append(received, trade)
+		return nil
+	})
+
+	payload := `{
+		"arg": {"channel": "trades", "instId": "BTC-USDT"},
+		"data": [
+			{"instId": "BTC-USDT", "tradeId": "130639474", "px": "42219.9", "sz": "0.12060306", "side": "buy", "ts": "1630048897897", "count": "3"},
+			{"instId": "BTC-USDT", "tradeId": "130639475", "px": "42219.8", "sz": "0.5", "side": "sell", "ts": "1630048897898", "count": "1"}
+		]
+	}`
+
+	if err := manager.handleMessage(1, []byte(payload)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 trades, got %d", len(received))
+	}
+
+	first := received[0]
+	if first.Side != "buy" || !first.IsBuy() {
+		t.Errorf("Expected side buy, got %s", first.Side)
+	}
+	if first.Px != "42219.9" {
+		t.Errorf("Expected px 42219.9, got %s", first.Px)
+	}
+	if first.Sz != "0.12060306" {
+		t.Errorf("Expected sz 0.12060306, got %s", first.Sz)
+	}
+	if first.TradeID != "130639474" || first.InstID != "BTC-USDT" {
+		t.Errorf("Unexpected trade identity: %+v", first)
+	}
+	if ts, err := first.GetTimestamp(); err != nil || ts.UnixMilli() != 1630048897897 {
+		t.Errorf("Expected ts 1630048897897, got %v (err: %v)", ts, err)
+	}
+
+	if received[1].Side != "sell" || received[1].IsBuy() {
+		t.Errorf("Expected second trade side sell, got %s", received[1].Side)
+	}
+}
+
+// TestNewTradeSubscribeRequest 測試成交訂閱請求格式
+func TestNewTradeSubscribeRequest(t *testing.T) {
+	req := okx.NewTradeSubscribeRequest("ETH-USDT-SWAP")
+
+	if req.Op != "subscribe" || len(req.Args) != 1 {
+		t.Fatalf("Unexpected request: %+v", req)
+	}
+	if req.Args[0].Channel != "trades" || req.Args[0].InstID != "ETH-USDT-SWAP" {
+		t.Errorf("Unexpected args: %+v", req.Args[0])
+	}
+}
+
+// TestNewManager_ConnectionTuning 測試自定義心跳/超時被傳給 WebSocket client，而不是使用默認值
+func TestNewManager_ConnectionTuning(t *testing.T) {
+	manager := NewManager(Config{
+		URL:          okx.BusinessWSURL,
+		Logger:       logger.NewMulti(),
+		PingInterval: 15 * time.Second,
+		PongWait:     25 * time.Second,
+		WriteWait:    5 * time.Second,
+	})
+
+	got := manager.client.Config()
+	if got.PingInterval != 15*time.Second {
+		t.Errorf("Expected ping interval 15s, got %s", got.PingInterval)
+	}
+	if got.PongWait != 25*time.Second {
+		t.Errorf("Expected pong wait 25s, got %s", got.PongWait)
+	}
+	if got.WriteWait != 5*time.Second {
+		t.Errorf("Expected write wait 5s, got %s", got.WriteWait)
+	}
+
+	// 未設置時回退到默認值
+	defaults := NewManager(Config{URL: okx.PublicWSURL, Logger: logger.NewMulti()}).client.Config()
+	if defaults.PingInterval != ws.DefaultPingInterval || defaults.PongWait != ws.DefaultPongWait ||
+		defaults.WriteWait != ws.DefaultWriteWait {
+		t.Errorf("Expected client defaults, got %+v", defaults)
+	}
+}
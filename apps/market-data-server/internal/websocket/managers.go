@@ -10,6 +10,7 @@ package websocket
 type Managers struct {
 	Ticker *Manager // Ticker 数据管理器（可能为 nil）
 	Candle *Manager // Candle 数据管理器（可能为 nil）
+	Trade  *Manager // Trade 成交数据管理器（Public URL，可能为 nil）
 }
 
 // Close 关闭所有 WebSocket 连接
@@ -28,6 +29,12 @@ func (m *Managers) Close() error {
 		}
 	}
 
+	if m.Trade != nil {
+		if e := m.Trade.Close(); e != nil {
+			err = e
+		}
+	}
+
 	return err
 }
 
@@ -40,4 +47,23 @@ func (m *Managers) Wait() {
 	if m.Candle != nil {
 		m.Candle.Wait()
 	}
+
+	if m.Trade != nil {
+		m.Trade.Wait()
+	}
+}
+
+// IsConnected 所有已啟用的 WebSocket 連接均已連線時返回 true（沒有任何連接時返回 false）
+func (m *Managers) IsConnected() bool {
+	active := 0
+	for _, manager := range []*Manager{m.Ticker, m.Candle, m.Trade} {
+		if manager == nil {
+			continue
+		}
+		if !manager.IsConnected() {
+			return false
+		}
+		active++
+	}
+	return active > 0
 }
@@ -15,11 +15,14 @@ import (
 // 需要創建兩個獨立的 Manager 實例：
 // - Ticker: wss://ws.okx.com:8443/ws/v5/public
 // - Candle: wss://ws.okx.com:8443/ws/v5/business
+//
+// Trades 雖然也使用 Public URL，但成交數據量大，使用獨立連接避免影響 Ticker
 func Setup(
 	cfg *config.Config,
 	log logger.Logger,
 	tickerHandler *handler.TickerHandler, // 注入 Ticker Handler
 	candleHandler *handler.CandleHandler, // 注入 Candle Handler
+	tradeHandler *handler.TradeHandler, // 注入 Trade Handler
 ) (*Managers, error) {
 	managers := &Managers{}
 
@@ -45,6 +48,17 @@ func Setup(
 		managers.Candle = candleManager
 	}
 
+	// 3. 根據配置創建並設置 Trade Manager
+	if cfg.OKX.Subscription.Trades {
+		tradeManager, err := setupTradeManager(cfg, log, tradeHandler)
+		if err != nil {
+			// 如果 Trade Manager 創建失敗，關閉已創建的 Manager
+			managers.Close()
+			return nil, fmt.Errorf("failed to setup trade manager: %w", err)
+		}
+		managers.Trade = tradeManager
+	}
+
 	return managers, nil
 }
 
@@ -56,8 +70,11 @@ func setupTickerManager(
 ) (*Manager, error) {
 	// 1. 創建 WebSocket Manager（使用 Public URL）
 	wsManager := NewManager(Config{
-		URL:    okx.PublicWSURL, // Ticker 使用 Public WebSocket
-		Logger: log,
+		URL:          okx.PublicWSURL, // Ticker 使用 Public WebSocket
+		Logger:       log,
+		PingInterval: cfg.OKX.Ticker.PingInterval,
+		PongWait:     cfg.OKX.Ticker.PongWait,
+		WriteWait:    cfg.OKX.Ticker.WriteWait,
 	})
 
 	// 2. 註冊 Ticker Handler
@@ -91,8 +108,11 @@ func setupCandleManager(
 ) (*Manager, error) {
 	// 1. 創建 WebSocket Manager（使用 Business URL）
 	wsManager := NewManager(Config{
-		URL:    okx.BusinessWSURL, // Candle 使用 Business WebSocket
-		Logger: log,
+		URL:          okx.BusinessWSURL, // Candle 使用 Business WebSocket
+		Logger:       log,
+		PingInterval: cfg.OKX.Candle.PingInterval,
+		PongWait:     cfg.OKX.Candle.PongWait,
+		WriteWait:    cfg.OKX.Candle.WriteWait,
 	})
 
 	// 2. 註冊 Candle Handler
@@ -123,6 +143,44 @@ func setupCandleManager(
 	return wsManager, nil
 }
 
+// setupTradeManager 設置 Trade WebSocket Manager
+func setupTradeManager(
+	cfg *config.Config,
+	log logger.Logger,
+	tradeHandler *handler.TradeHandler,
+) (*Manager, error) {
+	// 1. 創建 WebSocket Manager（使用 Public URL）
+	wsManager := NewManager(Config{
+		URL:          okx.PublicWSURL, // Trades 使用 Public WebSocket
+		Logger:       log,
+		PingInterval: cfg.OKX.Trade.PingInterval,
+		PongWait:     cfg.OKX.Trade.PongWait,
+		WriteWait:    cfg.OKX.Trade.WriteWait,
+	})
+
+	// 2. 註冊 Trade Handler
+	wsManager.AddTradeHandler(tradeHandler.Handle)
+	log.Info("Trade handler registered")
+
+	// 3. 連接到 OKX WebSocket
+	if err := wsManager.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to OKX Public WebSocket: %w", err)
+	}
+
+	// 4. 訂閱 Trades
+	for _, instID := range cfg.OKX.Instruments {
+		if err := wsManager.SubscribeTrades(instID); err != nil {
+			log.Error("Failed to subscribe to trades", map[string]any{
+				"error":  err,
+				"instId": instID,
+			})
+			// 繼續訂閱其他項目，不中斷
+		}
+	}
+
+	return wsManager, nil
+}
+
 // getEnabledCandles 獲取已啟用的 K線週期列表
 func getEnabledCandles(candles map[string]bool) []string {
 	result := []string{}
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -219,3 +221,86 @@ func TestAutoFunding_ResetsOnOpen(t *testing.T) {
 	t.Logf("   Total fundings: %d", len(engine.fundingHistory))
 	t.Logf("   Final balance: %.2f USDT", result.FinalBalance)
 }
+
+// TestAutoFunding_PercentOfNotional 測試按持倉價值比例注資
+// 注資金額 = 觸發時持倉價值 × AutoFundingPercent，隨持倉規模增長
+func TestAutoFunding_PercentOfNotional(t *testing.T) {
+	config := BacktestConfig{
+		InitialBalance:        500.0,
+		FeeRate:               0.0005,
+		InstID:                "ETH-USDT-SWAP",
+		TakeProfitMin:         0.0015,
+		TakeProfitMax:         0.0020,
+		PositionSize:          200.0,
+		BreakEvenProfitMin:    1.0,
+		BreakEvenProfitMax:    20.0,
+		EnableTrendFilter:     false,
+		EnableRedCandleFilter: false,
+		EnableAutoFunding:     true,
+		AutoFundingIdle:       10,
+		AutoFundingMode:       AutoFundingPercentOfNotional,
+		AutoFundingPercent:    0.5, // 持倉價值的 50%
+	}
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create backtest engine: %v", err)
+	}
+
+	// 價格持續下跌，倉位不會止盈，持倉價值只增不減
+	candles := make([]value_objects.Candle, 60)
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 60 {
+		price := 2500.0 - float64(i)*5.0
+		candle, err := value_objects.NewCandle(price, price, price-5.0, price-3.0, baseTime.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle: %v", err)
+		}
+		candles[i] = candle
+	}
+
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	if len(engine.fundingHistory) < 2 {
+		t.Fatalf("Expected at least 2 funding records, got %d", len(engine.fundingHistory))
+	}
+
+	// 每個倉位 $200，注資金額應為 $100 的整數倍，且隨持倉增加而增長
+	for i, record := range engine.fundingHistory {
+		positions := record.Amount / (config.PositionSize * config.AutoFundingPercent)
+		if record.Amount <= 0 || math.Abs(positions-math.Round(positions)) > 1e-9 {
+			t.Errorf("Funding #%d: amount %.4f is not 50%% of whole positions", i+1, record.Amount)
+		}
+		if i > 0 && record.Amount <= engine.fundingHistory[i-1].Amount {
+			t.Errorf("Funding #%d: expected amount to grow with exposure, got %.2f after %.2f",
+				i+1, record.Amount, engine.fundingHistory[i-1].Amount)
+		}
+	}
+}
+
+// TestAutoFunding_InvalidMode 測試無效的注資模式配置
+func TestAutoFunding_InvalidMode(t *testing.T) {
+	base := BacktestConfig{
+		InitialBalance:    10000.0,
+		FeeRate:           0.0005,
+		TakeProfitMin:     0.0015,
+		TakeProfitMax:     0.0020,
+		PositionSize:      200.0,
+		EnableAutoFunding: true,
+		AutoFundingIdle:   10,
+	}
+
+	unknown := base
+	unknown.AutoFundingMode = "unknown"
+	if _, err := NewBacktestEngine(unknown); !errors.Is(err, ErrInvalidConfig) {
+		t.Error("Expected error for unknown auto-funding mode")
+	}
+
+	noPercent := base
+	noPercent.AutoFundingMode = AutoFundingPercentOfNotional
+	if _, err := NewBacktestEngine(noPercent); !errors.Is(err, ErrInvalidConfig) {
+		t.Error("Expected error for percent mode without AutoFundingPercent")
+	}
+}
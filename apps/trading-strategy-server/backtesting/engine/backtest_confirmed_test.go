@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// buildMixedConfirmCandles 構建混合完成狀態的K線：索引 1、3 為未完成且大幅上影（會觸發止盈）
+func buildMixedConfirmCandles(t *testing.T) []value_objects.Candle {
+	t.Helper()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]value_objects.Candle, 0, 5)
+	for i := 0; i < 5; i++ {
+		high := 2501.0
+		if i%2 == 1 {
+			high = 2600.0
+		}
+		candle, err := value_objects.NewCandle(2500, high, 2499, 2500, start.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle %d: %v", i, err)
+		}
+		if i%2 == 1 {
+			candle = candle.WithConfirmed(false)
+		}
+		candles = append(candles, candle)
+	}
+	return candles
+}
+
+// runMixedConfirm 運行回測並返回開倉、平倉事件的K線索引
+func runMixedConfirm(t *testing.T, requireConfirmed bool) (*BacktestEngine, []int, []int) {
+	t.Helper()
+
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance:          10000.0,
+		FeeRate:                 0.0005,
+		InstID:                  "ETH-USDT-SWAP",
+		TakeProfitMin:           0.0015,
+		TakeProfitMax:           0.0020,
+		PositionSize:            200,
+		EnableTrendFilter:       false,
+		EnableRedCandleFilter:   false,
+		RequireConfirmedCandles: requireConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	sink := &SliceEventSink{}
+	engine.SetEventSink(sink)
+
+	if _, err := engine.Run(buildMixedConfirmCandles(t)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var opened, closed []int
+	for _, event := range sink.Events {
+		switch event.Type {
+		case EventPositionOpened:
+			opened = append(opened, event.CandleIndex)
+		case EventPositionClosed:
+			closed = append(closed, event.CandleIndex)
+		}
+	}
+	return engine, opened, closed
+}
+
+// TestBacktestEngine_RequireConfirmedCandles 測試只有已完成的K線驅動開平倉
+func TestBacktestEngine_RequireConfirmedCandles(t *testing.T) {
+	engine, opened, closed := runMixedConfirm(t, true)
+
+	if want := []int{0, 2, 4}; !reflect.DeepEqual(opened, want) {
+		t.Errorf("Expected opens on confirmed candles %v, got %v", want, opened)
+	}
+	if len(closed) != 0 {
+		t.Errorf("Expected no closes (targets only hit on unconfirmed candles), got %v", closed)
+	}
+	if got := engine.GetSkippedUnconfirmedCount(); got != 2 {
+		t.Errorf("Expected 2 skipped candles, got %d", got)
+	}
+}
+
+// TestBacktestEngine_UnconfirmedCandlesUsedByDefault 測試默認不檢查完成狀態（與舊行為一致）
+func TestBacktestEngine_UnconfirmedCandlesUsedByDefault(t *testing.T) {
+	engine, opened, closed := runMixedConfirm(t, false)
+
+	if len(opened) != 5 {
+		t.Errorf("Expected an open on every candle, got %v", opened)
+	}
+	if len(closed) == 0 || closed[0] != 1 {
+		t.Errorf("Expected the first close on unconfirmed candle 1, got %v", closed)
+	}
+	if got := engine.GetSkippedUnconfirmedCount(); got != 0 {
+		t.Errorf("Expected 0 skipped candles, got %d", got)
+	}
+}
+
+// runOpenPrices 以前一根K線 MidLow 為開倉參考運行回測，返回每次開倉的價格
+func runOpenPrices(t *testing.T, candles []value_objects.Candle, requireConfirmed bool) []float64 {
+	t.Helper()
+
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance:          10000.0,
+		FeeRate:                 0.0005,
+		InstID:                  "ETH-USDT-SWAP",
+		TakeProfitMin:           0.0015,
+		TakeProfitMax:           0.0020,
+		PositionSize:            200,
+		EnableTrendFilter:       false,
+		EnableRedCandleFilter:   false,
+		OpenReference:           grid.OpenReferenceLastCandleMidLow,
+		RequireConfirmedCandles: requireConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	sink := &SliceEventSink{}
+	engine.SetEventSink(sink)
+
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var prices []float64
+	for _, event := range sink.Events {
+		if event.Type == EventPositionOpened {
+			prices = append(prices, event.Price)
+		}
+	}
+	return prices
+}
+
+// TestBacktestEngine_SkippedCandleNotUsedAsPrevious 測試跳過的未完成K線不作為下一根的上一根K線和歷史
+func TestBacktestEngine_SkippedCandleNotUsedAsPrevious(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCandle := func(i int, low, closePrice float64) value_objects.Candle {
+		candle, err := value_objects.NewCandle(2500, 2501, low, closePrice, start.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle %d: %v", i, err)
+		}
+		return candle
+	}
+
+	// 索引 1 為未完成的深跌K線：作為上一根K線時會把開倉價拉低到其 MidLow
+	first := newCandle(0, 2499, 2500)
+	unconfirmed := newCandle(1, 2400, 2450).WithConfirmed(false)
+	last := newCandle(2, 2499, 2500)
+
+	withSkipped := runOpenPrices(t, []value_objects.Candle{first, unconfirmed, last}, true)
+	without := runOpenPrices(t, []value_objects.Candle{first, last}, true)
+	if !reflect.DeepEqual(withSkipped, without) {
+		t.Errorf("Expected open prices %v as if the unconfirmed candle were absent, got %v", without, withSkipped)
+	}
+
+	// 不要求完成時同一根K線會改變開倉價（確認用例確實覆蓋上一根K線）
+	if used := runOpenPrices(t, []value_objects.Candle{first, unconfirmed, last}, false); reflect.DeepEqual(used, without) {
+		t.Errorf("Expected the unconfirmed candle to change open prices when used, got %v", used)
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// cancelAfterContext 在 Err() 被調用指定次數後返回 context.Canceled（模擬運行中途取消）
+type cancelAfterContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+// TestBacktestEngine_RunContext_CancelMidRun 測試運行中途取消時提前返回部分結果
+func TestBacktestEngine_RunContext_CancelMidRun(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	candles := testutil.GenerateFlat(5000)
+
+	// 開始前檢查 + 第 1000 根檢查通過，第 2000 根時取消
+	ctx := &cancelAfterContext{Context: context.Background(), remaining: 2}
+
+	result, err := engine.RunContext(ctx, candles)
+	if err == nil {
+		t.Fatal("Expected cancellation error, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// 最後的資金快照應停在取消前最後處理的K線
+	snapshots := engine.GetMetricsCalculator().GetBalanceSnapshots()
+	lastSnapshot := snapshots[len(snapshots)-1]
+	if want := candles[1999].Timestamp(); !lastSnapshot.Time.Equal(want) {
+		t.Errorf("Expected last snapshot at %v, got %v", want, lastSnapshot.Time)
+	}
+
+	if result.FinalBalance <= 0 {
+		t.Errorf("Expected partial result with final balance, got %.2f", result.FinalBalance)
+	}
+}
+
+// TestBacktestEngine_RunContext_AlreadyCancelled 測試 ctx 已取消時不執行回測
+func TestBacktestEngine_RunContext_AlreadyCancelled(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = engine.RunContext(ctx, testutil.GenerateFlat(10))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if len(engine.GetTradeLog()) != 0 {
+		t.Errorf("Expected no trades, got %d", len(engine.GetTradeLog()))
+	}
+}
@@ -1,11 +1,15 @@
 package engine
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"dizzycode.xyz/logger"
 	"dizzycode.xyz/shared/domain/value_objects"
 	"dizzycode.xyz/trading-strategy-server/backtesting/loader"
 	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
@@ -14,23 +18,124 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// 回測引擎錯誤（可用 errors.Is 判斷）⭐
+var (
+	ErrInvalidConfig   = errors.New("invalid backtest config")             // 配置無效（未知模式、越界參數、無效初始持倉）
+	ErrNoCandles       = errors.New("no candles provided")                 // 沒有提供K線數據
+	ErrAvgCostMismatch = errors.New("average cost does not match tracker") // 平倉使用的平均成本與倉位追蹤器不一致（內部狀態錯誤）
+	ErrFeeMismatch     = errors.New("fee ledger does not match trade log") // 交易日誌的手續費與開倉 + 關倉手續費賬本不一致（內部狀態錯誤）
+)
+
+// AutoFundingMode 自動注資金額模式 ⭐
+type AutoFundingMode string
+
+const (
+	AutoFundingFixed             AutoFundingMode = "fixed"               // 固定金額（AutoFundingAmount，默認）
+	AutoFundingPercentOfNotional AutoFundingMode = "percent_of_notional" // 按觸發時持倉價值的比例（AutoFundingPercent）
+)
+
 // BacktestConfig 回測配置
 type BacktestConfig struct {
 	InitialBalance        float64 // 初始資金
 	FeeRate               float64 // 手續費率（默認: 0.0005 = 0.05%）
-	Slippage              float64 // 滑點（默認: 0）
+	Slippage              float64 // 滑點率（默認: 0；買入按建議價 × (1 + Slippage)、賣出按 × (1 - Slippage) 成交）
 	InstID                string  // 交易對 (e.g., "ETH-USDT-SWAP")
+	TickSize              float64 // 價格最小變動單位（開平倉價按此取整，並推導 CSV 導出精度；0 = 小數點後 2 位）⭐
 	TakeProfitMin         float64 // 最小停利百分比
 	TakeProfitMax         float64 // 最大停利百分比
+	FirstEntryTakeProfit  float64 // 本輪第一筆倉位的停利百分比（0 = 與 TakeProfitMin 相同）⭐
 	PositionSize          float64 // 單次開倉大小 (USDT)
 	BreakEvenProfitMin    float64 // 打平最小目標盈利（USDT）⭐
 	BreakEvenProfitMax    float64 // 打平最大目標盈利（USDT）⭐
+	RoundProfitTarget     float64 // 整輪止盈：本輪總盈虧（已實現 + 未實現）達到此值時平掉所有倉位（USDT，0 = 不啟用）⭐
 	EnableTrendFilter     bool    // 是否啟用趨勢過濾（默認: true）⭐
 	EnableRedCandleFilter bool    // 是否啟用紅K過濾（虧損時只在紅K開倉）⭐
+	RedCandleLookback     int     // 紅K過濾：檢查最近多少根K線（默認: 1）
+	RedCandleMinRed       int     // 紅K過濾：至少多少根為紅K（默認: 1）
+	// 手續費扣除幣種 ⭐
+	FeeCurrency simulator.FeeCurrency // quote = 以 USDT 支付（默認）；base = 買入手續費從收到的幣中扣除
+	// 每筆訂單的最低手續費（USDT）：開倉和平倉手續費都為 max(成交金額 × FeeRate, MinFeePerOrder)，0 = 不限制 ⭐
+	MinFeePerOrder float64
+	// 免手續費模擬（純信號分析）：開倉、平倉手續費、未實現盈虧的預估平倉費和資金費都為 0，
+	// 策略的開平倉信號不變（止盈價仍按 FeeRate 計算最小淨利潤），淨利潤 = 毛利 + 未實現盈虧（默認: false）⭐
+	DisableFees bool
+	// 打平退出 ⭐
+	BreakEvenCloseMode BreakEvenCloseMode // 打平退出的平倉記錄方式（默認: per_position）
+	// 保本止損：本輪預期盈利首次轉正後，在平均成本設置止損，盈利輪次不再轉為虧損（默認: false）⭐
+	BreakEvenStop bool
+	// 輪次時間止損：輪次開始後經過此K線根數（按 BarInterval 換算為時間）仍未結束時，以市價平掉本輪所有倉位（0 = 不啟用）⭐
+	MaxRoundCandles int
+	// K線內成交價格假設 ⭐
+	FillPriceModel FillPriceModel // 止盈觸發和打平成交價格的假設（默認: optimistic）
+	// 開倉限價單成交假設 ⭐
+	LimitFillModel  LimitFillModel // always（默認）| touch | strict
+	LimitFillBuffer float64        // strict 模式下 Low 必須低於限價的比例（例: 0.0005 = 0.05%）
+	// 趨勢計算使用的K線價格（EMA、價格跌幅、陰線統計）⭐
+	TrendPriceSource grid.PriceSource // close（默認）| hlc3 | ohlc4 | high | low
+	// 趨勢 EMA 週期按時間指定（0 = 固定 20 / 50 根），按 BarInterval 換算為K線根數 ⭐
+	TrendEMAShortWindow time.Duration
+	TrendEMALongWindow  time.Duration
+	BarInterval         time.Duration // 數據的K線週期（例: 5m）
+	// 趨勢過濾的開多判斷需連續多少根K線相同才生效，抑制趨勢剛翻轉時的來回切換（0 = 默認 1，立即生效）⭐
+	TrendConfirmCandles int
+	// 趨勢遲滯帶：EMA 差距超過閾值進入趨勢後，回落到「閾值 - 遲滯帶」以內才退出（例: 0.001 = 0.1%，0 = 不啟用）⭐
+	TrendHysteresis float64
+	// 回測結束時以最後收盤價平掉所有未平倉位（未實現盈虧轉為已實現，默認: false）⭐
+	ForceCloseAtEnd bool
+	// 記錄策略拒絕開倉的時間、價格和原因，可用 ExportRejectedAdviceCSV 導出（默認: false）⭐
+	LogRejectedAdvice bool
+	// 每 N 根K線在交易日誌寫入一筆 MARK（當前價格、持倉價值、未實現盈虧），長時間無交易時權益曲線仍有解析度（0 = 不記錄）⭐
+	MarkInterval int
+	// 最短持倉K線數：開倉後至少持有 N 根K線才允許止盈，避免回測捕捉到低於下單延遲的來回（0 = 不限制）⭐
+	MinHoldCandles int
+	// 盈虧比可信所需的最少已平倉交易數，少於此數時結果設置 LowSampleWarning（0 = 默認 30，負數 = 不檢查交易數）⭐
+	ProfitFactorMinTrades int
+	// 回撤熔斷：權益（餘額 + 持倉 + 未實現盈虧 - 待回收注資）從峰值回撤超過此比例時停止開倉 ⭐
+	MaxDrawdownHalt float64 // 熔斷回撤比例（例: 0.2 = 20%，0 = 不啟用）
+	HaltForceClose  bool    // 熔斷時以當前收盤價平掉所有未平倉位（默認: false）
+	// 回撤降倉：權益回撤達到某一檔時，之後的開倉大小乘以該檔倍數，回撤收窄後恢復（空 = 不啟用）⭐
+	DrawdownThrottle []DrawdownBand
+	// 數據來源不確定時（例如 Redis 導出的混合數據），跳過未完成的K線 ⭐
+	RequireConfirmedCandles bool // 是否只在已完成K線上執行開平倉邏輯（默認: false）
+	// 開倉間距 ⭐
+	GridSpacingMode      grid.GridSpacingMode // 開倉間距模式（默認: fixed）
+	SpacingRangeFraction float64              // 區間分層：每層間距佔近期高低區間的比例
+	SpacingLookback      int                  // 區間分層：計算高低區間的K線數量（0 = 全部歷史）
+	// 開倉價的參考價格：current_price（默認）| last_candle_mid_low（前一根K線的 MidLow）⭐
+	OpenReference grid.OpenReferenceMode
+	// 價位去重：掛單價與本輪已有倉位開倉價的相對距離小於此值時不開倉（例: 0.001 = 0.1%，0 = 不限制）⭐
+	MinPriceGapBetweenOpens float64
+	// 止盈保護 ⭐
+	MinNetProfitPerTrade float64 // 單筆止盈扣除手續費後的最小淨利潤（USDT，0 = 不限制）
+	ProfitVsAverageCost  bool    // 止盈目標按本輪平均成本重算（每根K線），而非開倉時固定的止盈價
 	// 自動注資機制 ⭐
-	EnableAutoFunding bool    // 是否啟用自動注資（默認: false）
-	AutoFundingAmount float64 // 自動注資金額（USDT，默認: 5000）
-	AutoFundingIdle   int     // 觸發注資的閒置K線數（默認: 288）
+	EnableAutoFunding  bool            // 是否啟用自動注資（默認: false）
+	AutoFundingAmount  float64         // 自動注資金額（USDT，默認: 5000）
+	AutoFundingIdle    int             // 觸發注資的閒置K線數（默認: 288）
+	AutoFundingMode    AutoFundingMode // 注資金額模式（默認: fixed）⭐
+	AutoFundingPercent float64         // 按比例注資：持倉價值的比例（例: 0.5 = 50%）⭐
+	// 正常止盈後，可用餘額超過初始資金的部分逐步回收注資（默認: false = 只在打平退出時全額回收）⭐
+	IncrementalFundingRecovery bool
+	// 總收益率的資本基數：initial（默認）| initial_plus_max_funding | initial_plus_net_funding ⭐
+	ReturnBasis metrics.ReturnBasis
+	// MaxDrawdown 的計算基礎：cash（默認，可用餘額）| equity（權益，含持倉浮虧）；兩種回撤都會輸出 ⭐
+	DrawdownBasis metrics.DrawdownBasis
+	// 永續合約資金費（與自動注資無關）：每次結算按未平倉價值 × 費率從餘額扣除（負費率 = 收取資金費，0 = 不模擬）⭐
+	FundingFeeRate     float64
+	FundingFeeInterval time.Duration // 資金費結算間隔（默認: 8h，按 UTC 對齊 00:00 / 08:00 / 16:00）
+	// 初始持倉（用於接續回測或模擬既有倉位）⭐
+	SeedPositions []SeedPosition
+}
+
+// SeedPosition 回測開始前已存在的持倉 ⭐
+//
+// 由 NewBacktestEngine 載入 PositionTracker，Run 開始時按「倉位 + 開倉手續費」從初始資金扣除，
+// 並計入第一輪的開倉統計
+type SeedPosition struct {
+	EntryPrice       float64   // 開倉價格
+	Size             float64   // 倉位大小（USDT）
+	OpenTime         time.Time // 開倉時間
+	TargetClosePrice float64   // 止盈價格
 }
 
 // BacktestEngine 回測引擎核心
@@ -43,29 +148,72 @@ type BacktestEngine struct {
 	tradeLog          []TradeLog                 // 交易日誌 ⭐ DEBUG
 	breakEvenRounds   []BreakEvenRound           // 打平輪次記錄 ⭐
 	currentRoundStats RoundStats                 // 當前輪次統計 ⭐
+	// 當前輪次累計（輪次結束時由 onRoundComplete 重置）⭐
+	currentRoundRealizedPnLD decimal.Decimal // 當前輪次已實現盈虧（扣除手續費）
+	currentRoundClosedValueD decimal.Decimal // 當前輪次累積關倉價值（本金 + 盈虧）
 	// 自動注資追蹤 ⭐
 	fundingHistory    []FundingRecord // 注資記錄
 	idleCandles       int             // 當前閒置K線計數
 	pendingFunding    float64         // 待回收的注資金額（累計未回收的注資）⭐
 	maxPendingFunding float64         // 最大待回收注資峰值 ⭐⭐
+	fundedRoundProfit float64         // 使用了注資的輪次累計淨利潤（用於 FundingEfficiency）⭐
+	// 事件輸出 ⭐
+	eventSink EventSink // 狀態轉換事件接收端（默認: NoopEventSink）
+	// 數據品質 ⭐
+	skippedUnconfirmed int // 因未完成而跳過的K線數（RequireConfirmedCandles）
+	// 斷點續跑 ⭐
+	checkpointInterval int            // 每處理多少根K線產生一次斷點（0 = 不定期產生）
+	checkpointHandler  CheckpointFunc // 斷點回調（可為 nil）
+	lastCheckpoint     []byte         // 最近一次斷點（JSON）
+	resumeState        *runState      // RestoreCheckpoint 載入的狀態，下一次 Run 從此繼續
+	// 進度回報 ⭐
+	progressFunc ProgressFunc // 進度回調（可為 nil）
+	// 拒絕開倉記錄 ⭐
+	rejectedAdvice []RejectedAdvice // LogRejectedAdvice 啟用時記錄
+	// 限價單成交假設 ⭐
+	unfilledLimitOrders int // 因限價未成交而撤銷的開倉次數
+	// 最短持倉 ⭐
+	openCandles map[string]int // 倉位ID → 開倉K線索引（MinHoldCandles 啟用時記錄）
+	// 回撤熔斷 ⭐
+	peakEquity float64   // 權益峰值（不含待回收注資）
+	haltedAt   time.Time // 觸發熔斷的時間（零值 = 未觸發）
+	haltReason string    // 熔斷原因
+	// 輪次時間止損 ⭐
+	roundTimeStops int // 被時間止損結束的輪次數
+	// 交易日誌輸出與逐筆累計 ⭐
+	tradeLogger       *StreamingTradeLogger      // 串流寫入交易日誌（nil = 內存日誌）
+	tradeLogErr       error                      // 第一次串流寫入失敗的錯誤
+	tradeLogFees      float64                    // 交易日誌的累計手續費（GetTotalFees）
+	reasonAttribution *metrics.ReasonAttribution // 按關倉原因逐筆累計的盈虧歸因
+	// 統計報告輸出 ⭐
+	reportLogger logger.Logger // 打平輪次和注資統計報告的輸出（SetLogger）
+	verbosity    Verbosity     // 輸出詳細程度（默認: VerbositySilent = 不輸出）
+	// 永續合約資金費 ⭐
+	currentRoundFundingFeeD decimal.Decimal // 當前輪次已支付的資金費（輪次結束時由 onRoundComplete 重置）
+	totalFundingFeeD        decimal.Decimal // 累計資金費
 }
 
 // BreakEvenRound 打平輪次記錄
+//
+// 所有以整輪平倉結束的輪次（打平退出、整輪止盈、保本止損、時間止損）都會記錄，按 ExitReason 區分
 type BreakEvenRound struct {
-	RoundID             int       // 輪次編號
-	StartTime           time.Time // 輪次開始時間
-	EndTime             time.Time // 輪次結束時間（打平觸發時間）
-	Duration            string    // 持續時長
-	TotalOpenCount      int       // 本輪總開倉次數
-	NormalCloseCount    int       // 正常止盈關倉次數 ⭐
-	BreakEvenCloseCount int       // 打平強制關倉次數 ⭐
-	TotalCloseCount     int       // 總關倉次數（正常 + 打平）⭐
-	RealizedPnL         float64   // 本輪已實現盈虧（扣除手續費）
-	UnrealizedPnL       float64   // 觸發時的未實現盈虧
-	ExpectedProfit      float64   // 預期總盈利（實現+未實現）
-	TotalFees           float64   // 本輪總手續費
-	TriggerPrice        float64   // 觸發打平時的價格
-	AvgCost             float64   // 平均成本
+	RoundID              int       // 輪次編號
+	StartTime            time.Time // 輪次開始時間
+	EndTime              time.Time // 輪次結束時間（打平觸發時間）
+	Duration             string    // 持續時長
+	ExitReason           string    // 結束原因分類（metrics.ReasonBreakEvenExit / ReasonRoundProfitExit / ...）⭐
+	TotalOpenCount       int       // 本輪總開倉次數
+	NormalCloseCount     int       // 正常止盈關倉次數 ⭐
+	BreakEvenCloseCount  int       // 打平強制關倉次數 ⭐
+	ProfitExitCloseCount int       // 整輪止盈關倉次數 ⭐
+	TimeStopCloseCount   int       // 時間止損關倉次數 ⭐
+	TotalCloseCount      int       // 總關倉次數（正常 + 打平 + 整輪止盈 + 時間止損）⭐
+	RealizedPnL          float64   // 本輪已實現盈虧（扣除手續費）
+	UnrealizedPnL        float64   // 觸發時的未實現盈虧
+	ExpectedProfit       float64   // 預期總盈利（實現+未實現）
+	TotalFees            float64   // 本輪總手續費
+	TriggerPrice         float64   // 觸發打平時的價格
+	AvgCost              float64   // 平均成本
 }
 
 // FundingRecord 自動注資記錄 ⭐
@@ -79,46 +227,37 @@ type FundingRecord struct {
 	CandleIndex   int       // K線索引
 	Recovered     bool      // 是否已回收 ⭐
 	RecoveredAt   time.Time // 回收時間 ⭐
+	// 已回收金額（逐步回收時可小於 Amount，全額回收後等於 Amount）⭐
+	RecoveredAmount float64
 }
 
 // RoundStats 當前輪次統計
 type RoundStats struct {
-	RoundID             int       // 當前輪次編號
-	StartTime           time.Time // 輪次開始時間
-	OpenCount           int       // 本輪開倉次數
-	NormalCloseCount    int       // 正常止盈關倉次數 ⭐
-	BreakEvenCloseCount int       // 打平強制關倉次數 ⭐
-	TotalFeesInRound    float64   // 本輪累積手續費
+	RoundID              int       // 當前輪次編號
+	StartTime            time.Time // 輪次開始時間
+	OpenCount            int       // 本輪開倉次數
+	NormalCloseCount     int       // 正常止盈關倉次數 ⭐
+	BreakEvenCloseCount  int       // 打平強制關倉次數 ⭐
+	ProfitExitCloseCount int       // 整輪止盈關倉次數 ⭐
+	TimeStopCloseCount   int       // 時間止損關倉次數 ⭐
+	TotalFeesInRound     float64   // 本輪累積手續費
+	BreakEvenStopArmed   bool      // 保本止損已武裝（本輪預期盈利曾經 > 0）⭐
+	ExitReason           string    // 輪次結束原因分類（輪次結束時設置，正常止盈結束為 metrics.ReasonHitTarget）⭐
 }
 
-// GetTotalCloseCount 獲取總關倉次數（正常 + 打平）
+// GetTotalCloseCount 獲取總關倉次數（正常 + 打平 + 整輪止盈 + 時間止損）
 func (rs *RoundStats) GetTotalCloseCount() int {
-	return rs.NormalCloseCount + rs.BreakEvenCloseCount
+	return rs.NormalCloseCount + rs.BreakEvenCloseCount + rs.ProfitExitCloseCount + rs.TimeStopCloseCount
 }
 
-// TradeLog 交易日誌（用於 debug）
-type TradeLog struct {
-	TradeID                 int       // 交易序號
-	Time                    time.Time // 時間
-	Action                  string    // OPEN / CLOSE
-	Price                   float64   // 價格
-	PositionSize            float64   // 倉位大小
-	Balance                 float64   // 當前餘額
-	OpenPositionValue       float64   // 累計持倉總價值（USDT）⭐
-	PnLPercent              float64   // 盈虧百分比（基於單筆開倉價）⭐
-	PnL                     float64   // 盈虧金額（基於單筆開倉價，未扣手續費）⭐
-	AvgCost                 float64   // 平倉時的平均成本（所有未平倉的加權平均）⭐
-	PnLPercent_Avg          float64   // 基於平均成本的盈虧百分比 ⭐
-	PnL_Avg                 float64   // 基於平均成本的盈虧金額（未扣手續費）⭐
-	Fee                     float64   // 手續費 ⭐
-	RoundClosedValue        float64   // 本輪累積關倉總價值（本金 + 盈虧）⭐
-	CurrentRoundRealizedPnL float64   // 本輪已實現盈虧（基於平均成本，扣除手續費）⭐
-	TotalRealizedPnL        float64   // 累計已實現盈虧（從回測開始到現在的所有已實現盈虧總和）⭐
-	UnrealizedPnL           float64   // 浮動盈虧（所有未平倉倉位的未實現盈虧）⭐
-	Reason                  string    // 原因
-	PositionID              string    // 倉位ID（關聯開倉和平倉）⭐
+// forcedCloseCount 整輪退出時強制平掉的倉位數（打平 + 整輪止盈 + 時間止損）
+func (r BreakEvenRound) forcedCloseCount() int {
+	return r.BreakEvenCloseCount + r.ProfitExitCloseCount + r.TimeStopCloseCount
 }
 
+// TradeLog 交易日誌（用於 debug，定義於 metrics 以便做盈虧歸因）⭐
+type TradeLog = metrics.TradeLog
+
 // ⭐ 已刪除：calculateUnrealizedPnL - 統一使用 PositionTracker.CalculateUnrealizedPnL()
 
 // ExecuteCloseResult 平倉執行結果（用於 decimal 累加）⭐
@@ -130,6 +269,7 @@ type ExecuteCloseResult struct {
 	RealizedPnL       decimal.Decimal // 已實現盈虧（基於平均成本，扣除手續費）
 	ClosedValue       decimal.Decimal // 平倉總價值
 	PositionSize      decimal.Decimal // 倉位大小（用於更新 openPositionValue）
+	SlippageCost      decimal.Decimal // 平倉滑點成本
 	// 用於交易日誌的 float64 值
 	PnLPercent     float64 // 基於開倉價的盈虧百分比
 	PnL            float64 // 基於開倉價的盈虧金額
@@ -142,20 +282,38 @@ type ExecuteCloseResult struct {
 func NewBacktestEngine(config BacktestConfig) (*BacktestEngine, error) {
 	// 1. 創建真實的 Grid 策略 ⭐ 直接寫死參數（POC）
 	strategy, err := grid.NewGridAggregate(grid.GridConfig{
-		InstID:                config.InstID,
-		PositionSize:          config.PositionSize,
-		FeeRate:               config.FeeRate,
-		TakeProfitRateMin:     config.TakeProfitMin,
-		TakeProfitRateMax:     config.TakeProfitMax,
-		BreakEvenProfitMin:    config.BreakEvenProfitMin,
-		BreakEvenProfitMax:    config.BreakEvenProfitMax,
-		EnableTrendFilter:     config.EnableTrendFilter,     // ⭐ 是否啟用趨勢過濾
-		EnableRedCandleFilter: config.EnableRedCandleFilter, // ⭐ 是否啟用紅K過濾
+		InstID:                  config.InstID,
+		PositionSize:            config.PositionSize,
+		FeeRate:                 config.FeeRate,
+		TakeProfitRateMin:       config.TakeProfitMin,
+		TakeProfitRateMax:       config.TakeProfitMax,
+		FirstEntryTakeProfit:    config.FirstEntryTakeProfit,
+		BreakEvenProfitMin:      config.BreakEvenProfitMin,
+		BreakEvenProfitMax:      config.BreakEvenProfitMax,
+		RoundProfitTarget:       config.RoundProfitTarget,
+		EnableTrendFilter:       config.EnableTrendFilter,     // ⭐ 是否啟用趨勢過濾
+		EnableRedCandleFilter:   config.EnableRedCandleFilter, // ⭐ 是否啟用紅K過濾
+		RedCandleLookback:       config.RedCandleLookback,
+		RedCandleMinRed:         config.RedCandleMinRed,
+		SpacingMode:             config.GridSpacingMode,
+		SpacingRangeFraction:    config.SpacingRangeFraction,
+		SpacingLookback:         config.SpacingLookback,
+		MinNetProfitPerTrade:    config.MinNetProfitPerTrade,
+		ProfitVsAverageCost:     config.ProfitVsAverageCost,
+		OpenReference:           config.OpenReference,
+		MinPriceGapBetweenOpens: config.MinPriceGapBetweenOpens,
+		TickSize:                config.TickSize,
 		TrendFilterConfig: grid.TrendAnalyzerConfig{
 			EMAThreshold:    0.003, // 0.3%
 			CandleThreshold: 0.004, // 0.4%
 			EMAShortPeriod:  20,
 			EMALongPeriod:   50,
+			PriceSource:     config.TrendPriceSource,
+			EMAShortWindow:  config.TrendEMAShortWindow,
+			EMALongWindow:   config.TrendEMALongWindow,
+			ConfirmCandles:  config.TrendConfirmCandles,
+			Hysteresis:      config.TrendHysteresis,
+			BarInterval:     config.BarInterval,
 			// 以下參數由 TrendAnalyzer 內部默認值處理：
 			// PriceDropThreshold: 0.008 (0.8%)
 			// ConsecutivePeriod:  5
@@ -165,10 +323,112 @@ func NewBacktestEngine(config BacktestConfig) (*BacktestEngine, error) {
 		return nil, fmt.Errorf("failed to create grid strategy: %w", err)
 	}
 
+	// ⭐ 免手續費模擬：策略創建之後再清零，模擬器、計算器和引擎都不收取任何費用
+	if config.DisableFees {
+		config.FeeRate = 0
+		config.MinFeePerOrder = 0
+		config.FundingFeeRate = 0
+	}
+
+	// 驗證打平平倉模式
+	switch config.BreakEvenCloseMode {
+	case "", BreakEvenClosePerPosition, BreakEvenCloseAggregate:
+	default:
+		return nil, fmt.Errorf("%w: unknown break even close mode: %s", ErrInvalidConfig, config.BreakEvenCloseMode)
+	}
+
+	// 驗證K線內成交價格假設
+	switch config.FillPriceModel {
+	case "", FillPriceOptimistic, FillPricePessimistic, FillPriceCloseOnly:
+	default:
+		return nil, fmt.Errorf("%w: unknown fill price model: %s", ErrInvalidConfig, config.FillPriceModel)
+	}
+
+	// 驗證限價單成交假設
+	switch config.LimitFillModel {
+	case "", LimitFillAlways, LimitFillTouch, LimitFillStrict:
+	default:
+		return nil, fmt.Errorf("%w: unknown limit fill model: %s", ErrInvalidConfig, config.LimitFillModel)
+	}
+	if config.MarkInterval < 0 {
+		return nil, fmt.Errorf("%w: mark interval must be non-negative, got %d", ErrInvalidConfig, config.MarkInterval)
+	}
+	if config.MinHoldCandles < 0 {
+		return nil, fmt.Errorf("%w: min hold candles must be non-negative, got %d", ErrInvalidConfig, config.MinHoldCandles)
+	}
+	if config.Slippage < 0 || config.Slippage >= 1 {
+		return nil, fmt.Errorf("%w: slippage must be in [0, 1), got %v", ErrInvalidConfig, config.Slippage)
+	}
+	if config.LimitFillBuffer < 0 || config.LimitFillBuffer >= 1 {
+		return nil, fmt.Errorf("%w: limit fill buffer must be in [0, 1), got %v", ErrInvalidConfig, config.LimitFillBuffer)
+	}
+
+	// 驗證回撤熔斷比例
+	if config.MaxDrawdownHalt < 0 || config.MaxDrawdownHalt >= 1 {
+		return nil, fmt.Errorf("%w: max drawdown halt must be in [0, 1), got %v", ErrInvalidConfig, config.MaxDrawdownHalt)
+	}
+	if err := validateDrawdownThrottle(config.DrawdownThrottle); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	// 驗證自動注資配置
+	switch config.AutoFundingMode {
+	case "", AutoFundingFixed:
+	case AutoFundingPercentOfNotional:
+		if config.EnableAutoFunding && config.AutoFundingPercent <= 0 {
+			return nil, fmt.Errorf("%w: auto funding percent must be positive in %s mode", ErrInvalidConfig, config.AutoFundingMode)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown auto funding mode: %s", ErrInvalidConfig, config.AutoFundingMode)
+	}
+
+	// 驗證收益率基數
+	if err := config.ReturnBasis.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := config.DrawdownBasis.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if config.MaxRoundCandles < 0 {
+		return nil, fmt.Errorf("%w: max round candles must not be negative", ErrInvalidConfig)
+	}
+	if config.MaxRoundCandles > 0 && config.BarInterval <= 0 {
+		return nil, fmt.Errorf("%w: max round candles requires a bar interval", ErrInvalidConfig)
+	}
+	if config.FundingFeeInterval < 0 {
+		return nil, fmt.Errorf("%w: funding fee interval must not be negative", ErrInvalidConfig)
+	}
+
 	// 2. 創建模擬器和追蹤器
 	orderSimulator := simulator.NewOrderSimulator(config.FeeRate, config.Slippage)
+	if err := orderSimulator.SetFeeCurrency(config.FeeCurrency); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := orderSimulator.SetMinFeePerOrder(config.MinFeePerOrder); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
 	positionTracker := simulator.NewPositionTracker()
 	calculator := metrics.NewMetricsCalculator(config.InitialBalance)
+	calculator.SetFeeRate(config.FeeRate)
+	calculator.SetReturnBasis(config.ReturnBasis)
+	calculator.SetDrawdownBasis(config.DrawdownBasis)
+	if config.ProfitFactorMinTrades != 0 {
+		calculator.SetProfitFactorMinTrades(max(config.ProfitFactorMinTrades, 0))
+	}
+
+	// 3. 載入初始持倉，第一輪統計從初始持倉開始 ⭐
+	firstRound := RoundStats{RoundID: 1} // 從第1輪開始
+	for i, seed := range config.SeedPositions {
+		if _, err := positionTracker.AddPosition(seed.EntryPrice, seed.Size, seed.OpenTime, seed.TargetClosePrice); err != nil {
+			return nil, fmt.Errorf("%w: invalid seed position %d: %w", ErrInvalidConfig, i, err)
+		}
+
+		if firstRound.StartTime.IsZero() || seed.OpenTime.Before(firstRound.StartTime) {
+			firstRound.StartTime = seed.OpenTime
+		}
+		firstRound.OpenCount++
+		firstRound.TotalFeesInRound += orderSimulator.Fee(decimal.NewFromFloat(seed.Size)).InexactFloat64()
+	}
 
 	return &BacktestEngine{
 		strategy:          strategy,
@@ -177,14 +437,24 @@ func NewBacktestEngine(config BacktestConfig) (*BacktestEngine, error) {
 		calculator:        calculator,
 		config:            config,
 		breakEvenRounds:   []BreakEvenRound{},
-		currentRoundStats: RoundStats{RoundID: 1}, // 從第1輪開始
-		fundingHistory:    []FundingRecord{},      // 初始化注資記錄 ⭐
-		idleCandles:       0,                      // 初始化閒置計數 ⭐
-		pendingFunding:    0,                      // 初始化待回收注資 ⭐
-		maxPendingFunding: 0,                      // 初始化最大待回收峰值 ⭐⭐
+		currentRoundStats: firstRound,        // 從第1輪開始（包含初始持倉）
+		fundingHistory:    []FundingRecord{}, // 初始化注資記錄 ⭐
+		idleCandles:       0,                 // 初始化閒置計數 ⭐
+		pendingFunding:    0,                 // 初始化待回收注資 ⭐
+		maxPendingFunding: 0,                 // 初始化最大待回收峰值 ⭐⭐
+		eventSink:         NoopEventSink{},   // 默認丟棄事件 ⭐
+		reasonAttribution: metrics.NewReasonAttribution(),
 	}, nil
 }
 
+// SetEventSink 設置事件接收端（nil 表示丟棄事件）⭐
+func (e *BacktestEngine) SetEventSink(sink EventSink) {
+	if sink == nil {
+		sink = NoopEventSink{}
+	}
+	e.eventSink = sink
+}
+
 // executeClose 执行平仓操作（返回需要累加的結果）⭐ 重構版
 //
 // 这个辅助函数封装了平仓的核心流程：
@@ -198,6 +468,11 @@ func NewBacktestEngine(config BacktestConfig) (*BacktestEngine, error) {
 //   - closeTime: 平仓时间
 //   - avgCost: 平均成本
 //
+// 同一根K線內的所有平倉都使用平倉循環開始前的平均成本 ⭐
+// 平倉只減少幣數、不改變平均成本（見 PositionTracker.ClosePosition），開倉又在所有平倉之後，
+// 因此循環前取得的 avgCost 在每一筆平倉時仍等於追蹤器的當前值，第二筆及之後的平倉
+// 與第一筆按同一個成本歸因盈虧。傳入的 avgCost 與追蹤器不一致時返回 ErrAvgCostMismatch，不平倉
+//
 // 返回：
 //   - ExecuteCloseResult: 平仓結果（用於 decimal 累加）
 //   - error: 如果平仓失败则返回错误
@@ -207,6 +482,12 @@ func (e *BacktestEngine) executeClose(
 	closeTime time.Time,
 	avgCost float64,
 ) (ExecuteCloseResult, error) {
+	// 0. 平均成本必須與追蹤器一致（調用方持有的值過期時歸因會出錯）
+	if trackerAvgCost := e.positionTracker.CalculateAverageCost(); avgCost != trackerAvgCost {
+		return ExecuteCloseResult{}, fmt.Errorf("%w: closing %s with %v, tracker has %v",
+			ErrAvgCostMismatch, pos.ID, avgCost, trackerAvgCost)
+	}
+
 	// 1. 模拟平仓（统一计算所有盈亏指标）
 	closeResult, err := e.simulator.SimulateClose(pos, closePrice, closeTime, avgCost)
 	if err != nil {
@@ -223,6 +504,7 @@ func (e *BacktestEngine) executeClose(
 	if err != nil {
 		return ExecuteCloseResult{}, err
 	}
+	e.forgetOpenCandle(pos.ID)
 
 	// 3. 構建返回結果（使用 decimal 類型）
 	return ExecuteCloseResult{
@@ -233,6 +515,7 @@ func (e *BacktestEngine) executeClose(
 		RealizedPnL:       decimal.NewFromFloat(closeResult.ClosedPosition.RealizedPnL),
 		ClosedValue:       decimal.NewFromFloat(closeResult.CloseValue),
 		PositionSize:      decimal.NewFromFloat(pos.Size),
+		SlippageCost:      decimal.NewFromFloat(closeResult.SlippageCost),
 		// 用於交易日誌的 float64 值
 		PnLPercent:     closeResult.PnLPercent,
 		PnL:            closeResult.PnL,
@@ -251,7 +534,7 @@ func (e *BacktestEngine) executeClose(
 //  4. 檢查所有未平倉是否觸及止盈
 //  5. 如果觸及，模擬平倉交易
 //  6. 記錄資金曲線（用於計算最大回撤）
-//  7. 回測結束後，強制平倉所有未平倉
+//  7. 回測結束後，強制平倉所有未平倉（ForceCloseAtEnd 時）
 //  8. 計算回測指標
 //
 // 參數：
@@ -260,8 +543,43 @@ func (e *BacktestEngine) executeClose(
 // 返回：
 //   - BacktestResult: 回測結果
 func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestResult, error) {
+	return e.RunContext(context.Background(), candles)
+}
+
+// ctxCheckInterval RunContext 每處理多少根K線檢查一次 ctx 是否已取消
+const ctxCheckInterval = 1000
+
+// RunContext 執行回測（支持取消）⭐
+//
+// 與 Run 相同，但每處理 ctxCheckInterval 根K線檢查一次 ctx.Err()。
+// 若 ctx 已取消，提前結束並返回截至當前K線的部分結果，以及包裝 ctx.Err() 的錯誤。
+//
+// 參數：
+//   - ctx: 用於取消回測（例如參數掃描任務超時或被中止）
+//   - candles: 歷史K線數據（從舊到新排序）
+//
+// 返回：
+//   - BacktestResult: 回測結果（取消時為部分結果）
+//   - error: 取消時可用 errors.Is(err, context.Canceled) 判斷
+func (e *BacktestEngine) RunContext(ctx context.Context, candles []value_objects.Candle) (metrics.BacktestResult, error) {
 	if len(candles) == 0 {
-		return metrics.BacktestResult{}, fmt.Errorf("no candles provided")
+		return metrics.BacktestResult{}, ErrNoCandles
+	}
+	return e.run(ctx, newSliceFeed(candles))
+}
+
+// run 回測主循環（Run 和 RunStream 共用）⭐
+func (e *BacktestEngine) run(ctx context.Context, feed candleFeed) (metrics.BacktestResult, error) {
+	if err := ctx.Err(); err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("backtest cancelled before start: %w", err)
+	}
+
+	firstCandle, ok, err := feed.peek()
+	if err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("failed to read candles: %w", err)
+	}
+	if !ok {
+		return metrics.BacktestResult{}, ErrNoCandles
 	}
 
 	// ⭐ 使用 decimal 計算，避免浮點誤差
@@ -269,31 +587,242 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 	tradeCounter := 0 // 交易計數器
 
 	// ⭐ 追蹤統計數據（使用 decimal）
-	totalOpenedTrades := 0                      // 總開倉數量
-	totalProfitGrossD := decimal.Zero           // 總利潤（基於平均成本，未扣手續費）
-	totalProfitGross_EntryD := decimal.Zero     // 總利潤（基於單筆開倉價，未扣手續費）⭐ 新增
-	totalFeesOpenD := decimal.Zero              // 開倉總手續費
-	totalFeesCloseD := decimal.Zero             // 關倉總手續費
+	totalOpenedTrades := 0                  // 總開倉數量
+	totalProfitGrossD := decimal.Zero       // 總利潤（基於平均成本，未扣手續費）
+	totalProfitGross_EntryD := decimal.Zero // 總利潤（基於單筆開倉價，未扣手續費）⭐ 新增
+	totalFeesOpenD := decimal.Zero          // 開倉總手續費
+	totalFeesCloseD := decimal.Zero         // 關倉總手續費
+
+	// ⭐ 滑點成本（開倉 + 平倉，理想淨利潤 - 實際淨利潤）
+	totalSlippageCostD := decimal.Zero
 
 	// ⭐ 追蹤當前交易輪次數據（用於打平機制）
-	openPositionValueD := decimal.Zero       // 累計持倉總價值（USDT）
-	currentRoundRealizedPnLD := decimal.Zero // 當前輪次已實現盈虧（扣除手續費）
-	currentRoundClosedValueD := decimal.Zero // 當前輪次累積關倉價值（本金 + 盈虧）⭐
-	totalRealizedPnLD := decimal.Zero        // 累計已實現盈虧（從回測開始的所有已實現盈虧總和）⭐
+	openPositionValueD := decimal.Zero // 累計持倉總價值（USDT）
+	totalRealizedPnLD := decimal.Zero  // 累計已實現盈虧（從回測開始的所有已實現盈虧總和）⭐
 
 	// ⭐ 追蹤持倉全滿天數（定義：可用餘額 < 單次開倉成本）
 	fullPositionDays := make(map[string]bool) // 記錄哪些天達到持倉全滿（key: YYYY-MM-DD）
 	maxOpenPositionValueD := decimal.Zero     // 追蹤最大持倉價值（USDT）⭐
 
-	// 記錄初始資金
-	e.calculator.RecordBalance(candles[0].Timestamp(), balanceD.InexactFloat64())
+	// ⭐ 從斷點恢復：載入累計狀態，從斷點的K線繼續（不重複扣除初始持倉、不重複記錄初始資金）
+	startIdx := 0
+	if resume := e.resumeState; resume != nil {
+		e.resumeState = nil
+		ok, err := feed.skip(resume.NextCandle)
+		if err != nil {
+			return metrics.BacktestResult{}, fmt.Errorf("failed to read candles: %w", err)
+		}
+		if !ok {
+			return metrics.BacktestResult{}, fmt.Errorf("checkpoint candle index %d out of range (%d candles)", resume.NextCandle, feed.total())
+		}
+		startIdx = resume.NextCandle
+		balanceD = resume.Balance
+		tradeCounter = resume.TradeCounter
+		totalOpenedTrades = resume.TotalOpenedTrades
+		totalProfitGrossD = resume.TotalProfitGross
+		totalProfitGross_EntryD = resume.TotalProfitGrossEntry
+		totalFeesOpenD = resume.TotalFeesOpen
+		totalFeesCloseD = resume.TotalFeesClose
+		totalSlippageCostD = resume.SlippageCost
+		openPositionValueD = resume.OpenPositionValue
+		e.currentRoundRealizedPnLD = resume.RoundRealizedPnL
+		e.currentRoundClosedValueD = resume.RoundClosedValue
+		totalRealizedPnLD = resume.TotalRealizedPnL
+		fullPositionDays = resume.FullPositionDays
+		maxOpenPositionValueD = resume.MaxOpenPositionValue
+	} else {
+		// ⭐ 初始持倉：扣除倉位成本（倉位 + 開倉手續費），計入持倉價值和開倉手續費
+		for _, pos := range e.positionTracker.GetOpenPositions() {
+			positionSizeD := decimal.NewFromFloat(pos.Size)
+			openFeeD := e.simulator.Fee(positionSizeD)
+
+			balanceD = balanceD.Sub(positionSizeD.Add(openFeeD))
+			openPositionValueD = openPositionValueD.Add(positionSizeD)
+			totalFeesOpenD = totalFeesOpenD.Add(openFeeD)
+
+			tradeCounter++
+			e.logTrade(TradeLog{
+				TradeID:           tradeCounter,
+				Time:              pos.OpenTime,
+				Action:            "OPEN",
+				Price:             pos.EntryPrice,
+				PositionSize:      pos.Size,
+				Balance:           balanceD.InexactFloat64(),
+				OpenPositionValue: openPositionValueD.InexactFloat64(),
+				AvgCost:           e.positionTracker.CalculateAverageCost(),
+				Fee:               openFeeD.InexactFloat64(),
+				Reason:            "seed_position",
+				PositionID:        pos.ID,
+			})
+		}
+		if openPositionValueD.GreaterThan(maxOpenPositionValueD) {
+			maxOpenPositionValueD = openPositionValueD
+		}
+
+		// 記錄初始資金
+		e.calculator.RecordBalance(firstCandle.Timestamp(), balanceD.InexactFloat64())
+	}
+
+	// captureState 記錄處理到 nextCandle 之前的累計狀態（用於斷點）
+	captureState := func(nextCandle int) runState {
+		return runState{
+			NextCandle:            nextCandle,
+			Balance:               balanceD,
+			TradeCounter:          tradeCounter,
+			TotalOpenedTrades:     totalOpenedTrades,
+			TotalProfitGross:      totalProfitGrossD,
+			TotalProfitGrossEntry: totalProfitGross_EntryD,
+			TotalFeesOpen:         totalFeesOpenD,
+			TotalFeesClose:        totalFeesCloseD,
+			SlippageCost:          totalSlippageCostD,
+			OpenPositionValue:     openPositionValueD,
+			RoundRealizedPnL:      e.currentRoundRealizedPnLD,
+			RoundClosedValue:      e.currentRoundClosedValueD,
+			TotalRealizedPnL:      totalRealizedPnLD,
+			FullPositionDays:      fullPositionDays,
+			MaxOpenPositionValue:  maxOpenPositionValueD,
+		}
+	}
+
+	var runErr error
+
+	// closeAllPositions 以指定價格平掉所有未平倉位（ForceCloseAtEnd 和 HaltForceClose 共用）⭐
+	// 平倉失敗的倉位保持持倉，第一個錯誤記錄到 runErr（Run 返回）
+	closeAllPositions := func(closePrice float64, closeTime time.Time, candleIndex int, reason string) {
+		avgCost := e.positionTracker.CalculateAverageCost()
+		positionsToClose := make([]simulator.Position, len(e.positionTracker.GetOpenPositions()))
+		copy(positionsToClose, e.positionTracker.GetOpenPositions())
+
+		for _, pos := range positionsToClose {
+			closeResult, err := e.executeClose(pos, closePrice, closeTime, avgCost)
+			if err != nil {
+				if runErr == nil {
+					runErr = fmt.Errorf("%s close of position %s failed: %w", reason, pos.ID, err)
+				}
+				continue
+			}
+
+			balanceD = balanceD.Add(closeResult.Revenue)
+			totalProfitGrossD = totalProfitGrossD.Add(closeResult.ProfitGross)
+			totalProfitGross_EntryD = totalProfitGross_EntryD.Add(closeResult.ProfitGross_Entry)
+			totalFeesCloseD = totalFeesCloseD.Add(closeResult.CloseFee)
+			totalSlippageCostD = totalSlippageCostD.Add(closeResult.SlippageCost)
+			openPositionValueD = openPositionValueD.Sub(closeResult.PositionSize)
+			e.currentRoundRealizedPnLD = e.currentRoundRealizedPnLD.Add(closeResult.RealizedPnL)
+			e.currentRoundClosedValueD = e.currentRoundClosedValueD.Add(closeResult.ClosedValue)
+			totalRealizedPnLD = totalRealizedPnLD.Add(closeResult.RealizedPnL)
+
+			tradeCounter++
+			e.logTrade(TradeLog{
+				TradeID:                 tradeCounter,
+				Time:                    closeTime,
+				Action:                  "CLOSE",
+				Price:                   closeResult.ClosePrice,
+				PositionSize:            closeResult.ClosedValue.InexactFloat64(),
+				Balance:                 balanceD.InexactFloat64(),
+				OpenPositionValue:       openPositionValueD.InexactFloat64(),
+				PnLPercent:              closeResult.PnLPercent,
+				PnL:                     closeResult.PnL,
+				AvgCost:                 avgCost,
+				PnLPercent_Avg:          closeResult.PnLPercent_Avg,
+				PnL_Avg:                 closeResult.PnL_Avg,
+				Fee:                     closeResult.CloseFee.InexactFloat64(),
+				RoundClosedValue:        e.currentRoundClosedValueD.InexactFloat64(),
+				CurrentRoundRealizedPnL: e.currentRoundRealizedPnLD.InexactFloat64(),
+				TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),
+				UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(closePrice, e.config.FeeRate),
+				Reason:                  reason,
+				PositionID:              pos.ID,
+			})
+			e.eventSink.Emit(BacktestEvent{
+				Type:        EventPositionClosed,
+				Time:        closeTime,
+				CandleIndex: candleIndex,
+				PositionID:  pos.ID,
+				Price:       closeResult.ClosePrice,
+				Size:        pos.Size,
+				Fee:         closeResult.CloseFee.InexactFloat64(),
+				RealizedPnL: closeResult.RealizedPnL.InexactFloat64(),
+				Balance:     balanceD.InexactFloat64(),
+				Reason:      reason,
+			})
+			e.currentRoundStats.TotalFeesInRound += closeResult.CloseFee.InexactFloat64()
+		}
+	}
+
+	processed := 0                      // 實際處理的K線數量（取消時小於總數）⭐
+	lastProcessed, _ := feed.previous() // 最後處理的K線（斷點恢復時初始為跳過的最後一根）
+	progressEvery := progressInterval(feed.total())
+	if feed.total() == 0 {
+		progressEvery = streamProgressInterval
+	}
 
 	// 遍歷所有K線
-	for i := 0; i < len(candles); i++ {
-		currentCandle := candles[i]
+	for i := startIdx; ; i++ {
+		currentCandle, ok, err := feed.advance()
+		if err != nil {
+			processed = i
+			runErr = fmt.Errorf("failed to read candle %d: %w", i, err)
+			break
+		}
+		if !ok {
+			processed = i
+			break
+		}
+
+		// ⭐ 串流交易日誌寫入失敗時中止（之後的記錄無法保存）
+		if e.tradeLogErr != nil {
+			processed = i
+			runErr = e.tradeLogErr
+			break
+		}
+
+		// ⭐ 定期檢查是否已取消
+		if i > 0 && i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				processed = i
+				runErr = fmt.Errorf("backtest cancelled at candle %s: %w", candlePosition(i, feed.total()), err)
+				break
+			}
+		}
+
+		// ⭐ 定期斷點（記錄處理第 i 根K線之前的狀態）
+		if e.checkpointInterval > 0 && i > startIdx && i%e.checkpointInterval == 0 {
+			data, err := e.saveCheckpoint(captureState(i))
+			if err == nil && e.checkpointHandler != nil {
+				err = e.checkpointHandler(i, data)
+			}
+			if err != nil {
+				processed = i
+				runErr = fmt.Errorf("checkpoint at candle %d failed: %w", i, err)
+				break
+			}
+		}
+
+		// ⭐ 約每 1% 回報一次進度（已處理 i 根）
+		if e.progressFunc != nil && i > startIdx && i%progressEvery == 0 {
+			e.progressFunc(i, feed.total())
+		}
+
+		// ⭐ 未完成的K線不驅動開平倉（只在 RequireConfirmedCandles 時生效）
+		if e.config.RequireConfirmedCandles && !currentCandle.IsConfirmed() {
+			e.skippedUnconfirmed++
+			continue
+		}
+
+		previousTime := lastProcessed.Timestamp() // 上一根K線時間（資金費結算判斷）
+		lastProcessed = currentCandle
+
 		currentPrice := currentCandle.Close()
 		currentTime := currentCandle.Timestamp()
 
+		// ========== 步驟 0: 永續合約資金費結算 ⭐ ==========
+		// 結算時點落在上一根K線和當前K線之間，按此時（平倉前）的持倉收取
+		if e.fundingFeeDue(previousTime, currentTime) && openPositionValueD.IsPositive() {
+			balanceD = balanceD.Sub(e.chargeFundingFee(openPositionValueD))
+			e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
+		}
+
 		// ========== 步驟 1: 檢查是否需要平倉 ==========
 		// ⭐ 在平倉循環開始前，先計算當前時刻的平均成本（所有同一時間的平倉都使用這個值）
 		avgCostAtThisTime := e.positionTracker.CalculateAverageCost()
@@ -302,14 +831,25 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 		positionsToCheck := make([]simulator.Position, len(e.positionTracker.GetOpenPositions()))
 		copy(positionsToCheck, e.positionTracker.GetOpenPositions())
 
+		// ⭐ 止盈目標使用的持倉摘要（ProfitVsAverageCost 時按平均成本重算止盈價）
+		targetSummary := value_objects.PositionSummary{
+			Count:     len(positionsToCheck),
+			TotalSize: e.positionTracker.GetTotalSize(),
+			AvgPrice:  avgCostAtThisTime,
+		}
+
 		// 注意：先檢查平倉，再考慮開倉（避免資金不足）
-		for _, pos := range positionsToCheck {
-			// ⭐ 檢查是否觸及目標平倉價格（使用 High 價格）
-			if currentCandle.High().Value() >= pos.TargetClosePrice {
+		// ⭐ 按價格-時間優先處理止盈單（見 takeProfitQueue）
+		for _, order := range e.takeProfitQueue(positionsToCheck, targetSummary) {
+			pos, targetPrice := order.Position, order.Target
+
+			// ⭐ 檢查是否觸及目標平倉價格（按 FillPriceModel：默認使用 High 價格）
+			// ⭐ 未滿最短持倉K線數（MinHoldCandles）的倉位本根K線不止盈
+			if e.config.FillPriceModel.takeProfitTriggered(currentCandle, targetPrice) && e.heldLongEnough(pos.ID, i) {
 				// ⭐ 使用提取的辅助函数执行平仓（使用止盈價）
 				closeResult, err := e.executeClose(
 					pos,
-					pos.TargetClosePrice, // ⭐ 修正：使用止盈價而不是收盤價
+					targetPrice, // ⭐ 修正：使用止盈價而不是收盤價
 					currentTime,
 					avgCostAtThisTime,
 				)
@@ -323,9 +863,10 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 				totalProfitGrossD = totalProfitGrossD.Add(closeResult.ProfitGross)
 				totalProfitGross_EntryD = totalProfitGross_EntryD.Add(closeResult.ProfitGross_Entry)
 				totalFeesCloseD = totalFeesCloseD.Add(closeResult.CloseFee)
+				totalSlippageCostD = totalSlippageCostD.Add(closeResult.SlippageCost)
 				openPositionValueD = openPositionValueD.Sub(closeResult.PositionSize)
-				currentRoundRealizedPnLD = currentRoundRealizedPnLD.Add(closeResult.RealizedPnL)
-				currentRoundClosedValueD = currentRoundClosedValueD.Add(closeResult.ClosedValue)
+				e.currentRoundRealizedPnLD = e.currentRoundRealizedPnLD.Add(closeResult.RealizedPnL)
+				e.currentRoundClosedValueD = e.currentRoundClosedValueD.Add(closeResult.ClosedValue)
 				totalRealizedPnLD = totalRealizedPnLD.Add(closeResult.RealizedPnL)
 
 				// 記錄資金快照
@@ -333,8 +874,8 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 
 				// 記錄交易日誌
 				tradeCounter++
-				reason := fmt.Sprintf("hit_target_%.2f", pos.TargetClosePrice)
-				e.tradeLog = append(e.tradeLog, TradeLog{
+				reason := fmt.Sprintf("hit_target_%.2f", targetPrice)
+				e.logTrade(TradeLog{
 					TradeID:                 tradeCounter,
 					Time:                    currentTime,
 					Action:                  "CLOSE",
@@ -348,23 +889,50 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 					PnLPercent_Avg:          closeResult.PnLPercent_Avg,
 					PnL_Avg:                 closeResult.PnL_Avg,
 					Fee:                     closeResult.CloseFee.InexactFloat64(),
-					RoundClosedValue:        currentRoundClosedValueD.InexactFloat64(),
-					CurrentRoundRealizedPnL: currentRoundRealizedPnLD.InexactFloat64(),
+					RoundClosedValue:        e.currentRoundClosedValueD.InexactFloat64(),
+					CurrentRoundRealizedPnL: e.currentRoundRealizedPnLD.InexactFloat64(),
 					TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),
-					UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(pos.TargetClosePrice, e.config.FeeRate),
+					UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(targetPrice, e.config.FeeRate),
 					Reason:                  reason,
 					PositionID:              pos.ID,
 				})
+				e.eventSink.Emit(BacktestEvent{
+					Type:        EventPositionClosed,
+					Time:        currentTime,
+					CandleIndex: i,
+					PositionID:  pos.ID,
+					Price:       closeResult.ClosePrice,
+					Size:        pos.Size,
+					Fee:         closeResult.CloseFee.InexactFloat64(),
+					RealizedPnL: closeResult.RealizedPnL.InexactFloat64(),
+					Balance:     balanceD.InexactFloat64(),
+					Reason:      reason,
+				})
+
+				// ⭐ 盈利平倉釋放資金後逐步回收注資（IncrementalFundingRecovery）
+				if closeResult.RealizedPnL.GreaterThan(decimal.Zero) {
+					if recoveryAmountD := e.incrementalRecoveryAmount(balanceD); recoveryAmountD.GreaterThan(decimal.Zero) {
+						balanceD = balanceD.Sub(e.recoverFunding(recoveryAmountD, currentTime))
+						e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
+					}
+				}
 
 				// ⭐ 更新正常關倉計數
 				e.currentRoundStats.NormalCloseCount++
 				e.currentRoundStats.TotalFeesInRound += closeResult.CloseFee.InexactFloat64()
 
 				// ⭐ 檢查是否所有倉位被關閉（交易輪次結束）
-				if openPositionValueD.LessThanOrEqual(decimal.NewFromFloat(0.01)) {
+				if isFlat(openPositionValueD) {
 					openPositionValueD = decimal.Zero
-					currentRoundRealizedPnLD = decimal.Zero // 重置，開始新的交易輪次
-					currentRoundClosedValueD = decimal.Zero // 重置關倉價值⭐
+					e.currentRoundStats.ExitReason = metrics.ReasonHitTarget
+					e.recordFundedRoundProfit(e.currentRoundRealizedPnLD) // ⭐ 注資效率統計
+					e.onRoundComplete(e.currentRoundStats.RoundID, e.currentRoundStats, BacktestEvent{
+						Time:        currentTime,
+						CandleIndex: i,
+						Price:       closeResult.ClosePrice,
+						Balance:     balanceD.InexactFloat64(),
+						Reason:      reason,
+					})
 				}
 			}
 		}
@@ -372,18 +940,21 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 		// ========== 步驟 2: 調用策略獲取開倉建議 ==========
 		// 使用當前價格和歷史K線（currentPrice 已經是 Price 對象）
 
-		// 構建歷史K線（最多100根）
-		startIdx := 0
-		if i > 100 {
-			startIdx = i - 100
-		}
-		histories := candles[startIdx:i]
+		// 構建歷史K線（最多 historyWindow 根）
+		histories := feed.history()
 
 		// 獲取上一根K線（如果存在）
-		var lastCandle value_objects.Candle
-		if i > 0 {
-			lastCandle = candles[i-1]
-		} else {
+		lastCandle, ok := feed.previous()
+
+		// ⭐ 跳過的未完成K線也不進入歷史和上一根K線
+		if e.config.RequireConfirmedCandles {
+			histories = confirmedOnly(histories)
+			ok = len(histories) > 0
+			if ok {
+				lastCandle = histories[len(histories)-1]
+			}
+		}
+		if !ok {
 			lastCandle = currentCandle
 		}
 
@@ -400,38 +971,84 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 		// ⭐ 計算未實現盈虧（通過 PositionTracker，已包含預估平倉費）
 		unrealizedPnL := e.positionTracker.CalculateUnrealizedPnL(currentPrice.Value(), e.config.FeeRate)
 
-		// 創建倉位摘要（包含當前輪次已實現盈虧和關倉價值）⭐
+		// 創建倉位摘要（包含當前輪次已實現盈虧、關倉價值和未平倉位開倉價）⭐
 		positionSummary := value_objects.NewPositionSummary(
 			openCount,
 			totalSize,
 			avgCost,
 			totalFeesPaid,
-			currentRoundRealizedPnLD.InexactFloat64(), // ⭐ 傳入當前輪次已實現盈虧
-			currentRoundClosedValueD.InexactFloat64(), // ⭐ 傳入當前輪次累積關倉價值
-			unrealizedPnL,                             // ⭐ 傳入外部計算的未實現盈虧
-		)
+			e.currentRoundRealizedPnLD.InexactFloat64(), // ⭐ 傳入當前輪次已實現盈虧
+			e.currentRoundClosedValueD.InexactFloat64(), // ⭐ 傳入當前輪次累積關倉價值
+			unrealizedPnL, // ⭐ 傳入外部計算的未實現盈虧
+		).WithEntryPrices(e.positionTracker.OpenEntryPrices()).
+			WithFundingFee(e.currentRoundFundingFeeD.InexactFloat64()) // ⭐ 打平目標扣除本輪資金費
+
+		// ========== 步驟 2.6: 回撤熔斷檢查（MaxDrawdownHalt）⭐ ==========
+		equity := balanceD.Add(openPositionValueD).InexactFloat64() + unrealizedPnL - e.pendingFunding
+		e.calculator.RecordEquity(currentTime, currentPrice.Value(), equity, openPositionValueD.InexactFloat64())
+		drawdown := e.updateDrawdown(equity)
+		if e.checkDrawdownHalt(currentTime, equity, drawdown) && e.config.HaltForceClose {
+			closeAllPositions(currentPrice.Value(), currentTime, i, metrics.ReasonDrawdownHalt)
+			e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
+		}
+
+		// ========== 步驟 2.7: 保本止損檢查（BreakEvenStop）⭐ ==========
+		stopTriggered, stopPrice := e.checkBreakEvenStop(currentCandle, e.currentRoundRealizedPnLD.InexactFloat64(), unrealizedPnL)
 
 		// 獲取開倉建議（grid.OpenAdvice）⭐ 傳入倉位摘要和當前K線
 		gridAdvice := e.strategy.GetOpenAdvice(currentPrice, currentCandle, lastCandle, histories, positionSummary)
+		if e.Halted() && gridAdvice.ShouldOpen {
+			// ⭐ 熔斷後不再開倉
+			gridAdvice.ShouldOpen = false
+			gridAdvice.Reason = e.haltReason
+		}
+		// ========== 步驟 2.75: 輪次時間止損（MaxRoundCandles）⭐ ==========
+		// 本根K線已觸發打平 / 整輪止盈時按原因退出，不計為時間止損
+		timeStopTriggered := false
+		if !stopTriggered && !gridAdvice.ExitRound {
+			if timeStopTriggered = e.checkRoundTimeStop(currentTime); timeStopTriggered {
+				gridAdvice.ShouldOpen = false
+				gridAdvice.Reason = fmt.Sprintf("%s: elapsed=%s (limit: %d candles)",
+					metrics.ReasonRoundTimeStop, currentTime.Sub(e.currentRoundStats.StartTime), e.config.MaxRoundCandles)
+			}
+		}
+		if stopTriggered {
+			// ⭐ 保本止損觸發：本根K線不開倉，按打平流程在止損價平掉所有倉位並結束輪次
+			gridAdvice.ShouldOpen = false
+			gridAdvice.Reason = fmt.Sprintf("%s: stop=%.2f", metrics.ReasonBreakEvenStop, stopPrice)
+		}
 
 		// ========== 步驟 2.8: 檢查是否觸發打平機制 ⭐ ==========
-		// 即使不應該開倉，也要檢查是否因為打平退出
-		if !gridAdvice.ShouldOpen && len(gridAdvice.Reason) >= 16 &&
-			gridAdvice.Reason[:16] == "break_even_exit:" {
+		// 即使不應該開倉，也要檢查是否因為打平退出（整輪止盈退出按同一流程平掉所有倉位）
+		isBreakEvenExit := stopTriggered || timeStopTriggered || gridAdvice.ExitRound
+		if isBreakEvenExit {
 			// ⭐ 觸發打平機制：平掉所有未平倉位
 			// ⭐ 重要：先複製倉位列表，避免在循環中修改導致跳過某些倉位
 			positionsToClose := make([]simulator.Position, len(e.positionTracker.GetOpenPositions()))
 			copy(positionsToClose, e.positionTracker.GetOpenPositions())
 
 			// ⭐ 記錄本輪打平前的狀態
-			beforeCloseRealizedPnL := currentRoundRealizedPnLD.InexactFloat64()
+			beforeCloseRealizedPnL := e.currentRoundRealizedPnLD.InexactFloat64()
 			beforeCloseUnrealizedPnL := unrealizedPnL
 
-			for _, pos := range positionsToClose {
+			// ⭐ 退出原因分類：整輪止盈、時間止損與打平分開計數
+			exitReason := metrics.ReasonCategory(gridAdvice.Reason)
+
+			// ⭐ 合併模式：所有倉位的平倉合併為一筆 CLOSE 記錄
+			aggregate := e.config.BreakEvenCloseMode == BreakEvenCloseAggregate
+			var agg aggregateClose
+
+			// ⭐ 成交價：打平按 FillPriceModel（默認收盤價），保本止損按止損價
+			exitPrice := e.config.FillPriceModel.breakEvenFillPrice(currentCandle)
+			if stopTriggered {
+				exitPrice = stopPrice
+			}
+
+			for idx, pos := range positionsToClose {
 				// ⭐ 使用提取的辅助函数执行平仓
 				closeResult, err := e.executeClose(
 					pos,
-					currentPrice.Value(),
+					exitPrice,
 					currentTime,
 					avgCostAtThisTime,
 				)
@@ -444,44 +1061,75 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 				totalProfitGrossD = totalProfitGrossD.Add(closeResult.ProfitGross)
 				totalProfitGross_EntryD = totalProfitGross_EntryD.Add(closeResult.ProfitGross_Entry)
 				totalFeesCloseD = totalFeesCloseD.Add(closeResult.CloseFee)
+				totalSlippageCostD = totalSlippageCostD.Add(closeResult.SlippageCost)
 				openPositionValueD = openPositionValueD.Sub(closeResult.PositionSize)
-				currentRoundRealizedPnLD = currentRoundRealizedPnLD.Add(closeResult.RealizedPnL)
-				currentRoundClosedValueD = currentRoundClosedValueD.Add(closeResult.ClosedValue)
+				e.currentRoundRealizedPnLD = e.currentRoundRealizedPnLD.Add(closeResult.RealizedPnL)
+				e.currentRoundClosedValueD = e.currentRoundClosedValueD.Add(closeResult.ClosedValue)
 				totalRealizedPnLD = totalRealizedPnLD.Add(closeResult.RealizedPnL)
 
-				// 記錄資金快照
-				e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
+				// ⭐ 逐倉模式每個倉位記錄一筆；合併模式在最後一個倉位（或輪次結束）時記錄一筆
+				fill := positionFill(pos, closeResult)
+				shouldRecord := true
+				if aggregate {
+					agg.add(closeResult)
+					fill = agg.fill()
+					shouldRecord = idx == len(positionsToClose)-1 || isFlat(openPositionValueD)
+				}
 
-				// 記錄交易日誌
-				tradeCounter++
-				e.tradeLog = append(e.tradeLog, TradeLog{
-					TradeID:                 tradeCounter,
-					Time:                    currentTime,
-					Action:                  "CLOSE",
-					Price:                   closeResult.ClosePrice,
-					PositionSize:            closeResult.ClosedValue.InexactFloat64(),
-					Balance:                 balanceD.InexactFloat64(),
-					OpenPositionValue:       openPositionValueD.InexactFloat64(),
-					PnLPercent:              closeResult.PnLPercent,
-					PnL:                     closeResult.PnL,
-					AvgCost:                 avgCostAtThisTime,
-					PnLPercent_Avg:          closeResult.PnLPercent_Avg,
-					PnL_Avg:                 closeResult.PnL_Avg,
-					Fee:                     closeResult.CloseFee.InexactFloat64(),
-					RoundClosedValue:        currentRoundClosedValueD.InexactFloat64(),
-					CurrentRoundRealizedPnL: currentRoundRealizedPnLD.InexactFloat64(),
-					TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),
-					UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(currentPrice.Value(), e.config.FeeRate),
-					Reason:                  gridAdvice.Reason,
-					PositionID:              pos.ID,
-				})
+				if shouldRecord {
+					// 記錄資金快照
+					e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
+
+					// 記錄交易日誌
+					tradeCounter++
+					e.logTrade(TradeLog{
+						TradeID:                 tradeCounter,
+						Time:                    currentTime,
+						Action:                  "CLOSE",
+						Price:                   fill.Price,
+						PositionSize:            fill.ClosedValue,
+						Balance:                 balanceD.InexactFloat64(),
+						OpenPositionValue:       openPositionValueD.InexactFloat64(),
+						PnLPercent:              fill.PnLPercent,
+						PnL:                     fill.PnL,
+						AvgCost:                 avgCostAtThisTime,
+						PnLPercent_Avg:          fill.PnLPercent_Avg,
+						PnL_Avg:                 fill.PnL_Avg,
+						Fee:                     fill.Fee,
+						RoundClosedValue:        e.currentRoundClosedValueD.InexactFloat64(),
+						CurrentRoundRealizedPnL: e.currentRoundRealizedPnLD.InexactFloat64(),
+						TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),
+						UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(currentPrice.Value(), e.config.FeeRate),
+						Reason:                  gridAdvice.Reason,
+						PositionID:              fill.PositionID,
+					})
+					e.eventSink.Emit(BacktestEvent{
+						Type:        EventPositionClosed,
+						Time:        currentTime,
+						CandleIndex: i,
+						PositionID:  fill.PositionID,
+						Price:       fill.Price,
+						Size:        fill.Size,
+						Fee:         fill.Fee,
+						RealizedPnL: fill.RealizedPnL,
+						Balance:     balanceD.InexactFloat64(),
+						Reason:      gridAdvice.Reason,
+					})
+				}
 
 				// ⭐ 打平机制特有：更新当前轮次统计
-				e.currentRoundStats.BreakEvenCloseCount++
+				switch exitReason {
+				case metrics.ReasonRoundProfitExit:
+					e.currentRoundStats.ProfitExitCloseCount++
+				case metrics.ReasonRoundTimeStop:
+					e.currentRoundStats.TimeStopCloseCount++
+				default:
+					e.currentRoundStats.BreakEvenCloseCount++
+				}
 				e.currentRoundStats.TotalFeesInRound += closeResult.CloseFee.InexactFloat64()
 
 				// ⭐ 檢查是否所有倉位被關閉（交易輪次結束）
-				if openPositionValueD.LessThanOrEqual(decimal.NewFromFloat(0.01)) {
+				if isFlat(openPositionValueD) {
 					openPositionValueD = decimal.Zero
 
 					// ⭐ 記錄打平輪次（在重置前）
@@ -489,61 +1137,77 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 						e.currentRoundStats.StartTime = currentTime // 首次設置開始時間
 					}
 
+					e.currentRoundStats.ExitReason = exitReason
 					round := BreakEvenRound{
-						RoundID:             e.currentRoundStats.RoundID,
-						StartTime:           e.currentRoundStats.StartTime,
-						EndTime:             currentTime,
-						Duration:            currentTime.Sub(e.currentRoundStats.StartTime).String(),
-						TotalOpenCount:      e.currentRoundStats.OpenCount,
-						NormalCloseCount:    e.currentRoundStats.NormalCloseCount,     // 正常止盈關倉數 ⭐
-						BreakEvenCloseCount: e.currentRoundStats.BreakEvenCloseCount,  // 打平強制關倉數 ⭐
-						TotalCloseCount:     e.currentRoundStats.GetTotalCloseCount(), // 總關倉數 ⭐
-						RealizedPnL:         beforeCloseRealizedPnL,                   // 打平前的已實現盈虧
-						UnrealizedPnL:       beforeCloseUnrealizedPnL,                 // 打平前的未實現盈虧
-						ExpectedProfit:      beforeCloseRealizedPnL + beforeCloseUnrealizedPnL,
-						TotalFees:           e.currentRoundStats.TotalFeesInRound,
-						TriggerPrice:        currentPrice.Value(),
-						AvgCost:             avgCostAtThisTime,
+						RoundID:              e.currentRoundStats.RoundID,
+						StartTime:            e.currentRoundStats.StartTime,
+						EndTime:              currentTime,
+						Duration:             currentTime.Sub(e.currentRoundStats.StartTime).String(),
+						ExitReason:           exitReason,
+						TotalOpenCount:       e.currentRoundStats.OpenCount,
+						NormalCloseCount:     e.currentRoundStats.NormalCloseCount,     // 正常止盈關倉數 ⭐
+						BreakEvenCloseCount:  e.currentRoundStats.BreakEvenCloseCount,  // 打平強制關倉數 ⭐
+						ProfitExitCloseCount: e.currentRoundStats.ProfitExitCloseCount, // 整輪止盈關倉數 ⭐
+						TimeStopCloseCount:   e.currentRoundStats.TimeStopCloseCount,   // 時間止損關倉數 ⭐
+						TotalCloseCount:      e.currentRoundStats.GetTotalCloseCount(), // 總關倉數 ⭐
+						RealizedPnL:          beforeCloseRealizedPnL,                   // 打平前的已實現盈虧
+						UnrealizedPnL:        beforeCloseUnrealizedPnL,                 // 打平前的未實現盈虧
+						ExpectedProfit:       beforeCloseRealizedPnL + beforeCloseUnrealizedPnL,
+						TotalFees:            e.currentRoundStats.TotalFeesInRound,
+						TriggerPrice:         currentPrice.Value(),
+						AvgCost:              avgCostAtThisTime,
 					}
 					e.breakEvenRounds = append(e.breakEvenRounds, round)
 
-					// ⭐ 打平退出時回收注資（如果有待回收的注資）
-					if e.pendingFunding > 0 {
-						recoveryAmountD := decimal.NewFromFloat(e.pendingFunding)
-						balanceD = balanceD.Sub(recoveryAmountD) // 扣除注資金額（相當於取回）
+					// ⭐ 輪次確實平掉後才計為時間止損（平倉失敗時下一根K線會再次觸發，不能重複計數）
+					if exitReason == metrics.ReasonRoundTimeStop {
+						e.roundTimeStops++
+					}
 
-						// 更新注資記錄狀態
-						for i := len(e.fundingHistory) - 1; i >= 0; i-- {
-							if !e.fundingHistory[i].Recovered {
-								e.fundingHistory[i].Recovered = true
-								e.fundingHistory[i].RecoveredAt = currentTime
-							}
-						}
+					// ⭐ 注資效率統計（在回收注資之前，與止盈結束輪次一致）
+					e.recordFundedRoundProfit(e.currentRoundRealizedPnLD)
 
-						// 清空待回收注資
-						e.pendingFunding = 0
+					// ⭐ 打平退出時回收注資（如果有待回收的注資）
+					// 時間止損是認賠出場，餘額不一定回到注資前水平：與止盈結束輪次一樣不全額回收，注資留待後續回收
+					if exitReason != metrics.ReasonRoundTimeStop && e.pendingFunding > 0 {
+						// 全額回收，更新注資記錄狀態並清空待回收注資
+						recoveryAmountD := e.recoverFunding(decimal.NewFromFloat(e.pendingFunding), currentTime)
+						balanceD = balanceD.Sub(recoveryAmountD) // 扣除注資金額（相當於取回）
 
 						// 記錄資金快照（重要：讓計算器知道資金減少了）
 						e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
 					}
 
-					// 重置輪次數據
-					currentRoundRealizedPnLD = decimal.Zero // 重置，開始新的交易輪次
-					currentRoundClosedValueD = decimal.Zero // 重置關倉價值⭐
-					e.currentRoundStats = RoundStats{
-						RoundID:   e.currentRoundStats.RoundID + 1,
-						StartTime: time.Time{}, // 重置，下次開倉時會設置
-					}
+					e.onRoundComplete(e.currentRoundStats.RoundID, e.currentRoundStats, BacktestEvent{
+						Time:        currentTime,
+						CandleIndex: i,
+						Price:       currentPrice.Value(),
+						Balance:     balanceD.InexactFloat64(),
+						Reason:      gridAdvice.Reason,
+						Round:       &round,
+					})
 				}
 			}
 		}
 
+		// ========== 步驟 2.9: 記錄拒絕開倉原因（LogRejectedAdvice）⭐ ==========
+		if !gridAdvice.ShouldOpen && !isBreakEvenExit {
+			e.recordRejectedAdvice(currentTime, currentPrice.Value(), gridAdvice.Reason)
+		}
+
 		// ========== 步驟 3: 如果建議開倉，模擬開倉 ==========
 		if gridAdvice.ShouldOpen {
+			// ⭐ 回撤降倉：按當前回撤縮小開倉大小
+			if multiplier := e.throttleMultiplier(drawdown); multiplier != 1 {
+				gridAdvice.PositionSize *= multiplier
+				gridAdvice.Reason = fmt.Sprintf("%s (drawdown_throttle: drawdown=%.2f%% size=x%g)", gridAdvice.Reason, drawdown*100, multiplier)
+			}
+
 			// 檢查餘額是否充足
-			estimatedCostD := decimal.NewFromFloat(gridAdvice.PositionSize).Mul(decimal.NewFromFloat(1 + e.config.FeeRate))
+			estimatedCostD := e.simulator.EstimateOpenCost(gridAdvice.PositionSize)
 
-			if balanceD.GreaterThanOrEqual(estimatedCostD) {
+			// ⭐ 限價單成交假設：下一根K線沒有到達限價時撤銷開倉（默認 always 不檢查）
+			if balanceD.GreaterThanOrEqual(estimatedCostD) && e.checkLimitFill(currentCandle, feed, gridAdvice.OpenPrice) {
 				// 轉換為 simulator.OpenAdvice
 				advice := simulator.OpenAdvice{
 					ShouldOpen:   gridAdvice.ShouldOpen,
@@ -553,6 +1217,7 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 					PositionSize: gridAdvice.PositionSize,
 					TakeProfit:   gridAdvice.TakeProfitRate,
 					Reason:       gridAdvice.Reason,
+					Tag:          gridAdvice.Tag,
 				}
 
 				// 模擬開倉
@@ -562,25 +1227,35 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 					continue
 				}
 
-				// 計算開倉手續費（使用 decimal）
-				openFeeD := decimal.NewFromFloat(position.Size).Mul(decimal.NewFromFloat(e.config.FeeRate))
+				// 計算開倉手續費（使用 decimal，不低於最低手續費）
+				openFeeD := e.simulator.Fee(decimal.NewFromFloat(position.Size))
 
 				// 更新倉位追蹤器
-				newPosition := e.positionTracker.AddPosition(
+				newPosition, err := e.positionTracker.AddPositionWithTag(
 					position.EntryPrice,
 					position.Size,
+					position.Coins, // ⭐ base 手續費模式下為扣費後的幣數
 					position.OpenTime,
 					position.TargetClosePrice,
+					position.Tag, // ⭐ 開倉標籤
 				)
+				if err != nil {
+					// 倉位數據無效，跳過（不扣除餘額）
+					continue
+				}
+				e.recordOpenCandle(newPosition.ID, i) // ⭐ 最短持倉計數起點
 
 				// 更新餘額（使用 decimal）
 				costD := decimal.NewFromFloat(cost)
 				balanceD = balanceD.Sub(costD)
 
 				// ⭐ 累加統計數據（使用 decimal）
-				totalOpenedTrades++                    // 累加開倉數量
+				totalOpenedTrades++                           // 累加開倉數量
 				totalFeesOpenD = totalFeesOpenD.Add(openFeeD) // 累加開倉手續費
 
+				// ⭐ 累加開倉滑點成本
+				totalSlippageCostD = totalSlippageCostD.Add(e.simulator.OpenSlippageCost(position))
+
 				// ⭐ 更新當前交易輪次數據（使用 decimal）
 				positionSizeD := decimal.NewFromFloat(position.Size)
 				openPositionValueD = openPositionValueD.Add(positionSizeD) // 增加累計持倉價值
@@ -603,7 +1278,7 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 
 				// ⭐ 記錄開倉日誌
 				tradeCounter++
-				e.tradeLog = append(e.tradeLog, TradeLog{
+				e.logTrade(TradeLog{
 					TradeID:                 tradeCounter,
 					Time:                    currentTime,
 					Action:                  "OPEN",
@@ -614,13 +1289,24 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 					AvgCost:                 avgCostAfterOpen,                    // ⭐ 開倉後的平均成本
 					PnL:                     0,
 					Fee:                     openFeeD.InexactFloat64(),                                                        // ⭐ 記錄開倉手續費
-					RoundClosedValue:        currentRoundClosedValueD.InexactFloat64(),                                        // ⭐ 本輪累積關倉總價值
-					CurrentRoundRealizedPnL: currentRoundRealizedPnLD.InexactFloat64(),                                        // ⭐ 本輪已實現盈虧
+					RoundClosedValue:        e.currentRoundClosedValueD.InexactFloat64(),                                      // ⭐ 本輪累積關倉總價值
+					CurrentRoundRealizedPnL: e.currentRoundRealizedPnLD.InexactFloat64(),                                      // ⭐ 本輪已實現盈虧
 					TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),                                               // ⭐ 累計已實現盈虧
 					UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(currentPrice.Value(), e.config.FeeRate), // ⭐ 統一使用 PositionTracker
 					Reason:                  gridAdvice.Reason,
 					PositionID:              newPosition.ID, // ⭐ 記錄倉位ID
 				})
+				e.eventSink.Emit(BacktestEvent{
+					Type:        EventPositionOpened,
+					Time:        currentTime,
+					CandleIndex: i,
+					PositionID:  newPosition.ID,
+					Price:       position.EntryPrice,
+					Size:        position.Size,
+					Fee:         openFeeD.InexactFloat64(),
+					Balance:     balanceD.InexactFloat64(),
+					Reason:      gridAdvice.Reason,
+				})
 			}
 		}
 
@@ -636,21 +1322,28 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 		}
 
 		// ⭐ 自動注資機制檢查（每根K線結束時）
-		if e.config.EnableAutoFunding {
+		if e.config.EnableAutoFunding && !e.Halted() {
 			// 增加閒置計數（無論是否開倉）
 			e.idleCandles++
 
 			// 檢查是否達到注資閾值
-			if e.idleCandles >= e.config.AutoFundingIdle {
+			// ⭐ 計算注資金額（按模式）
+			fundingAmountD := e.calculateFundingAmount(openPositionValueD)
+
+			if e.idleCandles >= e.config.AutoFundingIdle && fundingAmountD.LessThanOrEqual(decimal.Zero) {
+				// 比例模式下沒有持倉，無需注資
+				e.idleCandles = 0
+			} else if e.idleCandles >= e.config.AutoFundingIdle {
+				fundingAmount := fundingAmountD.InexactFloat64()
+
 				// 記錄注資前狀態
 				balanceBefore := balanceD.InexactFloat64()
 
 				// 執行注資（使用 decimal）
-				fundingAmountD := decimal.NewFromFloat(e.config.AutoFundingAmount)
 				balanceD = balanceD.Add(fundingAmountD)
 
 				// ⭐ 增加待回收注資金額
-				e.pendingFunding += e.config.AutoFundingAmount
+				e.pendingFunding += fundingAmount
 
 				// ⭐⭐ 更新最大待回收注資峰值
 				if e.pendingFunding > e.maxPendingFunding {
@@ -660,7 +1353,7 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 				// 記錄注資事件
 				fundingRecord := FundingRecord{
 					Time:          currentTime,
-					Amount:        e.config.AutoFundingAmount,
+					Amount:        fundingAmount, // 實際注資金額（比例模式下按持倉價值計算）⭐
 					IdleCandles:   e.idleCandles,
 					BalanceBefore: balanceBefore,
 					BalanceAfter:  balanceD.InexactFloat64(),
@@ -669,6 +1362,14 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 					Recovered:     false, // 初始未回收 ⭐
 				}
 				e.fundingHistory = append(e.fundingHistory, fundingRecord)
+				e.eventSink.Emit(BacktestEvent{
+					Type:        EventFundingInjected,
+					Time:        currentTime,
+					CandleIndex: i,
+					Price:       currentPrice.Value(),
+					Balance:     balanceD.InexactFloat64(),
+					Funding:     &fundingRecord,
+				})
 
 				// 重置閒置計數器
 				e.idleCandles = 0
@@ -677,46 +1378,59 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 				e.calculator.RecordBalance(currentTime, balanceD.InexactFloat64())
 			}
 		}
+
+		// ⭐ 定期盯市記錄（MarkInterval，不佔用交易序號）
+		if e.shouldMark(i) {
+			e.logTrade(TradeLog{
+				Time:                    currentTime,
+				Action:                  "MARK",
+				Price:                   currentPrice.Value(),
+				Balance:                 balanceD.InexactFloat64(),
+				OpenPositionValue:       openPositionValueD.InexactFloat64(),
+				AvgCost:                 e.positionTracker.CalculateAverageCost(),
+				RoundClosedValue:        e.currentRoundClosedValueD.InexactFloat64(),
+				CurrentRoundRealizedPnL: e.currentRoundRealizedPnLD.InexactFloat64(),
+				TotalRealizedPnL:        totalRealizedPnLD.InexactFloat64(),
+				UnrealizedPnL:           e.positionTracker.CalculateUnrealizedPnL(currentPrice.Value(), e.config.FeeRate),
+				Reason:                  "mark_to_market",
+			})
+		}
 	}
 
 	// ========== 步驟 4: 回測結束，強制平倉所有未平倉位 ⭐ ==========
-	lastCandle := candles[len(candles)-1]
-	lastPrice := lastCandle.Close().Value()
-	lastTime := lastCandle.Timestamp()
-
-	// 強制平倉所有未平倉位（使用最後收盤價）
-	// finalAvgCost := e.positionTracker.CalculateAverageCost()
-	// finalPositionsToClose := make([]simulator.Position, len(e.positionTracker.GetOpenPositions()))
-	// copy(finalPositionsToClose, e.positionTracker.GetOpenPositions())
-
-	// for _, pos := range finalPositionsToClose {
-	// 	err := e.executeClose(
-	// 		pos,
-	// 		lastPrice, // 使用最後收盤價平倉
-	// 		lastTime,
-	// 		finalAvgCost,
-	// 		"backtest_end_force_close", // 回測結束強制平倉
-	// 		&balance,
-	// 		&totalProfitGross,
-	// 		&totalProfitGross_Entry,
-	// 		&totalFeesClose,
-	// 		&openPositionValue,
-	// 		&currentRoundRealizedPnL,
-	// 		&currentRoundClosedValue,
-	// 		&totalRealizedPnL,
-	// 		&tradeCounter,
-	// 	)
-	// 	if err != nil {
-	// 		continue
-	// 	}
-	// 	// 標記為強制平倉（不計入正常止盈）
-	// 	e.currentRoundStats.BreakEvenCloseCount++
-	// }
+	lastPrice := lastProcessed.Close().Value() // 取消時使用最後處理的K線
+	lastTime := lastProcessed.Timestamp()
+
+	// ⭐ 全部處理完時回報 100%
+	if e.progressFunc != nil && runErr == nil {
+		e.progressFunc(processed, processed)
+	}
+
+	// ⭐ 保存最後一個斷點（取消時可從 processed 繼續；未設置斷點且正常跑完時跳過序列化）
+	if e.checkpointsEnabled() || runErr != nil {
+		if _, err := e.saveCheckpoint(captureState(processed)); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+
+	// ⭐ 強制平倉所有未平倉位（ForceCloseAtEnd，使用最後收盤價）
+	// 只在完整跑完時執行，並在保存斷點之後：斷點保留平倉前的持倉，恢復後可接續更多K線
+	if e.config.ForceCloseAtEnd && runErr == nil {
+		closeAllPositions(lastPrice, lastTime, processed-1, metrics.ReasonBacktestEnd)
+	}
 
 	// 記錄最終資金快照
 	e.calculator.RecordBalance(lastTime, balanceD.InexactFloat64())
 
+	// ⭐ 手續費對賬：交易日誌與 decimal 賬本必須一致
+	feeLedgerDiff, err := e.reconcileFees(totalFeesOpenD, totalFeesCloseD)
+	if err != nil && runErr == nil {
+		runErr = err
+	}
+
 	// ========== 步驟 5: 計算回測指標（包含未實現盈虧）==========
+	e.calculator.SetFunding(e.maxPendingFunding, e.pendingFunding)  // ⭐ 收益率基數使用的注資數額
+	e.calculator.SetFundingFee(e.totalFundingFeeD.InexactFloat64()) // ⭐ 永續合約資金費
 	result := e.calculator.Calculate(
 		e.positionTracker,
 		balanceD.InexactFloat64(),
@@ -731,14 +1445,30 @@ func (e *BacktestEngine) Run(candles []value_objects.Candle) (metrics.BacktestRe
 	// ⭐ 加入持倉全滿天數統計
 	result.FullPositionDays = len(fullPositionDays)
 	result.MaxOpenPositionValue = maxOpenPositionValueD.InexactFloat64() // ⭐ 加入最大持倉價值
+	result.PnLByReason = e.reasonAttribution.Result()                    // ⭐ 按關倉原因歸因盈虧
+	result.HaltedAt = e.haltedAt                                         // ⭐ 回撤熔斷
+	result.HaltReason = e.haltReason
+	result.SlippageCost = totalSlippageCostD.InexactFloat64() // ⭐ 滑點成本
+	result.RoundTimeStops = e.roundTimeStops                  // ⭐ 輪次時間止損
+	result.FeeLedgerDiff = feeLedgerDiff                      // ⭐ 手續費對賬差額
+
+	// ⭐ 按開倉標籤歸因盈虧
+	result.PnLByTag = metrics.PnLByTag(e.positionTracker.GetClosedPositions())
+
+	// ⭐ 按平倉時的波動率區間歸因盈虧
+	result.PnLByVolatilityRegime = metrics.PnLByVolatilityRegime(
+		e.positionTracker.GetClosedPositions(),
+		e.calculator.GetEquitySnapshots(),
+		metrics.DefaultVolatilityWindow,
+	)
 
-	// ⭐ 輸出打平輪次統計報告
-	e.printBreakEvenRoundsReport()
+	// ⭐ 打平輪次彙總（供掃參、JSON 導出和結果比較使用）
+	result.Rounds = summarizeRounds(e.breakEvenRounds)
 
-	// ⭐ 輸出自動注資統計報告
-	e.printFundingReport()
+	// ⭐ 輸出打平輪次和自動注資統計報告（VerbosityVerbose 時）
+	e.logReports()
 
-	return result, nil
+	return result, runErr
 }
 
 // RunFromFile 從文件執行回測
@@ -771,18 +1501,21 @@ func (e *BacktestEngine) GetMetricsCalculator() *metrics.MetricsCalculator {
 	return e.calculator
 }
 
+// GetSkippedUnconfirmedCount 獲取因未完成而跳過的K線數量
+func (e *BacktestEngine) GetSkippedUnconfirmedCount() int {
+	return e.skippedUnconfirmed
+}
+
 // GetTradeLog 獲取交易日誌（用於 debug）
 func (e *BacktestEngine) GetTradeLog() []TradeLog {
 	return e.tradeLog
 }
 
 // GetTotalFees 計算總手續費
+//
+// 交易日誌每記錄一筆時累計（串流交易日誌時同樣有效）
 func (e *BacktestEngine) GetTotalFees() float64 {
-	totalFees := 0.0
-	for _, log := range e.tradeLog {
-		totalFees += log.Fee
-	}
-	return totalFees
+	return e.tradeLogFees
 }
 
 // ExportRoundsToCSV 導出打平輪次詳細記錄到 CSV 文件 ⭐
@@ -810,6 +1543,8 @@ func (e *BacktestEngine) ExportRoundsToCSV(filePath string) error {
 		"TotalOpenCount",
 		"NormalCloseCount",
 		"BreakEvenCloseCount",
+		"ProfitExitCloseCount",
+		"TimeStopCloseCount",
 		"TotalCloseCount",
 		"RealizedPnL",
 		"UnrealizedPnL",
@@ -817,6 +1552,7 @@ func (e *BacktestEngine) ExportRoundsToCSV(filePath string) error {
 		"TotalFees",
 		"TriggerPrice",
 		"AvgCost",
+		"ExitReason",
 		"Status",
 	}
 	if err := writer.Write(header); err != nil {
@@ -838,6 +1574,8 @@ func (e *BacktestEngine) ExportRoundsToCSV(filePath string) error {
 			fmt.Sprintf("%d", round.TotalOpenCount),
 			fmt.Sprintf("%d", round.NormalCloseCount),
 			fmt.Sprintf("%d", round.BreakEvenCloseCount),
+			fmt.Sprintf("%d", round.ProfitExitCloseCount),
+			fmt.Sprintf("%d", round.TimeStopCloseCount),
 			fmt.Sprintf("%d", round.TotalCloseCount),
 			fmt.Sprintf("%.2f", round.RealizedPnL),
 			fmt.Sprintf("%.2f", round.UnrealizedPnL),
@@ -845,6 +1583,7 @@ func (e *BacktestEngine) ExportRoundsToCSV(filePath string) error {
 			fmt.Sprintf("%.2f", round.TotalFees),
 			fmt.Sprintf("%.2f", round.TriggerPrice),
 			fmt.Sprintf("%.2f", round.AvgCost),
+			round.ExitReason,
 			status,
 		}
 		if err := writer.Write(row); err != nil {
@@ -855,29 +1594,48 @@ func (e *BacktestEngine) ExportRoundsToCSV(filePath string) error {
 	return nil
 }
 
-// ExportTradeLogCSV 導出交易日誌到 CSV 文件
+// ExportBalanceSnapshotsCSV 導出資金快照和水下曲線（最高資金、瞬時回撤）到 CSV 文件 ⭐
+func (e *BacktestEngine) ExportBalanceSnapshotsCSV(filePath string) error {
+	return e.calculator.ExportSnapshotsCSV(filePath)
+}
+
+// ExportTradeLogCSV 導出交易日誌到 CSV 文件（默認精度）
 func (e *BacktestEngine) ExportTradeLogCSV(filepath string) error {
+	return e.ExportTradeLogCSVWithPrecision(filepath, DefaultCSVPrecision())
+}
+
+// ExportTradeLogCSVWithPrecision 按指定小數位數導出交易日誌到 CSV 文件 ⭐
+//
+// 低價幣建議使用 CSVPrecisionFromTickSize 推導精度，避免有效數字丟失
+func (e *BacktestEngine) ExportTradeLogCSVWithPrecision(filepath string, precision CSVPrecision) error {
 	content := "TradeID,Time,Action,Price,PositionSize,Balance,OpenPositionValue,PnL%,PnL,AvgCost,PnL%_Avg,PnL_Avg,Fee,RoundClosedValue,CurrentRoundRealizedPnL,TotalRealizedPnL,UnrealizedPnL,Reason,PositionID\n"
 
+	price := fmt.Sprintf("%%.%df", precision.Price)
+	value := fmt.Sprintf("%%.%df", precision.Value)
+	percent := fmt.Sprintf("%%.%df", precision.Percent)
+	fee := fmt.Sprintf("%%.%df", precision.Fee)
+	format := "%d,%s,%s," + price + "," + value + "," + value + "," + value + "," + percent + "," + value + "," +
+		price + "," + percent + "," + value + "," + fee + "," + value + "," + value + "," + value + "," + value + ",%s,%s\n"
+
 	for _, log := range e.tradeLog {
-		line := fmt.Sprintf("%d,%s,%s,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f,%.8f,%.6f,%.6f,%.6f,%.6f,%s,%s\n",
+		line := fmt.Sprintf(format,
 			log.TradeID,
 			log.Time.UTC().Format("2006-01-02 15:04:05"), // ⭐ 使用 UTC 時間（GMT+0）
 			log.Action,
-			log.Price,                   // 價格：6位小數
-			log.PositionSize,            // 倉位大小：6位小數
-			log.Balance,                 // 餘額：6位小數
-			log.OpenPositionValue,       // ⭐ 累計持倉總價值：6位小數
-			log.PnLPercent,              // ⭐ 盈虧百分比（基於單筆）：6位小數
-			log.PnL,                     // ⭐ 盈虧金額（基於單筆）：6位小數 ⭐
-			log.AvgCost,                 // ⭐ 平均成本：6位小數 ⭐
-			log.PnLPercent_Avg,          // ⭐ 盈虧百分比（基於平均）：6位小數
-			log.PnL_Avg,                 // ⭐ 盈虧金額（基於平均）：6位小數 ⭐
-			log.Fee,                     // ⭐ 手續費：8位小數
-			log.RoundClosedValue,        // ⭐ 本輪累積關倉總價值：6位小數
-			log.CurrentRoundRealizedPnL, // ⭐ 本輪已實現盈虧：6位小數
-			log.TotalRealizedPnL,        // ⭐ 累計已實現盈虧：6位小數
-			log.UnrealizedPnL,           // ⭐ 浮動盈虧（所有未平倉倉位）：6位小數
+			log.Price,                   // 價格：precision.Price
+			log.PositionSize,            // 倉位大小：precision.Value
+			log.Balance,                 // 餘額：precision.Value
+			log.OpenPositionValue,       // ⭐ 累計持倉總價值：precision.Value
+			log.PnLPercent,              // ⭐ 盈虧百分比（基於單筆）：precision.Percent
+			log.PnL,                     // ⭐ 盈虧金額（基於單筆）：precision.Value ⭐
+			log.AvgCost,                 // ⭐ 平均成本：precision.Price ⭐
+			log.PnLPercent_Avg,          // ⭐ 盈虧百分比（基於平均）：precision.Percent
+			log.PnL_Avg,                 // ⭐ 盈虧金額（基於平均）：precision.Value ⭐
+			log.Fee,                     // ⭐ 手續費：precision.Fee
+			log.RoundClosedValue,        // ⭐ 本輪累積關倉總價值：precision.Value
+			log.CurrentRoundRealizedPnL, // ⭐ 本輪已實現盈虧：precision.Value
+			log.TotalRealizedPnL,        // ⭐ 累計已實現盈虧：precision.Value
+			log.UnrealizedPnL,           // ⭐ 浮動盈虧（所有未平倉倉位）：precision.Value
 			log.Reason,
 			log.PositionID,
 		)
@@ -893,20 +1651,20 @@ func (e *BacktestEngine) ExportTradeLogCSV(filepath string) error {
 	return nil
 }
 
-// printBreakEvenRoundsReport 輸出打平輪次統計報告
-func (e *BacktestEngine) printBreakEvenRoundsReport() {
+// writeBreakEvenRoundsReport 寫入打平輪次統計報告
+func (e *BacktestEngine) writeBreakEvenRoundsReport(w io.Writer) {
 	if len(e.breakEvenRounds) == 0 {
-		fmt.Println("\n========================================")
-		fmt.Println("⭐ 打平輪次統計")
-		fmt.Println("========================================")
-		fmt.Println("本次回測沒有觸發打平機制")
+		fmt.Fprintln(w, "\n========================================")
+		fmt.Fprintln(w, "⭐ 打平輪次統計")
+		fmt.Fprintln(w, "========================================")
+		fmt.Fprintln(w, "本次回測沒有觸發打平機制")
 		return
 	}
 
-	fmt.Println("\n========================================")
-	fmt.Println("⭐ 打平輪次統計")
-	fmt.Println("========================================")
-	fmt.Printf("總輪次數: %d\n\n", len(e.breakEvenRounds))
+	fmt.Fprintln(w, "\n========================================")
+	fmt.Fprintln(w, "⭐ 打平輪次統計")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "總輪次數: %d\n\n", len(e.breakEvenRounds))
 
 	// 統計數據
 	totalProfit := 0.0
@@ -918,18 +1676,18 @@ func (e *BacktestEngine) printBreakEvenRoundsReport() {
 		totalProfit += round.ExpectedProfit
 		totalFees += round.TotalFees
 		totalTrades += round.TotalOpenCount + round.TotalCloseCount
-		releasePosition := float64(round.BreakEvenCloseCount) * e.config.PositionSize
+		releasePosition := float64(round.forcedCloseCount()) * e.config.PositionSize
 		if releasePosition > maxReleasePosition {
 			maxReleasePosition = releasePosition
 		}
 	}
 
 	// 彙總統計
-	fmt.Println("----------------------------------------")
-	fmt.Println("📊 彙總統計")
-	fmt.Println("----------------------------------------")
-	fmt.Printf("總輪次數: %d\n", len(e.breakEvenRounds))
-	fmt.Printf("詳細內容看報告")
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintln(w, "📊 彙總統計")
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintf(w, "總輪次數: %d\n", len(e.breakEvenRounds))
+	fmt.Fprintf(w, "詳細內容看報告")
 
 	// 盈虧分佈
 	profitRounds := 0
@@ -941,38 +1699,61 @@ func (e *BacktestEngine) printBreakEvenRoundsReport() {
 			lossRounds++
 		}
 	}
-	fmt.Printf("盈利輪次: %d (%.1f%%)\n", profitRounds, float64(profitRounds)/float64(len(e.breakEvenRounds))*100)
-	fmt.Printf("虧損輪次: %d (%.1f%%)\n", lossRounds, float64(lossRounds)/float64(len(e.breakEvenRounds))*100)
-	fmt.Println("========================================")
-	fmt.Println()
+	fmt.Fprintf(w, "盈利輪次: %d (%.1f%%)\n", profitRounds, float64(profitRounds)/float64(len(e.breakEvenRounds))*100)
+	fmt.Fprintf(w, "虧損輪次: %d (%.1f%%)\n", lossRounds, float64(lossRounds)/float64(len(e.breakEvenRounds))*100)
+	exitReasons := summarizeRounds(e.breakEvenRounds).ExitReasons
+	for _, reason := range sortedExitReasons(exitReasons) {
+		fmt.Fprintf(w, "結束原因 %s: %d 輪\n", reason, exitReasons[reason])
+	}
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintln(w)
+}
+
+// calculateFundingAmount 計算本次注資金額 ⭐
+//
+// 固定模式：AutoFundingAmount
+// 比例模式：openPositionValue × AutoFundingPercent（隨當前持倉規模縮放）
+func (e *BacktestEngine) calculateFundingAmount(openPositionValueD decimal.Decimal) decimal.Decimal {
+	if e.config.AutoFundingMode == AutoFundingPercentOfNotional {
+		return openPositionValueD.Mul(decimal.NewFromFloat(e.config.AutoFundingPercent))
+	}
+	return decimal.NewFromFloat(e.config.AutoFundingAmount)
+}
+
+// describeFundingAmount 注資金額設定描述（用於報告）
+func (e *BacktestEngine) describeFundingAmount() string {
+	if e.config.AutoFundingMode == AutoFundingPercentOfNotional {
+		return fmt.Sprintf("持倉價值的 %.1f%%", e.config.AutoFundingPercent*100)
+	}
+	return fmt.Sprintf("$%.2f USDT", e.config.AutoFundingAmount)
 }
 
-// printFundingReport 輸出自動注資統計報告 ⭐
-func (e *BacktestEngine) printFundingReport() {
+// writeFundingReport 寫入自動注資統計報告 ⭐
+func (e *BacktestEngine) writeFundingReport(w io.Writer) {
 	if !e.config.EnableAutoFunding {
 		return // 未啟用自動注資，不輸出報告
 	}
 
 	if len(e.fundingHistory) == 0 {
-		fmt.Println("\n========================================")
-		fmt.Println("💰 自動注資統計")
-		fmt.Println("========================================")
-		fmt.Println("本次回測未觸發自動注資機制")
-		fmt.Printf("閒置閾值設定: %d 根K線\n", e.config.AutoFundingIdle)
-		fmt.Printf("注資金額設定: %.2f USDT\n", e.config.AutoFundingAmount)
-		fmt.Println("========================================")
-		fmt.Println()
+		fmt.Fprintln(w, "\n========================================")
+		fmt.Fprintln(w, "💰 自動注資統計")
+		fmt.Fprintln(w, "========================================")
+		fmt.Fprintln(w, "本次回測未觸發自動注資機制")
+		fmt.Fprintf(w, "閒置閾值設定: %d 根K線\n", e.config.AutoFundingIdle)
+		fmt.Fprintf(w, "注資金額設定: %s\n", e.describeFundingAmount())
+		fmt.Fprintln(w, "========================================")
+		fmt.Fprintln(w)
 		return
 	}
 
-	fmt.Println("\n========================================")
-	fmt.Println("💰 自動注資統計")
-	fmt.Println("========================================")
-	fmt.Printf("總注資次數: %d 次\n", len(e.fundingHistory))
-	fmt.Printf("閒置閾值: %d 根K線 (約 %.1f 天)\n",
+	fmt.Fprintln(w, "\n========================================")
+	fmt.Fprintln(w, "💰 自動注資統計")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "總注資次數: %d 次\n", len(e.fundingHistory))
+	fmt.Fprintf(w, "閒置閾值: %d 根K線 (約 %.1f 天)\n",
 		e.config.AutoFundingIdle,
 		float64(e.config.AutoFundingIdle)*5/60/24) // 5分鐘K線換算天數
-	fmt.Printf("單次注資金額: %.2f USDT\n\n", e.config.AutoFundingAmount)
+	fmt.Fprintf(w, "單次注資金額: %s\n\n", e.describeFundingAmount())
 
 	// 計算總注資金額和已回收金額 ⭐
 	totalFunding := 0.0
@@ -980,46 +1761,46 @@ func (e *BacktestEngine) printFundingReport() {
 	recoveredCount := 0
 	for _, record := range e.fundingHistory {
 		totalFunding += record.Amount
+		totalRecovered += record.RecoveredAmount // ⭐ 包含部分回收
 		if record.Recovered {
-			totalRecovered += record.Amount
 			recoveredCount++
 		}
 	}
 	netFunding := totalFunding - totalRecovered // 淨注資金額（未回收的）
 
 	// 標準輸出只顯示簡要信息（詳細記錄見 report.md）
-	fmt.Println("----------------------------------------")
-	fmt.Println("📋 注資記錄")
-	fmt.Println("----------------------------------------")
-	fmt.Printf("總計 %d 次注資（詳細記錄請查看 report.md）\n\n", len(e.fundingHistory))
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintln(w, "📋 注資記錄")
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintf(w, "總計 %d 次注資（詳細記錄請查看 report.md）\n\n", len(e.fundingHistory))
 
 	// 彙總統計
-	fmt.Println("----------------------------------------")
-	fmt.Println("📊 注資彙總")
-	fmt.Println("----------------------------------------")
-	fmt.Printf("總注資次數: %d 次\n", len(e.fundingHistory))
-	fmt.Printf("總注資金額: $%.2f USDT ⭐\n", totalFunding)
-	fmt.Printf("已回收次數: %d 次 ✅\n", recoveredCount)
-	fmt.Printf("已回收金額: $%.2f USDT ✅\n", totalRecovered)
-	fmt.Printf("淨注資金額: $%.2f USDT 💰 (最終未回收)\n", netFunding)
-	fmt.Printf("最大注資峰值: $%.2f USDT 🔥 (最壞情況需準備的額外資金)\n", e.maxPendingFunding)
-	fmt.Printf("回收率: %.1f%% ⭐\n", (totalRecovered/totalFunding)*100)
-	fmt.Printf("平均注資間隔: %.1f 根K線 (約 %.1f 天)\n",
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintln(w, "📊 注資彙總")
+	fmt.Fprintln(w, "----------------------------------------")
+	fmt.Fprintf(w, "總注資次數: %d 次\n", len(e.fundingHistory))
+	fmt.Fprintf(w, "總注資金額: $%.2f USDT ⭐\n", totalFunding)
+	fmt.Fprintf(w, "已回收次數: %d 次 ✅\n", recoveredCount)
+	fmt.Fprintf(w, "已回收金額: $%.2f USDT ✅\n", totalRecovered)
+	fmt.Fprintf(w, "淨注資金額: $%.2f USDT 💰 (最終未回收)\n", netFunding)
+	fmt.Fprintf(w, "最大注資峰值: $%.2f USDT 🔥 (最壞情況需準備的額外資金)\n", e.maxPendingFunding)
+	fmt.Fprintf(w, "回收率: %.1f%% ⭐\n", (totalRecovered/totalFunding)*100)
+	fmt.Fprintf(w, "平均注資間隔: %.1f 根K線 (約 %.1f 天)\n",
 		float64(e.fundingHistory[len(e.fundingHistory)-1].CandleIndex)/float64(len(e.fundingHistory)),
 		float64(e.fundingHistory[len(e.fundingHistory)-1].CandleIndex)*5/60/24/float64(len(e.fundingHistory)))
 
 	// 如果有注資，計算對最終結果的影響
-	fmt.Printf("\n💡 注資影響分析:\n")
-	fmt.Printf("   初始資金: $%.2f\n", e.config.InitialBalance)
-	fmt.Printf("   累積注資: $%.2f (投入 %d 次)\n", totalFunding, len(e.fundingHistory))
-	fmt.Printf("   已回收: $%.2f (回收 %d 次) ✅\n", totalRecovered, recoveredCount)
-	fmt.Printf("   最終未回收: $%.2f 💰\n", netFunding)
-	fmt.Printf("   最大峰值: $%.2f 🔥\n", e.maxPendingFunding)
-	fmt.Printf("\n   📌 結論:\n")
-	fmt.Printf("      - 最壞情況需準備: $%.2f (初始 + 最大峰值)\n", e.config.InitialBalance+e.maxPendingFunding)
-	fmt.Printf("      - 回測結束時佔用: $%.2f (初始 + 最終未回收)\n", e.config.InitialBalance+netFunding)
-	fmt.Println("========================================")
-	fmt.Println()
+	fmt.Fprintf(w, "\n💡 注資影響分析:\n")
+	fmt.Fprintf(w, "   初始資金: $%.2f\n", e.config.InitialBalance)
+	fmt.Fprintf(w, "   累積注資: $%.2f (投入 %d 次)\n", totalFunding, len(e.fundingHistory))
+	fmt.Fprintf(w, "   已回收: $%.2f (回收 %d 次) ✅\n", totalRecovered, recoveredCount)
+	fmt.Fprintf(w, "   最終未回收: $%.2f 💰\n", netFunding)
+	fmt.Fprintf(w, "   最大峰值: $%.2f 🔥\n", e.maxPendingFunding)
+	fmt.Fprintf(w, "\n   📌 結論:\n")
+	fmt.Fprintf(w, "      - 最壞情況需準備: $%.2f (初始 + 最大峰值)\n", e.config.InitialBalance+e.maxPendingFunding)
+	fmt.Fprintf(w, "      - 回測結束時佔用: $%.2f (初始 + 最終未回收)\n", e.config.InitialBalance+netFunding)
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintln(w)
 }
 
 // GenerateBreakEvenReportMarkdown 生成打平輪次報告的 Markdown 內容 ⭐
@@ -1037,7 +1818,7 @@ func (e *BacktestEngine) GenerateBreakEvenReportMarkdown() string {
 	for _, round := range e.breakEvenRounds {
 		totalProfit += round.ExpectedProfit
 		totalFees += round.TotalFees
-		releasePosition := float64(round.BreakEvenCloseCount) * e.config.PositionSize
+		releasePosition := float64(round.forcedCloseCount()) * e.config.PositionSize
 		if releasePosition > maxReleasePosition {
 			maxReleasePosition = releasePosition
 		}
@@ -1063,7 +1844,12 @@ func (e *BacktestEngine) GenerateBreakEvenReportMarkdown() string {
 	content += fmt.Sprintf("- **最大釋放倉位量**: %.2f USDT\n", maxReleasePosition)
 	content += fmt.Sprintf("- **觸發平攤總盈虧**: %.2f USDT\n", totalProfit)
 	content += fmt.Sprintf("- **盈利輪次**: %d (%.1f%%)\n", profitRounds, float64(profitRounds)/float64(len(e.breakEvenRounds))*100)
-	content += fmt.Sprintf("- **虧損輪次**: %d (%.1f%%)\n\n", lossRounds, float64(lossRounds)/float64(len(e.breakEvenRounds))*100)
+	content += fmt.Sprintf("- **虧損輪次**: %d (%.1f%%)\n", lossRounds, float64(lossRounds)/float64(len(e.breakEvenRounds))*100)
+	exitReasons := summarizeRounds(e.breakEvenRounds).ExitReasons
+	for _, reason := range sortedExitReasons(exitReasons) {
+		content += fmt.Sprintf("- **結束原因 %s**: %d 輪\n", reason, exitReasons[reason])
+	}
+	content += "\n"
 
 	// 詳細輪次記錄說明
 	content += "### 詳細輪次記錄\n\n"
@@ -1088,8 +1874,8 @@ func (e *BacktestEngine) GenerateFundingReportMarkdown() string {
 	recoveredCount := 0
 	for _, record := range e.fundingHistory {
 		totalFunding += record.Amount
+		totalRecovered += record.RecoveredAmount // ⭐ 包含部分回收
 		if record.Recovered {
-			totalRecovered += record.Amount
 			recoveredCount++
 		}
 	}
@@ -1105,10 +1891,11 @@ func (e *BacktestEngine) GenerateFundingReportMarkdown() string {
 	content += fmt.Sprintf("- **淨注資金額**: $%.2f USDT 💰 (最終未回收)\n", netFunding)
 	content += fmt.Sprintf("- **最大注資峰值**: $%.2f USDT 🔥\n", e.maxPendingFunding)
 	content += fmt.Sprintf("- **回收率**: %.1f%%\n", (totalRecovered/totalFunding)*100)
+	content += fmt.Sprintf("- **注資效率**: %.4f (使用注資的輪次淨利潤 $%.2f / 最大注資峰值)\n", e.FundingEfficiency(), e.fundedRoundProfit)
 	content += fmt.Sprintf("- **自動注資閒置閾值**: %d 根K線 (約 %.1f 天)\n",
 		e.config.AutoFundingIdle,
 		float64(e.config.AutoFundingIdle)*5/60/24)
-	content += fmt.Sprintf("- **單次注資金額**: $%.2f USDT\n\n", e.config.AutoFundingAmount)
+	content += fmt.Sprintf("- **單次注資金額**: %s\n\n", e.describeFundingAmount())
 
 	// 注資影響分析
 	content += "### 注資影響分析\n\n"
@@ -1136,6 +1923,8 @@ func (e *BacktestEngine) GenerateFundingReportMarkdown() string {
 		if record.Recovered {
 			status = "✅ 已回收"
 			recoveredTime = record.RecoveredAt.Format("2006-01-02 15:04")
+		} else if record.RecoveredAmount > 0 {
+			status = fmt.Sprintf("🔄 部分回收 $%.2f", record.RecoveredAmount)
 		}
 
 		content += fmt.Sprintf("| %d | %s | %s | %d | %d | %.1f | $%.2f | $%.2f | $%.2f | $%.2f | %s |\n",
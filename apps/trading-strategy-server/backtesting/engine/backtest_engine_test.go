@@ -1,10 +1,13 @@
 package engine
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 
 	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
 )
 
 // TestBacktestEngine_NewBacktestEngine 測試引擎創建
@@ -63,8 +66,8 @@ func TestBacktestEngine_Run_EmptyCandles(t *testing.T) {
 	candles := []value_objects.Candle{}
 	_, err = engine.Run(candles)
 
-	if err == nil {
-		t.Error("Expected error for empty candles, got nil")
+	if !errors.Is(err, ErrNoCandles) {
+		t.Errorf("Expected ErrNoCandles for empty candles, got %v", err)
 	}
 }
 
@@ -164,7 +167,7 @@ func TestBacktestEngine_Run_MultipleCandles(t *testing.T) {
 	t.Logf("盈利交易: %d", result.WinningTrades)
 	t.Logf("虧損交易: %d", result.LosingTrades)
 	t.Logf("勝率: %.2f%%", result.WinRate)
-	t.Logf("盈虧比: %.2f", result.ProfitFactor)
+	t.Logf("盈虧比: %.2f", result.ProfitFactorTotal)
 	t.Logf("========================================")
 	t.Logf("盈虧明細:")
 	t.Logf("========================================")
@@ -341,7 +344,7 @@ func TestBacktestEngine_RunFromFile(t *testing.T) {
 	t.Logf("盈利交易: %d", result.WinningTrades)
 	t.Logf("虧損交易: %d", result.LosingTrades)
 	t.Logf("勝率: %.2f%%", result.WinRate)
-	t.Logf("盈虧比: %.2f", result.ProfitFactor)
+	t.Logf("盈虧比: %.2f", result.ProfitFactorTotal)
 	t.Logf("平均持倉時長: %s", result.AvgHoldDuration)
 	t.Logf("========================================")
 
@@ -354,3 +357,106 @@ func TestBacktestEngine_RunFromFile(t *testing.T) {
 		t.Error("Final balance should not be negative")
 	}
 }
+
+// TestBacktestEngine_MinFeePerOrder 測試最低手續費高於百分比手續費時每筆開平倉都按最低手續費計
+func TestBacktestEngine_MinFeePerOrder(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.MinFeePerOrder = 1 // 倉位 200 USDT × 0.05% = 0.1，最低手續費主導
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateSine(300))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	opens, closes := 0, 0
+	for _, log := range engine.GetTradeLog() {
+		switch log.Action {
+		case "OPEN":
+			opens++
+		case "CLOSE":
+			closes++
+		}
+	}
+	if opens == 0 || closes == 0 {
+		t.Fatalf("Expected opens and closes, got %d / %d", opens, closes)
+	}
+	if math.Abs(result.TotalFeesOpen-float64(opens)) > 1e-9 {
+		t.Errorf("Expected open fees %d (1 USDT per order), got %.6f", opens, result.TotalFeesOpen)
+	}
+	if math.Abs(result.TotalFeesClose-float64(closes)) > 1e-9 {
+		t.Errorf("Expected close fees %d (1 USDT per order), got %.6f", closes, result.TotalFeesClose)
+	}
+
+	config.MinFeePerOrder = -1
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for negative min fee, got %v", err)
+	}
+}
+
+// TestBacktestEngine_SlippageCost 測試滑點成本等於逐筆成交的理想收支與實際收支之差
+func TestBacktestEngine_SlippageCost(t *testing.T) {
+	const slippage = 0.001
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.Slippage = slippage
+	config.ForceCloseAtEnd = true
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateSine(300))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	// 從交易日誌重算：開倉少買的幣按成交價計；平倉按建議價（成交價 / (1 - slippage)）與成交價之差計，扣除手續費差異
+	coinsByID := make(map[string]float64) // 開倉日誌的幣數（平倉日誌的 PositionSize 是平倉價值）
+	expected, opens, closes := 0.0, 0, 0
+	for _, log := range engine.GetTradeLog() {
+		switch log.Action {
+		case "OPEN":
+			coinsByID[log.PositionID] = log.PositionSize / log.Price
+			expected += log.PositionSize * slippage / (1 + slippage)
+			opens++
+		case "CLOSE":
+			coins, ok := coinsByID[log.PositionID]
+			if !ok {
+				t.Fatalf("Close of unknown position %q", log.PositionID)
+			}
+			expected += coins * (log.Price/(1-slippage) - log.Price) * (1 - config.FeeRate)
+			closes++
+		}
+	}
+	if opens == 0 || closes == 0 {
+		t.Fatalf("Expected opens and closes, got %d / %d", opens, closes)
+	}
+
+	if result.SlippageCost <= 0 {
+		t.Fatalf("Expected positive slippage cost, got %.6f", result.SlippageCost)
+	}
+	if math.Abs(result.SlippageCost-expected) > 1e-6 {
+		t.Errorf("Expected slippage cost %.6f, got %.6f", expected, result.SlippageCost)
+	}
+
+	// 零滑點時沒有滑點成本
+	config.Slippage = 0
+	engine, err = NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if result, err = engine.Run(testutil.GenerateSine(300)); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if result.SlippageCost != 0 {
+		t.Errorf("Expected zero slippage cost without slippage, got %.6f", result.SlippageCost)
+	}
+
+	config.Slippage = -0.001
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for negative slippage, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// TestBacktestEngine_SeedPositions 測試載入初始持倉後的平均成本、輪次狀態和餘額
+func TestBacktestEngine_SeedPositions(t *testing.T) {
+	seedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+		SeedPositions: []SeedPosition{
+			{EntryPrice: 2600, Size: 200, OpenTime: seedTime, TargetClosePrice: 2604},
+			{EntryPrice: 2500, Size: 200, OpenTime: seedTime.Add(time.Hour), TargetClosePrice: 2504},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	// 開始前：平均成本 = 總本金 / 總幣數
+	wantAvgCost := 400.0 / (200.0/2600.0 + 200.0/2500.0)
+	if got := engine.GetPositionTracker().CalculateAverageCost(); math.Abs(got-wantAvgCost) > 1e-6 {
+		t.Errorf("Expected seeded avgCost %.6f, got %.6f", wantAvgCost, got)
+	}
+
+	if engine.currentRoundStats.RoundID != 1 {
+		t.Errorf("Expected round 1, got %d", engine.currentRoundStats.RoundID)
+	}
+	if engine.currentRoundStats.OpenCount != 2 {
+		t.Errorf("Expected first round open count 2, got %d", engine.currentRoundStats.OpenCount)
+	}
+	if !engine.currentRoundStats.StartTime.Equal(seedTime) {
+		t.Errorf("Expected round start %v, got %v", seedTime, engine.currentRoundStats.StartTime)
+	}
+	if math.Abs(engine.currentRoundStats.TotalFeesInRound-0.2) > 1e-9 {
+		t.Errorf("Expected round fees 0.2, got %.6f", engine.currentRoundStats.TotalFeesInRound)
+	}
+
+	// 價格遠低於止盈價，且不會觸發打平（本輪尚未關倉）
+	candle, _ := value_objects.NewCandle(2400, 2401, 2399, 2400, seedTime.Add(2*time.Hour))
+	if _, err := engine.Run([]value_objects.Candle{candle}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// 初始資金快照應已扣除初始持倉成本（倉位 + 開倉手續費）
+	snapshots := engine.GetMetricsCalculator().GetBalanceSnapshots()
+	wantStartBalance := 10000.0 - 2*(200+200*0.0005)
+	if math.Abs(snapshots[0].Balance-wantStartBalance) > 1e-9 {
+		t.Errorf("Expected starting balance %.4f, got %.4f", wantStartBalance, snapshots[0].Balance)
+	}
+
+	// 初始持倉記錄在交易日誌中（手續費計入 GetTotalFees）
+	tradeLog := engine.GetTradeLog()
+	if len(tradeLog) < 2 || tradeLog[0].Reason != "seed_position" || tradeLog[1].Reason != "seed_position" {
+		t.Fatalf("Expected two seed_position entries first in trade log, got %+v", tradeLog)
+	}
+}
+
+// TestBacktestEngine_SeedPositions_Invalid 測試無效的初始持倉
+func TestBacktestEngine_SeedPositions_Invalid(t *testing.T) {
+	_, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		SeedPositions: []SeedPosition{
+			{EntryPrice: 0, Size: 200, OpenTime: time.Now(), TargetClosePrice: 2504},
+		},
+	})
+	if !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, simulator.ErrInvalidOpenPrice) {
+		t.Errorf("Expected ErrInvalidConfig wrapping ErrInvalidOpenPrice for zero entry price, got %v", err)
+	}
+}
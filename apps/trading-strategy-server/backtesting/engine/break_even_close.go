@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// BreakEvenCloseMode 打平退出時的平倉記錄方式 ⭐
+type BreakEvenCloseMode string
+
+const (
+	BreakEvenClosePerPosition BreakEvenCloseMode = "per_position" // 逐倉平倉，每個倉位一筆 CLOSE（默認，便於逐筆分析）
+	BreakEvenCloseAggregate   BreakEvenCloseMode = "aggregate"    // 合併為一筆市價平倉，只記錄一筆 CLOSE（與交易所單筆市價單一致）
+)
+
+// aggregateClose 打平合併平倉的累計結果
+//
+// 每個倉位仍通過 executeClose 逐一平倉（倉位追蹤器和 decimal 累計與逐倉模式完全一致），
+// 只是交易日誌和事件合併為一筆
+type aggregateClose struct {
+	count        int
+	closedValue  decimal.Decimal // 合計平倉價值
+	positionSize decimal.Decimal // 合計倉位大小（USDT）
+	pnl          decimal.Decimal // 合計盈虧（基於開倉價，未扣手續費）
+	pnlAvg       decimal.Decimal // 合計盈虧（基於平均成本，未扣手續費）
+	fee          decimal.Decimal // 合計平倉手續費
+	realizedPnL  decimal.Decimal // 合計已實現盈虧（扣除手續費）
+	price        float64         // 平倉價格（所有倉位相同）
+}
+
+// add 累加一個倉位的平倉結果
+func (a *aggregateClose) add(closeResult ExecuteCloseResult) {
+	a.count++
+	a.closedValue = a.closedValue.Add(closeResult.ClosedValue)
+	a.positionSize = a.positionSize.Add(closeResult.PositionSize)
+	a.pnl = a.pnl.Add(decimal.NewFromFloat(closeResult.PnL))
+	a.pnlAvg = a.pnlAvg.Add(decimal.NewFromFloat(closeResult.PnL_Avg))
+	a.fee = a.fee.Add(closeResult.CloseFee)
+	a.realizedPnL = a.realizedPnL.Add(closeResult.RealizedPnL)
+	a.price = closeResult.ClosePrice
+}
+
+// percent 計算合計盈虧佔合計倉位大小的百分比
+func (a *aggregateClose) percent(pnl decimal.Decimal) float64 {
+	if a.positionSize.IsZero() {
+		return 0
+	}
+	return pnl.Div(a.positionSize).Mul(decimal.NewFromInt(100)).InexactFloat64()
+}
+
+// aggregatePositionIDPrefix 合併平倉的 PositionID 前綴
+const aggregatePositionIDPrefix = "aggregate_"
+
+// closeFill 一筆 CLOSE 交易日誌 / 事件的內容（逐倉或合併）
+type closeFill struct {
+	Price          float64
+	ClosedValue    float64
+	Size           float64
+	PnL            float64
+	PnLPercent     float64
+	PnL_Avg        float64
+	PnLPercent_Avg float64
+	Fee            float64
+	RealizedPnL    float64
+	PositionID     string // 合併平倉時為 "aggregate_<倉位數>"
+}
+
+// fill 合併平倉的交易日誌內容
+func (a *aggregateClose) fill() closeFill {
+	return closeFill{
+		Price:          a.price,
+		ClosedValue:    a.closedValue.InexactFloat64(),
+		Size:           a.positionSize.InexactFloat64(),
+		PnL:            a.pnl.InexactFloat64(),
+		PnLPercent:     a.percent(a.pnl),
+		PnL_Avg:        a.pnlAvg.InexactFloat64(),
+		PnLPercent_Avg: a.percent(a.pnlAvg),
+		Fee:            a.fee.InexactFloat64(),
+		RealizedPnL:    a.realizedPnL.InexactFloat64(),
+		PositionID:     fmt.Sprintf("%s%d", aggregatePositionIDPrefix, a.count),
+	}
+}
+
+// positionFill 逐倉平倉的交易日誌內容
+func positionFill(pos simulator.Position, closeResult ExecuteCloseResult) closeFill {
+	return closeFill{
+		Price:          closeResult.ClosePrice,
+		ClosedValue:    closeResult.ClosedValue.InexactFloat64(),
+		Size:           pos.Size,
+		PnL:            closeResult.PnL,
+		PnLPercent:     closeResult.PnLPercent,
+		PnL_Avg:        closeResult.PnL_Avg,
+		PnLPercent_Avg: closeResult.PnLPercent_Avg,
+		Fee:            closeResult.CloseFee.InexactFloat64(),
+		RealizedPnL:    closeResult.RealizedPnL.InexactFloat64(),
+		PositionID:     pos.ID,
+	}
+}
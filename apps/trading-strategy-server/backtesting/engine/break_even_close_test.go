@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// breakEvenCloses 返回打平退出產生的 CLOSE 交易日誌
+func breakEvenCloses(logs []TradeLog) []TradeLog {
+	var closes []TradeLog
+	for _, log := range logs {
+		if log.Action == "CLOSE" && strings.HasPrefix(log.Reason, "break_even_exit:") {
+			closes = append(closes, log)
+		}
+	}
+	return closes
+}
+
+// TestBreakEvenCloseMode_AggregateMatchesPerPosition 測試合併平倉與逐倉平倉的盈虧總額完全一致 ⭐
+func TestBreakEvenCloseMode_AggregateMatchesPerPosition(t *testing.T) {
+	candles := testutil.GenerateSine(600)
+
+	perPosition, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	perResult, err := perPosition.Run(candles)
+	if err != nil {
+		t.Fatalf("Per-position run failed: %v", err)
+	}
+
+	aggregate, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenCloseAggregate))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	aggResult, err := aggregate.Run(candles)
+	if err != nil {
+		t.Fatalf("Aggregate run failed: %v", err)
+	}
+
+	if len(perPosition.breakEvenRounds) == 0 {
+		t.Fatal("Test scenario should trigger at least one break-even exit")
+	}
+
+	// 盈虧、手續費和餘額完全一致
+	if perResult.FinalBalance != aggResult.FinalBalance ||
+		perResult.NetProfit != aggResult.NetProfit ||
+		perResult.TotalProfitGross != aggResult.TotalProfitGross ||
+		perResult.TotalProfitGross_Entry != aggResult.TotalProfitGross_Entry ||
+		perResult.TotalFeesClose != aggResult.TotalFeesClose {
+		t.Errorf("Aggregate result differs from per-position:\nper: %+v\nagg: %+v", perResult, aggResult)
+	}
+	if !reflect.DeepEqual(perPosition.positionTracker.GetClosedPositions(), aggregate.positionTracker.GetClosedPositions()) {
+		t.Error("Closed positions should be identical in both modes")
+	}
+	if !reflect.DeepEqual(perPosition.breakEvenRounds, aggregate.breakEvenRounds) {
+		t.Error("Break-even rounds should be identical in both modes")
+	}
+
+	// 合併模式每次打平只有一筆 CLOSE，合計與逐倉一致
+	perCloses := breakEvenCloses(perPosition.GetTradeLog())
+	aggCloses := breakEvenCloses(aggregate.GetTradeLog())
+	if len(aggCloses) != len(aggregate.breakEvenRounds) {
+		t.Errorf("Expected one aggregate CLOSE per break-even exit (%d), got %d", len(aggregate.breakEvenRounds), len(aggCloses))
+	}
+	if len(aggCloses) >= len(perCloses) {
+		t.Errorf("Expected fewer CLOSE rows in aggregate mode, got %d vs %d", len(aggCloses), len(perCloses))
+	}
+
+	sum := func(logs []TradeLog, field func(TradeLog) float64) decimal.Decimal {
+		total := decimal.Zero
+		for _, log := range logs {
+			total = total.Add(decimal.NewFromFloat(field(log)))
+		}
+		return total
+	}
+	fields := map[string]func(TradeLog) float64{
+		"PositionSize": func(l TradeLog) float64 { return l.PositionSize },
+		"PnL":          func(l TradeLog) float64 { return l.PnL },
+		"PnL_Avg":      func(l TradeLog) float64 { return l.PnL_Avg },
+		"Fee":          func(l TradeLog) float64 { return l.Fee },
+	}
+	for name, field := range fields {
+		per, agg := sum(perCloses, field), sum(aggCloses, field)
+		if per.Sub(agg).Abs().GreaterThan(decimal.NewFromFloat(1e-9)) {
+			t.Errorf("%s total mismatch: per-position %s, aggregate %s", name, per, agg)
+		}
+	}
+}
+
+// TestBreakEvenCloseMode_Unknown 測試未知的打平平倉模式
+func TestBreakEvenCloseMode_Unknown(t *testing.T) {
+	config := breakEvenTestConfig("batch")
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Error("Expected error for unknown break even close mode")
+	}
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"math"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// checkBreakEvenStop 保本止損（BreakEvenStop）⭐
+//
+// 與打平機制互補：打平處理虧損輪次的退出，保本止損保護已經轉為盈利的輪次。
+//  1. 已武裝且K線 Low <= 平均成本：觸發，按平均成本成交（開盤已低於平均成本時按開盤價成交）
+//  2. 未觸發時，本輪預期盈利（已實現 + 未實現，基於收盤價）> 0 則武裝，從下一根K線開始生效
+//
+// 武裝狀態記錄在 RoundStats 中，輪次結束（全部倉位關閉）時清除。
+// 返回：是否觸發、成交價格
+func (e *BacktestEngine) checkBreakEvenStop(candle value_objects.Candle, roundRealizedPnL, unrealizedPnL float64) (bool, float64) {
+	if !e.config.BreakEvenStop || len(e.positionTracker.GetOpenPositions()) == 0 {
+		return false, 0
+	}
+
+	avgCost := e.positionTracker.CalculateAverageCost()
+	if e.currentRoundStats.BreakEvenStopArmed && candle.Low().Value() <= avgCost {
+		return true, math.Min(candle.Open().Value(), avgCost)
+	}
+
+	if roundRealizedPnL+unrealizedPnL > 0 {
+		e.currentRoundStats.BreakEvenStopArmed = true
+	}
+	return false, 0
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestBreakEvenStop_ExitsAtAverageCost 測試本輪轉為盈利後反轉下跌時在平均成本退出，而不是繼續持倉下跌 ⭐
+func TestBreakEvenStop_ExitsAtAverageCost(t *testing.T) {
+	// 先下跌建倉，反彈使本輪轉為盈利（未觸及止盈），再持續下跌
+	candles := testutil.GenerateLegs(2500, 0.5,
+		testutil.Leg{Count: 10, Step: -3},
+		testutil.Leg{Count: 12, Step: 2},
+		testutil.Leg{Count: 20, Step: -3},
+	)
+
+	run := func(breakEvenStop bool) (*BacktestEngine, metrics.BacktestResult) {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.BreakEvenProfitMin = 5 // 反彈的預期盈利不足以觸發打平退出
+		config.BreakEvenStop = breakEvenStop
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return engine, result
+	}
+
+	// 未啟用：反彈後的倉位一路持有到下跌結束
+	plain, plainResult := run(false)
+	for _, log := range plain.GetTradeLog() {
+		if strings.HasPrefix(log.Reason, metrics.ReasonBreakEvenStop) {
+			t.Fatalf("Unexpected break-even stop without the mode enabled: %+v", log)
+		}
+	}
+
+	stopped, stoppedResult := run(true)
+	var stops []TradeLog
+	for _, log := range stopped.GetTradeLog() {
+		if log.Action == "CLOSE" && strings.HasPrefix(log.Reason, metrics.ReasonBreakEvenStop) {
+			stops = append(stops, log)
+		}
+	}
+	if len(stops) == 0 {
+		t.Fatal("Expected the reversal to trigger the break-even stop")
+	}
+	last := stops[len(stops)-1]
+	for _, stop := range stops {
+		if stop.Price != stop.AvgCost {
+			t.Errorf("Expected stop fill at avg cost %.4f, got %.4f", stop.AvgCost, stop.Price)
+		}
+		if !stop.Time.Equal(last.Time) {
+			t.Errorf("Expected all positions closed on the same candle, got %v and %v", stop.Time, last.Time)
+		}
+	}
+	if last.OpenPositionValue != 0 {
+		t.Errorf("Expected the round to be fully closed, %.2f still open", last.OpenPositionValue)
+	}
+
+	// 反彈時的倉位已在平均成本離場，後續下跌的浮虧小於一直持有
+	if stoppedResult.UnrealizedPnL <= plainResult.UnrealizedPnL {
+		t.Errorf("Expected the stop to reduce the loss from the reversal: unrealized %.2f vs %.2f without it",
+			stoppedResult.UnrealizedPnL, plainResult.UnrealizedPnL)
+	}
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/loader"
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+)
+
+// historyWindow 每根K線傳給策略的歷史K線數（最近 100 根）
+const historyWindow = 100
+
+// streamProgressInterval RunStream 不知道K線總數，每處理這麼多根回報一次進度
+const streamProgressInterval = 10000
+
+// RunStream 從K線串流執行回測（K線佔用的內存與數據量無關）⭐
+//
+// K線逐根讀取，只保留策略使用的最近 historyWindow 根歷史和預讀的下一根，結果與 Run 完全一致；
+// 交易日誌、資金和權益曲線仍隨回測長度增長。進度回調的 total 在讀完前為 0（未知）。
+// stream 由調用方關閉
+func (e *BacktestEngine) RunStream(stream loader.CandleStream) (metrics.BacktestResult, error) {
+	return e.RunStreamContext(context.Background(), stream)
+}
+
+// RunStreamContext 從K線串流執行回測（支持取消，規則同 RunContext）
+func (e *BacktestEngine) RunStreamContext(ctx context.Context, stream loader.CandleStream) (metrics.BacktestResult, error) {
+	return e.run(ctx, &streamFeed{stream: stream})
+}
+
+// candleFeed 回測主循環的K線來源 ⭐
+//
+// Run 直接索引切片；RunStream 逐根讀取並只保留 historyWindow 根歷史
+type candleFeed interface {
+	// advance 前進到下一根K線；沒有更多K線時返回 ok = false
+	advance() (candle value_objects.Candle, ok bool, err error)
+	// previous 當前K線的上一根（包括斷點恢復時跳過的K線；不存在時返回 false）
+	previous() (value_objects.Candle, bool)
+	// history 當前K線之前最多 historyWindow 根歷史（包括斷點恢復時跳過的K線）
+	history() []value_objects.Candle
+	// peek 下一根K線（不前進）
+	peek() (candle value_objects.Candle, ok bool, err error)
+	// skip 從斷點恢復時跳過前 n 根K線；之後沒有K線時返回 false
+	skip(n int) (bool, error)
+	// total K線總數（串流讀完前未知，返回 0）
+	total() int
+}
+
+// candlePosition 格式化K線位置（總數未知時只顯示索引）
+func candlePosition(i, total int) string {
+	if total == 0 {
+		return fmt.Sprintf("%d", i)
+	}
+	return fmt.Sprintf("%d/%d", i, total)
+}
+
+// sliceFeed 已載入的K線切片（Run 使用，歷史直接切片，不複製）
+type sliceFeed struct {
+	candles []value_objects.Candle
+	i       int // 當前K線索引（advance 前為 -1，斷點恢復後為跳過的最後一根）
+}
+
+func newSliceFeed(candles []value_objects.Candle) *sliceFeed {
+	return &sliceFeed{candles: candles, i: -1}
+}
+
+func (f *sliceFeed) advance() (value_objects.Candle, bool, error) {
+	if f.i+1 >= len(f.candles) {
+		return value_objects.Candle{}, false, nil
+	}
+	f.i++
+	return f.candles[f.i], true, nil
+}
+
+func (f *sliceFeed) previous() (value_objects.Candle, bool) {
+	if f.i < 1 {
+		return value_objects.Candle{}, false
+	}
+	return f.candles[f.i-1], true
+}
+
+func (f *sliceFeed) history() []value_objects.Candle {
+	i := max(f.i, 0)
+	return f.candles[max(0, i-historyWindow):i]
+}
+
+func (f *sliceFeed) peek() (value_objects.Candle, bool, error) {
+	if f.i+1 >= len(f.candles) {
+		return value_objects.Candle{}, false, nil
+	}
+	return f.candles[f.i+1], true, nil
+}
+
+func (f *sliceFeed) skip(n int) (bool, error) {
+	if n >= len(f.candles) {
+		return false, nil
+	}
+	f.i = n - 1
+	return true, nil
+}
+
+func (f *sliceFeed) total() int {
+	return len(f.candles)
+}
+
+// streamFeed 逐根讀取的K線串流，只保留最近 historyWindow 根歷史 ⭐
+type streamFeed struct {
+	stream  loader.CandleStream
+	buf     []value_objects.Candle // 歷史（超過 2 × historyWindow 時壓縮到最近 historyWindow 根）
+	current value_objects.Candle
+	started bool // 是否已 advance 過（current 有效）
+	prev    value_objects.Candle
+	hasPrev bool
+	// 預讀的下一根K線（peek 或開始前的檢查）
+	next      value_objects.Candle
+	nextOK    bool
+	nextErr   error
+	hasNext   bool
+	read      int  // 已從串流讀取的K線數
+	exhausted bool // 串流已讀完
+}
+
+// fetch 讀取下一根K線（優先使用預讀的結果）
+func (f *streamFeed) fetch() (value_objects.Candle, bool, error) {
+	if f.hasNext {
+		f.hasNext = false
+		return f.next, f.nextOK, f.nextErr
+	}
+	if f.exhausted {
+		return value_objects.Candle{}, false, nil
+	}
+	candle, ok, err := f.stream.Next()
+	if err == nil && !ok {
+		f.exhausted = true
+	}
+	if ok {
+		f.read++
+	}
+	return candle, ok, err
+}
+
+func (f *streamFeed) advance() (value_objects.Candle, bool, error) {
+	candle, ok, err := f.fetch()
+	if err != nil || !ok {
+		return value_objects.Candle{}, false, err
+	}
+
+	if f.started {
+		f.remember(f.current)
+	}
+	f.current, f.started = candle, true
+	return candle, true, nil
+}
+
+// remember 把已經過去的K線加入歷史
+func (f *streamFeed) remember(candle value_objects.Candle) {
+	f.buf = append(f.buf, candle)
+	if len(f.buf) >= 2*historyWindow {
+		n := copy(f.buf, f.buf[len(f.buf)-historyWindow:])
+		f.buf = f.buf[:n]
+	}
+	f.prev, f.hasPrev = candle, true
+}
+
+func (f *streamFeed) previous() (value_objects.Candle, bool) {
+	return f.prev, f.hasPrev
+}
+
+func (f *streamFeed) history() []value_objects.Candle {
+	if len(f.buf) > historyWindow {
+		return f.buf[len(f.buf)-historyWindow:]
+	}
+	return f.buf
+}
+
+func (f *streamFeed) peek() (value_objects.Candle, bool, error) {
+	if !f.hasNext {
+		f.next, f.nextOK, f.nextErr = f.fetch()
+		f.hasNext = true
+	}
+	return f.next, f.nextOK, f.nextErr
+}
+
+func (f *streamFeed) skip(n int) (bool, error) {
+	for range n {
+		candle, ok, err := f.fetch()
+		if err != nil || !ok {
+			return false, err
+		}
+		f.remember(candle)
+	}
+	_, ok, err := f.peek()
+	return ok, err
+}
+
+func (f *streamFeed) total() int {
+	if !f.exhausted {
+		return 0
+	}
+	return f.read
+}
+
+// confirmedOnly 過濾掉未完成的K線（RequireConfirmedCandles 時使用，返回新切片，不修改 feed 的歷史）
+func confirmedOnly(candles []value_objects.Candle) []value_objects.Candle {
+	confirmed := make([]value_objects.Candle, 0, len(candles))
+	for _, candle := range candles {
+		if candle.IsConfirmed() {
+			confirmed = append(confirmed, candle)
+		}
+	}
+	return confirmed
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/loader"
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// assertSameRun 兩次回測的結果和交易日誌必須完全一致
+func assertSameRun(t *testing.T, want, got metrics.BacktestResult, wantLog, gotLog []TradeLog) {
+	t.Helper()
+	for _, diff := range metrics.DiffResults(want, got, 0) {
+		t.Errorf("result differs: %s", diff)
+	}
+	if diffs := DiffTradeLogs(wantLog, gotLog); diffs != nil {
+		t.Errorf("trade log differs at %d rows, first: %s", len(diffs), diffs[0])
+	}
+}
+
+// TestRunStream_MatchesRun 測試串流回測與切片回測的結果完全一致 ⭐
+func TestRunStream_MatchesRun(t *testing.T) {
+	candles := testutil.GenerateSine(600)
+
+	baseline, err := NewBacktestEngine(streamTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	want, err := baseline.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want.TotalClosedTrades == 0 || baseline.UnfilledLimitOrders() == 0 {
+		t.Fatalf("Expected closes and unfilled limits in the scenario, got %d / %d",
+			want.TotalClosedTrades, baseline.UnfilledLimitOrders())
+	}
+
+	streamed, _ := NewBacktestEngine(streamTestConfig())
+	got, err := streamed.RunStream(loader.NewSliceStream(candles))
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	assertSameRun(t, want, got, baseline.GetTradeLog(), streamed.GetTradeLog())
+}
+
+// TestRunStream_FromFile 測試從新到舊排序的 OKX JSON 文件串流回測與載入後回測一致
+func TestRunStream_FromFile(t *testing.T) {
+	candles := testutil.GenerateSine(400)
+	rows := make([]string, len(candles))
+	for i, candle := range candles {
+		rows[len(candles)-1-i] = fmt.Sprintf(`["%d","%v","%v","%v","%v","1","1","1","1"]`,
+			candle.Timestamp().UnixMilli(), candle.Open().Value(), candle.High().Value(), candle.Low().Value(), candle.Close().Value())
+	}
+	path := filepath.Join(t.TempDir(), "history.json")
+	content := `{"code":"0","msg":"","data":[` + strings.Join(rows, ",\n") + "]}"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write data file: %v", err)
+	}
+
+	loaded, err := loader.LoadFromJSON(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSON failed: %v", err)
+	}
+	baseline, _ := NewBacktestEngine(streamTestConfig())
+	want, err := baseline.Run(loaded)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	stream, err := loader.OpenJSONStream(path)
+	if err != nil {
+		t.Fatalf("OpenJSONStream failed: %v", err)
+	}
+	defer stream.Close()
+	streamed, _ := NewBacktestEngine(streamTestConfig())
+	got, err := streamed.RunStream(stream)
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	assertSameRun(t, want, got, baseline.GetTradeLog(), streamed.GetTradeLog())
+}
+
+// TestRunStream_ResumeCheckpoint 測試串流回測從斷點恢復（跳過的K線仍進入歷史窗口）
+func TestRunStream_ResumeCheckpoint(t *testing.T) {
+	candles := testutil.GenerateSine(600)
+
+	baseline, _ := NewBacktestEngine(streamTestConfig())
+	want, err := baseline.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	first, _ := NewBacktestEngine(streamTestConfig())
+	stopErr := errors.New("stop after checkpoint")
+	var checkpoint []byte
+	first.SetCheckpointHandler(250, func(candleIndex int, data []byte) error {
+		checkpoint = data
+		return stopErr
+	})
+	if _, err := first.RunStream(loader.NewSliceStream(candles)); !errors.Is(err, stopErr) {
+		t.Fatalf("Expected run to stop at checkpoint, got %v", err)
+	}
+
+	resumed, _ := NewBacktestEngine(streamTestConfig())
+	if err := resumed.RestoreCheckpoint(checkpoint); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	got, err := resumed.RunStream(loader.NewSliceStream(candles))
+	if err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+	assertSameRun(t, want, got, baseline.GetTradeLog(), resumed.GetTradeLog())
+}
+
+// failingStream 讀取 n 根K線後返回錯誤
+type failingStream struct {
+	candles []value_objects.Candle
+	n       int
+}
+
+func (s *failingStream) Next() (value_objects.Candle, bool, error) {
+	if s.n == 0 {
+		return value_objects.Candle{}, false, errors.New("disk error")
+	}
+	s.n--
+	candle := s.candles[0]
+	s.candles = s.candles[1:]
+	return candle, true, nil
+}
+
+// TestRunStream_Errors 測試空串流和讀取錯誤
+func TestRunStream_Errors(t *testing.T) {
+	engine, _ := NewBacktestEngine(streamTestConfig())
+	if _, err := engine.RunStream(loader.NewSliceStream(nil)); !errors.Is(err, ErrNoCandles) {
+		t.Errorf("Expected ErrNoCandles for an empty stream, got %v", err)
+	}
+
+	engine, _ = NewBacktestEngine(streamTestConfig())
+	result, err := engine.RunStream(&failingStream{candles: testutil.GenerateSine(100), n: 50})
+	if err == nil || !strings.Contains(err.Error(), "disk error") {
+		t.Fatalf("Expected the read error to be returned, got %v", err)
+	}
+	if result.InitialBalance == 0 {
+		t.Error("Expected a partial result up to the failed candle")
+	}
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+	"github.com/shopspring/decimal"
+)
+
+// checkpointVersion 斷點格式版本（格式不兼容時遞增）
+const checkpointVersion = 1
+
+// ErrNoCheckpoint 尚未產生任何斷點（需要先執行 Run）
+var ErrNoCheckpoint = errors.New("no checkpoint available")
+
+// CheckpointFunc 斷點回調（candleIndex = 恢復後從哪根K線繼續）
+//
+// 返回錯誤時回測中止，RunContext 返回包裝後的錯誤
+type CheckpointFunc func(candleIndex int, data []byte) error
+
+// runState RunContext 的累計狀態（decimal 以字符串序列化，恢復後精確一致）⭐
+type runState struct {
+	NextCandle            int             // 下一根要處理的K線索引
+	Balance               decimal.Decimal // 可用餘額
+	TradeCounter          int             // 交易計數器
+	TotalOpenedTrades     int             // 總開倉數量
+	TotalProfitGross      decimal.Decimal // 總利潤（基於平均成本，未扣手續費）
+	TotalProfitGrossEntry decimal.Decimal // 總利潤（基於單筆開倉價，未扣手續費）
+	TotalFeesOpen         decimal.Decimal // 開倉總手續費
+	TotalFeesClose        decimal.Decimal // 關倉總手續費
+	SlippageCost          decimal.Decimal // 滑點成本（開倉 + 平倉）
+	OpenPositionValue     decimal.Decimal // 累計持倉總價值
+	RoundRealizedPnL      decimal.Decimal // 當前輪次已實現盈虧
+	RoundClosedValue      decimal.Decimal // 當前輪次累積關倉價值
+	TotalRealizedPnL      decimal.Decimal // 累計已實現盈虧
+	FullPositionDays      map[string]bool // 持倉全滿的日期
+	MaxOpenPositionValue  decimal.Decimal // 最大持倉價值
+}
+
+// engineCheckpoint 回測引擎的完整斷點
+type engineCheckpoint struct {
+	Version            int
+	InstID             string
+	State              runState
+	Tracker            simulator.TrackerSnapshot
+	BalanceSnapshots   []metrics.BalanceSnapshot
+	EquitySnapshots    []metrics.EquitySnapshot
+	TradeLog           []TradeLog
+	BreakEvenRounds    []BreakEvenRound
+	CurrentRoundStats  RoundStats
+	FundingHistory     []FundingRecord
+	IdleCandles        int
+	PendingFunding     float64
+	MaxPendingFunding  float64
+	FundedRoundProfit  float64
+	SkippedUnconfirmed int
+	RejectedAdvice     []RejectedAdvice
+	UnfilledLimits     int
+	OpenCandles        map[string]int
+	PeakEquity         float64
+	HaltedAt           time.Time
+	HaltReason         string
+	// 交易日誌的逐筆累計（串流交易日誌時斷點不含日誌本身；舊斷點沒有這些字段時從 TradeLog 重建）
+	TradeLogFees      float64
+	ReasonAttribution *metrics.ReasonAttribution
+	// 永續合約資金費
+	RoundFundingFee decimal.Decimal
+	TotalFundingFee decimal.Decimal
+	RoundTimeStops  int
+}
+
+// SetCheckpointHandler 設置定期斷點（每處理 interval 根K線調用一次 handler）⭐
+//
+// interval <= 0 表示不定期產生斷點。設置了 handler 或 interval 時，Run 結束時保存最後一個斷點，
+// 可通過 Checkpoint 取得；都未設置時只在 Run 中途結束（取消或出錯）時保存，
+// 正常跑完的回測不序列化交易日誌和已平倉位
+func (e *BacktestEngine) SetCheckpointHandler(interval int, handler CheckpointFunc) {
+	e.checkpointInterval = interval
+	e.checkpointHandler = handler
+}
+
+// Checkpoint 返回最近一次保存的斷點（JSON）
+//
+// 斷點包含餘額、倉位、輪次統計、注資記錄、交易日誌和K線索引，
+// 用 RestoreCheckpoint 載入到相同配置的新引擎後，以同一組K線調用 Run 即可從斷點繼續
+func (e *BacktestEngine) Checkpoint() ([]byte, error) {
+	if e.lastCheckpoint == nil {
+		return nil, ErrNoCheckpoint
+	}
+	return append([]byte{}, e.lastCheckpoint...), nil
+}
+
+// RestoreCheckpoint 載入斷點，下一次 Run 從斷點的K線索引繼續 ⭐
+func (e *BacktestEngine) RestoreCheckpoint(data []byte) error {
+	var cp engineCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d (expected %d)", cp.Version, checkpointVersion)
+	}
+	if cp.InstID != e.config.InstID {
+		return fmt.Errorf("checkpoint instrument %q does not match engine instrument %q", cp.InstID, e.config.InstID)
+	}
+	if cp.State.FullPositionDays == nil {
+		cp.State.FullPositionDays = make(map[string]bool)
+	}
+
+	e.positionTracker.Restore(cp.Tracker)
+	e.calculator.RestoreBalanceSnapshots(cp.BalanceSnapshots)
+	e.calculator.RestoreEquitySnapshots(cp.EquitySnapshots)
+	e.tradeLog = cp.TradeLog
+	e.tradeLogFees = cp.TradeLogFees
+	e.reasonAttribution = cp.ReasonAttribution
+	if e.reasonAttribution == nil {
+		e.tradeLogFees = 0
+		e.reasonAttribution = metrics.NewReasonAttribution()
+		for _, log := range cp.TradeLog {
+			e.tradeLogFees += log.Fee
+			e.reasonAttribution.Add(log)
+		}
+	}
+	e.currentRoundFundingFeeD = cp.RoundFundingFee
+	e.totalFundingFeeD = cp.TotalFundingFee
+	e.roundTimeStops = cp.RoundTimeStops
+	e.breakEvenRounds = cp.BreakEvenRounds
+	e.currentRoundStats = cp.CurrentRoundStats
+	e.fundingHistory = cp.FundingHistory
+	e.idleCandles = cp.IdleCandles
+	e.pendingFunding = cp.PendingFunding
+	e.maxPendingFunding = cp.MaxPendingFunding
+	e.fundedRoundProfit = cp.FundedRoundProfit
+	e.skippedUnconfirmed = cp.SkippedUnconfirmed
+	e.rejectedAdvice = cp.RejectedAdvice
+	e.unfilledLimitOrders = cp.UnfilledLimits
+	e.openCandles = cp.OpenCandles
+	e.peakEquity = cp.PeakEquity
+	e.haltedAt = cp.HaltedAt
+	e.haltReason = cp.HaltReason
+	e.resumeState = &cp.State
+	e.lastCheckpoint = append([]byte{}, data...)
+	return nil
+}
+
+// ResumeTradeID 已載入、尚未恢復的斷點的最後一筆交易 ID（沒有待恢復的斷點時為 0）
+//
+// 串流交易日誌續寫時用來截掉斷點之後寫入的記錄（見 ResumeStreamingTradeLogger）
+func (e *BacktestEngine) ResumeTradeID() int {
+	if e.resumeState == nil {
+		return 0
+	}
+	return e.resumeState.TradeCounter
+}
+
+// checkpointsEnabled 是否設置了斷點回調或定期斷點
+func (e *BacktestEngine) checkpointsEnabled() bool {
+	return e.checkpointHandler != nil || e.checkpointInterval > 0
+}
+
+// saveCheckpoint 序列化當前狀態並保存為最近一次斷點
+func (e *BacktestEngine) saveCheckpoint(state runState) ([]byte, error) {
+	data, err := json.Marshal(engineCheckpoint{
+		Version:            checkpointVersion,
+		InstID:             e.config.InstID,
+		State:              state,
+		Tracker:            e.positionTracker.Snapshot(),
+		BalanceSnapshots:   e.calculator.GetBalanceSnapshots(),
+		EquitySnapshots:    e.calculator.GetEquitySnapshots(),
+		TradeLog:           e.tradeLog,
+		BreakEvenRounds:    e.breakEvenRounds,
+		CurrentRoundStats:  e.currentRoundStats,
+		FundingHistory:     e.fundingHistory,
+		IdleCandles:        e.idleCandles,
+		PendingFunding:     e.pendingFunding,
+		MaxPendingFunding:  e.maxPendingFunding,
+		FundedRoundProfit:  e.fundedRoundProfit,
+		SkippedUnconfirmed: e.skippedUnconfirmed,
+		RejectedAdvice:     e.rejectedAdvice,
+		UnfilledLimits:     e.unfilledLimitOrders,
+		OpenCandles:        e.openCandles,
+		PeakEquity:         e.peakEquity,
+		HaltedAt:           e.haltedAt,
+		HaltReason:         e.haltReason,
+		TradeLogFees:       e.tradeLogFees,
+		ReasonAttribution:  e.reasonAttribution,
+		RoundFundingFee:    e.currentRoundFundingFeeD,
+		TotalFundingFee:    e.totalFundingFeeD,
+		RoundTimeStops:     e.roundTimeStops,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	e.lastCheckpoint = data
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestBacktestEngine_CheckpointResume 測試中途斷點恢復後的結果與一次跑完完全一致 ⭐
+func TestBacktestEngine_CheckpointResume(t *testing.T) {
+	candles := testutil.GenerateWave(600)
+	half := len(candles) / 2
+
+	// 1. 一次跑完（基準）
+	baseline, err := NewBacktestEngine(checkpointTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	want, err := baseline.Run(candles)
+	if err != nil {
+		t.Fatalf("Baseline run failed: %v", err)
+	}
+
+	// 2. 跑到一半時產生斷點後中止
+	first, _ := NewBacktestEngine(checkpointTestConfig())
+	stopErr := errors.New("stop after checkpoint")
+	var checkpoint []byte
+	first.SetCheckpointHandler(half, func(candleIndex int, data []byte) error {
+		checkpoint = data
+		return stopErr
+	})
+	if _, err := first.Run(candles); !errors.Is(err, stopErr) {
+		t.Fatalf("Expected run to stop at checkpoint, got %v", err)
+	}
+	if checkpoint == nil {
+		t.Fatal("Expected a checkpoint at the halfway candle")
+	}
+
+	// 3. 新引擎載入斷點，跑完剩下的K線
+	resumed, _ := NewBacktestEngine(checkpointTestConfig())
+	if err := resumed.RestoreCheckpoint(checkpoint); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	got, err := resumed.Run(candles)
+	if err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+
+	// 確保場景有意義：前後兩半都有交易，且觸發了注資
+	if want.TotalClosedTrades == 0 || len(baseline.fundingHistory) == 0 {
+		t.Fatalf("Expected closes and funding in the scenario, got %d closes, %d fundings",
+			want.TotalClosedTrades, len(baseline.fundingHistory))
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resumed result differs from uninterrupted run:\n got:  %+v\n want: %+v", got, want)
+	}
+	if !reflect.DeepEqual(resumed.GetTradeLog(), baseline.GetTradeLog()) {
+		t.Errorf("Resumed trade log differs (%d vs %d entries)", len(resumed.GetTradeLog()), len(baseline.GetTradeLog()))
+	}
+	if !reflect.DeepEqual(resumed.fundingHistory, baseline.fundingHistory) {
+		t.Errorf("Resumed funding history differs")
+	}
+}
+
+// TestBacktestEngine_Checkpoint_Errors 測試斷點的錯誤情況
+func TestBacktestEngine_Checkpoint_Errors(t *testing.T) {
+	engine, _ := NewBacktestEngine(checkpointTestConfig())
+
+	if _, err := engine.Checkpoint(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("Expected ErrNoCheckpoint before any run, got %v", err)
+	}
+
+	engine.SetCheckpointHandler(0, func(candleIndex int, data []byte) error { return nil })
+	if _, err := engine.Run(testutil.GenerateWave(10)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	data, err := engine.Checkpoint()
+	if err != nil {
+		t.Fatalf("Expected checkpoint after run, got %v", err)
+	}
+
+	// 交易對不一致
+	otherConfig := checkpointTestConfig()
+	otherConfig.InstID = "BTC-USDT-SWAP"
+	other, _ := NewBacktestEngine(otherConfig)
+	if err := other.RestoreCheckpoint(data); err == nil {
+		t.Error("Expected error restoring checkpoint for a different instrument")
+	}
+
+	// 斷點已處理完所有K線
+	same, _ := NewBacktestEngine(checkpointTestConfig())
+	if err := same.RestoreCheckpoint(data); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	if _, err := same.Run(testutil.GenerateWave(10)); err == nil {
+		t.Error("Expected error resuming past the last candle")
+	}
+
+	if err := same.RestoreCheckpoint([]byte("not json")); err == nil {
+		t.Error("Expected error for malformed checkpoint")
+	}
+}
+
+// TestBacktestEngine_Checkpoint_OnlyWhenRequested 測試未設置斷點時正常跑完不產生斷點，取消時仍保存斷點 ⭐
+func TestBacktestEngine_Checkpoint_OnlyWhenRequested(t *testing.T) {
+	engine, _ := NewBacktestEngine(checkpointTestConfig())
+	if _, err := engine.Run(testutil.GenerateWave(10)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := engine.Checkpoint(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("Expected no checkpoint without a handler or interval, got %v", err)
+	}
+
+	// 中途取消（第 2000 根）：保存斷點，可從取消處繼續
+	cancelled, _ := NewBacktestEngine(checkpointTestConfig())
+	ctx := &cancelAfterContext{Context: context.Background(), remaining: 2}
+	if _, err := cancelled.RunContext(ctx, testutil.GenerateFlat(3000)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancellation, got %v", err)
+	}
+	data, err := cancelled.Checkpoint()
+	if err != nil {
+		t.Fatalf("Expected a checkpoint after cancellation, got %v", err)
+	}
+	resumed, _ := NewBacktestEngine(checkpointTestConfig())
+	if err := resumed.RestoreCheckpoint(data); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	if want := 2 * ctxCheckInterval; resumed.resumeState.NextCandle != want {
+		t.Errorf("Expected to resume at candle %d, got %d", want, resumed.resumeState.NextCandle)
+	}
+}
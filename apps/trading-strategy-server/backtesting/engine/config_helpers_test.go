@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// checkpointTestConfig 斷點測試使用的配置（也是 breakEvenTestConfig 的基礎）
+func checkpointTestConfig() BacktestConfig {
+	return BacktestConfig{
+		InitialBalance:        1000.0,
+		FeeRate:               0.0005,
+		InstID:                "ETH-USDT-SWAP",
+		TakeProfitMin:         0.0015,
+		TakeProfitMax:         0.0020,
+		PositionSize:          200,
+		BreakEvenProfitMin:    0,
+		BreakEvenProfitMax:    20,
+		EnableTrendFilter:     false,
+		EnableRedCandleFilter: true,
+		EnableAutoFunding:     true,
+		AutoFundingAmount:     1000,
+		AutoFundingIdle:       10,
+	}
+}
+
+// breakEvenTestConfig 打平平倉模式測試使用的配置
+func breakEvenTestConfig(mode BreakEvenCloseMode) BacktestConfig {
+	config := checkpointTestConfig()
+	config.InitialBalance = 10000
+	config.EnableAutoFunding = false
+	config.BreakEvenCloseMode = mode
+	return config
+}
+
+// verbosityTestConfig 啟用自動注資，兩份報告都有內容
+func verbosityTestConfig() BacktestConfig {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.EnableAutoFunding = true
+	config.AutoFundingAmount = 500.0
+	config.AutoFundingIdle = 5
+	return config
+}
+
+// streamTestConfig 串流測試配置：區間分層間距和趨勢過濾（使用歷史窗口）、touch 限價成交（使用下一根K線）
+//
+// 關閉紅K過濾，讓開倉足夠頻繁，歷史窗口不一致時結果必然不同
+func streamTestConfig() BacktestConfig {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.EnableTrendFilter = true
+	config.GridSpacingMode = grid.GridSpacingRange
+	config.SpacingRangeFraction = 0.005
+	config.EnableRedCandleFilter = false
+	config.LimitFillModel = LimitFillTouch
+	return config
+}
+
+// roundTimeStopTestConfig 時間止損測試使用的配置（不注資，資金足夠在下跌中持續加倉）
+func roundTimeStopTestConfig(maxRoundCandles int) BacktestConfig {
+	return BacktestConfig{
+		InitialBalance:  100000.0,
+		FeeRate:         0.0005,
+		InstID:          "ETH-USDT-SWAP",
+		TakeProfitMin:   0.0015,
+		TakeProfitMax:   0.0020,
+		PositionSize:    200,
+		BarInterval:     5 * time.Minute,
+		MaxRoundCandles: maxRoundCandles,
+	}
+}
+
+// incrementalRecoveryConfig 注資後只靠正常止盈回收的配置（打平目標過高，不會觸發打平退出）
+func incrementalRecoveryConfig(incremental bool) BacktestConfig {
+	return BacktestConfig{
+		InitialBalance:             500.0,
+		FeeRate:                    0.0005,
+		InstID:                     "ETH-USDT-SWAP",
+		TakeProfitMin:              0.0015,
+		TakeProfitMax:              0.0020,
+		PositionSize:               200.0,
+		BreakEvenProfitMin:         1000.0,
+		BreakEvenProfitMax:         2000.0,
+		EnableAutoFunding:          true,
+		AutoFundingAmount:          500.0,
+		AutoFundingIdle:            5,
+		IncrementalFundingRecovery: incremental,
+	}
+}
@@ -0,0 +1,51 @@
+package engine
+
+import "github.com/shopspring/decimal"
+
+// CSVPrecision 交易日誌 CSV 導出的小數位數配置 ⭐
+//
+// 固定的 %.6f 對低價幣（例如 SHIB ≈ 0.00001）會丟失有效數字，
+// 對 BTC 則浪費空間；CSV 中的靜默四捨五入曾掩蓋過盈虧差異。
+type CSVPrecision struct {
+	Price   int // 價格類欄位（Price、AvgCost）
+	Value   int // 金額類欄位（倉位、餘額、盈虧）
+	Percent int // 百分比欄位（PnL%、PnL%_Avg）
+	Fee     int // 手續費
+}
+
+// DefaultCSVPrecision 默認精度（與原有輸出一致：6 位小數，手續費 8 位）
+func DefaultCSVPrecision() CSVPrecision {
+	return CSVPrecision{
+		Price:   6,
+		Value:   6,
+		Percent: 6,
+		Fee:     8,
+	}
+}
+
+// csvExtraPriceDigits 價格欄位比 tick size 多保留的位數（平均成本不在 tick 上）
+const csvExtraPriceDigits = 4
+
+// CSVPrecisionFromTickSize 根據交易對的 tick size 推導精度
+//
+// 價格欄位 = tick size 的小數位數 + 4（平均成本是加權結果，不落在 tick 上），
+// 其他欄位沿用默認值，且價格欄位不低於默認的 6 位。
+//
+// 例如：
+//   - SHIB tick 0.00000001 → 價格 12 位小數
+//   - BTC tick 0.1 → 價格 6 位小數（默認）
+func CSVPrecisionFromTickSize(tickSize float64) CSVPrecision {
+	precision := DefaultCSVPrecision()
+	if tickSize <= 0 {
+		return precision
+	}
+
+	tickDecimals := int(-decimal.NewFromFloat(tickSize).Exponent())
+	if tickDecimals < 0 {
+		tickDecimals = 0
+	}
+	if priceDecimals := tickDecimals + csvExtraPriceDigits; priceDecimals > precision.Price {
+		precision.Price = priceDecimals
+	}
+	return precision
+}
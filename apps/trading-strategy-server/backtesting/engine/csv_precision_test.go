@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestExportTradeLogCSV_LowPricedInstrument 測試低價幣導出時不丟失有效數字
+func TestExportTradeLogCSV_LowPricedInstrument(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "SHIB-USDT-SWAP",
+		TickSize:       0.00000001,
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	const price = 0.00001234
+	const avgCost = 0.0000123456
+	engine.tradeLog = []TradeLog{{
+		TradeID: 1,
+		Time:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Action:  "CLOSE",
+		Price:   price,
+		AvgCost: avgCost,
+		Fee:     0.1,
+		Reason:  "hit_target_0.00",
+	}}
+
+	path := filepath.Join(t.TempDir(), "trades.csv")
+	precision := CSVPrecisionFromTickSize(engine.config.TickSize)
+	if err := engine.ExportTradeLogCSVWithPrecision(path, precision); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	row := readCSVRow(t, path, 1)
+	assertColumn := func(name string, col int, want float64) {
+		got, err := strconv.ParseFloat(row[col], 64)
+		if err != nil {
+			t.Fatalf("%s: failed to parse %q: %v", name, row[col], err)
+		}
+		if math.Abs(got-want)/want > 1e-9 {
+			t.Errorf("%s: expected %v, got %s (significant digits lost)", name, want, row[col])
+		}
+	}
+	assertColumn("Price", 3, price)
+	assertColumn("AvgCost", 9, avgCost)
+
+	// 默認精度會丟失有效數字（對照組）
+	defaultPath := filepath.Join(t.TempDir(), "trades_default.csv")
+	if err := engine.ExportTradeLogCSV(defaultPath); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if got := readCSVRow(t, defaultPath, 1)[3]; got != "0.000012" {
+		t.Errorf("Expected default precision price 0.000012, got %s", got)
+	}
+}
+
+// TestCSVPrecisionFromTickSize 測試根據 tick size 推導精度
+func TestCSVPrecisionFromTickSize(t *testing.T) {
+	tests := []struct {
+		tickSize  float64
+		wantPrice int
+	}{
+		{0, 6},
+		{0.1, 6},         // BTC
+		{0.01, 6},        // ETH
+		{0.0001, 8},      // DOGE
+		{0.00000001, 12}, // SHIB
+	}
+
+	for _, tt := range tests {
+		if got := CSVPrecisionFromTickSize(tt.tickSize).Price; got != tt.wantPrice {
+			t.Errorf("tick %v: expected price precision %d, got %d", tt.tickSize, tt.wantPrice, got)
+		}
+	}
+}
+
+// readCSVRow 讀取 CSV 的指定行
+func readCSVRow(t *testing.T, path string, index int) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open CSV: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if index >= len(records) {
+		t.Fatalf("CSV has %d rows, want index %d", len(records), index)
+	}
+	return records[index]
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// runAndExport 執行回測並導出交易日誌和輪次 CSV
+func runAndExport(t *testing.T, dir string) (tradeLog, rounds []byte) {
+	t.Helper()
+
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(testutil.GenerateSine(600)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	tradeLogPath := filepath.Join(dir, "trades.csv")
+	roundsPath := filepath.Join(dir, "rounds.csv")
+	if err := engine.ExportTradeLogCSV(tradeLogPath); err != nil {
+		t.Fatalf("Failed to export trade log: %v", err)
+	}
+	if err := engine.ExportRoundsToCSV(roundsPath); err != nil {
+		t.Fatalf("Failed to export rounds: %v", err)
+	}
+
+	tradeLog, err = os.ReadFile(tradeLogPath)
+	if err != nil {
+		t.Fatalf("Failed to read trade log: %v", err)
+	}
+	rounds, err = os.ReadFile(roundsPath)
+	if err != nil {
+		t.Fatalf("Failed to read rounds: %v", err)
+	}
+	return tradeLog, rounds
+}
+
+// TestBacktestEngine_Deterministic 測試同一份數據跑兩次，導出的 CSV 逐字節相同 ⭐
+func TestBacktestEngine_Deterministic(t *testing.T) {
+	firstTrades, firstRounds := runAndExport(t, t.TempDir())
+	secondTrades, secondRounds := runAndExport(t, t.TempDir())
+
+	if len(bytes.Split(firstTrades, []byte("\n"))) < 10 || len(firstRounds) == 0 {
+		t.Fatal("Test scenario should produce trades and break-even rounds")
+	}
+	if !bytes.Equal(firstTrades, secondTrades) {
+		t.Error("Trade log CSV differs between identical runs")
+	}
+	if !bytes.Equal(firstRounds, secondRounds) {
+		t.Error("Rounds CSV differs between identical runs")
+	}
+}
+
+// TestBacktestEngine_RandomWalkAccounting 測試隨機遊走行情下的帳目一致性（失敗時用 seed 重放）
+func TestBacktestEngine_RandomWalkAccounting(t *testing.T) {
+	for _, seed := range []int64{1, 7, 42} {
+		engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(testutil.GenerateRandomWalk(seed, 1000, 2500, 0.002))
+		if err != nil {
+			t.Fatalf("seed %d: run failed: %v", seed, err)
+		}
+
+		if result.TotalOpenedTrades == 0 {
+			t.Errorf("seed %d: expected the random walk to open positions", seed)
+		}
+		if result.OpenPositionCount != result.TotalOpenedTrades-result.TotalClosedTrades {
+			t.Errorf("seed %d: open positions %d != opened %d - closed %d",
+				seed, result.OpenPositionCount, result.TotalOpenedTrades, result.TotalClosedTrades)
+		}
+		if fees := result.TotalFeesOpen + result.TotalFeesClose; math.Abs(result.TotalFeesPaid-fees) > 1e-6 {
+			t.Errorf("seed %d: total fees %.6f != open + close %.6f", seed, result.TotalFeesPaid, fees)
+		}
+		for _, anomaly := range ValidateTradeLog(engine.GetTradeLog()) {
+			t.Errorf("seed %d: trade log anomaly: %s", seed, anomaly)
+		}
+	}
+}
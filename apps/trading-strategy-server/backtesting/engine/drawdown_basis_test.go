@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestDrawdownBasis_SelectsMaxDrawdown 測試回撤基礎只決定 MaxDrawdown 取哪一個，兩種回撤都輸出 ⭐
+func TestDrawdownBasis_SelectsMaxDrawdown(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(30, 0)
+	run := func(basis metrics.DrawdownBasis) metrics.BacktestResult {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.DrawdownBasis = basis
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return result
+	}
+
+	cash := run(metrics.DrawdownBasisCash)
+	equity := run(metrics.DrawdownBasisEquity)
+	if cash.MaxDrawdownEquity <= 0 {
+		t.Fatalf("Expected an equity drawdown while holding through the dip, got %.4f%%", cash.MaxDrawdownEquity)
+	}
+	if cash.MaxDrawdown != cash.MaxDrawdownCash || equity.MaxDrawdown != equity.MaxDrawdownEquity {
+		t.Errorf("Expected MaxDrawdown to follow the basis, got cash %.4f%% / equity %.4f%%", cash.MaxDrawdown, equity.MaxDrawdown)
+	}
+	if cash.MaxDrawdownCash != equity.MaxDrawdownCash || cash.MaxDrawdownEquity != equity.MaxDrawdownEquity {
+		t.Error("Expected the basis not to change either drawdown")
+	}
+}
+
+// TestDrawdownBasis_Unknown 測試未知的回撤基礎
+func TestDrawdownBasis_Unknown(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.DrawdownBasis = "peak_to_trough"
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for unknown drawdown basis, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkDrawdownHalt 回撤超過 MaxDrawdownHalt 時觸發熔斷 ⭐
+//
+// drawdown 由 updateDrawdown 計算；equity 應扣除待回收注資：注資是外部資金，計入會抬高峰值，回收時被誤判為回撤。
+// 熔斷只觸發一次，觸發後本次回測不再開倉；返回 true 表示本根K線剛觸發熔斷
+func (e *BacktestEngine) checkDrawdownHalt(candleTime time.Time, equity, drawdown float64) bool {
+	if e.config.MaxDrawdownHalt <= 0 || e.Halted() {
+		return false
+	}
+	if drawdown < e.config.MaxDrawdownHalt {
+		return false
+	}
+
+	e.haltedAt = candleTime
+	e.haltReason = fmt.Sprintf(
+		"drawdown_halt: equity=%.2f peak=%.2f drawdown=%.2f%% (max %.2f%%)",
+		equity,
+		e.peakEquity,
+		drawdown*100,
+		e.config.MaxDrawdownHalt*100,
+	)
+	return true
+}
+
+// Halted 是否已觸發回撤熔斷
+func (e *BacktestEngine) Halted() bool {
+	return !e.haltedAt.IsZero()
+}
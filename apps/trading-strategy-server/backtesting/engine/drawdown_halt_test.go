@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// runDrawdownHalt 在持續暴跌行情中以指定熔斷比例執行回測
+func runDrawdownHalt(t *testing.T, maxDrawdown float64, forceClose bool) (*BacktestEngine, metrics.BacktestResult) {
+	t.Helper()
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.EnableRedCandleFilter = false
+	config.MaxDrawdownHalt = maxDrawdown
+	config.HaltForceClose = forceClose
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateDipRecovery(200, 0))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	return engine, result
+}
+
+// TestDrawdownHalt_StopsOpeningInCrash 測試暴跌時權益回撤達到閾值後停止開倉 ⭐
+func TestDrawdownHalt_StopsOpeningInCrash(t *testing.T) {
+	_, unhalted := runDrawdownHalt(t, 0, false)
+	if !unhalted.HaltedAt.IsZero() {
+		t.Fatalf("Expected no halt when MaxDrawdownHalt is 0, halted at %v", unhalted.HaltedAt)
+	}
+
+	engine, result := runDrawdownHalt(t, 0.02, false)
+	if result.HaltedAt.IsZero() {
+		t.Fatal("Expected the engine to halt during the crash")
+	}
+	if !strings.HasPrefix(result.HaltReason, "drawdown_halt:") {
+		t.Errorf("Unexpected halt reason: %q", result.HaltReason)
+	}
+
+	// 熔斷後沒有新開倉
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "OPEN" && log.Time.After(result.HaltedAt) {
+			t.Fatalf("Unexpected OPEN at %v after halt at %v", log.Time, result.HaltedAt)
+		}
+	}
+	if result.TotalOpenedTrades >= unhalted.TotalOpenedTrades {
+		t.Errorf("Expected fewer opens after halt, got %d vs %d without halt",
+			result.TotalOpenedTrades, unhalted.TotalOpenedTrades)
+	}
+
+	// 閾值越寬，熔斷越晚
+	_, looser := runDrawdownHalt(t, 0.04, false)
+	if looser.HaltedAt.IsZero() || !looser.HaltedAt.After(result.HaltedAt) {
+		t.Errorf("Expected 4%% halt (%v) after 2%% halt (%v)", looser.HaltedAt, result.HaltedAt)
+	}
+}
+
+// TestDrawdownHalt_ForceClose 測試熔斷時強制平掉所有倉位
+func TestDrawdownHalt_ForceClose(t *testing.T) {
+	engine, result := runDrawdownHalt(t, 0.02, true)
+	if result.HaltedAt.IsZero() {
+		t.Fatal("Expected the engine to halt during the crash")
+	}
+	if result.OpenPositionCount != 0 {
+		t.Errorf("Expected no open positions after halt force close, got %d", result.OpenPositionCount)
+	}
+
+	haltCloses := 0
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "CLOSE" && log.Reason == metrics.ReasonDrawdownHalt {
+			if !log.Time.Equal(result.HaltedAt) {
+				t.Errorf("Expected halt close at %v, got %v", result.HaltedAt, log.Time)
+			}
+			haltCloses++
+		}
+	}
+	if haltCloses == 0 {
+		t.Fatal("Expected drawdown_halt closes in the trade log")
+	}
+	if _, ok := result.PnLByReason[metrics.ReasonDrawdownHalt]; !ok {
+		t.Errorf("Expected %s in PnL attribution, got %v", metrics.ReasonDrawdownHalt, result.PnLByReason)
+	}
+}
+
+// TestDrawdownHalt_InvalidThreshold 測試無效的熔斷比例
+func TestDrawdownHalt_InvalidThreshold(t *testing.T) {
+	for _, value := range []float64{-0.1, 1, 5} {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.MaxDrawdownHalt = value
+		if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Expected error for max drawdown halt %v", value)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DrawdownBand 回撤分檔：回撤達到 Drawdown 時，開倉大小乘以 SizeMultiplier ⭐
+type DrawdownBand struct {
+	Drawdown       float64 // 回撤比例下限（例: 0.1 = 10%）
+	SizeMultiplier float64 // 開倉大小倍數（例: 0.5 = 半倉）
+}
+
+// ParseDrawdownThrottle 解析命令行的回撤分檔，格式 "回撤:倍數,回撤:倍數"（例: "0.1:0.5,0.2:0.25"）
+//
+// 空字符串 = 不啟用
+func ParseDrawdownThrottle(value string) ([]DrawdownBand, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var bands []DrawdownBand
+	for _, part := range strings.Split(value, ",") {
+		drawdown, multiplier, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid drawdown band %q: expected drawdown:multiplier", part)
+		}
+		band := DrawdownBand{}
+		var err error
+		if band.Drawdown, err = strconv.ParseFloat(drawdown, 64); err != nil {
+			return nil, fmt.Errorf("invalid drawdown in band %q: %w", part, err)
+		}
+		if band.SizeMultiplier, err = strconv.ParseFloat(multiplier, 64); err != nil {
+			return nil, fmt.Errorf("invalid size multiplier in band %q: %w", part, err)
+		}
+		bands = append(bands, band)
+	}
+	return bands, nil
+}
+
+// validateDrawdownThrottle 回撤比例在 (0, 1) 且不重複，倍數為正
+func validateDrawdownThrottle(bands []DrawdownBand) error {
+	seen := make(map[float64]bool, len(bands))
+	for _, band := range bands {
+		if band.Drawdown <= 0 || band.Drawdown >= 1 {
+			return fmt.Errorf("drawdown throttle band drawdown must be in (0, 1), got %v", band.Drawdown)
+		}
+		if band.SizeMultiplier <= 0 {
+			return fmt.Errorf("drawdown throttle size multiplier must be positive, got %v", band.SizeMultiplier)
+		}
+		if seen[band.Drawdown] {
+			return fmt.Errorf("duplicate drawdown throttle band at %v", band.Drawdown)
+		}
+		seen[band.Drawdown] = true
+	}
+	return nil
+}
+
+// updateDrawdown 更新權益峰值，返回當前權益相對峰值的回撤比例
+//
+// equity 應扣除待回收注資（同 checkDrawdownHalt）
+func (e *BacktestEngine) updateDrawdown(equity float64) float64 {
+	if equity > e.peakEquity {
+		e.peakEquity = equity
+	}
+	if e.peakEquity <= 0 {
+		return 0
+	}
+	return (e.peakEquity - equity) / e.peakEquity
+}
+
+// throttleMultiplier 按當前回撤取開倉大小倍數 ⭐
+//
+// 取回撤已達到的最深一檔；未達到任何一檔（或未配置）時返回 1。
+// 每根K線重新計算，權益回升到較淺的檔位時倉位隨之恢復
+func (e *BacktestEngine) throttleMultiplier(drawdown float64) float64 {
+	multiplier := 1.0
+	deepest := 0.0
+	for _, band := range e.config.DrawdownThrottle {
+		if drawdown >= band.Drawdown && band.Drawdown > deepest {
+			deepest = band.Drawdown
+			multiplier = band.SizeMultiplier
+		}
+	}
+	return multiplier
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestDrawdownThrottle_HalvesOpensInBand 測試回撤進入分檔後開倉大小減半 ⭐
+func TestDrawdownThrottle_HalvesOpensInBand(t *testing.T) {
+	// 以同一行情的熔斷時間作為回撤首次達到 2% 的時間點
+	_, halted := runDrawdownHalt(t, 0.02, false)
+	if halted.HaltedAt.IsZero() {
+		t.Fatal("Expected the crash to reach a 2% drawdown")
+	}
+
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.EnableRedCandleFilter = false
+	config.DrawdownThrottle = []DrawdownBand{{Drawdown: 0.02, SizeMultiplier: 0.5}}
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(testutil.GenerateDipRecovery(200, 0)); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	full, half := 0, 0
+	for _, log := range engine.GetTradeLog() {
+		if log.Action != "OPEN" {
+			continue
+		}
+		want := config.PositionSize
+		if !log.Time.Before(halted.HaltedAt) {
+			want = config.PositionSize / 2
+			half++
+		} else {
+			full++
+		}
+		if math.Abs(log.PositionSize-want) > 1e-9 {
+			t.Fatalf("OPEN at %v: expected size %.2f, got %.2f", log.Time, want, log.PositionSize)
+		}
+		if want != config.PositionSize && !strings.Contains(log.Reason, "drawdown_throttle:") {
+			t.Errorf("Expected throttled OPEN reason to mention the throttle, got %q", log.Reason)
+		}
+	}
+	if full == 0 || half == 0 {
+		t.Fatalf("Expected opens both before and inside the band, got %d full / %d half", full, half)
+	}
+}
+
+// TestDrawdownThrottle_DeepestBand 測試取已達到的最深一檔
+func TestDrawdownThrottle_DeepestBand(t *testing.T) {
+	engine := &BacktestEngine{config: BacktestConfig{DrawdownThrottle: []DrawdownBand{
+		{Drawdown: 0.2, SizeMultiplier: 0.25},
+		{Drawdown: 0.1, SizeMultiplier: 0.5},
+	}}}
+
+	cases := map[float64]float64{0: 1, 0.05: 1, 0.1: 0.5, 0.15: 0.5, 0.2: 0.25, 0.5: 0.25}
+	for drawdown, want := range cases {
+		if got := engine.throttleMultiplier(drawdown); got != want {
+			t.Errorf("drawdown %.2f: expected multiplier %v, got %v", drawdown, want, got)
+		}
+	}
+}
+
+// TestParseDrawdownThrottle 測試命令行分檔解析和配置驗證
+func TestParseDrawdownThrottle(t *testing.T) {
+	bands, err := ParseDrawdownThrottle("0.1:0.5, 0.2:0.25")
+	if err != nil {
+		t.Fatalf("Failed to parse bands: %v", err)
+	}
+	want := []DrawdownBand{{Drawdown: 0.1, SizeMultiplier: 0.5}, {Drawdown: 0.2, SizeMultiplier: 0.25}}
+	if !reflect.DeepEqual(bands, want) {
+		t.Errorf("Expected %v, got %v", want, bands)
+	}
+	if bands, err := ParseDrawdownThrottle(""); err != nil || bands != nil {
+		t.Errorf("Expected empty value to disable the throttle, got %v / %v", bands, err)
+	}
+	if _, err := ParseDrawdownThrottle("0.1"); err == nil {
+		t.Error("Expected error for a band without multiplier")
+	}
+
+	for _, bands := range [][]DrawdownBand{
+		{{Drawdown: 0, SizeMultiplier: 0.5}},
+		{{Drawdown: 0.1, SizeMultiplier: 0}},
+		{{Drawdown: 0.1, SizeMultiplier: 0.5}, {Drawdown: 0.1, SizeMultiplier: 0.25}},
+	} {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.DrawdownThrottle = bands
+		if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %v, got %v", bands, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package engine
+
+import "time"
+
+// BacktestEventType 回測事件類型
+type BacktestEventType string
+
+const (
+	EventPositionOpened  BacktestEventType = "position_opened"  // 開倉
+	EventPositionClosed  BacktestEventType = "position_closed"  // 平倉（止盈或打平）
+	EventRoundCompleted  BacktestEventType = "round_completed"  // 輪次結束（止盈或打平後全部平倉）
+	EventFundingInjected BacktestEventType = "funding_injected" // 自動注資
+)
+
+// BacktestEvent 回測引擎在狀態轉換時發出的事件 ⭐
+//
+// 不同事件類型使用的欄位：
+//   - PositionOpened: PositionID, Price（開倉價）, Size, Fee, Balance
+//   - PositionClosed: PositionID, Price（平倉價）, Size, Fee, RealizedPnL, Balance, Reason
+//   - RoundCompleted: RoundStats, Price, Balance, Reason（打平結束時另有 Round）
+//   - FundingInjected: Funding, Balance
+type BacktestEvent struct {
+	Type        BacktestEventType // 事件類型
+	Time        time.Time         // 事件時間（K線時間）
+	CandleIndex int               // K線索引
+	PositionID  string            // 倉位ID
+	Price       float64           // 成交價格
+	Size        float64           // 倉位大小（USDT）
+	Fee         float64           // 手續費
+	RealizedPnL float64           // 已實現盈虧（基於平均成本，扣除手續費）
+	Balance     float64           // 事件發生後的餘額
+	Reason      string            // 原因
+	Round       *BreakEvenRound   // 打平輪次記錄（僅打平結束的 RoundCompleted）
+	RoundStats  *RoundStats       // 結束輪次的統計（僅 RoundCompleted）
+	Funding     *FundingRecord    // 注資記錄（僅 FundingInjected）
+}
+
+// EventSink 回測事件接收端（用於儀表板、串流進度等）
+type EventSink interface {
+	Emit(event BacktestEvent)
+}
+
+// NoopEventSink 丟棄所有事件（默認）
+type NoopEventSink struct{}
+
+// Emit 丟棄事件
+func (NoopEventSink) Emit(BacktestEvent) {}
+
+// SliceEventSink 按順序收集所有事件（用於測試）
+type SliceEventSink struct {
+	Events []BacktestEvent
+}
+
+// Emit 追加事件
+func (s *SliceEventSink) Emit(event BacktestEvent) {
+	s.Events = append(s.Events, event)
+}
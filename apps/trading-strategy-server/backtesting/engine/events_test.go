@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// TestBacktestEngine_Events_OpenThenClose 測試開倉後止盈平倉的事件順序
+func TestBacktestEngine_Events_OpenThenClose(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	sink := &SliceEventSink{}
+	engine.SetEventSink(sink)
+
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// K1: 開倉 @ 2497.5（市價 -0.1%），止盈 @ 2501.25
+	c1, _ := value_objects.NewCandle(2500, 2500, 2495, 2500, baseTime)
+	// K2: High 觸及止盈價 → 平倉，隨後再次開倉
+	c2, _ := value_objects.NewCandle(2500, 2510, 2499, 2505, baseTime.Add(5*time.Minute))
+
+	if _, err := engine.Run([]value_objects.Candle{c1, c2}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expected := []BacktestEventType{
+		EventPositionOpened,
+		EventPositionClosed,
+		EventRoundCompleted,
+		EventPositionOpened,
+	}
+
+	if len(sink.Events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(sink.Events), sink.Events)
+	}
+
+	for i, want := range expected {
+		if sink.Events[i].Type != want {
+			t.Errorf("event %d: expected %s, got %s", i, want, sink.Events[i].Type)
+		}
+	}
+
+	opened, closed := sink.Events[0], sink.Events[1]
+	if opened.PositionID != closed.PositionID {
+		t.Errorf("Expected close of %s, got %s", opened.PositionID, closed.PositionID)
+	}
+	if opened.CandleIndex != 0 || closed.CandleIndex != 1 {
+		t.Errorf("Expected candle indexes 0 and 1, got %d and %d", opened.CandleIndex, closed.CandleIndex)
+	}
+	if opened.Price != 2497.5 {
+		t.Errorf("Expected open price 2497.5, got %.2f", opened.Price)
+	}
+	if closed.RealizedPnL <= 0 {
+		t.Errorf("Expected positive realized PnL on take-profit, got %.4f", closed.RealizedPnL)
+	}
+
+	// 唯一倉位止盈後輪次結束
+	round := sink.Events[2]
+	if round.RoundStats == nil || round.RoundStats.RoundID != 1 || round.RoundStats.NormalCloseCount != 1 {
+		t.Errorf("Expected round 1 completed by one normal close, got %+v", round.RoundStats)
+	}
+	if round.Round != nil {
+		t.Errorf("Expected no break-even record for a take-profit round, got %+v", round.Round)
+	}
+}
+
+// TestBacktestEngine_Events_DefaultNoop 測試未設置接收端時不影響回測
+func TestBacktestEngine_Events_DefaultNoop(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	engine.SetEventSink(nil)
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2500, time.Now())
+	if _, err := engine.Run([]value_objects.Candle{candle}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
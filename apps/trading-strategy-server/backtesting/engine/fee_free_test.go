@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestDisableFees_NetEqualsGross 測試免手續費模擬的淨利潤等於毛利，收費模擬扣除手續費和資金費 ⭐
+func TestDisableFees_NetEqualsGross(t *testing.T) {
+	candles := testutil.GenerateWave(600)
+	run := func(disableFees bool) (metrics.BacktestResult, *BacktestEngine) {
+		config := checkpointTestConfig()
+		config.FundingFeeRate = 0.0001
+		config.FundingFeeInterval = time.Hour
+		config.ForceCloseAtEnd = true // 平掉所有倉位，淨利潤只由已實現部分組成
+		config.DisableFees = disableFees
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.TotalClosedTrades == 0 {
+			t.Fatal("Expected closed trades in the scenario")
+		}
+		return result, engine
+	}
+
+	free, freeEngine := run(true)
+	if free.TotalFeesOpen != 0 || free.TotalFeesClose != 0 || free.FundingFee != 0 || freeEngine.GetTotalFees() != 0 {
+		t.Errorf("Expected no fees when disabled, got open %.8f close %.8f funding %.8f trade log %.8f",
+			free.TotalFeesOpen, free.TotalFeesClose, free.FundingFee, freeEngine.GetTotalFees())
+	}
+	if math.Abs(free.NetProfit-free.TotalProfitGross) > 1e-9 {
+		t.Errorf("Expected fee-free net profit %.8f to equal gross %.8f", free.NetProfit, free.TotalProfitGross)
+	}
+
+	paid, _ := run(false)
+	if paid.TotalFeesPaid <= 0 || paid.FundingFee <= 0 {
+		t.Fatalf("Expected trading and funding fees when enabled, got %.8f / %.8f", paid.TotalFeesPaid, paid.FundingFee)
+	}
+	if want := paid.TotalProfitGross - paid.TotalFeesPaid - paid.FundingFee; math.Abs(paid.NetProfit-want) > 1e-9 {
+		t.Errorf("Expected net profit %.8f (gross - fees - funding), got %.8f", want, paid.NetProfit)
+	}
+}
+
+// TestDisableFees_UnrealizedEstimate 測試免手續費模擬的未實現盈虧不預估平倉手續費
+func TestDisableFees_UnrealizedEstimate(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(30, 0)
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.DisableFees = true
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.OpenPositionCount == 0 {
+		t.Fatal("Expected open positions after the dip")
+	}
+
+	lastPrice := candles[len(candles)-1].Close().Value()
+	if want := engine.positionTracker.CalculateUnrealizedPnL(lastPrice, 0); math.Abs(result.UnrealizedPnL-want) > 1e-9 {
+		t.Errorf("Expected unrealized PnL %.8f without a close fee estimate, got %.8f", want, result.UnrealizedPnL)
+	}
+	if want := result.TotalProfitGross + result.UnrealizedPnL; math.Abs(result.NetProfit-want) > 1e-9 {
+		t.Errorf("Expected net profit %.8f (gross + unrealized), got %.8f", want, result.NetProfit)
+	}
+}
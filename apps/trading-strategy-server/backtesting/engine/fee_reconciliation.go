@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// feeReconciliationTolerance 手續費對賬允許的誤差（USDT，1 美分）
+//
+// 交易日誌按 float64 逐筆累加，decimal 賬本按精確值累加，正常時只有浮點誤差
+const feeReconciliationTolerance = 0.01
+
+// reconcileFees 手續費對賬：交易日誌逐筆累計的手續費（GetTotalFees）與 decimal 賬本（開倉 + 關倉）比較 ⭐
+//
+// 返回差額（交易日誌 - 賬本）；超過 feeReconciliationTolerance 時返回 ErrFeeMismatch，
+// 說明某處漏記或重複記錄了手續費
+func (e *BacktestEngine) reconcileFees(totalFeesOpenD, totalFeesCloseD decimal.Decimal) (float64, error) {
+	ledger := totalFeesOpenD.Add(totalFeesCloseD).InexactFloat64()
+	diff := e.GetTotalFees() - ledger
+	if math.Abs(diff) > feeReconciliationTolerance {
+		return diff, fmt.Errorf("%w: trade log %.8f, ledger %.8f (open %.8f + close %.8f)",
+			ErrFeeMismatch, e.GetTotalFees(), ledger, totalFeesOpenD.InexactFloat64(), totalFeesCloseD.InexactFloat64())
+	}
+	return diff, nil
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestFeeReconciliation_CleanRun 測試正常回測的手續費賬本與交易日誌一致
+func TestFeeReconciliation_CleanRun(t *testing.T) {
+	engine, err := NewBacktestEngine(checkpointTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateWave(600))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if math.Abs(result.FeeLedgerDiff) > 1e-9 {
+		t.Errorf("Expected fee ledger to match the trade log, got diff %.12f", result.FeeLedgerDiff)
+	}
+	if want := result.TotalFeesOpen + result.TotalFeesClose; math.Abs(engine.GetTotalFees()-want) > 1e-9 {
+		t.Errorf("Expected trade log fees %.8f, got %.8f", want, engine.GetTotalFees())
+	}
+}
+
+// TestFeeReconciliation_CatchesDoubleCountedFee 測試賬本多記一筆開倉手續費時 Run 返回 ErrFeeMismatch ⭐
+//
+// 從中途斷點恢復前把一筆開倉手續費重複計入賬本（模擬累加時差一筆的錯誤），交易日誌保持不變
+func TestFeeReconciliation_CatchesDoubleCountedFee(t *testing.T) {
+	candles := testutil.GenerateWave(600)
+	first, err := NewBacktestEngine(checkpointTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	stopErr := errors.New("stop after checkpoint")
+	var data []byte
+	first.SetCheckpointHandler(len(candles)/2, func(candleIndex int, checkpoint []byte) error {
+		data = checkpoint
+		return stopErr
+	})
+	if _, err := first.Run(candles); !errors.Is(err, stopErr) {
+		t.Fatalf("Expected run to stop at checkpoint, got %v", err)
+	}
+
+	var cp engineCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		t.Fatalf("Failed to decode checkpoint: %v", err)
+	}
+	openFeeD := decimal.NewFromFloat(checkpointTestConfig().PositionSize * checkpointTestConfig().FeeRate)
+	cp.State.TotalFeesOpen = cp.State.TotalFeesOpen.Add(openFeeD)
+	tampered, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("Failed to encode checkpoint: %v", err)
+	}
+
+	resumed, err := NewBacktestEngine(checkpointTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if err := resumed.RestoreCheckpoint(tampered); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	result, err := resumed.Run(candles)
+	if !errors.Is(err, ErrFeeMismatch) {
+		t.Fatalf("Expected ErrFeeMismatch, got %v", err)
+	}
+	if math.Abs(result.FeeLedgerDiff+openFeeD.InexactFloat64()) > 1e-9 {
+		t.Errorf("Expected fee ledger diff -%.4f, got %.8f", openFeeD.InexactFloat64(), result.FeeLedgerDiff)
+	}
+}
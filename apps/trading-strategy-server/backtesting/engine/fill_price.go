@@ -0,0 +1,39 @@
+package engine
+
+import "dizzycode.xyz/shared/domain/value_objects"
+
+// FillPriceModel K線內成交價格假設 ⭐
+//
+// 回測只有 OHLC，不知道K線內價格的先後順序；不同假設對結果影響很大，
+// 用 Optimistic / Pessimistic 可以為同一組參數估出結果的上下界
+type FillPriceModel string
+
+const (
+	FillPriceOptimistic  FillPriceModel = "optimistic"  // 最佳K線內價格：High 觸及目標價即止盈；打平按收盤價成交（默認）
+	FillPricePessimistic FillPriceModel = "pessimistic" // 最差觸價順序：High 必須穿越目標價才止盈；打平按 Low 成交
+	FillPriceCloseOnly   FillPriceModel = "close_only"  // 忽略K線內極值：收盤價達到目標價才止盈；打平按收盤價成交
+)
+
+// takeProfitTriggered 判斷當前K線是否觸發止盈（成交價固定為目標價）
+//
+// Pessimistic 下僅觸及目標價不算成交：限價單排在同價位隊列中，只有價格穿越才能確定成交
+func (m FillPriceModel) takeProfitTriggered(candle value_objects.Candle, targetPrice float64) bool {
+	switch m {
+	case FillPricePessimistic:
+		return candle.High().Value() > targetPrice
+	case FillPriceCloseOnly:
+		return candle.Close().Value() >= targetPrice
+	default:
+		return candle.High().Value() >= targetPrice
+	}
+}
+
+// breakEvenFillPrice 打平退出的成交價格
+//
+// 打平信號基於收盤價計算；Pessimistic 假設信號出現後K線還會走到最低價，按 Low 成交
+func (m FillPriceModel) breakEvenFillPrice(candle value_objects.Candle) float64 {
+	if m == FillPricePessimistic {
+		return candle.Low().Value()
+	}
+	return candle.Close().Value()
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+func TestFillPriceModel_TakeProfitTriggered(t *testing.T) {
+	// High 剛好觸及目標價，收盤價低於目標價
+	candle, err := value_objects.NewCandle(2500, 2510, 2495, 2505, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to create candle: %v", err)
+	}
+
+	tests := []struct {
+		model  FillPriceModel
+		target float64
+		want   bool
+	}{
+		{"", 2510, true},
+		{FillPriceOptimistic, 2510, true},
+		{FillPricePessimistic, 2510, false},
+		{FillPricePessimistic, 2509, true},
+		{FillPriceCloseOnly, 2509, false},
+		{FillPriceCloseOnly, 2505, true},
+	}
+	for _, tt := range tests {
+		if got := tt.model.takeProfitTriggered(candle, tt.target); got != tt.want {
+			t.Errorf("%q target %.0f: expected %v, got %v", tt.model, tt.target, tt.want, got)
+		}
+	}
+
+	if got := FillPricePessimistic.breakEvenFillPrice(candle); got != 2495 {
+		t.Errorf("Expected pessimistic break-even fill at low 2495, got %v", got)
+	}
+	if got := FillPriceOptimistic.breakEvenFillPrice(candle); got != 2505 {
+		t.Errorf("Expected optimistic break-even fill at close 2505, got %v", got)
+	}
+}
+
+// TestFillPriceModel_PessimisticLowerPnL 測試同一組K線上悲觀成交假設的盈虧低於樂觀假設 ⭐
+func TestFillPriceModel_PessimisticLowerPnL(t *testing.T) {
+	candles := testutil.GenerateSine(600)
+
+	netProfit := func(model FillPriceModel) float64 {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.FillPriceModel = model
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return result.NetProfit
+	}
+
+	optimistic := netProfit(FillPriceOptimistic)
+	pessimistic := netProfit(FillPricePessimistic)
+	closeOnly := netProfit(FillPriceCloseOnly)
+	t.Logf("net profit: optimistic %.4f, close_only %.4f, pessimistic %.4f", optimistic, closeOnly, pessimistic)
+
+	if pessimistic >= optimistic {
+		t.Errorf("Expected pessimistic net profit below optimistic, got %.4f >= %.4f", pessimistic, optimistic)
+	}
+	if closeOnly > optimistic {
+		t.Errorf("Expected close-only net profit not above optimistic, got %.4f > %.4f", closeOnly, optimistic)
+	}
+
+	invalid := breakEvenTestConfig(BreakEvenClosePerPosition)
+	invalid.FillPriceModel = "random"
+	if _, err := NewBacktestEngine(invalid); !errors.Is(err, ErrInvalidConfig) {
+		t.Error("Expected error for unknown fill price model")
+	}
+}
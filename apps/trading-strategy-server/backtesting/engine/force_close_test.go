@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestForceCloseAtEnd_RealizesOpenPositions 測試回測結束強制平倉：未實現盈虧轉為已實現 ⭐
+func TestForceCloseAtEnd_RealizesOpenPositions(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(40, 0) // 持續下跌，結束時仍有持倉
+
+	run := func(forceClose bool) (*BacktestEngine, metrics.BacktestResult) {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.ForceCloseAtEnd = forceClose
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return engine, result
+	}
+
+	heldEngine, held := run(false)
+	closedEngine, closed := run(true)
+
+	if held.OpenPositionCount == 0 {
+		t.Fatal("Expected open positions at the end without force close")
+	}
+	if closed.OpenPositionCount != 0 || closed.UnrealizedPnL != 0 {
+		t.Fatalf("Expected no open positions after force close, got %d (unrealized %.4f)",
+			closed.OpenPositionCount, closed.UnrealizedPnL)
+	}
+
+	// 每個剩餘倉位都有一筆 backtest_end 平倉記錄
+	forceCloses := 0
+	for _, log := range closedEngine.GetTradeLog() {
+		if log.Action == "CLOSE" && log.Reason == metrics.ReasonBacktestEnd {
+			forceCloses++
+		}
+	}
+	if forceCloses != held.OpenPositionCount {
+		t.Errorf("Expected %d backtest_end closes, got %d", held.OpenPositionCount, forceCloses)
+	}
+
+	// 已實現盈虧吸收了原本的未實現盈虧（未實現盈虧不含已扣除的開倉手續費）
+	openFees := 0.0
+	for _, pos := range heldEngine.positionTracker.GetOpenPositions() {
+		openFees += pos.Size * heldEngine.config.FeeRate
+	}
+	heldRealized := heldEngine.positionTracker.CalculateTotalRealizedPnL()
+	closedRealized := closedEngine.positionTracker.CalculateTotalRealizedPnL()
+	if want := heldRealized + held.UnrealizedPnL - openFees; math.Abs(closedRealized-want) > 1e-6 {
+		t.Errorf("Expected realized PnL %.6f (%.6f + unrealized %.6f - open fees %.6f), got %.6f",
+			want, heldRealized, held.UnrealizedPnL, openFees, closedRealized)
+	}
+
+	// 淨利潤不因強制平倉而改變
+	if math.Abs(closed.NetProfit-held.NetProfit) > 1e-6 {
+		t.Errorf("Expected net profit unchanged, got %.6f vs %.6f", closed.NetProfit, held.NetProfit)
+	}
+}
+
+// disturbingSink 第一筆強制平倉後向追蹤器加入一筆倉位，使之後的平倉因平均成本不一致而失敗
+type disturbingSink struct {
+	engine *BacktestEngine
+	done   bool
+}
+
+func (s *disturbingSink) Emit(event BacktestEvent) {
+	if s.done || event.Type != EventPositionClosed || event.Reason != metrics.ReasonBacktestEnd {
+		return
+	}
+	s.done = true
+	if _, err := s.engine.positionTracker.AddPosition(1000, 200, event.Time, 1010); err != nil {
+		panic(err)
+	}
+}
+
+// TestForceCloseAtEnd_ReportsCloseFailure 測試強制平倉失敗時 Run 返回帶倉位ID的錯誤，失敗的倉位保持持倉 ⭐
+func TestForceCloseAtEnd_ReportsCloseFailure(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.ForceCloseAtEnd = true
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	engine.SetEventSink(&disturbingSink{engine: engine})
+
+	result, err := engine.Run(testutil.GenerateDipRecovery(40, 0))
+	if !errors.Is(err, ErrAvgCostMismatch) {
+		t.Fatalf("Expected ErrAvgCostMismatch from the force close, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "close of position pos_") {
+		t.Errorf("Expected the error to name the position, got %v", err)
+	}
+	if result.OpenPositionCount < 2 {
+		t.Errorf("Expected the failed positions to stay open, got %d open", result.OpenPositionCount)
+	}
+}
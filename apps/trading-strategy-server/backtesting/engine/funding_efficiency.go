@@ -0,0 +1,24 @@
+package engine
+
+import "github.com/shopspring/decimal"
+
+// recordFundedRoundProfit 輪次結束時累計使用了注資的輪次淨利潤 ⭐
+//
+// 輪次結束時仍有待回收注資 = 本輪使用了注資資金；必須在回收注資（清空 pendingFunding）之前調用
+func (e *BacktestEngine) recordFundedRoundProfit(roundRealizedPnLD decimal.Decimal) {
+	if e.pendingFunding <= 0 {
+		return
+	}
+	e.fundedRoundProfit += roundRealizedPnLD.InexactFloat64()
+}
+
+// FundingEfficiency 注資效率 = 使用注資的輪次淨利潤 / 最大注資峰值 ⭐
+//
+// 表示每 1 USDT 峰值注資帶來多少淨利潤：> 0 說明注資放大了收益，<= 0 說明注資只是在支撐虧損倉位。
+// 沒有注資時返回 0
+func (e *BacktestEngine) FundingEfficiency() float64 {
+	if e.maxPendingFunding <= 0 {
+		return 0
+	}
+	return e.fundedRoundProfit / e.maxPendingFunding
+}
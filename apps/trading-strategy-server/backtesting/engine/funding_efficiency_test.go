@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+func TestFundingEfficiency_FundedRoundProfits(t *testing.T) {
+	config := BacktestConfig{
+		InitialBalance:        500.0,
+		FeeRate:               0.0005,
+		InstID:                "ETH-USDT-SWAP",
+		TakeProfitMin:         0.0015,
+		TakeProfitMax:         0.0020,
+		PositionSize:          200.0,
+		BreakEvenProfitMin:    1.0,
+		BreakEvenProfitMax:    20.0,
+		EnableRedCandleFilter: false,
+		EnableAutoFunding:     true,
+		AutoFundingAmount:     500.0,
+		AutoFundingIdle:       5,
+	}
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create backtest engine: %v", err)
+	}
+
+	result, err := engine.Run(testutil.GenerateDipRecovery(30, 60))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if len(engine.fundingHistory) == 0 {
+		t.Fatal("Expected auto funding to trigger during the dip")
+	}
+	if len(engine.breakEvenRounds) != 1 {
+		t.Fatalf("Expected one funded break-even round, got %d", len(engine.breakEvenRounds))
+	}
+
+	// 輪次淨利潤 = 輪次結束那筆 CLOSE 的本輪已實現盈虧
+	round := engine.breakEvenRounds[0]
+	roundProfit := 0.0
+	for _, log := range engine.tradeLog {
+		if log.Action == "CLOSE" && log.Time.Equal(round.EndTime) {
+			roundProfit = log.CurrentRoundRealizedPnL
+		}
+	}
+	if roundProfit <= 0 {
+		t.Fatalf("Expected the funded round to be profitable, got %.4f", roundProfit)
+	}
+
+	want := roundProfit / engine.maxPendingFunding
+	if got := engine.FundingEfficiency(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected funding efficiency %.6f (%.4f / %.2f), got %.6f", want, roundProfit, engine.maxPendingFunding, got)
+	}
+
+	report := engine.GenerateFundingReportMarkdown()
+	if !strings.Contains(report, "注資效率") {
+		t.Error("Expected funding report to include funding efficiency")
+	}
+	t.Logf("funded round profit %.4f / peak funding %.2f = %.6f (open positions at end: %d)",
+		roundProfit, engine.maxPendingFunding, engine.FundingEfficiency(), result.OpenPositionCount)
+}
+
+func TestFundingEfficiency_NoFunding(t *testing.T) {
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create backtest engine: %v", err)
+	}
+	if got := engine.FundingEfficiency(); got != 0 {
+		t.Errorf("Expected zero efficiency without funding, got %v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// defaultFundingFeeInterval 永續合約資金費結算間隔（OKX: 每 8 小時）
+const defaultFundingFeeInterval = 8 * time.Hour
+
+// fundingFeeDue 上一根K線和當前K線之間是否經過資金費結算時點 ⭐
+//
+// 結算時點按 UTC 對齊到 FundingFeeInterval 的整數倍（8h = 00:00 / 08:00 / 16:00）；
+// FundingFeeRate 為 0 或沒有上一根K線時不結算
+func (e *BacktestEngine) fundingFeeDue(previousTime, currentTime time.Time) bool {
+	if e.config.FundingFeeRate == 0 || previousTime.IsZero() {
+		return false
+	}
+	interval := e.config.FundingFeeInterval
+	if interval <= 0 {
+		interval = defaultFundingFeeInterval
+	}
+	return currentTime.Truncate(interval).After(previousTime.Truncate(interval))
+}
+
+// chargeFundingFee 按未平倉價值收取一次資金費，計入本輪和累計資金費，返回應從餘額扣除的金額
+//
+// 只收取一次：K線間隔大於結算間隔（跨過多個結算時點）時仍按一次計算
+func (e *BacktestEngine) chargeFundingFee(openPositionValueD decimal.Decimal) decimal.Decimal {
+	feeD := openPositionValueD.Mul(decimal.NewFromFloat(e.config.FundingFeeRate))
+	e.currentRoundFundingFeeD = e.currentRoundFundingFeeD.Add(feeD)
+	e.totalFundingFeeD = e.totalFundingFeeD.Add(feeD)
+	return feeD
+}
+
+// GetFundingFee 返回累計資金費（USDT，正數 = 支付）
+func (e *BacktestEngine) GetFundingFee() float64 {
+	return e.totalFundingFeeD.InexactFloat64()
+}
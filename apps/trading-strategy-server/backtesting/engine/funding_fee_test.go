@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestFundingFee_ChargedAtSettlements 測試資金費在每個結算時點按持倉價值收取，並從餘額和淨利潤扣除 ⭐
+func TestFundingFee_ChargedAtSettlements(t *testing.T) {
+	run := func(rate float64) (float64, float64, float64) {
+		engine, err := NewBacktestEngine(BacktestConfig{
+			InitialBalance:     10000.0,
+			FeeRate:            0.0005,
+			InstID:             "ETH-USDT-SWAP",
+			TakeProfitMin:      0.0015,
+			TakeProfitMax:      0.0020,
+			PositionSize:       200,
+			FundingFeeRate:     rate,
+			FundingFeeInterval: 0, // 默認 8h
+		})
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		// 5m K線從 00:00 開始共 25 小時：跨過 08:00、16:00、次日 00:00 三個結算時點
+		result, err := engine.Run(testutil.GenerateFlat(300))
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.FundingFee != engine.GetFundingFee() {
+			t.Errorf("Expected result funding fee %v to match engine %v", result.FundingFee, engine.GetFundingFee())
+		}
+		return result.FundingFee, result.FinalBalance, result.NetProfit
+	}
+
+	noFee, noFeeBalance, noFeeProfit := run(0)
+	if noFee != 0 {
+		t.Fatalf("Expected no funding fee when disabled, got %v", noFee)
+	}
+
+	// 橫盤K線每根都平掉上一根開的倉位再開新倉：每個結算時點持有一個 200 USDT 倉位
+	fee, balance, profit := run(0.0001)
+	if want := 3 * 200 * 0.0001; math.Abs(fee-want) > 1e-9 {
+		t.Errorf("Expected funding fee %.4f, got %.4f", want, fee)
+	}
+	if math.Abs(noFeeBalance-balance-fee) > 1e-9 {
+		t.Errorf("Expected balance reduced by funding fee, got %.4f vs %.4f", balance, noFeeBalance)
+	}
+	if math.Abs(noFeeProfit-profit-fee) > 1e-9 {
+		t.Errorf("Expected net profit reduced by funding fee, got %.4f vs %.4f", profit, noFeeProfit)
+	}
+}
+
+// TestFundingFee_InvalidInterval 測試負數的資金費結算間隔
+func TestFundingFee_InvalidInterval(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.FundingFeeInterval = -1
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for negative funding fee interval, got %v", err)
+	}
+}
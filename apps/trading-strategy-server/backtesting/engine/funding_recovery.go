@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// recoverFunding 回收最多 amountD 的待回收注資，按注資先後順序（先進先出）更新注資記錄 ⭐
+//
+// 返回實際回收金額（不超過 pendingFunding），調用方負責從餘額扣除
+func (e *BacktestEngine) recoverFunding(amountD decimal.Decimal, recoveredAt time.Time) decimal.Decimal {
+	pendingD := decimal.NewFromFloat(e.pendingFunding)
+	if amountD.GreaterThan(pendingD) {
+		amountD = pendingD
+	}
+	if amountD.LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero
+	}
+
+	remainingD := amountD
+	for i := range e.fundingHistory {
+		if remainingD.LessThanOrEqual(decimal.Zero) {
+			break
+		}
+		record := &e.fundingHistory[i]
+		if record.Recovered {
+			continue
+		}
+
+		outstandingD := decimal.NewFromFloat(record.Amount).Sub(decimal.NewFromFloat(record.RecoveredAmount))
+		repayD := decimal.Min(outstandingD, remainingD)
+		record.RecoveredAmount = decimal.NewFromFloat(record.RecoveredAmount).Add(repayD).InexactFloat64()
+		remainingD = remainingD.Sub(repayD)
+
+		if repayD.Equal(outstandingD) {
+			record.Recovered = true
+			record.RecoveredAt = recoveredAt
+		}
+	}
+
+	e.pendingFunding = pendingD.Sub(amountD).InexactFloat64()
+
+	// 全額回收：避免浮點累加誤差留下未標記的記錄
+	if amountD.Equal(pendingD) {
+		for i := range e.fundingHistory {
+			if !e.fundingHistory[i].Recovered {
+				e.fundingHistory[i].Recovered = true
+				e.fundingHistory[i].RecoveredAt = recoveredAt
+				e.fundingHistory[i].RecoveredAmount = e.fundingHistory[i].Amount
+			}
+		}
+	}
+	return amountD
+}
+
+// incrementalRecoveryAmount 正常止盈後可逐步回收的注資金額（IncrementalFundingRecovery）⭐
+//
+// 可用餘額超過初始資金的部分視為已釋放的資金，最多回收 pendingFunding。
+// 注意：不能以「初始資金 + 待回收注資」為基準——回收會同時減少餘額和待回收注資，
+// 超出部分不變，每次止盈都會重複回收同一筆金額
+func (e *BacktestEngine) incrementalRecoveryAmount(balanceD decimal.Decimal) decimal.Decimal {
+	if !e.config.IncrementalFundingRecovery || e.pendingFunding <= 0 {
+		return decimal.Zero
+	}
+
+	pendingD := decimal.NewFromFloat(e.pendingFunding)
+	excessD := balanceD.Sub(decimal.NewFromFloat(e.config.InitialBalance))
+	if excessD.LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero
+	}
+	return decimal.Min(excessD, pendingD)
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestIncrementalFundingRecovery_RecoversAsCapitalFrees 測試正常止盈釋放資金後逐步回收注資 ⭐
+func TestIncrementalFundingRecovery_RecoversAsCapitalFrees(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(30, 120)
+
+	run := func(incremental bool) (*BacktestEngine, metrics.BacktestResult) {
+		engine, err := NewBacktestEngine(incrementalRecoveryConfig(incremental))
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		result, err := engine.Run(candles)
+		if err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		if len(engine.fundingHistory) == 0 {
+			t.Fatal("Expected auto funding to trigger during the dip")
+		}
+		if len(engine.breakEvenRounds) != 0 {
+			t.Fatalf("Scenario should not trigger break-even exits, got %d", len(engine.breakEvenRounds))
+		}
+		return engine, result
+	}
+
+	totals := func(engine *BacktestEngine) (funded, recovered float64) {
+		for _, record := range engine.fundingHistory {
+			funded += record.Amount
+			recovered += record.RecoveredAmount
+			if record.RecoveredAmount > record.Amount+1e-9 {
+				t.Errorf("Recovered %.4f exceeds funded %.4f", record.RecoveredAmount, record.Amount)
+			}
+		}
+		return funded, recovered
+	}
+
+	// 不啟用時：正常結束的輪次不回收注資
+	lumpSum, lumpSumResult := run(false)
+	if _, recovered := totals(lumpSum); recovered != 0 {
+		t.Errorf("Expected no recovery without incremental mode, got %.4f", recovered)
+	}
+
+	// 啟用時：止盈釋放的資金逐步回收，待回收注資相應減少
+	engine, result := run(true)
+	funded, recovered := totals(engine)
+	if recovered <= 0 {
+		t.Fatal("Expected normal closes to recover part of the funding")
+	}
+	if math.Abs(funded-recovered-engine.pendingFunding) > 1e-6 {
+		t.Errorf("Expected pending funding %.4f = funded %.4f - recovered %.4f",
+			engine.pendingFunding, funded, recovered)
+	}
+	if engine.pendingFunding >= lumpSum.pendingFunding {
+		t.Errorf("Expected less pending funding with incremental recovery, got %.4f vs %.4f",
+			engine.pendingFunding, lumpSum.pendingFunding)
+	}
+
+	// 隨倉位陸續止盈分多次回收，而非一次性回收
+	recoveryTimes := make(map[time.Time]bool)
+	for _, record := range engine.fundingHistory {
+		if record.Recovered {
+			recoveryTimes[record.RecoveredAt] = true
+		}
+	}
+	if len(recoveryTimes) < 2 {
+		t.Errorf("Expected funding to be recovered over several closes, got %d recovery times", len(recoveryTimes))
+	}
+
+	// 回收注資只是取回本金，不影響盈虧
+	if math.Abs(result.NetProfit-lumpSumResult.NetProfit) > 1e-9 {
+		t.Errorf("Expected identical net profit, got %.6f vs %.6f", result.NetProfit, lumpSumResult.NetProfit)
+	}
+	t.Logf("funded %.2f, recovered %.4f, pending %.4f", funded, recovered, engine.pendingFunding)
+}
+
+// TestRecoverFunding_Partial 測試按注資先後順序部分回收
+func TestRecoverFunding_Partial(t *testing.T) {
+	engine, err := NewBacktestEngine(incrementalRecoveryConfig(true))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	engine.fundingHistory = []FundingRecord{{Amount: 500}, {Amount: 500}}
+	engine.pendingFunding = 1000
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := engine.recoverFunding(decimal.NewFromFloat(700), at); !got.Equal(decimal.NewFromFloat(700)) {
+		t.Fatalf("Expected to recover 700, got %s", got)
+	}
+	first, second := engine.fundingHistory[0], engine.fundingHistory[1]
+	if !first.Recovered || first.RecoveredAmount != 500 || !first.RecoveredAt.Equal(at) {
+		t.Errorf("Expected first record fully recovered, got %+v", first)
+	}
+	if second.Recovered || second.RecoveredAmount != 200 {
+		t.Errorf("Expected second record partially recovered (200), got %+v", second)
+	}
+	if engine.pendingFunding != 300 {
+		t.Errorf("Expected pending funding 300, got %v", engine.pendingFunding)
+	}
+
+	// 超過待回收注資時只回收剩餘部分
+	if got := engine.recoverFunding(decimal.NewFromFloat(1000), at); !got.Equal(decimal.NewFromFloat(300)) {
+		t.Errorf("Expected to recover remaining 300, got %s", got)
+	}
+	if !engine.fundingHistory[1].Recovered || engine.pendingFunding != 0 {
+		t.Errorf("Expected all funding recovered, got %+v (pending %v)", engine.fundingHistory[1], engine.pendingFunding)
+	}
+}
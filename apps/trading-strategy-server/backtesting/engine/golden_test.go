@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// 更新黃金文件：go test ./backtesting/engine -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "重新生成 testdata 中的黃金文件")
+
+// goldenTolerance 黃金文件比對的浮點容差（只吸收 JSON 往返和平台差異，不掩蓋邏輯變化）
+const goldenTolerance = 1e-9
+
+// goldenRun 黃金文件內容：回測結果 + 完整交易日誌
+type goldenRun struct {
+	Result   metrics.BacktestResult
+	TradeLog []TradeLog
+}
+
+// TestGolden_SineBacktest 固定行情和配置的回測結果必須與黃金文件一致 ⭐
+//
+// 引擎改動導致數值變化時此測試失敗，並列出變化的字段和第一批不一致的交易；
+// 確認變化符合預期後用 -update 重新生成黃金文件
+func TestGolden_SineBacktest(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.BreakEvenProfitMin = 1
+	config.BreakEvenProfitMax = 20
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateSine(200))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	got := goldenRun{Result: result, TradeLog: engine.GetTradeLog()}
+	for _, anomaly := range ValidateTradeLog(got.TradeLog) {
+		t.Errorf("trade log anomaly: %s", anomaly)
+	}
+
+	path := filepath.Join("testdata", "golden_sine.json")
+	if *updateGolden {
+		data, err := marshalGolden(got)
+		if err != nil {
+			t.Fatalf("Failed to marshal golden run: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to write golden file: %v", err)
+		}
+		t.Logf("Updated %s", path)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	var want goldenRun
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("Failed to parse golden file: %v", err)
+	}
+
+	for _, diff := range metrics.DiffResults(want.Result, got.Result, goldenTolerance) {
+		t.Errorf("result changed: %s", diff)
+	}
+	tradeDiffs := DiffTradeLogsWithTolerance(want.TradeLog, got.TradeLog, goldenTolerance)
+	for i, diff := range tradeDiffs {
+		if i == 5 {
+			t.Errorf("... and %d more trade log diffs", len(tradeDiffs)-i)
+			break
+		}
+		t.Errorf("trade log changed: %s", diff)
+	}
+}
+
+// marshalGolden 結果縮排輸出，交易日誌每行一筆（文件較小，git diff 可直接看出變化的交易）
+func marshalGolden(run goldenRun) ([]byte, error) {
+	result, err := json.MarshalIndent(run.Result, "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n  \"Result\": ")
+	buf.Write(result)
+	buf.WriteString(",\n  \"TradeLog\": [\n")
+	for i, trade := range run.TradeLog {
+		row, err := json.Marshal(trade)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("    ")
+		buf.Write(row)
+		if i < len(run.TradeLog)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("  ]\n}\n")
+	return buf.Bytes(), nil
+}
+
+// TestDiffTradeLogs 測試逐行比較：相同日誌無差異，偏離的字段和多出的行都被報告
+func TestDiffTradeLogs(t *testing.T) {
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(testutil.GenerateSine(300)); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	original := engine.GetTradeLog()
+	if len(original) < 3 {
+		t.Fatalf("Expected several trades, got %d", len(original))
+	}
+
+	if diffs := DiffTradeLogs(original, append([]TradeLog(nil), original...)); diffs != nil {
+		t.Fatalf("Expected identical trade logs, got %v", diffs)
+	}
+
+	divergent := append([]TradeLog(nil), original...)
+	divergent[1].Price += 0.5
+	divergent[1].Reason = "changed"
+	divergent = divergent[:len(divergent)-1]
+
+	diffs := DiffTradeLogs(original, divergent)
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 row diffs, got %d: %v", len(diffs), diffs)
+	}
+	if diffs[0].Index != 1 || len(diffs[0].Fields) != 2 ||
+		diffs[0].Fields[0].Field != "Price" || diffs[0].Fields[1].Field != "Reason" {
+		t.Errorf("Unexpected field diff: %v", diffs[0])
+	}
+	if diffs[1].Index != len(original)-1 || diffs[1].Missing != "b" {
+		t.Errorf("Expected last row missing in b, got %v", diffs[1])
+	}
+
+	// 容差內的浮點差異被忽略
+	divergent = append([]TradeLog(nil), original...)
+	divergent[0].Balance += 1e-12
+	if diffs := DiffTradeLogsWithTolerance(original, divergent, 1e-9); diffs != nil {
+		t.Errorf("Expected diffs within tolerance to be ignored, got %v", diffs)
+	}
+}
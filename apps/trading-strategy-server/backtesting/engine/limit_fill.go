@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"github.com/shopspring/decimal"
+)
+
+// LimitFillModel 開倉限價單的成交假設 ⭐
+//
+// 策略在K線收盤後以低於市價的限價（默認 0.1%）掛買單。Always 假設掛單必定成交（歷史行為）；
+// Touch / Strict 用下一根K線的 Low 判斷限價單是否成交，沒有成交的掛單直接撤銷。
+// 剛好觸及K線最低價的限價單在實盤中往往排不到隊，Strict 要求價格穿越限價一個緩衝才算成交
+type LimitFillModel string
+
+const (
+	LimitFillAlways LimitFillModel = "always" // 掛單必定成交（默認）
+	LimitFillTouch  LimitFillModel = "touch"  // 下一根K線 Low <= 限價即成交
+	LimitFillStrict LimitFillModel = "strict" // 下一根K線 Low < 限價 × (1 - LimitFillBuffer) 才成交
+)
+
+// limitFilled 判斷限價買單在下一根K線是否成交
+//
+// next 為 nil（已是最後一根K線）時，除 Always 外均視為未成交
+func (m LimitFillModel) limitFilled(next *value_objects.Candle, limitPrice, buffer float64) bool {
+	switch m {
+	case LimitFillTouch:
+		return next != nil && next.Low().Value() <= limitPrice
+	case LimitFillStrict:
+		return next != nil && next.Low().Value() < limitPrice*(1-buffer)
+	default:
+		return true
+	}
+}
+
+// checkLimitFill 檢查開倉限價單是否成交，未成交時計數並記錄拒絕原因 ⭐
+//
+// 成交判斷使用下一根K線；成交的倉位仍在當前K線記帳，保持開倉流程和斷點狀態不變。
+// 開倉價無法解析時返回 true，交由 SimulateOpen 報錯
+func (e *BacktestEngine) checkLimitFill(current value_objects.Candle, feed candleFeed, openPrice string) bool {
+	if e.config.LimitFillModel == "" || e.config.LimitFillModel == LimitFillAlways {
+		return true
+	}
+	limitPriceD, err := decimal.NewFromString(openPrice)
+	if err != nil {
+		return true
+	}
+	limitPrice := limitPriceD.InexactFloat64()
+
+	// 讀取錯誤視為沒有下一根K線，錯誤由下一次 advance 返回
+	var next *value_objects.Candle
+	if candle, ok, err := feed.peek(); ok && err == nil {
+		next = &candle
+	}
+	if e.config.LimitFillModel.limitFilled(next, limitPrice, e.config.LimitFillBuffer) {
+		return true
+	}
+
+	e.unfilledLimitOrders++
+	e.recordRejectedAdvice(current.Timestamp(), current.Close().Value(),
+		fmt.Sprintf("limit_not_filled: limit %.8g not reached (%s)", limitPrice, e.config.LimitFillModel))
+	return false
+}
+
+// UnfilledLimitOrders 因限價未成交而撤銷的開倉次數
+func (e *BacktestEngine) UnfilledLimitOrders() int {
+	return e.unfilledLimitOrders
+}
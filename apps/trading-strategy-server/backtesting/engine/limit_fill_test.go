@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+func TestLimitFillModel_LimitFilled(t *testing.T) {
+	// 下一根K線的 Low 剛好等於限價 2497.5
+	next, err := value_objects.NewCandle(2505, 2510, 2497.5, 2500, time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to create candle: %v", err)
+	}
+
+	tests := []struct {
+		model  LimitFillModel
+		limit  float64
+		buffer float64
+		want   bool
+	}{
+		{"", 2497.5, 0, true},
+		{LimitFillAlways, 2490, 0, true},
+		{LimitFillTouch, 2497.5, 0, true},
+		{LimitFillTouch, 2497, 0, false},
+		{LimitFillStrict, 2497.5, 0, false},      // 剛好觸及最低價：不成交 ⭐
+		{LimitFillStrict, 2497.5, 0.0005, false}, // 有緩衝時同樣不成交
+		{LimitFillStrict, 2498, 0, true},
+		{LimitFillStrict, 2498, 0.0005, false}, // 2498 × 0.9995 = 2496.751 < Low
+		{LimitFillStrict, 2499, 0.0005, true},  // 2499 × 0.9995 = 2497.7505 > Low
+	}
+	for _, tt := range tests {
+		if got := tt.model.limitFilled(&next, tt.limit, tt.buffer); got != tt.want {
+			t.Errorf("%q limit %.1f buffer %v: expected %v, got %v", tt.model, tt.limit, tt.buffer, tt.want, got)
+		}
+	}
+
+	// 最後一根K線之後沒有成交機會
+	if LimitFillTouch.limitFilled(nil, 2497.5, 0) || LimitFillStrict.limitFilled(nil, 2497.5, 0) {
+		t.Error("Expected no fill without a next candle")
+	}
+}
+
+// TestLimitFillModel_StrictSkipsUnfilledOpens 測試未成交的開倉被撤銷並記錄原因
+func TestLimitFillModel_StrictSkipsUnfilledOpens(t *testing.T) {
+	candles := testutil.GenerateSine(300)
+
+	always, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	alwaysResult, err := always.Run(candles)
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if always.UnfilledLimitOrders() != 0 {
+		t.Errorf("Expected no unfilled orders in always mode, got %d", always.UnfilledLimitOrders())
+	}
+
+	// 緩衝大到任何K線都無法穿越：所有開倉都被撤銷
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.LimitFillModel = LimitFillStrict
+	config.LimitFillBuffer = 0.5
+	config.LogRejectedAdvice = true
+	strict, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	strictResult, err := strict.Run(candles)
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	if alwaysResult.TotalOpenedTrades == 0 {
+		t.Fatal("Test scenario should open positions in always mode")
+	}
+	if strictResult.TotalOpenedTrades != 0 {
+		t.Errorf("Expected no opens under an unreachable buffer, got %d", strictResult.TotalOpenedTrades)
+	}
+	if strict.UnfilledLimitOrders() == 0 {
+		t.Error("Expected unfilled limit orders to be counted")
+	}
+	found := false
+	for _, rejected := range strict.GetRejectedAdvice() {
+		if strings.HasPrefix(rejected.Reason, "limit_not_filled") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("Expected limit_not_filled entries in rejected advice")
+	}
+}
+
+func TestLimitFillModel_InvalidConfig(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.LimitFillModel = "maybe"
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for unknown model, got %v", err)
+	}
+
+	config = breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.LimitFillModel = LimitFillStrict
+	config.LimitFillBuffer = -0.001
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for negative buffer, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package engine
+
+// shouldMark 第 i 根K線結束時是否寫入盯市記錄（MarkInterval）⭐
+//
+// 按K線索引計算（每 MarkInterval 根一次），斷點續跑後節奏不變；
+// 因 RequireConfirmedCandles 跳過的K線不寫入
+func (e *BacktestEngine) shouldMark(i int) bool {
+	return e.config.MarkInterval > 0 && (i+1)%e.config.MarkInterval == 0
+}
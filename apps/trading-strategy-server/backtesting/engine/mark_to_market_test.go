@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestMarkInterval_Cadence 測試 MARK 記錄按配置的K線間隔出現 ⭐
+func TestMarkInterval_Cadence(t *testing.T) {
+	candles := testutil.GenerateSine(100)
+
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.MarkInterval = 10
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	var marks []TradeLog
+	trades := 0
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "MARK" {
+			marks = append(marks, log)
+		} else {
+			trades++
+		}
+	}
+	if len(marks) != 10 {
+		t.Fatalf("Expected 10 MARK entries for 100 candles, got %d", len(marks))
+	}
+	for n, mark := range marks {
+		candle := candles[(n+1)*10-1]
+		if !mark.Time.Equal(candle.Timestamp()) || mark.Price != candle.Close().Value() {
+			t.Errorf("MARK %d: expected candle %d at %v / %.2f, got %v / %.2f",
+				n, (n+1)*10-1, candle.Timestamp(), candle.Close().Value(), mark.Time, mark.Price)
+		}
+		if mark.TradeID != 0 || mark.Fee != 0 || mark.PnL != 0 {
+			t.Errorf("MARK %d should not carry trade fields: %+v", n, mark)
+		}
+	}
+	if trades == 0 {
+		t.Fatal("Test scenario should also produce trades")
+	}
+	if marks[len(marks)-1].OpenPositionValue > 0 && marks[len(marks)-1].UnrealizedPnL == 0 {
+		t.Error("Expected MARK to capture unrealized PnL while positions are open")
+	}
+
+	// 未啟用時不寫入 MARK，交易記錄不變
+	plain, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := plain.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if len(plain.GetTradeLog()) != trades {
+		t.Errorf("Expected %d trade log entries without marks, got %d", trades, len(plain.GetTradeLog()))
+	}
+}
+
+func TestMarkInterval_Invalid(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.MarkInterval = -1
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+}
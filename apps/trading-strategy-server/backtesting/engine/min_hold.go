@@ -0,0 +1,30 @@
+package engine
+
+// recordOpenCandle 記錄倉位開倉時的K線索引（MinHoldCandles 啟用時）⭐
+func (e *BacktestEngine) recordOpenCandle(positionID string, candleIndex int) {
+	if e.config.MinHoldCandles <= 0 {
+		return
+	}
+	if e.openCandles == nil {
+		e.openCandles = make(map[string]int)
+	}
+	e.openCandles[positionID] = candleIndex
+}
+
+// forgetOpenCandle 倉位平倉後移除開倉K線記錄
+func (e *BacktestEngine) forgetOpenCandle(positionID string) {
+	delete(e.openCandles, positionID)
+}
+
+// heldLongEnough 第 candleIndex 根K線時倉位是否已滿足最短持倉K線數（MinHoldCandles）⭐
+//
+// 持倉K線數 = 當前K線索引 - 開倉K線索引；開倉在本根K線的止盈檢查之後，最早在下一根K線止盈（持倉 1 根）。
+// 只限制止盈：打平退出、保本止損、回撤熔斷等風控平倉不受影響。
+// 沒有開倉記錄的倉位（初始持倉、未啟用時開的倉位）不受限制
+func (e *BacktestEngine) heldLongEnough(positionID string, candleIndex int) bool {
+	openIndex, ok := e.openCandles[positionID]
+	if !ok {
+		return true
+	}
+	return candleIndex-openIndex >= e.config.MinHoldCandles
+}
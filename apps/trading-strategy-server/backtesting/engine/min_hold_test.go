@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// firstClose 返回指定倉位的 CLOSE 記錄
+func firstClose(t *testing.T, logs []TradeLog, positionID string) TradeLog {
+	t.Helper()
+	for _, log := range logs {
+		if log.Action == "CLOSE" && log.PositionID == positionID {
+			return log
+		}
+	}
+	t.Fatalf("Position %s was never closed", positionID)
+	return TradeLog{}
+}
+
+// TestMinHoldCandles_DelaysTakeProfit 測試開倉後立即觸及止盈價的倉位，滿足最短持倉K線數後才平倉 ⭐
+func TestMinHoldCandles_DelaysTakeProfit(t *testing.T) {
+	// 橫盤開倉後，下一根K線起價格跳升並維持在止盈價之上
+	candles := testutil.GenerateLevels(1, testutil.Level{Count: 1, Price: 2500}, testutil.Level{Count: 5, Price: 2530})
+
+	run := func(minHold int) []TradeLog {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.BreakEvenProfitMin = 1000 // 不觸發打平退出，只看止盈
+		config.BreakEvenProfitMax = 1000
+		config.MinHoldCandles = minHold
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		if _, err := engine.Run(candles); err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return engine.GetTradeLog()
+	}
+
+	// 未限制：第 0 根K線開倉，第 1 根K線跳升即止盈
+	immediate := firstClose(t, run(0), "pos_1")
+	if !immediate.Time.Equal(candles[1].Timestamp()) {
+		t.Fatalf("Expected immediate take-profit on candle 1, got %v", immediate.Time)
+	}
+
+	// 最短持倉 3 根：第 1、2 根K線雖然觸及止盈價也不平倉，第 3 根K線才平倉
+	delayed := firstClose(t, run(3), "pos_1")
+	if !delayed.Time.Equal(candles[3].Timestamp()) {
+		t.Errorf("Expected take-profit on candle 3 after the min hold, got %v", delayed.Time)
+	}
+	if delayed.Price != immediate.Price || delayed.Reason != immediate.Reason {
+		t.Errorf("Expected the same take-profit fill, got %.2f (%s) vs %.2f (%s)",
+			delayed.Price, delayed.Reason, immediate.Price, immediate.Reason)
+	}
+}
+
+// TestMinHoldCandles_InvalidConfig 測試負數配置被拒絕
+func TestMinHoldCandles_InvalidConfig(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.MinHoldCandles = -1
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+}
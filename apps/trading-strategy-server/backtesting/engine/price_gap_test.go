@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// clusteredOpens 統計交易日誌中新開倉價與同時未平倉位開倉價相對距離小於 gap 的次數
+func clusteredOpens(logs []TradeLog, gap float64) int {
+	open := make(map[string]float64)
+	count := 0
+	for _, log := range logs {
+		switch log.Action {
+		case "OPEN":
+			for _, entry := range open {
+				if math.Abs(log.Price-entry)/entry < gap-1e-9 {
+					count++
+					break
+				}
+			}
+			open[log.PositionID] = log.Price
+		case "CLOSE":
+			if isAggregatePositionID(log.PositionID) {
+				clear(open)
+			}
+			delete(open, log.PositionID)
+		}
+	}
+	return count
+}
+
+// TestMinPriceGapBetweenOpens_NoClusteredEntries 測試啟用價位去重後本輪未平倉位的開倉價互相至少相距 gap ⭐
+func TestMinPriceGapBetweenOpens_NoClusteredEntries(t *testing.T) {
+	const gap = 0.003
+	candles := testutil.GenerateSine(600)
+
+	baseline, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := baseline.Run(candles); err != nil {
+		t.Fatalf("Baseline run failed: %v", err)
+	}
+	if clusteredOpens(baseline.GetTradeLog(), gap) == 0 {
+		t.Fatal("Expected the baseline run to open clustered positions")
+	}
+
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.MinPriceGapBetweenOpens = gap
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(candles)
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if result.TotalOpenedTrades == 0 {
+		t.Fatal("Expected positions to be opened")
+	}
+	if n := clusteredOpens(engine.GetTradeLog(), gap); n != 0 {
+		t.Errorf("Expected no opens within %.2f%% of an open entry, got %d", gap*100, n)
+	}
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestProfitFactorMinTrades 測試樣本不足警告的交易數門檻：0 = 默認 30，負數 = 不檢查交易數 ⭐
+func TestProfitFactorMinTrades(t *testing.T) {
+	tests := []struct {
+		name      string
+		minTrades int
+		wantWarn  bool
+	}{
+		{"default", 0, true},
+		{"custom threshold met", 5, false},
+		{"disabled", -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := roundTimeStopTestConfig(0)
+			config.ProfitFactorMinTrades = tt.minTrades
+			engine, err := NewBacktestEngine(config)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+			result, err := engine.Run(testutil.GenerateWave(20))
+			if err != nil {
+				t.Fatalf("Backtest failed: %v", err)
+			}
+
+			if result.TotalTrades < 5 || result.ProfitFactorCapped {
+				t.Fatalf("Scenario should close at least 5 trades without capping the profit factor, got %d (capped %v)",
+					result.TotalTrades, result.ProfitFactorCapped)
+			}
+			if result.LowSampleWarning != tt.wantWarn {
+				t.Errorf("Expected LowSampleWarning %v with %d closed trades, got %v",
+					tt.wantWarn, result.TotalTrades, result.LowSampleWarning)
+			}
+		})
+	}
+}
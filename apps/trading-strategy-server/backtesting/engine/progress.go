@@ -0,0 +1,22 @@
+package engine
+
+// ProgressFunc 回測進度回調（processed = 已處理K線數，total = K線總數；RunStream 讀完前為 0）
+//
+// 在回測主循環中同步調用，實現應盡量輕量（例如更新進度條或推送到 channel）
+type ProgressFunc func(processed, total int)
+
+// progressSteps 每次回測最多回報的進度次數（約每 1% 一次）
+const progressSteps = 100
+
+// SetProgressFunc 設置進度回調（nil 表示不回報）⭐
+//
+// Run 約每處理 1% 的K線調用一次（RunStream 每 10000 根一次），K線全部處理完時以 processed == total 調用最後一次；
+// 被取消或中止時不會回報 100%。從斷點恢復時，processed 從斷點的K線索引開始計算
+func (e *BacktestEngine) SetProgressFunc(fn ProgressFunc) {
+	e.progressFunc = fn
+}
+
+// progressInterval 返回回報進度的K線間隔（至少 1）
+func progressInterval(total int) int {
+	return max(1, total/progressSteps)
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestBacktestEngine_ProgressFunc 測試進度回調單調遞增、約每 1% 一次並以 100% 結束
+func TestBacktestEngine_ProgressFunc(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	candles := testutil.GenerateFlat(2550)
+
+	var calls [][2]int
+	engine.SetProgressFunc(func(processed, total int) {
+		calls = append(calls, [2]int{processed, total})
+	})
+
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(calls) < progressSteps || len(calls) > progressSteps+2 {
+		t.Errorf("Expected about %d progress calls, got %d", progressSteps, len(calls))
+	}
+	for i, call := range calls {
+		if call[1] != len(candles) {
+			t.Fatalf("call %d: expected total %d, got %d", i, len(candles), call[1])
+		}
+		if i > 0 && call[0] <= calls[i-1][0] {
+			t.Fatalf("call %d: progress not monotonic (%d after %d)", i, call[0], calls[i-1][0])
+		}
+	}
+	if last := calls[len(calls)-1]; last[0] != len(candles) {
+		t.Errorf("Expected final progress %d/%d, got %d", len(candles), len(candles), last[0])
+	}
+}
+
+// TestBacktestEngine_ProgressFunc_Cancelled 測試取消時不回報 100%
+func TestBacktestEngine_ProgressFunc_Cancelled(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	candles := testutil.GenerateFlat(5000)
+	lastProcessed := 0
+	engine.SetProgressFunc(func(processed, total int) {
+		lastProcessed = processed
+	})
+
+	// 開始前檢查 + 第 1000 根檢查通過，第 2000 根時取消
+	ctx := &cancelAfterContext{Context: context.Background(), remaining: 2}
+	if _, err := engine.RunContext(ctx, candles); err == nil {
+		t.Fatal("Expected cancellation error, got nil")
+	}
+
+	if lastProcessed >= len(candles) {
+		t.Errorf("Expected no 100%% progress after cancellation, got %d", lastProcessed)
+	}
+	if lastProcessed == 0 {
+		t.Error("Expected progress to be reported before cancellation")
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RejectedAdvice 策略拒絕開倉的記錄（LogRejectedAdvice）⭐
+//
+// 用於排查「為什麼這裡沒有開倉」：記錄 GetOpenAdvice 返回 ShouldOpen=false 時的時間、價格和原因。
+// 打平退出不在此記錄（已作為 CLOSE 寫入交易日誌）
+type RejectedAdvice struct {
+	Time   time.Time // K線時間
+	Price  float64   // 當時價格
+	Reason string    // 策略返回的拒絕原因（例: trend_filter_blocked: ...）
+}
+
+// recordRejectedAdvice 記錄一次拒絕開倉（未啟用 LogRejectedAdvice 時不記錄）
+func (e *BacktestEngine) recordRejectedAdvice(candleTime time.Time, price float64, reason string) {
+	if !e.config.LogRejectedAdvice {
+		return
+	}
+	e.rejectedAdvice = append(e.rejectedAdvice, RejectedAdvice{
+		Time:   candleTime,
+		Price:  price,
+		Reason: reason,
+	})
+}
+
+// GetRejectedAdvice 獲取拒絕開倉記錄
+func (e *BacktestEngine) GetRejectedAdvice() []RejectedAdvice {
+	return e.rejectedAdvice
+}
+
+// ExportRejectedAdviceCSV 導出拒絕開倉記錄到 CSV 文件 ⭐
+func (e *BacktestEngine) ExportRejectedAdviceCSV(filePath string) error {
+	if len(e.rejectedAdvice) == 0 {
+		return nil // 沒有拒絕記錄，跳過
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"Time", "Price", "Reason"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, rejected := range e.rejectedAdvice {
+		row := []string{
+			rejected.Time.UTC().Format("2006-01-02 15:04:05"), // ⭐ 與交易日誌一致使用 UTC
+			fmt.Sprintf("%.2f", rejected.Price),
+			rejected.Reason,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestLogRejectedAdvice_TrendFilter 測試趨勢過濾拒絕開倉時記錄時間、價格和原因 ⭐
+func TestLogRejectedAdvice_TrendFilter(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(120, 0)
+
+	config := checkpointTestConfig()
+	config.EnableTrendFilter = true
+	config.EnableRedCandleFilter = false
+	config.EnableAutoFunding = false
+	config.LogRejectedAdvice = true
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	rejected := engine.GetRejectedAdvice()
+	trendBlocked := 0
+	for _, r := range rejected {
+		if r.Reason == "" {
+			t.Errorf("Rejected advice at %v has empty reason", r.Time)
+		}
+		if r.Time.IsZero() || r.Price <= 0 {
+			t.Errorf("Rejected advice missing time or price: %+v", r)
+		}
+		if strings.HasPrefix(r.Reason, "trend_filter_blocked:") {
+			trendBlocked++
+		}
+	}
+	if trendBlocked == 0 {
+		t.Fatalf("Expected trend filter rejections in a steady downtrend, got %d records", len(rejected))
+	}
+
+	// 導出 CSV：標題 + 每條記錄一行
+	path := filepath.Join(t.TempDir(), "rejected.csv")
+	if err := engine.ExportRejectedAdviceCSV(path); err != nil {
+		t.Fatalf("Failed to export rejected advice: %v", err)
+	}
+	if header := readCSVRow(t, path, 0); strings.Join(header, ",") != "Time,Price,Reason" {
+		t.Errorf("Unexpected CSV header: %v", header)
+	}
+	last := readCSVRow(t, path, len(rejected))
+	if last[2] != rejected[len(rejected)-1].Reason {
+		t.Errorf("Expected last row reason %q, got %q", rejected[len(rejected)-1].Reason, last[2])
+	}
+}
+
+// TestLogRejectedAdvice_DisabledByDefault 測試未啟用時不記錄拒絕開倉
+func TestLogRejectedAdvice_DisabledByDefault(t *testing.T) {
+	config := checkpointTestConfig()
+	config.EnableTrendFilter = true
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(testutil.GenerateDipRecovery(120, 0)); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+	if n := len(engine.GetRejectedAdvice()); n != 0 {
+		t.Errorf("Expected no rejected advice when logging is disabled, got %d", n)
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestReturnBasis_FundedRun 測試有注資的回測按三種資本基數計算總收益率 ⭐
+func TestReturnBasis_FundedRun(t *testing.T) {
+	run := func(basis metrics.ReturnBasis, falling, rising int) (metrics.BacktestResult, *BacktestEngine) {
+		config := BacktestConfig{
+			InitialBalance:        500.0,
+			FeeRate:               0.0005,
+			InstID:                "ETH-USDT-SWAP",
+			TakeProfitMin:         0.0015,
+			TakeProfitMax:         0.0020,
+			PositionSize:          200.0,
+			BreakEvenProfitMin:    1.0,
+			BreakEvenProfitMax:    20.0,
+			EnableRedCandleFilter: false,
+			EnableAutoFunding:     true,
+			AutoFundingAmount:     500.0,
+			AutoFundingIdle:       5,
+			ReturnBasis:           basis,
+		}
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create backtest engine: %v", err)
+		}
+		result, err := engine.Run(testutil.GenerateDipRecovery(falling, rising))
+		if err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return result, engine
+	}
+	totalReturn := func(netProfit, capital float64) float64 {
+		return math.Trunc(netProfit/capital*100*100) / 100
+	}
+
+	// 只下跌：注資未回收，峰值 >= 未回收 > 0
+	initial, engine := run(metrics.ReturnBasisInitialOnly, 30, 0)
+	maxFunding, netFunding := engine.maxPendingFunding, engine.pendingFunding
+	if netFunding <= 0 || maxFunding < netFunding {
+		t.Fatalf("Expected outstanding funding after the dip, got max %.2f net %.2f", maxFunding, netFunding)
+	}
+	withMax, _ := run(metrics.ReturnBasisInitialPlusMaxFunding, 30, 0)
+	withNet, _ := run(metrics.ReturnBasisInitialPlusNetFunding, 30, 0)
+
+	cases := []struct {
+		name    string
+		result  metrics.BacktestResult
+		capital float64
+	}{
+		{"initial", initial, 500},
+		{"initial_plus_max_funding", withMax, 500 + maxFunding},
+		{"initial_plus_net_funding", withNet, 500 + netFunding},
+	}
+	for _, c := range cases {
+		if string(c.result.ReturnBasis) != c.name {
+			t.Errorf("%s: expected basis recorded in result, got %q", c.name, c.result.ReturnBasis)
+		}
+		if math.Abs(c.result.ReturnCapital-c.capital) > 1e-9 {
+			t.Errorf("%s: expected capital %.2f, got %.2f", c.name, c.capital, c.result.ReturnCapital)
+		}
+		if want := totalReturn(c.result.NetProfit, c.capital); math.Abs(c.result.TotalReturn-want) > 1e-9 {
+			t.Errorf("%s: expected total return %.2f%%, got %.2f%%", c.name, want, c.result.TotalReturn)
+		}
+		if c.result.NetProfit != initial.NetProfit {
+			t.Errorf("%s: basis must not change net profit, got %.4f vs %.4f", c.name, c.result.NetProfit, initial.NetProfit)
+		}
+	}
+	// 下跌中虧損：分母越大，虧損收益率的絕對值越小
+	if !(initial.TotalReturn <= withNet.TotalReturn && withNet.TotalReturn <= withMax.TotalReturn) {
+		t.Errorf("Expected initial <= net <= max funding returns for a loss, got %.2f / %.2f / %.2f",
+			initial.TotalReturn, withNet.TotalReturn, withMax.TotalReturn)
+	}
+
+	// 反彈後打平退出全額回收注資：未回收注資為 0，net 基數等同 initial
+	recovered, _ := run(metrics.ReturnBasisInitialPlusNetFunding, 30, 60)
+	if recovered.ReturnCapital != 500 {
+		t.Errorf("Expected net funding capital 500 after full recovery, got %.2f", recovered.ReturnCapital)
+	}
+}
+
+// TestReturnBasis_Unknown 測試未知的收益率基數
+func TestReturnBasis_Unknown(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.ReturnBasis = "initial_plus_everything"
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for unknown return basis, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package engine
+
+import "github.com/shopspring/decimal"
+
+// flatPositionValue 未平倉價值不超過此值（USDT）即視為已全部平倉（吸收 decimal 累減的殘差）
+var flatPositionValue = decimal.NewFromFloat(0.01)
+
+// isFlat 是否已全部平倉（交易輪次結束）
+func isFlat(openPositionValueD decimal.Decimal) bool {
+	return openPositionValueD.LessThanOrEqual(flatPositionValue)
+}
+
+// onRoundComplete 輪次結束（全部倉位平倉）⭐
+//
+// 止盈平倉和打平平倉共用：重置本輪已實現盈虧、關倉價值、資金費和輪次統計（進入 roundID+1 輪），
+// 然後發出 RoundCompleted 事件。event 由調用方填入時間、價格、餘額、原因等上下文，
+// stats 為結束輪次的統計快照。注資效率統計和注資回收依賴重置前的狀態，由調用方在此之前完成
+func (e *BacktestEngine) onRoundComplete(roundID int, stats RoundStats, event BacktestEvent) {
+	e.currentRoundRealizedPnLD = decimal.Zero
+	e.currentRoundClosedValueD = decimal.Zero
+	e.currentRoundFundingFeeD = decimal.Zero
+	e.currentRoundStats = RoundStats{RoundID: roundID + 1} // StartTime 在下次開倉時設置
+
+	event.Type = EventRoundCompleted
+	event.RoundStats = &stats
+	e.eventSink.Emit(event)
+}
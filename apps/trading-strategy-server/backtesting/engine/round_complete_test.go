@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// roundResetState RoundCompleted 事件發出時引擎的輪次狀態
+type roundResetState struct {
+	Stats       RoundStats
+	RealizedPnL decimal.Decimal
+	ClosedValue decimal.Decimal
+}
+
+// roundStateSink 在每個 RoundCompleted 事件時記錄引擎的輪次狀態
+type roundStateSink struct {
+	engine *BacktestEngine
+	events []BacktestEvent
+	states []roundResetState
+}
+
+func (s *roundStateSink) Emit(event BacktestEvent) {
+	if event.Type != EventRoundCompleted {
+		return
+	}
+	s.events = append(s.events, event)
+	s.states = append(s.states, roundResetState{
+		Stats:       s.engine.currentRoundStats,
+		RealizedPnL: s.engine.currentRoundRealizedPnLD,
+		ClosedValue: s.engine.currentRoundClosedValueD,
+	})
+}
+
+// runWithRoundSink 執行回測並返回第一個輪次結束時的事件和狀態
+func runWithRoundSink(t *testing.T, config BacktestConfig, candles []value_objects.Candle) (BacktestEvent, roundResetState) {
+	t.Helper()
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	sink := &roundStateSink{engine: engine}
+	engine.SetEventSink(sink)
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sink.events) == 0 {
+		t.Fatal("Expected at least one completed round")
+	}
+	return sink.events[0], sink.states[0]
+}
+
+// TestOnRoundComplete_SameStateForBothClosePaths 測試止盈平倉和打平平倉結束輪次後的狀態一致 ⭐
+func TestOnRoundComplete_SameStateForBothClosePaths(t *testing.T) {
+	// 止盈路徑：K1 開倉，K2 觸及止盈價平掉唯一倉位
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c1, _ := value_objects.NewCandle(2500, 2500, 2495, 2500, baseTime)
+	c2, _ := value_objects.NewCandle(2500, 2510, 2499, 2505, baseTime.Add(5*time.Minute))
+	normalEvent, normalState := runWithRoundSink(t, BacktestConfig{
+		InitialBalance: 10000.0,
+		FeeRate:        0.0005,
+		InstID:         "ETH-USDT-SWAP",
+		TakeProfitMin:  0.0015,
+		TakeProfitMax:  0.0020,
+		PositionSize:   200,
+	}, []value_objects.Candle{c1, c2})
+
+	// 打平路徑：橫盤累積倉位，整輪止盈按打平流程平掉所有倉位
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.RoundProfitTarget = 0.5
+	breakEvenEvent, breakEvenState := runWithRoundSink(t, config, testutil.GenerateTightRange(30))
+
+	if normalEvent.RoundStats.NormalCloseCount != 1 || normalEvent.RoundStats.BreakEvenCloseCount != 0 {
+		t.Errorf("Expected the normal round to end by one take-profit, got %+v", normalEvent.RoundStats)
+	}
+	if breakEvenEvent.RoundStats.ProfitExitCloseCount < 2 || breakEvenEvent.Round == nil {
+		t.Errorf("Expected the round profit exit to close several positions, got %+v", breakEvenEvent.RoundStats)
+	}
+
+	want := roundResetState{
+		Stats:       RoundStats{RoundID: 2},
+		RealizedPnL: decimal.Zero,
+		ClosedValue: decimal.Zero,
+	}
+	for name, got := range map[string]roundResetState{"normal": normalState, "break-even": breakEvenState} {
+		if got.Stats != want.Stats {
+			t.Errorf("%s: expected post-round stats %+v, got %+v", name, want.Stats, got.Stats)
+		}
+		if !got.RealizedPnL.IsZero() || !got.ClosedValue.IsZero() {
+			t.Errorf("%s: expected round accumulators reset, got realized %s closed %s", name, got.RealizedPnL, got.ClosedValue)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestRoundProfitTarget_ClosesWholeRound 測試本輪總盈虧越過整輪止盈目標時，所有倉位在同一根K線平倉 ⭐
+func TestRoundProfitTarget_ClosesWholeRound(t *testing.T) {
+	// 每筆倉位在市價下方 0.1% 開倉，橫盤時每筆約有 0.1 USDT 未實現盈利，累積數筆後越過整輪目標
+	candles := testutil.GenerateTightRange(30)
+
+	run := func(target float64) *BacktestEngine {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.RoundProfitTarget = target
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		if _, err := engine.Run(candles); err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return engine
+	}
+
+	// 未啟用：不會出現整輪止盈
+	for _, log := range run(0).GetTradeLog() {
+		if strings.HasPrefix(log.Reason, metrics.ReasonRoundProfitExit) {
+			t.Fatalf("Unexpected round profit exit without a target: %+v", log)
+		}
+	}
+
+	const target = 0.5
+	engine := run(target)
+	var exits []TradeLog
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "CLOSE" && strings.HasPrefix(log.Reason, metrics.ReasonRoundProfitExit+":") {
+			exits = append(exits, log)
+		}
+	}
+	if len(exits) < 2 {
+		t.Fatalf("Expected the flat market to close a multi-position round, got %d exits", len(exits))
+	}
+
+	first, last := exits[0], exits[len(exits)-1]
+	for _, exit := range exits {
+		if !exit.Time.Equal(first.Time) {
+			break
+		}
+		last = exit
+	}
+	if last.OpenPositionValue != 0 {
+		t.Errorf("Expected every position closed, %.2f still open", last.OpenPositionValue)
+	}
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "CLOSE" && strings.HasPrefix(log.Reason, metrics.ReasonHitTarget) {
+			t.Errorf("Expected no position to reach its own target in the tight range, got %+v", log)
+		}
+	}
+	if _, ok := metrics.PnLByReason(engine.GetTradeLog())[metrics.ReasonRoundProfitExit]; !ok {
+		t.Error("Expected round profit exits to be attributed in PnLByReason")
+	}
+
+	// 整輪止盈與打平分開計數
+	if len(engine.breakEvenRounds) == 0 {
+		t.Fatal("Expected the round profit exit to be recorded as a round")
+	}
+	for _, round := range engine.breakEvenRounds {
+		if round.ExitReason != metrics.ReasonRoundProfitExit {
+			t.Errorf("Expected exit reason %s, got %+v", metrics.ReasonRoundProfitExit, round)
+		}
+		if round.BreakEvenCloseCount != 0 || round.ProfitExitCloseCount == 0 {
+			t.Errorf("Expected closes counted as profit exits, not break-even closes, got %+v", round)
+		}
+		if round.TotalCloseCount != round.NormalCloseCount+round.ProfitExitCloseCount {
+			t.Errorf("Expected total closes to include profit exit closes, got %+v", round)
+		}
+	}
+	exitReasons := summarizeRounds(engine.breakEvenRounds).ExitReasons
+	if exitReasons[metrics.ReasonRoundProfitExit] != len(engine.breakEvenRounds) || exitReasons[metrics.ReasonBreakEvenExit] != 0 {
+		t.Errorf("Expected every round summarised as a profit exit, got %v", exitReasons)
+	}
+
+	// 負數目標無效
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.RoundProfitTarget = -1
+	if _, err := NewBacktestEngine(config); err == nil {
+		t.Error("Expected error for a negative round profit target")
+	}
+}
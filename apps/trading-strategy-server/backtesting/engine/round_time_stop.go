@@ -0,0 +1,21 @@
+package engine
+
+import "time"
+
+// checkRoundTimeStop 輪次時間止損（MaxRoundCandles）⭐
+//
+// 打平機制要等輪次回到盈虧平衡才退出，行情不回頭時輪次可能持續數週、佔用資金。
+// 輪次開始（RoundStats.StartTime，首次開倉時間）後經過 MaxRoundCandles × BarInterval 仍有持倉時觸發，
+// 由調用方以市價平掉所有倉位並結束輪次（不論盈虧）。輪次按 ReasonRoundTimeStop 記錄，
+// 關倉計入 TimeStopCloseCount，輪次平掉後才計入 RoundTimeStops；不按打平退出全額回收注資
+func (e *BacktestEngine) checkRoundTimeStop(currentTime time.Time) bool {
+	if e.config.MaxRoundCandles <= 0 || len(e.positionTracker.GetOpenPositions()) == 0 {
+		return false
+	}
+	start := e.currentRoundStats.StartTime
+	if start.IsZero() {
+		return false
+	}
+	limit := time.Duration(e.config.MaxRoundCandles) * e.config.BarInterval
+	return currentTime.Sub(start) >= limit
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestRoundTimeStop_ClosesNeverRecoveringRound 測試持續下跌、永不回本的輪次在時間上限平掉所有倉位 ⭐
+func TestRoundTimeStop_ClosesNeverRecoveringRound(t *testing.T) {
+	candles := testutil.GenerateDipRecovery(60, 0)
+
+	// 不啟用時：只跌不漲，沒有任何平倉
+	engine, err := NewBacktestEngine(roundTimeStopTestConfig(0))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.TotalClosedTrades != 0 || result.RoundTimeStops != 0 {
+		t.Fatalf("Expected no closes without a time stop, got %d closes, %d time stops", result.TotalClosedTrades, result.RoundTimeStops)
+	}
+
+	engine, err = NewBacktestEngine(roundTimeStopTestConfig(20))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err = engine.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.RoundTimeStops == 0 {
+		t.Fatal("Expected at least one time-stopped round")
+	}
+
+	// 第一輪：第一筆開倉後 20 根K線（100 分鐘）以市價平掉本輪所有倉位
+	var firstOpen time.Time
+	var stopCloses []TradeLog
+	openBefore := 0
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "OPEN" && firstOpen.IsZero() {
+			firstOpen = log.Time
+		}
+		if log.Action == "OPEN" && len(stopCloses) == 0 {
+			openBefore++
+		}
+		if log.Action == "CLOSE" {
+			if !strings.HasPrefix(log.Reason, metrics.ReasonRoundTimeStop+":") {
+				t.Fatalf("Expected only time-stop closes in a falling market, got %q", log.Reason)
+			}
+			if len(stopCloses) > 0 && !log.Time.Equal(stopCloses[0].Time) {
+				break
+			}
+			stopCloses = append(stopCloses, log)
+		}
+	}
+	if want := firstOpen.Add(20 * 5 * time.Minute); !stopCloses[0].Time.Equal(want) {
+		t.Errorf("Expected the time stop at %s, got %s", want, stopCloses[0].Time)
+	}
+	if len(stopCloses) != openBefore {
+		t.Errorf("Expected all %d positions of the round closed, got %d", openBefore, len(stopCloses))
+	}
+	if last := stopCloses[len(stopCloses)-1]; last.OpenPositionValue != 0 {
+		t.Errorf("Expected the round to be flat after the time stop, got %.2f open", last.OpenPositionValue)
+	}
+	if pnl, ok := result.PnLByReason[metrics.ReasonRoundTimeStop]; !ok || pnl >= 0 {
+		t.Errorf("Expected a loss attributed to %s, got %v", metrics.ReasonRoundTimeStop, result.PnLByReason)
+	}
+
+	// 輪次按時間止損記錄，不計為打平關倉
+	if result.Rounds.ExitReasons[metrics.ReasonRoundTimeStop] != result.RoundTimeStops {
+		t.Errorf("Expected %d time-stopped rounds in the summary, got %v", result.RoundTimeStops, result.Rounds.ExitReasons)
+	}
+	for _, round := range engine.breakEvenRounds {
+		if round.ExitReason != metrics.ReasonRoundTimeStop {
+			t.Errorf("Expected exit reason %s, got %+v", metrics.ReasonRoundTimeStop, round)
+		}
+		if round.BreakEvenCloseCount != 0 || round.TimeStopCloseCount == 0 {
+			t.Errorf("Expected closes counted as time-stop closes, not break-even closes, got %+v", round)
+		}
+	}
+}
+
+// TestRoundTimeStop_KeepsFundingPending 測試時間止損認賠出場時不按打平退出全額回收注資 ⭐
+func TestRoundTimeStop_KeepsFundingPending(t *testing.T) {
+	config := roundTimeStopTestConfig(20)
+	config.InitialBalance = 500
+	config.EnableAutoFunding = true
+	config.AutoFundingAmount = 500
+	config.AutoFundingIdle = 5
+
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateDipRecovery(60, 0))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.RoundTimeStops == 0 || len(engine.fundingHistory) == 0 {
+		t.Fatalf("Expected a funded round to be time-stopped, got %d time stops, %d fundings",
+			result.RoundTimeStops, len(engine.fundingHistory))
+	}
+
+	for _, record := range engine.fundingHistory {
+		if record.RecoveredAmount != 0 {
+			t.Errorf("Expected no funding recovered by a time stop, got %+v", record)
+		}
+	}
+	if engine.pendingFunding == 0 {
+		t.Error("Expected funding to stay pending after a time stop")
+	}
+}
+
+// TestRoundTimeStop_InvalidConfig 測試時間止損的配置校驗
+func TestRoundTimeStop_InvalidConfig(t *testing.T) {
+	config := roundTimeStopTestConfig(-1)
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for negative max round candles, got %v", err)
+	}
+
+	config = roundTimeStopTestConfig(20)
+	config.BarInterval = 0
+	if _, err := NewBacktestEngine(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig without a bar interval, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+)
+
+// summarizeRounds 彙總打平輪次記錄 ⭐
+//
+// 沒有輪次時返回零值
+func summarizeRounds(rounds []BreakEvenRound) metrics.RoundsSummary {
+	summary := metrics.RoundsSummary{TotalRounds: len(rounds)}
+	if len(rounds) == 0 {
+		return summary
+	}
+
+	summary.ExitReasons = make(map[string]int)
+	var totalDuration time.Duration
+	totalOpens := 0
+	totalPnL := 0.0
+	for _, round := range rounds {
+		if round.ExpectedProfit >= 0 {
+			summary.ProfitRounds++
+		} else {
+			summary.LossRounds++
+		}
+		summary.ExitReasons[round.ExitReason]++
+		totalDuration += round.EndTime.Sub(round.StartTime)
+		totalOpens += round.TotalOpenCount
+		totalPnL += round.ExpectedProfit
+	}
+
+	summary.AvgDuration = totalDuration / time.Duration(len(rounds))
+	summary.AvgOpensPerRound = float64(totalOpens) / float64(len(rounds))
+	summary.AvgRoundPnL = totalPnL / float64(len(rounds))
+	return summary
+}
+
+// sortedExitReasons 返回排序後的輪次結束原因（保證報告輸出順序穩定）
+func sortedExitReasons(counts map[string]int) []string {
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestRoundsSummary_MatchesRounds 測試結果中的輪次彙總與輪次記錄一致 ⭐
+func TestRoundsSummary_MatchesRounds(t *testing.T) {
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := engine.Run(testutil.GenerateSine(300))
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	rounds := engine.breakEvenRounds
+	if len(rounds) < 2 {
+		t.Fatalf("Expected several break-even rounds, got %d", len(rounds))
+	}
+
+	profit, loss, opens := 0, 0, 0
+	var duration time.Duration
+	pnl := 0.0
+	for _, round := range rounds {
+		if round.ExpectedProfit >= 0 {
+			profit++
+		} else {
+			loss++
+		}
+		opens += round.TotalOpenCount
+		duration += round.EndTime.Sub(round.StartTime)
+		pnl += round.ExpectedProfit
+	}
+	n := len(rounds)
+
+	got := result.Rounds
+	if got.TotalRounds != n || got.ProfitRounds != profit || got.LossRounds != loss {
+		t.Errorf("Expected %d rounds (%d profit / %d loss), got %+v", n, profit, loss, got)
+	}
+	if got.ProfitRounds+got.LossRounds != got.TotalRounds {
+		t.Errorf("Profit + loss rounds should equal total rounds, got %+v", got)
+	}
+	if got.AvgDuration != duration/time.Duration(n) {
+		t.Errorf("Expected avg duration %s, got %s", duration/time.Duration(n), got.AvgDuration)
+	}
+	if math.Abs(got.AvgOpensPerRound-float64(opens)/float64(n)) > 1e-9 {
+		t.Errorf("Expected avg opens %.4f, got %.4f", float64(opens)/float64(n), got.AvgOpensPerRound)
+	}
+	if math.Abs(got.AvgRoundPnL-pnl/float64(n)) > 1e-9 {
+		t.Errorf("Expected avg round PnL %.4f, got %.4f", pnl/float64(n), got.AvgRoundPnL)
+	}
+	if got.ExitReasons[metrics.ReasonBreakEvenExit] != n {
+		t.Errorf("Expected all %d rounds to end by break-even exit, got %v", n, got.ExitReasons)
+	}
+}
+
+// TestRoundsSummary_NoRounds 測試沒有輪次時返回零值
+func TestRoundsSummary_NoRounds(t *testing.T) {
+	if got := summarizeRounds(nil); got.TotalRounds != 0 || got.AvgDuration != 0 || got.AvgRoundPnL != 0 {
+		t.Errorf("Expected zero summary, got %+v", got)
+	}
+}
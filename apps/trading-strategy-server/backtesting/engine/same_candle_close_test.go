@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// TestSameCandleCloses_UsePreLoopAverageCost 測試同一根K線平掉多個倉位時都按循環前的平均成本歸因，盈虧總和與手算一致 ⭐
+func TestSameCandleCloses_UsePreLoopAverageCost(t *testing.T) {
+	// 連續 3 根紅K下跌建倉（K線振幅小於止盈距離），第 4 根大陽線同時觸及 3 個止盈價
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []value_objects.Candle
+	price := 2500.0
+	for i := 0; i < 3; i++ {
+		candle, _ := value_objects.NewCandle(price, price+0.5, price-3.5, price-3, start.Add(time.Duration(i)*5*time.Minute))
+		candles = append(candles, candle)
+		price -= 3
+	}
+	rally, _ := value_objects.NewCandle(price, price+30, price-0.5, price+25, start.Add(3*5*time.Minute))
+	candles = append(candles, rally)
+
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	var closes []TradeLog
+	for _, log := range engine.GetTradeLog() {
+		if log.Action == "CLOSE" {
+			closes = append(closes, log)
+		}
+	}
+	if len(closes) != 3 {
+		t.Fatalf("Expected the rally to close all 3 positions, got %d closes", len(closes))
+	}
+
+	// 手算：平均成本 = 總投入 / 總幣數；每筆已實現盈虧 = 幣數 × (平倉價 - 平均成本) - 開倉費 - 平倉費
+	closed := engine.GetPositionTracker().GetClosedPositions()[:3]
+	totalSize, totalCoins := 0.0, 0.0
+	for _, pos := range closed {
+		totalSize += pos.Size
+		totalCoins += pos.Size / pos.EntryPrice
+	}
+	avgCost := totalSize / totalCoins
+
+	wantTotal, gotTotal := 0.0, 0.0
+	for i, pos := range closed {
+		if !closes[i].Time.Equal(closes[0].Time) {
+			t.Fatalf("Expected all closes on the same candle, got %v and %v", closes[0].Time, closes[i].Time)
+		}
+		if math.Abs(closes[i].AvgCost-avgCost) > 1e-9 {
+			t.Errorf("close %d: expected pre-loop avg cost %.6f, got %.6f", i, avgCost, closes[i].AvgCost)
+		}
+
+		coins := pos.Size / pos.EntryPrice
+		closeValue := pos.Size + coins*(pos.ClosePrice-pos.EntryPrice)
+		want := coins*(pos.ClosePrice-avgCost) - pos.Size*config.FeeRate - closeValue*config.FeeRate
+		if math.Abs(pos.RealizedPnL-want) > 1e-6 {
+			t.Errorf("close %d: expected realized PnL %.6f, got %.6f", i, want, pos.RealizedPnL)
+		}
+		wantTotal += want
+		gotTotal += pos.RealizedPnL
+	}
+	if math.Abs(gotTotal-wantTotal) > 1e-6 {
+		t.Errorf("Expected total realized PnL %.6f, got %.6f", wantTotal, gotTotal)
+	}
+	if math.Abs(closes[2].CurrentRoundRealizedPnL-wantTotal) > 1e-6 {
+		t.Errorf("Expected round realized PnL %.6f, got %.6f", wantTotal, closes[2].CurrentRoundRealizedPnL)
+	}
+}
+
+// TestExecuteClose_RejectsStaleAverageCost 測試傳入與追蹤器不一致的平均成本時拒絕平倉
+func TestExecuteClose_RejectsStaleAverageCost(t *testing.T) {
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	tracker := engine.GetPositionTracker()
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, _ := tracker.AddPosition(2500, 200, openTime, 2504)
+	if _, err := tracker.AddPosition(2480, 200, openTime, 2484); err != nil {
+		t.Fatalf("Failed to add position: %v", err)
+	}
+
+	stale := first.EntryPrice // 第二筆開倉前的平均成本
+	if _, err := engine.executeClose(first, 2504, openTime.Add(time.Minute), stale); !errors.Is(err, ErrAvgCostMismatch) {
+		t.Fatalf("Expected ErrAvgCostMismatch, got %v", err)
+	}
+	if tracker.GetOpenPositionCount() != 2 {
+		t.Errorf("Expected the rejected close to leave both positions open, got %d", tracker.GetOpenPositionCount())
+	}
+
+	if _, err := engine.executeClose(first, 2504, openTime.Add(time.Minute), tracker.CalculateAverageCost()); err != nil {
+		t.Errorf("Expected close with the tracker's avg cost to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+package engine
+
+import (
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// takeProfitQueue 按價格-時間優先排列止盈單（見 simulator.TakeProfitQueue）⭐
+//
+// 平倉順序決定交易日誌、資金快照的順序；平倉中途被打斷（例如資金或熔斷條件）時也決定哪些倉位先離場
+func (e *BacktestEngine) takeProfitQueue(positions []simulator.Position, summary value_objects.PositionSummary) []simulator.TakeProfitOrder {
+	return simulator.TakeProfitQueue(positions, func(pos simulator.Position) float64 {
+		return e.strategy.TakeProfitTarget(pos.EntryPrice, pos.TargetClosePrice, summary)
+	})
+}
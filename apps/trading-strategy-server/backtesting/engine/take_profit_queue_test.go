@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestTakeProfitQueue_SameTargetClosesByOpenTime 測試同一根K線觸及相同止盈價時按開倉時間平倉
+func TestTakeProfitQueue_SameTargetClosesByOpenTime(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.ProfitVsAverageCost = true // 所有倉位的止盈價都按平均成本計算，止盈價相同
+	config.BreakEvenProfitMin = 1000  // 不觸發打平退出，只看止盈
+	config.BreakEvenProfitMax = 1000
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(testutil.GenerateDipRecovery(8, 4)); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	openTimes := make(map[string]time.Time)
+	var lastClose time.Time
+	var batch []TradeLog
+	var largest []TradeLog
+	for _, log := range engine.GetTradeLog() {
+		switch log.Action {
+		case "OPEN":
+			openTimes[log.PositionID] = log.Time
+		case "CLOSE":
+			if !log.Time.Equal(lastClose) {
+				batch = nil
+				lastClose = log.Time
+			}
+			batch = append(batch, log)
+			if len(batch) > len(largest) {
+				largest = batch
+			}
+		}
+	}
+	if len(largest) < 3 {
+		t.Fatalf("Expected several positions to close on the same candle, got %d", len(largest))
+	}
+	for i := 1; i < len(largest); i++ {
+		prev, cur := largest[i-1], largest[i]
+		if prev.Price != cur.Price {
+			t.Fatalf("Expected a shared target price, got %.2f and %.2f", prev.Price, cur.Price)
+		}
+		if openTimes[cur.PositionID].Before(openTimes[prev.PositionID]) {
+			t.Errorf("Position %s (opened %v) closed after %s (opened %v)",
+				prev.PositionID, openTimes[prev.PositionID], cur.PositionID, openTimes[cur.PositionID])
+		}
+	}
+}
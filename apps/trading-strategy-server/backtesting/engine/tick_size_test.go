@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// TestBacktestEngine_TickSizeRoundsOrderPrices 測試 TickSize 傳給策略：開倉價舍去、止盈價進位到 tick 的整數倍 ⭐
+func TestBacktestEngine_TickSizeRoundsOrderPrices(t *testing.T) {
+	onTick := func(price, tick float64) bool {
+		steps := price / tick
+		return math.Abs(steps-math.Round(steps)) < 1e-6
+	}
+
+	run := func(tickSize float64) *BacktestEngine {
+		config := breakEvenTestConfig(BreakEvenClosePerPosition)
+		config.TickSize = tickSize
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		if _, err := engine.Run(testutil.GenerateSine(200)); err != nil {
+			t.Fatalf("Backtest failed: %v", err)
+		}
+		return engine
+	}
+
+	// 默認（小數點後 2 位）的價格不全在 0.5 的整數倍上，確認用例能區分
+	offTick := 0
+	for _, log := range run(0).GetTradeLog() {
+		if log.Action == "OPEN" && !onTick(log.Price, 0.5) {
+			offTick++
+		}
+	}
+	if offTick == 0 {
+		t.Fatal("Expected some default-precision open prices off the 0.5 tick")
+	}
+
+	opens, takeProfits := 0, 0
+	for _, log := range run(0.5).GetTradeLog() {
+		switch {
+		case log.Action == "OPEN":
+			opens++
+			if !onTick(log.Price, 0.5) {
+				t.Errorf("Open price %.4f is not a multiple of the 0.5 tick", log.Price)
+			}
+		case metrics.ReasonCategory(log.Reason) == metrics.ReasonHitTarget:
+			takeProfits++
+			if !onTick(log.Price, 0.5) {
+				t.Errorf("Take-profit price %.4f of %s is not a multiple of the 0.5 tick", log.Price, log.PositionID)
+			}
+		}
+	}
+	if opens == 0 || takeProfits == 0 {
+		t.Fatalf("Expected opens and take-profit closes, got %d opens, %d take-profits", opens, takeProfits)
+	}
+}
+
+// TestNewBacktestEngine_NegativeTickSize 測試負數 TickSize 被拒絕
+func TestNewBacktestEngine_NegativeTickSize(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	config.TickSize = -0.01
+	if _, err := NewBacktestEngine(config); err == nil {
+		t.Error("Expected error for negative tick size")
+	}
+}
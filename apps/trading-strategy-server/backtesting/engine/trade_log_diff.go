@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+)
+
+// TradeDiff 交易日誌中不一致的一行
+type TradeDiff struct {
+	Index   int                 // 行號（從 0 開始）
+	TradeID int                 // 交易序號（取存在的一邊）
+	Missing string              // "a" / "b" = 該行在這一邊不存在；空 = 兩邊都有該行
+	Fields  []metrics.FieldDiff // 不一致的字段（缺行時為空）
+}
+
+// String 格式化為一行摘要
+func (d TradeDiff) String() string {
+	if d.Missing != "" {
+		return fmt.Sprintf("row %d (trade %d): missing in %s", d.Index, d.TradeID, d.Missing)
+	}
+	fields := make([]string, len(d.Fields))
+	for i, field := range d.Fields {
+		fields[i] = field.String()
+	}
+	return fmt.Sprintf("row %d (trade %d): %s", d.Index, d.TradeID, strings.Join(fields, ", "))
+}
+
+// DiffTradeLogs 逐行精確比較兩份交易日誌 ⭐
+//
+// 用途：回歸測試中定位具體從哪一筆交易開始出現差異
+func DiffTradeLogs(a, b []TradeLog) []TradeDiff {
+	return DiffTradeLogsWithTolerance(a, b, 0)
+}
+
+// DiffTradeLogsWithTolerance 逐行比較兩份交易日誌，浮點字段允許 tolerance 的誤差
+//
+// 行按位置對齊；多出的行以 Missing 標記在缺少的一邊
+func DiffTradeLogsWithTolerance(a, b []TradeLog, tolerance float64) []TradeDiff {
+	var diffs []TradeDiff
+	for i := 0; i < max(len(a), len(b)); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, TradeDiff{Index: i, TradeID: b[i].TradeID, Missing: "a"})
+		case i >= len(b):
+			diffs = append(diffs, TradeDiff{Index: i, TradeID: a[i].TradeID, Missing: "b"})
+		default:
+			if fields := metrics.DiffFields(a[i], b[i], tolerance); len(fields) > 0 {
+				diffs = append(diffs, TradeDiff{Index: i, TradeID: a[i].TradeID, Fields: fields})
+			}
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// tradeLogTolerance 交易日誌浮點字段的比較誤差（日誌字段為 decimal 轉 float64）
+const tradeLogTolerance = 1e-6
+
+// 交易日誌異常類型 ⭐
+const (
+	AnomalyNegativeBalance     = "negative_balance"      // 餘額為負
+	AnomalyNegativeOpenValue   = "negative_open_value"   // 未平倉價值為負
+	AnomalyUnmatchedClose      = "unmatched_close"       // CLOSE 找不到之前未平的 OPEN
+	AnomalyRealizedPnLDrift    = "realized_pnl_drift"    // 非 CLOSE 行的累計已實現盈虧發生變化
+	AnomalyRealizedPnLMismatch = "realized_pnl_mismatch" // CLOSE 行的累計已實現盈虧增量與本輪增量不一致
+)
+
+// Anomaly 交易日誌中違反記賬不變量的一行
+type Anomaly struct {
+	Index   int    // 行號（從 0 開始）
+	TradeID int    // 交易序號
+	Kind    string // 異常類型（Anomaly* 常量）
+	Message string // 具體描述
+}
+
+// String 格式化為一行摘要
+func (a Anomaly) String() string {
+	return fmt.Sprintf("row %d (trade %d): %s: %s", a.Index, a.TradeID, a.Kind, a.Message)
+}
+
+// ValidateTradeLog 檢查交易日誌的記賬不變量，返回所有違規行 ⭐
+//
+// 檢查項：
+//   - Balance 和 OpenPositionValue 不為負
+//   - CLOSE 的 PositionID 對應之前一筆尚未平倉的 OPEN（合併平倉 "aggregate_N" 平掉所有未平倉位）
+//   - TotalRealizedPnL 只在 CLOSE 行變化，且增量等於本輪已實現盈虧的增量（輪次重置時等於本輪已實現盈虧）
+//
+// 用途：測試中作為記賬回歸的守衛
+func ValidateTradeLog(logs []TradeLog) []Anomaly {
+	var anomalies []Anomaly
+	flag := func(i int, kind, format string, args ...any) {
+		anomalies = append(anomalies, Anomaly{
+			Index:   i,
+			TradeID: logs[i].TradeID,
+			Kind:    kind,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	open := make(map[string]bool)
+	for i, log := range logs {
+		if log.Balance < -tradeLogTolerance {
+			flag(i, AnomalyNegativeBalance, "balance %.8f", log.Balance)
+		}
+		if log.OpenPositionValue < -tradeLogTolerance {
+			flag(i, AnomalyNegativeOpenValue, "open position value %.8f", log.OpenPositionValue)
+		}
+
+		switch log.Action {
+		case "OPEN":
+			open[log.PositionID] = true
+		case "CLOSE":
+			if isAggregatePositionID(log.PositionID) {
+				if len(open) == 0 {
+					flag(i, AnomalyUnmatchedClose, "aggregate close %q with no open positions", log.PositionID)
+				}
+				clear(open)
+			} else {
+				if !open[log.PositionID] {
+					flag(i, AnomalyUnmatchedClose, "position %q was not opened or already closed", log.PositionID)
+				}
+				delete(open, log.PositionID)
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+		prev := logs[i-1]
+		delta := log.TotalRealizedPnL - prev.TotalRealizedPnL
+		if log.Action != "CLOSE" {
+			if math.Abs(delta) > tradeLogTolerance {
+				flag(i, AnomalyRealizedPnLDrift, "total realized PnL changed by %.8f on %s", delta, log.Action)
+			}
+			continue
+		}
+		roundDelta := log.CurrentRoundRealizedPnL - prev.CurrentRoundRealizedPnL
+		if math.Abs(delta-roundDelta) > tradeLogTolerance && math.Abs(delta-log.CurrentRoundRealizedPnL) > tradeLogTolerance {
+			flag(i, AnomalyRealizedPnLMismatch, "total realized PnL changed by %.8f, round realized PnL by %.8f", delta, roundDelta)
+		}
+	}
+	return anomalies
+}
+
+// isAggregatePositionID 是否為合併平倉的 PositionID（"aggregate_<倉位數>"）
+func isAggregatePositionID(id string) bool {
+	count, ok := strings.CutPrefix(id, aggregatePositionIDPrefix)
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(count)
+	return err == nil
+}
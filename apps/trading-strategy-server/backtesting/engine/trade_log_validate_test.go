@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// validTradeLog 一份符合記賬不變量的最小交易日誌
+func validTradeLog() []TradeLog {
+	return []TradeLog{
+		{TradeID: 1, Action: "OPEN", Balance: 9800, OpenPositionValue: 200, PositionID: "pos_1"},
+		{TradeID: 2, Action: "OPEN", Balance: 9600, OpenPositionValue: 400, PositionID: "pos_2"},
+		{TradeID: 3, Action: "CLOSE", Balance: 9801, OpenPositionValue: 200, CurrentRoundRealizedPnL: 1, TotalRealizedPnL: 1, PositionID: "pos_2"},
+		{TradeID: 4, Action: "CLOSE", Balance: 10002, OpenPositionValue: 0, CurrentRoundRealizedPnL: 2, TotalRealizedPnL: 2, PositionID: "pos_1"},
+		{TradeID: 5, Action: "OPEN", Balance: 9802, OpenPositionValue: 200, TotalRealizedPnL: 2, PositionID: "pos_3"},
+		{TradeID: 6, Action: "CLOSE", Balance: 10003, OpenPositionValue: 0, CurrentRoundRealizedPnL: 1, TotalRealizedPnL: 3, PositionID: "aggregate_1"},
+	}
+}
+
+// TestValidateTradeLog_Valid 測試合法交易日誌沒有異常（包括輪次重置和合併平倉）
+func TestValidateTradeLog_Valid(t *testing.T) {
+	if anomalies := ValidateTradeLog(validTradeLog()); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies, got %v", anomalies)
+	}
+}
+
+// TestValidateTradeLog_Corrupted 測試注入的錯誤從正確的行開始被標記 ⭐
+func TestValidateTradeLog_Corrupted(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(logs []TradeLog)
+		index   int
+		kind    string
+	}{
+		{"negative balance", func(logs []TradeLog) { logs[2].Balance = -0.5 }, 2, AnomalyNegativeBalance},
+		{"negative open value", func(logs []TradeLog) { logs[1].OpenPositionValue = -1 }, 1, AnomalyNegativeOpenValue},
+		{"close without open", func(logs []TradeLog) { logs[2].PositionID = "pos_9" }, 2, AnomalyUnmatchedClose},
+		{"realized PnL changed on open", func(logs []TradeLog) { logs[4].TotalRealizedPnL = 2.5 }, 4, AnomalyRealizedPnLDrift},
+		{"realized PnL out of step", func(logs []TradeLog) { logs[3].CurrentRoundRealizedPnL = 1.5 }, 3, AnomalyRealizedPnLMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := validTradeLog()
+			tt.corrupt(logs)
+
+			anomalies := ValidateTradeLog(logs)
+			// 累計值被改動時下一行的增量也會不一致，只檢查第一個異常
+			if len(anomalies) == 0 {
+				t.Fatal("Expected the corruption to be flagged")
+			}
+			if anomalies[0].Index != tt.index || anomalies[0].Kind != tt.kind {
+				t.Errorf("Expected %s at row %d, got %s", tt.kind, tt.index, anomalies[0])
+			}
+		})
+	}
+}
+
+// TestValidateTradeLog_BacktestRuns 測試逐倉和合併平倉模式的回測交易日誌都沒有異常
+func TestValidateTradeLog_BacktestRuns(t *testing.T) {
+	for _, mode := range []BreakEvenCloseMode{BreakEvenClosePerPosition, BreakEvenCloseAggregate} {
+		config := breakEvenTestConfig(mode)
+		config.ForceCloseAtEnd = true
+		engine, err := NewBacktestEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		if _, err := engine.Run(testutil.GenerateSine(600)); err != nil {
+			t.Fatalf("%s: backtest failed: %v", mode, err)
+		}
+
+		for _, anomaly := range ValidateTradeLog(engine.GetTradeLog()) {
+			t.Errorf("%s: trade log anomaly: %s", mode, anomaly)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StreamingTradeLogger 把交易日誌逐筆寫入 JSON Lines 文件 ⭐
+//
+// 長時間回測時不在內存保留完整的交易日誌：每筆記錄產生時立即寫入文件（一行一個 JSON 對象），
+// 回測中途崩潰也保留已寫入的記錄。由 SetTradeLogger 交給引擎使用
+type StreamingTradeLogger struct {
+	file    *os.File
+	encoder *json.Encoder
+	count   int
+}
+
+// NewStreamingTradeLogger 創建（覆蓋）path 並返回寫入器
+func NewStreamingTradeLogger(path string) (*StreamingTradeLogger, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trade log file: %w", err)
+	}
+	return &StreamingTradeLogger{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// ResumeStreamingTradeLogger 斷點續跑時打開已有的 path，接在斷點的最後一筆交易之後繼續寫入 ⭐
+//
+// 保留 TradeID <= lastTradeID 的記錄，截掉斷點之後寫入的記錄（以及崩潰時寫了一半的行），
+// 之後以追加模式寫入。lastTradeID 取自 BacktestEngine.ResumeTradeID；
+// 文件中斷點之前的記錄不完整時返回錯誤（不能拼出完整的交易日誌）
+func ResumeStreamingTradeLogger(path string, lastTradeID int) (*StreamingTradeLogger, error) {
+	flag := os.O_RDWR | os.O_APPEND
+	if lastTradeID == 0 {
+		flag |= os.O_CREATE // 斷點之前沒有交易，文件可以不存在
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trade log file: %w", err)
+	}
+
+	// 找到最後一筆屬於斷點的記錄的結尾
+	kept, offset := 0, int64(0)
+	reader := bufio.NewReader(file)
+	for kept < lastTradeID {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			file.Close()
+			return nil, fmt.Errorf("failed to read trade log file: %w", readErr)
+		}
+		if readErr != nil {
+			break // 文件結尾（包括寫了一半的行）
+		}
+		var entry TradeLog
+		if err := json.Unmarshal(line, &entry); err != nil || entry.TradeID > lastTradeID {
+			break
+		}
+		kept++
+		offset += int64(len(line))
+	}
+	if kept != lastTradeID {
+		file.Close()
+		return nil, fmt.Errorf("trade log %s has %d of the %d trades in the checkpoint", path, kept, lastTradeID)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate trade log file: %w", err)
+	}
+	return &StreamingTradeLogger{file: file, encoder: json.NewEncoder(file), count: kept}, nil
+}
+
+// Log 寫入一筆記錄（一次寫入一整行，不經過緩衝）
+func (l *StreamingTradeLogger) Log(entry TradeLog) error {
+	if err := l.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write trade %d: %w", entry.TradeID, err)
+	}
+	l.count++
+	return nil
+}
+
+// Count 已寫入的記錄數（續寫時包括斷點之前的記錄）
+func (l *StreamingTradeLogger) Count() int {
+	return l.count
+}
+
+// Close 同步並關閉文件
+func (l *StreamingTradeLogger) Close() error {
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to sync trade log file: %w", err)
+	}
+	return l.file.Close()
+}
+
+// SetTradeLogger 使用串流寫入器記錄交易日誌，取代內存中的日誌（nil = 內存日誌，默認）⭐
+//
+// 設置後 GetTradeLog 和 ExportTradeLogCSV 沒有內容，斷點也不包含交易日誌；
+// 盈虧歸因和手續費統計逐筆累計，結果與內存日誌一致。寫入失敗時 Run 中止並返回錯誤。
+// 文件由調用方在 Run 之後 Close
+func (e *BacktestEngine) SetTradeLogger(logger *StreamingTradeLogger) {
+	e.tradeLogger = logger
+}
+
+// logTrade 記錄一筆交易日誌：累計手續費和盈虧歸因，寫入串流文件或追加到內存日誌
+func (e *BacktestEngine) logTrade(entry TradeLog) {
+	e.tradeLogFees += entry.Fee
+	e.reasonAttribution.Add(entry)
+
+	if e.tradeLogger == nil {
+		e.tradeLog = append(e.tradeLog, entry)
+		return
+	}
+	if err := e.tradeLogger.Log(entry); err != nil && e.tradeLogErr == nil {
+		e.tradeLogErr = err
+	}
+}
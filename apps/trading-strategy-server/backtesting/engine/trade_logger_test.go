@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// readTradeLogLines 逐行解析 JSON Lines 交易日誌
+func readTradeLogLines(t *testing.T, path string) []TradeLog {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read trade log: %v", err)
+	}
+	var logs []TradeLog
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry TradeLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v (%q)", len(logs)+1, err, scanner.Text())
+		}
+		logs = append(logs, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to scan trade log: %v", err)
+	}
+	return logs
+}
+
+// flushSink 在每個事件時檢查文件中的行數與已寫入記錄數一致
+type flushSink struct {
+	t      *testing.T
+	path   string
+	logger *StreamingTradeLogger
+	counts []int
+}
+
+func (s *flushSink) Emit(event BacktestEvent) {
+	lines := len(readTradeLogLines(s.t, s.path))
+	if lines != s.logger.Count() {
+		s.t.Errorf("%s: expected %d lines on disk, got %d", event.Type, s.logger.Count(), lines)
+	}
+	s.counts = append(s.counts, lines)
+}
+
+// TestStreamingTradeLogger_FlushesIncrementally 測試串流日誌逐筆寫入文件，結果與內存日誌一致 ⭐
+func TestStreamingTradeLogger_FlushesIncrementally(t *testing.T) {
+	config := breakEvenTestConfig(BreakEvenClosePerPosition)
+	candles := testutil.GenerateSine(300)
+
+	memEngine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	memResult, err := memEngine.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "trades.jsonl")
+	logger, err := NewStreamingTradeLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to create trade logger: %v", err)
+	}
+	engine, err := NewBacktestEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	engine.SetTradeLogger(logger)
+	sink := &flushSink{t: t, path: path, logger: logger}
+	engine.SetEventSink(sink)
+	result, err := engine.Run(candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close trade logger: %v", err)
+	}
+
+	// 回測進行中文件已有內容且逐步增長
+	if len(sink.counts) < 2 || sink.counts[0] == 0 || sink.counts[0] >= logger.Count() {
+		t.Fatalf("Expected lines to be flushed during the run, got counts %v of %d", sink.counts, logger.Count())
+	}
+	for i := 1; i < len(sink.counts); i++ {
+		if sink.counts[i] < sink.counts[i-1] {
+			t.Fatalf("Line count went backwards: %v", sink.counts)
+		}
+	}
+
+	logs := readTradeLogLines(t, path)
+	if len(logs) != logger.Count() {
+		t.Errorf("Expected %d lines, got %d", logger.Count(), len(logs))
+	}
+	if !reflect.DeepEqual(logs, memEngine.GetTradeLog()) {
+		t.Error("Expected streamed trade log to match the in-memory trade log")
+	}
+	if len(engine.GetTradeLog()) != 0 {
+		t.Errorf("Expected no in-memory trade log when streaming, got %d entries", len(engine.GetTradeLog()))
+	}
+	if !reflect.DeepEqual(result.PnLByReason, memResult.PnLByReason) {
+		t.Errorf("Expected PnL attribution %v, got %v", memResult.PnLByReason, result.PnLByReason)
+	}
+	if result.NetProfit != memResult.NetProfit || engine.GetTotalFees() != memEngine.GetTotalFees() {
+		t.Errorf("Expected net profit %.4f fees %.4f, got %.4f %.4f",
+			memResult.NetProfit, memEngine.GetTotalFees(), result.NetProfit, engine.GetTotalFees())
+	}
+}
+
+// TestStreamingTradeLogger_WriteFailureStopsRun 測試寫入失敗時回測中止並返回錯誤
+func TestStreamingTradeLogger_WriteFailureStopsRun(t *testing.T) {
+	logger, err := NewStreamingTradeLogger(filepath.Join(t.TempDir(), "trades.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to create trade logger: %v", err)
+	}
+	logger.file.Close() // 模擬磁盤錯誤
+
+	engine, err := NewBacktestEngine(breakEvenTestConfig(BreakEvenClosePerPosition))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	engine.SetTradeLogger(logger)
+	if _, err := engine.Run(testutil.GenerateSine(300)); err == nil {
+		t.Error("Expected Run to fail when the trade log cannot be written")
+	}
+}
+
+// TestStreamingTradeLogger_ResumeFromCheckpoint 測試斷點續跑時續寫串流日誌：保留斷點之前的記錄，截掉之後的記錄 ⭐
+func TestStreamingTradeLogger_ResumeFromCheckpoint(t *testing.T) {
+	candles := testutil.GenerateWave(600)
+
+	baseline, err := NewBacktestEngine(checkpointTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := baseline.Run(candles); err != nil {
+		t.Fatalf("Baseline run failed: %v", err)
+	}
+
+	// 第一次運行：在一半時產生斷點，之後繼續寫入（模擬斷點之後才崩潰），最後留下寫了一半的行
+	path := filepath.Join(t.TempDir(), "trades.jsonl")
+	logger, err := NewStreamingTradeLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to create trade logger: %v", err)
+	}
+	first, _ := NewBacktestEngine(checkpointTestConfig())
+	first.SetTradeLogger(logger)
+	var checkpoint []byte
+	first.SetCheckpointHandler(len(candles)/2, func(candleIndex int, data []byte) error {
+		if checkpoint == nil {
+			checkpoint = data
+		}
+		return nil
+	})
+	if _, err := first.Run(candles); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+	if _, err := logger.file.WriteString(`{"TradeID":`); err != nil {
+		t.Fatalf("Failed to write partial line: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close trade logger: %v", err)
+	}
+
+	resumed, _ := NewBacktestEngine(checkpointTestConfig())
+	if err := resumed.RestoreCheckpoint(checkpoint); err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+	lastTradeID := resumed.ResumeTradeID()
+	if lastTradeID == 0 || lastTradeID >= len(baseline.GetTradeLog()) {
+		t.Fatalf("Expected trades on both sides of the checkpoint, got last trade %d of %d", lastTradeID, len(baseline.GetTradeLog()))
+	}
+
+	logger, err = ResumeStreamingTradeLogger(path, lastTradeID)
+	if err != nil {
+		t.Fatalf("ResumeStreamingTradeLogger failed: %v", err)
+	}
+	if logger.Count() != lastTradeID {
+		t.Errorf("Expected %d trades kept from before the checkpoint, got %d", lastTradeID, logger.Count())
+	}
+	resumed.SetTradeLogger(logger)
+	if _, err := resumed.Run(candles); err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close trade logger: %v", err)
+	}
+
+	if logs := readTradeLogLines(t, path); !reflect.DeepEqual(logs, baseline.GetTradeLog()) {
+		t.Errorf("Expected resumed trade log to match the uninterrupted run (%d vs %d entries)", len(logs), len(baseline.GetTradeLog()))
+	}
+
+	// 文件缺少斷點之前的記錄
+	if _, err := ResumeStreamingTradeLogger(path, len(baseline.GetTradeLog())+1); err == nil {
+		t.Error("Expected error when the trade log is missing trades from before the checkpoint")
+	}
+	if _, err := ResumeStreamingTradeLogger(filepath.Join(t.TempDir(), "missing.jsonl"), lastTradeID); err == nil {
+		t.Error("Expected error when resuming a trade log that does not exist")
+	}
+}
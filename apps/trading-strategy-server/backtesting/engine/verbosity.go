@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"io"
+	"strings"
+
+	"dizzycode.xyz/logger"
+)
+
+// Verbosity 引擎輸出的詳細程度 ⭐
+//
+// 作為函數庫使用時（服務、參數掃描）默認不輸出任何內容；CLI 使用 VerbosityVerbose
+type Verbosity int
+
+const (
+	VerbositySilent  Verbosity = iota // 不輸出（默認）
+	VerbosityVerbose                  // Run 結束時輸出打平輪次和自動注資統計報告
+)
+
+// SetLogger 設置統計報告的輸出和詳細程度（log 為 nil 時使用 logger.Default）⭐
+//
+// 每份報告作為一條多行的 Info 日誌輸出
+func (e *BacktestEngine) SetLogger(log logger.Logger, verbosity Verbosity) {
+	if log == nil {
+		log = logger.Default
+	}
+	e.reportLogger = log
+	e.verbosity = verbosity
+}
+
+// logReports 輸出打平輪次和自動注資統計報告（VerbositySilent 或未設置 logger 時不輸出）
+func (e *BacktestEngine) logReports() {
+	if e.verbosity < VerbosityVerbose || e.reportLogger == nil {
+		return
+	}
+	for _, write := range []func(io.Writer){e.writeBreakEvenRoundsReport, e.writeFundingReport} {
+		var report strings.Builder
+		write(&report)
+		if report.Len() > 0 {
+			e.reportLogger.Info(strings.TrimSuffix(report.String(), "\n"))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// recordingLogger 記錄 Info 日誌
+type recordingLogger struct {
+	infos []string
+}
+
+func (l *recordingLogger) Info(msg string, context ...any)  { l.infos = append(l.infos, msg) }
+func (l *recordingLogger) Error(msg string, context ...any) {}
+func (l *recordingLogger) Warn(msg string, context ...any)  {}
+func (l *recordingLogger) Debug(msg string, context ...any) {}
+
+// TestVerbosity_SilentEngineWritesNothingToStdout 測試默認（靜默）引擎在 Run 期間不寫標準輸出 ⭐
+func TestVerbosity_SilentEngineWritesNothingToStdout(t *testing.T) {
+	engine, err := NewBacktestEngine(verbosityTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	_, runErr := engine.Run(testutil.GenerateSine(300))
+	os.Stdout = stdout
+	writer.Close()
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read captured stdout: %v", err)
+	}
+
+	if runErr != nil {
+		t.Fatalf("Run failed: %v", runErr)
+	}
+	if len(output) != 0 {
+		t.Errorf("Expected no stdout output from a silent engine, got %q", output)
+	}
+}
+
+// TestVerbosity_VerboseLogsReports 測試 VerbosityVerbose 通過 logger 輸出打平和注資統計報告
+func TestVerbosity_VerboseLogsReports(t *testing.T) {
+	engine, err := NewBacktestEngine(verbosityTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	log := &recordingLogger{}
+	engine.SetLogger(log, VerbosityVerbose)
+	if _, err := engine.Run(testutil.GenerateSine(300)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(log.infos) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(log.infos))
+	}
+	if !strings.Contains(log.infos[0], "打平輪次統計") || !strings.Contains(log.infos[1], "自動注資統計") {
+		t.Errorf("Unexpected reports: %q", log.infos)
+	}
+
+	silent, err := NewBacktestEngine(verbosityTestConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	silentLog := &recordingLogger{}
+	silent.SetLogger(silentLog, VerbositySilent)
+	if _, err := silent.Run(testutil.GenerateSine(300)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(silentLog.infos) != 0 {
+		t.Errorf("Expected no reports at VerbositySilent, got %d", len(silentLog.infos))
+	}
+}
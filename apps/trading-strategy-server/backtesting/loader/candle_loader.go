@@ -2,6 +2,7 @@ package loader
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -28,30 +29,103 @@ type OKXCandle struct {
 	// 其他字段暫時不需要
 }
 
+// DefaultMaxCandles 載入時最多解析的K線數（約 19 年的 1m 數據）⭐
+//
+// 防止格式錯誤或超大的數據文件耗盡內存；更長的數據請使用串流回測（OpenJSONStream / OpenCSVStream）
+const DefaultMaxCandles = 10_000_000
+
+// ErrTooManyCandles 數據文件的K線數超過載入上限
+var ErrTooManyCandles = errors.New("too many candles")
+
 // CandleLoader K線數據加載器
 type CandleLoader struct {
-	filepath string
+	filepath   string
+	maxCandles int                          // 最多解析的K線數（超過時返回 ErrTooManyCandles）
+	validation value_objects.OHLCValidation // OHLC 校驗模式（默認嚴格）
+	adjusted   int                          // 容錯模式下被修正的K線數
 }
 
 // NewCandleLoader 創建加載器
 func NewCandleLoader(filepath string) *CandleLoader {
 	return &CandleLoader{
-		filepath: filepath,
+		filepath:   filepath,
+		maxCandles: DefaultMaxCandles,
+	}
+}
+
+// SetMaxCandles 設置最多解析的K線數（<= 0 = DefaultMaxCandles）
+func (l *CandleLoader) SetMaxCandles(maxCandles int) {
+	if maxCandles <= 0 {
+		maxCandles = DefaultMaxCandles
 	}
+	l.maxCandles = maxCandles
+}
+
+// SetOHLCValidation 設置 OHLC 校驗模式 ⭐
+//
+//   - OHLCStrict（默認）: 違反 low <= open, close <= high 的K線視為格式錯誤（Load 返回錯誤，LoadPartial 跳過該行）
+//   - OHLCClamp: 修正後保留，修正數量由 AdjustedCount 返回
+func (l *CandleLoader) SetOHLCValidation(mode value_objects.OHLCValidation) {
+	l.validation = mode
+}
+
+// AdjustedCount 上次載入時被 OHLCClamp 修正的K線數
+func (l *CandleLoader) AdjustedCount() int {
+	return l.adjusted
+}
+
+// tooManyCandles 返回超過上限的錯誤
+func tooManyCandles(maxCandles int) error {
+	return fmt.Errorf("%w: file has more than %d candles", ErrTooManyCandles, maxCandles)
 }
 
 // Load 載入歷史K線數據
+//
+// 逐行解析 data 數組（不把整個文件讀入內存），K線數超過上限時返回 ErrTooManyCandles
 // 返回：Candle切片（從舊到新排序）
 func (l *CandleLoader) Load() ([]value_objects.Candle, error) {
-	// 1. 讀取文件
-	data, err := os.ReadFile(l.filepath)
+	l.adjusted = 0
+
+	// 1. 打開文件
+	file, err := os.Open(l.filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	defer file.Close()
+
+	// 2. 解析 JSON（data 逐行轉換為 Candle 對象）
+	decoder := json.NewDecoder(file)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
 
-	// 2. 解析 JSON
 	var response OKXResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	var candles []value_objects.Candle
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		switch key, _ := keyToken.(string); key {
+		case "code":
+			err = decoder.Decode(&response.Code)
+		case "msg":
+			err = decoder.Decode(&response.Msg)
+		case "data":
+			candles, err = l.decodeStrictRows(decoder)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			var ignored json.RawMessage
+			err = decoder.Decode(&ignored)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
@@ -60,14 +134,45 @@ func (l *CandleLoader) Load() ([]value_objects.Candle, error) {
 		return nil, fmt.Errorf("OKX error: %s", response.Msg)
 	}
 
-	if len(response.Data) == 0 {
+	if len(candles) == 0 {
 		return nil, fmt.Errorf("no data in file")
 	}
 
-	// 4. 轉換為 Candle 對象
-	candles := make([]value_objects.Candle, 0, len(response.Data))
+	// 4. 反轉順序（OKX 是從新到舊，我們需要從舊到新）
+	reversed := make([]value_objects.Candle, len(candles))
+	for i := range candles {
+		reversed[i] = candles[len(candles)-1-i]
+	}
+
+	return reversed, nil
+}
+
+// decodeStrictRows 嚴格模式逐行解析 data 數組（null 視為空數組）
+//
+// 任何一行解析失敗或K線數超過 maxCandles 時返回錯誤
+func (l *CandleLoader) decodeStrictRows(decoder *json.Decoder) ([]value_objects.Candle, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("failed to parse JSON: expected %q, got %v", '[', token)
+	}
+
+	candles := make([]value_objects.Candle, 0)
+	for i := 0; decoder.More(); i++ {
+		if len(candles) >= l.maxCandles {
+			return nil, tooManyCandles(l.maxCandles)
+		}
+
+		var row []string
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
 
-	for i, row := range response.Data {
 		// 驗證數組長度
 		if len(row) < 5 {
 			return nil, fmt.Errorf("invalid candle at index %d: insufficient fields", i)
@@ -81,13 +186,160 @@ func (l *CandleLoader) Load() ([]value_objects.Candle, error) {
 		candles = append(candles, candle)
 	}
 
-	// 5. 反轉順序（OKX 是從新到舊，我們需要從舊到新）
+	// 讀取結尾的 ]
+	if err := expectDelim(decoder, ']'); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return candles, nil
+}
+
+// LoadPartial 容錯載入歷史K線數據 ⭐
+//
+// 與 Load 的差異：
+//   - Load: 嚴格模式，任何一行解析失敗（包括文件被截斷）都返回錯誤
+//   - LoadPartial: 逐行串流解析，跳過格式錯誤的行；文件被截斷時保留已解析的完整行
+//
+// 用途：下載中斷導致 JSON 文件不完整時，仍可挽救可用數據
+//
+// 返回：
+//   - []value_objects.Candle: 成功解析的 K 線（從舊到新排序）
+//   - int: 被跳過的格式錯誤行數（包括被截斷的最後一行）
+//   - error: 文件無法讀取、OKX 返回錯誤或沒有任何可用數據時返回錯誤
+func (l *CandleLoader) LoadPartial() ([]value_objects.Candle, int, error) {
+	l.adjusted = 0
+
+	// 1. 打開文件（串流解析）
+	file, err := os.Open(l.filepath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	// 2. 逐個字段解析，遇到 data 時逐行解析
+	candles := make([]value_objects.Candle, 0)
+	skipped := 0
+	code := ""
+	msg := ""
+
+fields:
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			break // 文件被截斷
+		}
+		key, _ := keyToken.(string)
+
+		switch key {
+		case "code":
+			if err := decoder.Decode(&code); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse code: %w", err)
+			}
+		case "msg":
+			if err := decoder.Decode(&msg); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse msg: %w", err)
+			}
+		case "data":
+			var truncated bool
+			candles, skipped, truncated, err = l.decodeRows(decoder)
+			if err != nil {
+				return nil, skipped, err
+			}
+			if truncated {
+				break fields
+			}
+		default:
+			var ignored json.RawMessage
+			if err := decoder.Decode(&ignored); err != nil {
+				break fields
+			}
+		}
+	}
+
+	// 3. 檢查響應（code 缺失時視為被截斷，不判斷）
+	if code != "" && code != "0" {
+		return nil, skipped, fmt.Errorf("OKX error: %s", msg)
+	}
+
+	if len(candles) == 0 {
+		return nil, skipped, fmt.Errorf("no data in file")
+	}
+
+	// 4. 反轉順序（OKX 是從新到舊，我們需要從舊到新）
 	reversed := make([]value_objects.Candle, len(candles))
 	for i := range candles {
 		reversed[i] = candles[len(candles)-1-i]
 	}
 
-	return reversed, nil
+	return reversed, skipped, nil
+}
+
+// decodeRows 逐行解析 data 數組
+//
+// 返回：
+//   - candles: 成功解析的 K 線（保持文件順序）
+//   - skipped: 跳過的行數
+//   - truncated: 數組是否因文件截斷而未能讀完
+//   - err: K線數超過 maxCandles 時返回 ErrTooManyCandles（不截斷，避免把超大文件當作完整數據）
+func (l *CandleLoader) decodeRows(decoder *json.Decoder) (candles []value_objects.Candle, skipped int, truncated bool, err error) {
+	candles = make([]value_objects.Candle, 0)
+
+	if err := expectDelim(decoder, '['); err != nil {
+		return candles, 0, true, nil
+	}
+
+	for decoder.More() {
+		if len(candles) >= l.maxCandles {
+			return nil, skipped, false, tooManyCandles(l.maxCandles)
+		}
+
+		var row []string
+		if err := decoder.Decode(&row); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				// 類型錯誤：該行已被完整讀取，跳過繼續
+				skipped++
+				continue
+			}
+			// 語法錯誤或 EOF：文件被截斷，保留之前的數據
+			return candles, skipped + 1, true, nil
+		}
+
+		if len(row) < 5 {
+			skipped++
+			continue
+		}
+
+		candle, err := l.parseOKXCandle(row)
+		if err != nil {
+			skipped++
+			continue
+		}
+		candles = append(candles, candle)
+	}
+
+	// 讀取結尾的 ]
+	if _, err := decoder.Token(); err != nil {
+		return candles, skipped, true, nil
+	}
+
+	return candles, skipped, false, nil
+}
+
+// expectDelim 讀取下一個 token 並確認是指定的分隔符
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, token)
+	}
+	return nil
 }
 
 // parseOKXCandle 解析 OKX K線數據為 Candle 對象
@@ -120,11 +372,19 @@ func (l *CandleLoader) parseOKXCandle(row []string) (value_objects.Candle, error
 		return value_objects.Candle{}, fmt.Errorf("invalid close price: %w", err)
 	}
 
-	// 創建 Candle 值對象
-	candle, err := value_objects.NewCandle(open, high, low, close, timestamp)
+	// 創建 Candle 值對象（按校驗模式拒絕或修正 OHLC 異常）
+	candle, adjusted, err := value_objects.NewCandleWithValidation(open, high, low, close, timestamp, l.validation)
 	if err != nil {
 		return value_objects.Candle{}, fmt.Errorf("failed to create candle: %w", err)
 	}
+	if adjusted {
+		l.adjusted++
+	}
+
+	// 第 9 個欄位為 confirm（0 = 未完成，1 = 已完成），缺失時視為已完成
+	if len(row) > 8 && row[8] == "0" {
+		candle = candle.WithConfirmed(false)
+	}
 
 	return candle, nil
 }
@@ -134,3 +394,11 @@ func LoadFromJSON(filepath string) ([]value_objects.Candle, error) {
 	loader := NewCandleLoader(filepath)
 	return loader.Load()
 }
+
+// LoadFromJSONPartial 便捷函數：容錯模式從 JSON 文件加載 K 線數據 ⭐
+//
+// 返回成功解析的 K 線和被跳過的格式錯誤行數（詳見 CandleLoader.LoadPartial）
+func LoadFromJSONPartial(filepath string) ([]value_objects.Candle, int, error) {
+	loader := NewCandleLoader(filepath)
+	return loader.LoadPartial()
+}
@@ -0,0 +1,171 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// writeTempFile 寫入臨時數據文件
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "candles.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	return path
+}
+
+// TestLoadFromJSONPartial_TruncatedLastRow 測試最後一行被截斷時保留完整的前綴
+func TestLoadFromJSONPartial_TruncatedLastRow(t *testing.T) {
+	// OKX 從新到舊排序，最後一行（最舊）被截斷
+	content := `{"code":"0","msg":"","data":[` +
+		`["1704067500000","2510","2515","2505","2512","1","1","1","1"],` +
+		`["1704067200000","2500","2510","2495","2505","1","1","1","1"],` +
+		`["1704066900000","2490","25`
+	path := writeTempFile(t, content)
+
+	// 嚴格模式：整體失敗
+	if _, err := LoadFromJSON(path); err == nil {
+		t.Fatal("Expected strict loader to fail on truncated file")
+	}
+
+	// 容錯模式：保留完整的兩行
+	candles, skipped, err := LoadFromJSONPartial(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSONPartial failed: %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("Expected 2 candles, got %d", len(candles))
+	}
+	if skipped != 1 {
+		t.Errorf("Expected 1 skipped row, got %d", skipped)
+	}
+
+	// 從舊到新排序
+	if candles[0].Close().Value() != 2505 || candles[1].Close().Value() != 2512 {
+		t.Errorf("Expected closes [2505 2512], got [%.0f %.0f]", candles[0].Close().Value(), candles[1].Close().Value())
+	}
+}
+
+// TestLoadFromJSONPartial_SkipsMalformedRows 測試跳過格式錯誤的行並繼續解析
+func TestLoadFromJSONPartial_SkipsMalformedRows(t *testing.T) {
+	content := `{"code":"0","msg":"","data":[` +
+		`["1704067500000","2510","2515","2505","2512"],` +
+		`["1704067200000","abc","2510","2495","2505"],` +
+		`["1704066900000","2490"],` +
+		`[1704066600000,2480,2490,2475,2485],` +
+		`["1704066300000","2470","2480","2465","2475"]` +
+		`]}`
+	path := writeTempFile(t, content)
+
+	candles, skipped, err := LoadFromJSONPartial(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSONPartial failed: %v", err)
+	}
+	if len(candles) != 2 {
+		t.Errorf("Expected 2 candles, got %d", len(candles))
+	}
+	if skipped != 3 {
+		t.Errorf("Expected 3 skipped rows, got %d", skipped)
+	}
+}
+
+// TestLoadFromJSONPartial_OKXError 測試 OKX 錯誤響應
+func TestLoadFromJSONPartial_OKXError(t *testing.T) {
+	path := writeTempFile(t, `{"code":"51000","msg":"parameter error","data":[]}`)
+
+	if _, _, err := LoadFromJSONPartial(path); err == nil {
+		t.Error("Expected error for OKX error response")
+	}
+}
+
+// TestLoadFromJSON_ConfirmFlag 測試從 confirm 欄位讀取K線完成狀態（缺失時視為已完成）
+func TestLoadFromJSON_ConfirmFlag(t *testing.T) {
+	content := `{"code":"0","msg":"","data":[` +
+		`["1704067800000","2520","2525","2515","2522","1","1","1","0"],` +
+		`["1704067500000","2510","2515","2505","2512","1","1","1","1"],` +
+		`["1704067200000","2500","2510","2495","2505"]` +
+		`]}`
+	path := writeTempFile(t, content)
+
+	candles, err := LoadFromJSON(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSON failed: %v", err)
+	}
+	if len(candles) != 3 {
+		t.Fatalf("Expected 3 candles, got %d", len(candles))
+	}
+
+	// 從舊到新：無 confirm 欄位 → 已完成、confirm=1 → 已完成、confirm=0 → 未完成
+	want := []bool{true, true, false}
+	for i, candle := range candles {
+		if candle.IsConfirmed() != want[i] {
+			t.Errorf("Candle %d: expected confirmed=%v, got %v", i, want[i], candle.IsConfirmed())
+		}
+	}
+}
+
+// TestCandleLoader_MaxCandles 測試K線數超過上限時嚴格和容錯模式都返回 ErrTooManyCandles
+func TestCandleLoader_MaxCandles(t *testing.T) {
+	content := `{"code":"0","msg":"","data":[` +
+		`["1704067500000","2510","2515","2505","2512","1","1","1","1"],` +
+		`["1704067200000","2500","2510","2495","2505","1","1","1","1"],` +
+		`["1704066900000","2490","2500","2485","2495","1","1","1","1"]]}`
+	path := writeTempFile(t, content)
+
+	loader := NewCandleLoader(path)
+	loader.SetMaxCandles(2)
+	if _, err := loader.Load(); !errors.Is(err, ErrTooManyCandles) {
+		t.Errorf("Expected ErrTooManyCandles from Load, got %v", err)
+	}
+	if _, _, err := loader.LoadPartial(); !errors.Is(err, ErrTooManyCandles) {
+		t.Errorf("Expected ErrTooManyCandles from LoadPartial, got %v", err)
+	}
+
+	// 剛好等於上限時正常載入
+	loader.SetMaxCandles(3)
+	candles, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Expected load within limit to succeed, got %v", err)
+	}
+	if len(candles) != 3 || !candles[0].Timestamp().Before(candles[2].Timestamp()) {
+		t.Errorf("Expected 3 candles from old to new, got %d", len(candles))
+	}
+}
+
+// TestCandleLoader_OHLCValidation 測試 high/low 顛倒的K線在嚴格和容錯校驗模式下的處理 ⭐
+func TestCandleLoader_OHLCValidation(t *testing.T) {
+	// 中間一行 high (2490) < low (2510)
+	content := `{"code":"0","msg":"","data":[` +
+		`["1704067500000","2510","2515","2505","2512","1","1","1","1"],` +
+		`["1704067200000","2500","2490","2510","2505","1","1","1","1"],` +
+		`["1704066900000","2490","2500","2485","2495","1","1","1","1"]]}`
+	path := writeTempFile(t, content)
+
+	// 嚴格模式（默認）：Load 返回錯誤，LoadPartial 跳過該行
+	loader := NewCandleLoader(path)
+	if _, err := loader.Load(); !errors.Is(err, value_objects.ErrInvalidOHLC) {
+		t.Errorf("Expected ErrInvalidOHLC from strict Load, got %v", err)
+	}
+	candles, skipped, err := loader.LoadPartial()
+	if err != nil || len(candles) != 2 || skipped != 1 {
+		t.Errorf("Expected strict LoadPartial to skip the inverted row, got %d candles, %d skipped, err %v", len(candles), skipped, err)
+	}
+
+	// 容錯模式：交換 high/low 後保留，並報告修正數量
+	loader.SetOHLCValidation(value_objects.OHLCClamp)
+	candles, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Expected clamp Load to succeed, got %v", err)
+	}
+	if len(candles) != 3 || loader.AdjustedCount() != 1 {
+		t.Fatalf("Expected 3 candles with 1 adjusted, got %d candles, %d adjusted", len(candles), loader.AdjustedCount())
+	}
+	if fixed := candles[1]; fixed.High().Value() != 2510 || fixed.Low().Value() != 2490 {
+		t.Errorf("Expected high/low swapped to 2510/2490, got %v/%v", fixed.High().Value(), fixed.Low().Value())
+	}
+}
@@ -0,0 +1,338 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// CandleStream 逐根提供K線（從舊到新）⭐
+//
+// 與 Load 返回完整切片不同，串流實現只在內存中保留當前讀取位置，
+// 供 BacktestEngine.RunStream 回測多年的 1m 數據
+type CandleStream interface {
+	// Next 返回下一根K線；讀完時返回 ok = false（err 為 nil）
+	Next() (candle value_objects.Candle, ok bool, err error)
+}
+
+// SliceStream 把已載入的K線切片包裝為 CandleStream（測試和小數據使用）
+type SliceStream struct {
+	candles []value_objects.Candle
+	pos     int
+}
+
+// NewSliceStream 創建切片串流（candles 需從舊到新排序）
+func NewSliceStream(candles []value_objects.Candle) *SliceStream {
+	return &SliceStream{candles: candles}
+}
+
+// Next 返回下一根K線
+func (s *SliceStream) Next() (value_objects.Candle, bool, error) {
+	if s.pos >= len(s.candles) {
+		return value_objects.Candle{}, false, nil
+	}
+	s.pos++
+	return s.candles[s.pos-1], true, nil
+}
+
+// FileStream 從 JSON / CSV 文件串流讀取K線 ⭐
+//
+// 文件按從舊到新排序時逐行讀取，不保留已讀的行；
+// 按從新到舊排序（OKX 導出格式）時，打開文件會先掃描一遍，只記錄每行的結束偏移（每行 8 字節），
+// 之後從文件尾部往前逐行重新讀取。解析規則與 LoadFromJSON / LoadFromCSV 相同（嚴格模式，OHLC 校驗為 OHLCStrict），
+// 另外要求時間戳嚴格遞增，否則返回 ErrNonMonotonicTimestamps。
+//
+// 使用完畢需調用 Close
+type FileStream struct {
+	file  *os.File
+	rows  rowReader                                                   // 順序讀取下一行
+	parse func(row []string, index int) (value_objects.Candle, error) // 把第 index 行（從 0 開始）解析為K線
+	// decodeRaw 把 [ends[i-1], ends[i]) 的原始字節重新解析為一行（只在從新到舊的文件使用）
+	decodeRaw func(raw []byte) ([]string, error)
+
+	pending []value_objects.Candle // 打開時為判斷排序預讀的K線
+	row     int                    // 從舊到新的文件：下一個要讀的行號
+	ends    []int64                // 從新到舊的文件：每行的結束偏移（ends[0] 為第一行的起點）
+	next    int                    // 從新到舊的文件：下一個要讀的行號（從最後一行往前）
+	last    time.Time              // 上一根K線的時間（校驗遞增）
+	count   int                    // 已返回的K線數
+}
+
+// rowReader 順序讀取下一行，返回該行和讀完該行後的文件偏移；讀完時返回 io.EOF
+type rowReader func() (row []string, end int64, err error)
+
+// OpenJSONStream 串流讀取 OKX JSON 格式的K線文件 ⭐
+//
+// 文件中位於 data 之前的 code 字段非 "0" 時返回錯誤
+func OpenJSONStream(filepath string) (*FileStream, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	decoder := json.NewDecoder(file)
+	if err := seekJSONData(decoder); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	l := &CandleLoader{filepath: filepath}
+	stream := &FileStream{
+		file: file,
+		rows: func() ([]string, int64, error) {
+			if !decoder.More() {
+				return nil, 0, io.EOF
+			}
+			var row []string
+			if err := decoder.Decode(&row); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse JSON: %w", err)
+			}
+			return row, decoder.InputOffset(), nil
+		},
+		parse: func(row []string, index int) (value_objects.Candle, error) {
+			if len(row) < 5 {
+				return value_objects.Candle{}, fmt.Errorf("invalid candle at index %d: insufficient fields", index)
+			}
+			candle, err := l.parseOKXCandle(row)
+			if err != nil {
+				return value_objects.Candle{}, fmt.Errorf("failed to parse candle at index %d: %w", index, err)
+			}
+			return candle, nil
+		},
+		decodeRaw: func(raw []byte) ([]string, error) {
+			var row []string
+			if err := json.Unmarshal(bytes.TrimLeft(raw, " \t\r\n,"), &row); err != nil {
+				return nil, fmt.Errorf("failed to parse JSON: %w", err)
+			}
+			return row, nil
+		},
+	}
+	if err := stream.init(decoder.InputOffset()); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
+// seekJSONData 讀到 data 數組的 [ 之後，途中檢查 code 字段
+func seekJSONData(decoder *json.Decoder) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	code, msg := "", ""
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		switch key, _ := keyToken.(string); key {
+		case "code":
+			err = decoder.Decode(&code)
+		case "msg":
+			err = decoder.Decode(&msg)
+		case "data":
+			if code != "" && code != "0" {
+				return fmt.Errorf("OKX error: %s", msg)
+			}
+			if err := expectDelim(decoder, '['); err != nil {
+				return fmt.Errorf("failed to parse JSON: %w", err)
+			}
+			return nil
+		default:
+			var ignored json.RawMessage
+			err = decoder.Decode(&ignored)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+
+	if code != "" && code != "0" {
+		return fmt.Errorf("OKX error: %s", msg)
+	}
+	return fmt.Errorf("no data in file")
+}
+
+// OpenCSVStream 串流讀取 CSV 格式的K線文件（格式和時區規則同 LoadFromCSV）⭐
+func OpenCSVStream(filepath string, loc *time.Location) (*FileStream, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	newReader := func(r io.Reader) *csv.Reader {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader
+	}
+	reader := newReader(file)
+
+	// 第一行無法解析時間時視為表頭，從表頭之後開始
+	start := int64(0)
+	first, err := reader.Read()
+	if err != nil {
+		file.Close()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no data in file")
+		}
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	headerLines := 0
+	if isCSVHeader(first, loc) {
+		start = reader.InputOffset()
+		headerLines = 1
+		first = nil
+	}
+
+	stream := &FileStream{
+		file: file,
+		rows: func() ([]string, int64, error) {
+			if first != nil {
+				row := first
+				first = nil
+				return row, reader.InputOffset(), nil
+			}
+			row, err := reader.Read()
+			if err == io.EOF {
+				return nil, 0, io.EOF
+			}
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to parse CSV: %w", err)
+			}
+			return row, reader.InputOffset(), nil
+		},
+		parse: func(row []string, index int) (value_objects.Candle, error) {
+			candle, _, err := parseCSVCandle(row, index+headerLines+1, loc, value_objects.OHLCStrict)
+			return candle, err
+		},
+		decodeRaw: func(raw []byte) ([]string, error) {
+			row, err := newReader(bytes.NewReader(raw)).Read()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CSV: %w", err)
+			}
+			return row, nil
+		},
+	}
+	if err := stream.init(start); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
+// init 預讀前兩行判斷文件排序；從新到舊時掃描全文件記錄每行的結束偏移
+//
+// start 為第一行的起始偏移
+func (s *FileStream) init(start int64) error {
+	ends := []int64{start}
+	for len(s.pending) < 2 {
+		row, end, err := s.rows()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		candle, err := s.parse(row, s.row)
+		if err != nil {
+			return err
+		}
+		s.row++
+		s.pending = append(s.pending, candle)
+		ends = append(ends, end)
+	}
+
+	if len(s.pending) == 0 {
+		return fmt.Errorf("no data in file")
+	}
+	if len(s.pending) < 2 || !s.pending[0].Timestamp().After(s.pending[1].Timestamp()) {
+		return nil // 從舊到新：順序讀取
+	}
+
+	// 從新到舊：只記錄偏移，之後從最後一行往前讀
+	for {
+		_, end, err := s.rows()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		ends = append(ends, end)
+	}
+	s.pending = nil
+	s.ends = ends
+	s.next = len(ends) - 2
+	return nil
+}
+
+// Next 返回下一根K線（從舊到新）
+func (s *FileStream) Next() (value_objects.Candle, bool, error) {
+	candle, ok, err := s.read()
+	if err != nil || !ok {
+		return value_objects.Candle{}, false, err
+	}
+
+	if s.count > 0 && !candle.Timestamp().After(s.last) {
+		return value_objects.Candle{}, false, fmt.Errorf("%w: index %d (%s) follows %s",
+			ErrNonMonotonicTimestamps, s.count, candle.Timestamp().UTC().Format(time.RFC3339), s.last.UTC().Format(time.RFC3339))
+	}
+	s.last = candle.Timestamp()
+	s.count++
+	return candle, true, nil
+}
+
+// read 按文件排序讀取下一根K線
+func (s *FileStream) read() (value_objects.Candle, bool, error) {
+	if s.ends == nil {
+		if len(s.pending) > 0 {
+			candle := s.pending[0]
+			s.pending = s.pending[1:]
+			return candle, true, nil
+		}
+		row, _, err := s.rows()
+		if err == io.EOF {
+			return value_objects.Candle{}, false, nil
+		}
+		if err != nil {
+			return value_objects.Candle{}, false, err
+		}
+		candle, err := s.parse(row, s.row)
+		s.row++
+		return candle, err == nil, err
+	}
+
+	if s.next < 0 {
+		return value_objects.Candle{}, false, nil
+	}
+	start, end := s.ends[s.next], s.ends[s.next+1]
+	raw := make([]byte, end-start)
+	if _, err := s.file.ReadAt(raw, start); err != nil {
+		return value_objects.Candle{}, false, fmt.Errorf("failed to read row %d: %w", s.next, err)
+	}
+	row, err := s.decodeRaw(raw)
+	if err != nil {
+		return value_objects.Candle{}, false, fmt.Errorf("row %d: %w", s.next, err)
+	}
+	candle, err := s.parse(row, s.next)
+	s.next--
+	return candle, err == nil, err
+}
+
+// Close 關閉文件
+func (s *FileStream) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,120 @@
+package loader
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// drainStream 讀完串流中的所有K線
+func drainStream(t *testing.T, stream CandleStream) ([]value_objects.Candle, error) {
+	t.Helper()
+	var candles []value_objects.Candle
+	for {
+		candle, ok, err := stream.Next()
+		if err != nil {
+			return candles, err
+		}
+		if !ok {
+			return candles, nil
+		}
+		candles = append(candles, candle)
+	}
+}
+
+// TestOpenJSONStream_MatchesLoad 測試從新到舊和從舊到新的 JSON 文件串流結果與 LoadFromJSON 一致 ⭐
+func TestOpenJSONStream_MatchesLoad(t *testing.T) {
+	rows := []string{
+		`["1704067500000","2510","2515","2505","2512","1","1","1","0"]`,
+		`["1704067200000","2500","2510","2495","2505","1","1","1","1"]`,
+		`["1704066900000","2490","2500","2485","2495","1","1","1","1"]`,
+	}
+	newestFirst := writeTempFile(t, `{"code":"0","msg":"","data":[`+"\n  "+strings.Join(rows, ",\n  ")+"\n]}")
+	want, err := LoadFromJSON(newestFirst)
+	if err != nil {
+		t.Fatalf("LoadFromJSON failed: %v", err)
+	}
+
+	// 從舊到新的文件（字段順序不同、code 在 data 之後）
+	oldestFirst := writeTempFile(t, `{"data":[`+rows[2]+`,`+rows[1]+`,`+rows[0]+`],"msg":"","code":"0"}`)
+
+	for name, path := range map[string]string{"newest first": newestFirst, "oldest first": oldestFirst} {
+		stream, err := OpenJSONStream(path)
+		if err != nil {
+			t.Fatalf("%s: OpenJSONStream failed: %v", name, err)
+		}
+		got, err := drainStream(t, stream)
+		stream.Close()
+		if err != nil {
+			t.Fatalf("%s: stream failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+// TestOpenCSVStream_MatchesLoad 測試 CSV 串流（表頭、時區、從新到舊）與 LoadFromCSV 一致
+func TestOpenCSVStream_MatchesLoad(t *testing.T) {
+	content := "timestamp,open,high,low,close\n" +
+		"2024-01-01 08:10:00,2510,2515,2505,2512\n" +
+		"2024-01-01 08:05:00,2500,2510,2495,2505\n" +
+		"2024-01-01 08:00:00,2490,2500,2485,2495\n"
+	path := writeTempFile(t, content)
+	loc := time.FixedZone("UTC+8", 8*3600)
+
+	want, err := LoadFromCSV(path, loc)
+	if err != nil {
+		t.Fatalf("LoadFromCSV failed: %v", err)
+	}
+	stream, err := OpenCSVStream(path, loc)
+	if err != nil {
+		t.Fatalf("OpenCSVStream failed: %v", err)
+	}
+	defer stream.Close()
+	got, err := drainStream(t, stream)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestFileStream_Errors 測試亂序、格式錯誤和 OKX 錯誤
+func TestFileStream_Errors(t *testing.T) {
+	// 前兩行遞增，之後亂序
+	path := writeTempFile(t, "1704066900000,1,2,0.5,1\n1704067200000,1,2,0.5,1\n1704067000000,1,2,0.5,1\n")
+	stream, err := OpenCSVStream(path, nil)
+	if err != nil {
+		t.Fatalf("OpenCSVStream failed: %v", err)
+	}
+	got, err := drainStream(t, stream)
+	stream.Close()
+	if !errors.Is(err, ErrNonMonotonicTimestamps) || len(got) != 2 {
+		t.Errorf("Expected ErrNonMonotonicTimestamps after 2 candles, got %d candles / %v", len(got), err)
+	}
+
+	// 壞行在從新到舊的文件中間：回放到該行時報錯並帶行號
+	path = writeTempFile(t, "1704067500000,1,2,0.5,1\n1704067200000,1,x,0.5,1\n1704066900000,1,2,0.5,1\n")
+	stream, err = OpenCSVStream(path, nil)
+	if err == nil {
+		_, err = drainStream(t, stream)
+		stream.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error at line 2, got %v", err)
+	}
+
+	if _, err := OpenJSONStream(writeTempFile(t, `{"code":"51000","msg":"parameter error","data":[]}`)); err == nil ||
+		!strings.Contains(err.Error(), "parameter error") {
+		t.Errorf("Expected OKX error, got %v", err)
+	}
+	if _, err := OpenJSONStream(writeTempFile(t, `{"code":"0","msg":"","data":[]}`)); err == nil {
+		t.Error("Expected error for empty data")
+	}
+}
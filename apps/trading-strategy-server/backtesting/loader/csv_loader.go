@@ -0,0 +1,208 @@
+package loader
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// ErrNonMonotonicTimestamps K線時間戳重複或非遞增
+var ErrNonMonotonicTimestamps = errors.New("candle timestamps are not strictly increasing")
+
+// csvTimeLayouts CSV 時間欄位支持的格式（不含時區的格式按指定時區解析）
+var csvTimeLayouts = []string{
+	time.RFC3339, // 自帶時區偏移，忽略指定時區
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+}
+
+// LoadFromCSV 從 CSV 文件加載 K 線數據 ⭐
+//
+// CSV 格式（第一行可為表頭）：
+//
+//	timestamp,open,high,low,close[,其他欄位...]
+//
+// 時間欄位支持：
+//   - 毫秒時間戳（絕對時間，與時區無關）
+//   - 日期時間字符串（例如 "2024-01-01 08:00:00"），按 loc 時區解析
+//   - RFC3339（自帶時區偏移）
+//
+// 所有時間戳統一轉換為 UTC，避免本地時間導致的時段過濾、注資間隔錯位。
+// 文件可以是從舊到新或從新到舊（OKX 導出格式）排序，返回時統一為從舊到新；
+// 轉換後若時間戳重複或非單調遞增，返回 ErrNonMonotonicTimestamps。
+//
+// 參數：
+//   - filepath: CSV 文件路徑
+//   - loc: 無時區信息的時間字符串所使用的時區（nil 表示 UTC）
+func LoadFromCSV(filepath string, loc *time.Location) ([]value_objects.Candle, error) {
+	return LoadFromCSVWithLimit(filepath, loc, DefaultMaxCandles)
+}
+
+// LoadFromCSVWithLimit 從 CSV 文件加載 K 線數據，最多解析 maxCandles 根（<= 0 = DefaultMaxCandles）⭐
+//
+// 超過上限時返回 ErrTooManyCandles
+func LoadFromCSVWithLimit(filepath string, loc *time.Location, maxCandles int) ([]value_objects.Candle, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseCSVWithLimit(file, loc, maxCandles)
+}
+
+// LoadFromCSVWithValidation 從 CSV 文件加載 K 線數據，按 validation 校驗 OHLC ⭐
+//
+// 返回的 int 為 OHLCClamp 模式下被修正的K線數（OHLCStrict 模式違反規則時返回錯誤）
+func LoadFromCSVWithValidation(filepath string, loc *time.Location, maxCandles int, validation value_objects.OHLCValidation) ([]value_objects.Candle, int, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseCSVWithValidation(file, loc, maxCandles, validation)
+}
+
+// ParseCSV 從 reader 解析 CSV 格式的 K 線數據（規則同 LoadFromCSV）
+func ParseCSV(r io.Reader, loc *time.Location) ([]value_objects.Candle, error) {
+	return ParseCSVWithLimit(r, loc, DefaultMaxCandles)
+}
+
+// ParseCSVWithLimit 從 reader 逐行解析 CSV 格式的 K 線數據，最多 maxCandles 根（<= 0 = DefaultMaxCandles）
+func ParseCSVWithLimit(r io.Reader, loc *time.Location, maxCandles int) ([]value_objects.Candle, error) {
+	candles, _, err := ParseCSVWithValidation(r, loc, maxCandles, value_objects.OHLCStrict)
+	return candles, err
+}
+
+// ParseCSVWithValidation 從 reader 逐行解析 CSV 格式的 K 線數據，按 validation 校驗 OHLC
+//
+// 返回的 int 為 OHLCClamp 模式下被修正的K線數
+func ParseCSVWithValidation(r io.Reader, loc *time.Location, maxCandles int, validation value_objects.OHLCValidation) ([]value_objects.Candle, int, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if maxCandles <= 0 {
+		maxCandles = DefaultMaxCandles
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	candles := make([]value_objects.Candle, 0)
+	adjusted := 0
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if i == 0 && isCSVHeader(record, loc) {
+			continue
+		}
+		if len(candles) >= maxCandles {
+			return nil, 0, tooManyCandles(maxCandles)
+		}
+		candle, fixed, err := parseCSVCandle(record, i+1, loc, validation)
+		if err != nil {
+			return nil, 0, err
+		}
+		if fixed {
+			adjusted++
+		}
+		candles = append(candles, candle)
+	}
+
+	if len(candles) == 0 {
+		return nil, 0, fmt.Errorf("no data in file")
+	}
+
+	// 從新到舊排序的文件（例如 OKX 導出）反轉為從舊到新
+	if candles[0].Timestamp().After(candles[len(candles)-1].Timestamp()) {
+		for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+			candles[i], candles[j] = candles[j], candles[i]
+		}
+	}
+
+	if err := ValidateTimestamps(candles); err != nil {
+		return nil, 0, err
+	}
+
+	return candles, adjusted, nil
+}
+
+// parseCSVCandle 解析 CSV 的一行（line 從 1 開始，用於錯誤信息），返回K線和是否被 OHLCClamp 修正
+func parseCSVCandle(record []string, line int, loc *time.Location, validation value_objects.OHLCValidation) (value_objects.Candle, bool, error) {
+	if len(record) < 5 {
+		return value_objects.Candle{}, false, fmt.Errorf("invalid candle at line %d: insufficient fields", line)
+	}
+
+	timestamp, err := parseCSVTimestamp(record[0], loc)
+	if err != nil {
+		return value_objects.Candle{}, false, fmt.Errorf("invalid timestamp at line %d: %w", line, err)
+	}
+
+	prices := make([]float64, 4)
+	for j := range prices {
+		prices[j], err = strconv.ParseFloat(strings.TrimSpace(record[j+1]), 64)
+		if err != nil {
+			return value_objects.Candle{}, false, fmt.Errorf("invalid price at line %d: %w", line, err)
+		}
+	}
+
+	candle, adjusted, err := value_objects.NewCandleWithValidation(prices[0], prices[1], prices[2], prices[3], timestamp.UTC(), validation)
+	if err != nil {
+		return value_objects.Candle{}, false, fmt.Errorf("failed to create candle at line %d: %w", line, err)
+	}
+	return candle, adjusted, nil
+}
+
+// ValidateTimestamps 校驗 K 線時間戳嚴格遞增（無重複、無亂序）
+func ValidateTimestamps(candles []value_objects.Candle) error {
+	for i := 1; i < len(candles); i++ {
+		prev := candles[i-1].Timestamp()
+		curr := candles[i].Timestamp()
+		if !curr.After(prev) {
+			return fmt.Errorf("%w: index %d (%s) follows %s",
+				ErrNonMonotonicTimestamps, i, curr.UTC().Format(time.RFC3339), prev.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// isCSVHeader 第一行欄位足夠但時間無法解析時視為表頭
+func isCSVHeader(record []string, loc *time.Location) bool {
+	if len(record) < 5 {
+		return false
+	}
+	_, err := parseCSVTimestamp(record[0], loc)
+	return err != nil
+}
+
+// parseCSVTimestamp 解析 CSV 時間欄位
+func parseCSVTimestamp(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if tsMs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(tsMs), nil
+	}
+
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time format %q", value)
+}
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// TestLoadFromCSV_NormalizesToUTC 測試非 UTC 的 CSV 時間統一轉換為 UTC 且嚴格遞增
+func TestLoadFromCSV_NormalizesToUTC(t *testing.T) {
+	// 台北時間（UTC+8），從新到舊排序
+	content := "timestamp,open,high,low,close\n" +
+		"2024-01-01 08:10:00,2510,2515,2505,2512\n" +
+		"2024-01-01 08:05:00,2500,2510,2495,2505\n" +
+		"2024-01-01 08:00:00,2490,2500,2485,2495\n"
+	path := writeTempFile(t, content)
+
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	candles, err := LoadFromCSV(path, taipei)
+	if err != nil {
+		t.Fatalf("LoadFromCSV failed: %v", err)
+	}
+	if len(candles) != 3 {
+		t.Fatalf("Expected 3 candles, got %d", len(candles))
+	}
+
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, candle := range candles {
+		ts := candle.Timestamp()
+		if ts.Location() != time.UTC {
+			t.Errorf("Candle %d: expected UTC location, got %v", i, ts.Location())
+		}
+		if !ts.Equal(want) {
+			t.Errorf("Candle %d: expected %v, got %v", i, want, ts)
+		}
+		if i > 0 && !ts.After(candles[i-1].Timestamp()) {
+			t.Errorf("Candle %d: timestamps not strictly increasing", i)
+		}
+		want = want.Add(5 * time.Minute)
+	}
+}
+
+// TestParseCSV_MillisecondTimestamps 測試毫秒時間戳不受時區參數影響
+func TestParseCSV_MillisecondTimestamps(t *testing.T) {
+	content := "1704067200000,2500,2510,2495,2505\n1704067500000,2505,2515,2500,2510\n"
+
+	candles, err := ParseCSV(strings.NewReader(content), time.FixedZone("UTC+8", 8*3600))
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	if got := candles[0].Timestamp(); !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-01-01T00:00:00Z, got %v", got)
+	}
+}
+
+// TestParseCSV_RejectsDuplicateAndOutOfOrder 測試拒絕重複和亂序的時間戳
+func TestParseCSV_RejectsDuplicateAndOutOfOrder(t *testing.T) {
+	tests := map[string]string{
+		"重複": "2024-01-01 00:00:00,1,1,1,1\n2024-01-01 00:05:00,1,1,1,1\n2024-01-01 00:05:00,1,1,1,1\n2024-01-01 00:10:00,1,1,1,1\n",
+		"亂序": "2024-01-01 00:00:00,1,1,1,1\n2024-01-01 00:10:00,1,1,1,1\n2024-01-01 00:05:00,1,1,1,1\n2024-01-01 00:15:00,1,1,1,1\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(content), time.UTC)
+			if !errors.Is(err, ErrNonMonotonicTimestamps) {
+				t.Errorf("Expected ErrNonMonotonicTimestamps, got %v", err)
+			}
+		})
+	}
+}
+
+// TestParseCSVWithLimit 測試表頭不計入上限，超過上限時返回 ErrTooManyCandles
+func TestParseCSVWithLimit(t *testing.T) {
+	content := "timestamp,open,high,low,close\n2024-01-01 00:00:00,1,1,1,1\n2024-01-01 00:05:00,1,1,1,1\n2024-01-01 00:10:00,1,1,1,1\n"
+
+	if _, err := ParseCSVWithLimit(strings.NewReader(content), time.UTC, 2); !errors.Is(err, ErrTooManyCandles) {
+		t.Errorf("Expected ErrTooManyCandles, got %v", err)
+	}
+	candles, err := ParseCSVWithLimit(strings.NewReader(content), time.UTC, 3)
+	if err != nil || len(candles) != 3 {
+		t.Errorf("Expected 3 candles within limit, got %d (err: %v)", len(candles), err)
+	}
+}
+
+// TestParseCSVWithValidation_InvertedHighLow 測試 CSV 中 high/low 顛倒的K線在兩種校驗模式下的處理
+func TestParseCSVWithValidation_InvertedHighLow(t *testing.T) {
+	content := "1704067200000,2500,2490,2510,2505\n1704067500000,2505,2515,2500,2510\n"
+
+	if _, err := ParseCSV(strings.NewReader(content), nil); !errors.Is(err, value_objects.ErrInvalidOHLC) {
+		t.Errorf("Expected ErrInvalidOHLC in strict mode, got %v", err)
+	}
+
+	candles, adjusted, err := ParseCSVWithValidation(strings.NewReader(content), nil, 0, value_objects.OHLCClamp)
+	if err != nil {
+		t.Fatalf("Expected clamp mode to succeed, got %v", err)
+	}
+	if len(candles) != 2 || adjusted != 1 {
+		t.Fatalf("Expected 2 candles with 1 adjusted, got %d candles, %d adjusted", len(candles), adjusted)
+	}
+	if candles[0].High().Value() != 2510 || candles[0].Low().Value() != 2490 {
+		t.Errorf("Expected high/low swapped to 2510/2490, got %v/%v", candles[0].High().Value(), candles[0].Low().Value())
+	}
+}
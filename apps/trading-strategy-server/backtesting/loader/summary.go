@@ -0,0 +1,86 @@
+package loader
+
+import (
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// DataSummary K線數據品質摘要（回測前檢查是否載入了正確的數據）⭐
+type DataSummary struct {
+	Count          int           // K線數量
+	Start          time.Time     // 第一根K線時間
+	End            time.Time     // 最後一根K線時間
+	Interval       time.Duration // K線週期（相鄰時間差的眾數）
+	MinPrice       float64       // 最低價（所有K線的 Low）
+	MaxPrice       float64       // 最高價（所有K線的 High）
+	AvgPrice       float64       // 平均收盤價
+	Gaps           int           // 時間缺口數量（相鄰K線間隔大於 Interval）
+	MissingCandles int           // 缺口內缺失的K線總數
+	FlatCandles    int           // 零成交K線數量（OHLC 完全相同；Candle 不保存成交量，以此近似）
+}
+
+// Summarize 統計K線數據品質（K線需從舊到新排序）
+//
+// K線週期取相鄰時間差的眾數，避免少量缺口影響判斷；
+// 缺口 = 相鄰K線間隔大於週期，缺失數量 = 間隔 / 週期 - 1
+//
+// 返回：沒有K線時返回零值
+func Summarize(candles []value_objects.Candle) DataSummary {
+	if len(candles) == 0 {
+		return DataSummary{}
+	}
+
+	summary := DataSummary{
+		Count:    len(candles),
+		Start:    candles[0].Timestamp(),
+		End:      candles[len(candles)-1].Timestamp(),
+		MinPrice: candles[0].Low().Value(),
+		MaxPrice: candles[0].High().Value(),
+		Interval: mostCommonInterval(candles),
+	}
+
+	closeSum := 0.0
+	for i, candle := range candles {
+		if low := candle.Low().Value(); low < summary.MinPrice {
+			summary.MinPrice = low
+		}
+		if high := candle.High().Value(); high > summary.MaxPrice {
+			summary.MaxPrice = high
+		}
+		closeSum += candle.Close().Value()
+
+		if candle.Open().Equals(candle.Close()) && candle.High().Equals(candle.Low()) && candle.Open().Equals(candle.High()) {
+			summary.FlatCandles++
+		}
+
+		if i > 0 && summary.Interval > 0 {
+			if diff := candle.Timestamp().Sub(candles[i-1].Timestamp()); diff > summary.Interval {
+				summary.Gaps++
+				summary.MissingCandles += int(diff/summary.Interval) - 1
+			}
+		}
+	}
+	summary.AvgPrice = closeSum / float64(len(candles))
+
+	return summary
+}
+
+// mostCommonInterval 返回相鄰K線時間差的眾數（相同次數時取較小的間隔）
+func mostCommonInterval(candles []value_objects.Candle) time.Duration {
+	counts := make(map[time.Duration]int)
+	for i := 1; i < len(candles); i++ {
+		if diff := candles[i].Timestamp().Sub(candles[i-1].Timestamp()); diff > 0 {
+			counts[diff]++
+		}
+	}
+
+	var interval time.Duration
+	best := 0
+	for diff, count := range counts {
+		if count > best || (count == best && diff < interval) {
+			interval, best = diff, count
+		}
+	}
+	return interval
+}
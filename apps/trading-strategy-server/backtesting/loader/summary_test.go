@@ -0,0 +1,62 @@
+package loader
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+func TestSummarize_KnownCandles(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 5 分鐘K線；第 3 根之後缺少 2 根（10:15 → 10:30），最後一根為零成交K線
+	rows := []struct {
+		offset                 time.Duration
+		open, high, low, close float64
+	}{
+		{0, 2500, 2510, 2495, 2505},
+		{5 * time.Minute, 2505, 2520, 2500, 2515},
+		{10 * time.Minute, 2515, 2518, 2480, 2490},
+		{25 * time.Minute, 2490, 2495, 2485, 2490},
+		{30 * time.Minute, 2490, 2490, 2490, 2490},
+	}
+	candles := make([]value_objects.Candle, len(rows))
+	for i, r := range rows {
+		candle, err := value_objects.NewCandle(r.open, r.high, r.low, r.close, start.Add(r.offset))
+		if err != nil {
+			t.Fatalf("Failed to create candle %d: %v", i, err)
+		}
+		candles[i] = candle
+	}
+
+	got := Summarize(candles)
+
+	if got.Count != 5 {
+		t.Errorf("Count = %d, want 5", got.Count)
+	}
+	if !got.Start.Equal(start) || !got.End.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Range = %v → %v, want %v → %v", got.Start, got.End, start, start.Add(30*time.Minute))
+	}
+	if got.Interval != 5*time.Minute {
+		t.Errorf("Interval = %v, want 5m", got.Interval)
+	}
+	if got.MinPrice != 2480 || got.MaxPrice != 2520 {
+		t.Errorf("Price range = %.2f ~ %.2f, want 2480 ~ 2520", got.MinPrice, got.MaxPrice)
+	}
+	if want := (2505.0 + 2515 + 2490 + 2490 + 2490) / 5; math.Abs(got.AvgPrice-want) > 1e-9 {
+		t.Errorf("AvgPrice = %.4f, want %.4f", got.AvgPrice, want)
+	}
+	if got.Gaps != 1 || got.MissingCandles != 2 {
+		t.Errorf("Gaps = %d (missing %d), want 1 (missing 2)", got.Gaps, got.MissingCandles)
+	}
+	if got.FlatCandles != 1 {
+		t.Errorf("FlatCandles = %d, want 1", got.FlatCandles)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	if got := Summarize(nil); got != (DataSummary{}) {
+		t.Errorf("Summarize(nil) = %+v, want zero value", got)
+	}
+}
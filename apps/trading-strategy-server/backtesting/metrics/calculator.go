@@ -28,12 +28,74 @@ type BacktestResult struct {
 	TotalFeesClose         float64 // 關倉總手續費 ⭐ 新增
 	TotalFeesPaid          float64 // 總手續費（開倉 + 關倉）
 	UnrealizedPnL          float64 // 未實現盈虧（含預估關倉手續費）⭐
-	NetProfit              float64 // 淨利潤 = 總利潤 + 未實現盈虧 - 總手續費 ⭐
-	TotalReturn      float64       // 總收益率 (%)
-	ProfitFactor     float64       // 盈虧比（含未實現盈虧）⭐
+	NetProfit              float64 // 淨利潤 = 總利潤 + 未實現盈虧 - 總手續費 - 資金費 ⭐
+	TotalReturn      float64       // 總收益率 (%，分母見 ReturnBasis / ReturnCapital)
+	ProfitFactorTotal    float64   // 盈虧比-總計（已實現淨盈虧 + 未實現盈虧，CLI 摘要使用此值）⭐
+	ProfitFactorRealized float64   // 盈虧比-已實現（僅已平倉，已扣手續費）⭐
+	ProfitFactorGross    float64   // 盈虧比-毛利（僅已平倉，未扣手續費）⭐
 	WinRate          float64       // 勝率 (%)
 	AvgHoldDuration  time.Duration // 平均持倉時長
-	MaxDrawdown      float64       // 最大回撤 (%)
+	MaxDrawdown      float64       // 最大回撤 (%，按 DrawdownBasis 取 MaxDrawdownCash 或 MaxDrawdownEquity)
+
+	// 回撤基礎 ⭐
+	DrawdownBasis     DrawdownBasis // MaxDrawdown 的計算基礎
+	MaxDrawdownCash   float64       // 按可用餘額快照計算的最大回撤 (%)
+	MaxDrawdownEquity float64       // 按權益快照計算的最大回撤 (%，含持倉浮虧)
+
+	// 收益率基數 ⭐
+	ReturnBasis   ReturnBasis // 總收益率的資本基數
+	ReturnCapital float64     // 總收益率的分母（USDT，initial 時等於初始資金）
+
+	// 手續費侵蝕 ⭐
+	FeeToProfitRatio float64 // 總手續費 / 總毛利（毛利 <= 0 時為 0，見 FeeToProfitRatio 和 FeeWarning）
+
+	// 盈虧比可信度 ⭐
+	ProfitFactorCapped bool // 總盈虧比達到 ProfitFactorCap（沒有虧損或虧損可忽略），數值沒有意義
+	LowSampleWarning   bool // 盈虧比不可信：已平倉交易少於最少交易數或 ProfitFactorCapped（見 ProfitFactorWarning）
+
+	// 滑點成本 ⭐
+	SlippageCost float64 // 理想（零滑點）淨利潤 - 實際淨利潤（USDT，逐筆成交按建議價與成交價之差累加，含手續費差異）
+
+	// 永續合約資金費 ⭐
+	FundingFee float64 // 累計資金費（USDT，正數 = 支付，負數 = 收取；已從餘額和淨利潤扣除）
+
+	// 回撤痛苦程度 ⭐
+	AnnualizedReturn float64 // 年化收益率 (%，線性年化)
+	UlcerIndex       float64 // 潰瘍指數 (%，所有回撤的均方根)
+	PainRatio        float64 // 痛苦比率 = 年化收益率 / 潰瘍指數
+
+	// 敞口與 Beta ⭐
+	AvgNetExposure  float64 // 平均淨多頭敞口 = 持倉價值 / 權益（時間加權，例 0.35 = 35%，見 AverageNetExposure）
+	BetaToBenchmark float64 // 策略收益對標的收益的回歸 Beta（見 BetaToBenchmark）
+
+	// 持倉時長分佈 ⭐
+	MedianHoldDuration    time.Duration  // 持倉時長中位數
+	P95HoldDuration       time.Duration  // 持倉時長 P95
+	HoldDurationHistogram map[string]int // 持倉時長分桶統計（見 HoldDurationBuckets）
+	TradesPerDay          float64        // 日均關倉次數（基於回測時間跨度）
+
+	// 連勝 / 連虧 ⭐
+	MaxConsecutiveWins   int     // 最大連續盈利次數（按平倉時間）
+	MaxConsecutiveLosses int     // 最大連續虧損次數（按平倉時間）
+	WorstLosingStreak    float64 // 金額最大的一段連續虧損（USDT，正數）
+
+	// 盈虧歸因 ⭐
+	PnLByReason           map[string]float64 // 按關倉原因分類的淨已實現盈虧（見 PnLByReason）
+	PnLByTag              map[string]float64 // 按開倉標籤分類的淨已實現盈虧（見 PnLByTag）
+	PnLByVolatilityRegime map[string]float64 // 按平倉時的波動率區間（low / medium / high）分類的淨已實現盈虧（見 PnLByVolatilityRegime）
+
+	// 打平輪次 ⭐
+	Rounds RoundsSummary // 打平輪次彙總（見 RoundsSummary）
+
+	// 回撤熔斷 ⭐
+	HaltedAt   time.Time // 觸發熔斷的時間（零值 = 未觸發）
+	HaltReason string    // 熔斷原因（例: drawdown_halt: ...）
+
+	// 輪次時間止損 ⭐
+	RoundTimeStops int // 持續超過 MaxRoundCandles 被強制平倉的輪次數
+
+	// 手續費對賬 ⭐
+	FeeLedgerDiff float64 // 交易日誌累計手續費 - 開倉與關倉手續費賬本（USDT，超過 1 美分時 Run 返回 ErrFeeMismatch）
 
 	// 詳細統計（保留用於其他分析）
 	TotalTrades   int     // 總交易次數（已平倉）
@@ -49,18 +111,72 @@ type BalanceSnapshot struct {
 	Balance float64
 }
 
+// defaultFeeRate 預估平倉手續費的默認費率（OKX Taker 手续费）
+const defaultFeeRate = 0.0005
+
 // MetricsCalculator 指标计算器
 type MetricsCalculator struct {
 	initialBalance   float64
+	feeRate          float64 // 預估未實現盈虧平倉手續費的費率（可為負數 = 返傭）
 	balanceSnapshots []BalanceSnapshot
+	equitySnapshots  []EquitySnapshot // 每根K線的權益快照（敞口和 Beta）⭐
+	minTrades        int              // 盈虧比可信所需的最少已平倉交易數 ⭐
+	// 收益率基數 ⭐
+	returnBasis ReturnBasis // 總收益率的資本基數（默認: initial）
+	maxFunding  float64     // 待回收注資峰值（USDT）
+	netFunding  float64     // 回測結束時尚未回收的注資（USDT）
+	fundingFee  float64     // 永續合約累計資金費（USDT，與注資無關）⭐
+
+	drawdownBasis DrawdownBasis // MaxDrawdown 的計算基礎（默認: cash）⭐
 }
 
 // NewMetricsCalculator 创建指标计算器
 func NewMetricsCalculator(initialBalance float64) *MetricsCalculator {
 	return &MetricsCalculator{
 		initialBalance:   initialBalance,
+		feeRate:          defaultFeeRate,
 		balanceSnapshots: make([]BalanceSnapshot, 0),
+		minTrades:        DefaultProfitFactorMinTrades,
+		returnBasis:      ReturnBasisInitialOnly,
+		drawdownBasis:    DrawdownBasisCash,
+	}
+}
+
+// SetFeeRate 設置預估平倉手續費的費率（與回測配置一致；負數表示 maker 返傭）
+func (mc *MetricsCalculator) SetFeeRate(feeRate float64) {
+	mc.feeRate = feeRate
+}
+
+// SetProfitFactorMinTrades 設置盈虧比可信所需的最少已平倉交易數（少於此數時設置 LowSampleWarning，0 = 不檢查交易數）
+func (mc *MetricsCalculator) SetProfitFactorMinTrades(minTrades int) {
+	mc.minTrades = minTrades
+}
+
+// SetReturnBasis 設置總收益率的資本基數（空值 = initial）
+func (mc *MetricsCalculator) SetReturnBasis(basis ReturnBasis) {
+	if basis == "" {
+		basis = ReturnBasisInitialOnly
+	}
+	mc.returnBasis = basis
+}
+
+// SetDrawdownBasis 設置 MaxDrawdown 的計算基礎（空值 = cash；兩種回撤都會輸出）
+func (mc *MetricsCalculator) SetDrawdownBasis(basis DrawdownBasis) {
+	if basis == "" {
+		basis = DrawdownBasisCash
 	}
+	mc.drawdownBasis = basis
+}
+
+// SetFunding 設置自動注資數額（用於 initial_plus_* 收益率基數，Calculate 之前調用）
+func (mc *MetricsCalculator) SetFunding(maxFunding, netFunding float64) {
+	mc.maxFunding = maxFunding
+	mc.netFunding = netFunding
+}
+
+// SetFundingFee 設置累計資金費（從淨利潤扣除；餘額由引擎扣除）
+func (mc *MetricsCalculator) SetFundingFee(fundingFee float64) {
+	mc.fundingFee = fundingFee
 }
 
 // RecordBalance 记录资金快照（用于最大回撤计算）
@@ -103,8 +219,7 @@ func (mc *MetricsCalculator) Calculate(
 	openPositionValue := positionTracker.GetTotalSize()
 
 	// 计算未实现盈亏（使用最后价格，包含預估關倉手續費）
-	feeRate := 0.0005 // OKX Taker 手续费
-	unrealizedPnL := positionTracker.CalculateUnrealizedPnL(lastPrice, feeRate)
+	unrealizedPnL := positionTracker.CalculateUnrealizedPnL(lastPrice, mc.feeRate)
 
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	totalFeesOpenD := decimal.NewFromFloat(totalFeesOpen)
@@ -113,7 +228,7 @@ func (mc *MetricsCalculator) Calculate(
 	unrealizedPnLD := decimal.NewFromFloat(unrealizedPnL)
 	finalBalanceD := decimal.NewFromFloat(finalBalance)
 	openPositionValueD := decimal.NewFromFloat(openPositionValue)
-	initialBalanceD := decimal.NewFromFloat(mc.initialBalance)
+	returnCapitalD := ReturnCapital(mc.returnBasis, mc.initialBalance, mc.maxFunding, mc.netFunding)
 	hundred := decimal.NewFromInt(100)
 
 	// 2. 計算總手續費
@@ -121,8 +236,8 @@ func (mc *MetricsCalculator) Calculate(
 	totalFeesPaid := totalFeesPaidD.InexactFloat64()
 
 	// 3. 計算淨利潤 ⭐
-	// NetProfit = 已平倉淨利潤 + 未平倉淨盈虧
-	netProfitD := totalProfitGrossD.Add(unrealizedPnLD).Sub(totalFeesPaidD)
+	// NetProfit = 已平倉淨利潤 + 未平倉淨盈虧 - 資金費
+	netProfitD := totalProfitGrossD.Add(unrealizedPnLD).Sub(totalFeesPaidD).Sub(decimal.NewFromFloat(mc.fundingFee))
 	netProfit := netProfitD.InexactFloat64()
 
 	// 4. 计算总权益（可用余额 + 未平仓价值 + 未实现盈亏）
@@ -130,21 +245,28 @@ func (mc *MetricsCalculator) Calculate(
 	totalEquity := totalEquityD.InexactFloat64()
 
 	// 5. 计算总收益率（基于淨利潤）⭐
-	// TotalReturn = NetProfit / InitialBalance * 100
+	// TotalReturn = NetProfit / 資本基數 * 100（默認資本基數 = InitialBalance）
 	totalReturn := 0.0
-	if mc.initialBalance > 0 {
-		totalReturnD := netProfitD.Div(initialBalanceD).Mul(hundred)
+	if returnCapitalD.IsPositive() {
+		totalReturnD := netProfitD.Div(returnCapitalD).Mul(hundred)
 		totalReturn = totalReturnD.Truncate(2).InexactFloat64() // 截斷到小數點後兩位
 	}
 
-	// 6. 计算最大回撤
-	maxDrawdown := mc.calculateMaxDrawdown()
+	// 6. 计算最大回撤（cash 和 equity 兩種基礎）⭐
+	maxDrawdownCash := mc.calculateMaxDrawdown()
+	maxDrawdownEquity := maxDrawdownPercent(equityBalanceSnapshots(mc.equitySnapshots))
+	maxDrawdown := maxDrawdownCash
+	if mc.drawdownBasis == DrawdownBasisEquity {
+		maxDrawdown = maxDrawdownEquity
+	}
 
-	// 7. 计算胜率、盈亏比（含未實現盈虧）⭐
+	// 7. 计算胜率、盈亏比（已實現 / 毛利 / 含未實現盈虧）⭐
 	winningTrades := 0
 	losingTrades := 0
 	totalProfitRealizedD := decimal.Zero // 已實現盈利（已扣費）
 	totalLossRealizedD := decimal.Zero   // 已實現虧損（已扣費）
+	grossProfitD := decimal.Zero         // 已實現毛利盈利（未扣費）
+	grossLossD := decimal.Zero           // 已實現毛利虧損（未扣費）
 
 	for _, closed := range closedPositions {
 		realizedPnLD := decimal.NewFromFloat(closed.RealizedPnL)
@@ -155,6 +277,17 @@ func (mc *MetricsCalculator) Calculate(
 			losingTrades++
 			totalLossRealizedD = totalLossRealizedD.Add(realizedPnLD.Neg()) // 转为正数
 		}
+
+		// 毛利 = 幣數 × (平倉價 - 開倉價)
+		if closed.EntryPrice > 0 {
+			coinsD := decimal.NewFromFloat(closed.Size).Div(decimal.NewFromFloat(closed.EntryPrice))
+			grossPnLD := coinsD.Mul(decimal.NewFromFloat(closed.ClosePrice).Sub(decimal.NewFromFloat(closed.EntryPrice)))
+			if grossPnLD.IsPositive() {
+				grossProfitD = grossProfitD.Add(grossPnLD)
+			} else if grossPnLD.IsNegative() {
+				grossLossD = grossLossD.Add(grossPnLD.Neg())
+			}
+		}
 	}
 
 	// 勝率（只計算已平倉）
@@ -177,17 +310,30 @@ func (mc *MetricsCalculator) Calculate(
 		totalLossWithUnrealizedD = totalLossWithUnrealizedD.Add(unrealizedPnLD.Neg())
 	}
 
-	profitFactor := 0.0
-	if totalLossWithUnrealizedD.GreaterThan(decimal.Zero) {
-		profitFactorD := totalProfitWithUnrealizedD.Div(totalLossWithUnrealizedD)
-		profitFactor = profitFactorD.InexactFloat64()
-	} else if totalProfitWithUnrealizedD.GreaterThan(decimal.Zero) {
-		profitFactor = 999.99 // 无亏损，盈亏比极高
-	}
+	profitFactorTotal, profitFactorCapped := profitFactor(totalProfitWithUnrealizedD, totalLossWithUnrealizedD)
+	profitFactorRealized, _ := profitFactor(totalProfitRealizedD, totalLossRealizedD)
+	profitFactorGross, _ := profitFactor(grossProfitD, grossLossD)
+
+	// 盈虧比可信度：樣本太少或虧損可忽略時警告 ⭐
+	lowSampleWarning := profitFactorCapped || totalTrades < mc.minTrades
 
 	// 8. 计算平均持仓时长
 	avgHoldDuration := positionTracker.GetAverageHoldDuration()
 
+	// 9. 计算持仓时长分布和交易频率 ⭐
+	span := mc.calculateSpan()
+	tradesPerDay := 0.0
+	if span > 0 {
+		tradesPerDay = float64(totalTrades) / (span.Hours() / 24)
+	}
+
+	// 10. 计算潰瘍指數和痛苦比率 ⭐
+	annualizedReturn := AnnualizedReturn(totalReturn, span)
+	ulcerIndex := UlcerIndex(mc.balanceSnapshots)
+
+	// 11. 计算連勝 / 連虧 ⭐
+	streaks := CalculateStreaks(closedPositions)
+
 	return BacktestResult{
 		InitialBalance: mc.initialBalance,
 		FinalBalance:   finalBalance,
@@ -208,11 +354,52 @@ func (mc *MetricsCalculator) Calculate(
 		UnrealizedPnL:          unrealizedPnL,
 		NetProfit:              netProfit,
 		TotalReturn:      totalReturn,
-		ProfitFactor:     profitFactor,
+		ProfitFactorTotal:    profitFactorTotal,
+		ProfitFactorRealized: profitFactorRealized,
+		ProfitFactorGross:    profitFactorGross,
 		WinRate:          winRate,
 		AvgHoldDuration:  avgHoldDuration,
 		MaxDrawdown:      maxDrawdown,
 
+		// 回撤基礎
+		DrawdownBasis:     mc.drawdownBasis,
+		MaxDrawdownCash:   maxDrawdownCash,
+		MaxDrawdownEquity: maxDrawdownEquity,
+
+		// 永續合約資金費
+		FundingFee: mc.fundingFee,
+
+		// 收益率基數
+		ReturnBasis:   mc.returnBasis,
+		ReturnCapital: returnCapitalD.InexactFloat64(),
+
+		// 手續費侵蝕
+		FeeToProfitRatio: FeeToProfitRatio(totalFeesPaid, totalProfitGross),
+
+		// 盈虧比可信度
+		ProfitFactorCapped: profitFactorCapped,
+		LowSampleWarning:   lowSampleWarning,
+
+		// 回撤痛苦程度
+		AnnualizedReturn: annualizedReturn,
+		UlcerIndex:       ulcerIndex,
+		PainRatio:        PainRatio(annualizedReturn, ulcerIndex),
+
+		// 敞口與 Beta
+		AvgNetExposure:  AverageNetExposure(mc.equitySnapshots),
+		BetaToBenchmark: BetaToBenchmark(mc.equitySnapshots),
+
+		// 持倉時長分佈
+		MedianHoldDuration:    MedianHoldDuration(closedPositions),
+		P95HoldDuration:       PercentileHoldDuration(closedPositions, 95),
+		HoldDurationHistogram: HoldDurationHistogram(closedPositions),
+		TradesPerDay:          tradesPerDay,
+
+		// 連勝 / 連虧
+		MaxConsecutiveWins:   streaks.MaxConsecutiveWins,
+		MaxConsecutiveLosses: streaks.MaxConsecutiveLosses,
+		WorstLosingStreak:    streaks.WorstLosingStreak,
+
 		// 詳細統計
 		TotalTrades:   totalTrades,
 		WinningTrades: winningTrades,
@@ -232,37 +419,80 @@ func (mc *MetricsCalculator) Calculate(
 //  3. 计算当前回撤 = (最高资金 - 当前资金) / 最高资金
 //  4. 更新最大回撤
 func (mc *MetricsCalculator) calculateMaxDrawdown() float64 {
-	if len(mc.balanceSnapshots) == 0 {
+	return maxDrawdownPercent(mc.balanceSnapshots)
+}
+
+// drawdownCandidateTolerance 第二遍精確計算的候選範圍（相對 float64 估計的最大回撤）
+//
+// float64 回撤的相對誤差只有幾個 ulp（~1e-16），1e-9 足以保證真正的最大值一定在候選內
+const drawdownCandidateTolerance = 1e-9
+
+// maxDrawdownPercent 计算最大回撤（%），結果與逐快照 decimal 計算完全一致 ⭐
+//
+// 兩遍掃描：
+//  1. float64 掃描所有快照，得到最大回撤的估計值（不分配內存）
+//  2. 只對回撤接近估計值的快照（通常只有一兩個）用 decimal 精確計算
+//
+// 逐快照創建 decimal 在一年 5m K線（~10 萬快照）時會產生大量垃圾，
+// 參數掃描時尤其明顯。10 萬快照的基準測試（BenchmarkMaxDrawdown）：
+// 約 170ms / 226 萬次分配 → 約 0.3ms / 35 次分配
+func maxDrawdownPercent(snapshots []BalanceSnapshot) float64 {
+	if len(snapshots) == 0 {
 		return 0.0
 	}
 
-	// ⭐ 使用 decimal 計算，避免浮點誤差
+	// 第一遍：float64 估計最大回撤（與 DrawdownSeries 相同的掃描）
+	tracker := newRunningPeak(snapshots[0].Balance)
+	maxEstimate := 0.0
+	for _, snapshot := range snapshots {
+		if _, drawdown := tracker.update(snapshot.Balance); drawdown > maxEstimate {
+			maxEstimate = drawdown
+		}
+	}
+	if maxEstimate <= 0 {
+		return 0.0
+	}
+
+	// 第二遍：⭐ 只對候選快照使用 decimal 計算，避免浮點誤差
+	threshold := maxEstimate * (1 - drawdownCandidateTolerance)
 	hundred := decimal.NewFromInt(100)
 	maxDrawdownD := decimal.Zero
-	peakD := decimal.NewFromFloat(mc.balanceSnapshots[0].Balance)
-
-	for _, snapshot := range mc.balanceSnapshots {
-		balanceD := decimal.NewFromFloat(snapshot.Balance)
+	tracker = newRunningPeak(snapshots[0].Balance)
 
+	for _, snapshot := range snapshots {
 		// 更新历史最高资金
-		if balanceD.GreaterThan(peakD) {
-			peakD = balanceD
+		peak, drawdown := tracker.update(snapshot.Balance)
+		if peak <= 0 || drawdown < threshold {
+			continue
 		}
 
-		// 计算当前回撤
-		if peakD.GreaterThan(decimal.Zero) {
-			// drawdown = (peak - balance) / peak * 100
-			drawdownD := peakD.Sub(balanceD).Div(peakD).Mul(hundred)
-			if drawdownD.GreaterThan(maxDrawdownD) {
-				maxDrawdownD = drawdownD
-			}
+		// drawdown = (peak - balance) / peak * 100
+		peakD := decimal.NewFromFloat(peak)
+		drawdownD := peakD.Sub(decimal.NewFromFloat(snapshot.Balance)).Div(peakD).Mul(hundred)
+		if drawdownD.GreaterThan(maxDrawdownD) {
+			maxDrawdownD = drawdownD
 		}
 	}
 
 	return maxDrawdownD.InexactFloat64()
 }
 
+// calculateSpan 计算回测时间跨度（第一个到最后一个资金快照）
+func (mc *MetricsCalculator) calculateSpan() time.Duration {
+	if len(mc.balanceSnapshots) < 2 {
+		return 0
+	}
+	first := mc.balanceSnapshots[0].Time
+	last := mc.balanceSnapshots[len(mc.balanceSnapshots)-1].Time
+	return last.Sub(first)
+}
+
 // GetBalanceSnapshots 获取资金快照列表（用于绘图或调试）
 func (mc *MetricsCalculator) GetBalanceSnapshots() []BalanceSnapshot {
 	return mc.balanceSnapshots
 }
+
+// RestoreBalanceSnapshots 用已有的資金快照覆蓋當前記錄（用於回測斷點續跑）
+func (mc *MetricsCalculator) RestoreBalanceSnapshots(snapshots []BalanceSnapshot) {
+	mc.balanceSnapshots = append(make([]BalanceSnapshot, 0, len(snapshots)), snapshots...)
+}
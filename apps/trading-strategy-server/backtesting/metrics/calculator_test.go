@@ -38,21 +38,21 @@ func TestNetProfitCalculation_VerifyFeeAccounting(t *testing.T) {
 	totalProfitGross := 0.0
 
 	// 開倉 #1: 價格 2500
-	pos1 := positionTracker.AddPosition(2500, 100, now, 2510)
+	pos1, _ := positionTracker.AddPosition(2500, 100, now, 2510)
 	openFee1 := 100 * feeRate // 0.05
 	balance -= (100 + openFee1)
 	totalFeesOpen += openFee1
 	t.Logf("開倉 #1: price=2500, size=100, openFee=%.4f, balance=%.2f", openFee1, balance)
 
 	// 開倉 #2: 價格 2520
-	pos2 := positionTracker.AddPosition(2520, 100, now, 2530)
+	pos2, _ := positionTracker.AddPosition(2520, 100, now, 2530)
 	openFee2 := 100 * feeRate // 0.05
 	balance -= (100 + openFee2)
 	totalFeesOpen += openFee2
 	t.Logf("開倉 #2: price=2520, size=100, openFee=%.4f, balance=%.2f", openFee2, balance)
 
 	// 開倉 #3: 價格 2510 (未平倉)
-	_ = positionTracker.AddPosition(2510, 100, now, 2520)
+	_, _ = positionTracker.AddPosition(2510, 100, now, 2520)
 	openFee3 := 100 * feeRate // 0.05
 	balance -= (100 + openFee3)
 	totalFeesOpen += openFee3
@@ -184,3 +184,80 @@ func TestNetProfitCalculation_VerifyFeeAccounting(t *testing.T) {
 		t.Logf("\n✅ 淨利潤計算正確：%.4f USDT", netProfit)
 	}
 }
+
+// TestProfitFactorVariants_OpenUnrealizedLoss 測試未平倉浮虧時三種盈虧比的差異
+//
+// 測試場景：
+// 1. 平倉 1 筆盈利（2500 → 2550）、1 筆虧損（2500 → 2475）
+// 2. 1 筆未平倉大額浮虧（2600 → 2340，-10%）
+//
+// 驗證：
+// - ProfitFactorGross 只看毛利：2 / 1 = 2
+// - ProfitFactorRealized 只看已平倉淨盈虧（扣費後）
+// - ProfitFactorTotal 把浮虧計入虧損，遠低於已實現盈虧比
+func TestProfitFactorVariants_OpenUnrealizedLoss(t *testing.T) {
+	feeRate := 0.0005
+	calculator := NewMetricsCalculator(10000)
+	positionTracker := simulator.NewPositionTracker()
+	now := time.Now()
+
+	// 平倉 #1：盈利，毛利 = 0.04 × 50 = 2
+	pos1, _ := positionTracker.AddPosition(2500, 100, now, 2550)
+	winPnL := 2.0 - 100*feeRate - 102*feeRate
+	positionTracker.ClosePosition(pos1.ID, 2550, now.Add(5*time.Minute), winPnL)
+
+	// 平倉 #2：虧損，毛利 = 0.04 × (-25) = -1
+	pos2, _ := positionTracker.AddPosition(2500, 100, now, 2510)
+	lossPnL := -1.0 - 100*feeRate - 99*feeRate
+	positionTracker.ClosePosition(pos2.ID, 2475, now.Add(10*time.Minute), lossPnL)
+
+	// 未平倉 #3：浮虧 -10%
+	_, _ = positionTracker.AddPosition(2600, 100, now, 2610)
+	lastPrice := 2340.0
+	unrealizedPnL := positionTracker.CalculateUnrealizedPnL(lastPrice, feeRate)
+
+	result := calculator.Calculate(positionTracker, 9700, lastPrice, 3, 1.0, 1.0, 3*100*feeRate, 201*feeRate)
+
+	if diff := result.ProfitFactorGross - 2.0; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected gross profit factor 2.00, got %.6f", result.ProfitFactorGross)
+	}
+
+	wantRealized := winPnL / -lossPnL
+	if diff := result.ProfitFactorRealized - wantRealized; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected realized profit factor %.6f, got %.6f", wantRealized, result.ProfitFactorRealized)
+	}
+
+	wantTotal := winPnL / (-lossPnL - unrealizedPnL)
+	if diff := result.ProfitFactorTotal - wantTotal; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected total profit factor %.6f, got %.6f", wantTotal, result.ProfitFactorTotal)
+	}
+
+	// 浮虧拖低總盈虧比，已實現盈虧比不受影響
+	if result.ProfitFactorTotal >= 1.0 || result.ProfitFactorRealized <= 1.0 {
+		t.Errorf("Expected total < 1 < realized, got total=%.4f realized=%.4f",
+			result.ProfitFactorTotal, result.ProfitFactorRealized)
+	}
+	// 手續費拉低已實現盈虧比
+	if result.ProfitFactorRealized >= result.ProfitFactorGross {
+		t.Errorf("Expected realized (%.4f) < gross (%.4f) due to fees",
+			result.ProfitFactorRealized, result.ProfitFactorGross)
+	}
+}
+
+// TestCalculate_UsesConfiguredFeeRate 測試未實現盈虧使用配置的手續費率（負數 = 返傭）
+func TestCalculate_UsesConfiguredFeeRate(t *testing.T) {
+	positionTracker := simulator.NewPositionTracker()
+	_, _ = positionTracker.AddPosition(2500, 100, time.Now(), 2510)
+	lastPrice := 2450.0
+
+	for _, feeRate := range []float64{0.0005, 0, -0.0002} {
+		calculator := NewMetricsCalculator(10000)
+		calculator.SetFeeRate(feeRate)
+		result := calculator.Calculate(positionTracker, 9900, lastPrice, 1, 0, 0, 100*feeRate, 0)
+
+		want := positionTracker.CalculateUnrealizedPnL(lastPrice, feeRate)
+		if diff := result.UnrealizedPnL - want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("feeRate %v: expected unrealized PnL %.6f, got %.6f", feeRate, want, result.UnrealizedPnL)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// FieldDiff 兩份結果中不一致的字段
+type FieldDiff struct {
+	Field string // 字段名（map 字段為 "PnLByReason[hit_target]"）
+	A     any    // 第一份結果的值（map 中缺少該鍵時為 nil）
+	B     any    // 第二份結果的值
+}
+
+// String 格式化為 "Field: A → B"
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %v → %v", d.Field, d.A, d.B)
+}
+
+// DiffResults 比較兩份回測結果，返回所有超出容差的字段 ⭐
+//
+// 用途：回歸測試，確認引擎改動沒有意外改變數值結果（取代手動比對 CSV）。
+//
+// 比較規則：
+//   - float64：|a - b| > tolerance 視為不同（兩邊都是 NaN 視為相同）
+//   - map：逐鍵比較，只存在於一邊的鍵也算不同
+//   - time.Time：Equal；其他類型（int、string、time.Duration）精確比較
+//
+// 返回：按字段順序排列的差異；完全一致時返回 nil
+func DiffResults(a, b BacktestResult, tolerance float64) []FieldDiff {
+	return DiffFields(a, b, tolerance)
+}
+
+// DiffFields 逐字段比較兩個同類型結構體（規則同 DiffResults）
+//
+// 供其他結構體（例如交易日誌）複用；a、b 類型不同或不是結構體時 panic
+func DiffFields(a, b any, tolerance float64) []FieldDiff {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() || va.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("DiffFields: expected two structs of the same type, got %T and %T", a, b))
+	}
+
+	var diffs []FieldDiff
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		diffs = append(diffs, diffValue(field.Name, va.Field(i), vb.Field(i), tolerance)...)
+	}
+	return diffs
+}
+
+// diffValue 比較單個字段的值
+func diffValue(name string, a, b reflect.Value, tolerance float64) []FieldDiff {
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if !floatsEqual(a.Float(), b.Float(), tolerance) {
+			return []FieldDiff{{Field: name, A: a.Interface(), B: b.Interface()}}
+		}
+		return nil
+
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, key := range append(a.MapKeys(), b.MapKeys()...) {
+			keys[fmt.Sprint(key.Interface())] = key
+		}
+		names := make([]string, 0, len(keys))
+		for keyName := range keys {
+			names = append(names, keyName)
+		}
+		sort.Strings(names)
+
+		var diffs []FieldDiff
+		for _, keyName := range names {
+			key := keys[keyName]
+			entryName := fmt.Sprintf("%s[%s]", name, keyName)
+			va, vb := a.MapIndex(key), b.MapIndex(key)
+			switch {
+			case !va.IsValid() && !vb.IsValid():
+			case !va.IsValid():
+				diffs = append(diffs, FieldDiff{Field: entryName, A: nil, B: vb.Interface()})
+			case !vb.IsValid():
+				diffs = append(diffs, FieldDiff{Field: entryName, A: va.Interface(), B: nil})
+			default:
+				diffs = append(diffs, diffValue(entryName, va, vb, tolerance)...)
+			}
+		}
+		return diffs
+	}
+
+	if ta, ok := a.Interface().(time.Time); ok {
+		if !ta.Equal(b.Interface().(time.Time)) {
+			return []FieldDiff{{Field: name, A: a.Interface(), B: b.Interface()}}
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return []FieldDiff{{Field: name, A: a.Interface(), B: b.Interface()}}
+	}
+	return nil
+}
+
+// floatsEqual 容差內相等（NaN 與 NaN 視為相等）
+func floatsEqual(a, b, tolerance float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	if a == b {
+		return true // 包括同號無窮大
+	}
+	return math.Abs(a-b) <= tolerance
+}
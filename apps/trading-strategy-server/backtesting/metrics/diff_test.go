@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func sampleResult() BacktestResult {
+	return BacktestResult{
+		InitialBalance:  10000,
+		FinalBalance:    10123.45,
+		NetProfit:       123.45,
+		TotalReturn:     1.2345,
+		TotalTrades:     42,
+		AvgHoldDuration: 90 * time.Minute,
+		PnLByReason:     map[string]float64{ReasonHitTarget: 150, ReasonBreakEvenExit: -26.55},
+	}
+}
+
+// TestDiffResults_Identical 相同結果沒有差異
+func TestDiffResults_Identical(t *testing.T) {
+	if diffs := DiffResults(sampleResult(), sampleResult(), 0); diffs != nil {
+		t.Errorf("Expected no diffs, got %v", diffs)
+	}
+}
+
+// TestDiffResults_Divergent 刻意偏離的結果：報告超出容差的字段，容差內的忽略 ⭐
+func TestDiffResults_Divergent(t *testing.T) {
+	a := sampleResult()
+	b := sampleResult()
+	b.NetProfit += 0.01    // 超出容差
+	b.TotalReturn += 1e-12 // 容差內
+	b.TotalTrades = 43     // 整數精確比較
+	// hit_target 相同；break_even_exit 只存在於 a，stop_loss 只存在於 b
+	b.PnLByReason = map[string]float64{ReasonHitTarget: 150, ReasonStopLoss: -5}
+	b.HaltedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	diffs := DiffResults(a, b, 1e-9)
+
+	want := []string{
+		"NetProfit",
+		"TotalTrades",
+		"PnLByReason[break_even_exit]",
+		"PnLByReason[stop_loss]",
+		"HaltedAt",
+	}
+	got := make(map[string]FieldDiff, len(diffs))
+	for _, diff := range diffs {
+		got[diff.Field] = diff
+	}
+	for _, field := range want {
+		if _, ok := got[field]; !ok {
+			t.Errorf("Expected diff for %s, got %v", field, diffs)
+		}
+	}
+	if len(diffs) != len(want) {
+		t.Errorf("Expected %d diffs, got %d: %v", len(want), len(diffs), diffs)
+	}
+	if _, ok := got["TotalReturn"]; ok {
+		t.Error("Expected TotalReturn within tolerance to be ignored")
+	}
+	if missing := got["PnLByReason[break_even_exit]"]; missing.B != nil || missing.A != -26.55 {
+		t.Errorf("Unexpected missing-key diff: %v", missing)
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import "fmt"
+
+// DrawdownBasis 最大回撤的計算基礎 ⭐
+//
+// 可用餘額快照在開倉時減少、關倉時回升，持倉期間的浮虧看不到：
+// 大倉位被套時 cash 回撤只反映鎖定的資金，equity 回撤才反映按市價計值的真實虧損
+type DrawdownBasis string
+
+const (
+	DrawdownBasisCash   DrawdownBasis = "cash"   // 可用餘額快照（默認）
+	DrawdownBasisEquity DrawdownBasis = "equity" // 權益快照（餘額 + 持倉按市價計值，不含待回收的注資）
+)
+
+// Validate 檢查基礎是否有效（空值 = cash）
+func (b DrawdownBasis) Validate() error {
+	switch b {
+	case "", DrawdownBasisCash, DrawdownBasisEquity:
+		return nil
+	default:
+		return fmt.Errorf("unknown drawdown basis: %s", b)
+	}
+}
+
+// equityBalanceSnapshots 把權益快照轉為資金快照（共用 maxDrawdownPercent 的計算）
+func equityBalanceSnapshots(snapshots []EquitySnapshot) []BalanceSnapshot {
+	balances := make([]BalanceSnapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		balances[i] = BalanceSnapshot{Time: snapshot.Time, Balance: snapshot.Equity}
+	}
+	return balances
+}
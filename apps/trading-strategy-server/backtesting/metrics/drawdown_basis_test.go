@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// TestDrawdownBasis_EquityShowsOpenPositionLoss 測試持倉浮虧在可用餘額中看不到、在權益中可見 ⭐
+//
+// 場景：開倉 1000 USDT 後一直持有，價格 2500 → 2250（-10%）→ 2300
+//   - 可用餘額一直是 9000（開倉後沒有成交）：cash 回撤 = 0
+//   - 權益 = 9000 + 持倉市值：10000 → 9900 → 9920，equity 回撤 = 1%
+func TestDrawdownBasis_EquityShowsOpenPositionLoss(t *testing.T) {
+	positionTracker := simulator.NewPositionTracker()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _ = positionTracker.AddPosition(2500, 1000, start, 2510)
+
+	run := func(basis DrawdownBasis) BacktestResult {
+		calculator := NewMetricsCalculator(10000)
+		calculator.SetDrawdownBasis(basis)
+		for i, price := range []float64{2500, 2400, 2250, 2300} {
+			ts := start.Add(time.Duration(i) * 5 * time.Minute)
+			calculator.RecordBalance(ts, 9000)
+			calculator.RecordEquity(ts, price, 9000+1000*price/2500, 1000)
+		}
+		return calculator.Calculate(positionTracker, 9000, 2300, 1, 0, 0, 0.5, 0)
+	}
+
+	cash := run("")
+	if cash.DrawdownBasis != DrawdownBasisCash {
+		t.Errorf("Expected default drawdown basis cash, got %q", cash.DrawdownBasis)
+	}
+	if cash.MaxDrawdownCash != 0 {
+		t.Errorf("Expected no cash drawdown while the position is held, got %.4f%%", cash.MaxDrawdownCash)
+	}
+	if math.Abs(cash.MaxDrawdownEquity-1) > 1e-9 {
+		t.Errorf("Expected equity drawdown 1%%, got %.4f%%", cash.MaxDrawdownEquity)
+	}
+	if cash.MaxDrawdown != cash.MaxDrawdownCash {
+		t.Errorf("Expected MaxDrawdown to follow cash basis, got %.4f%%", cash.MaxDrawdown)
+	}
+
+	equity := run(DrawdownBasisEquity)
+	if equity.MaxDrawdown != equity.MaxDrawdownEquity || equity.MaxDrawdownCash != cash.MaxDrawdownCash {
+		t.Errorf("Expected MaxDrawdown to follow equity basis, got %.4f%% (cash %.4f%%, equity %.4f%%)",
+			equity.MaxDrawdown, equity.MaxDrawdownCash, equity.MaxDrawdownEquity)
+	}
+}
+
+// TestDrawdownBasis_Validate 測試回撤基礎的校驗
+func TestDrawdownBasis_Validate(t *testing.T) {
+	for _, basis := range []DrawdownBasis{"", DrawdownBasisCash, DrawdownBasisEquity} {
+		if err := basis.Validate(); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", basis, err)
+		}
+	}
+	if err := DrawdownBasis("peak_to_trough").Validate(); err == nil {
+		t.Error("Expected unknown drawdown basis to fail validation")
+	}
+}
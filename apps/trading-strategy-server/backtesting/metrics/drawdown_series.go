@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runningPeak 逐快照追蹤歷史最高資金和瞬時回撤（maxDrawdownPercent 和 DrawdownSeries 共用）
+type runningPeak struct {
+	peak float64
+}
+
+// newRunningPeak 以第一個快照的資金作為初始最高資金
+func newRunningPeak(first float64) runningPeak {
+	return runningPeak{peak: first}
+}
+
+// update 加入一個快照，返回截至此快照的最高資金和瞬時回撤比例（最高資金 <= 0 時回撤為 0）
+func (r *runningPeak) update(balance float64) (peak, drawdown float64) {
+	if balance > r.peak {
+		r.peak = balance
+	}
+	if r.peak <= 0 {
+		return r.peak, 0
+	}
+	return r.peak, (r.peak - balance) / r.peak
+}
+
+// DrawdownPoint 水下曲線的一個點
+type DrawdownPoint struct {
+	Time            time.Time
+	Balance         float64
+	Peak            float64 // 截至此快照的歷史最高資金
+	DrawdownPercent float64 // 瞬時回撤（%）= (Peak - Balance) / Peak × 100
+}
+
+// DrawdownSeries 逐快照計算歷史最高資金和瞬時回撤 ⭐
+//
+// 與 maxDrawdownPercent 的第一遍掃描相同（float64），
+// 因此 DrawdownPercent 的最大值與最大回撤只差浮點誤差
+func DrawdownSeries(snapshots []BalanceSnapshot) []DrawdownPoint {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	points := make([]DrawdownPoint, len(snapshots))
+	tracker := newRunningPeak(snapshots[0].Balance)
+	for i, snapshot := range snapshots {
+		peak, drawdown := tracker.update(snapshot.Balance)
+		points[i] = DrawdownPoint{
+			Time:            snapshot.Time,
+			Balance:         snapshot.Balance,
+			Peak:            peak,
+			DrawdownPercent: drawdown * 100,
+		}
+	}
+	return points
+}
+
+// ExportSnapshotsCSV 導出資金快照和水下曲線到 CSV 文件 ⭐
+//
+// 每個快照一行：時間、資金、歷史最高資金、瞬時回撤（%），可直接繪製水下曲線。
+// 沒有快照時不創建文件
+func (mc *MetricsCalculator) ExportSnapshotsCSV(filePath string) error {
+	points := DrawdownSeries(mc.balanceSnapshots)
+	if len(points) == 0 {
+		return nil // 沒有快照，跳過
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"Time", "Balance", "Peak", "DrawdownPercent"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, point := range points {
+		row := []string{
+			point.Time.UTC().Format("2006-01-02 15:04:05"), // ⭐ 與交易日誌一致使用 UTC
+			fmt.Sprintf("%.4f", point.Balance),
+			fmt.Sprintf("%.4f", point.Peak),
+			fmt.Sprintf("%.6f", point.DrawdownPercent),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
+	}
+	return nil
+}
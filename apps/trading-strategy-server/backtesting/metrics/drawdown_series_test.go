@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestExportSnapshotsCSV_DrawdownColumn 測試導出的回撤列在谷底等於最大回撤 ⭐
+func TestExportSnapshotsCSV_DrawdownColumn(t *testing.T) {
+	mc := NewMetricsCalculator(10000)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 峰值 10500 → 谷底 9450（回撤 10%）→ 新高 11000 → 10450（回撤 5%）
+	balances := []float64{10000, 10500, 9975, 9450, 9900, 11000, 10450}
+	for i, balance := range balances {
+		mc.RecordBalance(start.Add(time.Duration(i)*5*time.Minute), balance)
+	}
+
+	path := filepath.Join(t.TempDir(), "balance_snapshots.csv")
+	if err := mc.ExportSnapshotsCSV(path); err != nil {
+		t.Fatalf("Failed to export snapshots: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open CSV: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	if len(rows) != len(balances)+1 {
+		t.Fatalf("Expected header + %d rows, got %d rows", len(balances), len(rows))
+	}
+	if got := rows[0]; got[0] != "Time" || got[2] != "Peak" || got[3] != "DrawdownPercent" {
+		t.Errorf("Unexpected header %v", got)
+	}
+
+	wantPeaks := []float64{10000, 10500, 10500, 10500, 10500, 11000, 11000}
+	trough, troughDrawdown := 0, 0.0
+	for i, row := range rows[1:] {
+		peak, _ := strconv.ParseFloat(row[2], 64)
+		drawdown, _ := strconv.ParseFloat(row[3], 64)
+		if peak != wantPeaks[i] {
+			t.Errorf("row %d: expected peak %.2f, got %.2f", i, wantPeaks[i], peak)
+		}
+		if drawdown > troughDrawdown {
+			trough, troughDrawdown = i, drawdown
+		}
+	}
+
+	if trough != 3 {
+		t.Errorf("Expected the trough at snapshot 3, got %d", trough)
+	}
+	if maxDrawdown := mc.calculateMaxDrawdown(); math.Abs(troughDrawdown-maxDrawdown) > 1e-6 {
+		t.Errorf("Expected trough drawdown %.6f to equal max drawdown %.6f", troughDrawdown, maxDrawdown)
+	}
+	if math.Abs(troughDrawdown-10) > 1e-6 {
+		t.Errorf("Expected trough drawdown 10%%, got %.6f", troughDrawdown)
+	}
+}
+
+// TestExportSnapshotsCSV_NoSnapshots 測試沒有快照時不創建文件
+func TestExportSnapshotsCSV_NoSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "balance_snapshots.csv")
+	if err := NewMetricsCalculator(10000).ExportSnapshotsCSV(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no file without snapshots, got %v", err)
+	}
+}
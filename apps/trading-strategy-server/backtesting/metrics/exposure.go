@@ -0,0 +1,96 @@
+package metrics
+
+import "time"
+
+// EquitySnapshot 每根K線的權益快照（用於敞口和 Beta 計算）⭐
+type EquitySnapshot struct {
+	Time     time.Time
+	Price    float64 // 標的收盤價
+	Equity   float64 // 權益（餘額 + 持倉價值 + 浮盈虧，不含待回收的注資）
+	Notional float64 // 持倉價值（USDT，按開倉成本）
+}
+
+// RecordEquity 記錄權益快照（每根K線一次）
+func (mc *MetricsCalculator) RecordEquity(timestamp time.Time, price, equity, notional float64) {
+	mc.equitySnapshots = append(mc.equitySnapshots, EquitySnapshot{
+		Time:     timestamp,
+		Price:    price,
+		Equity:   equity,
+		Notional: notional,
+	})
+}
+
+// GetEquitySnapshots 獲取權益快照列表
+func (mc *MetricsCalculator) GetEquitySnapshots() []EquitySnapshot {
+	return mc.equitySnapshots
+}
+
+// RestoreEquitySnapshots 用已有的權益快照覆蓋當前記錄（用於回測斷點續跑）
+func (mc *MetricsCalculator) RestoreEquitySnapshots(snapshots []EquitySnapshot) {
+	mc.equitySnapshots = append(make([]EquitySnapshot, 0, len(snapshots)), snapshots...)
+}
+
+// AverageNetExposure 平均淨多頭敞口 = 持倉價值 / 權益 的時間平均 ⭐
+//
+// 每個快照按到下一個快照的時長加權（數據缺口不會被少算）；權益 <= 0 的快照跳過。
+// 例：0.35 = 平均 35% 的權益投入在持倉中
+//
+// 返回：少於兩個有效快照時為 0
+func AverageNetExposure(snapshots []EquitySnapshot) float64 {
+	var weighted float64
+	var total time.Duration
+	for i := 0; i < len(snapshots)-1; i++ {
+		if snapshots[i].Equity <= 0 {
+			continue
+		}
+		span := snapshots[i+1].Time.Sub(snapshots[i].Time)
+		weighted += snapshots[i].Notional / snapshots[i].Equity * span.Seconds()
+		total += span
+	}
+	if total <= 0 {
+		return 0
+	}
+	return weighted / total.Seconds()
+}
+
+// BetaToBenchmark 策略收益對標的收益的 Beta ⭐
+//
+// 按同一快照對齊兩條收益序列（權益收益率、標的收盤價收益率），
+// 最小二乘回歸斜率 = Cov(策略, 標的) / Var(標的)。
+// 1 = 與持有標的同漲同跌；0 = 與標的無關；網格策略一般介於兩者之間（隨持倉比例變化）
+//
+// 返回：少於兩個收益點或標的收益無波動時為 0
+func BetaToBenchmark(snapshots []EquitySnapshot) float64 {
+	var strategy, benchmark []float64
+	for i := 1; i < len(snapshots); i++ {
+		prev, cur := snapshots[i-1], snapshots[i]
+		if prev.Equity <= 0 || prev.Price <= 0 {
+			continue
+		}
+		strategy = append(strategy, cur.Equity/prev.Equity-1)
+		benchmark = append(benchmark, cur.Price/prev.Price-1)
+	}
+	if len(benchmark) < 2 {
+		return 0
+	}
+
+	meanS, meanB := mean(strategy), mean(benchmark)
+	var covariance, variance float64
+	for i := range benchmark {
+		covariance += (strategy[i] - meanS) * (benchmark[i] - meanB)
+		variance += (benchmark[i] - meanB) * (benchmark[i] - meanB)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
+
+// mean 算術平均
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// trackingSnapshots 權益按固定比例跟隨標的價格（全倉持有標的）
+func trackingSnapshots(prices []float64, exposure float64) []EquitySnapshot {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := make([]EquitySnapshot, len(prices))
+	equity := 1000.0
+	for i, price := range prices {
+		if i > 0 {
+			equity *= 1 + exposure*(price/prices[i-1]-1)
+		}
+		snapshots[i] = EquitySnapshot{
+			Time:     start.Add(time.Duration(i) * 5 * time.Minute),
+			Price:    price,
+			Equity:   equity,
+			Notional: equity * exposure,
+		}
+	}
+	return snapshots
+}
+
+// TestBetaToBenchmark_TracksAsset 測試收益完全跟隨標的時 Beta ≈ 1，半倉時 ≈ 0.5 ⭐
+func TestBetaToBenchmark_TracksAsset(t *testing.T) {
+	prices := []float64{2500, 2520, 2490, 2510, 2550, 2530, 2480, 2505, 2540, 2525}
+
+	full := trackingSnapshots(prices, 1)
+	if beta := BetaToBenchmark(full); math.Abs(beta-1) > 1e-9 {
+		t.Errorf("Expected beta 1 for a fully invested strategy, got %.6f", beta)
+	}
+	if exposure := AverageNetExposure(full); math.Abs(exposure-1) > 1e-9 {
+		t.Errorf("Expected net exposure 1, got %.6f", exposure)
+	}
+
+	half := trackingSnapshots(prices, 0.5)
+	if beta := BetaToBenchmark(half); math.Abs(beta-0.5) > 1e-9 {
+		t.Errorf("Expected beta 0.5 for a half invested strategy, got %.6f", beta)
+	}
+}
+
+// TestBetaToBenchmark_Flat 測試空倉（權益不變）Beta 為 0，標的無波動時返回 0
+func TestBetaToBenchmark_Flat(t *testing.T) {
+	cash := trackingSnapshots([]float64{2500, 2520, 2490, 2510}, 0)
+	if beta := BetaToBenchmark(cash); beta != 0 {
+		t.Errorf("Expected beta 0 for an all-cash strategy, got %.6f", beta)
+	}
+	if exposure := AverageNetExposure(cash); exposure != 0 {
+		t.Errorf("Expected zero exposure, got %.6f", exposure)
+	}
+
+	flat := trackingSnapshots([]float64{2500, 2500, 2500}, 1)
+	if beta := BetaToBenchmark(flat); beta != 0 {
+		t.Errorf("Expected beta 0 when the asset does not move, got %.6f", beta)
+	}
+	if beta := BetaToBenchmark(nil); beta != 0 {
+		t.Errorf("Expected beta 0 without snapshots, got %.6f", beta)
+	}
+}
+
+// TestAverageNetExposure_TimeWeighted 測試敞口按快照間隔加權
+func TestAverageNetExposure_TimeWeighted(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []EquitySnapshot{
+		{Time: start, Equity: 1000, Notional: 0},                       // 空倉 3 小時
+		{Time: start.Add(3 * time.Hour), Equity: 1000, Notional: 800},  // 80% 敞口 1 小時
+		{Time: start.Add(4 * time.Hour), Equity: 1000, Notional: 1000}, // 最後一個快照不計時長
+	}
+	if got := AverageNetExposure(snapshots); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("Expected time-weighted exposure 0.2, got %.6f", got)
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import "fmt"
+
+// DefaultFeeWarnRatio 手續費佔總毛利超過此比例時發出警告（30%）
+const DefaultFeeWarnRatio = 0.3
+
+// FeeToProfitRatio 手續費佔毛利比例 = 總手續費 / 總毛利（未扣手續費）⭐
+//
+// 高頻網格每筆利潤很薄，手續費可能吃掉大部分毛利（「死於手續費」）。
+// 毛利 <= 0 時比例沒有意義，返回 0；此時是否有手續費侵蝕由 FeeWarning 判斷
+func FeeToProfitRatio(totalFees, grossProfit float64) float64 {
+	if grossProfit <= 0 {
+		return 0
+	}
+	return totalFees / grossProfit
+}
+
+// FeeWarning 手續費佔毛利超過 threshold 時返回警告文字，否則返回空字符串 ⭐
+//
+// 毛利 <= 0 但仍支付了手續費時總是警告（手續費全部是淨虧損）；threshold <= 0 表示不檢查
+func FeeWarning(result BacktestResult, threshold float64) string {
+	if threshold <= 0 || result.TotalFeesPaid <= 0 {
+		return ""
+	}
+	if result.TotalProfitGross <= 0 {
+		return fmt.Sprintf("no gross profit but paid $%.2f in fees", result.TotalFeesPaid)
+	}
+	if result.FeeToProfitRatio > threshold {
+		return fmt.Sprintf("fees are %.1f%% of gross profit (threshold %.1f%%)", result.FeeToProfitRatio*100, threshold*100)
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// TestFeeToProfitRatio_HighFees 測試手續費吃掉大部分毛利時的比例和警告 ⭐
+func TestFeeToProfitRatio_HighFees(t *testing.T) {
+	calculator := NewMetricsCalculator(10000)
+
+	// 毛利 10 USDT，開倉手續費 4 + 關倉手續費 2 = 6 USDT（60%）
+	result := calculator.Calculate(simulator.NewPositionTracker(), 10004, 2500, 20, 10, 10, 4, 2)
+
+	if math.Abs(result.FeeToProfitRatio-0.6) > 1e-12 {
+		t.Errorf("Expected fee-to-profit ratio 0.6, got %v", result.FeeToProfitRatio)
+	}
+
+	warning := FeeWarning(result, DefaultFeeWarnRatio)
+	if warning == "" || !strings.Contains(warning, "60.0%") {
+		t.Errorf("Expected warning mentioning 60.0%%, got %q", warning)
+	}
+	if warning := FeeWarning(result, 0.7); warning != "" {
+		t.Errorf("Expected no warning below threshold, got %q", warning)
+	}
+	if warning := FeeWarning(result, 0); warning != "" {
+		t.Errorf("Expected threshold 0 to disable the check, got %q", warning)
+	}
+}
+
+// TestFeeToProfitRatio_NoGrossProfit 測試沒有毛利時比例為 0，但仍警告手續費
+func TestFeeToProfitRatio_NoGrossProfit(t *testing.T) {
+	if ratio := FeeToProfitRatio(5, 0); ratio != 0 {
+		t.Errorf("Expected 0 ratio without gross profit, got %v", ratio)
+	}
+	if ratio := FeeToProfitRatio(5, -3); ratio != 0 {
+		t.Errorf("Expected 0 ratio with gross loss, got %v", ratio)
+	}
+
+	result := BacktestResult{TotalProfitGross: 0, TotalFeesPaid: 5}
+	if warning := FeeWarning(result, DefaultFeeWarnRatio); !strings.Contains(warning, "no gross profit") {
+		t.Errorf("Expected no-gross-profit warning, got %q", warning)
+	}
+
+	// 沒有交易：不警告
+	if warning := FeeWarning(BacktestResult{}, DefaultFeeWarnRatio); warning != "" {
+		t.Errorf("Expected no warning without fees, got %q", warning)
+	}
+}
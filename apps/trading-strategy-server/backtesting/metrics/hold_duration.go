@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// 持倉時長分桶標籤（用於 HoldDurationHistogram）
+const (
+	HoldBucketUnder5m = "<5m"
+	HoldBucket5mTo30m = "5m-30m"
+	HoldBucket30mTo2h = "30m-2h"
+	HoldBucket2hTo1d  = "2h-1d"
+	HoldBucketOver1d  = ">1d"
+)
+
+// HoldDurationBuckets 按時長從短到長排序的分桶標籤（用於報告輸出）
+var HoldDurationBuckets = []string{
+	HoldBucketUnder5m,
+	HoldBucket5mTo30m,
+	HoldBucket30mTo2h,
+	HoldBucket2hTo1d,
+	HoldBucketOver1d,
+}
+
+// HoldDurationHistogram 持倉時長分佈直方圖
+//
+// 平均持倉時長會掩蓋雙峰分佈（快速止盈 vs. 長時間打平），
+// 分桶統計可以看出實際的分佈形狀。
+//
+// 分桶規則（左閉右開）：
+//   - <5m:    [0, 5m)
+//   - 5m-30m: [5m, 30m)
+//   - 30m-2h: [30m, 2h)
+//   - 2h-1d:  [2h, 24h)
+//   - >1d:    [24h, ∞)
+//
+// 返回：所有分桶都會出現在結果中（計數可能為 0）
+func HoldDurationHistogram(closedPositions []simulator.ClosedPosition) map[string]int {
+	histogram := make(map[string]int, len(HoldDurationBuckets))
+	for _, bucket := range HoldDurationBuckets {
+		histogram[bucket] = 0
+	}
+
+	for _, closed := range closedPositions {
+		histogram[holdDurationBucket(closed.HoldDuration)]++
+	}
+
+	return histogram
+}
+
+// holdDurationBucket 返回持倉時長所屬的分桶標籤
+func holdDurationBucket(d time.Duration) string {
+	switch {
+	case d < 5*time.Minute:
+		return HoldBucketUnder5m
+	case d < 30*time.Minute:
+		return HoldBucket5mTo30m
+	case d < 2*time.Hour:
+		return HoldBucket30mTo2h
+	case d < 24*time.Hour:
+		return HoldBucket2hTo1d
+	default:
+		return HoldBucketOver1d
+	}
+}
+
+// MedianHoldDuration 計算持倉時長中位數
+//
+// 偶數筆時取中間兩筆的平均值；沒有已平倉記錄時返回 0
+func MedianHoldDuration(closedPositions []simulator.ClosedPosition) time.Duration {
+	durations := sortedHoldDurations(closedPositions)
+	n := len(durations)
+	if n == 0 {
+		return 0
+	}
+
+	if n%2 == 1 {
+		return durations[n/2]
+	}
+	return (durations[n/2-1] + durations[n/2]) / 2
+}
+
+// PercentileHoldDuration 計算持倉時長的百分位數（nearest-rank 方法）
+//
+// 參數：
+//   - closedPositions: 已平倉記錄
+//   - p: 百分位（0-100，例如 95 表示 P95）
+//
+// 返回：對應百分位的持倉時長；沒有已平倉記錄時返回 0
+func PercentileHoldDuration(closedPositions []simulator.ClosedPosition, p float64) time.Duration {
+	durations := sortedHoldDurations(closedPositions)
+	n := len(durations)
+	if n == 0 {
+		return 0
+	}
+
+	// nearest-rank: rank = ceil(p/100 * n)
+	rank := int(p / 100 * float64(n))
+	if float64(rank) < p/100*float64(n) {
+		rank++
+	}
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > n {
+		rank = n
+	}
+
+	return durations[rank-1]
+}
+
+// sortedHoldDurations 提取並排序所有持倉時長（從短到長）
+func sortedHoldDurations(closedPositions []simulator.ClosedPosition) []time.Duration {
+	durations := make([]time.Duration, len(closedPositions))
+	for i, closed := range closedPositions {
+		durations[i] = closed.HoldDuration
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// newClosedWithHold 創建指定持倉時長的已平倉記錄
+func newClosedWithHold(openTime time.Time, hold time.Duration) simulator.ClosedPosition {
+	return simulator.ClosedPosition{
+		Position: simulator.Position{
+			EntryPrice: 2500,
+			Size:       100,
+			OpenTime:   openTime,
+		},
+		ClosePrice:   2505,
+		CloseTime:    openTime.Add(hold),
+		HoldDuration: hold,
+	}
+}
+
+// TestHoldDurationHistogram_MixedHolds 測試快速止盈與長時間打平混合的分佈
+func TestHoldDurationHistogram_MixedHolds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	holds := []time.Duration{
+		1 * time.Minute,  // <5m
+		3 * time.Minute,  // <5m
+		4 * time.Minute,  // <5m
+		10 * time.Minute, // 5m-30m
+		45 * time.Minute, // 30m-2h
+		5 * time.Hour,    // 2h-1d
+		30 * time.Hour,   // >1d
+		72 * time.Hour,   // >1d
+	}
+
+	closed := make([]simulator.ClosedPosition, 0, len(holds))
+	for _, h := range holds {
+		closed = append(closed, newClosedWithHold(now, h))
+	}
+
+	histogram := HoldDurationHistogram(closed)
+
+	expected := map[string]int{
+		HoldBucketUnder5m: 3,
+		HoldBucket5mTo30m: 1,
+		HoldBucket30mTo2h: 1,
+		HoldBucket2hTo1d:  1,
+		HoldBucketOver1d:  2,
+	}
+	for bucket, want := range expected {
+		if got := histogram[bucket]; got != want {
+			t.Errorf("bucket %s: expected %d, got %d", bucket, want, got)
+		}
+	}
+
+	// 偶數筆：中位數 = (10m + 45m) / 2 = 27.5m
+	median := MedianHoldDuration(closed)
+	if want := 27*time.Minute + 30*time.Second; median != want {
+		t.Errorf("Expected median %v, got %v", want, median)
+	}
+
+	// P95（nearest-rank）：ceil(0.95 * 8) = 8 → 最長的 72h
+	p95 := PercentileHoldDuration(closed, 95)
+	if p95 != 72*time.Hour {
+		t.Errorf("Expected P95 %v, got %v", 72*time.Hour, p95)
+	}
+
+	t.Logf("✅ Histogram: %v, median=%v, p95=%v", histogram, median, p95)
+}
+
+// TestHoldDurationHistogram_Empty 測試沒有已平倉記錄
+func TestHoldDurationHistogram_Empty(t *testing.T) {
+	histogram := HoldDurationHistogram(nil)
+
+	for _, bucket := range HoldDurationBuckets {
+		if histogram[bucket] != 0 {
+			t.Errorf("bucket %s: expected 0, got %d", bucket, histogram[bucket])
+		}
+	}
+
+	if MedianHoldDuration(nil) != 0 {
+		t.Error("Expected zero median for no closed positions")
+	}
+	if PercentileHoldDuration(nil, 95) != 0 {
+		t.Error("Expected zero P95 for no closed positions")
+	}
+}
+
+// TestCalculate_TradesPerDay 測試日均關倉次數
+func TestCalculate_TradesPerDay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calculator := NewMetricsCalculator(10000)
+	tracker := simulator.NewPositionTracker()
+
+	// 兩天內平倉 4 筆
+	for i := 0; i < 4; i++ {
+		openTime := start.Add(time.Duration(i) * 12 * time.Hour)
+		pos, _ := tracker.AddPosition(2500, 100, openTime, 2505)
+		tracker.ClosePosition(pos.ID, 2505, openTime.Add(10*time.Minute), 0.1)
+	}
+
+	calculator.RecordBalance(start, 10000)
+	calculator.RecordBalance(start.Add(48*time.Hour), 10000.4)
+
+	result := calculator.Calculate(tracker, 10000.4, 2505, 4, 0.8, 0.8, 0.2, 0.2)
+
+	if result.TradesPerDay != 2 {
+		t.Errorf("Expected 2 trades per day, got %.2f", result.TradesPerDay)
+	}
+	if result.MedianHoldDuration != 10*time.Minute {
+		t.Errorf("Expected median hold 10m, got %v", result.MedianHoldDuration)
+	}
+	if result.HoldDurationHistogram[HoldBucket5mTo30m] != 4 {
+		t.Errorf("Expected 4 holds in 5m-30m bucket, got %d", result.HoldDurationHistogram[HoldBucket5mTo30m])
+	}
+}
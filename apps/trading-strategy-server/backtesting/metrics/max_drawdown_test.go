@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// benchmarkSnapshotCount 約一年 5m K線的快照數量
+const benchmarkSnapshotCount = 100_000
+
+// maxDrawdownDecimalReference 逐快照 decimal 計算的原始實現（作為精確結果的基準）
+func maxDrawdownDecimalReference(snapshots []BalanceSnapshot) float64 {
+	if len(snapshots) == 0 {
+		return 0.0
+	}
+
+	hundred := decimal.NewFromInt(100)
+	maxDrawdownD := decimal.Zero
+	peakD := decimal.NewFromFloat(snapshots[0].Balance)
+
+	for _, snapshot := range snapshots {
+		balanceD := decimal.NewFromFloat(snapshot.Balance)
+		if balanceD.GreaterThan(peakD) {
+			peakD = balanceD
+		}
+		if peakD.GreaterThan(decimal.Zero) {
+			drawdownD := peakD.Sub(balanceD).Div(peakD).Mul(hundred)
+			if drawdownD.GreaterThan(maxDrawdownD) {
+				maxDrawdownD = drawdownD
+			}
+		}
+	}
+
+	return maxDrawdownD.InexactFloat64()
+}
+
+// randomWalkSnapshots 生成隨機遊走的資金快照（固定種子，結果可重現）
+func randomWalkSnapshots(seed int64, count int) []BalanceSnapshot {
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := make([]BalanceSnapshot, count)
+	balance := 10000.0
+	for i := range snapshots {
+		balance *= 1 + (rng.Float64()-0.5)*0.004
+		snapshots[i] = BalanceSnapshot{Time: start.Add(time.Duration(i) * 5 * time.Minute), Balance: balance}
+	}
+	return snapshots
+}
+
+func balancesToSnapshots(balances ...float64) []BalanceSnapshot {
+	snapshots := make([]BalanceSnapshot, len(balances))
+	for i, balance := range balances {
+		snapshots[i] = BalanceSnapshot{Balance: balance}
+	}
+	return snapshots
+}
+
+// TestMaxDrawdownPercent_MatchesDecimalReference 新實現與逐快照 decimal 計算結果完全一致 ⭐
+func TestMaxDrawdownPercent_MatchesDecimalReference(t *testing.T) {
+	cases := map[string][]BalanceSnapshot{
+		"empty":           nil,
+		"single":          balancesToSnapshots(10000),
+		"only rising":     balancesToSnapshots(100, 101, 102.5, 103),
+		"equal drawdowns": balancesToSnapshots(100, 90, 100, 90, 200, 180),
+		"flat bottom":     balancesToSnapshots(500, 400.01, 400.01, 400.01, 450),
+		"non-positive":    balancesToSnapshots(0, -5, 10, 3, -1),
+		"tiny drawdown":   balancesToSnapshots(10000, 9999.999999999, 10000.000000001),
+	}
+	for seed := int64(1); seed <= 5; seed++ {
+		cases[fmt.Sprintf("random walk %d", seed)] = randomWalkSnapshots(seed, 20_000)
+	}
+
+	for name, snapshots := range cases {
+		want := maxDrawdownDecimalReference(snapshots)
+		if got := maxDrawdownPercent(snapshots); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+// BenchmarkMaxDrawdown 10 萬快照的最大回撤（兩遍掃描）
+func BenchmarkMaxDrawdown(b *testing.B) {
+	snapshots := randomWalkSnapshots(42, benchmarkSnapshotCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		maxDrawdownPercent(snapshots)
+	}
+}
+
+// BenchmarkMaxDrawdownDecimalReference 10 萬快照的最大回撤（逐快照 decimal，對照組）
+func BenchmarkMaxDrawdownDecimalReference(b *testing.B) {
+	snapshots := randomWalkSnapshots(42, benchmarkSnapshotCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		maxDrawdownDecimalReference(snapshots)
+	}
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// 關倉原因分類（用於 PnLByReason）
+const (
+	ReasonHitTarget       = "hit_target"        // 正常止盈
+	ReasonBreakEvenExit   = "break_even_exit"   // 打平退出
+	ReasonStopLoss        = "stop_loss"         // 止損（預留）
+	ReasonBacktestEnd     = "backtest_end"      // 回測結束強制平倉（ForceCloseAtEnd）
+	ReasonDrawdownHalt    = "drawdown_halt"     // 回撤熔斷強制平倉（HaltForceClose）
+	ReasonBreakEvenStop   = "break_even_stop"   // 保本止損（BreakEvenStop）
+	ReasonRoundProfitExit = "round_profit_exit" // 整輪止盈退出（RoundProfitTarget）
+	ReasonRoundTimeStop   = "round_time_stop"   // 輪次時間止損（MaxRoundCandles）
+)
+
+// PnLByReason 按關倉原因歸因已實現盈虧 ⭐
+//
+// 用途：拆分淨利潤來自正常止盈、打平退出還是止損。
+//
+// 計算方式：
+//   - 只統計 CLOSE 記錄，按 ReasonCategory(Reason) 分組
+//   - 單筆淨盈虧 = PnL_Avg - 平倉手續費 - 開倉手續費
+//   - 開倉手續費通過 PositionID 從對應的 OPEN 記錄取得
+//
+// 因此所有分組加總 = 累計已實現盈虧（與 ClosedPosition.RealizedPnL 一致）
+//
+// 返回：分類 → 淨盈虧（USDT）；沒有關倉時返回空 map
+func PnLByReason(trades []TradeLog) map[string]float64 {
+	attribution := NewReasonAttribution()
+	for _, trade := range trades {
+		attribution.Add(trade)
+	}
+	return attribution.Result()
+}
+
+// ReasonAttribution 逐筆累計的 PnLByReason（串流交易日誌時不需要保留完整日誌）⭐
+//
+// 只保留未平倉位的開倉手續費和各分類的累計值；字段導出以便寫入斷點
+type ReasonAttribution struct {
+	OpenFees map[string]decimal.Decimal // 倉位ID → 開倉手續費（平倉後刪除）
+	Sums     map[string]decimal.Decimal // 分類 → 淨盈虧
+}
+
+// NewReasonAttribution 創建空的累計器
+func NewReasonAttribution() *ReasonAttribution {
+	return &ReasonAttribution{
+		OpenFees: make(map[string]decimal.Decimal),
+		Sums:     make(map[string]decimal.Decimal),
+	}
+}
+
+// Add 按交易日誌順序加入一筆記錄（OPEN 記錄開倉手續費，CLOSE 計入所屬分類）
+func (a *ReasonAttribution) Add(trade TradeLog) {
+	switch trade.Action {
+	case "OPEN":
+		if trade.PositionID != "" {
+			a.OpenFees[trade.PositionID] = decimal.NewFromFloat(trade.Fee)
+		}
+	case "CLOSE":
+		// ⭐ 使用 decimal 累加，避免浮點誤差
+		netPnLD := decimal.NewFromFloat(trade.PnL_Avg).
+			Sub(decimal.NewFromFloat(trade.Fee)).
+			Sub(a.OpenFees[trade.PositionID])
+		delete(a.OpenFees, trade.PositionID)
+
+		category := ReasonCategory(trade.Reason)
+		a.Sums[category] = a.Sums[category].Add(netPnLD)
+	}
+}
+
+// Result 返回分類 → 淨盈虧（USDT）；沒有關倉時返回空 map
+func (a *ReasonAttribution) Result() map[string]float64 {
+	result := make(map[string]float64, len(a.Sums))
+	for category, sumD := range a.Sums {
+		result[category] = sumD.InexactFloat64()
+	}
+	return result
+}
+
+// UntaggedPositions 沒有開倉標籤的倉位在 PnLByTag 中的分組名
+const UntaggedPositions = "untagged"
+
+// PnLByTag 按開倉標籤歸因已實現盈虧 ⭐
+//
+// 與 PnLByReason 對應：PnLByReason 按關倉一側分組，PnLByTag 按開倉時的子規則
+// （Position.Tag，例: "dip_buy"）分組，用於比較不同開倉規則的表現。
+// 單筆淨盈虧 = ClosedPosition.RealizedPnL，沒有標籤的倉位歸入 UntaggedPositions
+//
+// 返回：標籤 → 淨盈虧（USDT）；沒有已平倉位時返回空 map
+func PnLByTag(closedPositions []simulator.ClosedPosition) map[string]float64 {
+	// ⭐ 使用 decimal 累加，避免浮點誤差
+	sums := make(map[string]decimal.Decimal)
+	for _, closed := range closedPositions {
+		tag := closed.Tag
+		if tag == "" {
+			tag = UntaggedPositions
+		}
+		sums[tag] = sums[tag].Add(decimal.NewFromFloat(closed.RealizedPnL))
+	}
+
+	result := make(map[string]float64, len(sums))
+	for tag, sumD := range sums {
+		result[tag] = sumD.InexactFloat64()
+	}
+	return result
+}
+
+// ReasonCategory 提取關倉原因的分類前綴
+//
+// 範例：
+//   - "hit_target_2504.00" → "hit_target"
+//   - "break_even_exit: expected_profit=1.20 USDT (target: 1-20 USDT)" → "break_even_exit"
+//   - "stop_loss" → "stop_loss"
+func ReasonCategory(reason string) string {
+	category := reason
+	if idx := strings.Index(category, ":"); idx >= 0 {
+		category = category[:idx]
+	}
+	category = strings.TrimSpace(category)
+
+	// 去掉結尾的價格參數（例如 "_2504.00"）
+	if idx := strings.LastIndex(category, "_"); idx > 0 {
+		if _, err := strconv.ParseFloat(category[idx+1:], 64); err == nil {
+			category = category[:idx]
+		}
+	}
+
+	if category == "" {
+		return "unknown"
+	}
+	return category
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// TestPnLByReason 測試混合關倉原因的盈虧歸因，加總應等於累計已實現盈虧
+func TestPnLByReason(t *testing.T) {
+	trades := []TradeLog{
+		{Action: "OPEN", PositionID: "pos-1", Fee: 0.10},
+		{Action: "OPEN", PositionID: "pos-2", Fee: 0.10},
+		{Action: "OPEN", PositionID: "pos-3", Fee: 0.10},
+		{Action: "OPEN", PositionID: "pos-4", Fee: 0.10},
+		// 正常止盈：0.40 - 0.10 - 0.10 = 0.20
+		{Action: "CLOSE", PositionID: "pos-1", PnL_Avg: 0.40, Fee: 0.10, Reason: "hit_target_2504.00", TotalRealizedPnL: 0.20},
+		// 正常止盈：0.50 - 0.10 - 0.10 = 0.30
+		{Action: "CLOSE", PositionID: "pos-2", PnL_Avg: 0.50, Fee: 0.10, Reason: "hit_target_2510.50", TotalRealizedPnL: 0.50},
+		// 打平退出：-1.00 - 0.10 - 0.10 = -1.20
+		{Action: "CLOSE", PositionID: "pos-3", PnL_Avg: -1.00, Fee: 0.10, Reason: "break_even_exit: expected_profit=1.20 USDT (target: 1-20 USDT)", TotalRealizedPnL: -0.70},
+		// 止損：-2.00 - 0.10 - 0.10 = -2.20
+		{Action: "CLOSE", PositionID: "pos-4", PnL_Avg: -2.00, Fee: 0.10, Reason: "stop_loss", TotalRealizedPnL: -2.90},
+	}
+
+	got := PnLByReason(trades)
+
+	want := map[string]float64{
+		ReasonHitTarget:     0.50,
+		ReasonBreakEvenExit: -1.20,
+		ReasonStopLoss:      -2.20,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d categories, got %v", len(want), got)
+	}
+	for reason, wantPnL := range want {
+		if math.Abs(got[reason]-wantPnL) > 1e-9 {
+			t.Errorf("%s: expected %.4f, got %.4f", reason, wantPnL, got[reason])
+		}
+	}
+
+	// 歸因加總 = 累計已實現盈虧
+	sum := 0.0
+	for _, pnl := range got {
+		sum += pnl
+	}
+	totalRealized := trades[len(trades)-1].TotalRealizedPnL
+	if math.Abs(sum-totalRealized) > 1e-9 {
+		t.Errorf("Expected attribution sum %.4f to equal total realized PnL %.4f", sum, totalRealized)
+	}
+}
+
+// TestReasonCategory 測試關倉原因分類
+func TestReasonCategory(t *testing.T) {
+	tests := map[string]string{
+		"hit_target_2504.00":                         ReasonHitTarget,
+		"break_even_exit: expected_profit=1.20 USDT": ReasonBreakEvenExit,
+		"stop_loss":     ReasonStopLoss,
+		"custom_reason": "custom_reason",
+		"":              "unknown",
+	}
+
+	for reason, want := range tests {
+		if got := ReasonCategory(reason); got != want {
+			t.Errorf("ReasonCategory(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
+
+// TestPnLByTag 測試按開倉標籤歸因盈虧：標籤隨倉位帶到已平倉記錄，各標籤分別加總
+func TestPnLByTag(t *testing.T) {
+	tracker := simulator.NewPositionTracker()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	opens := []struct {
+		tag         string
+		realizedPnL float64
+	}{
+		{"trend_confirmed", 0.30},
+		{"dip_buy", 0.25},
+		{"trend_confirmed", -0.10},
+		{"dip_buy", 0.45},
+		{"", 0.05},
+	}
+	for i, open := range opens {
+		position, err := tracker.AddPositionWithTag(2500, 200, 0, now, 2505, open.tag)
+		if err != nil {
+			t.Fatalf("Failed to add position %d: %v", i, err)
+		}
+		if err := tracker.ClosePosition(position.ID, 2505, now.Add(time.Hour), open.realizedPnL); err != nil {
+			t.Fatalf("Failed to close position %d: %v", i, err)
+		}
+	}
+
+	closed := tracker.GetClosedPositions()
+	if closed[1].Tag != "dip_buy" {
+		t.Fatalf("Expected tag to be carried to ClosedPosition, got %q", closed[1].Tag)
+	}
+
+	got := PnLByTag(closed)
+	want := map[string]float64{
+		"trend_confirmed": 0.20,
+		"dip_buy":         0.70,
+		UntaggedPositions: 0.05,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d tags, got %v", len(want), got)
+	}
+	for tag, wantPnL := range want {
+		if math.Abs(got[tag]-wantPnL) > 1e-9 {
+			t.Errorf("%s: expected %.4f, got %.4f", tag, wantPnL, got[tag])
+		}
+	}
+
+	if len(PnLByTag(nil)) != 0 {
+		t.Error("Expected empty attribution without closed positions")
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProfitFactorCap 盈虧比上限 ⭐
+//
+// 沒有虧損、或虧損小到盈利 / 虧損超過此值時，盈虧比記為此值並標記 ProfitFactorCapped：
+// 一筆 0.01 USDT 的虧損會讓 5000 的盈利得到 500000 的盈虧比，看起來像真實數字卻沒有意義
+const ProfitFactorCap = 999.99
+
+// DefaultProfitFactorMinTrades 已平倉交易少於此數時盈虧比樣本不足（設置 LowSampleWarning）
+const DefaultProfitFactorMinTrades = 30
+
+// profitFactor 计算盈亏比 = 总盈利 / 总亏损（均为正数）
+//
+// 无亏损但有盈利、或比值超过 ProfitFactorCap 时返回 ProfitFactorCap 和 capped = true；都为 0 时返回 0
+func profitFactor(profitD, lossD decimal.Decimal) (factor float64, capped bool) {
+	capD := decimal.NewFromFloat(ProfitFactorCap)
+	if lossD.GreaterThan(decimal.Zero) {
+		factorD := profitD.Div(lossD)
+		if factorD.GreaterThan(capD) {
+			return ProfitFactorCap, true // 虧損可忽略
+		}
+		return factorD.InexactFloat64(), false
+	}
+	if profitD.GreaterThan(decimal.Zero) {
+		return ProfitFactorCap, true // 无亏损，盈亏比极高
+	}
+	return 0.0, false
+}
+
+// ProfitFactorWarning 盈虧比不可信時返回警告文字，否則返回空字符串 ⭐
+//
+// 依據 Calculate 設置的 LowSampleWarning / ProfitFactorCapped：
+// 已平倉交易太少或虧損可忽略時，盈虧比不應作為策略優劣的依據
+func ProfitFactorWarning(result BacktestResult) string {
+	if !result.LowSampleWarning {
+		return ""
+	}
+
+	if result.ProfitFactorCapped {
+		return fmt.Sprintf("profit factor is unreliable: %d closed trades, losses negligible (capped at %.2f)", result.TotalTrades, ProfitFactorCap)
+	}
+	return fmt.Sprintf("profit factor is unreliable: only %d closed trades", result.TotalTrades)
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// closeTrades 按給定的已實現盈虧平倉（每筆都在開倉價平倉，只測盈虧比統計）
+func closeTrades(tracker *simulator.PositionTracker, pnls []float64) {
+	now := time.Now()
+	for i, pnl := range pnls {
+		pos, _ := tracker.AddPosition(2500, 100, now, 2510)
+		tracker.ClosePosition(pos.ID, 2500, now.Add(time.Duration(i+1)*time.Minute), pnl)
+	}
+}
+
+// TestProfitFactor_NegligibleLossIsCapped 測試虧損可忽略時盈虧比被封頂並發出警告
+//
+// 40 筆盈利共 5000 USDT、1 筆虧損 0.01 USDT：原始盈虧比 500000，應記為 ProfitFactorCap
+func TestProfitFactor_NegligibleLossIsCapped(t *testing.T) {
+	pnls := make([]float64, 0, 41)
+	for range 40 {
+		pnls = append(pnls, 125)
+	}
+	pnls = append(pnls, -0.01)
+
+	tracker := simulator.NewPositionTracker()
+	closeTrades(tracker, pnls)
+	result := NewMetricsCalculator(10000).Calculate(tracker, 15000, 2500, 41, 0, 0, 0, 0)
+
+	if result.ProfitFactorTotal != ProfitFactorCap || !result.ProfitFactorCapped {
+		t.Errorf("Expected capped profit factor %.2f, got %.2f (capped=%v)",
+			ProfitFactorCap, result.ProfitFactorTotal, result.ProfitFactorCapped)
+	}
+	if result.ProfitFactorRealized != ProfitFactorCap {
+		t.Errorf("Expected realized profit factor capped at %.2f, got %.2f", ProfitFactorCap, result.ProfitFactorRealized)
+	}
+	if !result.LowSampleWarning {
+		t.Fatal("Expected LowSampleWarning for a capped profit factor")
+	}
+	if warning := ProfitFactorWarning(result); !strings.Contains(warning, "losses negligible") {
+		t.Errorf("Expected negligible-loss warning, got %q", warning)
+	}
+}
+
+// TestProfitFactor_LowSampleWarning 測試交易數少於門檻時警告，門檻可配置
+func TestProfitFactor_LowSampleWarning(t *testing.T) {
+	tracker := simulator.NewPositionTracker()
+	closeTrades(tracker, []float64{3, -1, 2, -1})
+
+	result := NewMetricsCalculator(10000).Calculate(tracker, 10003, 2500, 4, 0, 0, 0, 0)
+	if result.ProfitFactorCapped {
+		t.Errorf("Expected uncapped profit factor, got %.2f", result.ProfitFactorTotal)
+	}
+	if !result.LowSampleWarning {
+		t.Fatalf("Expected LowSampleWarning with %d trades (< %d)", result.TotalTrades, DefaultProfitFactorMinTrades)
+	}
+	if warning := ProfitFactorWarning(result); !strings.Contains(warning, "only 4 closed trades") {
+		t.Errorf("Expected low sample warning, got %q", warning)
+	}
+
+	calculator := NewMetricsCalculator(10000)
+	calculator.SetProfitFactorMinTrades(4)
+	result = calculator.Calculate(tracker, 10003, 2500, 4, 0, 0, 0, 0)
+	if result.LowSampleWarning || ProfitFactorWarning(result) != "" {
+		t.Errorf("Expected no warning with min trades 4, got %q", ProfitFactorWarning(result))
+	}
+}
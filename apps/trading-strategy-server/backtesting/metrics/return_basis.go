@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReturnBasis 總收益率的資本基數 ⭐
+//
+// 啟用自動注資時，實際投入風險的資本是初始資金加上注資，只除以初始資金會高估收益率
+type ReturnBasis string
+
+const (
+	ReturnBasisInitialOnly           ReturnBasis = "initial"                  // 初始資金（默認）
+	ReturnBasisInitialPlusMaxFunding ReturnBasis = "initial_plus_max_funding" // 初始資金 + 待回收注資峰值（最多同時投入的注資）
+	ReturnBasisInitialPlusNetFunding ReturnBasis = "initial_plus_net_funding" // 初始資金 + 回測結束時尚未回收的注資
+)
+
+// Validate 檢查基數是否有效（空值 = initial）
+func (b ReturnBasis) Validate() error {
+	switch b {
+	case "", ReturnBasisInitialOnly, ReturnBasisInitialPlusMaxFunding, ReturnBasisInitialPlusNetFunding:
+		return nil
+	default:
+		return fmt.Errorf("unknown return basis: %s", b)
+	}
+}
+
+// ReturnCapital 按基數計算總收益率的分母（USDT）
+//
+// maxFunding 為待回收注資的峰值，netFunding 為回測結束時尚未回收的注資
+func ReturnCapital(basis ReturnBasis, initialBalance, maxFunding, netFunding float64) decimal.Decimal {
+	capitalD := decimal.NewFromFloat(initialBalance)
+	switch basis {
+	case ReturnBasisInitialPlusMaxFunding:
+		capitalD = capitalD.Add(decimal.NewFromFloat(maxFunding))
+	case ReturnBasisInitialPlusNetFunding:
+		capitalD = capitalD.Add(decimal.NewFromFloat(netFunding))
+	}
+	return capitalD
+}
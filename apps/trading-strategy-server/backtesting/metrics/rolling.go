@@ -0,0 +1,65 @@
+package metrics
+
+import "time"
+
+// RollingPoint 滾動窗口指標（每個資金快照一個點，可直接導出 CSV 或繪圖）
+type RollingPoint struct {
+	Time        time.Time // 快照時間（窗口結束）
+	WindowStart time.Time // 窗口內第一個快照的時間
+	Balance     float64   // 當前資金
+	Return      float64   // 窗口收益率（%）= (當前資金 - 窗口起點資金) / 窗口起點資金 × 100
+	MaxDrawdown float64   // 窗口內最大回撤（%），只考慮窗口內的高點
+}
+
+// RollingMetrics 計算每個資金快照的滾動窗口收益率和最大回撤 ⭐
+//
+// 窗口 = [快照時間 - window, 快照時間]；回測開始不足一個窗口時，從第一個快照算起。
+// 用於觀察策略表現隨時間的變化（例如某個日期之後策略失效）。
+//
+// 複雜度為 O(快照數 × 窗口內快照數)，只在回測結束後生成報告時調用
+//
+// 返回：window <= 0 或沒有快照時返回 nil
+func (mc *MetricsCalculator) RollingMetrics(window time.Duration) []RollingPoint {
+	return RollingMetrics(mc.balanceSnapshots, window)
+}
+
+// RollingMetrics 按資金快照計算滾動窗口指標（見 MetricsCalculator.RollingMetrics）
+func RollingMetrics(snapshots []BalanceSnapshot, window time.Duration) []RollingPoint {
+	if window <= 0 || len(snapshots) == 0 {
+		return nil
+	}
+
+	points := make([]RollingPoint, 0, len(snapshots))
+	start := 0
+	for i, snapshot := range snapshots {
+		// 移動窗口起點：丟棄早於 (當前時間 - window) 的快照
+		for snapshot.Time.Sub(snapshots[start].Time) > window {
+			start++
+		}
+
+		first := snapshots[start].Balance
+		point := RollingPoint{
+			Time:        snapshot.Time,
+			WindowStart: snapshots[start].Time,
+			Balance:     snapshot.Balance,
+		}
+		if first > 0 {
+			point.Return = (snapshot.Balance - first) / first * 100
+		}
+
+		peak := first
+		for _, s := range snapshots[start : i+1] {
+			if s.Balance > peak {
+				peak = s.Balance
+			}
+			if peak > 0 {
+				if drawdown := (peak - s.Balance) / peak * 100; drawdown > point.MaxDrawdown {
+					point.MaxDrawdown = drawdown
+				}
+			}
+		}
+
+		points = append(points, point)
+	}
+	return points
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestRollingMetrics_KnownPoint 測試已知快照序列在指定點的滾動收益率和回撤
+func TestRollingMetrics_KnownPoint(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 每天一個快照
+	balances := []float64{1000, 1100, 990, 1050, 1200, 1080}
+	mc := NewMetricsCalculator(1000)
+	for i, balance := range balances {
+		mc.RecordBalance(start.Add(time.Duration(i)*24*time.Hour), balance)
+	}
+
+	points := mc.RollingMetrics(2 * 24 * time.Hour)
+	if len(points) != len(balances) {
+		t.Fatalf("Expected %d rolling points, got %d", len(balances), len(points))
+	}
+
+	// 第 1 個點：窗口只有自己
+	if points[0].Return != 0 || points[0].MaxDrawdown != 0 {
+		t.Errorf("Expected zero metrics for the first point, got %+v", points[0])
+	}
+
+	// 第 4 個點（day 3, 1050）：窗口 = day 1..3 (1100, 990, 1050)
+	// 收益率 = (1050 - 1100) / 1100 = -4.545%，最大回撤 = (1100 - 990) / 1100 = 10%
+	p := points[3]
+	if !p.WindowStart.Equal(start.Add(24 * time.Hour)) {
+		t.Errorf("Expected window start at day 1, got %v", p.WindowStart)
+	}
+	if math.Abs(p.Return-(-50.0/1100*100)) > 1e-9 {
+		t.Errorf("Expected rolling return -4.5455%%, got %.6f", p.Return)
+	}
+	if math.Abs(p.MaxDrawdown-10) > 1e-9 {
+		t.Errorf("Expected rolling max drawdown 10%%, got %.6f", p.MaxDrawdown)
+	}
+
+	// 最後一個點（day 5, 1080）：窗口 = day 3..5 (1050, 1200, 1080)
+	// 早於窗口的 1100 高點和 990 低點不再影響
+	last := points[len(points)-1]
+	if math.Abs(last.Return-(30.0/1050*100)) > 1e-9 {
+		t.Errorf("Expected rolling return 2.857%%, got %.6f", last.Return)
+	}
+	if math.Abs(last.MaxDrawdown-10) > 1e-9 {
+		t.Errorf("Expected rolling max drawdown 10%% (1200 → 1080), got %.6f", last.MaxDrawdown)
+	}
+}
+
+func TestRollingMetrics_Empty(t *testing.T) {
+	if points := RollingMetrics(nil, time.Hour); points != nil {
+		t.Errorf("Expected nil for no snapshots, got %v", points)
+	}
+	snapshots := []BalanceSnapshot{{Balance: 100}}
+	if points := RollingMetrics(snapshots, 0); points != nil {
+		t.Errorf("Expected nil for non-positive window, got %v", points)
+	}
+}
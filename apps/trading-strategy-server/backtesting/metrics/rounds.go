@@ -0,0 +1,17 @@
+package metrics
+
+import "time"
+
+// RoundsSummary 打平輪次彙總統計 ⭐
+//
+// 一輪 = 從首次開倉到所有倉位被整輪平倉（打平退出、整輪止盈、保本止損、時間止損，按 ExitReasons 區分）；
+// 盈虧以觸發時的預期盈利（已實現 + 未實現）計算，與控制台和報告中的輪次統計口徑一致
+type RoundsSummary struct {
+	TotalRounds      int            // 總輪次數
+	ProfitRounds     int            // 盈利輪次（預期盈利 >= 0）
+	LossRounds       int            // 虧損輪次
+	AvgDuration      time.Duration  // 平均每輪時長
+	AvgOpensPerRound float64        // 平均每輪開倉次數
+	AvgRoundPnL      float64        // 平均每輪盈虧（USDT）
+	ExitReasons      map[string]int // 結束原因分類 → 輪次數（例: break_even_exit、round_profit_exit）
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// Streaks 連勝 / 連虧統計
+type Streaks struct {
+	MaxConsecutiveWins   int     // 最大連續盈利次數
+	MaxConsecutiveLosses int     // 最大連續虧損次數
+	WorstLosingStreak    float64 // 金額最大的一段連續虧損（USDT，正數）
+}
+
+// CalculateStreaks 按平倉時間順序計算連勝 / 連虧
+//
+// 已平倉記錄大致按時間排序，但打平同時平多倉等情況下不保證，
+// 因此先按 CloseTime 穩定排序（不修改傳入的切片）。
+// 盈虧以 RealizedPnL（已扣費）判斷，盈虧為 0 的交易會中斷連勝和連虧。
+func CalculateStreaks(closedPositions []simulator.ClosedPosition) Streaks {
+	sorted := make([]simulator.ClosedPosition, len(closedPositions))
+	copy(sorted, closedPositions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CloseTime.Before(sorted[j].CloseTime)
+	})
+
+	var streaks Streaks
+	wins, losses := 0, 0
+	lossAmountD := decimal.Zero
+	worstLossD := decimal.Zero
+
+	for _, closed := range sorted {
+		switch {
+		case closed.RealizedPnL > 0:
+			wins++
+			losses = 0
+			lossAmountD = decimal.Zero
+		case closed.RealizedPnL < 0:
+			losses++
+			wins = 0
+			lossAmountD = lossAmountD.Add(decimal.NewFromFloat(closed.RealizedPnL).Neg())
+		default:
+			wins, losses = 0, 0
+			lossAmountD = decimal.Zero
+		}
+
+		streaks.MaxConsecutiveWins = max(streaks.MaxConsecutiveWins, wins)
+		streaks.MaxConsecutiveLosses = max(streaks.MaxConsecutiveLosses, losses)
+		if lossAmountD.GreaterThan(worstLossD) {
+			worstLossD = lossAmountD
+		}
+	}
+
+	streaks.WorstLosingStreak = worstLossD.InexactFloat64()
+	return streaks
+}
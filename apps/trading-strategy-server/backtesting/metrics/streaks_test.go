@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// newClosedWithPnL 創建指定平倉時間和已實現盈虧的記錄
+func newClosedWithPnL(closeTime time.Time, realizedPnL float64) simulator.ClosedPosition {
+	return simulator.ClosedPosition{
+		Position:    simulator.Position{EntryPrice: 2500, Size: 200},
+		CloseTime:   closeTime,
+		RealizedPnL: realizedPnL,
+	}
+}
+
+// TestCalculateStreaks_WWLLLW 測試 W W L L L W：連勝 2 次、連虧 3 次
+func TestCalculateStreaks_WWLLLW(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pnls := []float64{1.5, 2.0, -3.0, -1.0, -4.5, 0.8}
+
+	// 故意打亂順序，驗證按 CloseTime 排序
+	order := []int{5, 2, 0, 4, 1, 3}
+	closed := make([]simulator.ClosedPosition, 0, len(pnls))
+	for _, idx := range order {
+		closed = append(closed, newClosedWithPnL(start.Add(time.Duration(idx)*time.Hour), pnls[idx]))
+	}
+
+	streaks := CalculateStreaks(closed)
+
+	if streaks.MaxConsecutiveWins != 2 {
+		t.Errorf("Expected max consecutive wins 2, got %d", streaks.MaxConsecutiveWins)
+	}
+	if streaks.MaxConsecutiveLosses != 3 {
+		t.Errorf("Expected max consecutive losses 3, got %d", streaks.MaxConsecutiveLosses)
+	}
+	if streaks.WorstLosingStreak != 8.5 {
+		t.Errorf("Expected worst losing streak 8.5, got %.4f", streaks.WorstLosingStreak)
+	}
+	if closed[0].RealizedPnL != pnls[5] {
+		t.Error("CalculateStreaks should not reorder the input slice")
+	}
+}
+
+// TestCalculateStreaks_WorstAmountNotLongest 測試金額最大的連虧不一定是最長的連虧
+func TestCalculateStreaks_WorstAmountNotLongest(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pnls := []float64{-1, -1, -1, 2, -10, 0, -0.5}
+
+	closed := make([]simulator.ClosedPosition, 0, len(pnls))
+	for i, pnl := range pnls {
+		closed = append(closed, newClosedWithPnL(start.Add(time.Duration(i)*time.Hour), pnl))
+	}
+
+	streaks := CalculateStreaks(closed)
+
+	if streaks.MaxConsecutiveLosses != 3 {
+		t.Errorf("Expected max consecutive losses 3, got %d", streaks.MaxConsecutiveLosses)
+	}
+	if streaks.WorstLosingStreak != 10 {
+		t.Errorf("Expected worst losing streak 10, got %.4f", streaks.WorstLosingStreak)
+	}
+	if empty := CalculateStreaks(nil); empty != (Streaks{}) {
+		t.Errorf("Expected zero streaks for no trades, got %+v", empty)
+	}
+}
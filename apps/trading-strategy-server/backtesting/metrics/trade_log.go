@@ -0,0 +1,26 @@
+package metrics
+
+import "time"
+
+// TradeLog 交易日誌（用於 debug）
+type TradeLog struct {
+	TradeID                 int       // 交易序號
+	Time                    time.Time // 時間
+	Action                  string    // OPEN / CLOSE / MARK（定期盯市，見 BacktestConfig.MarkInterval）
+	Price                   float64   // 價格
+	PositionSize            float64   // 倉位大小
+	Balance                 float64   // 當前餘額
+	OpenPositionValue       float64   // 累計持倉總價值（USDT）⭐
+	PnLPercent              float64   // 盈虧百分比（基於單筆開倉價）⭐
+	PnL                     float64   // 盈虧金額（基於單筆開倉價，未扣手續費）⭐
+	AvgCost                 float64   // 平倉時的平均成本（所有未平倉的加權平均）⭐
+	PnLPercent_Avg          float64   // 基於平均成本的盈虧百分比 ⭐
+	PnL_Avg                 float64   // 基於平均成本的盈虧金額（未扣手續費）⭐
+	Fee                     float64   // 手續費 ⭐
+	RoundClosedValue        float64   // 本輪累積關倉總價值（本金 + 盈虧）⭐
+	CurrentRoundRealizedPnL float64   // 本輪已實現盈虧（基於平均成本，扣除手續費）⭐
+	TotalRealizedPnL        float64   // 累計已實現盈虧（從回測開始到現在的所有已實現盈虧總和）⭐
+	UnrealizedPnL           float64   // 浮動盈虧（所有未平倉倉位的未實現盈虧）⭐
+	Reason                  string    // 原因
+	PositionID              string    // 倉位ID（關聯開倉和平倉）⭐
+}
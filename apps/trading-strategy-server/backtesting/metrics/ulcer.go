@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"math"
+	"time"
+)
+
+// UlcerIndex 計算潰瘍指數（Ulcer Index）
+//
+// 最大回撤只反映最差的一個點；潰瘍指數同時反映所有回撤的深度和持續時間，
+// 更接近持有策略的實際「痛苦程度」。
+//
+// 公式：
+//
+//	drawdown_i = (歷史最高資金 - 當前資金) / 歷史最高資金 × 100
+//	UlcerIndex = sqrt(Σ drawdown_i² / N)
+//
+// 返回：潰瘍指數（%），沒有快照時返回 0
+func UlcerIndex(snapshots []BalanceSnapshot) float64 {
+	if len(snapshots) == 0 {
+		return 0.0
+	}
+
+	peak := snapshots[0].Balance
+	sumSquares := 0.0
+	for _, snapshot := range snapshots {
+		if snapshot.Balance > peak {
+			peak = snapshot.Balance
+		}
+		if peak > 0 {
+			drawdown := (peak - snapshot.Balance) / peak * 100
+			sumSquares += drawdown * drawdown
+		}
+	}
+
+	return math.Sqrt(sumSquares / float64(len(snapshots)))
+}
+
+// AnnualizedReturn 將總收益率按回測時間跨度線性年化
+//
+// 使用線性年化（總收益率 × 365天 / 時間跨度），避免短週期回測按複利年化後數值失真
+//
+// 返回：年化收益率（%），時間跨度為 0 時返回 0
+func AnnualizedReturn(totalReturn float64, span time.Duration) float64 {
+	if span <= 0 {
+		return 0.0
+	}
+	return totalReturn * (365 * 24 * time.Hour).Hours() / span.Hours()
+}
+
+// PainRatio 計算痛苦比率 = 年化收益率 / 潰瘍指數
+//
+// 返回：潰瘍指數為 0（沒有任何回撤）時返回 0
+func PainRatio(annualizedReturn, ulcerIndex float64) float64 {
+	if ulcerIndex <= 0 {
+		return 0.0
+	}
+	return annualizedReturn / ulcerIndex
+}
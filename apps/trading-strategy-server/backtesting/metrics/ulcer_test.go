@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestUlcerIndex_KnownDrawdowns 測試已知回撤序列的潰瘍指數
+func TestUlcerIndex_KnownDrawdowns(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	balances := []float64{100, 90, 80, 100, 110, 99}
+	snapshots := make([]BalanceSnapshot, len(balances))
+	for i, balance := range balances {
+		snapshots[i] = BalanceSnapshot{Time: start.Add(time.Duration(i) * time.Hour), Balance: balance}
+	}
+
+	// 回撤：0, 10, 20, 0, 0, 10（相對新高 110）
+	// UI = sqrt((0 + 100 + 400 + 0 + 0 + 100) / 6) = sqrt(100) = 10
+	if got := UlcerIndex(snapshots); math.Abs(got-10) > 1e-9 {
+		t.Errorf("Expected Ulcer Index 10, got %.6f", got)
+	}
+}
+
+// TestUlcerIndex_Empty 測試沒有快照和沒有回撤的情況
+func TestUlcerIndex_Empty(t *testing.T) {
+	if got := UlcerIndex(nil); got != 0 {
+		t.Errorf("Expected 0 for empty snapshots, got %.6f", got)
+	}
+
+	rising := []BalanceSnapshot{{Balance: 100}, {Balance: 101}, {Balance: 102}}
+	if got := UlcerIndex(rising); got != 0 {
+		t.Errorf("Expected 0 without drawdowns, got %.6f", got)
+	}
+	if got := PainRatio(12, 0); got != 0 {
+		t.Errorf("Expected pain ratio 0 when Ulcer Index is 0, got %.6f", got)
+	}
+}
+
+// TestPainRatio_Annualized 測試年化收益率和痛苦比率
+func TestPainRatio_Annualized(t *testing.T) {
+	// 73 天收益 2% → 年化 10%
+	annualized := AnnualizedReturn(2, 73*24*time.Hour)
+	if math.Abs(annualized-10) > 1e-9 {
+		t.Fatalf("Expected annualized return 10%%, got %.6f", annualized)
+	}
+	if got := PainRatio(annualized, 4); math.Abs(got-2.5) > 1e-9 {
+		t.Errorf("Expected pain ratio 2.5, got %.6f", got)
+	}
+	if got := AnnualizedReturn(2, 0); got != 0 {
+		t.Errorf("Expected 0 for zero span, got %.6f", got)
+	}
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// 波動率區間（用於 PnLByVolatilityRegime）
+const (
+	VolatilityRegimeLow     = "low"     // 低波動（全程波動率的下三分之一）
+	VolatilityRegimeMedium  = "medium"  // 中波動
+	VolatilityRegimeHigh    = "high"    // 高波動（全程波動率的上三分之一）
+	VolatilityRegimeUnknown = "unknown" // 回測開頭不足一個窗口，無法計算波動率
+)
+
+// DefaultVolatilityWindow 滾動已實現波動率的窗口（收益率個數，即K線根數）
+const DefaultVolatilityWindow = 20
+
+// VolatilityRegimes 按滾動已實現波動率把每個權益快照劃分為低 / 中 / 高波動區間 ⭐
+//
+// 波動率 = 最近 window 根K線收盤價對數收益率的標準差（按 EquitySnapshot.Price 計算）。
+// 分檔閾值取全程波動率的三分位數：不高於下三分位為 low，不低於上三分位為 high，其餘為 medium
+// （波動率全部相同時都歸入 low）。閾值使用整段回測的分佈，只用於事後分析，不可作為交易信號
+//
+// 返回：與 snapshots 一一對應的區間；前 window 個快照為 VolatilityRegimeUnknown；window <= 0 時返回 nil
+func VolatilityRegimes(snapshots []EquitySnapshot, window int) []string {
+	if window <= 0 {
+		return nil
+	}
+	regimes := make([]string, len(snapshots))
+	returns := make([]float64, len(snapshots)) // returns[i] = ln(Price[i] / Price[i-1])
+	vols := make([]float64, len(snapshots))
+	var sum, sumSq float64 // 窗口內收益率的和與平方和
+	for i := range snapshots {
+		regimes[i] = VolatilityRegimeUnknown
+		if i == 0 {
+			continue
+		}
+		if prev, cur := snapshots[i-1].Price, snapshots[i].Price; prev > 0 && cur > 0 {
+			returns[i] = math.Log(cur / prev)
+		}
+		sum += returns[i]
+		sumSq += returns[i] * returns[i]
+		if i > window {
+			// 移出窗口外的收益率
+			sum -= returns[i-window]
+			sumSq -= returns[i-window] * returns[i-window]
+		}
+		if i >= window {
+			n := float64(window)
+			vols[i] = math.Sqrt(math.Max(sumSq/n-(sum/n)*(sum/n), 0))
+		}
+	}
+	if len(snapshots) <= window {
+		return regimes
+	}
+
+	// 三分位閾值
+	sorted := append([]float64(nil), vols[window:]...)
+	sort.Float64s(sorted)
+	lower, upper := sorted[len(sorted)/3], sorted[len(sorted)*2/3]
+	for i := window; i < len(snapshots); i++ {
+		switch {
+		case vols[i] <= lower:
+			regimes[i] = VolatilityRegimeLow
+		case vols[i] >= upper:
+			regimes[i] = VolatilityRegimeHigh
+		default:
+			regimes[i] = VolatilityRegimeMedium
+		}
+	}
+	return regimes
+}
+
+// PnLByVolatilityRegime 按平倉時所處的波動率區間歸因已實現盈虧 ⭐
+//
+// 平倉時間對應到不晚於平倉時間的最後一個權益快照（同一根K線內的平倉歸入該K線），
+// 該快照的區間見 VolatilityRegimes；早於第一個快照或處於窗口期的平倉歸入 VolatilityRegimeUnknown。
+// 單筆淨盈虧 = ClosedPosition.RealizedPnL，各區間加總等於總已實現盈虧。
+// 用於判斷策略的優勢是否依賴特定的波動環境
+//
+// 返回：區間 → 淨盈虧（USDT）；沒有已平倉位時返回空 map
+func PnLByVolatilityRegime(closedPositions []simulator.ClosedPosition, snapshots []EquitySnapshot, window int) map[string]float64 {
+	regimes := VolatilityRegimes(snapshots, window)
+
+	// ⭐ 使用 decimal 累加，避免浮點誤差
+	sums := make(map[string]decimal.Decimal)
+	for _, closed := range closedPositions {
+		regime := VolatilityRegimeUnknown
+		// 第一個晚於平倉時間的快照的前一個
+		idx := sort.Search(len(snapshots), func(i int) bool { return snapshots[i].Time.After(closed.CloseTime) }) - 1
+		if idx >= 0 && idx < len(regimes) {
+			regime = regimes[idx]
+		}
+		sums[regime] = sums[regime].Add(decimal.NewFromFloat(closed.RealizedPnL))
+	}
+
+	result := make(map[string]float64, len(sums))
+	for regime, sumD := range sums {
+		result[regime] = sumD.InexactFloat64()
+	}
+	return result
+}
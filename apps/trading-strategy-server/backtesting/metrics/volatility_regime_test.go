@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+)
+
+// volatilitySegmentSnapshots 生成前 60 根低波動（±0.05%）、後 60 根高波動（±2%）的權益快照
+func volatilitySegmentSnapshots(start time.Time) []EquitySnapshot {
+	snapshots := make([]EquitySnapshot, 120)
+	price := 2500.0
+	for i := range snapshots {
+		move := 0.0005
+		if i >= 60 {
+			move = 0.02
+		}
+		if i > 0 {
+			if i%2 == 0 {
+				price *= 1 + move
+			} else {
+				price *= 1 - move
+			}
+		}
+		snapshots[i] = EquitySnapshot{Time: start.Add(time.Duration(i) * 5 * time.Minute), Price: price, Equity: 10000}
+	}
+	return snapshots
+}
+
+// TestPnLByVolatilityRegime_SegmentAttribution 測試低波動段和高波動段的平倉盈虧歸入對應區間 ⭐
+func TestPnLByVolatilityRegime_SegmentAttribution(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := volatilitySegmentSnapshots(start)
+	at := func(candle int) time.Time { return start.Add(time.Duration(candle) * 5 * time.Minute) }
+	closed := func(candle int, pnl float64) simulator.ClosedPosition {
+		return simulator.ClosedPosition{CloseTime: at(candle), RealizedPnL: pnl}
+	}
+
+	regimes := VolatilityRegimes(snapshots, 10)
+	if regimes[5] != VolatilityRegimeUnknown || regimes[40] != VolatilityRegimeLow || regimes[100] != VolatilityRegimeHigh {
+		t.Fatalf("Expected unknown / low / high regimes, got %s / %s / %s", regimes[5], regimes[40], regimes[100])
+	}
+
+	positions := []simulator.ClosedPosition{
+		closed(5, 1.0),   // 窗口期
+		closed(30, 2.0),  // 低波動
+		closed(45, 3.0),  // 低波動
+		closed(90, -4.0), // 高波動
+		closed(110, 6.5), // 高波動
+	}
+	// 同一根K線內稍晚的平倉仍歸入該K線
+	positions = append(positions, simulator.ClosedPosition{CloseTime: at(100).Add(time.Minute), RealizedPnL: -1.5})
+
+	got := PnLByVolatilityRegime(positions, snapshots, 10)
+	want := map[string]float64{
+		VolatilityRegimeUnknown: 1.0,
+		VolatilityRegimeLow:     5.0,
+		VolatilityRegimeHigh:    1.0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected regimes %v, got %v", want, got)
+	}
+	for regime, wantPnL := range want {
+		if math.Abs(got[regime]-wantPnL) > 1e-9 {
+			t.Errorf("%s: expected %.4f, got %.4f", regime, wantPnL, got[regime])
+		}
+	}
+}
+
+// TestVolatilityRegimes_Edges 測試窗口無效、快照不足和沒有已平倉位的情況
+func TestVolatilityRegimes_Edges(t *testing.T) {
+	snapshots := volatilitySegmentSnapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if regimes := VolatilityRegimes(snapshots, 0); regimes != nil {
+		t.Errorf("Expected nil regimes for window 0, got %d", len(regimes))
+	}
+	for _, regime := range VolatilityRegimes(snapshots[:10], 10) {
+		if regime != VolatilityRegimeUnknown {
+			t.Fatalf("Expected only unknown regimes before a full window, got %s", regime)
+		}
+	}
+	if got := PnLByVolatilityRegime(nil, snapshots, 10); len(got) != 0 {
+		t.Errorf("Expected empty attribution without closed positions, got %v", got)
+	}
+}
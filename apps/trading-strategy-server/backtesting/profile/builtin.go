@@ -0,0 +1,37 @@
+package profile
+
+// builtinProfiles 內建配置方案 ⭐
+//
+// 修改已發布方案的參數時新增一個版本，而不是直接修改，
+// 以便用 "name@version" 復現舊的實驗結果
+var builtinProfiles = []Profile{
+	{
+		Name:        "conservative",
+		Version:     1,
+		Description: "小倉位、較寬止盈，虧損時需要連續紅K才加倉，回撤 20% 熔斷",
+		Options: map[string]any{
+			"position-size":            100.0,
+			"take-profit-min":          0.002,
+			"take-profit-max":          0.01,
+			"enable-red-candle-filter": true,
+			"red-candle-lookback":      3,
+			"red-candle-min-red":       2,
+			"min-net-profit":           0.05,
+			"min-price-gap":            0.002,
+			"max-drawdown-halt":        0.2,
+		},
+	},
+	{
+		Name:        "aggressive",
+		Version:     1,
+		Description: "大倉位、窄止盈，不過濾紅K，整輪盈利 5 USDT 即退出",
+		Options: map[string]any{
+			"position-size":            300.0,
+			"take-profit-min":          0.0012,
+			"take-profit-max":          0.01,
+			"first-entry-take-profit":  0.001,
+			"enable-red-candle-filter": false,
+			"round-profit-target":      5.0,
+		},
+	},
+}
@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound 註冊表中沒有指定名稱（或版本）的配置方案
+var ErrNotFound = errors.New("profile not found")
+
+// Profile 命名、帶版本的回測參數方案 ⭐
+//
+// Options 的鍵名與回測命令行參數相同（不帶 --），值為 YAML 標量，
+// 與 --config 配置文件的格式一致，由命令行按相同規則填入參數
+type Profile struct {
+	Name        string         `yaml:"name"`
+	Version     int            `yaml:"version"`
+	Description string         `yaml:"description"`
+	Options     map[string]any `yaml:"options"`
+}
+
+// String 格式化為 "name@version"
+func (p Profile) String() string {
+	return fmt.Sprintf("%s@%d", p.Name, p.Version)
+}
+
+// Registry 配置方案註冊表（同一名稱可以有多個版本）
+type Registry struct {
+	profiles map[string][]Profile // 名稱 → 按版本升序排列
+}
+
+// NewRegistry 創建空的註冊表
+func NewRegistry() *Registry {
+	return &Registry{profiles: make(map[string][]Profile)}
+}
+
+// Register 註冊一個配置方案；名稱和版本都相同時返回錯誤
+func (r *Registry) Register(p Profile) error {
+	if p.Name == "" || strings.ContainsAny(p.Name, "@ \t") {
+		return fmt.Errorf("invalid profile name %q", p.Name)
+	}
+	if p.Version <= 0 {
+		return fmt.Errorf("profile %s: version must be positive, got %d", p.Name, p.Version)
+	}
+
+	versions := r.profiles[p.Name]
+	for _, existing := range versions {
+		if existing.Version == p.Version {
+			return fmt.Errorf("profile %s is already registered", p)
+		}
+	}
+	versions = append(versions, p)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	r.profiles[p.Name] = versions
+	return nil
+}
+
+// Lookup 按引用查找配置方案 ⭐
+//
+// ref 為 "name"（最新版本）或 "name@version"（指定版本，便於復現舊實驗）
+func (r *Registry) Lookup(ref string) (Profile, error) {
+	name, versionStr, pinned := strings.Cut(ref, "@")
+	versions := r.profiles[name]
+	if len(versions) == 0 {
+		return Profile{}, fmt.Errorf("%w: %s (available: %s)", ErrNotFound, name, strings.Join(r.Names(), ", "))
+	}
+	if !pinned {
+		return versions[len(versions)-1], nil
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return Profile{}, fmt.Errorf("invalid profile version in %q: %w", ref, err)
+	}
+	for _, p := range versions {
+		if p.Version == version {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Names 返回所有配置方案名稱（排序）
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadDir 從目錄載入用戶自定義的配置方案（*.yaml / *.yml / *.json，每個文件一個方案）⭐
+//
+// 文件格式：
+//
+//	name: eth-tight
+//	version: 2
+//	description: 窄止盈 + 價位去重
+//	options:
+//	  take-profit-min: 0.0012
+//	  min-price-gap: 0.002
+//
+// 用戶方案與內建方案同名時需使用不同的版本號
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read profile directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read profile %s: %w", path, err)
+		}
+		var p Profile
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("failed to parse profile %s: %w", path, err)
+		}
+		if err := r.Register(p); err != nil {
+			return fmt.Errorf("profile file %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Default 返回包含內建方案的註冊表（CLI 和測試共用）
+func Default() *Registry {
+	r := NewRegistry()
+	for _, p := range builtinProfiles {
+		if err := r.Register(p); err != nil {
+			panic(err) // 內建方案在編譯時確定，註冊失敗屬於程序錯誤
+		}
+	}
+	return r
+}
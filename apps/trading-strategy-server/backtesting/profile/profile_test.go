@@ -0,0 +1,118 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestDefault_BuiltinProfiles 測試內建方案註冊
+func TestDefault_BuiltinProfiles(t *testing.T) {
+	r := Default()
+
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"aggressive", "conservative"}) {
+		t.Fatalf("Unexpected builtin profiles: %v", names)
+	}
+
+	p, err := r.Lookup("conservative")
+	if err != nil {
+		t.Fatalf("Failed to lookup conservative: %v", err)
+	}
+	if p.String() != "conservative@1" {
+		t.Errorf("Expected conservative@1, got %s", p)
+	}
+	if p.Options["max-drawdown-halt"] != 0.2 {
+		t.Errorf("Expected max-drawdown-halt 0.2, got %v", p.Options["max-drawdown-halt"])
+	}
+}
+
+// TestRegistry_LookupVersions 測試最新版本與指定版本的查找 ⭐
+func TestRegistry_LookupVersions(t *testing.T) {
+	r := NewRegistry()
+	for _, v := range []int{2, 1, 3} {
+		if err := r.Register(Profile{Name: "tight", Version: v}); err != nil {
+			t.Fatalf("Failed to register v%d: %v", v, err)
+		}
+	}
+
+	latest, err := r.Lookup("tight")
+	if err != nil || latest.Version != 3 {
+		t.Errorf("Expected latest version 3, got %v (err %v)", latest.Version, err)
+	}
+	pinned, err := r.Lookup("tight@2")
+	if err != nil || pinned.Version != 2 {
+		t.Errorf("Expected pinned version 2, got %v (err %v)", pinned.Version, err)
+	}
+
+	if _, err := r.Lookup("tight@4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing version, got %v", err)
+	}
+	if _, err := r.Lookup("loose"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing name, got %v", err)
+	}
+	if _, err := r.Lookup("tight@v2"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected parse error for invalid version, got %v", err)
+	}
+}
+
+// TestRegistry_RegisterInvalid 測試非法或重複的方案
+func TestRegistry_RegisterInvalid(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(Profile{Name: "tight", Version: 1}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	cases := map[string]Profile{
+		"duplicate":     {Name: "tight", Version: 1},
+		"empty name":    {Name: "", Version: 1},
+		"name with @":   {Name: "tight@1", Version: 1},
+		"name with gap": {Name: "very tight", Version: 1},
+		"zero version":  {Name: "loose", Version: 0},
+	}
+	for name, p := range cases {
+		if err := r.Register(p); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestRegistry_LoadDir 測試從目錄載入用戶方案
+func TestRegistry_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tight.yaml": "name: tight\nversion: 1\noptions:\n  take-profit-min: 0.0012\n",
+		"wide.json":  `{"name": "wide", "version": 1, "options": {"take-profit-min": 0.004}}`,
+		"notes.txt":  "not a profile",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	r := Default()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("Failed to load dir: %v", err)
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"aggressive", "conservative", "tight", "wide"}) {
+		t.Fatalf("Unexpected profiles after LoadDir: %v", names)
+	}
+	wide, err := r.Lookup("wide")
+	if err != nil {
+		t.Fatalf("Failed to lookup wide: %v", err)
+	}
+	if wide.Options["take-profit-min"] != 0.004 {
+		t.Errorf("Expected take-profit-min 0.004, got %v", wide.Options["take-profit-min"])
+	}
+
+	// 與內建方案同名同版本應報錯
+	clash := filepath.Join(t.TempDir(), "conservative.yaml")
+	if err := os.WriteFile(clash, []byte("name: conservative\nversion: 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write clash: %v", err)
+	}
+	if err := Default().LoadDir(filepath.Dir(clash)); err == nil {
+		t.Error("Expected error when user profile clashes with builtin version")
+	}
+}
@@ -8,11 +8,32 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// 模擬器錯誤（可用 errors.Is 判斷）⭐
+var (
+	ErrShouldNotOpen       = errors.New("advice indicates should not open") // 建議不開倉
+	ErrInvalidOpenPrice    = errors.New("invalid open price")               // 開倉價無法解析或非正數
+	ErrInvalidClosePrice   = errors.New("invalid close price")              // 平倉價無法解析或非正數
+	ErrInvalidPositionSize = errors.New("invalid position size")            // 倉位大小非正數
+	ErrInsufficientBalance = errors.New("insufficient balance")             // 餘額不足以支付倉位 + 手續費
+	ErrPositionNotFound    = errors.New("position not found")               // 找不到指定持倉
+)
+
+// FeeCurrency 手續費的扣除幣種 ⭐
+type FeeCurrency string
+
+const (
+	FeeCurrencyQuote FeeCurrency = "quote" // 手續費以 USDT 支付（開倉額外扣 USDT，默認）
+	FeeCurrencyBase  FeeCurrency = "base"  // 買入手續費從收到的幣中扣除，賣出手續費從收到的 USDT 中扣除
+)
+
 // OrderSimulator 成交模擬器
 type OrderSimulator struct {
 	feeRate       float64        // OKX taker 手續費: 0.05% (0.0005)
-	slippage      float64        // 滑點（簡單版設為 0）
+	slippage      float64        // 滑點率：買入成交價 = 建議價 × (1 + slippage)，賣出成交價 = 建議價 × (1 - slippage)
+	feeCurrency   FeeCurrency    // 手續費扣除幣種（默認: quote）⭐
+	minFee        float64        // 每筆訂單的最低手續費（USDT，0 = 不限制）⭐
 	pnlCalculator *PnLCalculator // 盈虧計算器 ⭐ Single Source of Truth
+	nextID        int            // 下一個持倉序號（確定性ID，見 FormatPositionID）⭐
 }
 
 // OpenAdvice 開倉建議（與 strategy-server 保持一致）
@@ -24,6 +45,7 @@ type OpenAdvice struct {
 	PositionSize float64 // 建議倉位大小（美元）
 	TakeProfit   float64 // 建議停利百分比
 	Reason       string  // 原因
+	Tag          string  // 開倉標籤（複製到 Position.Tag）⭐
 }
 
 // CloseResult 平倉結果（統一計算所有盈虧指標）⭐
@@ -42,6 +64,8 @@ type CloseResult struct {
 	CloseFee   float64 // 平倉手續費
 	CloseValue float64 // 平倉時的總價值（本金 + 盈虧）
 	Revenue    float64 // 實際收入（closeValue - closeFee）
+
+	SlippageCost float64 // 平倉滑點成本 = 按建議價成交的收入 - 實際收入 ⭐
 }
 
 // NewOrderSimulator 創建成交模擬器
@@ -49,25 +73,93 @@ func NewOrderSimulator(feeRate, slippage float64) *OrderSimulator {
 	return &OrderSimulator{
 		feeRate:       feeRate,
 		slippage:      slippage,
+		feeCurrency:   FeeCurrencyQuote,
 		pnlCalculator: NewPnLCalculator(), // 初始化盈虧計算器 ⭐
 	}
 }
 
+// SetFeeCurrency 設置手續費扣除幣種（空字符串 = quote）⭐
+//
+// base 模式下開倉不額外扣 USDT，實際收到的幣數 = Size / EntryPrice × (1 - feeRate)，
+// 記錄在 Position.Coins；平倉手續費仍從賣出所得的 USDT 中扣除
+func (s *OrderSimulator) SetFeeCurrency(currency FeeCurrency) error {
+	switch currency {
+	case "":
+		currency = FeeCurrencyQuote
+	case FeeCurrencyQuote, FeeCurrencyBase:
+	default:
+		return fmt.Errorf("unknown fee currency: %s", currency)
+	}
+	s.feeCurrency = currency
+	return nil
+}
+
+// SetMinFeePerOrder 設置每筆訂單的最低手續費（USDT）⭐
+//
+// 開倉和平倉手續費都為 max(成交金額 × feeRate, minFee)，用於模擬按筆收取最低費用的交易所；
+// 0 = 不限制（默認）
+func (s *OrderSimulator) SetMinFeePerOrder(minFee float64) error {
+	if minFee < 0 {
+		return fmt.Errorf("min fee per order must be non-negative, got %v", minFee)
+	}
+	s.minFee = minFee
+	return nil
+}
+
+// Fee 計算一筆訂單的手續費：max(成交金額 × feeRate, 最低手續費) ⭐
+//
+// 未設置最低手續費時保持原費率（包括負費率的返傭）
+func (s *OrderSimulator) Fee(notional decimal.Decimal) decimal.Decimal {
+	feeD := notional.Mul(decimal.NewFromFloat(s.feeRate))
+	if s.minFee > 0 {
+		feeD = decimal.Max(feeD, decimal.NewFromFloat(s.minFee))
+	}
+	return feeD
+}
+
+// EstimateOpenCost 估算開倉需要的 USDT（quote: 倉位 + 手續費；base: 倉位）
+func (s *OrderSimulator) EstimateOpenCost(positionSize float64) decimal.Decimal {
+	positionSizeD := decimal.NewFromFloat(positionSize)
+	if s.feeCurrency == FeeCurrencyBase {
+		return positionSizeD
+	}
+	return positionSizeD.Add(s.Fee(positionSizeD))
+}
+
+// OpenSlippageCost 開倉滑點成本 = 實際收到的幣數 × (成交價 - 建議價) ⭐
+//
+// 倉位大小（USDT）固定，滑點讓同樣的 USDT 買到更少的幣；建議價由成交價反推：EntryPrice / (1 + slippage)。
+// 只適用於本模擬器 SimulateOpen 開出的倉位（初始持倉沒有開倉成交，不應計入）
+func (s *OrderSimulator) OpenSlippageCost(position Position) decimal.Decimal {
+	if s.slippage == 0 || position.EntryPrice <= 0 {
+		return decimal.Zero
+	}
+	entryPriceD := decimal.NewFromFloat(position.EntryPrice)
+	coinsD := decimal.NewFromFloat(position.Size).Div(entryPriceD)
+	if position.Coins > 0 {
+		coinsD = decimal.NewFromFloat(position.Coins)
+	}
+	idealPriceD := entryPriceD.Div(decimal.NewFromFloat(1 + s.slippage))
+	return coinsD.Mul(entryPriceD.Sub(idealPriceD))
+}
+
 // SimulateOpen 模擬開倉
 //
 // 功能：
 //  1. 檢查餘額是否足夠
 //  2. 計算開倉手續費
-//  3. 計算實際成本（倉位大小 + 手續費）
+//  3. 計算實際成本（quote: 倉位大小 + 手續費；base: 倉位大小，手續費從收到的幣中扣除）
 //  4. 返回持倉記錄和實際成本
 //
+// 開倉成交價 = 建議開倉價 × (1 + slippage)
+//
 // 參數：
 //   - advice: 開倉建議（包含開倉價格、倉位大小等）
 //   - balance: 當前可用餘額
 //
 // 返回：
 //   - Position: 持倉記錄
-//   - float64: 實際成本（倉位大小 + 手續費）
+//   - float64: 實際成本（從餘額扣除的 USDT）
 //   - error: 錯誤信息
 func (s *OrderSimulator) SimulateOpen(
 	advice OpenAdvice,
@@ -76,51 +168,81 @@ func (s *OrderSimulator) SimulateOpen(
 ) (Position, float64, error) {
 	// 1. 驗證是否應該開倉
 	if !advice.ShouldOpen {
-		return Position{}, 0, errors.New("advice indicates should not open")
+		return Position{}, 0, ErrShouldNotOpen
 	}
 
 	// 2. 解析開倉價格和平倉價格（使用精確的 decimal 計算）
 	openPriceDecimal, err := decimal.NewFromString(advice.OpenPrice)
 	if err != nil {
-		return Position{}, 0, fmt.Errorf("invalid open price: %w", err)
+		return Position{}, 0, fmt.Errorf("%w: %w", ErrInvalidOpenPrice, err)
 	}
 
 	closePriceDecimal, err := decimal.NewFromString(advice.ClosePrice)
 	if err != nil {
-		return Position{}, 0, fmt.Errorf("invalid close price: %w", err)
+		return Position{}, 0, fmt.Errorf("%w: %w", ErrInvalidClosePrice, err)
 	}
 
-	openPrice := openPriceDecimal.InexactFloat64()
 	closePrice := closePriceDecimal.InexactFloat64()
 
+	// ⭐ 滑點：買入成交價高於建議價
+	if s.slippage != 0 {
+		openPriceDecimal = openPriceDecimal.Mul(decimal.NewFromFloat(1 + s.slippage))
+	}
+	openPrice := openPriceDecimal.InexactFloat64()
+
+	// ⭐ 防禦性檢查：拒絕非正數的價格和倉位大小（避免壞數據污染倉位計算）
+	if openPrice <= 0 {
+		return Position{}, 0, fmt.Errorf("%w: open price must be positive", ErrInvalidOpenPrice)
+	}
+	if closePrice <= 0 {
+		return Position{}, 0, fmt.Errorf("%w: close price must be positive", ErrInvalidClosePrice)
+	}
+	if advice.PositionSize <= 0 {
+		return Position{}, 0, fmt.Errorf("%w: position size must be positive", ErrInvalidPositionSize)
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	positionSizeD := decimal.NewFromFloat(advice.PositionSize)
-	feeRateD := decimal.NewFromFloat(s.feeRate)
 	balanceD := decimal.NewFromFloat(balance)
 
-	// 3. 計算開倉手續費（倉位大小 * 手續費率）
-	feeD := positionSizeD.Mul(feeRateD)
+	// 3. 計算開倉手續費（倉位大小 * 手續費率，不低於最低手續費）
+	feeD := s.Fee(positionSizeD)
 
 	// 4. 計算實際成本（倉位大小 + 手續費）
 	actualCostD := positionSizeD.Add(feeD)
 
+	// ⭐ base 模式：手續費以幣支付，只扣倉位大小，收到的幣數相應減少
+	var coins float64
+	if s.feeCurrency == FeeCurrencyBase {
+		actualCostD = positionSizeD
+		if !feeD.LessThan(positionSizeD) {
+			return Position{}, 0, fmt.Errorf("%w: open fee %.2f exceeds position size %.2f in base fee mode",
+				ErrInvalidPositionSize, feeD.InexactFloat64(), positionSizeD.InexactFloat64())
+		}
+		coins = positionSizeD.Div(openPriceDecimal).Mul(decimal.NewFromInt(1).Sub(feeD.Div(positionSizeD))).InexactFloat64()
+	}
+
 	// 5. 檢查餘額是否足夠
 	if balanceD.LessThan(actualCostD) {
 		return Position{}, 0, fmt.Errorf(
-			"insufficient balance: need %.2f USDT (position: %.2f + fee: %.2f), have %.2f USDT",
-			actualCostD.InexactFloat64(), positionSizeD.InexactFloat64(), feeD.InexactFloat64(), balance,
+			"%w: need %.2f USDT (position: %.2f + fee: %.2f), have %.2f USDT",
+			ErrInsufficientBalance, actualCostD.InexactFloat64(), positionSizeD.InexactFloat64(), feeD.InexactFloat64(), balance,
 		)
 	}
 
 	// 6. 創建持倉記錄
 	position := Position{
-		ID:               fmt.Sprintf("backtest_pos_%d", time.Now().UnixNano()),
+		ID:               FormatPositionID(s.nextID), // ⭐ 確定性計數器，不依賴系統時間
 		EntryPrice:       openPrice,
 		Size:             advice.PositionSize,
 		OpenTime:         openTime,
 		TargetClosePrice: closePrice,
+		Coins:            coins,
+		Tag:              advice.Tag,
 	}
 
+	s.nextID++
+
 	return position, actualCostD.InexactFloat64(), nil
 }
 
@@ -130,7 +252,7 @@ func (s *OrderSimulator) SimulateOpen(
 //  1. 計算關閉的幣數（核心邏輯）
 //  2. 計算基於開倉價的盈虧
 //  3. 計算基於平均成本的盈虧
-//  4. 計算手續費和實際收入
+//  4. 計算手續費、實際收入和滑點成本（成交價 = closePrice × (1 - slippage)）
 //  5. 返回完整的 CloseResult
 //
 // 參數：
@@ -150,21 +272,29 @@ func (s *OrderSimulator) SimulateClose(
 ) (CloseResult, error) {
 	// 1. 驗證輸入
 	if closePrice <= 0 {
-		return CloseResult{}, errors.New("close price must be positive")
+		return CloseResult{}, fmt.Errorf("%w: close price must be positive", ErrInvalidClosePrice)
 	}
 	if avgCost <= 0 {
 		return CloseResult{}, errors.New("avgCost must be positive")
 	}
 
+	// ⭐ 滑點：賣出成交價低於建議價（idealClosePrice 用於計算滑點成本）
+	idealClosePrice := closePrice
+	if s.slippage != 0 {
+		closePrice = decimal.NewFromFloat(closePrice).Mul(decimal.NewFromFloat(1 - s.slippage)).InexactFloat64()
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	positionSizeD := decimal.NewFromFloat(position.Size)
 	entryPriceD := decimal.NewFromFloat(position.EntryPrice)
-	feeRateD := decimal.NewFromFloat(s.feeRate)
 
 	// ⭐ 2. 計算關閉的幣數（核心邏輯 - 必須用 EntryPrice）
 	// 重要：pos.Size 是該筆開倉投入的 USDT 金額
-	// 該筆開倉實際買入的幣數 = Size / EntryPrice
+	// 該筆開倉實際買入的幣數 = Size / EntryPrice（base 手續費模式下為扣費後的 Coins）
 	closedCoinsD := positionSizeD.Div(entryPriceD)
+	if position.Coins > 0 {
+		closedCoinsD = decimal.NewFromFloat(position.Coins)
+	}
 	closedCoins := closedCoinsD.InexactFloat64()
 
 	// ⭐ 3. 使用 PnLCalculator 計算兩套盈虧 (Single Source of Truth)
@@ -189,12 +319,16 @@ func (s *OrderSimulator) SimulateClose(
 
 	// closeValue = position.Size + pnlAmount（平倉時的總價值：本金 + 盈虧）
 	closeValueD := positionSizeD.Add(pnlAmountD)
+	if position.Coins > 0 {
+		// base 模式：開倉手續費已從幣中扣除，平倉價值 = 實際持有幣數 × 平倉價
+		closeValueD = closedCoinsD.Mul(decimal.NewFromFloat(closePrice))
+	}
 
-	// closeFee = closeValue * feeRate（平倉手續費基於總價值）
-	closeFeeD := closeValueD.Mul(feeRateD)
+	// closeFee = closeValue * feeRate（平倉手續費基於總價值，不低於最低手續費）
+	closeFeeD := s.Fee(closeValueD)
 
-	// openFee = position.Size * feeRate（開倉手續費）
-	openFeeD := positionSizeD.Mul(feeRateD)
+	// openFee = position.Size * feeRate（開倉手續費；base 模式下為按開倉價折算的扣幣價值）
+	openFeeD := s.Fee(positionSizeD)
 
 	// realizedPnL = pnlAmount_Avg - openFee - closeFee（已實現盈虧，基於平均成本）
 	realizedPnLD := pnlAmountAvgD.Sub(openFeeD).Sub(closeFeeD)
@@ -204,6 +338,13 @@ func (s *OrderSimulator) SimulateClose(
 	// （開倉手續費已在開倉時扣除，這裡只扣平倉手續費）
 	revenueD := closeValueD.Sub(closeFeeD)
 
+	// ⭐ 滑點成本：同樣的幣數按建議價成交（零滑點）的收入 - 實際收入
+	slippageCostD := decimal.Zero
+	if s.slippage != 0 {
+		idealValueD := closedCoinsD.Mul(decimal.NewFromFloat(idealClosePrice))
+		slippageCostD = idealValueD.Sub(s.Fee(idealValueD)).Sub(revenueD)
+	}
+
 	// 7. 創建已平倉記錄
 	closedPosition := ClosedPosition{
 		Position:     position,
@@ -223,5 +364,6 @@ func (s *OrderSimulator) SimulateClose(
 		CloseFee:       closeFeeD.InexactFloat64(),
 		CloseValue:     closeValueD.InexactFloat64(),
 		Revenue:        revenueD.InexactFloat64(),
+		SlippageCost:   slippageCostD.InexactFloat64(),
 	}, nil
 }
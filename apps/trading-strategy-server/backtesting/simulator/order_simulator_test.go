@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,7 +74,7 @@ func TestOrderSimulator_SimulateOpen_InsufficientBalance(t *testing.T) {
 
 	// 驗證錯誤
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "insufficient balance")
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
 
 	t.Logf("✅ Insufficient balance check passed")
 }
@@ -94,11 +95,65 @@ func TestOrderSimulator_SimulateOpen_ShouldNotOpen(t *testing.T) {
 
 	// 驗證錯誤
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "should not open")
+	assert.ErrorIs(t, err, ErrShouldNotOpen)
 
 	t.Logf("✅ Should not open check passed")
 }
 
+// TestOrderSimulator_SimulateOpen_InvalidInputs 測試非正數價格和倉位大小
+func TestOrderSimulator_SimulateOpen_InvalidInputs(t *testing.T) {
+	simulator := NewOrderSimulator(OKXTakerFeeRate, 0)
+
+	tests := []struct {
+		name   string
+		advice OpenAdvice
+		errMsg string
+		want   error
+	}{
+		{
+			name:   "零開倉價",
+			advice: OpenAdvice{ShouldOpen: true, OpenPrice: "0", ClosePrice: "2503.75", PositionSize: 200},
+			errMsg: "open price must be positive",
+			want:   ErrInvalidOpenPrice,
+		},
+		{
+			name:   "負開倉價",
+			advice: OpenAdvice{ShouldOpen: true, OpenPrice: "-2500", ClosePrice: "2503.75", PositionSize: 200},
+			errMsg: "open price must be positive",
+			want:   ErrInvalidOpenPrice,
+		},
+		{
+			name:   "零平倉價",
+			advice: OpenAdvice{ShouldOpen: true, OpenPrice: "2500", ClosePrice: "0", PositionSize: 200},
+			errMsg: "close price must be positive",
+			want:   ErrInvalidClosePrice,
+		},
+		{
+			name:   "零倉位",
+			advice: OpenAdvice{ShouldOpen: true, OpenPrice: "2500", ClosePrice: "2503.75", PositionSize: 0},
+			errMsg: "position size must be positive",
+			want:   ErrInvalidPositionSize,
+		},
+		{
+			name:   "負倉位",
+			advice: OpenAdvice{ShouldOpen: true, OpenPrice: "2500", ClosePrice: "2503.75", PositionSize: -200},
+			errMsg: "position size must be positive",
+			want:   ErrInvalidPositionSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cost, err := simulator.SimulateOpen(tt.advice, 10000.0, time.Now())
+
+			assert.Error(t, err)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Contains(t, err.Error(), tt.errMsg)
+			assert.Equal(t, 0.0, cost)
+		})
+	}
+}
+
 // TestOrderSimulator_SimulateClose_Profit 測試盈利平倉
 func TestOrderSimulator_SimulateClose_Profit(t *testing.T) {
 	simulator := NewOrderSimulator(OKXTakerFeeRate, 0)
@@ -244,7 +299,7 @@ func TestOrderSimulator_SimulateClose_InvalidPrice(t *testing.T) {
 
 	// 驗證錯誤
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "close price must be positive")
+	assert.ErrorIs(t, err, ErrInvalidClosePrice)
 
 	t.Logf("✅ Invalid close price check passed")
 }
@@ -297,4 +352,256 @@ func TestOrderSimulator_CompleteTradeFlow(t *testing.T) {
 	t.Logf("   Initial: 10000.00 → Final: %.2f USDT", balance)
 	t.Logf("   Net Profit: %.2f USDT", balance-10000.0)
 	t.Logf("   Win Rate: 100%% (1/1 profitable)")
-}
\ No newline at end of file
+}
+
+// TestSimulateOpen_DeterministicIDs 測試持倉ID由遞增序號生成，不依賴系統時間
+func TestSimulateOpen_DeterministicIDs(t *testing.T) {
+	advice := OpenAdvice{
+		ShouldOpen:   true,
+		OpenPrice:    "2500.00",
+		ClosePrice:   "2503.75",
+		PositionSize: 200.0,
+	}
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := NewOrderSimulator(0.0005, 0)
+	second := NewOrderSimulator(0.0005, 0)
+	for i := 0; i < 3; i++ {
+		a, _, errA := first.SimulateOpen(advice, 10000, openTime)
+		b, _, errB := second.SimulateOpen(advice, 10000, openTime)
+		assert.NoError(t, errA)
+		assert.NoError(t, errB)
+		assert.Equal(t, FormatPositionID(i), a.ID)
+		assert.Equal(t, a.ID, b.ID)
+	}
+}
+
+// TestOrderSimulator_FeeCurrency_RoundTrip 比較 quote / base 手續費模式的完整交易
+//
+// 開倉 200 USDT @ 2500，平倉 @ 2510，手續費率 0.05%：
+//   - quote: 開倉額外扣 0.1 USDT，持有 0.08 個幣
+//   - base:  開倉只扣 200 USDT，手續費以幣支付，持有 0.08 × (1 - 0.0005) = 0.07996 個幣
+//
+// 兩種模式的幣數差額就是開倉手續費（0.00004 個幣 ≈ 0.1 USDT），
+// 平倉價值因此少 0.00004 × 2510 = 0.1004 USDT
+func TestOrderSimulator_FeeCurrency_RoundTrip(t *testing.T) {
+	advice := OpenAdvice{
+		ShouldOpen:   true,
+		OpenPrice:    "2500.00",
+		ClosePrice:   "2510.00",
+		PositionSize: 200.0,
+	}
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	closeTime := openTime.Add(time.Hour)
+
+	type roundTrip struct {
+		cost     float64
+		coins    float64
+		avgCost  float64
+		result   CloseResult
+		finalBal float64
+	}
+	run := func(currency FeeCurrency) roundTrip {
+		sim := NewOrderSimulator(0.0005, 0)
+		assert.NoError(t, sim.SetFeeCurrency(currency))
+		tracker := NewPositionTracker()
+
+		pos, cost, err := sim.SimulateOpen(advice, 10000, openTime)
+		assert.NoError(t, err)
+		tracked, err := tracker.AddPositionWithCoins(pos.EntryPrice, pos.Size, pos.Coins, pos.OpenTime, pos.TargetClosePrice)
+		assert.NoError(t, err)
+
+		rt := roundTrip{cost: cost, coins: tracker.totalCoins, avgCost: tracker.CalculateAverageCost()}
+		rt.result, err = sim.SimulateClose(tracked, 2510, closeTime, rt.avgCost)
+		assert.NoError(t, err)
+		assert.NoError(t, tracker.ClosePosition(tracked.ID, 2510, closeTime, rt.result.ClosedPosition.RealizedPnL))
+		assert.InDelta(t, 0, tracker.totalCoins, 1e-12, "all coins should be sold")
+
+		rt.finalBal = 10000 - cost + rt.result.Revenue
+		return rt
+	}
+
+	quote := run(FeeCurrencyQuote)
+	base := run(FeeCurrencyBase)
+
+	// 開倉成本：base 模式不額外扣 USDT
+	assert.InDelta(t, 200.1, quote.cost, 1e-9)
+	assert.InDelta(t, 200.0, base.cost, 1e-9)
+
+	// 持有幣數：base 模式少了開倉手續費對應的幣
+	assert.InDelta(t, 0.08, quote.coins, 1e-12)
+	assert.InDelta(t, 0.07996, base.coins, 1e-12)
+	assert.InDelta(t, 0.00004, quote.coins-base.coins, 1e-12)
+
+	// 平均成本按開倉價加權，手續費單獨記賬
+	assert.InDelta(t, 2500.0, quote.avgCost, 1e-9)
+	assert.InDelta(t, 2500.0, base.avgCost, 1e-9)
+
+	// 平倉價值 = 持有幣數 × 平倉價
+	assert.InDelta(t, 200.8, quote.result.CloseValue, 1e-9)
+	assert.InDelta(t, 200.6996, base.result.CloseValue, 1e-9)
+
+	// 已實現盈虧與餘額變化一致（兩種模式都不重複扣手續費）
+	assert.InDelta(t, quote.result.ClosedPosition.RealizedPnL, quote.finalBal-10000, 1e-9)
+	assert.InDelta(t, base.result.ClosedPosition.RealizedPnL, base.finalBal-10000, 1e-9)
+}
+
+func TestOrderSimulator_SetFeeCurrency_Invalid(t *testing.T) {
+	sim := NewOrderSimulator(0.0005, 0)
+	assert.Error(t, sim.SetFeeCurrency("usd"))
+	assert.NoError(t, sim.SetFeeCurrency(""))
+}
+
+// TestOrderSimulator_NegativeFeeRebate 測試負手續費（maker 返傭）在開平倉時都計為收入
+//
+// 開倉 200 USDT @ 2500，平倉 @ 2510：
+//   - 零手續費: 已實現盈虧 = 0.8
+//   - 返傭 -0.02%: 開倉返 0.04，平倉返 200.8 × 0.0002 = 0.04016，已實現盈虧 = 0.88016
+func TestOrderSimulator_NegativeFeeRebate(t *testing.T) {
+	advice := OpenAdvice{
+		ShouldOpen:   true,
+		OpenPrice:    "2500.00",
+		ClosePrice:   "2510.00",
+		PositionSize: 200.0,
+	}
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	roundTrip := func(feeRate float64) (cost float64, result CloseResult) {
+		sim := NewOrderSimulator(feeRate, 0)
+		pos, cost, err := sim.SimulateOpen(advice, 10000, openTime)
+		assert.NoError(t, err)
+		result, err = sim.SimulateClose(pos, 2510, openTime.Add(time.Hour), pos.EntryPrice)
+		assert.NoError(t, err)
+		return cost, result
+	}
+
+	zeroCost, zero := roundTrip(0)
+	rebateCost, rebate := roundTrip(-0.0002)
+
+	// 返傭降低開倉成本、增加平倉收入
+	assert.InDelta(t, 200.0, zeroCost, 1e-9)
+	assert.InDelta(t, 199.96, rebateCost, 1e-9)
+	assert.InDelta(t, -0.04016, rebate.CloseFee, 1e-9)
+	assert.Greater(t, rebate.Revenue, zero.Revenue)
+
+	// 已實現盈虧高於零手續費
+	assert.InDelta(t, 0.8, zero.ClosedPosition.RealizedPnL, 1e-9)
+	assert.InDelta(t, 0.88016, rebate.ClosedPosition.RealizedPnL, 1e-9)
+	assert.Greater(t, rebate.ClosedPosition.RealizedPnL, zero.ClosedPosition.RealizedPnL)
+
+	// 餘額變化與已實現盈虧一致
+	assert.InDelta(t, rebate.ClosedPosition.RealizedPnL, rebate.Revenue-rebateCost, 1e-9)
+
+	// 未實現盈虧的預估平倉費同樣計為返傭
+	tracker := NewPositionTracker()
+	_, err := tracker.AddPosition(2500, 200, openTime, 2510)
+	assert.NoError(t, err)
+	assert.Greater(t, tracker.CalculateUnrealizedPnL(2510, -0.0002), tracker.CalculateUnrealizedPnL(2510, 0))
+}
+
+// TestOrderSimulator_MinFeePerOrder_TinyPosition 測試小倉位時最低手續費取代百分比手續費
+//
+// 開倉 10 USDT @ 2500，平倉 @ 2510，費率 0.05%，最低手續費 0.1 USDT：
+//   - 百分比手續費: 開倉 0.005，平倉 10.04 × 0.0005 = 0.00502
+//   - 最低手續費: 開平倉各 0.1，已實現盈虧 = 0.04 - 0.2 = -0.16（毛利不足以覆蓋手續費）
+func TestOrderSimulator_MinFeePerOrder_TinyPosition(t *testing.T) {
+	advice := OpenAdvice{
+		ShouldOpen:   true,
+		OpenPrice:    "2500.00",
+		ClosePrice:   "2510.00",
+		PositionSize: 10.0,
+	}
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	roundTrip := func(minFee float64) (cost float64, result CloseResult) {
+		sim := NewOrderSimulator(OKXTakerFeeRate, 0)
+		assert.NoError(t, sim.SetMinFeePerOrder(minFee))
+		pos, cost, err := sim.SimulateOpen(advice, 10000, openTime)
+		assert.NoError(t, err)
+		result, err = sim.SimulateClose(pos, 2510, openTime.Add(time.Hour), pos.EntryPrice)
+		assert.NoError(t, err)
+		return cost, result
+	}
+
+	percentCost, percent := roundTrip(0)
+	minCost, floored := roundTrip(0.1)
+
+	assert.InDelta(t, 10.005, percentCost, 1e-9)
+	assert.InDelta(t, 0.00502, percent.CloseFee, 1e-9)
+	assert.InDelta(t, 0.02998, percent.ClosedPosition.RealizedPnL, 1e-9)
+
+	// 最低手續費主導：開平倉各 0.1
+	assert.InDelta(t, 10.1, minCost, 1e-9)
+	assert.InDelta(t, 0.1, floored.CloseFee, 1e-9)
+	assert.InDelta(t, -0.16, floored.ClosedPosition.RealizedPnL, 1e-9)
+	assert.InDelta(t, floored.ClosedPosition.RealizedPnL, floored.Revenue-minCost, 1e-9)
+
+	// 大倉位的百分比手續費高於最低手續費時不受影響
+	sim := NewOrderSimulator(OKXTakerFeeRate, 0)
+	assert.NoError(t, sim.SetMinFeePerOrder(0.1))
+	assert.InDelta(t, 1.0, sim.Fee(decimal.NewFromInt(2000)).InexactFloat64(), 1e-9)
+	assert.InDelta(t, 2001.0, sim.EstimateOpenCost(2000).InexactFloat64(), 1e-9)
+}
+
+// TestOrderSimulator_MinFeePerOrder_BaseFee 測試 base 模式下最低手續費從收到的幣中扣除
+func TestOrderSimulator_MinFeePerOrder_BaseFee(t *testing.T) {
+	sim := NewOrderSimulator(OKXTakerFeeRate, 0)
+	assert.NoError(t, sim.SetFeeCurrency(FeeCurrencyBase))
+	assert.NoError(t, sim.SetMinFeePerOrder(0.1))
+
+	advice := OpenAdvice{ShouldOpen: true, OpenPrice: "2500.00", ClosePrice: "2510.00", PositionSize: 10.0}
+	pos, cost, err := sim.SimulateOpen(advice, 10000, time.Now())
+	assert.NoError(t, err)
+	assert.InDelta(t, 10.0, cost, 1e-9)
+	assert.InDelta(t, 9.9/2500, pos.Coins, 1e-12) // 扣除 0.1 USDT 等值的幣
+
+	// 手續費不低於倉位大小時無法開倉
+	advice.PositionSize = 0.1
+	_, _, err = sim.SimulateOpen(advice, 10000, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidPositionSize)
+
+	assert.Error(t, sim.SetMinFeePerOrder(-1))
+}
+
+// TestOrderSimulator_Slippage 測試滑點調整成交價，並逐筆計算滑點成本
+//
+// 開倉 200 USDT @ 2500，平倉 @ 2510，滑點 0.1%，費率 0.05%：
+//   - 開倉成交價 2502.5，幣數 = 200 / 2502.5；開倉滑點成本 = 幣數 × 2.5 = 200 × 0.001 / 1.001
+//   - 平倉成交價 2507.49；平倉滑點成本 = 幣數 × 2.51 × (1 - 0.0005)（成交金額變小，手續費也略少）
+func TestOrderSimulator_Slippage(t *testing.T) {
+	const slippage = 0.001
+	advice := OpenAdvice{
+		ShouldOpen:   true,
+		OpenPrice:    "2500.00",
+		ClosePrice:   "2510.00",
+		PositionSize: 200.0,
+	}
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sim := NewOrderSimulator(OKXTakerFeeRate, slippage)
+	pos, cost, err := sim.SimulateOpen(advice, 10000, openTime)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2502.5, pos.EntryPrice, 1e-9)
+	assert.InDelta(t, 200.1, cost, 1e-9) // 倉位大小固定，手續費不受滑點影響
+
+	coins := 200 / 2502.5
+	assert.InDelta(t, 200*slippage/(1+slippage), sim.OpenSlippageCost(pos).InexactFloat64(), 1e-9)
+
+	result, err := sim.SimulateClose(pos, 2510, openTime.Add(time.Hour), pos.EntryPrice)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2507.49, result.ClosedPosition.ClosePrice, 1e-9)
+	assert.InDelta(t, coins*2507.49*(1-OKXTakerFeeRate), result.Revenue, 1e-9)
+	assert.InDelta(t, coins*2.51*(1-OKXTakerFeeRate), result.SlippageCost, 1e-9)
+	assert.Greater(t, result.SlippageCost, 0.0)
+
+	// 零滑點：成交價等於建議價，沒有滑點成本
+	ideal := NewOrderSimulator(OKXTakerFeeRate, 0)
+	pos, _, err = ideal.SimulateOpen(advice, 10000, openTime)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500.0, pos.EntryPrice)
+	assert.True(t, ideal.OpenSlippageCost(pos).IsZero())
+	result, err = ideal.SimulateClose(pos, 2510, openTime.Add(time.Hour), pos.EntryPrice)
+	assert.NoError(t, err)
+	assert.Equal(t, 2510.0, result.ClosedPosition.ClosePrice)
+	assert.Zero(t, result.SlippageCost)
+}
@@ -1,6 +1,10 @@
 package simulator
 
-import "github.com/shopspring/decimal"
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
 
 // PnLCalculator 盈亏计算器
 //
@@ -26,6 +30,19 @@ func NewPnLCalculator() *PnLCalculator {
 	return &PnLCalculator{}
 }
 
+// allFinite 检查输入是否都是有限数 ⭐
+//
+// NaN/Inf 无法转换为 decimal，且会沿着 avgCost 污染之后的所有计算，
+// 因此计算器遇到非有限输入时直接返回 0
+func allFinite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
 // CalculatePriceChangeRate 计算价格变化率
 //
 // 公式: (currentPrice - basePrice) / basePrice
@@ -34,13 +51,17 @@ func NewPnLCalculator() *PnLCalculator {
 //   - currentPrice: 当前价格（或平仓价格）
 //   - basePrice: 基准价格（开仓价或平均成本）
 //
-// 返回: 价格变化率（小数形式，如 0.02 表示 2%）
+// 返回: 价格变化率（小数形式，如 0.02 表示 2%）；输入为 NaN/Inf 时返回 0
 //
 // 示例:
 //
 //	rate := calc.CalculatePriceChangeRate(2510, 2500)
 //	// rate = 0.004 (0.4%)
 func (pc *PnLCalculator) CalculatePriceChangeRate(currentPrice, basePrice float64) float64 {
+	if !allFinite(currentPrice, basePrice) {
+		return 0
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	currentPriceD := decimal.NewFromFloat(currentPrice)
 	basePriceD := decimal.NewFromFloat(basePrice)
@@ -58,13 +79,17 @@ func (pc *PnLCalculator) CalculatePriceChangeRate(currentPrice, basePrice float6
 //   - currentPrice: 当前价格（或平仓价格）
 //   - basePrice: 基准价格（开仓价或平均成本）
 //
-// 返回: 价格变化百分比（如 2.5 表示 2.5%）
+// 返回: 价格变化百分比（如 2.5 表示 2.5%）；输入为 NaN/Inf 时返回 0
 //
 // 示例:
 //
 //	percent := calc.CalculatePriceChangePercent(2510, 2500)
 //	// percent = 0.4 (0.4%)
 func (pc *PnLCalculator) CalculatePriceChangePercent(currentPrice, basePrice float64) float64 {
+	if !allFinite(currentPrice, basePrice) {
+		return 0
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	currentPriceD := decimal.NewFromFloat(currentPrice)
 	basePriceD := decimal.NewFromFloat(basePrice)
@@ -85,6 +110,7 @@ func (pc *PnLCalculator) CalculatePriceChangePercent(currentPrice, basePrice flo
 // 返回:
 //   - pnlAmount: 盈亏金额（未扣手续费）
 //   - pnlPercent: 盈亏百分比
+//   - 任一输入为 NaN/Inf 时两者都返回 0
 //
 // 用途说明:
 //   1. 基于单笔开仓价（分析单笔交易表现）:
@@ -103,6 +129,10 @@ func (pc *PnLCalculator) CalculatePriceChangePercent(currentPrice, basePrice flo
 //	amount, percent := calc.CalculatePnL(2510, 2490, 0.08)
 //	// amount = 1.6 USDT, percent = 0.8%
 func (pc *PnLCalculator) CalculatePnL(closePrice, basePrice, coins float64) (pnlAmount, pnlPercent float64) {
+	if !allFinite(closePrice, basePrice, coins) {
+		return 0, 0
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	closePriceD := decimal.NewFromFloat(closePrice)
 	basePriceD := decimal.NewFromFloat(basePrice)
@@ -0,0 +1,32 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPnLCalculator_NonFiniteInputs 测试 NaN/Inf 输入返回 0，不会 panic 或传播
+func TestPnLCalculator_NonFiniteInputs(t *testing.T) {
+	calc := NewPnLCalculator()
+	nonFinite := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+
+	for _, bad := range nonFinite {
+		assert.Equal(t, 0.0, calc.CalculatePriceChangeRate(bad, 2500))
+		assert.Equal(t, 0.0, calc.CalculatePriceChangeRate(2510, bad))
+		assert.Equal(t, 0.0, calc.CalculatePriceChangePercent(bad, 2500))
+		assert.Equal(t, 0.0, calc.CalculatePriceChangePercent(2510, bad))
+
+		for _, args := range [][3]float64{{bad, 2500, 0.08}, {2510, bad, 0.08}, {2510, 2500, bad}} {
+			amount, percent := calc.CalculatePnL(args[0], args[1], args[2])
+			assert.Equal(t, 0.0, amount, "amount for %v", args)
+			assert.Equal(t, 0.0, percent, "percent for %v", args)
+		}
+	}
+
+	// 有限输入不受影响
+	amount, percent := calc.CalculatePnL(2510, 2500, 0.08)
+	assert.InDelta(t, 0.8, amount, 1e-12)
+	assert.InDelta(t, 0.4, percent, 1e-12)
+}
@@ -14,6 +14,19 @@ type Position struct {
 	Size             float64   // 倉位大小（美元）
 	OpenTime         time.Time // 開倉時間
 	TargetClosePrice float64   // 目標平倉價格
+	Coins            float64   // 實際持有幣數（0 = Size / EntryPrice；base 手續費模式下已扣除開倉手續費）⭐
+	Tag              string    // 開倉標籤（策略設置，平倉後隨 ClosedPosition 保留，用於 PnLByTag）⭐
+}
+
+// coinsDecimal 返回該倉位實際持有的幣數
+//
+// 未記錄 Coins 時（quote 手續費模式）按 Size / EntryPrice 計算：
+// Size 是該筆開倉投入的 USDT 金額，實際買入的幣數 = Size / EntryPrice
+func (p Position) coinsDecimal() decimal.Decimal {
+	if p.Coins > 0 {
+		return decimal.NewFromFloat(p.Coins)
+	}
+	return decimal.NewFromFloat(p.Size).Div(decimal.NewFromFloat(p.EntryPrice))
 }
 
 // ClosedPosition 已平倉記錄
@@ -25,6 +38,13 @@ type ClosedPosition struct {
 	HoldDuration time.Duration // 持倉時長
 }
 
+// FormatPositionID 按序號生成持倉ID ⭐
+//
+// 持倉ID只由遞增序號決定（不使用系統時間），同一份數據重複回測得到完全相同的ID和交易日誌
+func FormatPositionID(seq int) string {
+	return fmt.Sprintf("pos_%d", seq)
+}
+
 // PositionTracker 倉位追蹤器
 type PositionTracker struct {
 	openPositions   []Position       // 未平倉持倉
@@ -48,12 +68,50 @@ func NewPositionTracker() *PositionTracker {
 }
 
 // AddPosition 添加新持倉（使用累進式計算平均成本）⭐
+//
+// 開倉價或倉位大小非正數時返回錯誤（避免 size/entryPrice 產生 +Inf 污染平均成本）
 func (pt *PositionTracker) AddPosition(
 	entryPrice float64,
 	size float64,
 	openTime time.Time,
 	targetClosePrice float64,
-) Position {
+) (Position, error) {
+	return pt.AddPositionWithCoins(entryPrice, size, 0, openTime, targetClosePrice)
+}
+
+// AddPositionWithCoins 添加持倉並指定實際買入的幣數 ⭐
+//
+// coins <= 0 時按 Size / EntryPrice 計算（與 AddPosition 相同）。
+// base 手續費模式下開倉手續費以幣支付，實際幣數少於 Size / EntryPrice，
+// 平均成本仍按開倉價加權（手續費單獨記賬），平倉時只能賣出實際持有的幣數
+func (pt *PositionTracker) AddPositionWithCoins(
+	entryPrice float64,
+	size float64,
+	coins float64,
+	openTime time.Time,
+	targetClosePrice float64,
+) (Position, error) {
+	return pt.AddPositionWithTag(entryPrice, size, coins, openTime, targetClosePrice, "")
+}
+
+// AddPositionWithTag 添加持倉並記錄開倉標籤 ⭐
+//
+// 與 AddPositionWithCoins 相同，tag 保存在 Position.Tag（可為空）
+func (pt *PositionTracker) AddPositionWithTag(
+	entryPrice float64,
+	size float64,
+	coins float64,
+	openTime time.Time,
+	targetClosePrice float64,
+	tag string,
+) (Position, error) {
+	if entryPrice <= 0 {
+		return Position{}, fmt.Errorf("%w: entry price must be positive", ErrInvalidOpenPrice)
+	}
+	if size <= 0 {
+		return Position{}, fmt.Errorf("%w: position size must be positive", ErrInvalidPositionSize)
+	}
+
 	// ⭐ 使用 decimal 計算，避免浮點誤差
 	entryPriceD := decimal.NewFromFloat(entryPrice)
 	sizeD := decimal.NewFromFloat(size)
@@ -62,6 +120,9 @@ func (pt *PositionTracker) AddPosition(
 
 	// ⭐ 計算新買入的幣數
 	newCoinsD := sizeD.Div(entryPriceD)
+	if coins > 0 {
+		newCoinsD = decimal.NewFromFloat(coins)
+	}
 
 	// ⭐ 累進公式更新平均成本
 	// 新平均成本 = (原平均成本 × 原幣數 + 新價格 × 新幣數) / (原幣數 + 新幣數)
@@ -80,17 +141,21 @@ func (pt *PositionTracker) AddPosition(
 
 	// 創建持倉記錄
 	position := Position{
-		ID:               fmt.Sprintf("pos_%d", pt.nextID),
+		ID:               FormatPositionID(pt.nextID),
 		EntryPrice:       entryPrice,
 		Size:             size,
 		OpenTime:         openTime,
 		TargetClosePrice: targetClosePrice,
+		Tag:              tag,
+	}
+	if coins > 0 {
+		position.Coins = coins
 	}
 
 	pt.openPositions = append(pt.openPositions, position)
 	pt.nextID++
 
-	return position
+	return position, nil
 }
 
 // ClosePosition 平倉（關倉只減少幣數，不改變平均成本）⭐
@@ -113,20 +178,18 @@ func (pt *PositionTracker) ClosePosition(
 	}
 
 	if foundIndex == -1 {
-		return fmt.Errorf("position not found: %s", positionID)
+		return fmt.Errorf("%w: %s", ErrPositionNotFound, positionID)
 	}
 
 	// ⭐ 使用 decimal 計算，避免浮點誤差
-	sizeD := decimal.NewFromFloat(position.Size)
-	entryPriceD := decimal.NewFromFloat(position.EntryPrice)
 	totalCoinsD := decimal.NewFromFloat(pt.totalCoins)
 
 	// ⭐ 計算減少的幣數（用該倉位的開倉價計算）
 	// 重要：必須用 EntryPrice 而不是 avgCost，因為：
 	// - position.Size 是該筆開倉投入的 USDT 金額
-	// - 該筆開倉實際買入的幣數 = Size / EntryPrice
+	// - 該筆開倉實際買入的幣數 = Size / EntryPrice（base 手續費模式下為扣費後的 Coins）
 	// - 平倉時應該平掉實際買入的幣數，而不是用平均成本計算的幣數
-	closedCoinsD := sizeD.Div(entryPriceD)
+	closedCoinsD := position.coinsDecimal()
 
 	// ⭐ 只減少總幣數，平均成本不變
 	newTotalCoinsD := totalCoinsD.Sub(closedCoinsD)
@@ -178,10 +241,42 @@ func (pt *PositionTracker) GetOpenPositionCount() int {
 }
 
 // CalculateAverageCost 計算平均成本（直接返回累進計算的結果）⭐
+//
+// 按幣數加權：Σ(開倉價 × 幣數) / Σ幣數。未實現盈虧、打平和平倉盈虧都以此計算，
+// 因為盈虧 = 幣數 × (價格 - 成本)，只有幣數加權的成本能讓 totalCoins × (price - avgCost) 等於逐倉盈虧之和
 func (pt *PositionTracker) CalculateAverageCost() float64 {
 	return pt.avgCost
 }
 
+// NotionalWeightedAvgEntry 按開倉金額（USDT）加權的平均開倉價（僅用於報告）⭐
+//
+// = Σ(開倉價 × Size) / ΣSize，沒有未平倉位時返回 0。
+// 每筆開倉金額相同時，等於各筆開倉價的算術平均，符合「每格投入固定 USDT」的直覺；
+// 同樣的 USDT 在低價買到更多幣，所以幣數加權的 CalculateAverageCost 總是不高於此值（開倉價都相同時相等）。
+// 計算盈虧請使用 CalculateAverageCost
+func (pt *PositionTracker) NotionalWeightedAvgEntry() float64 {
+	weightedD := decimal.Zero
+	totalSizeD := decimal.Zero
+	for _, pos := range pt.openPositions {
+		sizeD := decimal.NewFromFloat(pos.Size)
+		weightedD = weightedD.Add(decimal.NewFromFloat(pos.EntryPrice).Mul(sizeD))
+		totalSizeD = totalSizeD.Add(sizeD)
+	}
+	if !totalSizeD.IsPositive() {
+		return 0
+	}
+	return weightedD.Div(totalSizeD).InexactFloat64()
+}
+
+// OpenEntryPrices 未平倉位的開倉價（按開倉順序，用於 PositionSummary.WithEntryPrices）
+func (pt *PositionTracker) OpenEntryPrices() []float64 {
+	prices := make([]float64, len(pt.openPositions))
+	for i, pos := range pt.openPositions {
+		prices[i] = pos.EntryPrice
+	}
+	return prices
+}
+
 // CalculateUnrealizedPnL 計算未實現盈虧（含預估平倉手續費）
 //
 // ⭐ 使用平均成本計算（avgCost），而非逐個倉位的入場價格
@@ -280,3 +375,32 @@ func (pt *PositionTracker) GetWinRate() float64 {
 	totalCountD := decimal.NewFromInt(int64(len(pt.closedPositions)))
 	return winCountD.Div(totalCountD).InexactFloat64()
 }
+
+// TrackerSnapshot 倉位追蹤器的完整狀態（用於回測斷點續跑）⭐
+type TrackerSnapshot struct {
+	OpenPositions   []Position       // 未平倉持倉
+	ClosedPositions []ClosedPosition // 已平倉記錄
+	NextID          int              // 下一個持倉ID
+	AvgCost         float64          // 累進的平均成本
+	TotalCoins      float64          // 總持倉幣數
+}
+
+// Snapshot 導出當前狀態（切片為副本，之後的開平倉不影響快照）
+func (pt *PositionTracker) Snapshot() TrackerSnapshot {
+	return TrackerSnapshot{
+		OpenPositions:   append([]Position{}, pt.openPositions...),
+		ClosedPositions: append([]ClosedPosition{}, pt.closedPositions...),
+		NextID:          pt.nextID,
+		AvgCost:         pt.avgCost,
+		TotalCoins:      pt.totalCoins,
+	}
+}
+
+// Restore 用快照覆蓋當前狀態
+func (pt *PositionTracker) Restore(snapshot TrackerSnapshot) {
+	pt.openPositions = append(make([]Position, 0, len(snapshot.OpenPositions)), snapshot.OpenPositions...)
+	pt.closedPositions = append(make([]ClosedPosition, 0, len(snapshot.ClosedPositions)), snapshot.ClosedPositions...)
+	pt.nextID = snapshot.NextID
+	pt.avgCost = snapshot.AvgCost
+	pt.totalCoins = snapshot.TotalCoins
+}
@@ -1,6 +1,7 @@
 package simulator
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -9,7 +10,7 @@ func TestPositionTracker_AddPosition(t *testing.T) {
 	tracker := NewPositionTracker()
 
 	// 添加第一個持倉
-	pos1 := tracker.AddPosition(2500, 200, time.Now(), 2510)
+	pos1, _ := tracker.AddPosition(2500, 200, time.Now(), 2510)
 
 	if pos1.ID != "pos_1" {
 		t.Errorf("Expected ID pos_1, got %s", pos1.ID)
@@ -20,7 +21,7 @@ func TestPositionTracker_AddPosition(t *testing.T) {
 	}
 
 	// 添加第二個持倉
-	pos2 := tracker.AddPosition(2505, 200, time.Now(), 2515)
+	pos2, _ := tracker.AddPosition(2505, 200, time.Now(), 2515)
 
 	if pos2.ID != "pos_2" {
 		t.Errorf("Expected ID pos_2, got %s", pos2.ID)
@@ -58,6 +59,33 @@ func TestPositionTracker_CalculateAverageCost(t *testing.T) {
 	t.Logf("✅ Average cost: %.2f (expected: %.2f)", avgCost, expected)
 }
 
+// TestPositionTracker_NotionalWeightedAvgEntry 測試金額加權與幣數加權的平均開倉價
+//
+// 200 USDT @ 2000（0.1 幣）+ 200 USDT @ 3000（0.0667 幣）：
+// - 幣數加權（CalculateAverageCost）= 400 / 0.1667 = 2400
+// - 金額加權（NotionalWeightedAvgEntry）= (2000 + 3000) / 2 = 2500
+func TestPositionTracker_NotionalWeightedAvgEntry(t *testing.T) {
+	tracker := NewPositionTracker()
+	if got := tracker.NotionalWeightedAvgEntry(); got != 0 {
+		t.Errorf("Expected 0 without open positions, got %.4f", got)
+	}
+
+	tracker.AddPosition(2000, 200, time.Now(), 2010)
+	tracker.AddPosition(3000, 200, time.Now(), 3010)
+
+	avgCost := tracker.CalculateAverageCost()
+	if diff := avgCost - 2400; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected coin-weighted average 2400, got %.6f", avgCost)
+	}
+	notional := tracker.NotionalWeightedAvgEntry()
+	if diff := notional - 2500; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected notional-weighted average 2500, got %.6f", notional)
+	}
+	if notional <= avgCost {
+		t.Errorf("Expected notional-weighted average (%.2f) above coin-weighted (%.2f)", notional, avgCost)
+	}
+}
+
 func TestPositionTracker_CalculateUnrealizedPnL(t *testing.T) {
 	tracker := NewPositionTracker()
 	feeRate := 0.0005 // 0.05%
@@ -93,7 +121,7 @@ func TestPositionTracker_ClosePosition(t *testing.T) {
 	tracker := NewPositionTracker()
 
 	// 開倉
-	pos := tracker.AddPosition(2500, 200, time.Now(), 2510)
+	pos, _ := tracker.AddPosition(2500, 200, time.Now(), 2510)
 
 	if tracker.GetOpenPositionCount() != 1 {
 		t.Fatal("Expected 1 open position")
@@ -134,19 +162,19 @@ func TestPositionTracker_GetWinRate(t *testing.T) {
 	// 3 個盈利，2 個虧損
 	now := time.Now()
 
-	pos1 := tracker.AddPosition(2500, 200, now, 2510)
+	pos1, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos1.ID, 2510, now.Add(1*time.Minute), 0.56) // 盈利
 
-	pos2 := tracker.AddPosition(2500, 200, now, 2510)
+	pos2, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos2.ID, 2490, now.Add(2*time.Minute), -0.80) // 虧損
 
-	pos3 := tracker.AddPosition(2500, 200, now, 2510)
+	pos3, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos3.ID, 2515, now.Add(3*time.Minute), 1.20) // 盈利
 
-	pos4 := tracker.AddPosition(2500, 200, now, 2510)
+	pos4, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos4.ID, 2505, now.Add(4*time.Minute), 0.40) // 盈利
 
-	pos5 := tracker.AddPosition(2500, 200, now, 2510)
+	pos5, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos5.ID, 2485, now.Add(5*time.Minute), -1.20) // 虧損
 
 	winRate := tracker.GetWinRate()
@@ -164,13 +192,13 @@ func TestPositionTracker_GetTotalRealizedPnL(t *testing.T) {
 	now := time.Now()
 
 	// 添加多個交易
-	pos1 := tracker.AddPosition(2500, 200, now, 2510)
+	pos1, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos1.ID, 2510, now.Add(1*time.Minute), 0.56)
 
-	pos2 := tracker.AddPosition(2500, 200, now, 2510)
+	pos2, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos2.ID, 2490, now.Add(2*time.Minute), -0.80)
 
-	pos3 := tracker.AddPosition(2500, 200, now, 2510)
+	pos3, _ := tracker.AddPosition(2500, 200, now, 2510)
 	tracker.ClosePosition(pos3.ID, 2515, now.Add(3*time.Minute), 1.20)
 
 	totalPnL := tracker.CalculateTotalRealizedPnL()
@@ -192,7 +220,7 @@ func TestPositionTracker_ClosePosition_MultiPosition(t *testing.T) {
 	tracker := NewPositionTracker()
 
 	// 開倉1: 100 USDT @ 2500 → 應該買入 0.04 BTC
-	pos1 := tracker.AddPosition(2500, 100, time.Now(), 2600)
+	pos1, _ := tracker.AddPosition(2500, 100, time.Now(), 2600)
 	expectedCoins1 := 100.0 / 2500.0 // 0.04 BTC
 
 	if tracker.totalCoins != expectedCoins1 {
@@ -271,7 +299,7 @@ func TestUnrealizedPnL_AfterPartialClose_Bug(t *testing.T) {
 	tracker := NewPositionTracker()
 
 	// 開倉1: 100 USDT @ 2500 → 買入 0.04 BTC
-	pos1 := tracker.AddPosition(2500, 100, time.Now(), 2600)
+	pos1, _ := tracker.AddPosition(2500, 100, time.Now(), 2600)
 	coins1 := 100.0 / 2500.0 // 0.04 BTC
 
 	// 開倉2: 100 USDT @ 2600 → 買入 0.0385 BTC
@@ -377,7 +405,7 @@ func TestUnrealizedPnL_NoPartialClose(t *testing.T) {
 // TestPositionTracker_ClosePosition_CoinsCalculation 對比正確/錯誤的幣數計算
 func TestPositionTracker_ClosePosition_CoinsCalculation(t *testing.T) {
 	tracker := NewPositionTracker()
-	pos1 := tracker.AddPosition(2500, 100, time.Now(), 2600)
+	pos1, _ := tracker.AddPosition(2500, 100, time.Now(), 2600)
 	tracker.AddPosition(2600, 100, time.Now(), 2700)
 
 	// 第一筆開倉買入的實際幣數
@@ -404,3 +432,60 @@ func TestPositionTracker_ClosePosition_CoinsCalculation(t *testing.T) {
 
 	t.Logf("✅ 正確減少了 %.6f BTC", actualReduction)
 }
+
+// TestPositionTracker_AddPosition_InvalidInputs 測試非正數開倉價和倉位大小
+func TestPositionTracker_AddPosition_InvalidInputs(t *testing.T) {
+	tests := []struct {
+		name       string
+		entryPrice float64
+		size       float64
+		want       error
+	}{
+		{"零開倉價", 0, 200, ErrInvalidOpenPrice},
+		{"負開倉價", -2500, 200, ErrInvalidOpenPrice},
+		{"零倉位", 2500, 0, ErrInvalidPositionSize},
+		{"負倉位", 2500, -200, ErrInvalidPositionSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPositionTracker()
+			tracker.AddPosition(2500, 200, time.Now(), 2510)
+
+			_, err := tracker.AddPosition(tt.entryPrice, tt.size, time.Now(), 2510)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+
+			// 驗證追蹤器狀態未被污染
+			if len(tracker.GetOpenPositions()) != 1 {
+				t.Errorf("Expected 1 open position, got %d", len(tracker.GetOpenPositions()))
+			}
+			if tracker.avgCost != 2500 {
+				t.Errorf("Expected avgCost 2500, got %f", tracker.avgCost)
+			}
+			if tracker.totalCoins != 200.0/2500.0 {
+				t.Errorf("Expected totalCoins %f, got %f", 200.0/2500.0, tracker.totalCoins)
+			}
+		})
+	}
+}
+
+// TestPositionTracker_ClosePosition_NotFound 測試平掉不存在的持倉
+func TestPositionTracker_ClosePosition_NotFound(t *testing.T) {
+	tracker := NewPositionTracker()
+	pos, _ := tracker.AddPosition(2500, 200, time.Now(), 2510)
+
+	err := tracker.ClosePosition("pos_missing", 2510, time.Now(), 0)
+	if !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("Expected ErrPositionNotFound, got %v", err)
+	}
+
+	// 重複平倉同一持倉也返回 ErrPositionNotFound
+	if err := tracker.ClosePosition(pos.ID, 2510, time.Now(), 0.5); err != nil {
+		t.Fatalf("Failed to close position: %v", err)
+	}
+	if err := tracker.ClosePosition(pos.ID, 2510, time.Now(), 0.5); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("Expected ErrPositionNotFound for closed position, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package simulator
+
+import "sort"
+
+// TakeProfitOrder 止盈隊列中的一個倉位（已按策略算出實際止盈價）
+type TakeProfitOrder struct {
+	Position Position
+	Target   float64 // 實際止盈價（ProfitVsAverageCost 時按平均成本重算）
+}
+
+// TakeProfitQueue 按價格-時間優先排列止盈單 ⭐
+//
+// 同一根K線內多個止盈價被觸及時，平倉順序與交易所撮合一致：
+//  1. 止盈價低的先成交（價格上漲時先被觸及）
+//  2. 止盈價相同時，開倉早的先成交
+//  3. 開倉時間也相同時，保持輸入順序
+//
+// target 返回倉位的實際止盈價（回測引擎和模擬盤都傳入 GridAggregate.TakeProfitTarget）；
+// 返回新切片，不修改 positions
+func TakeProfitQueue(positions []Position, target func(Position) float64) []TakeProfitOrder {
+	queue := make([]TakeProfitOrder, len(positions))
+	for i, pos := range positions {
+		queue[i] = TakeProfitOrder{Position: pos, Target: target(pos)}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		if queue[i].Target != queue[j].Target {
+			return queue[i].Target < queue[j].Target
+		}
+		return queue[i].Position.OpenTime.Before(queue[j].Position.OpenTime)
+	})
+	return queue
+}
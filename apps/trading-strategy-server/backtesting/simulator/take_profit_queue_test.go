@@ -0,0 +1,45 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTakeProfitQueue_PriceTimePriority 測試止盈單按止盈價、再按開倉時間排序，與輸入順序無關 ⭐
+func TestTakeProfitQueue_PriceTimePriority(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	// 輸入順序打亂（例如從斷點恢復或倉位列表被重排）
+	positions := []Position{
+		{ID: "late", TargetClosePrice: 2505, OpenTime: at(20)},
+		{ID: "high", TargetClosePrice: 2510, OpenTime: at(0)},
+		{ID: "early", TargetClosePrice: 2505, OpenTime: at(5)},
+		{ID: "middle", TargetClosePrice: 2505, OpenTime: at(10)},
+		{ID: "low", TargetClosePrice: 2500, OpenTime: at(30)},
+	}
+
+	queue := TakeProfitQueue(positions, func(pos Position) float64 { return pos.TargetClosePrice })
+	want := []string{"low", "early", "middle", "late", "high"}
+	for i, order := range queue {
+		if order.Position.ID != want[i] {
+			t.Fatalf("Expected close order %v, got position %s at %d", want, order.Position.ID, i)
+		}
+	}
+	if positions[0].ID != "late" {
+		t.Error("Expected the input positions to stay unchanged")
+	}
+}
+
+// TestTakeProfitQueue_UsesTarget 測試排序使用 target 返回的止盈價，而非開倉時記錄的止盈價
+func TestTakeProfitQueue_UsesTarget(t *testing.T) {
+	positions := []Position{
+		{ID: "a", TargetClosePrice: 2500},
+		{ID: "b", TargetClosePrice: 2510},
+	}
+	targets := map[string]float64{"a": 2520, "b": 2490}
+
+	queue := TakeProfitQueue(positions, func(pos Position) float64 { return targets[pos.ID] })
+	if queue[0].Position.ID != "b" || queue[0].Target != 2490 || queue[1].Target != 2520 {
+		t.Errorf("Expected b (2490) before a (2520), got %+v", queue)
+	}
+}
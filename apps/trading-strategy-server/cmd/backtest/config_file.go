@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/profile"
+)
+
+// applyConfigFile 從 YAML/JSON 配置文件填入回測參數 ⭐
+//
+// 文件是一層鍵值對，鍵名與命令行參數相同（不帶 --），例如：
+//
+//	data: data/ETH-USDT-SWAP-5m.json
+//	initial-balance: 5000
+//	enable-trend-filter: true
+//
+// 命令行上已指定的參數保持不變（命令行優先）；未知的鍵、嵌套值或類型錯誤返回錯誤。
+// JSON 是 YAML 的子集，同一個解析器處理兩種格式。
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if _, nested := values["config"]; nested {
+		return fmt.Errorf("config file %s: nested config is not supported", path)
+	}
+	return applyOptionValues(fs, "config file "+path, values)
+}
+
+// applyProfile 從配置方案填入回測參數 ⭐
+//
+// 規則與 applyConfigFile 相同，在配置文件之後調用，因此優先級：命令行 > 配置文件 > 配置方案
+func applyProfile(fs *flag.FlagSet, p profile.Profile) error {
+	for _, name := range []string{"config", "profile", "profile-dir"} {
+		if _, nested := p.Options[name]; nested {
+			return fmt.Errorf("profile %s: option %q is not supported in a profile", p, name)
+		}
+	}
+	return applyOptionValues(fs, "profile "+p.String(), p.Options)
+}
+
+// loadProfile 從內建方案（和 dir 中的用戶方案）查找配置方案
+func loadProfile(ref, dir string) (profile.Profile, error) {
+	registry := profile.Default()
+	if dir != "" {
+		if err := registry.LoadDir(dir); err != nil {
+			return profile.Profile{}, err
+		}
+	}
+	return registry.Lookup(ref)
+}
+
+// applyOptionValues 把鍵值對填入 fs 中尚未設置的參數
+//
+// 已設置的參數（命令行或之前的來源）保持不變；未知的鍵、嵌套值或類型錯誤返回錯誤
+func applyOptionValues(fs *flag.FlagSet, source string, values map[string]any) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	// 按鍵名排序，錯誤信息穩定
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown option %q", source, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		value, err := formatConfigValue(values[name])
+		if err != nil {
+			return fmt.Errorf("%s: option %q: %w", source, name, err)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s: option %q: %w", source, name, err)
+		}
+	}
+	return nil
+}
+
+// formatConfigValue 把 YAML 標量轉為 flag 可解析的字符串
+func formatConfigValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case nil:
+		return "", fmt.Errorf("value is empty")
+	default:
+		return "", fmt.Errorf("expected a scalar value, got %T", value)
+	}
+}
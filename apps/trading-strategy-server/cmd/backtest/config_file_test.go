@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/trading-strategy-server/backtesting/engine"
+)
+
+// loadTestOptions 在獨立的 FlagSet 上解析 args，再載入配置文件
+func loadTestOptions(t *testing.T, content string, args ...string) (*cliOptions, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backtest.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	opts := registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	return opts, applyConfigFile(fs, path)
+}
+
+// TestApplyConfigFile_YAML 測試配置文件的值進入回測配置，命令行參數優先 ⭐
+func TestApplyConfigFile_YAML(t *testing.T) {
+	opts, err := loadTestOptions(t, `
+data: data/eth.json
+initial-balance: 5000
+position-size: 250
+take-profit-min: 0.002
+enable-trend-filter: true
+trend-ema-short: 100m
+red-candle-lookback: 3
+break-even-close-mode: aggregate
+`, "--position-size=150", "--bar=5m")
+	if err != nil {
+		t.Fatalf("Failed to apply config file: %v", err)
+	}
+	config, err := opts.backtestConfig()
+	if err != nil {
+		t.Fatalf("Failed to build config: %v", err)
+	}
+
+	if *opts.dataFile != "data/eth.json" {
+		t.Errorf("Expected data file from config, got %q", *opts.dataFile)
+	}
+	if config.InitialBalance != 5000 {
+		t.Errorf("Expected InitialBalance 5000, got %f", config.InitialBalance)
+	}
+	if config.PositionSize != 150 {
+		t.Errorf("Expected command line PositionSize 150 to win, got %f", config.PositionSize)
+	}
+	if config.TakeProfitMin != 0.002 {
+		t.Errorf("Expected TakeProfitMin 0.002, got %f", config.TakeProfitMin)
+	}
+	if !config.EnableTrendFilter {
+		t.Error("Expected EnableTrendFilter from config")
+	}
+	if config.TrendEMAShortWindow != 100*time.Minute {
+		t.Errorf("Expected TrendEMAShortWindow 100m, got %v", config.TrendEMAShortWindow)
+	}
+	if config.RedCandleLookback != 3 {
+		t.Errorf("Expected RedCandleLookback 3, got %d", config.RedCandleLookback)
+	}
+	if config.BreakEvenCloseMode != engine.BreakEvenCloseAggregate {
+		t.Errorf("Expected aggregate close mode, got %q", config.BreakEvenCloseMode)
+	}
+	// 文件和命令行都未指定的參數保持默認值
+	if config.FeeRate != 0.0005 {
+		t.Errorf("Expected default FeeRate, got %f", config.FeeRate)
+	}
+}
+
+// TestApplyConfigFile_JSON 測試 JSON 格式
+func TestApplyConfigFile_JSON(t *testing.T) {
+	opts, err := loadTestOptions(t, `{"initial-balance": 2000, "force-close-at-end": true}`)
+	if err != nil {
+		t.Fatalf("Failed to apply config file: %v", err)
+	}
+	if *opts.initialBalance != 2000 || !*opts.forceCloseAtEnd {
+		t.Errorf("Expected JSON values to be applied, got %f / %v", *opts.initialBalance, *opts.forceCloseAtEnd)
+	}
+}
+
+// TestApplyConfigFile_Invalid 測試未知鍵、嵌套值和類型錯誤
+func TestApplyConfigFile_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown option":  "initial-balanse: 5000\n",
+		"scalar":          "initial-balance:\n  value: 5000\n",
+		"initial-balance": "initial-balance: lots\n",
+	}
+	for want, content := range cases {
+		if _, err := loadTestOptions(t, content); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q for %q, got %v", want, content, err)
+		}
+	}
+}
+
+// loadProfileOptions 依次解析 args、載入配置文件（content 非空時）和參數方案 ref
+func loadProfileOptions(t *testing.T, content, ref, profileDir string, args ...string) (*cliOptions, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	opts := registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if content != "" {
+		path := filepath.Join(t.TempDir(), "backtest.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if err := applyConfigFile(fs, path); err != nil {
+			return nil, err
+		}
+	}
+	p, err := loadProfile(ref, profileDir)
+	if err != nil {
+		return nil, err
+	}
+	return opts, applyProfile(fs, p)
+}
+
+// TestApplyProfile_Builtin 測試載入內建參數方案，優先級：命令行 > 配置文件 > 方案 ⭐
+func TestApplyProfile_Builtin(t *testing.T) {
+	opts, err := loadProfileOptions(t, "take-profit-min: 0.0025\n", "conservative", "", "--position-size=150")
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	config, err := opts.backtestConfig()
+	if err != nil {
+		t.Fatalf("Failed to build config: %v", err)
+	}
+
+	// 方案的參數
+	if config.RedCandleLookback != 3 || config.RedCandleMinRed != 2 {
+		t.Errorf("Expected red candle filter 2 of 3 from profile, got %d of %d", config.RedCandleMinRed, config.RedCandleLookback)
+	}
+	if config.MaxDrawdownHalt != 0.2 || config.MinPriceGapBetweenOpens != 0.002 || config.MinNetProfitPerTrade != 0.05 {
+		t.Errorf("Unexpected profile parameters: halt %v, gap %v, min net profit %v",
+			config.MaxDrawdownHalt, config.MinPriceGapBetweenOpens, config.MinNetProfitPerTrade)
+	}
+	// 命令行和配置文件優先
+	if config.PositionSize != 150 {
+		t.Errorf("Expected command line PositionSize 150 to win, got %f", config.PositionSize)
+	}
+	if config.TakeProfitMin != 0.0025 {
+		t.Errorf("Expected config file TakeProfitMin 0.0025 to win, got %f", config.TakeProfitMin)
+	}
+	// 方案未指定的參數保持默認值
+	if config.FeeRate != 0.0005 {
+		t.Errorf("Expected default FeeRate, got %f", config.FeeRate)
+	}
+}
+
+// TestApplyProfile_UserDir 測試從目錄載入用戶方案並按版本選擇
+func TestApplyProfile_UserDir(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+name: aggressive
+version: 2
+description: wider target
+options:
+  take-profit-min: 0.0018
+`
+	if err := os.WriteFile(filepath.Join(dir, "aggressive-v2.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+
+	latest, err := loadProfileOptions(t, "", "aggressive", dir)
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	if *latest.takeProfitMin != 0.0018 || *latest.positionSize != 100 {
+		t.Errorf("Expected user profile v2 (tp 0.0018, default size), got tp %v size %v", *latest.takeProfitMin, *latest.positionSize)
+	}
+
+	pinned, err := loadProfileOptions(t, "", "aggressive@1", dir)
+	if err != nil {
+		t.Fatalf("Failed to load pinned profile: %v", err)
+	}
+	if *pinned.takeProfitMin != 0.0012 || *pinned.positionSize != 300 {
+		t.Errorf("Expected built-in v1 (tp 0.0012, size 300), got tp %v size %v", *pinned.takeProfitMin, *pinned.positionSize)
+	}
+
+	if _, err := loadProfileOptions(t, "", "balanced", dir); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
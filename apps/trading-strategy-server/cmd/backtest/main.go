@@ -5,39 +5,49 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"dizzycode.xyz/shared/domain/value_objects"
 	"dizzycode.xyz/trading-strategy-server/backtesting/engine"
+	"dizzycode.xyz/trading-strategy-server/backtesting/loader"
 	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/backtesting/profile"
 )
 
 func main() {
-	// 解析命令行參數
-	dataFile := flag.String("data", "", "歷史數據文件路徑 (必填)")
-	initialBalance := flag.Float64("initial-balance", 10000.0, "初始資金 (USDT)")
-	feeRate := flag.Float64("fee-rate", 0.0005, "手續費率 (默認: 0.0005 = 0.05%)")
-	positionSize := flag.Float64("position-size", 100.0, "單次開倉大小 (USDT)")
-	slippage := flag.Float64("slippage", 0.0, "滑點 (默認: 0)")
-	instID := flag.String("inst-id", "ETH-USDT-SWAP", "交易對")
-	takeProfitMin := flag.Float64("take-profit-min", 0.0015, "最小止盈百分比 (默認: 0.0015 = 0.15%)")
-	takeProfitMax := flag.Float64("take-profit-max", 0.01, "最大止盈百分比 (默認: 0.0020 = 0.20%)")
-	breakEvenProfitMin := flag.Float64("break-even-profit-min", 0.0, "打平最小目標盈利 (USDT, 默認: 0)")
-	breakEvenProfitMax := flag.Float64("break-even-profit-max", 20.0, "打平最大目標盈利 (USDT, 默認: 20)")
-	enableTrendFilter := flag.Bool("enable-trend-filter", false, "是否啟用趨勢過濾 (默認: false) ⭐")
-	enableRedCandleFilter := flag.Bool("enable-red-candle-filter", true, "是否啟用紅K過濾（虧損時只在紅K開倉，默認: true）⭐")
-	// 自動注資參數 ⭐
-	enableAutoFunding := flag.Bool("enable-auto-funding", true, "是否啟用自動注資 (默認: false)")
-	autoFundingAmount := flag.Float64("auto-funding-amount", 5000.0, "自動注資金額 (USDT, 默認: 5000)")
-	autoFundingIdle := flag.Int("auto-funding-idle", 12, "觸發注資的閒置K線數 (默認: 288 根，約1天)")
-
+	opts := registerFlags(flag.CommandLine)
 	flag.Parse()
 
+	// 載入配置文件：只填入命令行未指定的參數 ⭐
+	if *opts.configFile != "" {
+		if err := applyConfigFile(flag.CommandLine, *opts.configFile); err != nil {
+			fmt.Printf("錯誤: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 載入參數方案：只填入命令行和配置文件都未指定的參數 ⭐
+	var selectedProfile profile.Profile
+	if *opts.profile != "" {
+		p, err := loadProfile(*opts.profile, *opts.profileDir)
+		if err == nil {
+			err = applyProfile(flag.CommandLine, p)
+		}
+		if err != nil {
+			fmt.Printf("錯誤: %v\n", err)
+			os.Exit(1)
+		}
+		selectedProfile = p
+	}
+
 	// 驗證必填參數
-	if *dataFile == "" {
+	if *opts.dataFile == "" {
 		fmt.Println("錯誤: 必須指定歷史數據文件路徑")
 		fmt.Println()
 		fmt.Println("使用方式:")
-		fmt.Println("  go run cmd/backtest.go --data=data/20240930-20241001-5m-ETH-USDT-SWAP.json")
+		fmt.Println("  go run ./cmd/backtest --data=data/20240930-20241001-5m-ETH-USDT-SWAP.json")
 		fmt.Println()
 		fmt.Println("參數說明:")
 		flag.PrintDefaults()
@@ -45,8 +55,15 @@ func main() {
 	}
 
 	// 檢查文件是否存在
-	if _, err := os.Stat(*dataFile); os.IsNotExist(err) {
-		fmt.Printf("錯誤: 文件不存在: %s\n", *dataFile)
+	if _, err := os.Stat(*opts.dataFile); os.IsNotExist(err) {
+		fmt.Printf("錯誤: 文件不存在: %s\n", *opts.dataFile)
+		os.Exit(1)
+	}
+
+	// 組裝回測配置（按時間指定的 EMA 週期在此解析）
+	config, err := opts.backtestConfig()
+	if err != nil {
+		fmt.Printf("錯誤: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -54,44 +71,50 @@ func main() {
 	fmt.Println("========================================")
 	fmt.Println("回測引擎 - 配置信息")
 	fmt.Println("========================================")
-	fmt.Printf("數據文件: %s\n", *dataFile)
-	fmt.Printf("交易對: %s\n", *instID)
-	fmt.Printf("初始資金: $%.2f USDT\n", *initialBalance)
-	fmt.Printf("倉位大小: $%.2f USDT\n", *positionSize)
-	fmt.Printf("手續費率: %.4f%% (%.6f)\n", *feeRate*100, *feeRate)
-	fmt.Printf("滑點: %.4f%%\n", *slippage*100)
-	fmt.Printf("止盈範圍: %.2f%% ~ %.2f%%\n", *takeProfitMin*100, *takeProfitMax*100)
-	fmt.Printf("打平目標: $%.2f ~ $%.2f USDT\n", *breakEvenProfitMin, *breakEvenProfitMax)
-	fmt.Printf("趨勢過濾: %v ⭐\n", *enableTrendFilter)
-	fmt.Printf("紅K過濾: %v ⭐ (虧損時只在紅K開倉)\n", *enableRedCandleFilter)
-	fmt.Printf("自動注資: %v", *enableAutoFunding)
-	if *enableAutoFunding {
-		fmt.Printf(" ⭐ (金額: $%.2f, 閒置閾值: %d 根K線)\n", *autoFundingAmount, *autoFundingIdle)
+	fmt.Printf("數據文件: %s\n", *opts.dataFile)
+	if selectedProfile.Name != "" {
+		fmt.Printf("參數方案: %s (%s)\n", selectedProfile, selectedProfile.Description)
+	}
+	fmt.Printf("交易對: %s\n", *opts.instID)
+	fmt.Printf("初始資金: $%.2f USDT\n", *opts.initialBalance)
+	fmt.Printf("倉位大小: $%.2f USDT\n", *opts.positionSize)
+	fmt.Printf("手續費率: %.4f%% (%.6f, 扣除幣種: %s)\n", *opts.feeRate*100, *opts.feeRate, *opts.feeCurrency)
+	if !*opts.feesEnabled {
+		fmt.Println("免手續費模擬: 所有手續費和資金費為 0（純信號分析）⭐")
+	}
+	fmt.Printf("滑點: %.4f%%\n", *opts.slippage*100)
+	fmt.Printf("止盈範圍: %.2f%% ~ %.2f%%\n", *opts.takeProfitMin*100, *opts.takeProfitMax*100)
+	if *opts.firstEntryTakeProfit > 0 {
+		fmt.Printf("首筆止盈: %.2f%% ⭐\n", *opts.firstEntryTakeProfit*100)
+	}
+	fmt.Printf("打平目標: $%.2f ~ $%.2f USDT (平倉記錄: %s)\n", *opts.breakEvenProfitMin, *opts.breakEvenProfitMax, *opts.breakEvenCloseMode)
+	if *opts.roundProfitTarget > 0 {
+		fmt.Printf("整輪止盈: $%.2f USDT\n", *opts.roundProfitTarget)
+	}
+	fmt.Printf("趨勢過濾: %v ⭐ (價格來源: %s)\n", *opts.enableTrendFilter, *opts.trendPriceSource)
+	fmt.Printf("紅K過濾: %v ⭐ (虧損時最近 %d 根中至少 %d 根紅K才開倉)\n", *opts.enableRedCandleFilter, *opts.redCandleLookback, *opts.redCandleMinRed)
+	if *opts.minNetProfit > 0 {
+		fmt.Printf("單筆最小淨利潤: $%.4f USDT ⭐\n", *opts.minNetProfit)
+	}
+	if *opts.minPriceGap > 0 {
+		fmt.Printf("價位去重: 與已有倉位開倉價相距至少 %.3f%% ⭐\n", *opts.minPriceGap*100)
+	}
+	fmt.Printf("自動注資: %v", *opts.enableAutoFunding)
+	if *opts.enableAutoFunding {
+		if engine.AutoFundingMode(*opts.autoFundingMode) == engine.AutoFundingPercentOfNotional {
+			fmt.Printf(" ⭐ (持倉價值的 %.1f%%, 閒置閾值: %d 根K線)\n", *opts.autoFundingPercent*100, *opts.autoFundingIdle)
+		} else {
+			fmt.Printf(" ⭐ (金額: $%.2f, 閒置閾值: %d 根K線)\n", *opts.autoFundingAmount, *opts.autoFundingIdle)
+		}
 	} else {
 		fmt.Println()
 	}
+	if basis := metrics.ReturnBasis(*opts.returnBasis); basis != metrics.ReturnBasisInitialOnly {
+		fmt.Printf("收益率基數: %s ⭐\n", basis)
+	}
 	fmt.Println("========================================")
 	fmt.Println()
 
-	// 創建回測引擎配置
-	config := engine.BacktestConfig{
-		InitialBalance:        *initialBalance,
-		FeeRate:               *feeRate,
-		Slippage:              *slippage,
-		InstID:                *instID,
-		TakeProfitMin:         *takeProfitMin,
-		TakeProfitMax:         *takeProfitMax,
-		PositionSize:          *positionSize,
-		BreakEvenProfitMin:    *breakEvenProfitMin,
-		BreakEvenProfitMax:    *breakEvenProfitMax,
-		EnableTrendFilter:     *enableTrendFilter,     // ⭐ 趨勢過濾
-		EnableRedCandleFilter: *enableRedCandleFilter, // ⭐ 紅K過濾
-		// 自動注資配置 ⭐
-		EnableAutoFunding: *enableAutoFunding, // 是否啟用自動注資
-		AutoFundingAmount: *autoFundingAmount, // 注資金額
-		AutoFundingIdle:   *autoFundingIdle,   // 閒置閾值
-	}
-
 	// 創建回測引擎
 	fmt.Println("正在初始化回測引擎...")
 	backtestEngine, err := engine.NewBacktestEngine(config)
@@ -99,26 +122,186 @@ func main() {
 		fmt.Printf("錯誤: 創建回測引擎失敗: %v\n", err)
 		os.Exit(1)
 	}
+	backtestEngine.SetLogger(stdoutLogger{}, engine.VerbosityVerbose) // ⭐ CLI 輸出打平和注資統計報告
+
+	// 斷點續跑 ⭐
+	if *opts.resume {
+		data, readErr := os.ReadFile(*opts.checkpointFile)
+		if readErr != nil {
+			fmt.Printf("錯誤: 讀取斷點文件失敗: %v\n", readErr)
+			os.Exit(1)
+		}
+		if err := backtestEngine.RestoreCheckpoint(data); err != nil {
+			fmt.Printf("錯誤: 載入斷點失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已載入斷點: %s\n", *opts.checkpointFile)
+	}
+	if *opts.checkpointEvery > 0 {
+		backtestEngine.SetCheckpointHandler(*opts.checkpointEvery, func(candleIndex int, data []byte) error {
+			return os.WriteFile(*opts.checkpointFile, data, 0o644)
+		})
+	}
+
+	// 串流交易日誌 ⭐
+	var tradeLogger *engine.StreamingTradeLogger
+	if *opts.tradeLogJSONL != "" {
+		if *opts.resume {
+			// 續寫斷點之前的記錄，截掉斷點之後寫入的記錄
+			tradeLogger, err = engine.ResumeStreamingTradeLogger(*opts.tradeLogJSONL, backtestEngine.ResumeTradeID())
+		} else {
+			tradeLogger, err = engine.NewStreamingTradeLogger(*opts.tradeLogJSONL)
+		}
+		if err != nil {
+			fmt.Printf("錯誤: %v\n", err)
+			os.Exit(1)
+		}
+		backtestEngine.SetTradeLogger(tradeLogger)
+	}
+
+	if *opts.showProgress {
+		backtestEngine.SetProgressFunc(func(processed, total int) {
+			if total == 0 {
+				// 串流回測讀完前不知道K線總數
+				fmt.Printf("\r回測進度: %d 根K線", processed)
+				return
+			}
+			fmt.Printf("\r回測進度: %3d%% (%d/%d)", processed*100/total, processed, total)
+			if processed == total {
+				fmt.Println()
+			}
+		})
+	}
 
 	// 運行回測
-	fmt.Printf("正在載入歷史數據: %s\n", *dataFile)
 	startTime := time.Now()
-	result, err := backtestEngine.RunFromFile(*dataFile)
+	var result metrics.BacktestResult
+	if *opts.stream {
+		result, err = runStream(backtestEngine, opts)
+	} else {
+		result, err = runLoaded(backtestEngine, opts)
+	}
+	if tradeLogger != nil {
+		// 回測失敗也關閉文件，保留已寫入的記錄
+		if closeErr := tradeLogger.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
 	if err != nil {
-		fmt.Printf("錯誤: 回測執行失敗: %v\n", err)
+		fmt.Printf("錯誤: %v\n", err)
 		os.Exit(1)
 	}
 	duration := time.Since(startTime)
+	if skipped := backtestEngine.GetSkippedUnconfirmedCount(); skipped > 0 {
+		fmt.Printf("已跳過 %d 根未完成K線\n", skipped)
+	}
 
 	// 打印回測結果
-	printBacktestResult(result, *dataFile, duration)
+	printBacktestResult(result, *opts.dataFile, duration, *opts.feeWarnRatio)
 
 	// ⭐ 導出回測結果到文件夾
-	exportResults(backtestEngine, result, *dataFile, *positionSize, duration, config)
+	exportResults(backtestEngine, result, *opts.dataFile, *opts.positionSize, duration, config, *opts.feeWarnRatio, *opts.tradeLogJSONL)
+}
+
+// runLoaded 載入全部K線後回測（打印數據品質摘要）
+func runLoaded(backtestEngine *engine.BacktestEngine, opts *cliOptions) (metrics.BacktestResult, error) {
+	validation, err := opts.ohlcValidationMode()
+	if err != nil {
+		return metrics.BacktestResult{}, err
+	}
+
+	fmt.Printf("正在載入歷史數據: %s\n", *opts.dataFile)
+	var candles []value_objects.Candle
+	var adjusted int
+	if strings.EqualFold(filepath.Ext(*opts.dataFile), ".csv") {
+		loc, locErr := time.LoadLocation(*opts.tz)
+		if locErr != nil {
+			return metrics.BacktestResult{}, fmt.Errorf("無效的時區 %s: %w", *opts.tz, locErr)
+		}
+		candles, adjusted, err = loader.LoadFromCSVWithValidation(*opts.dataFile, loc, *opts.maxCandles, validation)
+	} else if *opts.tolerantLoad {
+		jsonLoader := loader.NewCandleLoader(*opts.dataFile)
+		jsonLoader.SetMaxCandles(*opts.maxCandles)
+		jsonLoader.SetOHLCValidation(validation)
+		var skipped int
+		candles, skipped, err = jsonLoader.LoadPartial()
+		if err == nil && skipped > 0 {
+			fmt.Printf("⚠️  警告: 跳過 %d 行格式錯誤或被截斷的數據，已載入 %d 根K線\n", skipped, len(candles))
+		}
+		adjusted = jsonLoader.AdjustedCount()
+	} else {
+		jsonLoader := loader.NewCandleLoader(*opts.dataFile)
+		jsonLoader.SetMaxCandles(*opts.maxCandles)
+		jsonLoader.SetOHLCValidation(validation)
+		candles, err = jsonLoader.Load()
+		adjusted = jsonLoader.AdjustedCount()
+	}
+	if err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("載入歷史數據失敗: %w", err)
+	}
+	if adjusted > 0 {
+		fmt.Printf("⚠️  警告: 修正了 %d 根 OHLC 異常的K線（high/low 顛倒或開收盤價超出範圍）\n", adjusted)
+	}
+
+	// ⭐ 數據品質摘要（確認載入了正確的數據）
+	printDataSummary(loader.Summarize(candles))
+
+	result, err := backtestEngine.Run(candles)
+	if err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("回測執行失敗: %w", err)
+	}
+	return result, nil
+}
+
+// runStream 串流讀取K線回測（K線不全部載入內存）⭐
+func runStream(backtestEngine *engine.BacktestEngine, opts *cliOptions) (metrics.BacktestResult, error) {
+	if *opts.tolerantLoad {
+		return metrics.BacktestResult{}, fmt.Errorf("--stream 不支持 --tolerant-load")
+	}
+	if *opts.ohlcValidation != "strict" {
+		return metrics.BacktestResult{}, fmt.Errorf("--stream 只支持 --ohlc-validation strict")
+	}
+
+	fmt.Printf("正在串流讀取歷史數據: %s\n", *opts.dataFile)
+	var stream *loader.FileStream
+	var err error
+	if strings.EqualFold(filepath.Ext(*opts.dataFile), ".csv") {
+		loc, locErr := time.LoadLocation(*opts.tz)
+		if locErr != nil {
+			return metrics.BacktestResult{}, fmt.Errorf("無效的時區 %s: %w", *opts.tz, locErr)
+		}
+		stream, err = loader.OpenCSVStream(*opts.dataFile, loc)
+	} else {
+		stream, err = loader.OpenJSONStream(*opts.dataFile)
+	}
+	if err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("載入歷史數據失敗: %w", err)
+	}
+	defer stream.Close()
+
+	result, err := backtestEngine.RunStream(stream)
+	if err != nil {
+		return metrics.BacktestResult{}, fmt.Errorf("回測執行失敗: %w", err)
+	}
+	return result, nil
+}
+
+// printDataSummary 打印數據品質摘要 ⭐
+func printDataSummary(summary loader.DataSummary) {
+	fmt.Println("----------------------------------------")
+	fmt.Println("數據摘要")
+	fmt.Println("----------------------------------------")
+	fmt.Printf("K線數量: %d (週期: %v)\n", summary.Count, summary.Interval)
+	fmt.Printf("時間範圍: %s ~ %s\n",
+		summary.Start.UTC().Format("2006-01-02 15:04"), summary.End.UTC().Format("2006-01-02 15:04"))
+	fmt.Printf("價格範圍: %.2f ~ %.2f (平均收盤價: %.2f)\n", summary.MinPrice, summary.MaxPrice, summary.AvgPrice)
+	fmt.Printf("時間缺口: %d 處 (缺失 %d 根K線)\n", summary.Gaps, summary.MissingCandles)
+	fmt.Printf("零成交K線: %d 根\n", summary.FlatCandles)
+	fmt.Println()
 }
 
 // printBacktestResult 格式化輸出回測結果
-func printBacktestResult(result metrics.BacktestResult, dataFile string, duration time.Duration) {
+func printBacktestResult(result metrics.BacktestResult, dataFile string, duration time.Duration, feeWarnRatio float64) {
 	fmt.Println()
 	fmt.Println("========================================")
 	fmt.Printf("回測結果: %s\n", dataFile)
@@ -151,6 +334,16 @@ func printBacktestResult(result metrics.BacktestResult, dataFile string, duratio
 	fmt.Printf("總利潤:       $%.2f USDT 💸 (未扣手續費)\n", result.TotalProfitGross)
 	fmt.Printf("總手續費:     $%.2f USDT 💸 (開倉: $%.2f, 關倉: $%.2f)\n",
 		result.TotalFeesPaid, result.TotalFeesOpen, result.TotalFeesClose)
+	fmt.Printf("手續費/毛利:  %.1f%%\n", result.FeeToProfitRatio*100)
+	if warning := metrics.FeeWarning(result, feeWarnRatio); warning != "" {
+		fmt.Printf("⚠️  手續費侵蝕: %s\n", warning)
+	}
+	if result.SlippageCost != 0 {
+		fmt.Printf("滑點成本:     $%.2f USDT (零滑點淨利潤 - 實際淨利潤)\n", result.SlippageCost)
+	}
+	if result.FundingFee != 0 {
+		fmt.Printf("資金費:       $%.2f USDT (已計入淨利潤)\n", result.FundingFee)
+	}
 	fmt.Printf("未實現盈虧:   $%.2f USDT", result.UnrealizedPnL)
 	if result.UnrealizedPnL > 0 {
 		fmt.Printf(" 📈 (基於最後K線收盤價，含預估關倉手續費)\n")
@@ -175,17 +368,27 @@ func printBacktestResult(result metrics.BacktestResult, dataFile string, duratio
 	} else {
 		fmt.Printf(" ➡️\n")
 	}
-	fmt.Printf("盈虧比:       %.2f", result.ProfitFactor)
-	if result.ProfitFactor >= 2.0 {
+	if result.ReturnBasis != metrics.ReturnBasisInitialOnly {
+		fmt.Printf("收益率基數:   $%.2f USDT (%s)\n", result.ReturnCapital, result.ReturnBasis)
+	}
+	// 摘要和評級使用總盈虧比（含未實現盈虧），已實現/毛利盈虧比僅作參考
+	fmt.Printf("盈虧比:       %.2f", result.ProfitFactorTotal)
+	if result.ProfitFactorTotal >= 2.0 {
 		fmt.Printf(" ✅ (優秀)\n")
-	} else if result.ProfitFactor >= 1.5 {
+	} else if result.ProfitFactorTotal >= 1.5 {
 		fmt.Printf(" ✅ (良好)\n")
-	} else if result.ProfitFactor >= 1.0 {
+	} else if result.ProfitFactorTotal >= 1.0 {
 		fmt.Printf(" ⚠️ (一般)\n")
 	} else {
 		fmt.Printf(" ❌ (需改進)\n")
 	}
+	fmt.Printf("  已實現:     %.2f (毛利: %.2f)\n", result.ProfitFactorRealized, result.ProfitFactorGross)
+	if warning := metrics.ProfitFactorWarning(result); warning != "" {
+		fmt.Printf("  ⚠️ 盈虧比可信度: %s\n", warning)
+	}
 	fmt.Printf("平均持倉時長: %s\n", formatDuration(result.AvgHoldDuration))
+	fmt.Printf("持倉時長中位: %s (P95: %s)\n", formatDuration(result.MedianHoldDuration), formatDuration(result.P95HoldDuration))
+	fmt.Printf("日均關倉次數: %.2f\n", result.TradesPerDay)
 	fmt.Printf("勝率:         %.2f%%", result.WinRate)
 	if result.WinRate >= 60 {
 		fmt.Printf(" ✅\n")
@@ -194,6 +397,7 @@ func printBacktestResult(result metrics.BacktestResult, dataFile string, duratio
 	} else {
 		fmt.Printf(" ❌\n")
 	}
+	fmt.Printf("最大連勝/連虧: %d / %d (最大連虧金額: $%.2f)\n", result.MaxConsecutiveWins, result.MaxConsecutiveLosses, result.WorstLosingStreak)
 	fmt.Printf("最大回撤:     %.2f%%", result.MaxDrawdown)
 	if result.MaxDrawdown < 5 {
 		fmt.Printf(" ✅\n")
@@ -202,17 +406,62 @@ func printBacktestResult(result metrics.BacktestResult, dataFile string, duratio
 	} else {
 		fmt.Printf(" ❌\n")
 	}
+	fmt.Printf("回撤 (cash/equity): %.2f%% / %.2f%% (基礎: %s)\n", result.MaxDrawdownCash, result.MaxDrawdownEquity, result.DrawdownBasis)
+	fmt.Printf("潰瘍指數:     %.2f%% (痛苦比率: %.2f, 年化收益率: %.2f%%)\n", result.UlcerIndex, result.PainRatio, result.AnnualizedReturn)
+	fmt.Printf("平均淨敞口:   %.2f%% (Beta: %.2f)\n", result.AvgNetExposure*100, result.BetaToBenchmark)
+	if !result.HaltedAt.IsZero() {
+		fmt.Printf("回撤熔斷:     %s ⛔ %s\n", result.HaltedAt.UTC().Format("2006-01-02 15:04:05"), result.HaltReason)
+	}
+	if result.RoundTimeStops > 0 {
+		fmt.Printf("時間止損:     %d 輪 ⏱\n", result.RoundTimeStops)
+	}
 	fmt.Println()
 
+	// 盈虧歸因
+	if len(result.PnLByReason) > 0 {
+		fmt.Println("🧾 盈虧歸因（按關倉原因）")
+		fmt.Println("----------------------------------------")
+		for _, reason := range sortedReasons(result.PnLByReason) {
+			fmt.Printf("%-16s $%.2f USDT\n", reason+":", result.PnLByReason[reason])
+		}
+		fmt.Println()
+	}
+	if len(result.PnLByTag) > 0 {
+		fmt.Println("🏷️  盈虧歸因（按開倉標籤）")
+		fmt.Println("----------------------------------------")
+		for _, tag := range sortedReasons(result.PnLByTag) {
+			fmt.Printf("%-16s $%.2f USDT\n", tag+":", result.PnLByTag[tag])
+		}
+		fmt.Println()
+	}
+	if len(result.PnLByVolatilityRegime) > 0 {
+		fmt.Println("🌊 盈虧歸因（按波動率區間）")
+		fmt.Println("----------------------------------------")
+		for _, regime := range sortedReasons(result.PnLByVolatilityRegime) {
+			fmt.Printf("%-16s $%.2f USDT\n", regime+":", result.PnLByVolatilityRegime[regime])
+		}
+		fmt.Println()
+	}
+
 	// 策略評估
 	fmt.Println("🎯 策略評估")
 	fmt.Println("----------------------------------------")
-	evaluateStrategy(result)
+	evaluateStrategy(result, feeWarnRatio)
 	fmt.Println("========================================")
 }
 
+// sortedReasons 返回排序後的關倉原因（保證輸出順序穩定）
+func sortedReasons(pnlByReason map[string]float64) []string {
+	reasons := make([]string, 0, len(pnlByReason))
+	for reason := range pnlByReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
 // evaluateStrategy 根據結果評估策略表現
-func evaluateStrategy(result metrics.BacktestResult) {
+func evaluateStrategy(result metrics.BacktestResult, feeWarnRatio float64) {
 	score := 0
 
 	// 評分標準
@@ -229,9 +478,9 @@ func evaluateStrategy(result metrics.BacktestResult) {
 	} else if result.WinRate >= 50 {
 		score += 1
 	}
-	if result.ProfitFactor >= 1.5 {
+	if result.ProfitFactorTotal >= 1.5 {
 		score += 2
-	} else if result.ProfitFactor >= 1.0 {
+	} else if result.ProfitFactorTotal >= 1.0 {
 		score += 1
 	}
 	if result.TotalTrades >= 10 {
@@ -270,14 +519,17 @@ func evaluateStrategy(result metrics.BacktestResult) {
 	if result.WinRate < 50 {
 		fmt.Println("  • 勝率偏低，建議優化入場信號的準確性")
 	}
-	if result.ProfitFactor < 1.0 {
+	if result.ProfitFactorTotal < 1.0 {
 		fmt.Println("  • 盈虧比小於1，虧損金額大於盈利金額，需要調整止盈止損比例")
-	} else if result.ProfitFactor < 1.5 {
+	} else if result.ProfitFactorTotal < 1.5 {
 		fmt.Println("  • 盈虧比偏低，建議擴大止盈目標或縮小止損範圍")
 	}
 	if result.TotalTrades < 10 {
 		fmt.Println("  • 交易次數過少，可能數據量不足或策略過於保守")
 	}
+	if metrics.FeeWarning(result, feeWarnRatio) != "" {
+		fmt.Println("  • 手續費佔毛利過高，建議擴大止盈範圍、降低開倉頻率或使用 maker 費率")
+	}
 	if score >= 8 {
 		fmt.Println("  • 策略表現優秀，建議進行實盤小額測試！")
 	}
@@ -305,6 +557,8 @@ func exportResults(
 	positionSize float64,
 	duration time.Duration,
 	config engine.BacktestConfig,
+	feeWarnRatio float64,
+	tradeLogJSONL string,
 ) {
 	// 獲取數據文件所在目錄
 	dataDir := filepath.Dir(dataFile)
@@ -321,16 +575,21 @@ func exportResults(
 		return
 	}
 
-	// 1. 導出 CSV 文件
-	csvPath := filepath.Join(fullPath, "trades.csv")
-	if err := backtestEngine.ExportTradeLogCSV(csvPath); err != nil {
-		fmt.Printf("\n❌ 無法導出 CSV: %v\n", err)
+	// 1. 導出 CSV 文件（串流交易日誌已逐筆寫入 JSON Lines 文件，內存中沒有日誌可導出）
+	if tradeLogJSONL != "" {
+		fmt.Printf("\n✅ 交易日誌已串流寫入: %s\n", tradeLogJSONL)
 	} else {
-		fmt.Printf("\n✅ 交易日誌已導出: %s\n", csvPath)
+		csvPath := filepath.Join(fullPath, "trades.csv")
+		csvPrecision := engine.CSVPrecisionFromTickSize(config.TickSize)
+		if err := backtestEngine.ExportTradeLogCSVWithPrecision(csvPath, csvPrecision); err != nil {
+			fmt.Printf("\n❌ 無法導出 CSV: %v\n", err)
+		} else {
+			fmt.Printf("\n✅ 交易日誌已導出: %s\n", csvPath)
+		}
 	}
 
 	// 2. 生成報告內容
-	reportContent := generateReport(backtestEngine, result, dataFile, positionSize, duration, config)
+	reportContent := generateReport(backtestEngine, result, dataFile, positionSize, duration, config, feeWarnRatio)
 
 	// 3. 導出報告文件 (Markdown)
 	reportPath := filepath.Join(fullPath, "report.md")
@@ -348,6 +607,24 @@ func exportResults(
 		fmt.Printf("✅ 輪次詳細記錄已導出: %s\n", roundsCSVPath)
 	}
 
+	// 5. 導出資金快照和水下曲線 (CSV) ⭐
+	snapshotsCSVPath := filepath.Join(fullPath, "balance_snapshots.csv")
+	if err := backtestEngine.ExportBalanceSnapshotsCSV(snapshotsCSVPath); err != nil {
+		fmt.Printf("❌ 無法導出資金快照: %v\n", err)
+	} else if _, err := os.Stat(snapshotsCSVPath); err == nil {
+		fmt.Printf("✅ 資金快照（含回撤）已導出: %s\n", snapshotsCSVPath)
+	}
+
+	// 6. 導出拒絕開倉記錄 (CSV) ⭐
+	if config.LogRejectedAdvice {
+		rejectedCSVPath := filepath.Join(fullPath, "rejected_advice.csv")
+		if err := backtestEngine.ExportRejectedAdviceCSV(rejectedCSVPath); err != nil {
+			fmt.Printf("❌ 無法導出拒絕開倉記錄: %v\n", err)
+		} else if _, err := os.Stat(rejectedCSVPath); err == nil {
+			fmt.Printf("✅ 拒絕開倉記錄已導出: %s\n", rejectedCSVPath)
+		}
+	}
+
 	fmt.Printf("\n📁 所有文件已保存到文件夾: %s/\n", fullPath)
 }
 
@@ -359,6 +636,7 @@ func generateReport(
 	positionSize float64,
 	duration time.Duration,
 	config engine.BacktestConfig,
+	feeWarnRatio float64,
 ) string {
 	var report string
 
@@ -373,6 +651,9 @@ func generateReport(
 	report += fmt.Sprintf("- **初始資金**: $%.2f USDT\n", config.InitialBalance)
 	report += fmt.Sprintf("- **倉位大小**: $%.2f USDT\n", positionSize)
 	report += fmt.Sprintf("- **手續費率**: %.4f%% (%.6f)\n", config.FeeRate*100, config.FeeRate)
+	if config.DisableFees {
+		report += "- **免手續費模擬**: 所有手續費和資金費為 0（純信號分析）\n"
+	}
 	report += fmt.Sprintf("- **滑點**: %.4f%%\n", config.Slippage*100)
 	report += fmt.Sprintf("- **止盈範圍**: %.2f%% ~ %.2f%%\n", config.TakeProfitMin*100, config.TakeProfitMax*100)
 	report += fmt.Sprintf("- **執行時間**: %v\n", duration)
@@ -400,6 +681,18 @@ func generateReport(
 	report += fmt.Sprintf("- **總利潤 (基於單筆開倉價)**: $%.2f USDT 💸 (未扣手續費) ⭐\n", result.TotalProfitGross_Entry)
 	report += fmt.Sprintf("- **總手續費**: $%.2f USDT 💸 (開倉: $%.2f, 關倉: $%.2f)\n",
 		result.TotalFeesPaid, result.TotalFeesOpen, result.TotalFeesClose)
+	report += fmt.Sprintf("- **手續費/毛利**: %.1f%%", result.FeeToProfitRatio*100)
+	if warning := metrics.FeeWarning(result, feeWarnRatio); warning != "" {
+		report += fmt.Sprintf(" ⚠️ (%s)\n", warning)
+	} else {
+		report += "\n"
+	}
+	if result.SlippageCost != 0 {
+		report += fmt.Sprintf("- **滑點成本**: $%.2f USDT (零滑點淨利潤 - 實際淨利潤)\n", result.SlippageCost)
+	}
+	if result.FundingFee != 0 {
+		report += fmt.Sprintf("- **資金費**: $%.2f USDT (已計入淨利潤)\n", result.FundingFee)
+	}
 	report += fmt.Sprintf("- **未實現盈虧**: $%.2f USDT", result.UnrealizedPnL)
 	if result.UnrealizedPnL > 0 {
 		report += " 📈 (基於最後K線收盤價，含預估關倉手續費)\n"
@@ -421,21 +714,77 @@ func generateReport(
 	} else {
 		report += " 📉\n"
 	}
-	report += fmt.Sprintf("- **盈虧比**: %.2f", result.ProfitFactor)
-	if result.ProfitFactor >= 2.0 {
+	if result.ReturnBasis != metrics.ReturnBasisInitialOnly {
+		report += fmt.Sprintf("  > **收益率基數**: $%.2f USDT (%s)\n", result.ReturnCapital, result.ReturnBasis)
+	}
+	report += fmt.Sprintf("- **盈虧比**: %.2f", result.ProfitFactorTotal)
+	if result.ProfitFactorTotal >= 2.0 {
 		report += " ✅ (優秀)\n"
-	} else if result.ProfitFactor >= 1.5 {
+	} else if result.ProfitFactorTotal >= 1.5 {
 		report += " ✅ (良好)\n"
-	} else if result.ProfitFactor >= 1.0 {
+	} else if result.ProfitFactorTotal >= 1.0 {
 		report += " ⚠️ (一般)\n"
 	} else {
 		report += " ❌ (需改進)\n"
 	}
+	report += fmt.Sprintf("  - 已實現: %.2f (毛利: %.2f)\n", result.ProfitFactorRealized, result.ProfitFactorGross)
+	if warning := metrics.ProfitFactorWarning(result); warning != "" {
+		report += fmt.Sprintf("  - ⚠️ 盈虧比可信度: %s\n", warning)
+	}
 	report += fmt.Sprintf("- **平均持倉時長**: %s\n", formatDuration(result.AvgHoldDuration))
+	report += fmt.Sprintf("- **持倉時長中位數**: %s (P95: %s)\n", formatDuration(result.MedianHoldDuration), formatDuration(result.P95HoldDuration))
+	report += fmt.Sprintf("- **日均關倉次數**: %.2f\n", result.TradesPerDay)
 	report += fmt.Sprintf("- **勝率**: %.2f%%\n", result.WinRate)
-	report += fmt.Sprintf("- **最大回撤**: %.2f%%\n", result.MaxDrawdown)
+	report += fmt.Sprintf("- **最大連勝/連虧**: %d / %d (最大連虧金額: $%.2f)\n", result.MaxConsecutiveWins, result.MaxConsecutiveLosses, result.WorstLosingStreak)
+	report += fmt.Sprintf("- **最大回撤**: %.2f%% (cash: %.2f%%, equity: %.2f%%, 基礎: %s)\n", result.MaxDrawdown, result.MaxDrawdownCash, result.MaxDrawdownEquity, result.DrawdownBasis)
+	report += fmt.Sprintf("- **潰瘍指數**: %.2f%% (痛苦比率: %.2f, 年化收益率: %.2f%%)\n", result.UlcerIndex, result.PainRatio, result.AnnualizedReturn)
+	report += fmt.Sprintf("- **平均淨敞口**: %.2f%% (Beta: %.2f)\n", result.AvgNetExposure*100, result.BetaToBenchmark)
+	if !result.HaltedAt.IsZero() {
+		report += fmt.Sprintf("- **回撤熔斷**: %s (%s)\n", result.HaltedAt.UTC().Format("2006-01-02 15:04:05"), result.HaltReason)
+	}
+	if result.RoundTimeStops > 0 {
+		report += fmt.Sprintf("- **時間止損**: %d 輪\n", result.RoundTimeStops)
+	}
 	report += "\n"
 
+	// 持倉時長分佈
+	report += "### ⏱️ 持倉時長分佈\n\n"
+	report += "| 區間 | 筆數 |\n"
+	report += "|------|------|\n"
+	for _, bucket := range metrics.HoldDurationBuckets {
+		report += fmt.Sprintf("| %s | %d |\n", bucket, result.HoldDurationHistogram[bucket])
+	}
+	report += "\n"
+
+	// 盈虧歸因
+	if len(result.PnLByReason) > 0 {
+		report += "### 🧾 盈虧歸因（按關倉原因）\n\n"
+		report += "| 原因 | 淨已實現盈虧 |\n"
+		report += "|------|--------------|\n"
+		for _, reason := range sortedReasons(result.PnLByReason) {
+			report += fmt.Sprintf("| %s | $%.2f USDT |\n", reason, result.PnLByReason[reason])
+		}
+		report += "\n"
+	}
+	if len(result.PnLByTag) > 0 {
+		report += "### 🏷️ 盈虧歸因（按開倉標籤）\n\n"
+		report += "| 標籤 | 淨已實現盈虧 |\n"
+		report += "|------|--------------|\n"
+		for _, tag := range sortedReasons(result.PnLByTag) {
+			report += fmt.Sprintf("| %s | $%.2f USDT |\n", tag, result.PnLByTag[tag])
+		}
+		report += "\n"
+	}
+	if len(result.PnLByVolatilityRegime) > 0 {
+		report += "### 🌊 盈虧歸因（按波動率區間）\n\n"
+		report += "| 波動率區間 | 淨已實現盈虧 |\n"
+		report += "|------------|--------------|\n"
+		for _, regime := range sortedReasons(result.PnLByVolatilityRegime) {
+			report += fmt.Sprintf("| %s | $%.2f USDT |\n", regime, result.PnLByVolatilityRegime[regime])
+		}
+		report += "\n"
+	}
+
 	// 策略評估
 	report += "## 🎯 策略評估\n\n"
 	score := 0
@@ -452,9 +801,9 @@ func generateReport(
 	} else if result.WinRate >= 50 {
 		score += 1
 	}
-	if result.ProfitFactor >= 1.5 {
+	if result.ProfitFactorTotal >= 1.5 {
 		score += 2
-	} else if result.ProfitFactor >= 1.0 {
+	} else if result.ProfitFactorTotal >= 1.0 {
 		score += 1
 	}
 	if result.TotalTrades >= 10 {
@@ -487,9 +836,9 @@ func generateReport(
 	if result.WinRate < 50 {
 		report += "- 勝率偏低，建議優化入場信號的準確性\n"
 	}
-	if result.ProfitFactor < 1.0 {
+	if result.ProfitFactorTotal < 1.0 {
 		report += "- 盈虧比小於1，虧損金額大於盈利金額，需要調整止盈止損比例\n"
-	} else if result.ProfitFactor < 1.5 {
+	} else if result.ProfitFactorTotal < 1.5 {
 		report += "- 盈虧比偏低，建議擴大止盈目標或縮小止損範圍\n"
 	}
 	if result.TotalTrades < 10 {
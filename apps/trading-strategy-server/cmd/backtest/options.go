@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/engine"
+	"dizzycode.xyz/trading-strategy-server/backtesting/loader"
+	"dizzycode.xyz/trading-strategy-server/backtesting/metrics"
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// cliOptions 回測命令行參數（也可以由 --config 配置文件提供，命令行優先）⭐
+type cliOptions struct {
+	configFile            *string
+	profile               *string
+	profileDir            *string
+	dataFile              *string
+	initialBalance        *float64
+	feeRate               *float64
+	positionSize          *float64
+	slippage              *float64
+	feeCurrency           *string
+	minFeePerOrder        *float64
+	feesEnabled           *bool
+	instID                *string
+	tickSize              *float64
+	takeProfitMin         *float64
+	firstEntryTakeProfit  *float64
+	takeProfitMax         *float64
+	breakEvenProfitMin    *float64
+	breakEvenProfitMax    *float64
+	roundProfitTarget     *float64
+	profitFactorMinTrades *int
+	enableTrendFilter     *bool
+	trendPriceSource      *string
+	trendEMAShort         *string
+	trendEMALong          *string
+	trendConfirmCandles   *int
+	trendHysteresis       *float64
+	bar                   *string
+	enableRedCandleFilter *bool
+	redCandleLookback     *int
+	redCandleMinRed       *int
+	breakEvenStop         *bool
+	maxRoundCandles       *int
+	breakEvenCloseMode    *string
+	fillPriceModel        *string
+	limitFillModel        *string
+	limitFillBuffer       *float64
+	minHoldCandles        *int
+	markInterval          *int
+	logRejected           *bool
+	maxDrawdownHalt       *float64
+	drawdownThrottle      *string
+	haltForceClose        *bool
+	forceCloseAtEnd       *bool
+	requireConfirmed      *bool
+	profitVsAverageCost   *bool
+	feeWarnRatio          *float64
+	minNetProfit          *float64
+	minPriceGap           *float64
+	enableAutoFunding     *bool
+	autoFundingAmount     *float64
+	autoFundingIdle       *int
+	autoFundingMode       *string
+	autoFundingPercent    *float64
+	incrementalRecovery   *bool
+	returnBasis           *string
+	drawdownBasis         *string
+	fundingFeeRate        *float64
+	fundingFeeInterval    *time.Duration
+	tolerantLoad          *bool
+	ohlcValidation        *string
+	stream                *bool
+	maxCandles            *int
+	tz                    *string
+	showProgress          *bool
+	checkpointEvery       *int
+	checkpointFile        *string
+	resume                *bool
+	tradeLogJSONL         *string
+}
+
+// registerFlags 在 fs 上註冊所有回測參數
+func registerFlags(fs *flag.FlagSet) *cliOptions {
+	o := &cliOptions{}
+	o.configFile = fs.String("config", "", "回測參數配置文件（YAML/JSON，鍵名同命令行參數，例: initial-balance: 5000；命令行參數優先）")
+	o.profile = fs.String("profile", "", "回測參數方案「名稱」或「名稱@版本」（內建: conservative, aggressive；優先級低於命令行和 --config）")
+	o.profileDir = fs.String("profile-dir", "", "用戶自定義參數方案目錄（每個 YAML/JSON 文件一個方案，默認: 空 = 只使用內建方案）")
+	o.dataFile = fs.String("data", "", "歷史數據文件路徑 (必填)")
+	o.initialBalance = fs.Float64("initial-balance", 10000.0, "初始資金 (USDT)")
+	o.feeRate = fs.Float64("fee-rate", 0.0005, "手續費率 (默認: 0.0005 = 0.05%，負數表示 maker 返傭)")
+	o.positionSize = fs.Float64("position-size", 100.0, "單次開倉大小 (USDT)")
+	o.slippage = fs.Float64("slippage", 0.0, "滑點率：買入成交價 × (1 + 滑點)、賣出成交價 × (1 - 滑點)，結果中報告滑點成本 (默認: 0)")
+	o.feeCurrency = fs.String("fee-currency", "quote", "手續費扣除幣種: quote（USDT 支付） | base（買入手續費從收到的幣中扣除）")
+	o.feesEnabled = fs.Bool("fees-enabled", true, "是否收取手續費；false = 免手續費模擬（開平倉手續費、預估平倉費和資金費都為 0，用於比較毛利與淨利，默認: true）")
+	o.minFeePerOrder = fs.Float64("min-fee-per-order", 0, "每筆訂單的最低手續費 (USDT，開倉和平倉都適用，默認: 0 = 不限制)")
+	o.instID = fs.String("inst-id", "ETH-USDT-SWAP", "交易對")
+	o.tickSize = fs.Float64("tick-size", 0, "價格最小變動單位，開平倉價按此取整並推導 CSV 導出精度 (默認: 0 = 價格取整到 2 位小數、CSV 6 位小數)")
+	o.takeProfitMin = fs.Float64("take-profit-min", 0.0015, "最小止盈百分比 (默認: 0.0015 = 0.15%)")
+	o.firstEntryTakeProfit = fs.Float64("first-entry-take-profit", 0, "本輪第一筆倉位的止盈百分比 (默認: 0 = 與 take-profit-min 相同)")
+	o.takeProfitMax = fs.Float64("take-profit-max", 0.01, "最大止盈百分比 (默認: 0.0020 = 0.20%)")
+	o.breakEvenProfitMin = fs.Float64("break-even-profit-min", 0.0, "打平最小目標盈利 (USDT, 默認: 0)")
+	o.breakEvenProfitMax = fs.Float64("break-even-profit-max", 20.0, "打平最大目標盈利 (USDT, 默認: 20)")
+	o.roundProfitTarget = fs.Float64("round-profit-target", 0, "整輪止盈：本輪已實現 + 未實現盈虧達到此值時平掉所有倉位 (USDT, 默認: 0 = 不啟用)")
+	o.profitFactorMinTrades = fs.Int("profit-factor-min-trades", metrics.DefaultProfitFactorMinTrades, "已平倉交易少於此數時警告盈虧比不可信（負數 = 不檢查交易數）")
+	o.enableTrendFilter = fs.Bool("enable-trend-filter", false, "是否啟用趨勢過濾 (默認: false) ⭐")
+	o.trendPriceSource = fs.String("trend-price-source", "close", "趨勢計算使用的K線價格: close | hlc3 | ohlc4 | high | low（影響 EMA、價格跌幅和陰線統計）")
+	o.trendEMAShort = fs.String("trend-ema-short", "", "短期 EMA 週期按時間指定（例: 100m、4h；空 = 固定 20 根K線），按 --bar 換算為K線根數")
+	o.trendEMALong = fs.String("trend-ema-long", "", "長期 EMA 週期按時間指定（例: 250m、10h；空 = 固定 50 根K線）")
+	o.trendConfirmCandles = fs.Int("trend-confirm-candles", 1, "趨勢過濾的開多判斷需連續多少根K線相同才生效 (默認: 1 = 立即生效)")
+	o.trendHysteresis = fs.Float64("trend-hysteresis", 0, "趨勢遲滯帶：EMA 差距進入趨勢後回落到「閾值 - 遲滯帶」以內才退出 (例: 0.001 = 0.1%, 需小於 EMA 閾值 0.3%, 默認: 0 = 不啟用)")
+	o.bar = fs.String("bar", "5m", "數據文件的K線週期（例: 1m、5m、1H），用於換算按時間指定的 EMA 週期")
+	o.enableRedCandleFilter = fs.Bool("enable-red-candle-filter", true, "是否啟用紅K過濾（虧損時只在紅K開倉，默認: true）⭐")
+	o.redCandleLookback = fs.Int("red-candle-lookback", 1, "紅K過濾：檢查最近多少根K線（含當前K線，默認: 1）")
+	o.redCandleMinRed = fs.Int("red-candle-min-red", 1, "紅K過濾：最近 N 根中至少多少根為紅K才允許虧損時開倉（默認: 1）")
+	o.breakEvenStop = fs.Bool("break-even-stop", false, "保本止損：本輪預期盈利轉正後，價格跌回平均成本即平掉本輪所有倉位 (默認: false)")
+	o.maxRoundCandles = fs.Int("max-round-candles", 0, "輪次時間止損：輪次開始後經過此K線根數（按 --bar 換算時間）仍未結束時，以市價平掉本輪所有倉位 (默認: 0 = 不啟用)")
+	o.breakEvenCloseMode = fs.String("break-even-close-mode", "per_position", "打平退出的平倉記錄方式: per_position | aggregate（合併為一筆 CLOSE）")
+	o.fillPriceModel = fs.String("fill-price-model", "optimistic", "K線內成交價格假設: optimistic | pessimistic | close_only")
+	o.limitFillModel = fs.String("limit-fill-model", "always", "開倉限價單成交假設: always | touch（下一根K線 Low 觸及限價）| strict（Low 穿越限價一個緩衝）")
+	o.limitFillBuffer = fs.Float64("limit-fill-buffer", 0.0, "strict 模式下 Low 必須低於限價的比例 (例: 0.0005 = 0.05%, 默認: 0)")
+	o.minHoldCandles = fs.Int("min-hold-candles", 0, "最短持倉K線數：開倉後至少持有 N 根K線才允許止盈，模擬下單延遲（打平/止損不受限制，默認: 0 = 不限制）")
+	o.markInterval = fs.Int("mark-interval", 0, "每 N 根K線在交易日誌寫入一筆 MARK 盯市記錄（價格、持倉價值、未實現盈虧，默認: 0 = 不記錄）")
+	o.logRejected = fs.Bool("log-rejected", false, "記錄策略拒絕開倉的時間、價格和原因，導出到 rejected_advice.csv (默認: false)")
+	o.maxDrawdownHalt = fs.Float64("max-drawdown-halt", 0, "回撤熔斷：權益從峰值回撤超過此比例時停止開倉 (例: 0.2 = 20%, 默認: 0 = 不啟用)")
+	o.haltForceClose = fs.Bool("halt-force-close", false, "觸發回撤熔斷時以當前收盤價平掉所有未平倉位 (默認: false)")
+	o.drawdownThrottle = fs.String("drawdown-throttle", "", "回撤降倉分檔「回撤:倍數,...」（例: 0.1:0.5,0.2:0.25 = 回撤 10% 起半倉、20% 起四分之一倉，回撤收窄後恢復；默認: 空 = 不啟用）")
+	o.forceCloseAtEnd = fs.Bool("force-close-at-end", false, "回測結束時以最後收盤價平掉所有未平倉位（未實現盈虧轉為已實現，默認: false）")
+	o.requireConfirmed = fs.Bool("require-confirmed", false, "只在已完成K線上執行開平倉（數據混有未完成K線時使用，默認: false）⭐")
+	o.profitVsAverageCost = fs.Bool("profit-vs-average-cost", false, "止盈目標按本輪平均成本重算（每根K線），而非開倉時固定的止盈價 (默認: false)")
+	o.feeWarnRatio = fs.Float64("fee-warn-ratio", metrics.DefaultFeeWarnRatio, "手續費佔總毛利超過此比例時在結果和報告中警告 (默認: 0.3 = 30%, 0 = 不檢查)")
+	o.minNetProfit = fs.Float64("min-net-profit", 0.0, "單筆止盈扣除手續費後的最小淨利潤 (USDT, 默認: 0 = 不限制) ⭐")
+	o.minPriceGap = fs.Float64("min-price-gap", 0, "價位去重：掛單價與本輪已有倉位開倉價的相對距離小於此值時不開倉 (例: 0.001 = 0.1%, 默認: 0 = 不限制)")
+	// 自動注資參數 ⭐
+	o.enableAutoFunding = fs.Bool("enable-auto-funding", true, "是否啟用自動注資 (默認: false)")
+	o.autoFundingAmount = fs.Float64("auto-funding-amount", 5000.0, "自動注資金額 (USDT, 默認: 5000)")
+	o.autoFundingIdle = fs.Int("auto-funding-idle", 12, "觸發注資的閒置K線數 (默認: 288 根，約1天)")
+	o.autoFundingMode = fs.String("auto-funding-mode", "fixed", "注資金額模式: fixed | percent_of_notional")
+	o.autoFundingPercent = fs.Float64("auto-funding-percent", 0.5, "按比例注資：持倉價值的比例 (percent_of_notional 模式, 默認: 0.5 = 50%)")
+	o.incrementalRecovery = fs.Bool("incremental-funding-recovery", false, "正常止盈後可用餘額超過初始資金的部分逐步回收注資 (默認: false = 只在打平退出時全額回收)")
+	o.returnBasis = fs.String("return-basis", "initial", "總收益率的資本基數: initial | initial_plus_max_funding（+ 注資峰值）| initial_plus_net_funding（+ 未回收注資）")
+	o.drawdownBasis = fs.String("drawdown-basis", "cash", "最大回撤的計算基礎: cash（可用餘額）| equity（權益，含持倉浮虧）；兩種回撤都會輸出")
+	o.fundingFeeRate = fs.Float64("funding-fee-rate", 0, "永續合約資金費率：每次結算按持倉價值 × 費率從餘額扣除，打平目標扣除本輪資金費 (例: 0.0001 = 0.01%，負數 = 收取，默認: 0 = 不模擬)")
+	o.fundingFeeInterval = fs.Duration("funding-fee-interval", 8*time.Hour, "資金費結算間隔（按 UTC 對齊，默認: 8h）")
+	// 數據載入 ⭐
+	o.tolerantLoad = fs.Bool("tolerant-load", false, "容錯載入數據文件（跳過格式錯誤的行，文件被截斷時保留可用部分，默認: false）")
+	o.ohlcValidation = fs.String("ohlc-validation", "strict", "K線 OHLC 校驗: strict（拒絕 low <= open, close <= high 不成立的K線）| clamp（交換顛倒的 high/low、把 open/close 夾到範圍內並告警；--stream 只支持 strict）")
+	o.tz = fs.String("tz", "UTC", "CSV 數據文件中無時區時間的所屬時區（例: Asia/Taipei），統一轉換為 UTC")
+	o.stream = fs.Bool("stream", false, "串流讀取數據文件，K線不全部載入內存（多年 1m 數據使用；不顯示數據摘要，不支持 --tolerant-load，默認: false）")
+	o.maxCandles = fs.Int("max-candles", loader.DefaultMaxCandles, "載入數據文件時最多解析的K線數，超過時報錯退出（防止超大文件耗盡內存，--stream 不受限制）")
+	// 進度顯示 ⭐
+	o.showProgress = fs.Bool("progress", false, "顯示回測進度 (默認: false)")
+	// 斷點續跑 ⭐
+	o.checkpointEvery = fs.Int("checkpoint-every", 0, "每處理多少根K線寫入一次斷點 (默認: 0 = 不寫入)")
+	o.checkpointFile = fs.String("checkpoint-file", "backtest_checkpoint.json", "斷點文件路徑")
+	o.resume = fs.Bool("resume", false, "從 --checkpoint-file 的斷點繼續回測（需使用相同的數據文件和參數）")
+	// 串流交易日誌 ⭐
+	o.tradeLogJSONL = fs.String("trade-log-jsonl", "", "交易日誌逐筆寫入此 JSON Lines 文件，不保留在內存（長時間回測使用；不導出 trades.csv，文件已存在時覆蓋，--resume 時接在斷點的最後一筆交易之後續寫，默認: 空 = 內存日誌）")
+
+	return o
+}
+
+// backtestConfig 按參數組裝回測引擎配置
+func (o *cliOptions) backtestConfig() (engine.BacktestConfig, error) {
+	// 解析按時間指定的 EMA 週期
+	barInterval, err := grid.ParseBarDuration(*o.bar)
+	if err != nil {
+		return engine.BacktestConfig{}, fmt.Errorf("無效的K線週期: %w", err)
+	}
+	var trendEMAShortWindow, trendEMALongWindow time.Duration
+	if *o.trendEMAShort != "" {
+		if trendEMAShortWindow, err = grid.ParseBarDuration(*o.trendEMAShort); err != nil {
+			return engine.BacktestConfig{}, fmt.Errorf("無效的短期 EMA 週期: %w", err)
+		}
+	}
+	if *o.trendEMALong != "" {
+		if trendEMALongWindow, err = grid.ParseBarDuration(*o.trendEMALong); err != nil {
+			return engine.BacktestConfig{}, fmt.Errorf("無效的長期 EMA 週期: %w", err)
+		}
+	}
+	drawdownThrottle, err := engine.ParseDrawdownThrottle(*o.drawdownThrottle)
+	if err != nil {
+		return engine.BacktestConfig{}, fmt.Errorf("無效的回撤降倉分檔: %w", err)
+	}
+
+	return engine.BacktestConfig{
+		InitialBalance:          *o.initialBalance,
+		FeeRate:                 *o.feeRate,
+		Slippage:                *o.slippage,
+		InstID:                  *o.instID,
+		TickSize:                *o.tickSize,
+		TakeProfitMin:           *o.takeProfitMin,
+		TakeProfitMax:           *o.takeProfitMax,
+		FirstEntryTakeProfit:    *o.firstEntryTakeProfit,
+		PositionSize:            *o.positionSize,
+		BreakEvenProfitMin:      *o.breakEvenProfitMin,
+		BreakEvenProfitMax:      *o.breakEvenProfitMax,
+		RoundProfitTarget:       *o.roundProfitTarget,
+		ProfitFactorMinTrades:   *o.profitFactorMinTrades,
+		EnableTrendFilter:       *o.enableTrendFilter,     // ⭐ 趨勢過濾
+		EnableRedCandleFilter:   *o.enableRedCandleFilter, // ⭐ 紅K過濾
+		RedCandleLookback:       *o.redCandleLookback,     // ⭐ 紅K過濾：檢查K線數
+		RedCandleMinRed:         *o.redCandleMinRed,       // ⭐ 紅K過濾：最少紅K數
+		MinNetProfitPerTrade:    *o.minNetProfit,          // ⭐ 單筆最小淨利潤
+		MinPriceGapBetweenOpens: *o.minPriceGap,           // ⭐ 價位去重
+		ProfitVsAverageCost:     *o.profitVsAverageCost,   // ⭐ 按平均成本止盈
+		RequireConfirmedCandles: *o.requireConfirmed,      // ⭐ 跳過未完成K線
+		// 手續費扣除幣種 ⭐
+		FeeCurrency:    simulator.FeeCurrency(*o.feeCurrency),
+		MinFeePerOrder: *o.minFeePerOrder,
+		DisableFees:    !*o.feesEnabled,
+		// 打平退出 ⭐
+		BreakEvenCloseMode: engine.BreakEvenCloseMode(*o.breakEvenCloseMode),
+		BreakEvenStop:      *o.breakEvenStop,
+		MaxRoundCandles:    *o.maxRoundCandles,
+		ForceCloseAtEnd:    *o.forceCloseAtEnd,
+		LogRejectedAdvice:  *o.logRejected,
+		MarkInterval:       *o.markInterval,
+		MinHoldCandles:     *o.minHoldCandles,
+		// 回撤熔斷 ⭐
+		MaxDrawdownHalt: *o.maxDrawdownHalt,
+		HaltForceClose:  *o.haltForceClose,
+		// 回撤降倉 ⭐
+		DrawdownThrottle: drawdownThrottle,
+		FillPriceModel:   engine.FillPriceModel(*o.fillPriceModel),
+		// 限價單成交假設 ⭐
+		LimitFillModel:  engine.LimitFillModel(*o.limitFillModel),
+		LimitFillBuffer: *o.limitFillBuffer,
+		// 趨勢價格來源 ⭐
+		TrendPriceSource: grid.PriceSource(*o.trendPriceSource),
+		// 按時間指定的 EMA 週期 ⭐
+		TrendEMAShortWindow: trendEMAShortWindow,
+		TrendEMALongWindow:  trendEMALongWindow,
+		BarInterval:         barInterval,
+		TrendConfirmCandles: *o.trendConfirmCandles,
+		TrendHysteresis:     *o.trendHysteresis,
+		// 自動注資配置 ⭐
+		EnableAutoFunding:  *o.enableAutoFunding,                       // 是否啟用自動注資
+		AutoFundingAmount:  *o.autoFundingAmount,                       // 注資金額
+		AutoFundingIdle:    *o.autoFundingIdle,                         // 閒置閾值
+		AutoFundingMode:    engine.AutoFundingMode(*o.autoFundingMode), // 注資金額模式
+		AutoFundingPercent: *o.autoFundingPercent,                      // 按比例注資的比例
+		// 逐步回收注資 ⭐
+		IncrementalFundingRecovery: *o.incrementalRecovery,
+		// 收益率基數 ⭐
+		ReturnBasis: metrics.ReturnBasis(*o.returnBasis),
+		// 回撤基礎 ⭐
+		DrawdownBasis: metrics.DrawdownBasis(*o.drawdownBasis),
+		// 永續合約資金費 ⭐
+		FundingFeeRate:     *o.fundingFeeRate,
+		FundingFeeInterval: *o.fundingFeeInterval,
+	}, nil
+}
+
+// ohlcValidationMode 解析 --ohlc-validation
+func (o *cliOptions) ohlcValidationMode() (value_objects.OHLCValidation, error) {
+	switch *o.ohlcValidation {
+	case "strict":
+		return value_objects.OHLCStrict, nil
+	case "clamp":
+		return value_objects.OHLCClamp, nil
+	default:
+		return value_objects.OHLCStrict, fmt.Errorf("無效的 OHLC 校驗模式: %s（strict | clamp）", *o.ohlcValidation)
+	}
+}
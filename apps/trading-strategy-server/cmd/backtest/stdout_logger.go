@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+// stdoutLogger 把引擎的統計報告原樣輸出到標準輸出（不加時間戳和級別，保持 CLI 的報告格式）
+type stdoutLogger struct{}
+
+func (stdoutLogger) Info(msg string, context ...any)  { fmt.Println(msg) }
+func (stdoutLogger) Error(msg string, context ...any) { fmt.Println(msg) }
+func (stdoutLogger) Warn(msg string, context ...any)  { fmt.Println(msg) }
+func (stdoutLogger) Debug(msg string, context ...any) { fmt.Println(msg) }
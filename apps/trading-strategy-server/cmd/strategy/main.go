@@ -2,20 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"dizzycode.xyz/shared/health"
+	"dizzycode.xyz/shared/rediskeys"
 	"dizzycode.xyz/trading-strategy-server/internal/application"
 	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
 	"dizzycode.xyz/trading-strategy-server/internal/infrastructure/config"
 	"dizzycode.xyz/trading-strategy-server/internal/infrastructure/logger"
 	"dizzycode.xyz/trading-strategy-server/internal/infrastructure/messaging"
-)
-
-const (
-	positionSize = 200.0 // 固定單次開倉大小為 200 美元
+	"dizzycode.xyz/trading-strategy-server/internal/infrastructure/okx"
 )
 
 func main() {
@@ -29,6 +30,7 @@ func main() {
 		"environment": cfg.Environment,
 		"port":        cfg.Port,
 		"strategy":    cfg.Strategy.Type,
+		"bar":         cfg.Strategy.Bar,
 	})
 
 	// 3. 創建 Redis 客戶端
@@ -47,53 +49,204 @@ func main() {
 	log.Info("Connected to Redis", map[string]any{"addr": cfg.Redis.Addr})
 
 	// 4. 創建基礎設施層 - Market Data Reader ⭐
-	dataReader := messaging.NewMarketDataReader(redisClient, log)
+	// Key 命名空間必須與 market-data-service 的 REDIS_KEY_NAMESPACE 一致
+	keys, err := rediskeys.New(cfg.Redis.KeyNamespace)
+	if err != nil {
+		log.Error("Invalid Redis key namespace", map[string]any{"error": err})
+		os.Exit(1)
+	}
+	dataReader := messaging.NewMarketDataReader(redisClient, keys, cfg.Strategy.PriceMaxAge, log)
+	dataReader.SetReadTimeout(cfg.Redis.ReadTimeout)
 
-	// 5. 創建領域層 - GridAggregate
+	// 5. 檢查交易對配置
 	if len(cfg.Strategy.Instruments) == 0 {
 		log.Error("No instruments configured", map[string]any{})
 		os.Exit(1)
 	}
 
-	instID := cfg.Strategy.Instruments[0]
-	gridAggregate, err := grid.NewGridAggregate(
-		grid.GridConfig{
-			InstID:             instID,
-			PositionSize:       positionSize, // 固定單次開倉大小為 200 美元
-			FeeRate:            0.0005,       // 0.05% OKX Taker fee
-			TakeProfitRateMin:  cfg.Strategy.Grid.TakeProfitMin,
-			TakeProfitRateMax:  cfg.Strategy.Grid.TakeProfitMax,
-			BreakEvenProfitMin: 0,
-			BreakEvenProfitMax: 20,
-		})
-	if err != nil {
-		log.Error("Failed to create grid aggregate", map[string]any{"error": err})
-		os.Exit(1)
+	// 6. 每個交易對創建領域層 GridAggregate 和應用層 StrategyService（網格參數按交易對合併覆蓋）⭐
+	adviceTTL := application.AdviceTTL{
+		MaxAge:        cfg.Strategy.AdviceTTL,
+		MaxPriceDrift: cfg.Strategy.AdviceMaxPriceDrift,
+	}
+	// 交易對規格：價格按 tickSz 取整，校驗 minSz；載入失敗時沿用默認 2 位小數 ⭐
+	var instrumentLoader *okx.InstrumentLoader
+	if cfg.Strategy.InstrumentsSource != "" {
+		instrumentLoader = okx.NewInstrumentLoader(
+			cfg.Strategy.InstrumentsSource,
+			cfg.Strategy.InstrumentsType,
+		)
 	}
 
-	log.Info("Grid aggregate created", map[string]any{
-		"instId":             gridAggregate.InstID,
-		"positionSize":       gridAggregate.PositionSize,
-		"TakeProfitRateMin":  gridAggregate.TakeProfitRateMin,
-		"TakeProfitRateMax":  gridAggregate.TakeProfitRateMax,
-		"BreakEvenProfitMin": gridAggregate.BreakEvenProfitMin,
-		"BreakEvenProfitMax": gridAggregate.BreakEvenProfitMax,
-	})
+	strategyServices := make(map[string]*application.StrategyService, len(cfg.Strategy.Instruments))
+	for _, instID := range cfg.Strategy.Instruments {
+		gridCfg := cfg.Strategy.GridFor(instID)
+
+		var instrument okx.InstrumentInfo
+		if instrumentLoader != nil {
+			instrument, err = instrumentLoader.Instrument(context.Background(), instID)
+			if err != nil {
+				log.Warn("Failed to load instrument info, using default price precision", map[string]any{
+					"instId": instID,
+					"error":  err,
+				})
+			} else {
+				log.Info("Instrument info loaded", map[string]any{
+					"instId":   instID,
+					"tickSize": instrument.TickSize,
+					"lotSize":  instrument.LotSize,
+					"minSize":  instrument.MinSize,
+				})
+			}
+		}
+
+		gridAggregate, err := grid.NewGridAggregate(
+			grid.GridConfig{
+				InstID:             instID,
+				PositionSize:       gridCfg.PositionSize,
+				FeeRate:            0.0005, // 0.05% OKX Taker fee
+				TakeProfitRateMin:  gridCfg.TakeProfitMin,
+				TakeProfitRateMax:  gridCfg.TakeProfitMax,
+				BreakEvenProfitMin: 0,
+				BreakEvenProfitMax: 20,
+				// ⭐ 本輪第一筆倉位的止盈比例（0 = 與 TakeProfitRateMin 相同）
+				FirstEntryTakeProfit: gridCfg.FirstEntryTakeProfit,
+				// ⭐ 單筆止盈的最小淨利潤
+				MinNetProfitPerTrade: gridCfg.MinNetProfit,
+				// ⭐ 價位去重：與本輪已有倉位開倉價的最小相對距離
+				MinPriceGapBetweenOpens: gridCfg.MinPriceGap,
+				// ⭐ 交易所價格精度（未載入時為 0 = 默認 2 位小數）
+				TickSize: instrument.TickSize,
+			})
+		if err != nil {
+			log.Error("Failed to create grid aggregate", map[string]any{
+				"instId": instID,
+				"error":  err,
+			})
+			os.Exit(1)
+		}
+
+		log.Info("Grid aggregate created", map[string]any{
+			"instId":             gridAggregate.InstID,
+			"positionSize":       gridAggregate.PositionSize,
+			"TakeProfitRateMin":  gridAggregate.TakeProfitRateMin,
+			"TakeProfitRateMax":  gridAggregate.TakeProfitRateMax,
+			"BreakEvenProfitMin": gridAggregate.BreakEvenProfitMin,
+			"BreakEvenProfitMax": gridAggregate.BreakEvenProfitMax,
+			// ⭐ 第一筆倉位止盈比例
+			"FirstEntryTakeProfit": gridAggregate.FirstEntryTakeProfit,
+		})
+
+		strategyService := application.NewStrategyService(gridAggregate, dataReader, cfg.Strategy.Bar, log)
+		strategyService.SetAdviceValidator(
+			application.NewAdviceValidator(application.AdviceValidatorConfig{
+				MinOrderSize:      cfg.Strategy.MinOrderSize,
+				MaxPriceDeviation: cfg.Strategy.MaxPriceDeviation,
+				MinBaseSize:       instrument.MinBaseSize(),
+			}),
+			application.AccountState{
+				AvailableBalance: cfg.Strategy.SimulatedBalance,
+				FeeRate:          gridAggregate.FeeRate,
+			},
+		)
+
+		strategyServices[instID] = strategyService
+	}
 
-	// 6. 創建應用層 - StrategyService ⭐
-	strategyService := application.NewStrategyService(gridAggregate, dataReader, log)
+	// 7. 啟動健康檢查服務（/healthz, /readyz）⭐
+	// 就緒條件：Redis 可用，且能讀到最新K線
+	healthServer := health.NewServer(cfg.Port, []health.Checker{
+		{Name: "redis", Check: redisClient.Ping},
+		{Name: "latest_candle", Check: func(ctx context.Context) error {
+			for _, instID := range cfg.Strategy.Instruments {
+				if _, err := dataReader.GetLatestCandle(ctx, instID, cfg.Strategy.Bar); err != nil {
+					return fmt.Errorf("%s: %w", instID, err)
+				}
+			}
+			return nil
+		}},
+	}, log)
+	healthServer.Start()
 
 	log.Info("Trading Strategy Server started successfully", map[string]any{
 		"mode":        "passive_advisory", // 被動諮詢模式
-		"instId":      instID,
+		"instruments": cfg.Strategy.Instruments,
+		"bar":         cfg.Strategy.Bar,
 		"description": "Waiting for Order Service requests",
 	})
 
-	// 7. 模擬 Order Service 請求循環 ⭐
+	// 8. 模擬 Order Service 請求循環 ⭐
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	stopQueries := make(chan struct{}) // 關閉時停止發起新的詢問（進行中的詢問繼續使用 ctx 完成）
+	queriesDone := make(chan struct{})
+
+	// queryAdvice 模擬 Order Service 對單個交易對的一次詢問
+	queryAdvice := func(ctx context.Context, instID string, strategyService *application.StrategyService) {
+		// 模擬：從 Redis 讀取當前價格
+		currentPrice, err := dataReader.GetLatestPrice(ctx, instID)
+		if err != nil {
+			log.Warn("Failed to get current price", map[string]any{
+				"instId": instID,
+				"error":  err,
+			})
+			return
+		}
+
+		adviceIssuedAt := time.Now() // 建議基於此刻的價格
+
+		log.Info("🔍 Order Service: Querying open advice", map[string]any{
+			"instId":       instID,
+			"currentPrice": currentPrice.String(),
+		})
+
+		// 調用策略服務獲取建議
+		advice, err := strategyService.GetOpenAdvice(ctx, instID)
+		if errors.Is(err, application.ErrShuttingDown) {
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get open advice", map[string]any{
+				"instId": instID,
+				"error":  err,
+			})
+			return
+		}
+
+		// 模擬：執行前重新讀取價格，拒絕過期建議 ⭐
+		if advice.ShouldOpen {
+			execPrice, err := dataReader.GetLatestPrice(ctx, instID)
+			if err != nil {
+				log.Warn("Failed to get execution price", map[string]any{
+					"error": err,
+				})
+				return
+			}
+
+			if err := adviceTTL.Check(adviceIssuedAt, currentPrice.Value(), execPrice.Value(), time.Now()); err != nil {
+				log.Warn("⏱️ Order Service: Advice rejected", map[string]any{
+					"error":        err,
+					"priceAtIssue": currentPrice.String(),
+					"execPrice":    execPrice.String(),
+				})
+				return
+			}
+		}
+
+		// 輸出建議結果
+		if advice.ShouldOpen {
+			log.Info("✅ Order Service: SHOULD OPEN POSITION", map[string]any{
+				"advice": advice,
+			})
+		} else {
+			log.Debug("❌ Order Service: Should not open", map[string]any{
+				"reason": advice.Reason,
+			})
+		}
+	}
 
 	go func() {
+		defer close(queriesDone)
 		ticker := time.NewTicker(5 * time.Second) // 每 5 秒詢問一次
 		defer ticker.Stop()
 
@@ -103,40 +256,11 @@ func main() {
 
 		for {
 			select {
-			case <-ctx.Done():
+			case <-stopQueries:
 				return
 			case <-ticker.C:
-				// 模擬：從 Redis 讀取當前價格
-				currentPrice, err := dataReader.GetLatestPrice(ctx, instID)
-				if err != nil {
-					log.Warn("Failed to get current price", map[string]any{
-						"error": err,
-					})
-					continue
-				}
-
-				log.Info("🔍 Order Service: Querying open advice", map[string]any{
-					"currentPrice": currentPrice.String(),
-				})
-
-				// 調用策略服務獲取建議
-				advice, err := strategyService.GetOpenAdvice(ctx, instID)
-				if err != nil {
-					log.Error("Failed to get open advice", map[string]any{
-						"error": err,
-					})
-					continue
-				}
-
-				// 輸出建議結果
-				if advice.ShouldOpen {
-					log.Info("✅ Order Service: SHOULD OPEN POSITION", map[string]any{
-						"advice": advice,
-					})
-				} else {
-					log.Debug("❌ Order Service: Should not open", map[string]any{
-						"reason": advice.Reason,
-					})
+				for _, instID := range cfg.Strategy.Instruments {
+					queryAdvice(ctx, instID, strategyServices[instID])
 				}
 			}
 		}
@@ -148,5 +272,26 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down Trading Strategy Server...")
+
+	// 優雅關閉：停止新的詢問，等待進行中的建議計算完成後再取消 context ⭐
+	// Strategy Service 無狀態（倉位由 Order Service 管理），不需要落盤
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	close(stopQueries)
+	for instID, strategyService := range strategyServices {
+		if err := strategyService.Shutdown(shutdownCtx); err != nil {
+			log.Warn("Timed out waiting for in-flight advice", map[string]any{
+				"instId": instID,
+				"error":  err,
+			})
+		}
+	}
+	select {
+	case <-queriesDone:
+	case <-shutdownCtx.Done():
+	}
 	cancel() // Cancel context to stop subscriptions
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to shutdown health server", map[string]any{"error": err})
+	}
 }
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrAdviceExpired 開倉建議已過期（建議到執行之間延遲過久或價格已明顯變動）
+var ErrAdviceExpired = errors.New("advice expired")
+
+// AdviceTTL 開倉建議時效配置 ⭐
+// 用於模擬建議產生到實際下單之間的延遲：
+// 建議過舊或價格已明顯偏離時，應在執行前拒絕
+type AdviceTTL struct {
+	MaxAge        time.Duration // 建議最大有效時間（0 = 不檢查）
+	MaxPriceDrift float64       // 允許的最大價格偏移比例（例: 0.001 = 0.1%，0 = 不檢查）
+}
+
+// Check 在執行建議前檢查是否仍然有效
+// 參數：
+//   - issuedAt: 建議產生時間
+//   - priceAtIssue: 建議產生時的價格
+//   - currentPrice: 執行前的最新價格
+//   - now: 當前時間
+//
+// 返回：建議已過期時返回包裝 ErrAdviceExpired 的錯誤
+func (t AdviceTTL) Check(issuedAt time.Time, priceAtIssue, currentPrice float64, now time.Time) error {
+	if age := now.Sub(issuedAt); t.MaxAge > 0 && age > t.MaxAge {
+		return fmt.Errorf("%w: age %s exceeds %s", ErrAdviceExpired, age, t.MaxAge)
+	}
+
+	if t.MaxPriceDrift > 0 && priceAtIssue > 0 {
+		drift := math.Abs(currentPrice-priceAtIssue) / priceAtIssue
+		if drift > t.MaxPriceDrift {
+			return fmt.Errorf("%w: price drift %.4f%% exceeds %.4f%%",
+				ErrAdviceExpired, drift*100, t.MaxPriceDrift*100)
+		}
+	}
+
+	return nil
+}
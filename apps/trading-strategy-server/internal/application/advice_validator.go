@@ -0,0 +1,105 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// 開倉建議校驗錯誤（可用 errors.Is 判斷）
+var (
+	ErrInvalidAdvicePrice  = errors.New("invalid advice price") // 價格無法解析或非正數
+	ErrBelowMinSize        = errors.New("below min order size") // 倉位低於最小下單金額
+	ErrPriceOutOfBand      = errors.New("price out of band")    // 開倉價偏離市價過多
+	ErrInsufficientBalance = errors.New("insufficient balance") // 餘額不足以支付倉位 + 手續費
+)
+
+// AccountState 帳戶狀態（校驗用，實盤前為模擬餘額）
+type AccountState struct {
+	AvailableBalance float64 // 可用餘額（USDT）
+	FeeRate          float64 // 開倉手續費率（例: 0.0005 = 0.05%）
+}
+
+// AdviceValidatorConfig 開倉建議校驗配置
+type AdviceValidatorConfig struct {
+	MinOrderSize      float64 // 最小下單金額（USDT）
+	MaxPriceDeviation float64 // 開倉價相對市價的最大偏離比例（例: 0.01 = 1%）
+	MinBaseSize       float64 // 交易所最小下單數量（幣，來自交易對規格 minSz × ctVal；0 = 不檢查）⭐
+}
+
+// AdviceValidator 開倉建議校驗器（安全層）⭐
+// 在建議送往 Order Service 之前，按帳戶約束進行乾跑校驗
+type AdviceValidator struct {
+	config AdviceValidatorConfig
+}
+
+// NewAdviceValidator 創建開倉建議校驗器
+func NewAdviceValidator(config AdviceValidatorConfig) *AdviceValidator {
+	return &AdviceValidator{config: config}
+}
+
+// Validate 校驗開倉建議
+//
+// 檢查順序：
+//  1. 價格有效（開倉價、平倉價、市價皆為正數）
+//  2. 倉位 >= 最小下單金額，且按開倉價換算的數量 >= 交易所最小下單數量
+//  3. 開倉價在市價的允許偏離範圍內
+//  4. 可用餘額 >= 倉位 + 開倉手續費
+//
+// 返回：不通過時返回包裝對應錯誤類型的 error；不建議開倉的 advice 直接通過
+func (v *AdviceValidator) Validate(advice grid.OpenAdvice, accountState AccountState) error {
+	if !advice.ShouldOpen {
+		return nil
+	}
+
+	openPrice, err := parsePositivePrice(advice.OpenPrice)
+	if err != nil {
+		return fmt.Errorf("%w: open price %q", ErrInvalidAdvicePrice, advice.OpenPrice)
+	}
+	if _, err := parsePositivePrice(advice.ClosePrice); err != nil {
+		return fmt.Errorf("%w: close price %q", ErrInvalidAdvicePrice, advice.ClosePrice)
+	}
+	currentPrice, err := parsePositivePrice(advice.CurrentPrice)
+	if err != nil {
+		return fmt.Errorf("%w: current price %q", ErrInvalidAdvicePrice, advice.CurrentPrice)
+	}
+
+	if advice.PositionSize < v.config.MinOrderSize || advice.PositionSize <= 0 {
+		return fmt.Errorf("%w: size %.2f < min %.2f USDT", ErrBelowMinSize, advice.PositionSize, v.config.MinOrderSize)
+	}
+	if baseSize := advice.PositionSize / openPrice; baseSize < v.config.MinBaseSize {
+		return fmt.Errorf("%w: quantity %.8f < exchange min %.8f at price %.2f",
+			ErrBelowMinSize, baseSize, v.config.MinBaseSize, openPrice)
+	}
+
+	if v.config.MaxPriceDeviation > 0 {
+		deviation := math.Abs(openPrice-currentPrice) / currentPrice
+		if deviation > v.config.MaxPriceDeviation {
+			return fmt.Errorf("%w: open price %.2f deviates %.4f%% from market %.2f (max %.4f%%)",
+				ErrPriceOutOfBand, openPrice, deviation*100, currentPrice, v.config.MaxPriceDeviation*100)
+		}
+	}
+
+	requiredBalance := advice.PositionSize * (1 + accountState.FeeRate)
+	if accountState.AvailableBalance < requiredBalance {
+		return fmt.Errorf("%w: need %.2f USDT, have %.2f USDT",
+			ErrInsufficientBalance, requiredBalance, accountState.AvailableBalance)
+	}
+
+	return nil
+}
+
+// parsePositivePrice 解析價格字符串並要求為正數
+func parsePositivePrice(value string) (float64, error) {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0, errors.New("price must be positive")
+	}
+	return price, nil
+}
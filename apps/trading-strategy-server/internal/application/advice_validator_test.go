@@ -0,0 +1,151 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+func validAdvice() grid.OpenAdvice {
+	return grid.OpenAdvice{
+		ShouldOpen:     true,
+		CurrentPrice:   "2500",
+		OpenPrice:      "2497.5",
+		ClosePrice:     "2501.25",
+		PositionSize:   200,
+		TakeProfitRate: 0.0015,
+		Reason:         "simulated_advice",
+	}
+}
+
+// TestAdviceValidator_Validate 測試每一種拒絕條件
+func TestAdviceValidator_Validate(t *testing.T) {
+	validator := NewAdviceValidator(AdviceValidatorConfig{
+		MinOrderSize:      10,
+		MaxPriceDeviation: 0.01, // 1%
+		MinBaseSize:       0.01, // 交易所最小 0.01 ETH
+	})
+	account := AccountState{AvailableBalance: 1000, FeeRate: 0.0005}
+
+	tests := []struct {
+		name    string
+		modify  func(a *grid.OpenAdvice)
+		account AccountState
+		wantErr error
+	}{
+		{
+			name:    "有效建議",
+			modify:  func(a *grid.OpenAdvice) {},
+			account: account,
+			wantErr: nil,
+		},
+		{
+			name:    "開倉價無法解析",
+			modify:  func(a *grid.OpenAdvice) { a.OpenPrice = "abc" },
+			account: account,
+			wantErr: ErrInvalidAdvicePrice,
+		},
+		{
+			name:    "平倉價為零",
+			modify:  func(a *grid.OpenAdvice) { a.ClosePrice = "0" },
+			account: account,
+			wantErr: ErrInvalidAdvicePrice,
+		},
+		{
+			name:    "低於最小下單金額",
+			modify:  func(a *grid.OpenAdvice) { a.PositionSize = 5 },
+			account: account,
+			wantErr: ErrBelowMinSize,
+		},
+		{
+			name:    "低於交易所最小下單數量",
+			modify:  func(a *grid.OpenAdvice) { a.PositionSize = 20 }, // 20 / 2497.5 ≈ 0.008 ETH
+			account: account,
+			wantErr: ErrBelowMinSize,
+		},
+		{
+			name:    "開倉價偏離市價過多",
+			modify:  func(a *grid.OpenAdvice) { a.OpenPrice = "2400" }, // -4%
+			account: account,
+			wantErr: ErrPriceOutOfBand,
+		},
+		{
+			name:    "餘額不足（含手續費）",
+			modify:  func(a *grid.OpenAdvice) {},
+			account: AccountState{AvailableBalance: 200, FeeRate: 0.0005}, // 需要 200.1
+			wantErr: ErrInsufficientBalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advice := validAdvice()
+			tt.modify(&advice)
+
+			err := validator.Validate(advice, tt.account)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestAdviceValidator_SkipsShouldNotOpen 測試不開倉的建議不做校驗
+func TestAdviceValidator_SkipsShouldNotOpen(t *testing.T) {
+	validator := NewAdviceValidator(AdviceValidatorConfig{MinOrderSize: 10})
+
+	advice := grid.OpenAdvice{ShouldOpen: false, Reason: "trend_filter_blocked"}
+	if err := validator.Validate(advice, AccountState{}); err != nil {
+		t.Errorf("Expected no error for should-not-open advice, got %v", err)
+	}
+}
+
+// TestStrategyService_GetOpenAdvice_DowngradesRejectedAdvice 測試校驗失敗時降級為不開倉
+func TestStrategyService_GetOpenAdvice_DowngradesRejectedAdvice(t *testing.T) {
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, time.Now())
+	price, _ := value_objects.NewPrice(2505)
+	reader := &fakeMarketDataReader{candle: candle, price: price}
+
+	service := NewStrategyService(gridAggregate, reader, "5m", logger.NewMulti())
+	service.SetAdviceValidator(
+		NewAdviceValidator(AdviceValidatorConfig{MinOrderSize: 10, MaxPriceDeviation: 0.01}),
+		AccountState{AvailableBalance: 50, FeeRate: 0.0005}, // 餘額不足
+	)
+
+	advice, err := service.GetOpenAdvice(context.Background(), "ETH-USDT")
+	if err != nil {
+		t.Fatalf("GetOpenAdvice failed: %v", err)
+	}
+
+	if advice.ShouldOpen {
+		t.Error("Expected ShouldOpen to be downgraded to false")
+	}
+	if !strings.HasPrefix(advice.Reason, "validation_rejected:") {
+		t.Errorf("Expected validation reason, got %s", advice.Reason)
+	}
+	if !strings.Contains(advice.Reason, ErrInsufficientBalance.Error()) {
+		t.Errorf("Expected insufficient balance reason, got %s", advice.Reason)
+	}
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DrawdownBreaker 回撤熔斷器（實盤 kill switch）⭐
+// 記錄權益峰值，權益從峰值回撤超過閾值時跳閘；跳閘後保持狀態直到 Reset
+type DrawdownBreaker struct {
+	mu          sync.Mutex
+	maxDrawdown float64 // 最大允許回撤比例（例: 0.2 = 20%）
+	peak        float64 // 權益峰值
+	tripped     bool    // 是否已跳閘
+	reason      string  // 跳閘原因
+}
+
+// NewDrawdownBreaker 創建回撤熔斷器
+func NewDrawdownBreaker(maxDrawdown float64) (*DrawdownBreaker, error) {
+	if maxDrawdown <= 0 || maxDrawdown >= 1 {
+		return nil, fmt.Errorf("max drawdown must be in (0, 1), got %v", maxDrawdown)
+	}
+	return &DrawdownBreaker{maxDrawdown: maxDrawdown}, nil
+}
+
+// Update 記錄最新權益，返回是否在本次調用中跳閘（已跳閘時返回 false）
+func (b *DrawdownBreaker) Update(equity float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tripped {
+		return false
+	}
+	if equity > b.peak {
+		b.peak = equity
+	}
+	if b.peak <= 0 {
+		return false
+	}
+
+	drawdown := (b.peak - equity) / b.peak
+	if drawdown < b.maxDrawdown {
+		return false
+	}
+
+	b.tripped = true
+	b.reason = fmt.Sprintf("drawdown_halt: equity=%.2f peak=%.2f drawdown=%.2f%% (max %.2f%%)",
+		equity, b.peak, drawdown*100, b.maxDrawdown*100)
+	return true
+}
+
+// Tripped 是否已跳閘，以及跳閘原因
+func (b *DrawdownBreaker) Tripped() (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tripped, b.reason
+}
+
+// Reset 人工確認後重置熔斷器（以當前權益作為新峰值）
+func (b *DrawdownBreaker) Reset(equity float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripped = false
+	b.reason = ""
+	b.peak = equity
+}
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+func TestDrawdownBreaker_TripsAtThreshold(t *testing.T) {
+	breaker, err := NewDrawdownBreaker(0.2)
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+
+	// 峰值 12000，回撤 19% 不跳閘，20% 跳閘
+	for _, equity := range []float64{10000, 12000, 9720} {
+		if breaker.Update(equity) {
+			t.Fatalf("Unexpected trip at equity %.2f", equity)
+		}
+	}
+	if !breaker.Update(9600) {
+		t.Fatal("Expected trip at 20% drawdown")
+	}
+	if tripped, reason := breaker.Tripped(); !tripped || !strings.HasPrefix(reason, "drawdown_halt:") {
+		t.Errorf("Expected tripped with drawdown_halt reason, got %v %q", tripped, reason)
+	}
+	// 已跳閘時不重複觸發
+	if breaker.Update(5000) {
+		t.Error("Expected no repeated trip")
+	}
+
+	breaker.Reset(9600)
+	if tripped, _ := breaker.Tripped(); tripped {
+		t.Error("Expected breaker to be reset")
+	}
+
+	for _, value := range []float64{0, -0.1, 1} {
+		if _, err := NewDrawdownBreaker(value); err == nil {
+			t.Errorf("Expected error for max drawdown %v", value)
+		}
+	}
+}
+
+// TestStrategyService_DrawdownBreakerPauses 測試回撤熔斷跳閘後策略服務暫停開倉
+func TestStrategyService_DrawdownBreakerPauses(t *testing.T) {
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, time.Now())
+	price, _ := value_objects.NewPrice(2505)
+	service := NewStrategyService(gridAggregate, &fakeMarketDataReader{candle: candle, price: price}, "5m", logger.NewMulti())
+
+	breaker, _ := NewDrawdownBreaker(0.1)
+	service.SetDrawdownBreaker(breaker)
+
+	getAdvice := func() *grid.OpenAdvice {
+		advice, err := service.GetOpenAdvice(context.Background(), "ETH-USDT")
+		if err != nil {
+			t.Fatalf("GetOpenAdvice failed: %v", err)
+		}
+		return advice
+	}
+
+	service.RecordEquity(10000)
+	if !getAdvice().ShouldOpen {
+		t.Fatal("Expected open advice before the breaker trips")
+	}
+
+	// 崩盤：權益回撤 15%
+	if !service.RecordEquity(8500) {
+		t.Fatal("Expected breaker to trip and pause the service")
+	}
+	if paused, _ := service.IsPaused(); !paused {
+		t.Fatal("Expected service to be paused")
+	}
+	advice := getAdvice()
+	if advice.ShouldOpen || !strings.HasPrefix(advice.Reason, "strategy_paused: drawdown_halt:") {
+		t.Errorf("Expected paused advice, got ShouldOpen=%v reason=%q", advice.ShouldOpen, advice.Reason)
+	}
+
+	service.Resume()
+	if !getAdvice().ShouldOpen {
+		t.Error("Expected open advice after resume")
+	}
+}
@@ -0,0 +1,377 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/simulator"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+	"github.com/shopspring/decimal"
+)
+
+// paperStateVersion 模擬盤狀態文件的格式版本（格式不兼容時遞增）
+const paperStateVersion = 1
+
+// PaperTradingConfig 模擬盤配置
+type PaperTradingConfig struct {
+	InstID         string  // 交易對
+	InitialBalance float64 // 初始模擬餘額（USDT，沒有狀態文件時使用）
+	FeeRate        float64 // 手續費率（與回測一致，例: 0.0005）
+	Slippage       float64 // 滑點率（0 = 按建議價成交）
+	StatePath      string  // 狀態文件路徑（空 = 不持久化，重啟後從初始餘額開始）
+}
+
+// PaperFill 一筆模擬成交
+type PaperFill struct {
+	Time        time.Time // 成交時間（觸發成交的K線時間）
+	Action      string    // "OPEN" / "CLOSE"
+	PositionID  string    // 持倉ID
+	Price       float64   // 成交價
+	Size        float64   // 倉位大小（USDT）
+	Fee         float64   // 手續費
+	RealizedPnL float64   // 已實現盈虧（只有 CLOSE）
+	Balance     float64   // 成交後的模擬餘額
+	Reason      string    // 開倉建議原因或平倉原因
+}
+
+// paperState 模擬盤的持久化狀態（decimal 以字符串序列化，恢復後精確一致）
+type paperState struct {
+	Version          int
+	InstID           string
+	Balance          decimal.Decimal           // 模擬餘額
+	FeesPaid         decimal.Decimal           // 已支付的總手續費
+	RoundRealizedPnL decimal.Decimal           // 當前輪次已實現盈虧
+	RoundClosedValue decimal.Decimal           // 當前輪次累積關倉價值
+	LastCandleTime   time.Time                 // 最後處理的K線時間（重啟後不重複處理）
+	Tracker          simulator.TrackerSnapshot // 持倉
+}
+
+// PaperTradingService 模擬盤服務：實盤行情 + 模擬成交 ⭐
+//
+// 從 MarketDataReader 讀取實盤K線和價格，策略建議不發送到交易所，
+// 而是交給 OrderSimulator / PositionTracker 按回測的規則成交，維護模擬餘額。
+// 每處理一根新K線後把餘額和持倉寫入狀態文件，重啟後從文件恢復
+type PaperTradingService struct {
+	grid       *grid.GridAggregate
+	dataReader MarketDataReader
+	bar        string
+	config     PaperTradingConfig
+	simulator  *simulator.OrderSimulator
+	tracker    *simulator.PositionTracker
+	logger     logger.Logger
+
+	mu    sync.Mutex
+	state paperState
+}
+
+// NewPaperTradingService 創建模擬盤服務
+//
+// StatePath 指向的文件存在時從中恢復餘額和持倉（交易對必須一致）
+func NewPaperTradingService(
+	grid *grid.GridAggregate,
+	dataReader MarketDataReader,
+	bar string,
+	config PaperTradingConfig,
+	logger logger.Logger,
+) (*PaperTradingService, error) {
+	if config.InitialBalance <= 0 {
+		return nil, fmt.Errorf("initial balance must be positive, got %v", config.InitialBalance)
+	}
+	if config.Slippage < 0 || config.Slippage >= 1 {
+		return nil, fmt.Errorf("slippage must be in [0, 1), got %v", config.Slippage)
+	}
+
+	s := &PaperTradingService{
+		grid:       grid,
+		dataReader: dataReader,
+		bar:        bar,
+		config:     config,
+		simulator:  simulator.NewOrderSimulator(config.FeeRate, config.Slippage),
+		tracker:    simulator.NewPositionTracker(),
+		logger:     logger,
+		state: paperState{
+			Version: paperStateVersion,
+			InstID:  config.InstID,
+			Balance: decimal.NewFromFloat(config.InitialBalance),
+		},
+	}
+
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Step 處理最新的已確認K線：先檢查止盈，再按策略建議開倉 ⭐
+//
+// 同一根K線只處理一次（實盤輪詢時最新K線未變化則不做任何事），返回本次產生的模擬成交。
+// 最新K線尚未確認時改用歷史中最新的已確認K線，未完成的K線不驅動決策，也不推進 LastCandleTime
+func (s *PaperTradingService) Step(ctx context.Context) ([]PaperFill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instID := s.config.InstID
+	candle, err := s.dataReader.GetLatestCandle(ctx, instID, s.bar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest candle: %w", err)
+	}
+	newestFirst, err := s.dataReader.GetCandleHistories(ctx, instID, s.bar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candle histories: %w", err)
+	}
+	if !candle.IsConfirmed() {
+		if len(newestFirst) == 0 || !newestFirst[0].IsConfirmed() {
+			return nil, nil
+		}
+		candle, newestFirst = newestFirst[0], newestFirst[1:]
+	}
+	if !s.state.LastCandleTime.IsZero() && !candle.Timestamp().After(s.state.LastCandleTime) {
+		return nil, nil
+	}
+
+	price, err := s.dataReader.GetLatestPrice(ctx, instID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest price: %w", err)
+	}
+
+	now := candle.Timestamp()
+	var fills []PaperFill
+
+	// 1. 止盈：K線最高價觸及止盈價的倉位按止盈價平倉（與回測默認的 FillPriceModel 一致）
+	// 止盈價與回測一樣經 TakeProfitTarget 計算（ProfitVsAverageCost 時按平均成本重算），並按價格-時間優先成交
+	avgCost := s.tracker.CalculateAverageCost()
+	targetSummary := value_objects.PositionSummary{
+		Count:     s.tracker.GetOpenPositionCount(),
+		TotalSize: s.tracker.GetTotalSize(),
+		AvgPrice:  avgCost,
+	}
+	queue := simulator.TakeProfitQueue(s.tracker.GetOpenPositions(), func(pos simulator.Position) float64 {
+		return s.grid.TakeProfitTarget(pos.EntryPrice, pos.TargetClosePrice, targetSummary)
+	})
+	for _, order := range queue {
+		if candle.High().Value() < order.Target {
+			continue
+		}
+		fill, err := s.close(order.Position, order.Target, now, avgCost, fmt.Sprintf("hit_target_%.2f", order.Target))
+		if err != nil {
+			return fills, err
+		}
+		fills = append(fills, fill)
+	}
+
+	// 2. 策略建議（傳入模擬持倉的摘要，打平等依賴持倉的邏輯與回測一致）
+	previousCandle, histories := liveCandleInputs(candle, newestFirst)
+	advice := s.grid.GetOpenAdvice(price, candle, previousCandle, histories, s.positionSummary(price.Value()))
+
+	switch {
+	case advice.ExitRound:
+		// 3a. 打平或整輪止盈：按當前價平掉所有倉位
+		avgCost := s.tracker.CalculateAverageCost()
+		for _, pos := range s.tracker.GetOpenPositions() {
+			fill, err := s.close(pos, price.Value(), now, avgCost, advice.Reason)
+			if err != nil {
+				return fills, err
+			}
+			fills = append(fills, fill)
+		}
+
+	case advice.ShouldOpen:
+		// 3b. 開倉：餘額不足時跳過
+		if s.state.Balance.LessThan(s.simulator.EstimateOpenCost(advice.PositionSize)) {
+			s.logger.Warn("Paper trading: insufficient balance, open skipped", map[string]any{
+				"instId":  instID,
+				"balance": s.state.Balance.InexactFloat64(),
+				"size":    advice.PositionSize,
+			})
+			break
+		}
+		fill, err := s.open(advice, now)
+		if err != nil {
+			return fills, err
+		}
+		fills = append(fills, fill)
+	}
+
+	s.state.LastCandleTime = now
+	if err := s.saveState(); err != nil {
+		return fills, err
+	}
+	return fills, nil
+}
+
+// open 模擬開倉並加入持倉
+func (s *PaperTradingService) open(advice grid.OpenAdvice, now time.Time) (PaperFill, error) {
+	position, cost, err := s.simulator.SimulateOpen(simulator.OpenAdvice{
+		ShouldOpen:   advice.ShouldOpen,
+		CurrentPrice: advice.CurrentPrice,
+		OpenPrice:    advice.OpenPrice,
+		ClosePrice:   advice.ClosePrice,
+		PositionSize: advice.PositionSize,
+		TakeProfit:   advice.TakeProfitRate,
+		Reason:       advice.Reason,
+		Tag:          advice.Tag,
+	}, s.state.Balance.InexactFloat64(), now)
+	if err != nil {
+		return PaperFill{}, fmt.Errorf("failed to simulate open: %w", err)
+	}
+
+	added, err := s.tracker.AddPositionWithTag(
+		position.EntryPrice,
+		position.Size,
+		position.Coins,
+		position.OpenTime,
+		position.TargetClosePrice,
+		position.Tag,
+	)
+	if err != nil {
+		return PaperFill{}, fmt.Errorf("failed to track position: %w", err)
+	}
+
+	fee := s.simulator.Fee(decimal.NewFromFloat(position.Size))
+	s.state.Balance = s.state.Balance.Sub(decimal.NewFromFloat(cost))
+	s.state.FeesPaid = s.state.FeesPaid.Add(fee)
+
+	return s.recordFill(PaperFill{
+		Time:       now,
+		Action:     "OPEN",
+		PositionID: added.ID,
+		Price:      added.EntryPrice,
+		Size:       added.Size,
+		Fee:        fee.InexactFloat64(),
+		Balance:    s.state.Balance.InexactFloat64(),
+		Reason:     advice.Reason,
+	}), nil
+}
+
+// close 模擬平倉並更新當前輪次（全部平倉後開始新一輪）
+func (s *PaperTradingService) close(pos simulator.Position, closePrice float64, now time.Time, avgCost float64, reason string) (PaperFill, error) {
+	result, err := s.simulator.SimulateClose(pos, closePrice, now, avgCost)
+	if err != nil {
+		return PaperFill{}, fmt.Errorf("failed to simulate close of %s: %w", pos.ID, err)
+	}
+	closed := result.ClosedPosition
+	if err := s.tracker.ClosePosition(pos.ID, closed.ClosePrice, closed.CloseTime, closed.RealizedPnL); err != nil {
+		return PaperFill{}, fmt.Errorf("failed to close %s: %w", pos.ID, err)
+	}
+
+	s.state.Balance = s.state.Balance.Add(decimal.NewFromFloat(result.Revenue))
+	s.state.FeesPaid = s.state.FeesPaid.Add(decimal.NewFromFloat(result.CloseFee))
+	s.state.RoundRealizedPnL = s.state.RoundRealizedPnL.Add(decimal.NewFromFloat(closed.RealizedPnL))
+	s.state.RoundClosedValue = s.state.RoundClosedValue.Add(decimal.NewFromFloat(result.CloseValue))
+	if !s.tracker.HasOpenPositions() {
+		s.state.RoundRealizedPnL = decimal.Zero
+		s.state.RoundClosedValue = decimal.Zero
+	}
+
+	return s.recordFill(PaperFill{
+		Time:        now,
+		Action:      "CLOSE",
+		PositionID:  pos.ID,
+		Price:       closed.ClosePrice,
+		Size:        pos.Size,
+		Fee:         result.CloseFee,
+		RealizedPnL: closed.RealizedPnL,
+		Balance:     s.state.Balance.InexactFloat64(),
+		Reason:      reason,
+	}), nil
+}
+
+// recordFill 記錄模擬成交日誌
+func (s *PaperTradingService) recordFill(fill PaperFill) PaperFill {
+	s.logger.Info("Paper fill", map[string]any{
+		"instId":      s.config.InstID,
+		"action":      fill.Action,
+		"positionId":  fill.PositionID,
+		"price":       fill.Price,
+		"size":        fill.Size,
+		"fee":         fill.Fee,
+		"realizedPnL": fill.RealizedPnL,
+		"balance":     fill.Balance,
+		"reason":      fill.Reason,
+	})
+	return fill
+}
+
+// positionSummary 按模擬持倉計算倉位摘要
+func (s *PaperTradingService) positionSummary(currentPrice float64) value_objects.PositionSummary {
+	return value_objects.NewPositionSummary(
+		s.tracker.GetOpenPositionCount(),
+		s.tracker.GetTotalSize(),
+		s.tracker.CalculateAverageCost(),
+		s.state.FeesPaid.InexactFloat64(),
+		s.state.RoundRealizedPnL.InexactFloat64(),
+		s.state.RoundClosedValue.InexactFloat64(),
+		s.tracker.CalculateUnrealizedPnL(currentPrice, s.config.FeeRate),
+	).WithEntryPrices(s.tracker.OpenEntryPrices())
+}
+
+// Balance 當前模擬餘額
+func (s *PaperTradingService) Balance() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Balance.InexactFloat64()
+}
+
+// OpenPositions 當前模擬持倉
+func (s *PaperTradingService) OpenPositions() []simulator.Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.GetOpenPositions()
+}
+
+// loadState 從狀態文件恢復（文件不存在時保持初始狀態）
+func (s *PaperTradingService) loadState() error {
+	if s.config.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.config.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read paper trading state: %w", err)
+	}
+
+	var state paperState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse paper trading state: %w", err)
+	}
+	if state.Version != paperStateVersion {
+		return fmt.Errorf("unsupported paper trading state version %d (expected %d)", state.Version, paperStateVersion)
+	}
+	if state.InstID != s.config.InstID {
+		return fmt.Errorf("paper trading state is for %s, not %s", state.InstID, s.config.InstID)
+	}
+
+	s.state = state
+	s.tracker.Restore(state.Tracker)
+	return nil
+}
+
+// saveState 寫入狀態文件（先寫臨時文件再改名，避免中途退出留下不完整的文件）
+func (s *PaperTradingService) saveState() error {
+	if s.config.StatePath == "" {
+		return nil
+	}
+	s.state.Tracker = s.tracker.Snapshot()
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode paper trading state: %w", err)
+	}
+
+	tmp := s.config.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write paper trading state: %w", err)
+	}
+	if err := os.Rename(tmp, s.config.StatePath); err != nil {
+		return fmt.Errorf("failed to write paper trading state: %w", err)
+	}
+	return nil
+}
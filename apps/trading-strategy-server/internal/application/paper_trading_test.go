@@ -0,0 +1,264 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+	"dizzycode.xyz/trading-strategy-server/internal/infrastructure/replay"
+)
+
+// buildPaperCandles 構建正弦行情（反覆觸及止盈，產生開倉和平倉）
+func buildPaperCandles(t *testing.T, n int) []value_objects.Candle {
+	t.Helper()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]value_objects.Candle, 0, n)
+	prev := 2500.0
+	for i := 0; i < n; i++ {
+		closePrice := 2500 + 20*math.Sin(float64(i)/5)
+		high := max(prev, closePrice) * 1.001
+		low := min(prev, closePrice) * 0.999
+		candle, err := value_objects.NewCandle(prev, high, low, closePrice, start.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle %d: %v", i, err)
+		}
+		candles = append(candles, candle)
+		prev = closePrice
+	}
+	return candles
+}
+
+func newPaperGrid(t *testing.T) *grid.GridAggregate {
+	t.Helper()
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		FeeRate:           0.0005,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	return gridAggregate
+}
+
+// TestPaperTradingService_ReplayProducesFills 用回放讀取器驅動模擬盤，應產生開倉和平倉 ⭐
+func TestPaperTradingService_ReplayProducesFills(t *testing.T) {
+	candles := buildPaperCandles(t, 120)
+	reader := replay.NewReplayMarketDataReader(candles, 0)
+	statePath := filepath.Join(t.TempDir(), "paper.json")
+	config := PaperTradingConfig{
+		InstID:         "ETH-USDT",
+		InitialBalance: 10000,
+		FeeRate:        0.0005,
+		StatePath:      statePath,
+	}
+
+	service, err := NewPaperTradingService(newPaperGrid(t), reader, "5m", config, logger.NewMulti())
+	if err != nil {
+		t.Fatalf("Failed to create paper trading service: %v", err)
+	}
+
+	ctx := context.Background()
+	opens, closes := 0, 0
+	for {
+		fills, err := service.Step(ctx)
+		if errors.Is(err, replay.ErrReplayExhausted) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		for _, fill := range fills {
+			switch fill.Action {
+			case "OPEN":
+				opens++
+			case "CLOSE":
+				closes++
+				if fill.RealizedPnL <= 0 {
+					t.Errorf("Expected take-profit close to be profitable, got %+v", fill)
+				}
+			}
+		}
+	}
+
+	if opens == 0 || closes == 0 {
+		t.Fatalf("Expected simulated opens and closes, got %d opens, %d closes", opens, closes)
+	}
+	if service.Balance() == 10000 {
+		t.Errorf("Expected balance to change after fills")
+	}
+	t.Logf("opens=%d closes=%d balance=%.4f open=%d", opens, closes, service.Balance(), len(service.OpenPositions()))
+
+	// 重啟：從狀態文件恢復餘額和持倉
+	restored, err := NewPaperTradingService(newPaperGrid(t), reader, "5m", config, logger.NewMulti())
+	if err != nil {
+		t.Fatalf("Failed to restore paper trading service: %v", err)
+	}
+	if restored.Balance() != service.Balance() {
+		t.Errorf("Expected restored balance %v, got %v", service.Balance(), restored.Balance())
+	}
+	if len(restored.OpenPositions()) != len(service.OpenPositions()) {
+		t.Errorf("Expected %d restored open positions, got %d", len(service.OpenPositions()), len(restored.OpenPositions()))
+	}
+
+	// 其他交易對的狀態文件不能恢復
+	config.InstID = "BTC-USDT"
+	if _, err := NewPaperTradingService(newPaperGrid(t), reader, "5m", config, logger.NewMulti()); err == nil {
+		t.Error("Expected error when restoring state of another instrument")
+	}
+}
+
+// scriptedMarketDataReader 返回固定的最新K線和歷史（新→舊）
+type scriptedMarketDataReader struct {
+	latest      value_objects.Candle
+	newestFirst []value_objects.Candle
+}
+
+func (r *scriptedMarketDataReader) GetLatestCandle(ctx context.Context, instID string, bar string) (value_objects.Candle, error) {
+	return r.latest, nil
+}
+
+func (r *scriptedMarketDataReader) GetCandleHistories(ctx context.Context, instID string, bar string) ([]value_objects.Candle, error) {
+	return r.newestFirst, nil
+}
+
+func (r *scriptedMarketDataReader) GetLatestPrice(ctx context.Context, instID string) (value_objects.Price, error) {
+	return r.latest.Close(), nil
+}
+
+// TestPaperTradingService_SkipsUnconfirmedLatestCandle 最新K線未確認時處理最新的已確認K線，未確認的K線不推進 LastCandleTime ⭐
+func TestPaperTradingService_SkipsUnconfirmedLatestCandle(t *testing.T) {
+	candles := buildPaperCandles(t, 30)
+	confirmed := candles[len(candles)-2]
+	forming := candles[len(candles)-1].WithConfirmed(false)
+	newestFirst := chronologicalHistories(candles[:len(candles)-1])
+
+	reader := &scriptedMarketDataReader{latest: forming, newestFirst: newestFirst}
+	config := PaperTradingConfig{
+		InstID:         "ETH-USDT",
+		InitialBalance: 10000,
+		FeeRate:        0.0005,
+		StatePath:      filepath.Join(t.TempDir(), "paper.json"),
+	}
+	service, err := NewPaperTradingService(newPaperGrid(t), reader, "5m", config, logger.NewMulti())
+	if err != nil {
+		t.Fatalf("Failed to create paper trading service: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := service.Step(ctx); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if !service.state.LastCandleTime.Equal(confirmed.Timestamp()) {
+		t.Fatalf("Expected newest confirmed candle %v to be processed, got LastCandleTime %v",
+			confirmed.Timestamp(), service.state.LastCandleTime)
+	}
+
+	// 同一根未確認K線再次輪詢：不重複處理已確認的K線
+	balance := service.Balance()
+	fills, err := service.Step(ctx)
+	if err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if len(fills) != 0 || service.Balance() != balance {
+		t.Errorf("Expected no fills while the latest candle is still forming, got %+v", fills)
+	}
+
+	// K線收盤確認後才處理
+	reader.latest = candles[len(candles)-1]
+	if _, err := service.Step(ctx); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if !service.state.LastCandleTime.Equal(reader.latest.Timestamp()) {
+		t.Errorf("Expected confirmed candle %v to be processed, got LastCandleTime %v",
+			reader.latest.Timestamp(), service.state.LastCandleTime)
+	}
+}
+
+// TestPaperTradingService_NoConfirmedCandle 沒有任何已確認K線時不做任何事
+func TestPaperTradingService_NoConfirmedCandle(t *testing.T) {
+	candles := buildPaperCandles(t, 1)
+	reader := &scriptedMarketDataReader{latest: candles[0].WithConfirmed(false)}
+	config := PaperTradingConfig{
+		InstID:         "ETH-USDT",
+		InitialBalance: 10000,
+		FeeRate:        0.0005,
+		StatePath:      filepath.Join(t.TempDir(), "paper.json"),
+	}
+	service, err := NewPaperTradingService(newPaperGrid(t), reader, "5m", config, logger.NewMulti())
+	if err != nil {
+		t.Fatalf("Failed to create paper trading service: %v", err)
+	}
+
+	fills, err := service.Step(context.Background())
+	if err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if len(fills) != 0 || !service.state.LastCandleTime.IsZero() {
+		t.Errorf("Expected unconfirmed candle to be skipped, got fills %+v, LastCandleTime %v", fills, service.state.LastCandleTime)
+	}
+}
+
+// TestPaperTradingService_TakeProfitUsesStrategyTarget 止盈價經 TakeProfitTarget 計算並按價格-時間優先成交（與回測一致）⭐
+func TestPaperTradingService_TakeProfitUsesStrategyTarget(t *testing.T) {
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:              "ETH-USDT",
+		PositionSize:        200,
+		FeeRate:             0.0005,
+		TakeProfitRateMin:   0.002,
+		TakeProfitRateMax:   0.002,
+		ProfitVsAverageCost: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle, _ := value_objects.NewCandle(2450, 2460, 2445, 2455, start.Add(time.Hour))
+	reader := &scriptedMarketDataReader{latest: candle}
+	service, err := NewPaperTradingService(gridAggregate, reader, "5m", PaperTradingConfig{
+		InstID:         "ETH-USDT",
+		InitialBalance: 10000,
+		FeeRate:        0.0005,
+	}, logger.NewMulti())
+	if err != nil {
+		t.Fatalf("Failed to create paper trading service: %v", err)
+	}
+
+	// 開倉時的止盈價：first 2505 未被觸及，second 2404.8 已被觸及；按平均成本重算後兩筆都被觸及
+	first, _ := service.tracker.AddPosition(2500, 200, start, 2505)
+	second, _ := service.tracker.AddPosition(2400, 200, start.Add(5*time.Minute), 2404.8)
+	summary := value_objects.PositionSummary{Count: 2, TotalSize: 400, AvgPrice: service.tracker.CalculateAverageCost()}
+	target := gridAggregate.TakeProfitTarget(first.EntryPrice, first.TargetClosePrice, summary)
+	if target >= first.TargetClosePrice || target > candle.High().Value() {
+		t.Fatalf("Test setup: expected the average-cost target %.2f to be hit below %.2f", target, first.TargetClosePrice)
+	}
+
+	fills, err := service.Step(context.Background())
+	if err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	var closed []PaperFill
+	for _, fill := range fills {
+		if fill.Action == "CLOSE" {
+			closed = append(closed, fill)
+		}
+	}
+	if len(closed) != 2 || closed[0].PositionID != first.ID || closed[1].PositionID != second.ID {
+		t.Fatalf("Expected %s then %s to close, got %+v", first.ID, second.ID, closed)
+	}
+	for _, fill := range closed {
+		if fill.Price != target {
+			t.Errorf("Expected %s to close at the average-cost target %.2f, got %.2f", fill.PositionID, target, fill.Price)
+		}
+	}
+}
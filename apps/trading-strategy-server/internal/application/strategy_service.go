@@ -2,6 +2,9 @@ package application
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 
 	"dizzycode.xyz/logger"
 	"dizzycode.xyz/shared/domain/value_objects"
@@ -24,6 +27,9 @@ type MarketDataReader interface {
 	GetCandleHistories(ctx context.Context, instID string, bar string) ([]value_objects.Candle, error)
 }
 
+// ErrShuttingDown 服務正在關閉，不再接受新的建議查詢
+var ErrShuttingDown = errors.New("strategy service is shutting down")
+
 // StrategyService 策略應用服務（被動諮詢模式）⭐
 // 職責：
 // 1. 編排領域對象
@@ -32,30 +38,143 @@ type MarketDataReader interface {
 type StrategyService struct {
 	grid       *grid.GridAggregate // ⭐ 直接使用 GridAggregate
 	dataReader MarketDataReader    // ⭐ 新增：從 Redis 讀取市場數據
+	bar        string              // K線週期（例: 1m, 5m, 1H）⭐
+	validator  *AdviceValidator    // 開倉建議校驗器（nil = 不校驗）⭐
+	account    AccountState        // 校驗用帳戶狀態（實盤前為模擬餘額）
 	logger     logger.Logger
+
+	// 暫停開倉（kill switch）⭐
+	mu          sync.Mutex
+	paused      bool             // 暫停時 GetOpenAdvice 一律返回不開倉
+	pauseReason string           // 暫停原因
+	breaker     *DrawdownBreaker // 回撤熔斷器（nil = 不啟用），跳閘時調用 Pause
+
+	// 優雅關閉 ⭐
+	inFlight     sync.WaitGroup // 進行中的 GetOpenAdvice（只在 mu 下且未關閉時 Add）
+	shuttingDown bool           // Shutdown 後拒絕新的查詢
 }
 
 // NewStrategyService 創建策略服務
 func NewStrategyService(
 	grid *grid.GridAggregate, // ⭐ 接受 GridAggregate
 	dataReader MarketDataReader, // ⭐ 新增參數
+	bar string, // ⭐ K線週期（例: 1m, 5m, 1H）
 	logger logger.Logger,
 ) *StrategyService {
 	return &StrategyService{
 		grid:       grid,
 		dataReader: dataReader,
+		bar:        bar,
 		logger:     logger,
 	}
 }
 
+// SetAdviceValidator 設置開倉建議校驗器和帳戶狀態 ⭐
+// 設置後，GetOpenAdvice 會在返回前校驗建議，校驗失敗時降級為不開倉
+func (s *StrategyService) SetAdviceValidator(validator *AdviceValidator, account AccountState) {
+	s.validator = validator
+	s.account = account
+}
+
+// SetDrawdownBreaker 設置回撤熔斷器 ⭐
+// 設置後，RecordEquity 在權益回撤超過閾值時自動暫停開倉。
+// 熔斷器只看 RecordEquity 回報的權益：需由持有帳戶的一方（Order Service）在每次成交或盈虧更新後回報
+func (s *StrategyService) SetDrawdownBreaker(breaker *DrawdownBreaker) {
+	s.breaker = breaker
+}
+
+// RecordEquity 記錄帳戶最新權益，回撤熔斷器跳閘時暫停開倉 ⭐
+// 返回：是否在本次調用中觸發暫停
+func (s *StrategyService) RecordEquity(equity float64) bool {
+	if s.breaker == nil || !s.breaker.Update(equity) {
+		return false
+	}
+
+	_, reason := s.breaker.Tripped()
+	s.pause(reason)
+	s.logger.Error("Drawdown circuit breaker tripped, strategy paused", map[string]any{
+		"instId": s.grid.InstID,
+		"equity": equity,
+		"reason": reason,
+	})
+	return true
+}
+
+// Pause 暫停開倉（人工 kill switch）
+func (s *StrategyService) Pause() {
+	s.pause("manual_pause")
+}
+
+// pause 按指定原因暫停開倉
+func (s *StrategyService) pause(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	s.pauseReason = reason
+}
+
+// Resume 恢復開倉（回撤熔斷器需另行 Reset，否則下次跳閘前不會重新觸發）
+func (s *StrategyService) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	s.pauseReason = ""
+}
+
+// IsPaused 是否已暫停開倉，以及暫停原因
+func (s *StrategyService) IsPaused() (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused, s.pauseReason
+}
+
+// Shutdown 優雅關閉：停止接受新的建議查詢，等待進行中的 GetOpenAdvice 完成 ⭐
+//
+// 之後的 GetOpenAdvice 返回 ErrShuttingDown；ctx 到期時不再等待並返回 ctx.Err()
+// （進行中的查詢仍會自行結束）。可重複調用
+func (s *StrategyService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shuttingDown = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// beginRequest 登記一個進行中的查詢；已關閉時返回 false
+func (s *StrategyService) beginRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
 // GetOpenAdvice 獲取開倉建議（被動諮詢用例）⭐
 // 這是應用層的入口方法
 func (s *StrategyService) GetOpenAdvice(
 	ctx context.Context,
 	instID string,
 ) (*grid.OpenAdvice, error) {
+	if !s.beginRequest() {
+		return nil, ErrShuttingDown
+	}
+	defer s.inFlight.Done()
+
 	// 1. 從 Redis 讀取最新的已確認 Candle（歷史第一根）⭐
-	lastCandle, err := s.dataReader.GetLatestCandle(ctx, instID, "5m")
+	lastCandle, err := s.dataReader.GetLatestCandle(ctx, instID, s.bar)
 	if err != nil {
 		s.logger.Error("Failed to get last confirmed candle", map[string]any{
 			"error":  err,
@@ -64,7 +183,7 @@ func (s *StrategyService) GetOpenAdvice(
 		return nil, err
 	}
 
-	candlehistories, err := s.dataReader.GetCandleHistories(ctx, instID, "5m")
+	candlehistories, err := s.dataReader.GetCandleHistories(ctx, instID, s.bar)
 	if err != nil {
 		s.logger.Error("Failed to get last confirmed candle", map[string]any{
 			"error":  err,
@@ -88,17 +207,36 @@ func (s *StrategyService) GetOpenAdvice(
 		return nil, err
 	}
 
-	// 2. 創建價格值對象
-
-	// 3. 創建空的倉位摘要（Strategy Service 無狀態，倉位由 Order Service 管理）⭐
+	// 2. 創建空的倉位摘要（Strategy Service 無狀態，倉位由 Order Service 管理）⭐
 	// TODO: 未來可能需要從 Order Service 獲取倉位摘要
 	emptyPositionSummary := value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0) // ⭐ 包含 currentRoundRealizedPnL 和 currentRoundClosedValue
 
-	// 4. 調用領域邏輯獲取建議 ⭐ 傳入倉位摘要
+	// 3. 調用領域邏輯獲取建議 ⭐ 傳入倉位摘要
 	// 注意：實盤中使用 lastCandle 作為 currentCandle（因為當前K線還未結束）
-	advice := s.grid.GetOpenAdvice(currentPrice, lastCandle, candlehistories[0], candlehistories, emptyPositionSummary)
+	previousCandle, histories := liveCandleInputs(lastCandle, candlehistories)
+	advice := s.grid.GetOpenAdvice(currentPrice, lastCandle, previousCandle, histories, emptyPositionSummary)
 
-	// 4. 記錄日誌
+	// 4. 安全層：按帳戶約束校驗建議，不通過則降級為不開倉 ⭐
+	if s.validator != nil {
+		if err := s.validator.Validate(advice, s.account); err != nil {
+			s.logger.Warn("Open advice rejected by validator", map[string]any{
+				"error":     err,
+				"instId":    instID,
+				"openPrice": advice.OpenPrice,
+				"size":      advice.PositionSize,
+			})
+			advice.ShouldOpen = false
+			advice.Reason = fmt.Sprintf("validation_rejected: %v", err)
+		}
+	}
+
+	// 5. 暫停時（人工或回撤熔斷）不開倉 ⭐
+	if paused, reason := s.IsPaused(); paused && advice.ShouldOpen {
+		advice.ShouldOpen = false
+		advice.Reason = fmt.Sprintf("strategy_paused: %s", reason)
+	}
+
+	// 6. 記錄日誌
 	// if advice.ShouldOpen {
 	// 	s.logger.Info("Open advice: SHOULD OPEN", map[string]any{
 	// 		"currentPrice": currentPrice,
@@ -117,6 +255,28 @@ func (s *StrategyService) GetOpenAdvice(
 	return &advice, nil
 }
 
+// liveCandleInputs 把從 Redis 讀取的K線整理成 GetOpenAdvice 的輸入，與回測一致 ⭐
+//
+// Redis 歷史列表是 LPUSH 寫入（新→舊），領域層（趨勢分析、區間分層）需要舊→新；
+// 上一根K線為歷史中最新的一根，歷史為空時使用 latest
+func liveCandleInputs(latest value_objects.Candle, newestFirst []value_objects.Candle) (previous value_objects.Candle, histories []value_objects.Candle) {
+	histories = chronologicalHistories(newestFirst)
+	previous = latest
+	if len(histories) > 0 {
+		previous = histories[len(histories)-1]
+	}
+	return previous, histories
+}
+
+// chronologicalHistories 將 Redis 的新→舊歷史列表轉為舊→新（不修改原切片）
+func chronologicalHistories(newestFirst []value_objects.Candle) []value_objects.Candle {
+	histories := make([]value_objects.Candle, len(newestFirst))
+	for i, candle := range newestFirst {
+		histories[len(newestFirst)-1-i] = candle
+	}
+	return histories
+}
+
 // GetStrategyState 獲取策略狀態（查詢用例）
 func (s *StrategyService) GetStrategyState() map[string]any {
 	return s.grid.GetState()
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// blockingMarketDataReader 讀取最新K線時阻塞，直到 release 被關閉（模擬慢速 Redis）
+type blockingMarketDataReader struct {
+	fakeMarketDataReader
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingMarketDataReader) GetLatestCandle(ctx context.Context, instID string, bar string) (value_objects.Candle, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return b.candle, nil
+}
+
+func newShutdownTestService(t *testing.T) (*StrategyService, *blockingMarketDataReader) {
+	t.Helper()
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, time.Now())
+	price, _ := value_objects.NewPrice(2505)
+	reader := &blockingMarketDataReader{
+		fakeMarketDataReader: fakeMarketDataReader{candle: candle, price: price},
+		entered:              make(chan struct{}, 1),
+		release:              make(chan struct{}),
+	}
+	return NewStrategyService(gridAggregate, reader, "5m", logger.NewMulti()), reader
+}
+
+// TestStrategyService_ShutdownWaitsForInFlight 測試關閉時等待進行中的建議完成 ⭐
+func TestStrategyService_ShutdownWaitsForInFlight(t *testing.T) {
+	service, reader := newShutdownTestService(t)
+
+	type result struct {
+		advice *grid.OpenAdvice
+		err    error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		advice, err := service.GetOpenAdvice(context.Background(), "ETH-USDT")
+		inFlight <- result{advice, err}
+	}()
+	<-reader.entered
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- service.Shutdown(context.Background())
+	}()
+
+	// 進行中的查詢未完成前，Shutdown 不返回
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("Shutdown returned before the in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 關閉中拒絕新的查詢
+	if _, err := service.GetOpenAdvice(context.Background(), "ETH-USDT"); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown for a new request, got %v", err)
+	}
+
+	close(reader.release)
+
+	got := <-inFlight
+	if got.err != nil || got.advice == nil {
+		t.Fatalf("Expected in-flight request to complete, got %v / %v", got.advice, got.err)
+	}
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the in-flight request finished")
+	}
+}
+
+// TestStrategyService_ShutdownTimeout 測試等待超時時返回 context 錯誤
+func TestStrategyService_ShutdownTimeout(t *testing.T) {
+	service, reader := newShutdownTestService(t)
+	defer close(reader.release)
+
+	go service.GetOpenAdvice(context.Background(), "ETH-USDT")
+	<-reader.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := service.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/shared/rediskeys"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// fakeMarketDataReader 記錄讀取的 Redis key，返回固定數據
+type fakeMarketDataReader struct {
+	keys      []string
+	candle    value_objects.Candle
+	histories []value_objects.Candle // 歷史（新→舊，與 Redis 一致；nil 時只有 candle）
+	price     value_objects.Price
+}
+
+func (f *fakeMarketDataReader) GetLatestCandle(ctx context.Context, instID string, bar string) (value_objects.Candle, error) {
+	f.keys = append(f.keys, rediskeys.Default.CandleLatest(bar, instID))
+	return f.candle, nil
+}
+
+func (f *fakeMarketDataReader) GetCandleHistories(ctx context.Context, instID string, bar string) ([]value_objects.Candle, error) {
+	f.keys = append(f.keys, rediskeys.Default.CandleHistory(bar, instID))
+	if f.histories != nil {
+		return f.histories, nil
+	}
+	return []value_objects.Candle{f.candle}, nil
+}
+
+func (f *fakeMarketDataReader) GetLatestPrice(ctx context.Context, instID string) (value_objects.Price, error) {
+	f.keys = append(f.keys, rediskeys.Default.TickerLatest(instID))
+	return f.price, nil
+}
+
+// TestStrategyService_GetOpenAdvice_UsesConfiguredBar 測試使用配置的 K 線週期讀取 Redis
+func TestStrategyService_GetOpenAdvice_UsesConfiguredBar(t *testing.T) {
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, time.Now())
+	price, _ := value_objects.NewPrice(2505)
+	reader := &fakeMarketDataReader{candle: candle, price: price}
+
+	service := NewStrategyService(gridAggregate, reader, "1H", logger.NewMulti())
+
+	if _, err := service.GetOpenAdvice(context.Background(), "ETH-USDT"); err != nil {
+		t.Fatalf("GetOpenAdvice failed: %v", err)
+	}
+
+	found := false
+	for _, key := range reader.keys {
+		if key == "candle.history.1H.ETH-USDT" {
+			found = true
+		}
+		if key == "candle.history.5m.ETH-USDT" || key == "candle.latest.5m.ETH-USDT" {
+			t.Errorf("Unexpected 5m key read: %s", key)
+		}
+	}
+	if !found {
+		t.Errorf("Expected candle.history.1H.ETH-USDT to be read, got %v", reader.keys)
+	}
+}
+
+// TestStrategyService_GetOpenAdvice_CandleOrder 測試 Redis 的新→舊歷史以舊→新傳給領域層，上一根K線為最新的一根 ⭐
+func TestStrategyService_GetOpenAdvice_CandleOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	older, _ := value_objects.NewCandle(2500, 3000, 2000, 2500, start)                 // 區間 1000
+	newest, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, start.Add(time.Hour)) // 區間 20
+	price, _ := value_objects.NewPrice(2505)
+
+	for _, tc := range []struct {
+		name   string
+		config grid.GridConfig
+	}{
+		{
+			// 區間分層只看最近 1 根：順序顛倒時會用舊K線的 1000 區間
+			name: "history oldest first",
+			config: grid.GridConfig{
+				SpacingMode:          grid.GridSpacingRange,
+				SpacingRangeFraction: 1,
+				SpacingLookback:      1,
+			},
+		},
+		{
+			// 開倉價 = 上一根K線的 MidLow：上一根必須是最新的K線
+			name:   "previous candle is the newest",
+			config: grid.GridConfig{OpenReference: grid.OpenReferenceLastCandleMidLow},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.InstID = "ETH-USDT"
+			tc.config.PositionSize = 200
+			tc.config.TakeProfitRateMin = 0.0015
+			tc.config.TakeProfitRateMax = 0.002
+			gridAggregate, err := grid.NewGridAggregate(tc.config)
+			if err != nil {
+				t.Fatalf("Failed to create grid aggregate: %v", err)
+			}
+
+			reader := &fakeMarketDataReader{candle: newest, histories: []value_objects.Candle{newest, older}, price: price}
+			service := NewStrategyService(gridAggregate, reader, "1H", logger.NewMulti())
+			advice, err := service.GetOpenAdvice(context.Background(), "ETH-USDT")
+			if err != nil {
+				t.Fatalf("GetOpenAdvice failed: %v", err)
+			}
+
+			empty := value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0)
+			chronological := []value_objects.Candle{older, newest}
+			want := gridAggregate.GetOpenAdvice(price, newest, newest, chronological, empty)
+			if advice.OpenPrice != want.OpenPrice {
+				t.Errorf("Expected open price %s from oldest-first history, got %s", want.OpenPrice, advice.OpenPrice)
+			}
+			if reversed := gridAggregate.GetOpenAdvice(price, newest, older, reader.histories, empty); reversed.OpenPrice == want.OpenPrice {
+				t.Fatalf("Test candles do not distinguish the history order (open price %s)", want.OpenPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package grid
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// 開倉決策規則（純函數）⭐
+//
+// GetOpenAdvice 按順序組合以下規則，每條規則只依賴參數、不修改任何狀態，
+// 可以單獨測試（包括隨機輸入的性質測試）：
+//  1. ShouldBlockForTrend: 下跌趨勢禁止開多
+//  2. ShouldBreakEvenExit: 本輪虧損但整體可打平時，優先打平退出
+//     ShouldRoundProfitExit: 本輪總盈虧達到整輪止盈目標時，平掉所有倉位
+//  3. ShouldBlockForRedCandles: 持倉虧損時只在最近 M 根中至少 N 根紅K時開倉
+//  4. ComputeOpenClosePrices: 計算掛單價和止盈價
+//     ShouldBlockForPriceLevel: 掛單價離本輪已有倉位的開倉價太近時不開倉
+//  5. OpenTag: 按開倉時的持倉狀態為倉位打標籤（用於按標籤歸因盈虧）
+
+// ShouldBlockForTrend 趨勢過濾：趨勢分析器（經過 ConfirmCandles 確認後）不允許開多時返回 true 和原因
+//
+// 沒有歷史K線時不阻擋（無法判斷趨勢）
+func ShouldBlockForTrend(analyzer *TrendAnalyzer, candleHistories []value_objects.Candle) (bool, string) {
+	if analyzer == nil || len(candleHistories) == 0 {
+		return false, ""
+	}
+	if analyzer.ConfirmedCanOpenLong(candleHistories) {
+		return false, ""
+	}
+
+	trendInfo := analyzer.GetTrendInfo(candleHistories)
+	return true, fmt.Sprintf(
+		"trend_filter_blocked: trend=%s, ema_diff=%.2f%%, candle_change=%.2f%%",
+		trendInfo.Status,
+		trendInfo.EMADiffPercent,
+		trendInfo.CandleChange,
+	)
+}
+
+// ShouldBreakEvenExit 盈虧平衡檢查：有持倉且達到打平目標時返回 true 和原因（此時不開新倉）
+func ShouldBreakEvenExit(positionSummary value_objects.PositionSummary, profitMin, profitMax float64) (bool, string) {
+	if positionSummary.IsEmpty() {
+		return false, ""
+	}
+
+	shouldExit, expectedProfit := positionSummary.ShouldBreakEven(profitMin, profitMax)
+	if !shouldExit {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf(
+		"break_even_exit: expected_profit=%.2f USDT (target: %.0f-%.0f USDT)",
+		expectedProfit,
+		profitMin,
+		profitMax,
+	)
+}
+
+// ShouldRoundProfitExit 整輪止盈檢查：有持倉且本輪總盈虧達到目標時返回 true 和原因（此時不開新倉）
+//
+// targetProfit <= 0 時不啟用
+func ShouldRoundProfitExit(positionSummary value_objects.PositionSummary, targetProfit float64) (bool, string) {
+	shouldExit, expectedProfit := positionSummary.ShouldTakeRoundProfit(targetProfit)
+	if !shouldExit {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf(
+		"round_profit_exit: expected_profit=%.2f USDT (target: %.2f USDT)",
+		expectedProfit,
+		targetProfit,
+	)
+}
+
+// ShouldBlockForRedCandle 紅K過濾：持倉虧損（平均成本 > 現價）且當前為非紅K時返回 true 和原因
+func ShouldBlockForRedCandle(positionSummary value_objects.PositionSummary, currentPrice float64, currentCandle value_objects.Candle) (bool, string) {
+	if positionSummary.IsEmpty() {
+		return false, ""
+	}
+
+	avgCost := positionSummary.AvgPrice
+	if avgCost <= currentPrice {
+		return false, ""
+	}
+
+	isRedCandle := currentCandle.Close().Value() < currentCandle.Open().Value() // 紅K = Close < Open
+	if isRedCandle {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf(
+		"red_candle_filter: loss_state_green_candle (avgCost=%.2f, price=%.2f, close=%.2f, open=%.2f)",
+		avgCost,
+		currentPrice,
+		currentCandle.Close().Value(),
+		currentCandle.Open().Value(),
+	)
+}
+
+// ShouldBlockForRedCandles 紅K過濾（N of M）：持倉虧損且最近 lookback 根K線中紅K少於 minRed 根時返回 true 和原因 ⭐
+//
+// 最近 lookback 根 = candleHistories 的最後 lookback-1 根（舊→新）+ 當前K線；
+// 歷史不足時只統計可用的K線，紅K數仍需達到 minRed。
+// lookback <= 1 且 minRed <= 1 時等同於 ShouldBlockForRedCandle（只看當前K線）
+func ShouldBlockForRedCandles(
+	positionSummary value_objects.PositionSummary,
+	currentPrice float64,
+	currentCandle value_objects.Candle,
+	candleHistories []value_objects.Candle,
+	lookback, minRed int,
+) (bool, string) {
+	if lookback <= 1 && minRed <= 1 {
+		return ShouldBlockForRedCandle(positionSummary, currentPrice, currentCandle)
+	}
+	if positionSummary.IsEmpty() {
+		return false, ""
+	}
+
+	avgCost := positionSummary.AvgPrice
+	if avgCost <= currentPrice {
+		return false, ""
+	}
+
+	redCount := countRedCandles(currentCandle, candleHistories, lookback)
+	if redCount >= minRed {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf(
+		"red_candle_filter: loss_state_insufficient_red (red=%d/%d, required=%d, avgCost=%.2f, price=%.2f)",
+		redCount,
+		lookback,
+		minRed,
+		avgCost,
+		currentPrice,
+	)
+}
+
+// countRedCandles 統計當前K線和最近 lookback-1 根歷史K線中的紅K數量
+func countRedCandles(currentCandle value_objects.Candle, candleHistories []value_objects.Candle, lookback int) int {
+	window := candleHistories
+	if n := lookback - 1; len(window) > n {
+		window = window[len(window)-n:]
+	}
+
+	count := 0
+	for _, candle := range append(window[:len(window):len(window)], currentCandle) {
+		if candle.Close().Value() < candle.Open().Value() { // 紅K = Close < Open
+			count++
+		}
+	}
+	return count
+}
+
+// ReasonPriceLevelOccupied 掛單價所在價位已有本輪倉位（OpenAdvice.Reason）
+const ReasonPriceLevelOccupied = "price_level_occupied"
+
+// ShouldBlockForPriceLevel 價位去重：掛單價與本輪任一未平倉位開倉價的相對距離小於 minGap 時返回 true ⭐
+//
+// 行情來回震盪時避免在幾乎相同的價位重複開倉（風險集中在同一價位）。
+// minGap <= 0 或摘要沒有開倉價信息（PositionSummary.EntryPrices）時不阻擋
+func ShouldBlockForPriceLevel(positionSummary value_objects.PositionSummary, openPrice, minGap float64) (bool, string) {
+	if minGap <= 0 {
+		return false, ""
+	}
+	distance, ok := positionSummary.NearestEntryDistance(openPrice)
+	if !ok || distance >= minGap {
+		return false, ""
+	}
+	return true, ReasonPriceLevelOccupied
+}
+
+// 開倉標籤（OpenAdvice.Tag）
+const (
+	TagInitialEntry = "initial_entry" // 本輪首次開倉（空倉）
+	TagDipBuy       = "dip_buy"       // 持倉虧損時加倉（現價低於平均成本）
+	TagAddOn        = "add_on"        // 持倉盈利時加倉（現價不低於平均成本）
+)
+
+// OpenTag 按開倉時的持倉狀態返回開倉標籤 ⭐
+func OpenTag(positionSummary value_objects.PositionSummary, currentPrice float64) string {
+	switch {
+	case positionSummary.IsEmpty():
+		return TagInitialEntry
+	case positionSummary.AvgPrice > currentPrice:
+		return TagDipBuy
+	default:
+		return TagAddOn
+	}
+}
+
+// OpenClosePrices 掛單價和止盈價的計算結果
+type OpenClosePrices struct {
+	OpenPrice      decimal.Decimal // 開倉價（無條件舍去到小數點後 2 位）
+	ClosePrice     decimal.Decimal // 止盈價（無條件進位到小數點後 2 位）
+	TakeProfitRate float64         // 實際止盈比例（被最小淨利潤拉高時大於 takeProfitRate）
+}
+
+// ComputeOpenClosePrices 計算開倉價和止盈價 ⭐
+//
+// 參數：
+//   - currentPrice: 當前價格
+//   - openDiscountRate: 開倉價相對市價的折扣（例: 0.001 = 0.1%）
+//   - takeProfitRate: 止盈比例（例: 0.0015 = 0.15%）
+//   - positionSize, feeRate, minNetProfit: 最小淨利潤保護（minNetProfit <= 0 表示不限制）
+//
+// 規則：
+//   - 開倉價 = 當前價格 × (1 - 折扣)，無條件舍去到小數點第 2 位
+//   - 止盈價 = 開倉價 × (1 + 止盈比例)，無條件進位到小數點第 2 位
+//   - 止盈價扣除手續費後的淨利潤不足 minNetProfit 時，拉高止盈價
+func ComputeOpenClosePrices(currentPrice, openDiscountRate, takeProfitRate, positionSize, feeRate, minNetProfit float64) OpenClosePrices {
+	return ComputeOpenClosePricesWithTick(currentPrice, openDiscountRate, takeProfitRate, positionSize, feeRate, minNetProfit, 0)
+}
+
+// ComputeOpenClosePricesWithTick 與 ComputeOpenClosePrices 相同，但按交易所價格精度（tickSize）取整 ⭐
+//
+// 開倉價向下取整到 tickSize 的整數倍，止盈價向上取整；tickSize <= 0 時使用默認的小數點後 2 位
+func ComputeOpenClosePricesWithTick(currentPrice, openDiscountRate, takeProfitRate, positionSize, feeRate, minNetProfit, tickSize float64) OpenClosePrices {
+	// ✅ 使用 decimal 进行精确计算
+	currentPriceDecimal := decimal.NewFromFloat(currentPrice)
+
+	// 计算因子
+	openDiscountFactor := decimal.NewFromFloat(1 - openDiscountRate) // 固定模式: 1 - 0.001 = 0.999
+	takeProfitFactor := decimal.NewFromFloat(1 + takeProfitRate)     // 1 + 0.0015 = 1.0015
+
+	// 计算开仓价格：当前价格 * (1 - 折扣)，无条件舍去到价格精度
+	openPriceDecimal := floorToTick(currentPriceDecimal.Mul(openDiscountFactor), tickSize)
+
+	// 计算平仓价格：开仓价格 * 1.0015，无条件进位到价格精度
+	closePriceDecimal := ceilToTick(openPriceDecimal.Mul(takeProfitFactor), tickSize)
+
+	// ⭐ 最小淨利潤保護：止盈價扣除手續費後不足門檻時，拉高止盈價
+	effectiveRate := takeProfitRate
+	if minViable := minViableClosePrice(openPriceDecimal, positionSize, feeRate, minNetProfit); closePriceDecimal.LessThan(minViable) {
+		closePriceDecimal = ceilToTick(minViable, tickSize)
+		effectiveRate = closePriceDecimal.Div(openPriceDecimal).Sub(decimal.NewFromInt(1)).InexactFloat64()
+	}
+
+	return OpenClosePrices{
+		OpenPrice:      openPriceDecimal,
+		ClosePrice:     closePriceDecimal,
+		TakeProfitRate: effectiveRate,
+	}
+}
+
+// AverageCostTakeProfitPrice 按本輪平均成本計算止盈價 ⭐
+//
+// 止盈價 = AvgPrice × (1 + takeProfitRate)，無條件進位到小數點第 2 位（與 ComputeOpenClosePrices 一致）
+//
+// 返回：沒有持倉時返回 0
+func AverageCostTakeProfitPrice(positionSummary value_objects.PositionSummary, takeProfitRate float64) decimal.Decimal {
+	return AverageCostTakeProfitPriceWithTick(positionSummary, takeProfitRate, 0)
+}
+
+// AverageCostTakeProfitPriceWithTick 與 AverageCostTakeProfitPrice 相同，但向上取整到 tickSize（<= 0 = 小數點後 2 位）
+func AverageCostTakeProfitPriceWithTick(positionSummary value_objects.PositionSummary, takeProfitRate, tickSize float64) decimal.Decimal {
+	if positionSummary.IsEmpty() || positionSummary.AvgPrice <= 0 {
+		return decimal.Zero
+	}
+
+	return ceilToTick(decimal.NewFromFloat(positionSummary.AvgPrice).Mul(decimal.NewFromFloat(1+takeProfitRate)), tickSize)
+}
+
+// 未指定價格精度時的默認取整位數（小數點後 2 位）
+const defaultPricePlaces = 2
+
+// floorToTick 無條件舍去到 tickSize 的整數倍（tickSize <= 0 時舍去到小數點後 2 位）
+func floorToTick(price decimal.Decimal, tickSize float64) decimal.Decimal {
+	if tickSize <= 0 {
+		return price.Truncate(defaultPricePlaces)
+	}
+	tick := decimal.NewFromFloat(tickSize)
+	return price.Div(tick).Floor().Mul(tick)
+}
+
+// ceilToTick 無條件進位到 tickSize 的整數倍（tickSize <= 0 時進位到小數點後 2 位）
+func ceilToTick(price decimal.Decimal, tickSize float64) decimal.Decimal {
+	if tickSize <= 0 {
+		// 实现无条件进位：先乘以 100，向上取整，再除以 100
+		shift := decimal.New(1, defaultPricePlaces)
+		return price.Mul(shift).Ceil().Div(shift)
+	}
+	tick := decimal.NewFromFloat(tickSize)
+	return price.Div(tick).Ceil().Mul(tick)
+}
@@ -0,0 +1,324 @@
+package grid
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// propertyIterations 性質測試的隨機樣本數
+const propertyIterations = 2000
+
+// randomCandle 生成隨機K線（價格範圍 [1, 100000]）
+func randomCandle(rng *rand.Rand, ts time.Time) value_objects.Candle {
+	open := 1 + rng.Float64()*99999
+	closePrice := open * (1 + (rng.Float64()-0.5)*0.02)
+	high := max(open, closePrice) * (1 + rng.Float64()*0.01)
+	low := min(open, closePrice) * (1 - rng.Float64()*0.01)
+	candle, _ := value_objects.NewCandle(open, high, low, closePrice, ts)
+	return candle
+}
+
+// randomSummary 生成隨機倉位摘要（約 1/4 為空倉）
+func randomSummary(rng *rand.Rand, price float64) value_objects.PositionSummary {
+	if rng.Intn(4) == 0 {
+		return value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0)
+	}
+	count := 1 + rng.Intn(20)
+	return value_objects.NewPositionSummary(
+		count,
+		float64(count)*200,
+		price*(0.9+rng.Float64()*0.2),
+		rng.Float64()*5,
+		(rng.Float64()-0.5)*50,
+		rng.Float64()*1000,
+		(rng.Float64()-0.5)*50,
+	)
+}
+
+// TestComputeOpenClosePrices_Properties 性質測試：開倉價 < 當前價，止盈價 > 開倉價
+func TestComputeOpenClosePrices_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < propertyIterations; i++ {
+		currentPrice := 1 + rng.Float64()*99999
+		discount := fixedOpenDiscountRate + rng.Float64()*0.05
+		takeProfit := 0.0005 + rng.Float64()*0.02
+		minNetProfit := 0.0
+		if rng.Intn(2) == 0 {
+			minNetProfit = rng.Float64() * 2
+		}
+
+		prices := ComputeOpenClosePrices(currentPrice, discount, takeProfit, 200, 0.0005, minNetProfit)
+		current := decimal.NewFromFloat(currentPrice)
+
+		if !prices.OpenPrice.LessThan(current) {
+			t.Fatalf("case %d: open price %s should be < current price %s", i, prices.OpenPrice, current)
+		}
+		if !prices.ClosePrice.GreaterThan(prices.OpenPrice) {
+			t.Fatalf("case %d: close price %s should be > open price %s", i, prices.ClosePrice, prices.OpenPrice)
+		}
+		if prices.TakeProfitRate < takeProfit {
+			t.Fatalf("case %d: effective take profit %.6f should be >= configured %.6f", i, prices.TakeProfitRate, takeProfit)
+		}
+		if !prices.OpenPrice.Equal(prices.OpenPrice.Truncate(2)) || !prices.ClosePrice.Equal(prices.ClosePrice.Truncate(2)) {
+			t.Fatalf("case %d: prices should have at most 2 decimals, got %s / %s", i, prices.OpenPrice, prices.ClosePrice)
+		}
+	}
+}
+
+// TestComputeOpenClosePricesWithTick 測試按交易所價格精度取整 ⭐
+func TestComputeOpenClosePricesWithTick(t *testing.T) {
+	// 0.1 精度：2500 × 0.999 = 2497.5 → 開倉 2497.5；2497.5 × 1.0015 = 2501.24625 → 止盈 2501.3
+	prices := ComputeOpenClosePricesWithTick(2500, fixedOpenDiscountRate, 0.0015, 200, 0.0005, 0, 0.1)
+	if !prices.OpenPrice.Equal(decimal.RequireFromString("2497.5")) {
+		t.Errorf("Expected open price 2497.5, got %s", prices.OpenPrice)
+	}
+	if !prices.ClosePrice.Equal(decimal.RequireFromString("2501.3")) {
+		t.Errorf("Expected close price 2501.3, got %s", prices.ClosePrice)
+	}
+
+	// 低價幣 0.0001 精度：小數點後 2 位會把價格截斷成 0.12
+	prices = ComputeOpenClosePricesWithTick(0.12345, fixedOpenDiscountRate, 0.0015, 200, 0.0005, 0, 0.0001)
+	if !prices.OpenPrice.Equal(decimal.RequireFromString("0.1233")) {
+		t.Errorf("Expected open price 0.1233, got %s", prices.OpenPrice)
+	}
+	if !prices.ClosePrice.Equal(decimal.RequireFromString("0.1235")) {
+		t.Errorf("Expected close price 0.1235, got %s", prices.ClosePrice)
+	}
+
+	// tickSize = 0 與 ComputeOpenClosePrices 一致
+	withTick := ComputeOpenClosePricesWithTick(3893.83, fixedOpenDiscountRate, 0.0015, 200, 0.0005, 0.5, 0)
+	legacy := ComputeOpenClosePrices(3893.83, fixedOpenDiscountRate, 0.0015, 200, 0.0005, 0.5)
+	if !withTick.OpenPrice.Equal(legacy.OpenPrice) || !withTick.ClosePrice.Equal(legacy.ClosePrice) {
+		t.Errorf("Expected tickSize 0 to match 2-decimal rounding, got %s/%s vs %s/%s",
+			withTick.OpenPrice, withTick.ClosePrice, legacy.OpenPrice, legacy.ClosePrice)
+	}
+}
+
+// TestShouldBlockForRedCandle_Properties 性質測試：空倉或盈利時從不阻擋，虧損時只放行紅K
+func TestShouldBlockForRedCandle_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	now := time.Now()
+
+	for i := 0; i < propertyIterations; i++ {
+		candle := randomCandle(rng, now)
+		price := candle.Close().Value()
+		summary := randomSummary(rng, price)
+
+		blocked, reason := ShouldBlockForRedCandle(summary, price, candle)
+
+		inLoss := !summary.IsEmpty() && summary.AvgPrice > price
+		isRed := candle.Close().Value() < candle.Open().Value()
+		if blocked != (inLoss && !isRed) {
+			t.Fatalf("case %d: blocked=%v, inLoss=%v, isRed=%v", i, blocked, inLoss, isRed)
+		}
+		if blocked != strings.HasPrefix(reason, "red_candle_filter:") {
+			t.Fatalf("case %d: reason %q inconsistent with blocked=%v", i, reason, blocked)
+		}
+	}
+}
+
+// TestShouldBreakEvenExit_Properties 性質測試：空倉從不打平，結果與 PositionSummary.ShouldBreakEven 一致
+func TestShouldBreakEvenExit_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+
+	for i := 0; i < propertyIterations; i++ {
+		summary := randomSummary(rng, 2500)
+		profitMin := rng.Float64() * 10
+		profitMax := profitMin + rng.Float64()*20
+
+		exit, reason := ShouldBreakEvenExit(summary, profitMin, profitMax)
+
+		if summary.IsEmpty() && exit {
+			t.Fatalf("case %d: empty position should never break even", i)
+		}
+		want, _ := summary.ShouldBreakEven(profitMin, profitMax)
+		if exit != want {
+			t.Fatalf("case %d: expected exit=%v, got %v", i, want, exit)
+		}
+		if exit != strings.HasPrefix(reason, "break_even_exit:") {
+			t.Fatalf("case %d: reason %q inconsistent with exit=%v", i, reason, exit)
+		}
+	}
+}
+
+// TestShouldRoundProfitExit_Properties 性質測試：空倉或未設目標時從不退出，結果與 PositionSummary.ShouldTakeRoundProfit 一致
+func TestShouldRoundProfitExit_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+
+	for i := 0; i < propertyIterations; i++ {
+		summary := randomSummary(rng, 2500)
+		target := rng.Float64() * 20
+		if i%10 == 0 {
+			target = 0
+		}
+
+		exit, reason := ShouldRoundProfitExit(summary, target)
+
+		if (summary.IsEmpty() || target == 0) && exit {
+			t.Fatalf("case %d: should not exit (empty=%v, target=%v)", i, summary.IsEmpty(), target)
+		}
+		want, _ := summary.ShouldTakeRoundProfit(target)
+		if exit != want {
+			t.Fatalf("case %d: expected exit=%v, got %v", i, want, exit)
+		}
+		if exit != strings.HasPrefix(reason, "round_profit_exit:") {
+			t.Fatalf("case %d: reason %q inconsistent with exit=%v", i, reason, exit)
+		}
+	}
+}
+
+// TestShouldBlockForTrend_NoHistory 測試沒有歷史K線或沒有分析器時不阻擋
+func TestShouldBlockForTrend_NoHistory(t *testing.T) {
+	analyzer := NewTrendAnalyzer(TrendAnalyzerConfig{})
+	if blocked, _ := ShouldBlockForTrend(analyzer, nil); blocked {
+		t.Error("Expected no block without history")
+	}
+
+	rng := rand.New(rand.NewSource(3))
+	histories := []value_objects.Candle{randomCandle(rng, time.Now())}
+	if blocked, _ := ShouldBlockForTrend(nil, histories); blocked {
+		t.Error("Expected no block without analyzer")
+	}
+}
+
+// TestGetOpenAdvice_ComposesRules 性質測試：GetOpenAdvice 的結果與單獨規則的組合一致
+func TestGetOpenAdvice_ComposesRules(t *testing.T) {
+	rng := rand.New(rand.NewSource(99))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	g, err := NewGridAggregate(GridConfig{
+		InstID:                "ETH-USDT-SWAP",
+		PositionSize:          200,
+		FeeRate:               0.0005,
+		TakeProfitRateMin:     0.0015,
+		TakeProfitRateMax:     0.002,
+		BreakEvenProfitMin:    0,
+		BreakEvenProfitMax:    20,
+		EnableTrendFilter:     true,
+		EnableRedCandleFilter: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	for i := 0; i < 300; i++ {
+		histories := make([]value_objects.Candle, 60)
+		for j := range histories {
+			histories[j] = randomCandle(rng, start.Add(time.Duration(j)*5*time.Minute))
+		}
+		currentCandle := randomCandle(rng, start.Add(60*5*time.Minute))
+		currentPrice := currentCandle.Close()
+		summary := randomSummary(rng, currentPrice.Value())
+
+		advice := g.GetOpenAdvice(currentPrice, currentCandle, histories[len(histories)-1], histories, summary)
+
+		trendBlocked, _ := ShouldBlockForTrend(g.TrendAnalyzer, histories)
+		breakEven, _ := ShouldBreakEvenExit(summary, g.BreakEvenProfitMin, g.BreakEvenProfitMax)
+		redBlocked, _ := ShouldBlockForRedCandle(summary, currentPrice.Value(), currentCandle)
+
+		wantOpen := !trendBlocked && !breakEven && !redBlocked
+		if advice.ShouldOpen != wantOpen {
+			t.Fatalf("case %d: expected ShouldOpen=%v (trend=%v, breakEven=%v, red=%v), got %v (%s)",
+				i, wantOpen, trendBlocked, breakEven, redBlocked, advice.ShouldOpen, advice.Reason)
+		}
+	}
+}
+
+// colorCandles 按顏色序列生成K線（'r' = 紅K，'g' = 綠K）
+func colorCandles(t *testing.T, colors string) []value_objects.Candle {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]value_objects.Candle, len(colors))
+	for i, c := range colors {
+		open, closePrice := 100.0, 101.0
+		if c == 'r' {
+			open, closePrice = 101.0, 100.0
+		}
+		candle, err := value_objects.NewCandle(open, 102, 99, closePrice, start.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle: %v", err)
+		}
+		candles[i] = candle
+	}
+	return candles
+}
+
+// TestShouldBlockForRedCandles_NofM 測試虧損時最近 M 根中至少 N 根紅K才放行
+func TestShouldBlockForRedCandles_NofM(t *testing.T) {
+	// 平均成本 110 > 現價 100 → 虧損狀態
+	inLoss := value_objects.NewPositionSummary(2, 400, 110, 0, 0, 0, 0)
+
+	tests := []struct {
+		name        string
+		colors      string // 舊→新，最後一根為當前K線
+		lookback    int
+		minRed      int
+		wantBlocked bool
+	}{
+		{"1 of 1 red current", "ggr", 1, 1, false},
+		{"1 of 1 green current", "rrg", 1, 1, true},
+		{"2 of 3 satisfied with green current", "grrg", 3, 2, false},
+		{"2 of 3 not satisfied", "rggr", 3, 2, true},
+		{"3 of 3 all red", "grrr", 3, 3, false},
+		{"3 of 3 one green", "rgrr", 3, 3, true},
+		{"window ignores older reds", "rrrggr", 3, 2, true},
+		{"short history counts available candles", "rr", 5, 2, false},
+		{"short history not enough reds", "gr", 5, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles := colorCandles(t, tt.colors)
+			current := candles[len(candles)-1]
+			histories := candles[:len(candles)-1]
+
+			blocked, reason := ShouldBlockForRedCandles(inLoss, 100, current, histories, tt.lookback, tt.minRed)
+			if blocked != tt.wantBlocked {
+				t.Fatalf("expected blocked=%v, got %v (%s)", tt.wantBlocked, blocked, reason)
+			}
+			if blocked && !strings.HasPrefix(reason, "red_candle_filter:") {
+				t.Errorf("unexpected reason %q", reason)
+			}
+		})
+	}
+}
+
+// TestShouldBlockForRedCandles_NotInLoss 測試空倉或盈利時從不阻擋
+func TestShouldBlockForRedCandles_NotInLoss(t *testing.T) {
+	candles := colorCandles(t, "gggg")
+	current := candles[len(candles)-1]
+	histories := candles[:len(candles)-1]
+
+	empty := value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0)
+	if blocked, _ := ShouldBlockForRedCandles(empty, 100, current, histories, 3, 2); blocked {
+		t.Error("Expected no block without position")
+	}
+
+	inProfit := value_objects.NewPositionSummary(2, 400, 90, 0, 0, 0, 0)
+	if blocked, _ := ShouldBlockForRedCandles(inProfit, 100, current, histories, 3, 2); blocked {
+		t.Error("Expected no block when position is in profit")
+	}
+}
+
+// TestOpenTag 測試開倉標籤：空倉首次開倉、虧損時加倉、盈利時加倉
+func TestOpenTag(t *testing.T) {
+	empty := value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0)
+	holding := value_objects.NewPositionSummary(2, 400, 110, 0, 0, 0, 0)
+
+	if got := OpenTag(empty, 100); got != TagInitialEntry {
+		t.Errorf("Expected %s for empty position, got %s", TagInitialEntry, got)
+	}
+	if got := OpenTag(holding, 100); got != TagDipBuy {
+		t.Errorf("Expected %s below average cost, got %s", TagDipBuy, got)
+	}
+	if got := OpenTag(holding, 120); got != TagAddOn {
+		t.Errorf("Expected %s above average cost, got %s", TagAddOn, got)
+	}
+}
@@ -0,0 +1,39 @@
+package grid
+
+// rollingEMA 逐根K线递推的 EMA 状态 ⭐
+//
+// 与 calculateEMA 的运算顺序完全相同：前 period 根依次累加后求 SMA 作为种子，
+// 之后按 EMA(t) = (Price(t) - EMA(t-1)) × multiplier + EMA(t-1) 递推。
+// 因此对同一组K线逐根 Update 得到的值，与对每个前缀调用 calculateEMA 的结果逐位相同，
+// 整个窗口只需 O(n)，而不是每个前缀都从头计算的 O(n²)
+type rollingEMA struct {
+	period     int
+	multiplier float64
+	count      int     // 已输入的价格数量
+	sum        float64 // 种子 SMA 的累加和（count < period 时使用）
+	value      float64 // 当前 EMA（count >= period 时有效）
+}
+
+// newRollingEMA 创建 EMA 状态（period 需为正数）
+func newRollingEMA(period int) *rollingEMA {
+	return &rollingEMA{
+		period:     period,
+		multiplier: 2.0 / float64(period+1),
+	}
+}
+
+// Update 输入下一根K线的价格，返回当前 EMA；输入不足 period 根时 ready = false（ema 为 0，与 calculateEMA 一致）
+func (r *rollingEMA) Update(price float64) (ema float64, ready bool) {
+	r.count++
+	if r.count < r.period {
+		r.sum += price
+		return 0, false
+	}
+	if r.count == r.period {
+		r.sum += price
+		r.value = r.sum / float64(r.period)
+		return r.value, true
+	}
+	r.value = (price-r.value)*r.multiplier + r.value
+	return r.value, true
+}
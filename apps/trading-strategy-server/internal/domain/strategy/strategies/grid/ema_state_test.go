@@ -0,0 +1,87 @@
+package grid
+
+import (
+	"testing"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
+)
+
+// naiveConfirmedCanOpenLong 逐个前缀重新计算 CanOpenLong 的参考实现（每个前缀都从头计算 EMA）
+func naiveConfirmedCanOpenLong(ta *TrendAnalyzer, candles []value_objects.Candle) bool {
+	if ta.confirmCandles <= 1 {
+		return ta.CanOpenLong(candles)
+	}
+	run, verdict := 0, true
+	for end := len(candles); end > 0; end-- {
+		current := ta.CanOpenLong(candles[:end])
+		if run > 0 && current == verdict {
+			run++
+		} else {
+			run, verdict = 1, current
+		}
+		if run >= ta.confirmCandles {
+			return verdict
+		}
+	}
+	return true
+}
+
+// TestRollingEMA_MatchesCalculateEMA 测试逐根递推的 EMA 与每个前缀从头计算的 EMA 一致 ⭐
+func TestRollingEMA_MatchesCalculateEMA(t *testing.T) {
+	candles := testutil.GenerateRandomWalk(7, 300, 2500, 0.004)
+	for _, source := range []PriceSource{PriceSourceClose, PriceSourceHLC3} {
+		ta := NewTrendAnalyzer(TrendAnalyzerConfig{PriceSource: source})
+		for _, period := range []int{1, 20, 50} {
+			ema := newRollingEMA(period)
+			for i, candle := range candles {
+				got, ready := ema.Update(ta.sourcePrice(candle))
+				want := ta.calculateEMA(candles[:i+1], period)
+				if ready != (i+1 >= period) {
+					t.Fatalf("%s period %d index %d: expected ready=%v", source, period, i, i+1 >= period)
+				}
+				if diff := got - want; diff > 1e-9 || diff < -1e-9 {
+					t.Fatalf("%s period %d index %d: incremental EMA %.10f != batch %.10f", source, period, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestConfirmedCanOpenLong_MatchesNaive 测试单次递推的确认判断与逐前缀重算的结论完全相同
+func TestConfirmedCanOpenLong_MatchesNaive(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		candles := testutil.GenerateRandomWalk(seed, 160, 2500, 0.006)
+		for _, confirm := range []int{1, 2, 3, 5} {
+			ta := NewTrendAnalyzer(TrendAnalyzerConfig{ConfirmCandles: confirm})
+			for end := 1; end <= len(candles); end++ {
+				window := candles[max(0, end-100):end]
+				if got, want := ta.ConfirmedCanOpenLong(window), naiveConfirmedCanOpenLong(ta, window); got != want {
+					t.Fatalf("seed %d confirm %d end %d: got %v, naive %v", seed, confirm, end, got, want)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkConfirmedCanOpenLong 每根K线对 100 根历史做确认判断（回测趋势过滤的热路径）
+//
+// ConfirmCandles = 100 时找不到足够长的连续结论，需要扫描每个前缀（最坏情况）
+func BenchmarkConfirmedCanOpenLong(b *testing.B) {
+	candles := testutil.GenerateRandomWalk(1, 100, 2500, 0.004)
+	ta := NewTrendAnalyzer(TrendAnalyzerConfig{ConfirmCandles: 100})
+	b.ResetTimer()
+	for range b.N {
+		ta.ConfirmedCanOpenLong(candles)
+	}
+}
+
+// BenchmarkConfirmedCanOpenLong_Naive 逐前缀重算的参考实现（对比用）
+func BenchmarkConfirmedCanOpenLong_Naive(b *testing.B) {
+	candles := testutil.GenerateRandomWalk(1, 100, 2500, 0.004)
+	ta := NewTrendAnalyzer(TrendAnalyzerConfig{ConfirmCandles: 100})
+	b.ResetTimer()
+	for range b.N {
+		naiveConfirmedCanOpenLong(ta, candles)
+	}
+}
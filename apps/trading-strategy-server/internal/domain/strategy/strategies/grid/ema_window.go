@@ -0,0 +1,78 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// minEMAPeriod 按時間換算後 EMA 至少需要的K線根數
+const minEMAPeriod = 2
+
+// ParseBarDuration 解析K線週期或時間長度 ⭐
+//
+// 支持 OKX 的K線週期寫法（1m、5m、1H、4H、1D、1W）和常見的時間寫法（100m、4h、2d）：
+// 數字 + 單位，單位為 s / m / h(H) / d(D) / w(W)。OKX 的 1M（月）長度不固定，不支持
+func ParseBarDuration(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid duration: %q", value)
+	}
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid duration: %q", value)
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 's':
+		unit = time.Second
+	case 'm':
+		unit = time.Minute
+	case 'h', 'H':
+		unit = time.Hour
+	case 'd', 'D':
+		unit = 24 * time.Hour
+	case 'w', 'W':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid duration unit: %q", value)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// EMAPeriodFromWindow 把按時間指定的 EMA 週期換算為K線根數 ⭐
+//
+// 例：100 分鐘在 5m K線上 = 20 根，在 1H K線上四捨五入為 2 根。
+// 換算結果少於 2 根時返回錯誤（EMA 退化為當前價格，趨勢判斷失去意義）
+func EMAPeriodFromWindow(window, barInterval time.Duration) (int, error) {
+	if barInterval <= 0 {
+		return 0, fmt.Errorf("bar interval must be positive to convert EMA window %s", window)
+	}
+	period := int(math.Round(float64(window) / float64(barInterval)))
+	if period < minEMAPeriod {
+		return 0, fmt.Errorf("EMA window %s on %s bars yields %d candles, need at least %d", window, barInterval, period, minEMAPeriod)
+	}
+	return period, nil
+}
+
+// resolveEMAWindows 按 BarInterval 把 EMAShortWindow / EMALongWindow 換算為K線根數
+//
+// 未設置時間週期時原樣返回
+func (c TrendAnalyzerConfig) resolveEMAWindows() (TrendAnalyzerConfig, error) {
+	if c.EMAShortWindow > 0 {
+		period, err := EMAPeriodFromWindow(c.EMAShortWindow, c.BarInterval)
+		if err != nil {
+			return c, fmt.Errorf("short EMA: %w", err)
+		}
+		c.EMAShortPeriod = period
+	}
+	if c.EMALongWindow > 0 {
+		period, err := EMAPeriodFromWindow(c.EMALongWindow, c.BarInterval)
+		if err != nil {
+			return c, fmt.Errorf("long EMA: %w", err)
+		}
+		c.EMALongPeriod = period
+	}
+	return c, nil
+}
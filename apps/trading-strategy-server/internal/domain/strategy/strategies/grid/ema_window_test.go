@@ -0,0 +1,90 @@
+package grid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBarDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"5m", 5 * time.Minute},
+		{"100m", 100 * time.Minute},
+		{"1H", time.Hour},
+		{"4h", 4 * time.Hour},
+		{"1D", 24 * time.Hour},
+		{"1W", 7 * 24 * time.Hour},
+		{"30s", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseBarDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBarDuration(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "m", "0m", "-5m", "1M", "5x", "1.5h"} {
+		if _, err := ParseBarDuration(value); err == nil {
+			t.Errorf("ParseBarDuration(%q): expected error", value)
+		}
+	}
+}
+
+// TestEMAPeriodFromWindow 測試按時間指定的 EMA 週期換算為K線根數 ⭐
+func TestEMAPeriodFromWindow(t *testing.T) {
+	period, err := EMAPeriodFromWindow(100*time.Minute, 5*time.Minute)
+	if err != nil || period != 20 {
+		t.Fatalf("Expected 100m on 5m bars = 20 candles, got %d (err: %v)", period, err)
+	}
+
+	// 同樣的時間跨度在 1H K線上四捨五入為 2 根
+	if period, err := EMAPeriodFromWindow(100*time.Minute, time.Hour); err != nil || period != 2 {
+		t.Errorf("Expected 100m on 1H bars = 2 candles, got %d (err: %v)", period, err)
+	}
+
+	// 少於 2 根：錯誤
+	if _, err := EMAPeriodFromWindow(time.Hour, time.Hour); err == nil {
+		t.Error("Expected error when the window yields fewer than 2 candles")
+	}
+	if _, err := EMAPeriodFromWindow(100*time.Minute, 0); err == nil {
+		t.Error("Expected error without a bar interval")
+	}
+}
+
+// TestTrendAnalyzer_EMAWindows 測試時間週期在不同K線週期下換算為相同的時間跨度
+func TestTrendAnalyzer_EMAWindows(t *testing.T) {
+	fiveMinute := NewTrendAnalyzer(TrendAnalyzerConfig{
+		EMAShortWindow: 100 * time.Minute,
+		EMALongWindow:  250 * time.Minute,
+		BarInterval:    5 * time.Minute,
+	})
+	if fiveMinute.emaShortPeriod != 20 || fiveMinute.emaLongPeriod != 50 {
+		t.Errorf("Expected 20/50 on 5m bars, got %d/%d", fiveMinute.emaShortPeriod, fiveMinute.emaLongPeriod)
+	}
+
+	hourly := NewTrendAnalyzer(TrendAnalyzerConfig{
+		EMAShortWindow: 4 * time.Hour,
+		EMALongWindow:  10 * time.Hour,
+		BarInterval:    time.Hour,
+	})
+	if hourly.emaShortPeriod != 4 || hourly.emaLongPeriod != 10 {
+		t.Errorf("Expected 4/10 on 1H bars, got %d/%d", hourly.emaShortPeriod, hourly.emaLongPeriod)
+	}
+
+	// 換算不足 2 根：NewGridAggregate 報錯
+	_, err := NewGridAggregate(GridConfig{
+		InstID:            "ETH-USDT",
+		PositionSize:      200,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+		TrendFilterConfig: TrendAnalyzerConfig{
+			EMAShortWindow: 5 * time.Minute,
+			BarInterval:    5 * time.Minute,
+		},
+	})
+	if err == nil {
+		t.Error("Expected NewGridAggregate to reject an EMA window shorter than 2 candles")
+	}
+}
@@ -3,24 +3,57 @@ package grid
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/shopspring/decimal"
 
 	"dizzycode.xyz/shared/domain/value_objects"
 )
 
+// GridSpacingMode 網格開倉間距模式 ⭐
+type GridSpacingMode string
+
+const (
+	GridSpacingFixed GridSpacingMode = "fixed" // 固定折扣：每次都在市價下方 0.1% 掛單（默認）
+	GridSpacingRange GridSpacingMode = "range" // 區間分層：按近期高低區間的比例，隨輪次加深逐步拉開間距
+)
+
+// OpenReferenceMode 開倉價的參考價格 ⭐
+type OpenReferenceMode string
+
+const (
+	OpenReferenceCurrentPrice     OpenReferenceMode = "current_price"       // 以當前價格為基準，按間距模式折扣（默認）
+	OpenReferenceLastCandleMidLow OpenReferenceMode = "last_candle_mid_low" // 策略文件原始規則：開倉價 = 前一根K線的 MidLow
+)
+
+// 固定折扣模式的開倉折扣比例（在低於市價 0.1% 處掛單）
+const fixedOpenDiscountRate = 0.001
+
 // GridConfig 網格策略配置
 type GridConfig struct {
-	InstID                string              // 交易對
-	PositionSize          float64             // 單次開倉大小（美元）
-	FeeRate               float64             // 手續費率（例: 0.0005 = 0.05%）
-	TakeProfitRateMin     float64             // 最小停利比例（例: 0.0015 = 0.15%）
-	TakeProfitRateMax     float64             // 最大停利比例（例: 0.002 = 0.2%）
-	BreakEvenProfitMin    float64             // 盈虧平衡最小目標盈利（USDT）
-	BreakEvenProfitMax    float64             // 盈虧平衡最大目標盈利（USDT）
-	TrendFilterConfig     TrendAnalyzerConfig // 趨勢過濾配置 ⭐
-	EnableTrendFilter     bool                // 是否啟用趨勢過濾 ⭐
-	EnableRedCandleFilter bool                // 是否啟用紅K過濾（虧損時只在紅K開倉）⭐
+	InstID                  string              // 交易對
+	PositionSize            float64             // 單次開倉大小（美元）
+	FeeRate                 float64             // 手續費率（例: 0.0005 = 0.05%）
+	TakeProfitRateMin       float64             // 最小停利比例（例: 0.0015 = 0.15%）
+	TakeProfitRateMax       float64             // 最大停利比例（例: 0.002 = 0.2%）
+	FirstEntryTakeProfit    float64             // 本輪第一筆倉位的停利比例（例: 0.001 = 0.1%，0 = 與 TakeProfitRateMin 相同）⭐
+	BreakEvenProfitMin      float64             // 盈虧平衡最小目標盈利（USDT）
+	BreakEvenProfitMax      float64             // 盈虧平衡最大目標盈利（USDT）
+	RoundProfitTarget       float64             // 整輪止盈：本輪總盈虧（已實現 + 未實現）達到此值時平掉所有倉位（USDT，0 = 不啟用）⭐
+	TrendFilterConfig       TrendAnalyzerConfig // 趨勢過濾配置 ⭐
+	EnableTrendFilter       bool                // 是否啟用趨勢過濾 ⭐
+	EnableRedCandleFilter   bool                // 是否啟用紅K過濾（虧損時只在紅K開倉）⭐
+	RedCandleLookback       int                 // 紅K過濾：檢查最近多少根K線（含當前K線，0 = 默認 1）
+	RedCandleMinRed         int                 // 紅K過濾：最近 RedCandleLookback 根中至少多少根為紅K（0 = 默認 1）
+	SpacingMode             GridSpacingMode     // 開倉間距模式（默認: fixed）⭐
+	SpacingRangeFraction    float64             // 區間分層：每層間距佔近期高低區間的比例（例: 0.1 = 10%）
+	SpacingLookback         int                 // 區間分層：計算高低區間的K線數量（0 = 使用全部歷史）
+	MinNetProfitPerTrade    float64             // 單筆止盈扣除開平倉手續費後的最小淨利潤（USDT，0 = 不限制）⭐
+	ProfitVsAverageCost     bool                // 止盈目標按本輪平均成本計算（每根K線重算），而非開倉時固定的止盈價 ⭐
+	OpenReference           OpenReferenceMode   // 開倉價的參考價格（默認: current_price）⭐
+	MinPriceGapBetweenOpens float64             // 價位去重：掛單價與本輪已有倉位開倉價的相對距離小於此值時不開倉（例: 0.001 = 0.1%，0 = 不限制）⭐
+
+	TickSize float64 // 交易所價格精度（例: 0.01，來自交易對規格；0 = 默認小數點後 2 位）⭐
 }
 
 // OpenAdvice 開倉建議（領域值對象）
@@ -32,6 +65,8 @@ type OpenAdvice struct {
 	PositionSize   float64 // 建議倉位大小（美元）
 	TakeProfitRate float64 // 建議停利比例（例: 0.0015 = 0.15%）
 	Reason         string  // 原因
+	Tag            string  // 開倉標籤（按開倉時的子規則分類，見 OpenTag）⭐
+	ExitRound      bool    // 是否應平掉本輪所有倉位（打平退出或整輪止盈退出，ShouldOpen 為 false）⭐
 }
 
 // GridAggregate 網格聚合根（無狀態設計）⭐
@@ -41,17 +76,29 @@ type OpenAdvice struct {
 // 3. 無狀態：不記錄 lastCandle（改為參數傳入）
 // 4. 不依賴任何技術實現
 type GridAggregate struct {
-	InstID                string
-	PositionSize          float64 // 單次開倉大小（美元）
-	FeeRate               float64 // 手續費率（例: 0.0005 = 0.05%）
-	TakeProfitRateMin     float64 // 最小停利比例（例: 0.0015 = 0.15%）
-	TakeProfitRateMax     float64 // 最大停利比例（例: 0.002 = 0.2%）
-	BreakEvenProfitMin    float64 // 盈虧平衡最小目標盈利（USDT）
-	BreakEvenProfitMax    float64 // 盈虧平衡最大目標盈利（USDT）
-	Calculator            *GridCalculator
-	TrendAnalyzer         *TrendAnalyzer // 趨勢分析器 ⭐
-	EnableTrendFilter     bool           // 是否啟用趨勢過濾 ⭐
-	EnableRedCandleFilter bool           // 是否啟用紅K過濾（虧損時只在紅K開倉）⭐
+	InstID                  string
+	PositionSize            float64 // 單次開倉大小（美元）
+	FeeRate                 float64 // 手續費率（例: 0.0005 = 0.05%）
+	TakeProfitRateMin       float64 // 最小停利比例（例: 0.0015 = 0.15%）
+	TakeProfitRateMax       float64 // 最大停利比例（例: 0.002 = 0.2%）
+	FirstEntryTakeProfit    float64 // 本輪第一筆倉位的停利比例（0 = 與 TakeProfitRateMin 相同）⭐
+	BreakEvenProfitMin      float64 // 盈虧平衡最小目標盈利（USDT）
+	BreakEvenProfitMax      float64 // 盈虧平衡最大目標盈利（USDT）
+	RoundProfitTarget       float64 // 整輪止盈目標（USDT，0 = 不啟用）⭐
+	Calculator              *GridCalculator
+	TrendAnalyzer           *TrendAnalyzer    // 趨勢分析器 ⭐
+	EnableTrendFilter       bool              // 是否啟用趨勢過濾 ⭐
+	EnableRedCandleFilter   bool              // 是否啟用紅K過濾（虧損時只在紅K開倉）⭐
+	RedCandleLookback       int               // 紅K過濾：檢查最近多少根K線（含當前K線）
+	RedCandleMinRed         int               // 紅K過濾：至少多少根為紅K才允許虧損時開倉
+	SpacingMode             GridSpacingMode   // 開倉間距模式 ⭐
+	SpacingRangeFraction    float64           // 區間分層：每層間距佔近期高低區間的比例
+	SpacingLookback         int               // 區間分層：計算高低區間的K線數量（0 = 全部）
+	MinNetProfitPerTrade    float64           // 單筆止盈的最小淨利潤（USDT，0 = 不限制）⭐
+	ProfitVsAverageCost     bool              // 止盈目標按本輪平均成本計算 ⭐
+	OpenReference           OpenReferenceMode // 開倉價的參考價格 ⭐
+	MinPriceGapBetweenOpens float64           // 價位去重：與本輪已有倉位開倉價的最小相對距離（0 = 不限制）⭐
+	TickSize                float64           // 價格精度（0 = 小數點後 2 位）⭐
 	// ❌ 移除 lastCandle（改為參數傳入，無狀態設計）
 }
 
@@ -66,6 +113,10 @@ func NewGridAggregate(config GridConfig) (*GridAggregate, error) {
 		return nil, errors.New("take profit rate min must be <= max")
 	}
 
+	if config.FirstEntryTakeProfit < 0 {
+		return nil, errors.New("first entry take profit must be non-negative")
+	}
+
 	// if config.BreakEvenProfitMin < 0 || config.BreakEvenProfitMax < 0 {
 	// 	return nil, errors.New("break even profit must be non-negative")
 	// }
@@ -74,28 +125,143 @@ func NewGridAggregate(config GridConfig) (*GridAggregate, error) {
 		return nil, errors.New("break even profit min must be <= max")
 	}
 
+	if config.RoundProfitTarget < 0 {
+		return nil, errors.New("round profit target must be non-negative")
+	}
+
+	if config.MinNetProfitPerTrade < 0 {
+		return nil, errors.New("min net profit per trade must be non-negative")
+	}
+
+	if config.MinPriceGapBetweenOpens < 0 {
+		return nil, errors.New("min price gap between opens must be non-negative")
+	}
+
+	if config.TickSize < 0 {
+		return nil, errors.New("tick size must be non-negative")
+	}
+
+	if config.TrendFilterConfig.ConfirmCandles < 0 {
+		return nil, errors.New("trend confirm candles must be non-negative")
+	}
+
+	if err := config.TrendFilterConfig.validateHysteresis(); err != nil {
+		return nil, err
+	}
+
+	if _, err := ParsePriceSource(string(config.TrendFilterConfig.PriceSource)); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.TrendFilterConfig.resolveEMAWindows(); err != nil {
+		return nil, fmt.Errorf("invalid trend filter config: %w", err)
+	}
+
+	// 紅K過濾默認 1 根中 1 根為紅K（只看當前K線）
+	redCandleLookback := config.RedCandleLookback
+	if redCandleLookback == 0 {
+		redCandleLookback = 1
+	}
+	redCandleMinRed := config.RedCandleMinRed
+	if redCandleMinRed == 0 {
+		redCandleMinRed = 1
+	}
+	if redCandleLookback < 0 || redCandleMinRed < 0 {
+		return nil, errors.New("red candle lookback and min red must be non-negative")
+	}
+	if redCandleMinRed > redCandleLookback {
+		return nil, errors.New("red candle min red must be <= lookback")
+	}
+
+	spacingMode := config.SpacingMode
+	if spacingMode == "" {
+		spacingMode = GridSpacingFixed
+	}
+
+	switch spacingMode {
+	case GridSpacingFixed:
+	case GridSpacingRange:
+		if config.SpacingRangeFraction <= 0 {
+			return nil, errors.New("spacing range fraction must be positive in range spacing mode")
+		}
+		if config.SpacingLookback < 0 {
+			return nil, errors.New("spacing lookback must be non-negative")
+		}
+	default:
+		return nil, fmt.Errorf("unknown grid spacing mode: %s", spacingMode)
+	}
+
+	openReference := config.OpenReference
+	if openReference == "" {
+		openReference = OpenReferenceCurrentPrice
+	}
+
+	switch openReference {
+	case OpenReferenceCurrentPrice, OpenReferenceLastCandleMidLow:
+	default:
+		return nil, fmt.Errorf("unknown open reference mode: %s", openReference)
+	}
+
 	return &GridAggregate{
-		InstID:                config.InstID,
-		PositionSize:          config.PositionSize,
-		FeeRate:               config.FeeRate,
-		TakeProfitRateMin:     config.TakeProfitRateMin,
-		TakeProfitRateMax:     config.TakeProfitRateMax,
-		BreakEvenProfitMin:    config.BreakEvenProfitMin,
-		BreakEvenProfitMax:    config.BreakEvenProfitMax,
-		Calculator:            NewGridCalculator(),
-		TrendAnalyzer:         NewTrendAnalyzer(config.TrendFilterConfig), // ⭐ 初始化趨勢分析器
-		EnableTrendFilter:     config.EnableTrendFilter,                   // ⭐ 是否啟用趨勢過濾
-		EnableRedCandleFilter: config.EnableRedCandleFilter,               // ⭐ 是否啟用紅K過濾
+		InstID:                  config.InstID,
+		PositionSize:            config.PositionSize,
+		FeeRate:                 config.FeeRate,
+		TakeProfitRateMin:       config.TakeProfitRateMin,
+		TakeProfitRateMax:       config.TakeProfitRateMax,
+		FirstEntryTakeProfit:    config.FirstEntryTakeProfit,
+		BreakEvenProfitMin:      config.BreakEvenProfitMin,
+		BreakEvenProfitMax:      config.BreakEvenProfitMax,
+		RoundProfitTarget:       config.RoundProfitTarget,
+		Calculator:              NewGridCalculator(),
+		TrendAnalyzer:           NewTrendAnalyzer(config.TrendFilterConfig), // ⭐ 初始化趨勢分析器
+		EnableTrendFilter:       config.EnableTrendFilter,                   // ⭐ 是否啟用趨勢過濾
+		EnableRedCandleFilter:   config.EnableRedCandleFilter,               // ⭐ 是否啟用紅K過濾
+		RedCandleLookback:       redCandleLookback,
+		RedCandleMinRed:         redCandleMinRed,
+		SpacingMode:             spacingMode,
+		SpacingRangeFraction:    config.SpacingRangeFraction,
+		SpacingLookback:         config.SpacingLookback,
+		MinNetProfitPerTrade:    config.MinNetProfitPerTrade,
+		ProfitVsAverageCost:     config.ProfitVsAverageCost,
+		OpenReference:           openReference,
+		MinPriceGapBetweenOpens: config.MinPriceGapBetweenOpens,
+		TickSize:                config.TickSize,
 	}, nil
 }
 
+// TakeProfitTarget 返回倉位當前的止盈目標價 ⭐
+//
+// 默認使用開倉時固定的 positionTarget；啟用 ProfitVsAverageCost 且本輪有多筆倉位時，
+// 所有倉位的止盈價都按 PositionSummary.AvgPrice × (1 + TakeProfitRateMin) 重算，
+// 平均成本隨加倉下降，止盈目標也跟著下移（同一輪的倉位因此互相關聯）。
+// 重算的目標不低於該倉位滿足 MinNetProfitPerTrade 的最低平倉價，避免高成本倉位在平均成本止盈時虧損離場；
+// 本輪只有一筆倉位時平均成本就是其開倉價，直接使用開倉時的止盈價
+//
+// 參數：
+//   - entryPrice: 倉位開倉價（計算最小淨利潤的下限）
+//   - positionTarget: 倉位開倉時記錄的止盈價
+//   - positionSummary: 當前持倉摘要（只使用 Count 和 AvgPrice）
+func (g *GridAggregate) TakeProfitTarget(entryPrice, positionTarget float64, positionSummary value_objects.PositionSummary) float64 {
+	if !g.ProfitVsAverageCost || positionSummary.Count <= 1 {
+		return positionTarget
+	}
+	target := AverageCostTakeProfitPriceWithTick(positionSummary, g.takeProfitRate(positionSummary.Count-1), g.TickSize)
+	if !target.IsPositive() {
+		return positionTarget
+	}
+	if minViable := minViableClosePrice(decimal.NewFromFloat(entryPrice), g.PositionSize, g.FeeRate, g.MinNetProfitPerTrade); target.LessThan(minViable) {
+		target = ceilToTick(minViable, g.TickSize)
+	}
+	return target.InexactFloat64()
+}
+
 // GetOpenAdvice 獲取開倉建議（被動諮詢方法）⭐
 // 參數：
 //   - currentPrice: 當前價格（Order Service 提供）
 //   - currentCandle: 當前 K 線（用於紅K過濾）⭐ 新增
 //   - lastCandle: 上一根 K 線（從 Redis 讀取）
 //   - candleHistories: K線歷史數據
-//   - positionSummary: 當前持倉摘要（用於盈虧平衡判斷）⭐
+//   - positionSummary: 當前持倉摘要（用於盈虧平衡判斷；EntryPrices 用於價位去重）⭐
 //
 // 返回：OpenAdvice（開倉建議）
 func (g *GridAggregate) GetOpenAdvice(
@@ -107,105 +273,168 @@ func (g *GridAggregate) GetOpenAdvice(
 ) OpenAdvice {
 	// ========== 步驟 1: 趨勢過濾檢查 ⭐ ==========
 	// 如果啟用趨勢過濾，檢查是否允許開倉
-	if g.EnableTrendFilter && len(candleHistories) > 0 {
-		canOpenLong := g.TrendAnalyzer.CanOpenLong(candleHistories)
-		if !canOpenLong {
-			// 趨勢過濾：禁止開多單
-			trendInfo := g.TrendAnalyzer.GetTrendInfo(candleHistories)
-			return OpenAdvice{
-				ShouldOpen: false,
-				Reason: fmt.Sprintf(
-					"trend_filter_blocked: trend=%s, ema_diff=%.2f%%, candle_change=%.2f%%",
-					trendInfo.Status,
-					trendInfo.EMADiffPercent,
-					trendInfo.CandleChange,
-				),
-			}
+	if g.EnableTrendFilter {
+		if blocked, reason := ShouldBlockForTrend(g.TrendAnalyzer, candleHistories); blocked {
+			return OpenAdvice{ShouldOpen: false, Reason: reason}
 		}
 	}
 
 	// ========== 步驟 2: 檢查盈虧平衡退出 ⭐ ==========
-	// 如果有未平倉位，優先檢查是否應該盈虧平衡退出
-	if !positionSummary.IsEmpty() {
-		// 判斷是否應該盈虧平衡退出
-
-		shouldExit, expectedProfit := positionSummary.ShouldBreakEven(
-			g.BreakEvenProfitMin,
-			g.BreakEvenProfitMax,
-		)
-
-		if shouldExit {
-			// 應該盈虧平衡退出 - 不開新倉
-			return OpenAdvice{
-				ShouldOpen: false,
-				Reason: fmt.Sprintf(
-					"break_even_exit: expected_profit=%.2f USDT (target: %.0f-%.0f USDT)",
-					expectedProfit,
-					g.BreakEvenProfitMin,
-					g.BreakEvenProfitMax,
-				),
-			}
-		}
+	// 如果有未平倉位，優先檢查是否應該盈虧平衡退出（應該退出時不開新倉）
+	if exit, reason := ShouldBreakEvenExit(positionSummary, g.BreakEvenProfitMin, g.BreakEvenProfitMax); exit {
+		return OpenAdvice{ShouldOpen: false, Reason: reason, ExitRound: true}
 	}
 
-	// ========== 步驟 3: 紅K過濾檢查（虧損時只在紅K開倉）⭐ ==========
-	if g.EnableRedCandleFilter && !positionSummary.IsEmpty() {
-		avgCost := positionSummary.AvgPrice
-		currentPriceValue := currentPrice.Value()
-
-		// 如果平均成本高於現價（持倉處於虧損），檢查當前K線顏色
-		if avgCost > currentPriceValue {
-			isRedCandle := currentCandle.Close().Value() < currentCandle.Open().Value() // 紅K = Close < Open
-			if !isRedCandle {
-				// 當前為綠K，虧損狀態下不允許開倉
-				return OpenAdvice{
-					ShouldOpen: false,
-					Reason: fmt.Sprintf(
-						"red_candle_filter: loss_state_green_candle (avgCost=%.2f, price=%.2f, close=%.2f, open=%.2f)",
-						avgCost,
-						currentPriceValue,
-						currentCandle.Close().Value(),
-						currentCandle.Open().Value(),
-					),
-				}
-			}
+	// ========== 步驟 2.5: 檢查整輪止盈退出 ⭐ ==========
+	// 本輪總盈虧達到目標時平掉所有倉位鎖定利潤（與打平對稱，應該退出時不開新倉）
+	if exit, reason := ShouldRoundProfitExit(positionSummary, g.RoundProfitTarget); exit {
+		return OpenAdvice{ShouldOpen: false, Reason: reason, ExitRound: true}
+	}
+
+	// ========== 步驟 3: 紅K過濾檢查（虧損時最近 M 根中至少 N 根紅K才開倉）⭐ ==========
+	if g.EnableRedCandleFilter {
+		if blocked, reason := ShouldBlockForRedCandles(
+			positionSummary,
+			currentPrice.Value(),
+			currentCandle,
+			candleHistories,
+			g.RedCandleLookback,
+			g.RedCandleMinRed,
+		); blocked {
+			return OpenAdvice{ShouldOpen: false, Reason: reason}
 		}
 	}
 
 	// ========== 步驟 4: 正常開倉邏輯 ⭐ ==========
-	// ✅ 使用 decimal 进行精确计算
-	currentPriceDecimal := decimal.NewFromFloat(currentPrice.Value())
-
-	// 策略参数
-	openDiscountRate := 0.001 // 开仓折扣比例：0.1%（在低于市价 0.1% 处挂单）
+	referencePrice, openDiscountRate := g.openReferencePrice(currentPrice.Value(), lastCandle, candleHistories, positionSummary.Count)
+	prices := ComputeOpenClosePricesWithTick(
+		referencePrice,
+		openDiscountRate,
+		g.takeProfitRate(positionSummary.Count),
+		g.PositionSize,
+		g.FeeRate,
+		g.MinNetProfitPerTrade,
+		g.TickSize,
+	)
 
-	// 计算因子
-	openDiscountFactor := decimal.NewFromFloat(1 - openDiscountRate)  // 1 - 0.001 = 0.999
-	takeProfitFactor := decimal.NewFromFloat(1 + g.TakeProfitRateMin) // 1 + 0.0015 = 1.0015
-
-	// 计算开仓价格：当前价格 * 0.999，无条件舍去到小数点第 2 位
-	openPriceDecimal := currentPriceDecimal.Mul(openDiscountFactor).Truncate(2)
-
-	// 计算平仓价格：开仓价格 * 1.0015，无条件进位到小数点第 2 位
-	// 实现无条件进位：先乘以 100，向上取整，再除以 100
-	closePriceDecimal := openPriceDecimal.Mul(takeProfitFactor)
-	shift := decimal.NewFromInt(100)
-	closePriceDecimal = closePriceDecimal.Mul(shift).Ceil().Div(shift)
+	// ========== 步驟 5: 價位去重（掛單價離本輪已有倉位太近時不開倉）⭐ ==========
+	if blocked, reason := ShouldBlockForPriceLevel(positionSummary, prices.OpenPrice.InexactFloat64(), g.MinPriceGapBetweenOpens); blocked {
+		return OpenAdvice{ShouldOpen: false, Reason: reason}
+	}
 
 	return OpenAdvice{
 		ShouldOpen:     true,
-		CurrentPrice:   currentPriceDecimal.String(),
-		OpenPrice:      openPriceDecimal.String(),  // 例: "3889.94" (舍去)
-		ClosePrice:     closePriceDecimal.String(), // 例: "3895.78" (进位)
+		CurrentPrice:   decimal.NewFromFloat(currentPrice.Value()).String(),
+		OpenPrice:      prices.OpenPrice.String(),  // 例: "3889.94" (舍去)
+		ClosePrice:     prices.ClosePrice.String(), // 例: "3895.78" (进位)
 		PositionSize:   g.PositionSize,
-		TakeProfitRate: g.TakeProfitRateMin, // 0.0015 (0.15%)
+		TakeProfitRate: prices.TakeProfitRate, // 0.0015 (0.15%)，被最小淨利潤拉高時為實際比例
 		Reason:         "simulated_advice",
+		Tag:            OpenTag(positionSummary, currentPrice.Value()),
+	}
+}
+
+// takeProfitRate 返回開倉時使用的停利比例 ⭐
+//
+// 本輪第一筆倉位（openCount == 0）使用 FirstEntryTakeProfit（設置時），
+// 之後的加倉倉位使用 TakeProfitRateMin
+//
+// 參數：
+//   - openCount: 本輪已開倉位數量（PositionSummary.Count）
+func (g *GridAggregate) takeProfitRate(openCount int) float64 {
+	if openCount == 0 && g.FirstEntryTakeProfit > 0 {
+		return g.FirstEntryTakeProfit
+	}
+	return g.TakeProfitRateMin
+}
+
+// minViableClosePrice 計算滿足最小淨利潤的最低平倉價 ⭐
+//
+// 公式（開倉費按本金、平倉費按平倉價值收取）：
+//
+//	coins = PositionSize / openPrice
+//	淨利潤 = coins × closePrice × (1 - feeRate) - PositionSize × (1 + feeRate)
+//	淨利潤 >= MinNetProfitPerTrade
+//
+//	→ closePrice >= openPrice × (PositionSize × (1 + feeRate) + MinNetProfitPerTrade) / (PositionSize × (1 - feeRate))
+//
+// 返回：未設置門檻或參數無效時返回 0（不限制）
+func minViableClosePrice(openPrice decimal.Decimal, positionSize, feeRate, minNetProfit float64) decimal.Decimal {
+	if minNetProfit <= 0 || positionSize <= 0 || feeRate >= 1 || !openPrice.IsPositive() {
+		return decimal.Zero
+	}
+
+	one := decimal.NewFromInt(1)
+	size := decimal.NewFromFloat(positionSize)
+	fee := decimal.NewFromFloat(feeRate)
+	required := size.Mul(one.Add(fee)).Add(decimal.NewFromFloat(minNetProfit))
+
+	return openPrice.Mul(required).Div(size.Mul(one.Sub(fee)))
+}
+
+// openReferencePrice 返回計算開倉價的參考價格和折扣比例 ⭐
+//
+// current_price：當前價格，折扣按間距模式計算（calculateOpenDiscountRate）
+// last_candle_mid_low：前一根K線的 MidLow =（實體低點 + 最低價）/ 2，即實體下沿與下影線底部的中點，
+// 開倉價直接等於 MidLow（不再折扣，間距模式不生效）；沒有前一根K線（零值）時退回 current_price
+func (g *GridAggregate) openReferencePrice(currentPrice float64, lastCandle value_objects.Candle, candleHistories []value_objects.Candle, openCount int) (referencePrice, discountRate float64) {
+	if g.OpenReference == OpenReferenceLastCandleMidLow && lastCandle.Low().Value() > 0 {
+		return lastCandle.MidLow().Value(), 0
+	}
+	return currentPrice, g.calculateOpenDiscountRate(currentPrice, candleHistories, openCount)
+}
+
+// calculateOpenDiscountRate 計算開倉價相對市價的折扣比例 ⭐
+//
+// 固定模式：固定 0.1%
+// 區間分層模式：第 n 層（n = 當前持倉數 + 1）的距離 = n × 比例 × 近期高低區間，
+// 輪次越深掛單越遠，避免在趨勢中密集堆疊多單；折扣不會小於固定模式的 0.1%
+//
+// 參數：
+//   - currentPrice: 當前價格
+//   - candleHistories: K線歷史數據（舊→新）
+//   - openCount: 本輪已開倉位數量（PositionSummary.Count）
+func (g *GridAggregate) calculateOpenDiscountRate(currentPrice float64, candleHistories []value_objects.Candle, openCount int) float64 {
+	if g.SpacingMode != GridSpacingRange || currentPrice <= 0 {
+		return fixedOpenDiscountRate
 	}
+
+	recentRange := recentHighLowRange(candleHistories, g.SpacingLookback)
+	if recentRange <= 0 {
+		return fixedOpenDiscountRate
+	}
+
+	level := float64(openCount + 1)
+	discountRate := level * g.SpacingRangeFraction * recentRange / currentPrice
+
+	if discountRate < fixedOpenDiscountRate {
+		return fixedOpenDiscountRate
+	}
+	return discountRate
+}
+
+// recentHighLowRange 計算最近 lookback 根K線的最高價 - 最低價（lookback <= 0 表示全部）
+func recentHighLowRange(candles []value_objects.Candle, lookback int) float64 {
+	if lookback > 0 && len(candles) > lookback {
+		candles = candles[len(candles)-lookback:]
+	}
+	if len(candles) == 0 {
+		return 0
+	}
+
+	high := candles[0].High().Value()
+	low := candles[0].Low().Value()
+	for _, candle := range candles[1:] {
+		high = math.Max(high, candle.High().Value())
+		low = math.Min(low, candle.Low().Value())
+	}
+
+	return high - low
 }
 
 // ProcessCandle 處理新的K線（舊方法，保留用於向後兼容）
 // ⚠️ 已棄用：請使用 GetOpenAdvice() 方法
-// 根據策略文件：開倉位置 = 前一根K線的MidLow
+// 根據策略文件：開倉位置 = 前一根K線的MidLow（GetOpenAdvice 以 OpenReferenceLastCandleMidLow 支持）
 func (g *GridAggregate) ProcessCandle(candle value_objects.Candle) (*value_objects.Signal, error) {
 	// 舊方法已棄用，但保留代碼避免破壞現有依賴
 	return nil, fmt.Errorf("ProcessCandle is deprecated, use GetOpenAdvice instead")
@@ -214,13 +443,18 @@ func (g *GridAggregate) ProcessCandle(candle value_objects.Candle) (*value_objec
 // GetState 獲取當前狀態（用於日誌或監控）
 func (g *GridAggregate) GetState() map[string]any {
 	return map[string]any{
-		"instID":             g.InstID,
-		"positionSize":       g.PositionSize,
-		"takeProfitRateMin":  g.TakeProfitRateMin,
-		"takeProfitRateMax":  g.TakeProfitRateMax,
-		"breakEvenProfitMin": g.BreakEvenProfitMin,
-		"breakEvenProfitMax": g.BreakEvenProfitMax,
-		"enableTrendFilter":  g.EnableTrendFilter, // ⭐ 新增
+		"instID":                  g.InstID,
+		"positionSize":            g.PositionSize,
+		"takeProfitRateMin":       g.TakeProfitRateMin,
+		"takeProfitRateMax":       g.TakeProfitRateMax,
+		"firstEntryTakeProfit":    g.FirstEntryTakeProfit,
+		"breakEvenProfitMin":      g.BreakEvenProfitMin,
+		"breakEvenProfitMax":      g.BreakEvenProfitMax,
+		"enableTrendFilter":       g.EnableTrendFilter, // ⭐ 新增
+		"spacingMode":             g.SpacingMode,
+		"minNetProfit":            g.MinNetProfitPerTrade,
+		"openReference":           g.OpenReference,
+		"minPriceGapBetweenOpens": g.MinPriceGapBetweenOpens,
 	}
 }
 
@@ -0,0 +1,498 @@
+package grid
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// TestGridAggregate_RangeSpacingWidens 测试区间分层模式下，轮次越深开仓价离市价越远
+func TestGridAggregate_RangeSpacingWidens(t *testing.T) {
+	g, err := NewGridAggregate(GridConfig{
+		InstID:               "ETH-USDT-SWAP",
+		PositionSize:         200,
+		FeeRate:              0.0005,
+		TakeProfitRateMin:    0.0015,
+		TakeProfitRateMax:    0.002,
+		BreakEvenProfitMax:   20,
+		SpacingMode:          GridSpacingRange,
+		SpacingRangeFraction: 0.1, // 每层 = 区间的 10%
+		SpacingLookback:      20,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	// 近期区间：2450 - 2550（range = 100 → 每层 10 USDT）
+	histories := make([]value_objects.Candle, 20)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range histories {
+		candle, _ := value_objects.NewCandle(2500, 2550, 2450, 2500, start.Add(time.Duration(i)*5*time.Minute))
+		histories[i] = candle
+	}
+	current := histories[len(histories)-1]
+	price, _ := value_objects.NewPrice(2500)
+
+	expectedOpenPrices := []float64{2490, 2480, 2470, 2460}
+	lastDistance := 0.0
+
+	for count, expected := range expectedOpenPrices {
+		// 本轮尚未关仓 → 不会触发打平
+		summary := value_objects.NewPositionSummary(count, float64(count)*200, 2505, 0, 0, 0, 0)
+
+		advice := g.GetOpenAdvice(price, current, current, histories, summary)
+		if !advice.ShouldOpen {
+			t.Fatalf("count=%d: expected ShouldOpen, got reason %s", count, advice.Reason)
+		}
+
+		openPrice, _ := strconv.ParseFloat(advice.OpenPrice, 64)
+		if openPrice != expected {
+			t.Errorf("count=%d: expected open price %.2f, got %.2f", count, expected, openPrice)
+		}
+
+		distance := price.Value() - openPrice
+		if distance <= lastDistance {
+			t.Errorf("count=%d: expected spacing to widen, distance %.2f <= previous %.2f", count, distance, lastDistance)
+		}
+		lastDistance = distance
+	}
+}
+
+// TestGridAggregate_FixedSpacingDefault 测试默认固定折扣模式不受持仓数量影响
+func TestGridAggregate_FixedSpacingDefault(t *testing.T) {
+	g, err := NewGridAggregate(GridConfig{
+		TakeProfitRateMin:  0.0015,
+		TakeProfitRateMax:  0.002,
+		BreakEvenProfitMax: 20,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	if g.SpacingMode != GridSpacingFixed {
+		t.Errorf("Expected default spacing mode %s, got %s", GridSpacingFixed, g.SpacingMode)
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2550, 2450, 2500, time.Now())
+	price, _ := value_objects.NewPrice(2500)
+
+	for count := 0; count < 3; count++ {
+		summary := value_objects.NewPositionSummary(count, float64(count)*200, 2505, 0, 0, 0, 0)
+		advice := g.GetOpenAdvice(price, candle, candle, []value_objects.Candle{candle}, summary)
+		if advice.OpenPrice != "2497.5" {
+			t.Errorf("count=%d: expected open price 2497.5, got %s", count, advice.OpenPrice)
+		}
+	}
+}
+
+// TestNewGridAggregate_RangeSpacingRequiresFraction 测试区间分层模式需要正的间距比例
+func TestNewGridAggregate_RangeSpacingRequiresFraction(t *testing.T) {
+	_, err := NewGridAggregate(GridConfig{
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+		SpacingMode:       GridSpacingRange,
+	})
+	if err == nil {
+		t.Error("Expected error for range spacing without fraction")
+	}
+}
+
+// TestGridAggregate_MinNetProfitWidensTarget 测试止盈价扣除手续费后不足最小净利润时，止盈价被拉高
+func TestGridAggregate_MinNetProfitWidensTarget(t *testing.T) {
+	const (
+		positionSize = 200.0
+		feeRate      = 0.0005
+		minNetProfit = 0.5
+	)
+
+	netProfit := func(openPrice, closePrice float64) float64 {
+		coins := positionSize / openPrice
+		return coins*closePrice*(1-feeRate) - positionSize*(1+feeRate)
+	}
+
+	newGrid := func(minNet float64) *GridAggregate {
+		g, err := NewGridAggregate(GridConfig{
+			InstID:               "ETH-USDT-SWAP",
+			PositionSize:         positionSize,
+			FeeRate:              feeRate,
+			TakeProfitRateMin:    0.0015,
+			TakeProfitRateMax:    0.002,
+			MinNetProfitPerTrade: minNet,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create grid aggregate: %v", err)
+		}
+		return g
+	}
+
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2500, time.Now())
+	price, _ := value_objects.NewPrice(2500)
+	summary := value_objects.PositionSummary{}
+
+	// 不設門檻：0.15% 止盈扣費後淨利潤低於 0.5 USDT
+	base := newGrid(0).GetOpenAdvice(price, candle, candle, nil, summary)
+	baseOpen, _ := strconv.ParseFloat(base.OpenPrice, 64)
+	baseClose, _ := strconv.ParseFloat(base.ClosePrice, 64)
+	if net := netProfit(baseOpen, baseClose); net >= minNetProfit {
+		t.Fatalf("Test setup: expected base net profit < %.2f, got %.4f", minNetProfit, net)
+	}
+
+	// 設置門檻：止盈價被拉高到剛好滿足門檻（精確到 0.01）
+	advice := newGrid(minNetProfit).GetOpenAdvice(price, candle, candle, nil, summary)
+	openPrice, _ := strconv.ParseFloat(advice.OpenPrice, 64)
+	closePrice, _ := strconv.ParseFloat(advice.ClosePrice, 64)
+
+	if openPrice != baseOpen {
+		t.Errorf("Expected open price unchanged %.2f, got %.2f", baseOpen, openPrice)
+	}
+	if closePrice <= baseClose {
+		t.Errorf("Expected close price to widen above %.2f, got %.2f", baseClose, closePrice)
+	}
+	if net := netProfit(openPrice, closePrice); net < minNetProfit {
+		t.Errorf("Expected net profit >= %.2f, got %.4f", minNetProfit, net)
+	}
+	if net := netProfit(openPrice, closePrice-0.01); net >= minNetProfit {
+		t.Errorf("Expected target to be minimal, but %.2f also clears (net %.4f)", closePrice-0.01, net)
+	}
+	if advice.TakeProfitRate <= 0.0015 {
+		t.Errorf("Expected effective take profit rate > 0.0015, got %.6f", advice.TakeProfitRate)
+	}
+}
+
+// TestNewGridAggregate_NegativeMinNetProfit 测试最小净利润不能为负
+func TestNewGridAggregate_NegativeMinNetProfit(t *testing.T) {
+	_, err := NewGridAggregate(GridConfig{
+		TakeProfitRateMin:    0.0015,
+		TakeProfitRateMax:    0.002,
+		MinNetProfitPerTrade: -1,
+	})
+	if err == nil {
+		t.Error("Expected error for negative min net profit")
+	}
+}
+
+// TestNewGridAggregate_RedCandleLookback 測試紅K過濾的 N of M 默認值和驗證
+func TestNewGridAggregate_RedCandleLookback(t *testing.T) {
+	base := GridConfig{
+		InstID:            "ETH-USDT-SWAP",
+		PositionSize:      200,
+		FeeRate:           0.0005,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+	}
+
+	g, err := NewGridAggregate(base)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	if g.RedCandleLookback != 1 || g.RedCandleMinRed != 1 {
+		t.Errorf("Expected default 1 of 1, got %d of %d", g.RedCandleMinRed, g.RedCandleLookback)
+	}
+
+	invalid := base
+	invalid.RedCandleLookback = 2
+	invalid.RedCandleMinRed = 3
+	if _, err := NewGridAggregate(invalid); err == nil {
+		t.Error("Expected error when min red > lookback")
+	}
+}
+
+// TestGridAggregate_ProfitVsAverageCostTargets 测试止盈目标随本轮平均成本调整
+func TestGridAggregate_ProfitVsAverageCostTargets(t *testing.T) {
+	config := GridConfig{
+		InstID:             "ETH-USDT-SWAP",
+		PositionSize:       200,
+		FeeRate:            0.0005,
+		TakeProfitRateMin:  0.002,
+		TakeProfitRateMax:  0.002,
+		BreakEvenProfitMax: 20,
+	}
+	fixed, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	config.ProfitVsAverageCost = true
+	averaged, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	// 某个倉位开仓时记录的止盈价
+	const positionTarget = 2505.0
+
+	// 加仓后平均成本从 2500 降到 2450，止盈目标随之下移
+	for _, tt := range []struct {
+		avgPrice float64
+		want     float64
+	}{
+		{2500, 2505},    // 2500 × 1.002
+		{2475, 2479.95}, // 2475 × 1.002
+		{2450, 2454.9},  // 2450 × 1.002
+	} {
+		summary := value_objects.NewPositionSummary(3, 600, tt.avgPrice, 0, 0, 0, 0)
+		if got := averaged.TakeProfitTarget(2500, positionTarget, summary); got != tt.want {
+			t.Errorf("avg %.2f: expected target %.2f, got %.2f", tt.avgPrice, tt.want, got)
+		}
+		// 默认模式始终使用开仓时的止盈价
+		if got := fixed.TakeProfitTarget(2500, positionTarget, summary); got != positionTarget {
+			t.Errorf("avg %.2f: expected fixed target %.2f, got %.2f", tt.avgPrice, positionTarget, got)
+		}
+	}
+
+	// 没有持仓时退回开仓时的止盈价
+	if got := averaged.TakeProfitTarget(2500, positionTarget, value_objects.PositionSummary{}); got != positionTarget {
+		t.Errorf("empty summary: expected %.2f, got %.2f", positionTarget, got)
+	}
+
+	// 本轮只有一笔仓位时不重算（即使摘要的平均成本与开仓价不同）
+	if got := averaged.TakeProfitTarget(2500, positionTarget, value_objects.NewPositionSummary(1, 200, 2450, 0, 0, 0, 0)); got != positionTarget {
+		t.Errorf("single position: expected %.2f, got %.2f", positionTarget, got)
+	}
+}
+
+// TestGridAggregate_ProfitVsAverageCostMinNetProfit 测试平均成本止盈价不低于仓位的最小净利润平仓价
+func TestGridAggregate_ProfitVsAverageCostMinNetProfit(t *testing.T) {
+	averaged, err := NewGridAggregate(GridConfig{
+		InstID:               "ETH-USDT-SWAP",
+		PositionSize:         200,
+		FeeRate:              0.0005,
+		TakeProfitRateMin:    0.002,
+		TakeProfitRateMax:    0.002,
+		MinNetProfitPerTrade: 0.5,
+		ProfitVsAverageCost:  true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	summary := value_objects.NewPositionSummary(3, 600, 2450, 0, 0, 0, 0) // 平均成本止盈价 2454.9
+	for _, tt := range []struct {
+		name  string
+		entry float64
+		want  float64
+	}{
+		{"low entry keeps the average-cost target", 2400, 2454.9},
+		{"high entry is lifted to its min viable close", 2500, 2508.76}, // 2500 × (200 × 1.0005 + 0.5) / (200 × 0.9995)，进位
+	} {
+		if got := averaged.TakeProfitTarget(tt.entry, 2600, summary); got != tt.want {
+			t.Errorf("%s: expected target %.2f, got %.2f", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestGridAggregate_OpenReferenceLastCandleMidLow 测试 MidLow 模式下开仓价来自前一根K线，而非当前价格
+func TestGridAggregate_OpenReferenceLastCandleMidLow(t *testing.T) {
+	config := GridConfig{
+		TakeProfitRateMin:  0.0015,
+		TakeProfitRateMax:  0.002,
+		BreakEvenProfitMax: 20,
+	}
+	defaultGrid, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	if defaultGrid.OpenReference != OpenReferenceCurrentPrice {
+		t.Errorf("Expected default open reference %s, got %s", OpenReferenceCurrentPrice, defaultGrid.OpenReference)
+	}
+
+	config.OpenReference = OpenReferenceLastCandleMidLow
+	g, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	// 前一根K线：实体低点 2480（收盘），最低价 2460 → MidLow = 2470
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastCandle, _ := value_objects.NewCandle(2500, 2510, 2460, 2480, start)
+	currentCandle, _ := value_objects.NewCandle(2480, 2530, 2475, 2520, start.Add(5*time.Minute))
+	summary := value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0)
+	histories := []value_objects.Candle{lastCandle}
+
+	for _, current := range []float64{2520, 2600} {
+		price, _ := value_objects.NewPrice(current)
+		advice := g.GetOpenAdvice(price, currentCandle, lastCandle, histories, summary)
+		if !advice.ShouldOpen {
+			t.Fatalf("price=%.0f: expected ShouldOpen, got reason %s", current, advice.Reason)
+		}
+		if advice.OpenPrice != "2470" {
+			t.Errorf("price=%.0f: expected open price 2470 (last candle mid-low), got %s", current, advice.OpenPrice)
+		}
+		if advice.ClosePrice != "2473.71" {
+			t.Errorf("price=%.0f: expected close price 2473.71, got %s", current, advice.ClosePrice)
+		}
+	}
+
+	// 当前价格模式：2520 × 0.999 = 2517.48
+	price, _ := value_objects.NewPrice(2520)
+	if advice := defaultGrid.GetOpenAdvice(price, currentCandle, lastCandle, histories, summary); advice.OpenPrice != "2517.48" {
+		t.Errorf("Expected current price open 2517.48, got %s", advice.OpenPrice)
+	}
+
+	// 没有前一根K线时退回当前价格
+	if advice := g.GetOpenAdvice(price, currentCandle, value_objects.Candle{}, nil, summary); advice.OpenPrice != "2517.48" {
+		t.Errorf("Expected fallback to current price open 2517.48, got %s", advice.OpenPrice)
+	}
+
+	config.OpenReference = "previous_close"
+	if _, err := NewGridAggregate(config); err == nil {
+		t.Error("Expected error for unknown open reference mode")
+	}
+}
+
+// TestGridAggregate_FirstEntryTakeProfit 测试本轮第一笔仓位使用单独的止盈比例，之后的加仓使用 TakeProfitRateMin
+func TestGridAggregate_FirstEntryTakeProfit(t *testing.T) {
+	config := GridConfig{
+		TakeProfitRateMin:    0.0015,
+		TakeProfitRateMax:    0.002,
+		FirstEntryTakeProfit: 0.001,
+		BreakEvenProfitMax:   20,
+		OpenReference:        OpenReferenceLastCandleMidLow,
+	}
+	g, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	// 前一根K线 MidLow = 2470，开仓价固定为 2470
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastCandle, _ := value_objects.NewCandle(2500, 2510, 2460, 2480, start)
+	currentCandle, _ := value_objects.NewCandle(2480, 2530, 2475, 2520, start.Add(5*time.Minute))
+	histories := []value_objects.Candle{lastCandle}
+	price, _ := value_objects.NewPrice(2520)
+
+	for _, tt := range []struct {
+		name    string
+		summary value_objects.PositionSummary
+		close   string
+		rate    float64
+	}{
+		{"first entry", value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0), "2472.47", 0.001},     // 2470 × 1.001
+		{"add-on", value_objects.NewPositionSummary(1, 200, 2500, 0, 0, 0, -2.4), "2473.71", 0.0015}, // 2470 × 1.0015
+	} {
+		advice := g.GetOpenAdvice(price, currentCandle, lastCandle, histories, tt.summary)
+		if !advice.ShouldOpen {
+			t.Fatalf("%s: expected ShouldOpen, got reason %s", tt.name, advice.Reason)
+		}
+		if advice.OpenPrice != "2470" || advice.ClosePrice != tt.close {
+			t.Errorf("%s: expected open 2470 / close %s, got %s / %s", tt.name, tt.close, advice.OpenPrice, advice.ClosePrice)
+		}
+		if advice.TakeProfitRate != tt.rate {
+			t.Errorf("%s: expected take profit rate %v, got %v", tt.name, tt.rate, advice.TakeProfitRate)
+		}
+	}
+
+	// 按平均成本止盈：本轮只有一笔仓位时保留其开仓时的止盈价，多笔时按加仓比例重算
+	config.ProfitVsAverageCost = true
+	averaged, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	if got := averaged.TakeProfitTarget(2470, 2473.71, value_objects.NewPositionSummary(1, 200, 2470, 0, 0, 0, 0)); got != 2473.71 {
+		t.Errorf("Expected single position target 2473.71, got %.2f", got)
+	}
+	if got := averaged.TakeProfitTarget(2470, 2472.47, value_objects.NewPositionSummary(2, 400, 2470, 0, 0, 0, 0)); got != 2473.71 {
+		t.Errorf("Expected add-on average-cost target 2473.71, got %.2f", got)
+	}
+	config.ProfitVsAverageCost = false
+
+	// 未设置时第一笔仓位也使用 TakeProfitRateMin
+	config.FirstEntryTakeProfit = 0
+	defaultGrid, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+	advice := defaultGrid.GetOpenAdvice(price, currentCandle, lastCandle, histories, value_objects.NewPositionSummary(0, 0, 0, 0, 0, 0, 0))
+	if advice.ClosePrice != "2473.71" {
+		t.Errorf("Expected default first entry close 2473.71, got %s", advice.ClosePrice)
+	}
+
+	config.FirstEntryTakeProfit = -0.001
+	if _, err := NewGridAggregate(config); err == nil {
+		t.Error("Expected error for negative first entry take profit")
+	}
+}
+
+// TestGridAggregate_MinPriceGapBetweenOpens 测试挂单价离本轮已有仓位的开仓价太近时不开仓
+func TestGridAggregate_MinPriceGapBetweenOpens(t *testing.T) {
+	config := GridConfig{
+		TakeProfitRateMin:       0.0015,
+		TakeProfitRateMax:       0.002,
+		BreakEvenProfitMax:      20,
+		OpenReference:           OpenReferenceLastCandleMidLow,
+		MinPriceGapBetweenOpens: 0.002, // 0.2%
+	}
+	g, err := NewGridAggregate(config)
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	// 前一根K线 MidLow = 2470，开仓价固定为 2470
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastCandle, _ := value_objects.NewCandle(2500, 2510, 2460, 2480, start)
+	currentCandle, _ := value_objects.NewCandle(2480, 2530, 2475, 2520, start.Add(5*time.Minute))
+	histories := []value_objects.Candle{lastCandle}
+	price, _ := value_objects.NewPrice(2520)
+	summary := value_objects.NewPositionSummary(2, 400, 2480, 0, 0, 0, 1)
+
+	for _, tt := range []struct {
+		name    string
+		entries []float64
+		open    bool
+	}{
+		{"no entry info", nil, true},
+		{"entry 0.08% away", []float64{2490, 2472}, false}, // |2470 - 2472| / 2472 ≈ 0.08%
+		{"entries at least 0.2% away", []float64{2490, 2475}, true},
+	} {
+		advice := g.GetOpenAdvice(price, currentCandle, lastCandle, histories, summary.WithEntryPrices(tt.entries))
+		if advice.ShouldOpen != tt.open {
+			t.Errorf("%s: expected ShouldOpen=%v, got %v (reason %s)", tt.name, tt.open, advice.ShouldOpen, advice.Reason)
+		}
+		if !tt.open && advice.Reason != ReasonPriceLevelOccupied {
+			t.Errorf("%s: expected reason %s, got %s", tt.name, ReasonPriceLevelOccupied, advice.Reason)
+		}
+	}
+
+	config.MinPriceGapBetweenOpens = -0.001
+	if _, err := NewGridAggregate(config); err == nil {
+		t.Error("Expected error for negative min price gap")
+	}
+}
+
+// TestGridAggregate_ExitRound 测试打平退出和整轮止盈退出的建议标记 ExitRound，其他建议不标记
+func TestGridAggregate_ExitRound(t *testing.T) {
+	g, err := NewGridAggregate(GridConfig{
+		PositionSize:       200,
+		TakeProfitRateMin:  0.0015,
+		TakeProfitRateMax:  0.002,
+		BreakEvenProfitMin: 1,
+		BreakEvenProfitMax: 20,
+		RoundProfitTarget:  50,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle, _ := value_objects.NewCandle(2500, 2510, 2490, 2505, start)
+	price, _ := value_objects.NewPrice(2505)
+
+	for _, tt := range []struct {
+		name       string
+		unrealized float64
+		exit       bool
+	}{
+		{"break-even exit", 10, true},
+		{"round profit exit", 100, true},
+		{"losing round", -50, false},
+	} {
+		summary := value_objects.NewPositionSummary(2, 400, 2500, 0, -5, 200, tt.unrealized) // 本轮已实现 -5
+		advice := g.GetOpenAdvice(price, candle, candle, []value_objects.Candle{candle}, summary)
+		if advice.ExitRound != tt.exit {
+			t.Errorf("%s: expected ExitRound=%v, got %v (reason %s)", tt.name, tt.exit, advice.ExitRound, advice.Reason)
+		}
+		if advice.ExitRound && advice.ShouldOpen {
+			t.Errorf("%s: expected no open with an exit advice", tt.name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package grid
+
+import (
+	"fmt"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// PriceSource 趨勢分析使用的K線價格 ⭐
+type PriceSource string
+
+const (
+	PriceSourceClose PriceSource = "close" // 收盤價（默認）
+	PriceSourceHLC3  PriceSource = "hlc3"  // 典型價格 (high + low + close) / 3
+	PriceSourceOHLC4 PriceSource = "ohlc4" // (open + high + low + close) / 4
+	PriceSourceHigh  PriceSource = "high"  // 最高價
+	PriceSourceLow   PriceSource = "low"   // 最低價
+)
+
+// ParsePriceSource 解析價格來源（空字符串 = close）
+func ParsePriceSource(value string) (PriceSource, error) {
+	switch source := PriceSource(value); source {
+	case "":
+		return PriceSourceClose, nil
+	case PriceSourceClose, PriceSourceHLC3, PriceSourceOHLC4, PriceSourceHigh, PriceSourceLow:
+		return source, nil
+	default:
+		return "", fmt.Errorf("unknown price source: %s", value)
+	}
+}
+
+// Price 按價格來源取出K線的價格
+func (s PriceSource) Price(candle value_objects.Candle) float64 {
+	switch s {
+	case PriceSourceHLC3:
+		return (candle.High().Value() + candle.Low().Value() + candle.Close().Value()) / 3
+	case PriceSourceOHLC4:
+		return (candle.Open().Value() + candle.High().Value() + candle.Low().Value() + candle.Close().Value()) / 4
+	case PriceSourceHigh:
+		return candle.High().Value()
+	case PriceSourceLow:
+		return candle.Low().Value()
+	default:
+		return candle.Close().Value()
+	}
+}
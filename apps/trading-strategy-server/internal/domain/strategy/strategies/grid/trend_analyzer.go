@@ -1,6 +1,9 @@
 package grid
 
 import (
+	"fmt"
+	"time"
+
 	"dizzycode.xyz/shared/domain/value_objects"
 )
 
@@ -13,6 +16,9 @@ const (
 	RANGING          TrendState = "RANGING"          // 震荡
 )
 
+// defaultEMAThreshold EMA 差距阈值的默认值（0.5%）
+const defaultEMAThreshold = 0.005
+
 // TrendAnalyzer 趋势分析器（领域服务）⭐
 // 特点：
 // 1. 无状态设计（纯函数）
@@ -26,9 +32,19 @@ type TrendAnalyzer struct {
 	emaLongPeriod      int     // 长期 EMA 周期（默认 50）
 	priceDropThreshold float64 // 价格跌幅阈值（例如 0.02 = 2%）⭐ 新增
 	consecutivePeriod  int     // 连续阴线检测周期（默认 10）⭐ 新增
+
+	priceSource PriceSource // EMA / 价格跌幅 / 阴线统计使用的K线价格（默认 close）⭐
+
+	confirmCandles int // 开多判断需连续多少根K线相同才生效（默认 1 = 立即生效）⭐
+
+	hysteresis float64 // 迟滞带：进入趋势后，差距回落到 emaThreshold - hysteresis 以内才退出（0 = 不启用）⭐
 }
 
 // TrendAnalyzerConfig 趋势分析器配置
+//
+// 注意：EMA 周期和连续阴线检测周期都以「K线根数」表示，
+// 切换 K 线周期（例如 5m → 1H）会改变其对应的实际时间长度（20 根 5m ≈ 100 分钟，20 根 1H = 20 小时）；
+// 需要跨周期保持一致时，用 EMAShortWindow / EMALongWindow 按时间指定 EMA 周期
 type TrendAnalyzerConfig struct {
 	EMAThreshold       float64 // EMA 差距阈值
 	CandleThreshold    float64 // 单根K线幅度阈值
@@ -36,13 +52,49 @@ type TrendAnalyzerConfig struct {
 	EMALongPeriod      int     // 长期 EMA 周期
 	PriceDropThreshold float64 // 价格跌幅阈值 ⭐ 新增
 	ConsecutivePeriod  int     // 连续阴线检测周期 ⭐ 新增
+
+	// PriceSource 趋势计算使用的K线价格（空 = close）⭐
+	// 影响 EMA、价格跌幅和阴线统计（阴线 = 该价格 < 开盘价），单根K线幅度仍按实体（close - open）计算
+	PriceSource PriceSource
+
+	// EMA 周期按时间长度指定（例如 100m、4h，0 = 使用 EMAShortPeriod / EMALongPeriod）⭐
+	// 按 BarInterval 换算为K线根数（四舍五入，至少 2 根），换算失败时由 NewGridAggregate 报错
+	EMAShortWindow time.Duration
+	EMALongWindow  time.Duration
+	BarInterval    time.Duration // K线周期（使用时间周期时必填，例如 5m）
+
+	// ConfirmCandles 开多判断（CanOpenLong）需连续多少根K线给出相同结论才生效（0 = 默认 1，立即生效）⭐
+	// 见 ConfirmedCanOpenLong：趋势过滤刚翻转的那根K线不立即生效，过滤单根K线的闪烁
+	ConfirmCandles int
+
+	// Hysteresis 趋势迟滞带（EMA 差距比例，0 = 不启用）⭐
+	// 差距严格大于 EMAThreshold 才进入趋势；进入后差距仍严格大于 EMAThreshold - Hysteresis 时保持，
+	// 避免差距在阈值附近徘徊时状态来回翻转。需小于 EMAThreshold，由 NewGridAggregate 校验
+	Hysteresis float64
+}
+
+// validateHysteresis 校验迟滞带：0 <= Hysteresis < EMAThreshold（EMAThreshold 未设置时按默认值）
+func (c TrendAnalyzerConfig) validateHysteresis() error {
+	threshold := c.EMAThreshold
+	if threshold <= 0 {
+		threshold = defaultEMAThreshold
+	}
+	if c.Hysteresis < 0 || c.Hysteresis >= threshold {
+		return fmt.Errorf("trend hysteresis must be in [0, %g), got %g", threshold, c.Hysteresis)
+	}
+	return nil
 }
 
 // NewTrendAnalyzer 创建趋势分析器（工厂方法）
 func NewTrendAnalyzer(config TrendAnalyzerConfig) *TrendAnalyzer {
+	// 按时间指定的 EMA 周期换算为K线根数（换算失败时保留根数配置，错误由 NewGridAggregate 校验）
+	if resolved, err := config.resolveEMAWindows(); err == nil {
+		config = resolved
+	}
+
 	// 设置默认值
 	if config.EMAThreshold <= 0 {
-		config.EMAThreshold = 0.005 // 0.5%
+		config.EMAThreshold = defaultEMAThreshold
 	}
 	if config.CandleThreshold <= 0 {
 		config.CandleThreshold = 0.006 // 0.6%
@@ -59,6 +111,12 @@ func NewTrendAnalyzer(config TrendAnalyzerConfig) *TrendAnalyzer {
 	if config.ConsecutivePeriod <= 0 {
 		config.ConsecutivePeriod = 5 // 5根K线 ⭐ 修改：更短周期
 	}
+	if config.PriceSource == "" {
+		config.PriceSource = PriceSourceClose
+	}
+	if config.ConfirmCandles <= 0 {
+		config.ConfirmCandles = 1
+	}
 
 	return &TrendAnalyzer{
 		emaThreshold:       config.EMAThreshold,
@@ -67,6 +125,9 @@ func NewTrendAnalyzer(config TrendAnalyzerConfig) *TrendAnalyzer {
 		emaLongPeriod:      config.EMALongPeriod,
 		priceDropThreshold: config.PriceDropThreshold, // ⭐ 新增
 		consecutivePeriod:  config.ConsecutivePeriod,  // ⭐ 新增
+		priceSource:        config.PriceSource,
+		confirmCandles:     config.ConfirmCandles,
+		hysteresis:         config.Hysteresis,
 	}
 }
 
@@ -76,27 +137,66 @@ func NewTrendAnalyzer(config TrendAnalyzerConfig) *TrendAnalyzer {
 //
 // 返回：
 //   - TrendState: 趋势状态
+//
+// 启用迟滞时，趋势状态依赖之前的状态：在整个窗口上逐根递推（见 trendStates），
+// 分析器仍保持无状态，结论完全由传入的K线决定
 func (ta *TrendAnalyzer) DetectTrend(candles []value_objects.Candle) TrendState {
 	if len(candles) < ta.emaLongPeriod {
 		return RANGING // 数据不足，默认震荡
 	}
+	if ta.hysteresis > 0 {
+		states := ta.trendStates(ta.EMASeries(candles, ta.emaShortPeriod), ta.EMASeries(candles, ta.emaLongPeriod))
+		return states[len(states)-1]
+	}
 
 	// 计算 EMA
 	emaShort := ta.calculateEMA(candles, ta.emaShortPeriod)
 	emaLong := ta.calculateEMA(candles, ta.emaLongPeriod)
 
+	return ta.classifyTrend(emaShort, emaLong)
+}
+
+// classifyTrend 根据短期/长期 EMA 的差距判断趋势状态（不考虑迟滞）
+func (ta *TrendAnalyzer) classifyTrend(emaShort, emaLong float64) TrendState {
 	// 计算差距百分比
 	diff := (emaShort - emaLong) / emaLong
 
-	if diff > ta.emaThreshold {
+	return ta.nextTrend(RANGING, diff)
+}
+
+// nextTrend 根据上一根K线的趋势状态和当前 EMA 差距判断趋势状态 ⭐
+//
+// 边界（确定性）：
+//   - 进入趋势：diff > emaThreshold（严格大于，恰好等于阈值为 RANGING）
+//   - 保持趋势：上一状态为同方向趋势且 |diff| > emaThreshold - hysteresis（严格大于）
+//   - hysteresis = 0 时与上一状态无关
+func (ta *TrendAnalyzer) nextTrend(prev TrendState, diff float64) TrendState {
+	exit := ta.emaThreshold - ta.hysteresis
+
+	if diff > ta.emaThreshold || prev == STRONG_UPTREND && diff > exit {
 		return STRONG_UPTREND // EMA 20 明显高于 EMA 50
-	} else if diff < -ta.emaThreshold {
+	} else if diff < -ta.emaThreshold || prev == STRONG_DOWNTREND && diff < -exit {
 		return STRONG_DOWNTREND // EMA 20 明显低于 EMA 50
 	}
 
 	return RANGING // 两条 EMA 接近，震荡行情
 }
 
+// trendStates 按 EMA 序列逐根递推趋势状态（迟滞依赖上一根K线的状态）
+//
+// 数据不足短期/长期 EMA 周期的K线为 RANGING，从第一根数据足够的K线开始递推
+func (ta *TrendAnalyzer) trendStates(emaShort, emaLong []float64) []TrendState {
+	states := make([]TrendState, len(emaLong))
+	prev := RANGING
+	for i := range states {
+		if i+1 >= ta.emaLongPeriod && i+1 >= ta.emaShortPeriod {
+			prev = ta.nextTrend(prev, (emaShort[i]-emaLong[i])/emaLong[i])
+		}
+		states[i] = prev
+	}
+	return states
+}
+
 // CanOpenLong 是否允许开多单 ⭐ 方案3：多信号组合检测
 // 参数：
 //   - candles: K线历史数据
@@ -110,6 +210,13 @@ func (ta *TrendAnalyzer) DetectTrend(candles []value_objects.Candle) TrendState
 //   3. 检查连续阴线（最近10根K线中80%是阴线）⭐ 新增
 //   4. 检查 EMA 趋势是否为下降趋势（整体判断）
 func (ta *TrendAnalyzer) CanOpenLong(candles []value_objects.Candle) bool {
+	return ta.canOpenLong(candles, func() TrendState { return ta.DetectTrend(candles) })
+}
+
+// canOpenLong CanOpenLong 的判断逻辑，EMA 趋势由 trend 提供（只在前三项检查通过后调用）
+//
+// ConfirmedCanOpenLong 对每个前缀传入递推得到的趋势，避免每个前缀都从头计算 EMA
+func (ta *TrendAnalyzer) canOpenLong(candles []value_objects.Candle, trend func() TrendState) bool {
 	if len(candles) < ta.emaLongPeriod {
 		return true // 数据不足，默认允许（保守策略）
 	}
@@ -144,8 +251,7 @@ func (ta *TrendAnalyzer) CanOpenLong(candles []value_objects.Candle) bool {
 	}
 
 	// 检查 4: EMA 趋势检测 → 下降趋势禁止开多单
-	trend := ta.DetectTrend(candles)
-	if trend == STRONG_DOWNTREND {
+	if trend() == STRONG_DOWNTREND {
 		// 下降趋势，禁止开多单
 		return false
 	}
@@ -154,6 +260,41 @@ func (ta *TrendAnalyzer) CanOpenLong(candles []value_objects.Candle) bool {
 	return true
 }
 
+// ConfirmedCanOpenLong 经过确认的开多判断（趋势过滤使用）⭐
+//
+// 对每个历史前缀计算 CanOpenLong，从最新的K线往前，
+// 返回最近一段连续 confirmCandles 根相同结论的结论：结论翻转后要持续 confirmCandles 根K线才生效，
+// 只出现一根的反向结论被忽略。分析器保持无状态，结论完全由传入的K线决定。
+// 数据不足的前缀按 CanOpenLong 视为允许；找不到足够长的连续结论时默认允许。
+// confirmCandles = 1 时等同于 CanOpenLong
+//
+// 各前缀的 EMA 由 EMASeries 一次递推得到（与逐个前缀调用 calculateEMA 逐位相同），
+// 扫描整个窗口为 O(n)，而不是每个前缀重新计算 EMA 的 O(n²)
+func (ta *TrendAnalyzer) ConfirmedCanOpenLong(candles []value_objects.Candle) bool {
+	if ta.confirmCandles <= 1 {
+		return ta.CanOpenLong(candles)
+	}
+
+	// 每个前缀的 EMA 和趋势状态一次递推得到，按需取用
+	trends := ta.trendStates(ta.EMASeries(candles, ta.emaShortPeriod), ta.EMASeries(candles, ta.emaLongPeriod))
+
+	run, verdict := 0, true
+	for end := len(candles); end > 0; end-- {
+		current := ta.canOpenLong(candles[:end], func() TrendState {
+			return trends[end-1]
+		})
+		if run > 0 && current == verdict {
+			run++
+		} else {
+			run, verdict = 1, current
+		}
+		if run >= ta.confirmCandles {
+			return verdict
+		}
+	}
+	return true
+}
+
 // CanOpenShort 是否允许开空单 ⭐
 // 参数：
 //   - candles: K线历史数据
@@ -206,21 +347,77 @@ func (ta *TrendAnalyzer) calculateEMA(candles []value_objects.Candle, period int
 	// 1. 计算初始 SMA（简单移动平均）
 	sum := 0.0
 	for i := 0; i < period; i++ {
-		sum += candles[i].Close().Value()
+		sum += ta.sourcePrice(candles[i])
 	}
 	ema := sum / float64(period)
 
 	// 2. 计算 EMA（指数加权）
-	// EMA(t) = (Close(t) - EMA(t-1)) * multiplier + EMA(t-1)
+	// EMA(t) = (Price(t) - EMA(t-1)) * multiplier + EMA(t-1)，Price 由 priceSource 决定
 	// multiplier = 2 / (period + 1)
 	multiplier := 2.0 / float64(period+1)
 	for i := period; i < len(candles); i++ {
-		ema = (candles[i].Close().Value()-ema)*multiplier + ema
+		ema = (ta.sourcePrice(candles[i])-ema)*multiplier + ema
 	}
 
 	return ema
 }
 
+// EMASeries 计算每根K线对应的 EMA 序列（用于图表叠加）⭐
+// 参数：
+//   - candles: K线历史数据
+//   - period: EMA 周期
+//
+// 返回：
+//   - []float64: 与 candles 等长；前 period-1 根数据不足为 0，第 period 根为初始 SMA，之后递推
+//
+// 一次递推得到整条序列（不对每个点重新计算 EMA），最后一个值与 calculateEMA 一致
+func (ta *TrendAnalyzer) EMASeries(candles []value_objects.Candle, period int) []float64 {
+	series := make([]float64, len(candles))
+	if period <= 0 || len(candles) < period {
+		return series
+	}
+
+	// 初始 SMA + 指数加权递推（与 calculateEMA 相同）
+	ema := newRollingEMA(period)
+	for i, candle := range candles {
+		series[i], _ = ema.Update(ta.sourcePrice(candle))
+	}
+
+	return series
+}
+
+// TrendPoint 单根K线的趋势数据（用于图表）
+type TrendPoint struct {
+	Time     time.Time  // K线时间
+	EMAShort float64    // 短期 EMA（数据不足为 0）
+	EMALong  float64    // 长期 EMA（数据不足为 0）
+	Trend    TrendState // 趋势状态（与 DetectTrend(candles[:i+1]) 一致）
+}
+
+// TrendSeries 计算每根K线的短期/长期 EMA 和趋势状态 ⭐
+// 参数：
+//   - candles: K线历史数据
+//
+// 返回：
+//   - []TrendPoint: 与 candles 等长；K线数量不足长期 EMA 周期时为 RANGING
+func (ta *TrendAnalyzer) TrendSeries(candles []value_objects.Candle) []TrendPoint {
+	emaShort := ta.EMASeries(candles, ta.emaShortPeriod)
+	emaLong := ta.EMASeries(candles, ta.emaLongPeriod)
+
+	trends := ta.trendStates(emaShort, emaLong) // 数据不足的K线为 RANGING
+
+	points := make([]TrendPoint, len(candles))
+	for i, candle := range candles {
+		points[i] = TrendPoint{
+			Time:     candle.Timestamp(),
+			EMAShort: emaShort[i],
+			EMALong:  emaLong[i],
+			Trend:    trends[i],
+		}
+	}
+	return points
+}
+
 // calculatePriceChange 计算价格变化百分比 ⭐
 // 参数：
 //   - candles: K线历史数据
@@ -233,8 +430,8 @@ func (ta *TrendAnalyzer) calculatePriceChange(candles []value_objects.Candle, pe
 		return 0
 	}
 
-	startPrice := candles[len(candles)-period].Close().Value()
-	endPrice := candles[len(candles)-1].Close().Value()
+	startPrice := ta.sourcePrice(candles[len(candles)-period])
+	endPrice := ta.sourcePrice(candles[len(candles)-1])
 
 	return (endPrice - startPrice) / startPrice
 }
@@ -255,7 +452,7 @@ func (ta *TrendAnalyzer) countConsecutiveBearish(candles []value_objects.Candle,
 	startIdx := len(candles) - period
 
 	for i := startIdx; i < len(candles); i++ {
-		if candles[i].IsBearish() { // 收盘价 < 开盘价
+		if ta.sourcePrice(candles[i]) < candles[i].Open().Value() { // 价格（默认收盘价）< 开盘价
 			bearishCount++
 		}
 	}
@@ -330,3 +527,8 @@ type TrendInfo struct {
 	MinRequired      int     // 最少需要的K线数量
 	Current          int     // 当前K线数量
 }
+
+// sourcePrice 按配置的价格来源取出K线价格（EMA、价格跌幅、阴线统计共用）⭐
+func (ta *TrendAnalyzer) sourcePrice(candle value_objects.Candle) float64 {
+	return ta.priceSource.Price(candle)
+}
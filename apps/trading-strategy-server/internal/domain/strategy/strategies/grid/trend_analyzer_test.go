@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/internal/testutil"
 )
 
 // TestTrendAnalyzer_DetectTrend 测试趋势检测功能
@@ -23,17 +24,17 @@ func TestTrendAnalyzer_DetectTrend(t *testing.T) {
 	}{
 		{
 			name:     "震荡行情 - 价格波动小",
-			candles:  generateRangingCandles(60, 2500.0, 0.001), // 波动 0.1%
+			candles:  testutil.GenerateRanging(60, 2500.0, 0.001), // 波动 0.1%
 			expected: RANGING,
 		},
 		{
 			name:     "上升趋势 - 持续上涨",
-			candles:  generateTrendingCandles(60, 2500.0, 0.01), // 上涨 1%/根
+			candles:  testutil.GenerateTrending(60, 2500.0, 0.01), // 上涨 1%/根
 			expected: STRONG_UPTREND,
 		},
 		{
 			name:     "下降趋势 - 持续下跌",
-			candles:  generateTrendingCandles(60, 2500.0, -0.01), // 下跌 1%/根
+			candles:  testutil.GenerateTrending(60, 2500.0, -0.01), // 下跌 1%/根
 			expected: STRONG_DOWNTREND,
 		},
 	}
@@ -64,22 +65,22 @@ func TestTrendAnalyzer_CanOpenLong(t *testing.T) {
 	}{
 		{
 			name:     "震荡行情 - 允许开多",
-			candles:  generateRangingCandles(60, 2500.0, 0.001),
+			candles:  testutil.GenerateRanging(60, 2500.0, 0.001),
 			expected: true,
 		},
 		{
 			name:     "下降趋势 - 禁止开多",
-			candles:  generateTrendingCandles(60, 2500.0, -0.01),
+			candles:  testutil.GenerateTrending(60, 2500.0, -0.01),
 			expected: false,
 		},
 		{
 			name:     "上升趋势 - 允许开多",
-			candles:  generateTrendingCandles(60, 2500.0, 0.01),
+			candles:  testutil.GenerateTrending(60, 2500.0, 0.01),
 			expected: true,
 		},
 		{
 			name:     "单根大跌 - 禁止开多",
-			candles:  generateCandlesWithSharpMove(60, 2500.0, -0.008), // 最后一根跌 0.8%
+			candles:  testutil.GenerateSharpMove(60, 2500.0, -0.008), // 最后一根跌 0.8%
 			expected: false,
 		},
 	}
@@ -123,7 +124,7 @@ func TestTrendAnalyzer_GetTrendInfo(t *testing.T) {
 		EMALongPeriod:   50,
 	})
 
-	candles := generateRangingCandles(60, 2500.0, 0.001)
+	candles := testutil.GenerateRanging(60, 2500.0, 0.001)
 	info := analyzer.GetTrendInfo(candles)
 
 	if info.Status == "" {
@@ -137,80 +138,241 @@ func TestTrendAnalyzer_GetTrendInfo(t *testing.T) {
 	t.Logf("Trend Info: %+v", info)
 }
 
-// === 辅助函数：生成测试数据 ===
+// TestTrendAnalyzer_EMASeries 测试 EMA 序列长度和最后一个值与 calculateEMA 一致
+func TestTrendAnalyzer_EMASeries(t *testing.T) {
+	analyzer := NewTrendAnalyzer(TrendAnalyzerConfig{})
+	candles := testutil.GenerateTrending(80, 2500.0, -0.002)
 
-// generateRangingCandles 生成震荡行情的K线数据
-func generateRangingCandles(count int, startPrice float64, volatility float64) []value_objects.Candle {
-	candles := make([]value_objects.Candle, count)
-	price := startPrice
+	series := analyzer.EMASeries(candles, 20)
+	if len(series) != len(candles) {
+		t.Fatalf("EMASeries() length = %d, want %d", len(series), len(candles))
+	}
+	if series[18] != 0 {
+		t.Errorf("EMASeries()[18] = %v, want 0 before enough data", series[18])
+	}
+	for _, end := range []int{20, 50, len(candles)} {
+		if got, want := series[end-1], analyzer.calculateEMA(candles[:end], 20); got != want {
+			t.Errorf("EMASeries()[%d] = %v, want calculateEMA = %v", end-1, got, want)
+		}
+	}
 
-	for i := range candles {
-		// 随机波动（使用简单的正弦函数模拟）
-		change := volatility * float64(i%10-5) / 5.0
-		open := price
-		close := price * (1 + change)
-		high := max(open, close) * 1.001
-		low := min(open, close) * 0.999
-
-		candle, _ := value_objects.NewCandle(open, high, low, close, time.Now().Add(time.Duration(i)*5*time.Minute))
-		candles[i] = candle
+	if short := analyzer.EMASeries(candles[:10], 20); len(short) != 10 || short[9] != 0 {
+		t.Errorf("EMASeries() with insufficient data = %v, want all zeros", short)
+	}
+}
 
-		price = close
+// TestTrendAnalyzer_TrendSeries 测试趋势序列与逐点 DetectTrend 一致
+func TestTrendAnalyzer_TrendSeries(t *testing.T) {
+	analyzer := NewTrendAnalyzer(TrendAnalyzerConfig{})
+	candles := append(testutil.GenerateRanging(60, 2500.0, 0.001), testutil.GenerateTrending(40, 2500.0, -0.003)...)
+
+	points := analyzer.TrendSeries(candles)
+	if len(points) != len(candles) {
+		t.Fatalf("TrendSeries() length = %d, want %d", len(points), len(candles))
+	}
+
+	sawDowntrend := false
+	for i, point := range points {
+		if want := analyzer.DetectTrend(candles[:i+1]); point.Trend != want {
+			t.Errorf("TrendSeries()[%d].Trend = %s, want %s", i, point.Trend, want)
+		}
+		sawDowntrend = sawDowntrend || point.Trend == STRONG_DOWNTREND
+	}
+	if !sawDowntrend {
+		t.Error("TrendSeries() expected a STRONG_DOWNTREND after the drop")
 	}
 
-	return candles
+	last := points[len(points)-1]
+	info := analyzer.GetTrendInfo(candles)
+	if last.EMAShort != info.EMAShort || last.EMALong != info.EMALong {
+		t.Errorf("TrendSeries() last EMAs = %v/%v, want %v/%v", last.EMAShort, last.EMALong, info.EMAShort, info.EMALong)
+	}
 }
 
-// generateTrendingCandles 生成趋势行情的K线数据
-func generateTrendingCandles(count int, startPrice float64, trendRate float64) []value_objects.Candle {
-	candles := make([]value_objects.Candle, count)
-	price := startPrice
+// TestTrendAnalyzer_PriceSource 测试 hlc3 与收盘价在同一组K线上得到不同的 EMA ⭐
+func TestTrendAnalyzer_PriceSource(t *testing.T) {
+	candles := testutil.GenerateTrending(60, 2500.0, -0.002)
 
-	for i := range candles {
-		open := price
-		close := open * (1 + trendRate)
-		high := max(open, close) * 1.001
-		low := min(open, close) * 0.999
+	closeAnalyzer := NewTrendAnalyzer(TrendAnalyzerConfig{})
+	hlc3Analyzer := NewTrendAnalyzer(TrendAnalyzerConfig{PriceSource: PriceSourceHLC3})
 
-		candle, _ := value_objects.NewCandle(open, high, low, close, time.Now().Add(time.Duration(i)*5*time.Minute))
-		candles[i] = candle
+	closeEMA := closeAnalyzer.calculateEMA(candles, 20)
+	hlc3EMA := hlc3Analyzer.calculateEMA(candles, 20)
+	if closeEMA == hlc3EMA {
+		t.Fatalf("Expected hlc3 EMA to differ from close EMA, both %v", closeEMA)
+	}
+
+	// 下跌K线的收盘价是实体低点，典型价格 (H+L+C)/3 高于收盘价
+	if hlc3EMA <= closeEMA {
+		t.Errorf("Expected hlc3 EMA %v > close EMA %v on falling candles", hlc3EMA, closeEMA)
+	}
+	if series := hlc3Analyzer.EMASeries(candles, 20); series[len(series)-1] != hlc3EMA {
+		t.Errorf("EMASeries() last = %v, want %v", series[len(series)-1], hlc3EMA)
+	}
+
+	// 默认（空）等同于 close
+	if got := NewTrendAnalyzer(TrendAnalyzerConfig{PriceSource: PriceSourceClose}).calculateEMA(candles, 20); got != closeEMA {
+		t.Errorf("Expected explicit close EMA %v, got %v", closeEMA, got)
+	}
 
-		price = close
+	candle := candles[0]
+	wantHLC3 := (candle.High().Value() + candle.Low().Value() + candle.Close().Value()) / 3
+	if got := PriceSourceHLC3.Price(candle); got != wantHLC3 {
+		t.Errorf("PriceSourceHLC3.Price() = %v, want %v", got, wantHLC3)
 	}
 
-	return candles
+	if _, err := ParsePriceSource("vwap"); err == nil {
+		t.Error("Expected error for unknown price source")
+	}
+	if _, err := NewGridAggregate(GridConfig{
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+		TrendFilterConfig: TrendAnalyzerConfig{PriceSource: "vwap"},
+	}); err == nil {
+		t.Error("Expected NewGridAggregate to reject unknown price source")
+	}
 }
 
-// generateCandlesWithSharpMove 生成最后一根K线有剧烈波动的数据
-func generateCandlesWithSharpMove(count int, startPrice float64, lastMove float64) []value_objects.Candle {
-	// 前面的K线都是正常震荡
-	candles := generateRangingCandles(count-1, startPrice, 0.001)
+// TestTrendAnalyzer_ConfirmCandles 测试确认K线数：单根K线的反向结论被忽略，持续的变化才生效
+func TestTrendAnalyzer_ConfirmCandles(t *testing.T) {
+	config := TrendAnalyzerConfig{
+		EMAThreshold:    0.005,
+		CandleThreshold: 0.006,
+		EMAShortPeriod:  20,
+		EMALongPeriod:   50,
+	}
+	immediate := NewTrendAnalyzer(config)
+	config.ConfirmCandles = 2
+	confirmed := NewTrendAnalyzer(config)
+
+	// nextCandle 在最后一根K线之后追加一根涨跌 move 的K线
+	nextCandle := func(candles []value_objects.Candle, move float64) []value_objects.Candle {
+		last := candles[len(candles)-1]
+		open := last.Close().Value()
+		closePrice := open * (1 + move)
+		candle, _ := value_objects.NewCandle(open, max(open, closePrice), min(open, closePrice), closePrice, last.Timestamp().Add(testutil.Interval))
+		return append(candles[:len(candles):len(candles)], candle)
+	}
 
-	// 最后一根K线剧烈波动
-	lastCandle := candles[len(candles)-1]
-	open := lastCandle.Close().Value()
-	close := open * (1 + lastMove)
-	high := max(open, close) * 1.001
-	low := min(open, close) * 0.999
+	// 1. 单根大跌：原始结论立即翻转为禁止，确认后仍允许（闪烁被忽略）
+	flicker := testutil.GenerateSharpMove(60, 2500.0, -0.008)
+	if immediate.CanOpenLong(flicker) || immediate.ConfirmedCanOpenLong(flicker) {
+		t.Fatal("Expected the sharp drop to block immediately without confirmation")
+	}
+	if !confirmed.ConfirmedCanOpenLong(flicker) {
+		t.Error("Expected a one-candle flicker to be suppressed with ConfirmCandles = 2")
+	}
+
+	// 2. 下一根K线反弹：原始结论恢复允许，确认后一直允许
+	recovered := nextCandle(flicker, 0.008)
+	if !immediate.CanOpenLong(recovered) || !confirmed.ConfirmedCanOpenLong(recovered) {
+		t.Error("Expected the recovery candle to allow opening")
+	}
 
-	sharpCandle, _ := value_objects.NewCandle(open, high, low, close, time.Now())
-	candles = append(candles, sharpCandle)
+	// 3. 连续两根大跌：持续的变化在第二根K线生效
+	sustained := nextCandle(flicker, -0.008)
+	if immediate.CanOpenLong(sustained) {
+		t.Fatal("Expected the second drop candle to block without confirmation")
+	}
+	if confirmed.ConfirmedCanOpenLong(sustained) {
+		t.Error("Expected a sustained block to be honored after 2 candles")
+	}
+
+	// 4. 默认（0）等同于 1：不延迟
+	if NewTrendAnalyzer(TrendAnalyzerConfig{EMAThreshold: 0.005, CandleThreshold: 0.006}).ConfirmedCanOpenLong(flicker) {
+		t.Error("Expected default ConfirmCandles to act immediately")
+	}
 
-	return candles
+	if _, err := NewGridAggregate(GridConfig{
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.002,
+		TrendFilterConfig: TrendAnalyzerConfig{ConfirmCandles: -1},
+	}); err == nil {
+		t.Error("Expected NewGridAggregate to reject negative confirm candles")
+	}
 }
 
-// min 返回两个浮点数中的较小值
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+// TestTrendAnalyzer_ThresholdBoundary 测试恰好等于阈值时的边界：严格大于才进入趋势
+func TestTrendAnalyzer_ThresholdBoundary(t *testing.T) {
+	analyzer := NewTrendAnalyzer(TrendAnalyzerConfig{EMAThreshold: 0.005})
+
+	tests := []struct {
+		diff     float64
+		expected TrendState
+	}{
+		{0.005, RANGING},
+		{-0.005, RANGING},
+		{0.0050001, STRONG_UPTREND},
+		{-0.0050001, STRONG_DOWNTREND},
+	}
+	for _, tt := range tests {
+		if got := analyzer.nextTrend(RANGING, tt.diff); got != tt.expected {
+			t.Errorf("nextTrend(RANGING, %v) = %s, want %s", tt.diff, got, tt.expected)
+		}
+	}
+
+	// EMA 差距恰好为 0.5%
+	if got := analyzer.classifyTrend(100.5, 100); got != RANGING {
+		t.Errorf("classifyTrend() at exactly the threshold = %s, want RANGING", got)
 	}
-	return b
 }
 
-// max 返回两个浮点数中的较大值
-func max(a, b float64) float64 {
-	if a > b {
-		return a
+// TestTrendAnalyzer_Hysteresis 测试差距在阈值附近来回徘徊时，迟滞带使状态保持稳定 ⭐
+func TestTrendAnalyzer_Hysteresis(t *testing.T) {
+	plain := NewTrendAnalyzer(TrendAnalyzerConfig{EMAThreshold: 0.005})
+	damped := NewTrendAnalyzer(TrendAnalyzerConfig{EMAThreshold: 0.005, Hysteresis: 0.001})
+
+	// flips 按差距序列递推状态，返回最终状态和状态变化次数
+	flips := func(analyzer *TrendAnalyzer, diffs []float64) (TrendState, int) {
+		state, changes := RANGING, 0
+		for _, diff := range diffs {
+			next := analyzer.nextTrend(state, diff)
+			if next != state {
+				changes++
+			}
+			state = next
+		}
+		return state, changes
+	}
+
+	// 差距在 0.5% 上下 ±0.02% 来回徘徊
+	oscillating := make([]float64, 20)
+	for i := range oscillating {
+		oscillating[i] = 0.005 + 0.0002*float64(1-2*(i%2))
+	}
+	if _, changes := flips(plain, oscillating); changes != len(oscillating) {
+		t.Errorf("Expected the state to flip on every candle without hysteresis, got %d changes", changes)
+	}
+	if state, changes := flips(damped, oscillating); state != STRONG_UPTREND || changes != 1 {
+		t.Errorf("Expected one entry into STRONG_UPTREND with hysteresis, got %s after %d changes", state, changes)
+	}
+
+	// 回落到 阈值 - 迟滞带（含）以内才退出；下降趋势对称
+	if got := damped.nextTrend(STRONG_UPTREND, 0.004); got != RANGING {
+		t.Errorf("Expected exit at exactly threshold - hysteresis, got %s", got)
+	}
+	if got := damped.nextTrend(STRONG_DOWNTREND, -0.0041); got != STRONG_DOWNTREND {
+		t.Errorf("Expected downtrend held inside the band, got %s", got)
+	}
+	if got := damped.nextTrend(RANGING, -0.0049); got != RANGING {
+		t.Errorf("Expected no entry inside the band from RANGING, got %s", got)
+	}
+
+	// K线上：TrendSeries 与逐个前缀 DetectTrend 一致
+	candles := append(testutil.GenerateRanging(60, 2500.0, 0.001), testutil.GenerateTrending(40, 2500.0, -0.003)...)
+	for i, point := range damped.TrendSeries(candles) {
+		if want := damped.DetectTrend(candles[:i+1]); point.Trend != want {
+			t.Errorf("TrendSeries()[%d].Trend = %s, want %s", i, point.Trend, want)
+		}
+	}
+
+	for _, hysteresis := range []float64{-0.001, 0.005} {
+		if _, err := NewGridAggregate(GridConfig{
+			TakeProfitRateMin: 0.0015,
+			TakeProfitRateMax: 0.002,
+			TrendFilterConfig: TrendAnalyzerConfig{EMAThreshold: 0.005, Hysteresis: hysteresis},
+		}); err == nil {
+			t.Errorf("Expected NewGridAggregate to reject hysteresis %v", hysteresis)
+		}
 	}
-	return b
 }
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,17 +22,88 @@ type Config struct {
 
 // StrategyConfig 策略配置
 type StrategyConfig struct {
-	Instruments []string   // 要監控的交易對列表，例如: BTC-USDT,ETH-USDT
-	Type        string     // 策略類型: grid, dca, etc.
-	Grid        GridConfig // 網格策略參數
+	Instruments []string                // 要監控的交易對列表，例如: BTC-USDT,ETH-USDT
+	Type        string                  // 策略類型: grid, dca, etc.
+	Bar         string                  // K線週期: 1m, 5m, 1H（趨勢分析週期以K線根數計，切換週期會改變其實際時長）
+	Grid        GridConfig              // 網格策略參數（所有交易對的默認值）
+	GridByInst  map[string]GridOverride // 按交易對覆蓋的網格參數 ⭐
+
+	// 實盤時效控制 ⭐
+	PriceMaxAge         time.Duration // Ticker 最大允許延遲（超過則視為行情中斷）
+	AdviceTTL           time.Duration // 開倉建議最大有效時間
+	AdviceMaxPriceDrift float64       // 建議執行前允許的最大價格偏移比例
+
+	// 開倉建議校驗（乾跑安全層）⭐
+	MinOrderSize      float64 // 最小下單金額（USDT）
+	MaxPriceDeviation float64 // 開倉價相對市價的最大偏離比例
+	SimulatedBalance  float64 // 模擬帳戶可用餘額（USDT）
+
+	// 交易對規格（價格精度、最小下單數量）⭐
+	InstrumentsSource string // OKX 交易對規格來源：接口 URL 或本地 JSON 文件（空 = 不載入，使用默認 2 位小數）
+	InstrumentsType   string // 產品類型（SPOT / SWAP，請求接口時使用）
 }
 
 // GridConfig 網格策略配置
 type GridConfig struct {
-	TakeProfitMin float64 // 最小停利百分比
-	TakeProfitMax float64 // 最大停利百分比
-	MaxPositions  int     // 最大持倉數量
-	MaxNotional   float64 // 最大持倉名義價值（美元）
+	PositionSize         float64 // 單次開倉大小（美元）
+	TakeProfitMin        float64 // 最小停利百分比
+	TakeProfitMax        float64 // 最大停利百分比
+	FirstEntryTakeProfit float64 // 本輪第一筆倉位的停利百分比（0 = 與 TakeProfitMin 相同）⭐
+	MaxPositions         int     // 最大持倉數量
+	MaxNotional          float64 // 最大持倉名義價值（美元）
+	MinNetProfit         float64 // 單筆止盈扣除手續費後的最小淨利潤（USDT，0 = 不限制）⭐
+	MinPriceGap          float64 // 價位去重：與本輪已有倉位開倉價的最小相對距離（0 = 不限制）⭐
+}
+
+// GridOverride 單個交易對的網格參數覆蓋（nil 表示沿用默認值）
+//
+// 由 GRID_INSTRUMENTS 環境變量以 JSON 提供，例如：
+//
+//	{"BTC-USDT-SWAP": {"takeProfitMin": 0.002, "positionSize": 300}, "ETH-USDT-SWAP": {}}
+type GridOverride struct {
+	PositionSize         *float64 `json:"positionSize,omitempty"`
+	TakeProfitMin        *float64 `json:"takeProfitMin,omitempty"`
+	TakeProfitMax        *float64 `json:"takeProfitMax,omitempty"`
+	FirstEntryTakeProfit *float64 `json:"firstEntryTakeProfit,omitempty"`
+	MaxPositions         *int     `json:"maxPositions,omitempty"`
+	MaxNotional          *float64 `json:"maxNotional,omitempty"`
+	MinNetProfit         *float64 `json:"minNetProfit,omitempty"`
+	MinPriceGap          *float64 `json:"minPriceGap,omitempty"`
+}
+
+// GridFor 返回指定交易對的網格參數（覆蓋值合併到默認值之上）⭐
+func (s StrategyConfig) GridFor(instID string) GridConfig {
+	merged := s.Grid
+	override, ok := s.GridByInst[instID]
+	if !ok {
+		return merged
+	}
+
+	if override.PositionSize != nil {
+		merged.PositionSize = *override.PositionSize
+	}
+	if override.TakeProfitMin != nil {
+		merged.TakeProfitMin = *override.TakeProfitMin
+	}
+	if override.TakeProfitMax != nil {
+		merged.TakeProfitMax = *override.TakeProfitMax
+	}
+	if override.FirstEntryTakeProfit != nil {
+		merged.FirstEntryTakeProfit = *override.FirstEntryTakeProfit
+	}
+	if override.MaxPositions != nil {
+		merged.MaxPositions = *override.MaxPositions
+	}
+	if override.MaxNotional != nil {
+		merged.MaxNotional = *override.MaxNotional
+	}
+	if override.MinNetProfit != nil {
+		merged.MinNetProfit = *override.MinNetProfit
+	}
+	if override.MinPriceGap != nil {
+		merged.MinPriceGap = *override.MinPriceGap
+	}
+	return merged
 }
 
 type RedisConfig struct {
@@ -36,6 +111,13 @@ type RedisConfig struct {
 	Password string
 	DB       int
 	PoolSize int
+
+	// KeyNamespace 所有 key 的前綴（例: "prod" → prod.price.latest.{instId}，空 = 無前綴）⭐
+	// market-data-service 和 trading-strategy-server 必須設置相同的值
+	KeyNamespace string
+
+	// ReadTimeout 單次讀取市場數據的超時（Redis 卡住時即時諮詢循環不會無限等待）⭐
+	ReadTimeout time.Duration
 }
 
 var AppConfig *Config
@@ -48,8 +130,24 @@ func Load() *Config {
 		log.Println("⚠️  No .env file found")
 	}
 
-	instruments := getEnvOrDefault("STRATEGY_INSTRUMENTS", "BTC-USDT,ETH-USDT")
-	instList := parseInstruments(instruments)
+	gridByInst, err := parseGridOverrides(os.Getenv("GRID_INSTRUMENTS"))
+	if err != nil {
+		log.Fatalf("❌ Invalid GRID_INSTRUMENTS: %v", err)
+	}
+
+	// 未設置 STRATEGY_INSTRUMENTS 時，交易對列表取自 GRID_INSTRUMENTS 的 key ⭐
+	instList := parseInstruments(os.Getenv("STRATEGY_INSTRUMENTS"))
+	if len(instList) == 0 {
+		instList = overrideInstruments(gridByInst)
+	}
+	if len(instList) == 0 {
+		instList = parseInstruments("BTC-USDT,ETH-USDT")
+	}
+	for instID := range gridByInst {
+		if !containsString(instList, instID) {
+			log.Fatalf("❌ GRID_INSTRUMENTS configures %s which is not in STRATEGY_INSTRUMENTS", instID)
+		}
+	}
 
 	cfg := &Config{
 		Port:        requireEnv("PORT"),
@@ -58,18 +156,36 @@ func Load() *Config {
 		Strategy: StrategyConfig{
 			Instruments: instList,
 			Type:        getEnvOrDefault("STRATEGY_TYPE", "grid"),
+			Bar:         getEnvOrDefault("STRATEGY_BAR", "5m"),
 			Grid: GridConfig{
-				TakeProfitMin: getEnvFloatOrDefault("GRID_TP_MIN", 0.001), // 0.1%
-				TakeProfitMax: getEnvFloatOrDefault("GRID_TP_MAX", 0.003), // 0.3%
-				MaxPositions:  getEnvIntOrDefault("GRID_MAX_POSITIONS", 30),
-				MaxNotional:   getEnvFloatOrDefault("GRID_MAX_NOTIONAL", 3000.0),
+				PositionSize:         getEnvFloatOrDefault("GRID_POSITION_SIZE", 200.0),
+				TakeProfitMin:        getEnvFloatOrDefault("GRID_TP_MIN", 0.001), // 0.1%
+				TakeProfitMax:        getEnvFloatOrDefault("GRID_TP_MAX", 0.003), // 0.3%
+				FirstEntryTakeProfit: getEnvFloatOrDefault("GRID_TP_FIRST_ENTRY", 0.0),
+				MaxPositions:         getEnvIntOrDefault("GRID_MAX_POSITIONS", 30),
+				MaxNotional:          getEnvFloatOrDefault("GRID_MAX_NOTIONAL", 3000.0),
+				MinNetProfit:         getEnvFloatOrDefault("GRID_MIN_NET_PROFIT", 0.0),
+				MinPriceGap:          getEnvFloatOrDefault("GRID_MIN_PRICE_GAP", 0.0),
 			},
+			GridByInst:          gridByInst,
+			PriceMaxAge:         getEnvDurationOrDefault("PRICE_MAX_AGE", 15*time.Second),
+			AdviceTTL:           getEnvDurationOrDefault("ADVICE_TTL", 3*time.Second),
+			AdviceMaxPriceDrift: getEnvFloatOrDefault("ADVICE_MAX_PRICE_DRIFT", 0.001), // 0.1%
+			MinOrderSize:        getEnvFloatOrDefault("ADVICE_MIN_ORDER_SIZE", 5.0),
+			MaxPriceDeviation:   getEnvFloatOrDefault("ADVICE_MAX_PRICE_DEVIATION", 0.01), // 1%
+			SimulatedBalance:    getEnvFloatOrDefault("SIMULATED_BALANCE", 10000.0),
+			InstrumentsSource:   getEnvOrDefault("INSTRUMENTS_SOURCE", ""),
+			InstrumentsType:     getEnvOrDefault("INSTRUMENTS_TYPE", "SPOT"),
 		},
 		Redis: RedisConfig{
 			Addr:     requireEnv("REDIS_ADDR"),
 			Password: getEnvOrDefault("REDIS_PASSWORD", ""),
 			DB:       getEnvIntOrDefault("REDIS_DB", 0),
 			PoolSize: getEnvIntOrDefault("REDIS_POOL_SIZE", 10),
+
+			KeyNamespace: getEnvOrDefault("REDIS_KEY_NAMESPACE", ""),
+
+			ReadTimeout: getEnvDurationOrDefault("REDIS_READ_TIMEOUT", 2*time.Second),
 		},
 	}
 
@@ -119,6 +235,55 @@ func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
 	return floatValue
 }
 
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	durationValue, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid duration value for %s, using default: %s", key, defaultValue)
+		return defaultValue
+	}
+	return durationValue
+}
+
+// parseGridOverrides 解析 GRID_INSTRUMENTS（JSON: instID -> GridOverride），空字符串返回空 map
+func parseGridOverrides(raw string) (map[string]GridOverride, error) {
+	overrides := make(map[string]GridOverride)
+	if trimSpace(raw) == "" {
+		return overrides, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse grid overrides: %w", err)
+	}
+	for instID := range overrides {
+		if trimSpace(instID) == "" {
+			return nil, fmt.Errorf("grid overrides contain an empty instrument id")
+		}
+	}
+	return overrides, nil
+}
+
+// overrideInstruments 返回覆蓋配置中的交易對（按字母排序，保證啟動順序穩定）
+func overrideInstruments(overrides map[string]GridOverride) []string {
+	result := make([]string, 0, len(overrides))
+	for instID := range overrides {
+		result = append(result, instID)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
 func parseInstruments(instruments string) []string {
 	if instruments == "" {
 		return []string{}
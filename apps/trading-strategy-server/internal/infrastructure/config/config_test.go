@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLoad_PerInstrumentGridOverrides 測試兩個交易對使用不同止盈參數
+func TestLoad_PerInstrumentGridOverrides(t *testing.T) {
+	t.Setenv("PORT", "50052")
+	t.Setenv("ENVIRONMENT", "test")
+	t.Setenv("REDIS_ADDR", "localhost:6379")
+	t.Setenv("STRATEGY_INSTRUMENTS", "")
+	t.Setenv("GRID_TP_MIN", "0.001")
+	t.Setenv("GRID_TP_MAX", "0.003")
+	t.Setenv("GRID_INSTRUMENTS", `{
+		"ETH-USDT-SWAP": {"takeProfitMin": 0.0015},
+		"BTC-USDT-SWAP": {"takeProfitMin": 0.002, "takeProfitMax": 0.004, "firstEntryTakeProfit": 0.001, "positionSize": 300}
+	}`)
+
+	cfg := Load()
+
+	wantInstruments := []string{"BTC-USDT-SWAP", "ETH-USDT-SWAP"}
+	if !reflect.DeepEqual(cfg.Strategy.Instruments, wantInstruments) {
+		t.Fatalf("Expected instruments %v, got %v", wantInstruments, cfg.Strategy.Instruments)
+	}
+
+	btc := cfg.Strategy.GridFor("BTC-USDT-SWAP")
+	if btc.TakeProfitMin != 0.002 || btc.TakeProfitMax != 0.004 || btc.FirstEntryTakeProfit != 0.001 || btc.PositionSize != 300 {
+		t.Errorf("Unexpected BTC grid config: %+v", btc)
+	}
+
+	eth := cfg.Strategy.GridFor("ETH-USDT-SWAP")
+	if eth.TakeProfitMin != 0.0015 {
+		t.Errorf("Expected ETH take profit min 0.0015, got %v", eth.TakeProfitMin)
+	}
+	// 未覆蓋的字段沿用默認值
+	if eth.TakeProfitMax != 0.003 || eth.PositionSize != 200 {
+		t.Errorf("Expected ETH to inherit defaults, got %+v", eth)
+	}
+
+	// 沒有覆蓋配置的交易對返回默認值
+	if other := cfg.Strategy.GridFor("SOL-USDT-SWAP"); !reflect.DeepEqual(other, cfg.Strategy.Grid) {
+		t.Errorf("Expected defaults for unknown instrument, got %+v", other)
+	}
+}
+
+func TestParseGridOverrides_Invalid(t *testing.T) {
+	if _, err := parseGridOverrides(`{"ETH-USDT-SWAP": {"takeProfitMin": "high"}}`); err == nil {
+		t.Error("Expected error for non-numeric take profit")
+	}
+	if _, err := parseGridOverrides(`{"": {}}`); err == nil {
+		t.Error("Expected error for empty instrument id")
+	}
+	overrides, err := parseGridOverrides("")
+	if err != nil || len(overrides) != 0 {
+		t.Errorf("Expected empty overrides, got %v (%v)", overrides, err)
+	}
+}
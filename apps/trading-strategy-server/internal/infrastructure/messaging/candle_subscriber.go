@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"dizzycode.xyz/logger"
@@ -15,13 +16,23 @@ import (
 type CandleSubscriber struct {
 	client *RedisClient
 	logger logger.Logger
+
+	mu       sync.Mutex
+	lastSeen map[string]seenCandle // last processed candle per channel (instID + bar) ⭐
+}
+
+// seenCandle is the last candle passed to the callback on a channel
+type seenCandle struct {
+	timestamp time.Time
+	confirmed bool
 }
 
 // NewCandleSubscriber creates a new CandleSubscriber
 func NewCandleSubscriber(client *RedisClient, log logger.Logger) *CandleSubscriber {
 	return &CandleSubscriber{
-		client: client,
-		logger: log,
+		client:   client,
+		logger:   log,
+		lastSeen: make(map[string]seenCandle),
 	}
 }
 
@@ -64,7 +75,7 @@ func (s *CandleSubscriber) Subscribe(
 				continue
 			}
 
-			if err := s.handleCandleMessage(msg.Payload, onCandle); err != nil {
+			if err := s.handleCandleMessage(channel, msg.Payload, onCandle); err != nil {
 				s.logger.Error("Failed to handle candle message", map[string]any{
 					"error":   err,
 					"channel": channel,
@@ -76,12 +87,16 @@ func (s *CandleSubscriber) Subscribe(
 }
 
 // handleCandleMessage parses candle JSON and creates Candle value object
-func (s *CandleSubscriber) handleCandleMessage(payload string, onCandle func(candle value_objects.Candle) error) error {
+//
+// Candles that are stale for the channel are dropped (see accept), so the callback
+// sees each channel's candles in timestamp order even when Pub/Sub redelivers or reorders them
+func (s *CandleSubscriber) handleCandleMessage(channel, payload string, onCandle func(candle value_objects.Candle) error) error {
 	var raw struct {
 		Open      string `json:"open"`
 		High      string `json:"high"`
 		Low       string `json:"low"`
 		Close     string `json:"close"`
+		Confirm   string `json:"confirm"`
 		Timestamp string `json:"ts"`
 	}
 
@@ -122,6 +137,13 @@ func (s *CandleSubscriber) handleCandleMessage(payload string, onCandle func(can
 	if err != nil {
 		return fmt.Errorf("failed to create candle: %w", err)
 	}
+	if raw.Confirm == "0" {
+		candle = candle.WithConfirmed(false)
+	}
+
+	if !s.accept(channel, candle) {
+		return nil
+	}
 
 	s.logger.Debug("Received candle", map[string]any{
 		"open":  open,
@@ -137,3 +159,37 @@ func (s *CandleSubscriber) handleCandleMessage(payload string, onCandle func(can
 
 	return nil
 }
+
+// accept records candle as the last one seen on channel, or reports false when it should be dropped ⭐
+//
+// Rules (per channel, i.e. per instID + bar):
+//   - timestamp before the last processed candle: out-of-order arrival, dropped with a warning
+//   - same timestamp after that candle was confirmed: duplicate, dropped
+//   - same timestamp while the candle is still unconfirmed: in-progress update (or its confirmation), accepted
+//   - later timestamp: accepted
+func (s *CandleSubscriber) accept(channel string, candle value_objects.Candle) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timestamp := candle.Timestamp()
+	if last, ok := s.lastSeen[channel]; ok {
+		if timestamp.Before(last.timestamp) {
+			s.logger.Warn("Dropping out-of-order candle", map[string]any{
+				"channel":   channel,
+				"timestamp": timestamp,
+				"lastSeen":  last.timestamp,
+			})
+			return false
+		}
+		if timestamp.Equal(last.timestamp) && last.confirmed {
+			s.logger.Debug("Dropping duplicate candle", map[string]any{
+				"channel":   channel,
+				"timestamp": timestamp,
+			})
+			return false
+		}
+	}
+
+	s.lastSeen[channel] = seenCandle{timestamp: timestamp, confirmed: candle.IsConfirmed()}
+	return true
+}
@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+func candleJSON(ts time.Time, closePrice float64, confirm string) string {
+	return fmt.Sprintf(`{"open":"2500","high":"2510","low":"2490","close":"%g","confirm":"%s","ts":"%d"}`,
+		closePrice, confirm, ts.UnixMilli())
+}
+
+// TestHandleCandleMessage_DropsDuplicateAndStaleCandles 測試重複和亂序到達的K線不會傳給回調
+func TestHandleCandleMessage_DropsDuplicateAndStaleCandles(t *testing.T) {
+	subscriber := NewCandleSubscriber(nil, logger.NewMulti())
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const channel = "market.candle.1m.ETH-USDT"
+
+	var received []value_objects.Candle
+	onCandle := func(candle value_objects.Candle) error {
+		received = append(received, candle)
+		return nil
+	}
+
+	payloads := []string{
+		candleJSON(start, 2501, "1"),
+		candleJSON(start.Add(time.Minute), 2502, "1"),
+		candleJSON(start.Add(time.Minute), 2502, "1"), // 重複
+		candleJSON(start, 2501, "1"),                  // 亂序（舊K線）
+		candleJSON(start.Add(2*time.Minute), 2503, "0"),
+		candleJSON(start.Add(2*time.Minute), 2504, "0"), // 未完成K線的更新
+		candleJSON(start.Add(2*time.Minute), 2505, "1"), // 同一根K線完成
+		candleJSON(start.Add(2*time.Minute), 2505, "1"), // 完成後重複
+		candleJSON(start.Add(time.Minute), 2502, "1"),   // 亂序
+		candleJSON(start.Add(3*time.Minute), 2506, "1"),
+	}
+	for i, payload := range payloads {
+		if err := subscriber.handleCandleMessage(channel, payload, onCandle); err != nil {
+			t.Fatalf("payload %d: unexpected error: %v", i, err)
+		}
+	}
+
+	wantCloses := []float64{2501, 2502, 2503, 2504, 2505, 2506}
+	if len(received) != len(wantCloses) {
+		t.Fatalf("Expected %d candles, got %d", len(wantCloses), len(received))
+	}
+	for i, candle := range received {
+		if candle.Close().Value() != wantCloses[i] {
+			t.Errorf("candle %d: expected close %v, got %v", i, wantCloses[i], candle.Close().Value())
+		}
+		if i > 0 && candle.Timestamp().Before(received[i-1].Timestamp()) {
+			t.Errorf("candle %d arrived out of order", i)
+		}
+	}
+
+	// 每個頻道（instID + bar）獨立判斷
+	if err := subscriber.handleCandleMessage("market.candle.5m.ETH-USDT", candleJSON(start, 2501, "1"), onCandle); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(received) != len(wantCloses)+1 {
+		t.Error("Expected a candle on another channel to be accepted")
+	}
+}
@@ -3,12 +3,14 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"dizzycode.xyz/logger"
 	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/shared/rediskeys"
 )
 
 type CandleData struct {
@@ -22,28 +24,78 @@ type CandleData struct {
 	Ts      string `json:"ts"` // Timestamp in milliseconds
 }
 
+// ErrStalePrice Redis 中的 Ticker 時間戳超過允許的時間窗口（行情源可能中斷）
+var ErrStalePrice = errors.New("stale price")
+
+// ErrReadTimeout 單次 Redis 讀取超過 readTimeout（Redis 可能卡住）
+var ErrReadTimeout = errors.New("redis read timeout")
+
+// DefaultReadTimeout 單次 Redis 讀取的默認超時
+const DefaultReadTimeout = 2 * time.Second
+
 // MarketDataReader 從 Redis 讀取市場數據
 type MarketDataReader struct {
-	client *RedisClient
-	logger logger.Logger
+	client      *RedisClient
+	keys        rediskeys.Keys // key 格式（含命名空間，與 market-data-service 共用）⭐
+	logger      logger.Logger
+	priceMaxAge time.Duration    // Ticker 最大允許延遲（0 = 不檢查）⭐
+	readTimeout time.Duration    // 單次 Redis 讀取超時（調用方 context 沒有截止時間時也不會無限等待）⭐
+	now         func() time.Time // 當前時間（可注入，便於測試）
 }
 
 // NewMarketDataReader 創建 MarketDataReader
-func NewMarketDataReader(client *RedisClient, log logger.Logger) *MarketDataReader {
+// 參數：
+//   - keys: Redis key 格式，命名空間必須與 market-data-service 一致
+//   - priceMaxAge: Ticker 時間戳的最大允許延遲，超過則 GetLatestPrice 返回 ErrStalePrice（0 = 不檢查）
+func NewMarketDataReader(client *RedisClient, keys rediskeys.Keys, priceMaxAge time.Duration, log logger.Logger) *MarketDataReader {
 	return &MarketDataReader{
-		client: client,
-		logger: log,
+		client:      client,
+		keys:        keys,
+		logger:      log,
+		priceMaxAge: priceMaxAge,
+		readTimeout: DefaultReadTimeout,
+		now:         time.Now,
+	}
+}
+
+// SetReadTimeout 設置單次 Redis 讀取的超時（<= 0 = 默認 2 秒）
+func (r *MarketDataReader) SetReadTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultReadTimeout
 	}
+	r.readTimeout = timeout
+}
+
+// withReadTimeout 以 readTimeout 包裝一次 Redis 讀取 ⭐
+//
+// 超時返回包裝 ErrReadTimeout 的錯誤；調用方自己的 context 取消或到期時原樣返回
+func (r *MarketDataReader) withReadTimeout(ctx context.Context, read func(ctx context.Context) error) error {
+	if r.readTimeout <= 0 {
+		return read(ctx)
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.readTimeout)
+	defer cancel()
+
+	err := read(readCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(readCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrReadTimeout, r.readTimeout, err)
+	}
+	return err
 }
 
 // GetLatestCandle 從 Redis 讀取最新的 Candle（包括未確認的）
-// Key format: candle.latest.{bar}.{instId}
+// Key format: {namespace}.candle.latest.{bar}.{instId}
 // 用於即時監控，不用於策略計算
 func (r *MarketDataReader) GetLatestCandle(ctx context.Context, instID string, bar string) (value_objects.Candle, error) {
-	key := fmt.Sprintf("candle.latest.%s.%s", bar, instID)
+	key := r.keys.CandleLatest(bar, instID)
 
 	// Get from Redis
-	val, err := r.client.Client().Get(ctx, key).Result()
+	var val string
+	err := r.withReadTimeout(ctx, func(ctx context.Context) (err error) {
+		val, err = r.client.Client().Get(ctx, key).Result()
+		return err
+	})
 	if err != nil {
 		return value_objects.Candle{}, fmt.Errorf("failed to get candle from Redis (key: %s): %w", key, err)
 	}
@@ -70,10 +122,14 @@ func (r *MarketDataReader) GetLatestCandle(ctx context.Context, instID string, b
 }
 
 func (r *MarketDataReader) GetCandleHistories(ctx context.Context, instID string, bar string) ([]value_objects.Candle, error) {
-	key := fmt.Sprintf("candle.history.%s.%s", bar, instID)
+	key := r.keys.CandleHistory(bar, instID)
 
 	// Get from Redis
-	val, err := r.client.Client().LRange(ctx, key, 0, -1).Result()
+	var val []string
+	err := r.withReadTimeout(ctx, func(ctx context.Context) (err error) {
+		val, err = r.client.Client().LRange(ctx, key, 0, -1).Result()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get candle from Redis (key: %s): %w", key, err)
 	}
@@ -112,24 +168,39 @@ func (r *MarketDataReader) GetCandleHistories(ctx context.Context, instID string
 }
 
 // GetLatestPrice 從 Redis 讀取最新價格（用於模擬 Order Service）
-// Key format: price.latest.{instId}
+// Key format: {namespace}.price.latest.{instId}
+// 若 Ticker 時間戳超過 priceMaxAge，返回 ErrStalePrice ⭐
 func (r *MarketDataReader) GetLatestPrice(ctx context.Context, instID string) (value_objects.Price, error) {
-	key := fmt.Sprintf("price.latest.%s", instID)
+	key := r.keys.TickerLatest(instID)
 
-	val, err := r.client.Client().Get(ctx, key).Result()
+	var val string
+	err := r.withReadTimeout(ctx, func(ctx context.Context) (err error) {
+		val, err = r.client.Client().Get(ctx, key).Result()
+		return err
+	})
 	if err != nil {
 		return value_objects.Price{}, fmt.Errorf("failed to get price from Redis (key: %s): %w", key, err)
 	}
 
-	// Parse JSON
+	return r.parsePrice(val)
+}
+
+// parsePrice 解析 Redis 中的 Ticker JSON 並執行時效檢查
+func (r *MarketDataReader) parsePrice(val string) (value_objects.Price, error) {
 	var priceData struct {
 		Last string `json:"last"`
+		Ts   string `json:"ts"` // Timestamp in milliseconds
 	}
 
 	if err := json.Unmarshal([]byte(val), &priceData); err != nil {
 		return value_objects.Price{}, fmt.Errorf("failed to parse price JSON: %w", err)
 	}
 
+	// 時效檢查：避免行情中斷後用舊價格交易 ⭐
+	if err := PriceStalenessCheck(priceData.Ts, r.now(), r.priceMaxAge); err != nil {
+		return value_objects.Price{}, err
+	}
+
 	// Convert to float64
 	currentPrice, err := strconv.ParseFloat(priceData.Last, 64)
 	if err != nil {
@@ -145,6 +216,31 @@ func (r *MarketDataReader) GetLatestPrice(ctx context.Context, instID string) (v
 	return price, nil
 }
 
+// PriceStalenessCheck 檢查 Ticker 時間戳是否在允許的時間窗口內
+// 參數：
+//   - tickerTs: Ticker 時間戳（毫秒字符串，OKX 格式）
+//   - now: 當前時間
+//   - maxAge: 最大允許延遲（<= 0 表示不檢查）
+//
+// 返回：超出窗口時返回包裝 ErrStalePrice 的錯誤
+func PriceStalenessCheck(tickerTs string, now time.Time, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	tsMs, err := strconv.ParseInt(tickerTs, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ticker timestamp %q: %w", tickerTs, err)
+	}
+
+	age := now.Sub(time.UnixMilli(tsMs))
+	if age > maxAge {
+		return fmt.Errorf("%w: ticker age %s exceeds %s", ErrStalePrice, age, maxAge)
+	}
+
+	return nil
+}
+
 func parseCandleData(candleData CandleData) (*value_objects.Candle, error) {
 
 	// Convert strings to float64
@@ -180,6 +276,9 @@ func parseCandleData(candleData CandleData) (*value_objects.Candle, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create candle: %w", err)
 	}
+	if candleData.Confirm == "0" {
+		candle = candle.WithConfirmed(false)
+	}
 
 	return &candle, nil
 
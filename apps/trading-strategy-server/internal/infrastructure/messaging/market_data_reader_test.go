@@ -0,0 +1,177 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/rediskeys"
+)
+
+// newTestReader 創建不連接 Redis 的 MarketDataReader（固定當前時間）
+func newTestReader(priceMaxAge time.Duration, now time.Time) *MarketDataReader {
+	return &MarketDataReader{
+		priceMaxAge: priceMaxAge,
+		now:         func() time.Time { return now },
+	}
+}
+
+func tickerJSON(last string, ts time.Time) string {
+	return fmt.Sprintf(`{"instId":"ETH-USDT","last":"%s","ts":"%d"}`, last, ts.UnixMilli())
+}
+
+// TestParsePrice_StaleTicker 測試 Ticker 時間戳過舊時返回 ErrStalePrice
+func TestParsePrice_StaleTicker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	reader := newTestReader(15*time.Second, now)
+
+	// 行情中斷：Ticker 停在 2 分鐘前
+	_, err := reader.parsePrice(tickerJSON("2500.5", now.Add(-2*time.Minute)))
+	if err == nil {
+		t.Fatal("Expected stale price error, got nil")
+	}
+	if !errors.Is(err, ErrStalePrice) {
+		t.Errorf("Expected ErrStalePrice, got %v", err)
+	}
+}
+
+// TestParsePrice_FreshTicker 測試時間窗口內的 Ticker 正常返回價格
+func TestParsePrice_FreshTicker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	reader := newTestReader(15*time.Second, now)
+
+	price, err := reader.parsePrice(tickerJSON("2500.5", now.Add(-3*time.Second)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if price.Value() != 2500.5 {
+		t.Errorf("Expected price 2500.5, got %.2f", price.Value())
+	}
+}
+
+// TestPriceStalenessCheck_Disabled 測試 maxAge <= 0 時不做檢查
+func TestPriceStalenessCheck_Disabled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts := fmt.Sprintf("%d", now.Add(-time.Hour).UnixMilli())
+
+	if err := PriceStalenessCheck(ts, now, 0); err != nil {
+		t.Errorf("Expected no error when check disabled, got %v", err)
+	}
+}
+
+// keyRecorder 攔截 Redis 命令並記錄讀取的 key（不連接 Redis，所有命令返回 redis.Nil）
+type keyRecorder struct {
+	keys []string
+}
+
+func (h *keyRecorder) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *keyRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.keys = append(h.keys, fmt.Sprint(cmd.Args()[1]))
+		cmd.SetErr(redis.Nil)
+		return redis.Nil
+	}
+}
+
+func (h *keyRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestMarketDataReader_NamespacedKeys 測試讀取的 key 與 market-data-service 寫入的格式一致 ⭐
+//
+// market-data-service 的 RedisStorage 測試斷言相同的 key 字面值
+func TestMarketDataReader_NamespacedKeys(t *testing.T) {
+	keys, err := rediskeys.New("staging")
+	if err != nil {
+		t.Fatalf("Failed to create keys: %v", err)
+	}
+	recorder := &keyRecorder{}
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	rdb.AddHook(recorder)
+	defer rdb.Close()
+
+	reader := NewMarketDataReader(&RedisClient{rdb: rdb}, keys, 0, logger.NewMulti())
+	ctx := context.Background()
+	reader.GetLatestPrice(ctx, "ETH-USDT")
+	reader.GetLatestCandle(ctx, "ETH-USDT", "5m")
+	reader.GetCandleHistories(ctx, "ETH-USDT", "5m")
+
+	want := []string{
+		"staging.price.latest.ETH-USDT",
+		"staging.candle.latest.5m.ETH-USDT",
+		"staging.candle.history.5m.ETH-USDT",
+	}
+	if !reflect.DeepEqual(recorder.keys, want) {
+		t.Errorf("Expected reader keys %v, got %v", want, recorder.keys)
+	}
+}
+
+// blockingRedis 攔截 Redis 命令並一直阻塞到 context 結束（模擬 Redis 卡住）
+type blockingRedis struct{}
+
+func (blockingRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (blockingRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		select {
+		case <-ctx.Done():
+			cmd.SetErr(ctx.Err())
+		case <-time.After(5 * time.Second): // 防止測試真的卡住
+			cmd.SetErr(errors.New("blocking hook released without deadline"))
+		}
+		return cmd.Err()
+	}
+}
+
+func (blockingRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestMarketDataReader_ReadTimeout 測試 Redis 卡住時讀取在超時後返回 ErrReadTimeout，而不是無限等待 ⭐
+func TestMarketDataReader_ReadTimeout(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	rdb.AddHook(blockingRedis{})
+	defer rdb.Close()
+
+	reader := NewMarketDataReader(&RedisClient{rdb: rdb}, rediskeys.Keys{}, 0, logger.NewMulti())
+	reader.SetReadTimeout(50 * time.Millisecond)
+
+	reads := map[string]func(ctx context.Context) error{
+		"GetLatestPrice": func(ctx context.Context) error {
+			_, err := reader.GetLatestPrice(ctx, "ETH-USDT")
+			return err
+		},
+		"GetLatestCandle": func(ctx context.Context) error {
+			_, err := reader.GetLatestCandle(ctx, "ETH-USDT", "5m")
+			return err
+		},
+		"GetCandleHistories": func(ctx context.Context) error {
+			_, err := reader.GetCandleHistories(ctx, "ETH-USDT", "5m")
+			return err
+		},
+	}
+	for name, read := range reads {
+		start := time.Now()
+		err := read(context.Background()) // 調用方 context 沒有截止時間
+		if !errors.Is(err, ErrReadTimeout) {
+			t.Errorf("%s: expected ErrReadTimeout, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: expected to time out after ~50ms, took %s", name, elapsed)
+		}
+	}
+
+	// 調用方自己取消時不報告為讀取超時
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := reader.GetLatestPrice(ctx, "ETH-USDT"); err == nil || errors.Is(err, ErrReadTimeout) {
+		t.Errorf("Expected caller cancellation error, got %v", err)
+	}
+}
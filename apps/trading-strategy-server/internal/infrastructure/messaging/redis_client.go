@@ -21,6 +21,8 @@ func NewRedisClient(addr, password string, db int, log logger.Logger) (*RedisCli
 		Password: password,
 		DB:       db,
 		PoolSize: 10,
+		// context 的截止時間同時作為網絡讀寫的截止時間（MarketDataReader 的讀取超時依賴此設置）⭐
+		ContextTimeoutEnabled: true,
 	})
 
 	// Ping to verify connection
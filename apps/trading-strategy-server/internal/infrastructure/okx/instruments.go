@@ -0,0 +1,224 @@
+package okx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 交易對規格錯誤
+var (
+	ErrInstrumentNotFound = errors.New("instrument not found")
+	ErrInvalidResponse    = errors.New("invalid instruments response")
+)
+
+// DefaultInstrumentsURL OKX 公共交易對規格接口（不需要簽名）
+const DefaultInstrumentsURL = "https://www.okx.com/api/v5/public/instruments"
+
+// InstrumentInfo 交易對規格 ⭐
+//
+// 數值字段來自 OKX 返回的字符串（tickSz、lotSz、minSz、ctVal），載入時解析為 float64
+type InstrumentInfo struct {
+	InstID   string  // 交易對（例: ETH-USDT-SWAP）
+	InstType string  // 產品類型（SPOT / SWAP / FUTURES ...）
+	TickSize float64 // 下單價格精度（例: 0.01）
+	LotSize  float64 // 下單數量精度（SPOT: 幣；合約: 張）
+	MinSize  float64 // 最小下單數量（單位同 LotSize）
+	CtVal    float64 // 合約面值（SWAP/FUTURES，例: ETH-USDT-SWAP 一張 = 0.1 ETH；SPOT 為 0）
+}
+
+// MinBaseSize 最小下單數量換算成幣的數量（合約 = MinSize × CtVal，現貨 = MinSize）
+func (i InstrumentInfo) MinBaseSize() float64 {
+	if i.CtVal > 0 {
+		return i.MinSize * i.CtVal
+	}
+	return i.MinSize
+}
+
+// instrumentsResponse /api/v5/public/instruments 的響應格式
+type instrumentsResponse struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstID   string `json:"instId"`
+		InstType string `json:"instType"`
+		TickSz   string `json:"tickSz"`
+		LotSz    string `json:"lotSz"`
+		MinSz    string `json:"minSz"`
+		CtVal    string `json:"ctVal"`
+	} `json:"data"`
+}
+
+// ParseInstruments 解析 /api/v5/public/instruments 的響應（或緩存的同格式 JSON），按 instId 建立索引
+func ParseInstruments(data []byte) (map[string]InstrumentInfo, error) {
+	var resp instrumentsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if resp.Code != "" && resp.Code != "0" {
+		return nil, fmt.Errorf("%w: code=%s msg=%s", ErrInvalidResponse, resp.Code, resp.Msg)
+	}
+
+	instruments := make(map[string]InstrumentInfo, len(resp.Data))
+	for _, item := range resp.Data {
+		if item.InstID == "" {
+			continue
+		}
+
+		info := InstrumentInfo{InstID: item.InstID, InstType: item.InstType}
+		fields := []struct {
+			name  string
+			value string
+			dst   *float64
+		}{
+			{"tickSz", item.TickSz, &info.TickSize},
+			{"lotSz", item.LotSz, &info.LotSize},
+			{"minSz", item.MinSz, &info.MinSize},
+			{"ctVal", item.CtVal, &info.CtVal},
+		}
+		for _, field := range fields {
+			if field.value == "" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(field.value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s=%q", ErrInvalidResponse, item.InstID, field.name, field.value)
+			}
+			*field.dst = parsed
+		}
+
+		instruments[item.InstID] = info
+	}
+	return instruments, nil
+}
+
+// InstrumentLoader 交易對規格載入器（首次查詢時載入並緩存）⭐
+//
+// 數據來源：
+//   - http(s) URL: 請求 OKX 公共接口（自動附加 instType 查詢參數）
+//   - 其他: 視為本地 JSON 文件路徑（與接口響應格式相同，用於離線或測試）
+//
+// 規格只在啟動時讀取一次（網格和驗證器創建後不再更新），因此不做過期重載；
+// 載入失敗時不緩存，下一次查詢再重試
+type InstrumentLoader struct {
+	mu          sync.Mutex
+	source      string
+	instType    string
+	client      *http.Client
+	instruments map[string]InstrumentInfo
+}
+
+// NewInstrumentLoader 創建交易對規格載入器
+//
+// 參數：
+//   - source: OKX 接口 URL 或本地 JSON 文件路徑（空 = DefaultInstrumentsURL）
+//   - instType: 產品類型（例: SWAP，本地文件時忽略）
+func NewInstrumentLoader(source, instType string) *InstrumentLoader {
+	if source == "" {
+		source = DefaultInstrumentsURL
+	}
+	return &InstrumentLoader{
+		source:   source,
+		instType: instType,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Instrument 查詢交易對規格（首次查詢時載入）
+func (l *InstrumentLoader) Instrument(ctx context.Context, instID string) (InstrumentInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.instruments == nil {
+		instruments, err := l.load(ctx)
+		if err != nil {
+			return InstrumentInfo{}, err
+		}
+		l.instruments = instruments
+	}
+
+	info, ok := l.instruments[instID]
+	if !ok {
+		return InstrumentInfo{}, fmt.Errorf("%w: %s", ErrInstrumentNotFound, instID)
+	}
+	return info, nil
+}
+
+// TickSize 查詢價格精度
+func (l *InstrumentLoader) TickSize(ctx context.Context, instID string) (float64, error) {
+	info, err := l.Instrument(ctx, instID)
+	if err != nil {
+		return 0, err
+	}
+	return info.TickSize, nil
+}
+
+// LotSize 查詢數量精度
+func (l *InstrumentLoader) LotSize(ctx context.Context, instID string) (float64, error) {
+	info, err := l.Instrument(ctx, instID)
+	if err != nil {
+		return 0, err
+	}
+	return info.LotSize, nil
+}
+
+// MinSize 查詢最小下單數量
+func (l *InstrumentLoader) MinSize(ctx context.Context, instID string) (float64, error) {
+	info, err := l.Instrument(ctx, instID)
+	if err != nil {
+		return 0, err
+	}
+	return info.MinSize, nil
+}
+
+// load 從數據來源讀取並解析（調用方持有鎖）
+func (l *InstrumentLoader) load(ctx context.Context) (map[string]InstrumentInfo, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(l.source, "http://") || strings.HasPrefix(l.source, "https://") {
+		data, err = l.fetch(ctx)
+	} else {
+		data, err = os.ReadFile(l.source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instruments from %s: %w", l.source, err)
+	}
+	return ParseInstruments(data)
+}
+
+// fetch 請求 OKX 公共接口
+func (l *InstrumentLoader) fetch(ctx context.Context) ([]byte, error) {
+	url := l.source
+	if l.instType != "" {
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		url += separator + "instType=" + l.instType
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
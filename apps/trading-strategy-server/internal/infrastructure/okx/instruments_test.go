@@ -0,0 +1,108 @@
+package okx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sampleInstrumentsResponse /api/v5/public/instruments?instType=SWAP 的響應樣本（字段已精簡）
+const sampleInstrumentsResponse = `{
+  "code": "0",
+  "msg": "",
+  "data": [
+    {"instType": "SWAP", "instId": "ETH-USDT-SWAP", "tickSz": "0.01", "lotSz": "0.01", "minSz": "0.01", "ctVal": "0.1", "state": "live"},
+    {"instType": "SWAP", "instId": "BTC-USDT-SWAP", "tickSz": "0.1", "lotSz": "0.01", "minSz": "0.01", "ctVal": "0.01", "state": "live"},
+    {"instType": "SWAP", "instId": "DOGE-USDT-SWAP", "tickSz": "0.00001", "lotSz": "1", "minSz": "1", "ctVal": "1000", "state": "live"}
+  ]
+}`
+
+func TestParseInstruments(t *testing.T) {
+	instruments, err := ParseInstruments([]byte(sampleInstrumentsResponse))
+	if err != nil {
+		t.Fatalf("Failed to parse instruments: %v", err)
+	}
+	if len(instruments) != 3 {
+		t.Fatalf("Expected 3 instruments, got %d", len(instruments))
+	}
+
+	btc := instruments["BTC-USDT-SWAP"]
+	if btc.TickSize != 0.1 || btc.LotSize != 0.01 || btc.MinSize != 0.01 || btc.CtVal != 0.01 {
+		t.Errorf("Unexpected BTC-USDT-SWAP info: %+v", btc)
+	}
+	if got := instruments["ETH-USDT-SWAP"].MinBaseSize(); got != 0.001 {
+		t.Errorf("Expected ETH min base size 0.001, got %v", got)
+	}
+
+	if _, err := ParseInstruments([]byte(`{"code":"51001","msg":"Instrument ID does not exist","data":[]}`)); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse for error code, got %v", err)
+	}
+	if _, err := ParseInstruments([]byte(`{"code":"0","data":[{"instId":"X","tickSz":"abc"}]}`)); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse for bad number, got %v", err)
+	}
+}
+
+// TestInstrumentLoader_HTTPCached 測試從接口載入一次，之後的查詢使用緩存 ⭐
+func TestInstrumentLoader_HTTPCached(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.URL.Query().Get("instType"); got != "SWAP" {
+			t.Errorf("Expected instType=SWAP, got %q", got)
+		}
+		w.Write([]byte(sampleInstrumentsResponse))
+	}))
+	defer server.Close()
+
+	loader := NewInstrumentLoader(server.URL, "SWAP")
+	ctx := context.Background()
+
+	tickSize, err := loader.TickSize(ctx, "ETH-USDT-SWAP")
+	if err != nil {
+		t.Fatalf("TickSize failed: %v", err)
+	}
+	if tickSize != 0.01 {
+		t.Errorf("Expected tick size 0.01, got %v", tickSize)
+	}
+	if minSize, _ := loader.MinSize(ctx, "DOGE-USDT-SWAP"); minSize != 1 {
+		t.Errorf("Expected DOGE min size 1, got %v", minSize)
+	}
+	if lotSize, _ := loader.LotSize(ctx, "BTC-USDT-SWAP"); lotSize != 0.01 {
+		t.Errorf("Expected BTC lot size 0.01, got %v", lotSize)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request, got %d", requests)
+	}
+
+	if _, err := loader.TickSize(ctx, "SOL-USDT-SWAP"); !errors.Is(err, ErrInstrumentNotFound) {
+		t.Errorf("Expected ErrInstrumentNotFound, got %v", err)
+	}
+}
+
+// TestInstrumentLoader_FileSource 測試從緩存的 JSON 文件載入，載入後不再讀取文件
+func TestInstrumentLoader_FileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instruments.json")
+	if err := os.WriteFile(path, []byte(sampleInstrumentsResponse), 0o644); err != nil {
+		t.Fatalf("Failed to write sample: %v", err)
+	}
+
+	loader := NewInstrumentLoader(path, "")
+	ctx := context.Background()
+
+	if tickSize, err := loader.TickSize(ctx, "BTC-USDT-SWAP"); err != nil || tickSize != 0.1 {
+		t.Fatalf("Expected tick size 0.1, got %v (err %v)", tickSize, err)
+	}
+
+	os.Remove(path)
+	if tickSize, err := loader.TickSize(ctx, "BTC-USDT-SWAP"); err != nil || tickSize != 0.1 {
+		t.Errorf("Expected cached data after the file is removed, got %v (err %v)", tickSize, err)
+	}
+
+	if _, err := NewInstrumentLoader(path, "").TickSize(ctx, "BTC-USDT-SWAP"); err == nil {
+		t.Error("Expected error when the source is missing and nothing is cached")
+	}
+}
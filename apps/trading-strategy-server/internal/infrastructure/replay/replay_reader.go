@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// 回放錯誤
+var (
+	ErrReplayNotStarted = errors.New("replay not started: call GetLatestCandle first")
+	ErrReplayExhausted  = errors.New("replay exhausted: no more candles")
+)
+
+// defaultHistoryLength 默認歷史長度（與回測引擎一致：最多 100 根）
+const defaultHistoryLength = 100
+
+// ReplayMarketDataReader 回放市場數據讀取器（實現 application.MarketDataReader）⭐
+//
+// 用途：在沒有 Redis 的情況下，用預先載入的 K 線確定性地驅動實盤路徑
+// （StrategyService.GetOpenAdvice），便於集成測試。
+//
+// 回放語義（與 Redis 中的數據一致，也與回測引擎的每一步一致）：
+//   - GetLatestCandle: 前進一根，返回當前K線（相當於 candle.latest.*）
+//   - GetLatestPrice: 當前K線的收盤價（相當於 price.latest.*）
+//   - GetCandleHistories: 當前K線之前的已確認K線，新→舊排序（相當於 LPUSH 的 candle.history.*）
+type ReplayMarketDataReader struct {
+	mu            sync.Mutex
+	candles       []value_objects.Candle
+	historyLength int
+	cursor        int // 當前K線索引（-1 = 尚未開始）
+}
+
+// NewReplayMarketDataReader 創建回放讀取器
+//
+// 參數：
+//   - candles: K線數據（舊→新）
+//   - historyLength: GetCandleHistories 返回的最大K線數（<= 0 使用默認 100）
+func NewReplayMarketDataReader(candles []value_objects.Candle, historyLength int) *ReplayMarketDataReader {
+	if historyLength <= 0 {
+		historyLength = defaultHistoryLength
+	}
+	return &ReplayMarketDataReader{
+		candles:       candles,
+		historyLength: historyLength,
+		cursor:        -1,
+	}
+}
+
+// GetLatestCandle 前進一根K線並返回（每次調用推進回放）
+func (r *ReplayMarketDataReader) GetLatestCandle(ctx context.Context, instID string, bar string) (value_objects.Candle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cursor+1 >= len(r.candles) {
+		return value_objects.Candle{}, ErrReplayExhausted
+	}
+	r.cursor++
+	return r.candles[r.cursor], nil
+}
+
+// GetCandleHistories 返回當前K線之前的歷史K線（新→舊，與 Redis 一致）
+func (r *ReplayMarketDataReader) GetCandleHistories(ctx context.Context, instID string, bar string) ([]value_objects.Candle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cursor < 0 {
+		return nil, ErrReplayNotStarted
+	}
+
+	start := r.cursor - r.historyLength
+	if start < 0 {
+		start = 0
+	}
+
+	histories := make([]value_objects.Candle, 0, r.cursor-start)
+	for i := r.cursor - 1; i >= start; i-- {
+		histories = append(histories, r.candles[i])
+	}
+	return histories, nil
+}
+
+// GetLatestPrice 返回當前K線的收盤價
+func (r *ReplayMarketDataReader) GetLatestPrice(ctx context.Context, instID string) (value_objects.Price, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cursor < 0 {
+		return value_objects.Price{}, ErrReplayNotStarted
+	}
+	return r.candles[r.cursor].Close(), nil
+}
+
+// Cursor 返回當前K線索引（-1 = 尚未開始）
+func (r *ReplayMarketDataReader) Cursor() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cursor
+}
+
+// Remaining 返回剩餘可回放的K線數量
+func (r *ReplayMarketDataReader) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.candles) - r.cursor - 1
+}
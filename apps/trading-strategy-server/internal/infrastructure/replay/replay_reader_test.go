@@ -0,0 +1,170 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/logger"
+	"dizzycode.xyz/shared/domain/value_objects"
+	"dizzycode.xyz/trading-strategy-server/backtesting/engine"
+	"dizzycode.xyz/trading-strategy-server/internal/application"
+	"dizzycode.xyz/trading-strategy-server/internal/domain/strategy/strategies/grid"
+)
+
+// buildReplayCandles 構建已知序列：緩漲 → 急跌 → 反彈
+func buildReplayCandles(t *testing.T) []value_objects.Candle {
+	t.Helper()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]value_objects.Candle, 0, 150)
+	price := 2500.0
+
+	for i := 0; i < 150; i++ {
+		var change float64
+		switch {
+		case i < 70:
+			change = 0.0005 // 緩漲
+		case i < 85:
+			change = -0.006 // 急跌（觸發趨勢過濾）
+		default:
+			change = 0.002 // 反彈
+		}
+
+		open := price
+		closePrice := price * (1 + change)
+		high := max(open, closePrice) * 1.001
+		low := min(open, closePrice) * 0.999
+
+		candle, err := value_objects.NewCandle(open, high, low, closePrice, start.Add(time.Duration(i)*5*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create candle %d: %v", i, err)
+		}
+		candles = append(candles, candle)
+		price = closePrice
+	}
+	return candles
+}
+
+// TestReplayMarketDataReader_Sequence 測試回放的前進與歷史順序（新→舊，與 Redis 一致）
+func TestReplayMarketDataReader_Sequence(t *testing.T) {
+	candles := buildReplayCandles(t)[:5]
+	reader := NewReplayMarketDataReader(candles, 3)
+	ctx := context.Background()
+
+	if _, err := reader.GetLatestPrice(ctx, "ETH-USDT"); !errors.Is(err, ErrReplayNotStarted) {
+		t.Fatalf("Expected ErrReplayNotStarted, got %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		candle, err := reader.GetLatestCandle(ctx, "ETH-USDT", "5m")
+		if err != nil {
+			t.Fatalf("Step %d: GetLatestCandle failed: %v", i, err)
+		}
+		if !candle.Timestamp().Equal(candles[i].Timestamp()) {
+			t.Errorf("Step %d: expected candle %v, got %v", i, candles[i].Timestamp(), candle.Timestamp())
+		}
+
+		price, _ := reader.GetLatestPrice(ctx, "ETH-USDT")
+		if price.Value() != candles[i].Close().Value() {
+			t.Errorf("Step %d: expected price %.4f, got %.4f", i, candles[i].Close().Value(), price.Value())
+		}
+
+		histories, _ := reader.GetCandleHistories(ctx, "ETH-USDT", "5m")
+		wantLen := min(i, 3)
+		if len(histories) != wantLen {
+			t.Fatalf("Step %d: expected %d histories, got %d", i, wantLen, len(histories))
+		}
+		for j, h := range histories {
+			if !h.Timestamp().Equal(candles[i-1-j].Timestamp()) {
+				t.Errorf("Step %d: history %d out of order", i, j)
+			}
+		}
+	}
+
+	if reader.Remaining() != 0 {
+		t.Errorf("Expected 0 remaining, got %d", reader.Remaining())
+	}
+	if _, err := reader.GetLatestCandle(ctx, "ETH-USDT", "5m"); !errors.Is(err, ErrReplayExhausted) {
+		t.Errorf("Expected ErrReplayExhausted, got %v", err)
+	}
+}
+
+// TestReplay_LiveAdviceMatchesBacktest 測試實盤路徑（StrategyService）與回測引擎的開倉決策一致 ⭐
+func TestReplay_LiveAdviceMatchesBacktest(t *testing.T) {
+	candles := buildReplayCandles(t)
+
+	// 1. 回測引擎：記錄每次開倉的K線索引
+	backtestEngine, err := engine.NewBacktestEngine(engine.BacktestConfig{
+		InitialBalance:        1e9, // 資金充足，避免餘額不足影響開倉
+		FeeRate:               0.0005,
+		InstID:                "ETH-USDT-SWAP",
+		TakeProfitMin:         0.0015,
+		TakeProfitMax:         0.0020,
+		PositionSize:          200,
+		EnableTrendFilter:     true,
+		EnableRedCandleFilter: false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	sink := &engine.SliceEventSink{}
+	backtestEngine.SetEventSink(sink)
+	if _, err := backtestEngine.Run(candles); err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	var backtestOpens []int
+	for _, event := range sink.Events {
+		if event.Type == engine.EventPositionOpened {
+			backtestOpens = append(backtestOpens, event.CandleIndex)
+		}
+	}
+
+	// 2. 實盤路徑：同樣的策略參數，通過回放讀取器逐根諮詢
+	gridAggregate, err := grid.NewGridAggregate(grid.GridConfig{
+		InstID:            "ETH-USDT-SWAP",
+		PositionSize:      200,
+		FeeRate:           0.0005,
+		TakeProfitRateMin: 0.0015,
+		TakeProfitRateMax: 0.0020,
+		EnableTrendFilter: true,
+		TrendFilterConfig: grid.TrendAnalyzerConfig{
+			EMAThreshold:    0.003,
+			CandleThreshold: 0.004,
+			EMAShortPeriod:  20,
+			EMALongPeriod:   50,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid aggregate: %v", err)
+	}
+
+	reader := NewReplayMarketDataReader(candles, 100)
+	service := application.NewStrategyService(gridAggregate, reader, "5m", logger.NewMulti())
+
+	var liveOpens []int
+	for i := range candles {
+		advice, err := service.GetOpenAdvice(context.Background(), "ETH-USDT-SWAP")
+		if err != nil {
+			t.Fatalf("Step %d: GetOpenAdvice failed: %v", i, err)
+		}
+		if advice.ShouldOpen {
+			liveOpens = append(liveOpens, i)
+		}
+	}
+
+	// 3. 序列必須包含開倉 → 禁止 → 開倉的轉換，否則測試沒有意義
+	if len(liveOpens) == 0 || len(liveOpens) == len(candles) {
+		t.Fatalf("Expected both open and blocked advice, got %d opens of %d", len(liveOpens), len(candles))
+	}
+	if liveOpens[len(liveOpens)-1]-liveOpens[0]+1 == len(liveOpens) {
+		t.Errorf("Expected a blocked gap between opens, got contiguous opens %d..%d", liveOpens[0], liveOpens[len(liveOpens)-1])
+	}
+
+	if !reflect.DeepEqual(liveOpens, backtestOpens) {
+		t.Errorf("Live advice diverged from backtest:\n live:     %v\n backtest: %v", liveOpens, backtestOpens)
+	}
+}
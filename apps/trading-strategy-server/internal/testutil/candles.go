@@ -0,0 +1,191 @@
+// Package testutil 測試用的合成K線生成器 ⭐
+//
+// 所有生成器都是確定性的：時間從 StartTime 開始、每根間隔 Interval，
+// 隨機遊走由 seed 決定，同一組參數總是生成相同的K線，測試失敗可以直接重放
+package testutil
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// StartTime 生成K線的起始時間
+var StartTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Interval 生成K線的時間間隔（5m）
+const Interval = 5 * time.Minute
+
+// wickRate 影線長度：High / Low 在實體上下各延伸 0.1%
+const wickRate = 0.001
+
+// candleAt 按開收盤價生成第 i 根K線（High / Low 在實體外延伸 wickRate）
+func candleAt(i int, open, closePrice float64) value_objects.Candle {
+	high := math.Max(open, closePrice) * (1 + wickRate)
+	low := math.Min(open, closePrice) * (1 - wickRate)
+	candle, _ := value_objects.NewCandle(open, high, low, closePrice, StartTime.Add(time.Duration(i)*Interval))
+	return candle
+}
+
+// GenerateRanging 生成震盪行情：每 10 根為一個週期，單根漲跌幅在 ±volatility 之間
+func GenerateRanging(count int, startPrice, volatility float64) []value_objects.Candle {
+	candles := make([]value_objects.Candle, count)
+	price := startPrice
+	for i := range candles {
+		change := volatility * float64(i%10-5) / 5.0
+		closePrice := price * (1 + change)
+		candles[i] = candleAt(i, price, closePrice)
+		price = closePrice
+	}
+	return candles
+}
+
+// GenerateTrending 生成單邊趨勢行情：每根K線漲跌 trendRate（負數 = 下跌）
+func GenerateTrending(count int, startPrice, trendRate float64) []value_objects.Candle {
+	candles := make([]value_objects.Candle, count)
+	price := startPrice
+	for i := range candles {
+		closePrice := price * (1 + trendRate)
+		candles[i] = candleAt(i, price, closePrice)
+		price = closePrice
+	}
+	return candles
+}
+
+// GenerateSharpMove 生成前 count-1 根低波動震盪（0.1%）、最後一根漲跌 lastMove 的行情
+func GenerateSharpMove(count int, startPrice, lastMove float64) []value_objects.Candle {
+	if count <= 0 {
+		return []value_objects.Candle{}
+	}
+	candles := GenerateRanging(count-1, startPrice, 0.001)
+
+	open := startPrice
+	if len(candles) > 0 {
+		open = candles[len(candles)-1].Close().Value()
+	}
+	return append(candles, candleAt(count-1, open, open*(1+lastMove)))
+}
+
+// GenerateWithGap 生成震盪行情（波動 0.1%），在第 gapAt 根之後缺失 missing 根K線 ⭐
+//
+// 返回 count 根K線；第 gapAt 根與前一根的時間間隔為 (missing + 1) × Interval，
+// 價格連續（模擬數據源斷線而非跳空）
+func GenerateWithGap(count int, startPrice float64, gapAt, missing int) []value_objects.Candle {
+	candles := GenerateRanging(count, startPrice, 0.001)
+	for i := gapAt; i >= 0 && i < len(candles); i++ {
+		c := candles[i]
+		candles[i], _ = value_objects.NewCandle(c.Open().Value(), c.High().Value(), c.Low().Value(), c.Close().Value(),
+			c.Timestamp().Add(time.Duration(missing)*Interval))
+	}
+	return candles
+}
+
+// GenerateRandomWalk 生成可重放的隨機遊走行情 ⭐
+//
+// 每根K線的收盤價相對開盤價漲跌 N(0, volatility)，影線額外延伸 [0, volatility/2)；
+// 相同 seed 總是生成相同的序列。價格不會低於 startPrice 的 1%
+func GenerateRandomWalk(seed int64, count int, startPrice, volatility float64) []value_objects.Candle {
+	rng := rand.New(rand.NewSource(seed))
+	floor := startPrice * 0.01
+
+	candles := make([]value_objects.Candle, count)
+	price := startPrice
+	for i := range candles {
+		closePrice := math.Max(price*(1+rng.NormFloat64()*volatility), floor)
+		high := math.Max(price, closePrice) * (1 + rng.Float64()*volatility/2)
+		low := math.Min(price, closePrice) * (1 - rng.Float64()*volatility/2)
+		candles[i], _ = value_objects.NewCandle(price, high, low, closePrice, StartTime.Add(time.Duration(i)*Interval))
+		price = closePrice
+	}
+	return candles
+}
+
+// GenerateFlat 生成橫盤K線：開收盤價固定在 2500，上下影線 ±3
+func GenerateFlat(count int) []value_objects.Candle {
+	return GenerateLevels(3, Level{Count: count, Price: 2500})
+}
+
+// GenerateTightRange 生成窄幅橫盤K線：開收盤價固定在 2500，上下影線 ±0.5（小於單筆止盈距離）
+func GenerateTightRange(count int) []value_objects.Candle {
+	return GenerateLevels(0.5, Level{Count: count, Price: 2500})
+}
+
+// Level 分段水平行情中的一段：連續 Count 根開收盤價都為 Price 的K線
+type Level struct {
+	Count int
+	Price float64
+}
+
+// GenerateLevels 按順序拼接多段水平行情（段與段之間直接跳價）⭐
+//
+// High / Low 在價格上下延伸 wick
+func GenerateLevels(wick float64, levels ...Level) []value_objects.Candle {
+	var candles []value_objects.Candle
+	for _, level := range levels {
+		for range level.Count {
+			candles = append(candles, candleWithWick(len(candles), level.Price, level.Price, wick))
+		}
+	}
+	return candles
+}
+
+// GenerateSine 生成圍繞 2500 的橫盤正弦震盪（振幅 40，反覆觸發打平退出）
+func GenerateSine(count int) []value_objects.Candle {
+	candles := make([]value_objects.Candle, count)
+	for i := range candles {
+		open := 2500 + 40*math.Sin(float64(i)/10)
+		closePrice := open + 3*math.Cos(float64(i)/2)
+		candles[i] = candleWithWick(i, open, closePrice, 4)
+	}
+	return candles
+}
+
+// GenerateWave 生成震盪下行行情：每根下移 1.5 並疊加振幅 20 的正弦（觸發開倉、止盈、打平和自動注資）
+func GenerateWave(count int) []value_objects.Candle {
+	candles := make([]value_objects.Candle, count)
+	for i := range candles {
+		open := 2500 - float64(i)*1.5 + 20*math.Sin(float64(i)/6)
+		closePrice := open + 3*math.Cos(float64(i)/2)
+		candles[i] = candleWithWick(i, open, closePrice, 4)
+	}
+	return candles
+}
+
+// GenerateDipRecovery 生成先下跌後反彈的行情：前 falling 根每根跌 3，之後 rising 根每根漲 8
+//
+// 從 2500 開始，用於耗盡資金（觸發注資）後讓所有倉位止盈
+func GenerateDipRecovery(falling, rising int) []value_objects.Candle {
+	return GenerateLegs(2500, 1, Leg{Count: falling, Step: -3}, Leg{Count: rising, Step: 8})
+}
+
+// Leg 分段行情中的一段：連續 Count 根K線，每根收盤價相對開盤價變動 Step（負數 = 下跌）
+type Leg struct {
+	Count int
+	Step  float64
+}
+
+// GenerateLegs 按順序拼接多段行情 ⭐
+//
+// 從 startPrice 開始，每根K線開在上一根收盤價（價格連續），High / Low 在實體外延伸 wick
+func GenerateLegs(startPrice, wick float64, legs ...Leg) []value_objects.Candle {
+	var candles []value_objects.Candle
+	price := startPrice
+	for _, leg := range legs {
+		for range leg.Count {
+			closePrice := price + leg.Step
+			candles = append(candles, candleWithWick(len(candles), price, closePrice, wick))
+			price = closePrice
+		}
+	}
+	return candles
+}
+
+// candleWithWick 按開收盤價生成第 i 根K線（High / Low 在實體外延伸固定的 wick）
+func candleWithWick(i int, open, closePrice, wick float64) value_objects.Candle {
+	high := math.Max(open, closePrice) + wick
+	low := math.Min(open, closePrice) - wick
+	candle, _ := value_objects.NewCandle(open, high, low, closePrice, StartTime.Add(time.Duration(i)*Interval))
+	return candle
+}
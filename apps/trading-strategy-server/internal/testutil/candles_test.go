@@ -0,0 +1,155 @@
+package testutil
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"dizzycode.xyz/shared/domain/value_objects"
+)
+
+// TestGenerateRandomWalk_Reproducible 測試相同 seed 生成相同序列，不同 seed 生成不同序列 ⭐
+func TestGenerateRandomWalk_Reproducible(t *testing.T) {
+	first := GenerateRandomWalk(42, 500, 2500, 0.003)
+	second := GenerateRandomWalk(42, 500, 2500, 0.003)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("Expected identical candles for the same seed")
+	}
+
+	other := GenerateRandomWalk(43, 500, 2500, 0.003)
+	if reflect.DeepEqual(first, other) {
+		t.Error("Expected different candles for a different seed")
+	}
+
+	for i, candle := range first {
+		if candle.Low().Value() > math.Min(candle.Open().Value(), candle.Close().Value()) ||
+			candle.High().Value() < math.Max(candle.Open().Value(), candle.Close().Value()) {
+			t.Fatalf("Candle %d has an invalid range: %+v", i, candle)
+		}
+		if i > 0 && candle.Open().Value() != first[i-1].Close().Value() {
+			t.Fatalf("Candle %d does not open at the previous close", i)
+		}
+	}
+}
+
+func TestGenerateTrending(t *testing.T) {
+	candles := GenerateTrending(10, 100, 0.01)
+	if len(candles) != 10 {
+		t.Fatalf("Expected 10 candles, got %d", len(candles))
+	}
+	want := 100 * math.Pow(1.01, 10)
+	if got := candles[9].Close().Value(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected last close %.6f, got %.6f", want, got)
+	}
+	if !candles[9].Timestamp().Equal(StartTime.Add(9 * Interval)) {
+		t.Errorf("Unexpected timestamp %v", candles[9].Timestamp())
+	}
+}
+
+func TestGenerateRanging(t *testing.T) {
+	candles := GenerateRanging(60, 2500, 0.001)
+	for i, candle := range candles {
+		change := candle.Close().Value()/candle.Open().Value() - 1
+		if math.Abs(change) > 0.001+1e-12 {
+			t.Fatalf("Candle %d moved %.5f, expected within ±0.1%%", i, change)
+		}
+	}
+}
+
+func TestGenerateSharpMove(t *testing.T) {
+	candles := GenerateSharpMove(60, 2500, -0.008)
+	if len(candles) != 60 {
+		t.Fatalf("Expected 60 candles, got %d", len(candles))
+	}
+	last := candles[59]
+	if change := last.Close().Value()/last.Open().Value() - 1; math.Abs(change+0.008) > 1e-12 {
+		t.Errorf("Expected last candle to move -0.8%%, got %.5f", change)
+	}
+	if last.Open().Value() != candles[58].Close().Value() {
+		t.Error("Expected the sharp move to open at the previous close")
+	}
+}
+
+func TestGenerateWithGap(t *testing.T) {
+	candles := GenerateWithGap(20, 2500, 10, 3)
+	if len(candles) != 20 {
+		t.Fatalf("Expected 20 candles, got %d", len(candles))
+	}
+	for i := 1; i < len(candles); i++ {
+		want := Interval
+		if i == 10 {
+			want = 4 * Interval
+		}
+		if got := candles[i].Timestamp().Sub(candles[i-1].Timestamp()); got != want {
+			t.Errorf("Interval before candle %d = %v, want %v", i, got, want)
+		}
+	}
+	if candles[10].Open().Value() != candles[9].Close().Value() {
+		t.Error("Expected prices to stay continuous across the gap")
+	}
+}
+
+func TestGenerateDipRecovery(t *testing.T) {
+	candles := GenerateDipRecovery(3, 2)
+	if len(candles) != 5 {
+		t.Fatalf("Expected 5 candles, got %d", len(candles))
+	}
+	want := []float64{2497, 2494, 2491, 2499, 2507}
+	for i, candle := range candles {
+		if got := candle.Close().Value(); got != want[i] {
+			t.Errorf("Candle %d close = %.2f, want %.2f", i, got, want[i])
+		}
+		if i > 0 && candle.Open().Value() != candles[i-1].Close().Value() {
+			t.Errorf("Candle %d does not open at the previous close", i)
+		}
+	}
+}
+
+func TestGenerateSineAndWave_WickAroundBody(t *testing.T) {
+	for name, candles := range map[string][]value_objects.Candle{
+		"sine": GenerateSine(50),
+		"wave": GenerateWave(50),
+	} {
+		for i, candle := range candles {
+			bodyHigh := math.Max(candle.Open().Value(), candle.Close().Value())
+			bodyLow := math.Min(candle.Open().Value(), candle.Close().Value())
+			if math.Abs(candle.High().Value()-bodyHigh-4) > 1e-9 || math.Abs(bodyLow-candle.Low().Value()-4) > 1e-9 {
+				t.Fatalf("%s candle %d: expected a 4 wick on both sides, got %+v", name, i, candle)
+			}
+		}
+	}
+}
+
+func TestGenerateLegs(t *testing.T) {
+	candles := GenerateLegs(100, 0.5, Leg{Count: 2, Step: -3}, Leg{Count: 1, Step: 2})
+	want := []float64{97, 94, 96}
+	if len(candles) != len(want) {
+		t.Fatalf("Expected %d candles, got %d", len(want), len(candles))
+	}
+	for i, candle := range candles {
+		if got := candle.Close().Value(); got != want[i] {
+			t.Errorf("Candle %d close = %.2f, want %.2f", i, got, want[i])
+		}
+		if !candle.Timestamp().Equal(StartTime.Add(time.Duration(i) * Interval)) {
+			t.Errorf("Candle %d has unexpected timestamp %v", i, candle.Timestamp())
+		}
+	}
+	if got := candles[2].Low().Value(); got != 93.5 {
+		t.Errorf("Expected the rising candle's low at 93.5, got %.2f", got)
+	}
+}
+
+func TestGenerateLevels(t *testing.T) {
+	candles := GenerateLevels(1, Level{Count: 2, Price: 2500}, Level{Count: 1, Price: 2530})
+	if len(candles) != 3 {
+		t.Fatalf("Expected 3 candles, got %d", len(candles))
+	}
+	last := candles[2]
+	if last.Open().Value() != 2530 || last.Close().Value() != 2530 || last.High().Value() != 2531 || last.Low().Value() != 2529 {
+		t.Errorf("Unexpected level candle %+v", last)
+	}
+	if candles[1].Close().Value() != 2500 {
+		t.Errorf("Expected the first level to hold at 2500, got %.2f", candles[1].Close().Value())
+	}
+}
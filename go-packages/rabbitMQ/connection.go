@@ -16,17 +16,20 @@ type Connection struct {
 	conn         *amqp.Connection
 	channel      *amqp.Channel
 	consumerTags map[string]string
-	mu           sync.RWMutex
-	closed       bool
+	// declaredQueues caches queues already declared on the current channel
+	declaredQueues map[string]bool
+	mu             sync.RWMutex
+	closed         bool
 }
 
 // NewConnection creates a new RabbitMQ connection instance
 func NewConnection(config Config, logger Logger) *Connection {
 	return &Connection{
-		config:       config,
-		logger:       logger,
-		consumerTags: make(map[string]string),
-		closed:       false,
+		config:         config,
+		logger:         logger,
+		consumerTags:   make(map[string]string),
+		declaredQueues: make(map[string]bool),
+		closed:         false,
 	}
 }
 
@@ -74,6 +77,7 @@ func (c *Connection) Connect() error {
 
 	c.conn = conn
 	c.channel = channel
+	c.declaredQueues = make(map[string]bool) // New channel: queues must be declared again
 
 	c.setupConnectionHandlers()
 
@@ -150,6 +154,20 @@ func (c *Connection) RemoveConsumerTag(queue string) {
 	delete(c.consumerTags, queue)
 }
 
+// isQueueDeclared reports whether the queue was already declared on the current channel
+func (c *Connection) isQueueDeclared(queue string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.declaredQueues[queue]
+}
+
+// markQueueDeclared records a successful queue declaration
+func (c *Connection) markQueueDeclared(queue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.declaredQueues[queue] = true
+}
+
 // IsConnected checks if the connection and channel are active
 func (c *Connection) IsConnected() bool {
 	c.mu.RLock()
@@ -189,6 +207,7 @@ func (c *Connection) Close() error {
 	}
 
 	c.consumerTags = make(map[string]string)
+	c.declaredQueues = make(map[string]bool)
 	c.closed = true
 
 	c.logger.Info("RabbitMQ connection closed", nil)
@@ -2,12 +2,20 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// publishChannel is the subset of *amqp.Channel used for publishing
+type publishChannel interface {
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
 // PublishToQueue publishes a message to a queue
 func PublishToQueue(
 	conn *Connection,
@@ -20,88 +28,50 @@ func PublishToQueue(
 		return err
 	}
 
-	logger := conn.GetLogger()
-
-	// Use default options if not provided
-	if options == nil {
-		defaultOpts := DefaultPublishOptions()
-		options = &defaultOpts
+	message, err := marshalPayload(conn, queue, payload)
+	if err != nil {
+		return err
 	}
 
-	// Use default queue options if not provided
-	if options.QueueOptions == nil {
-		defaultQueueOpts := DefaultQueueOptions()
-		options.QueueOptions = &defaultQueueOpts
-	}
+	return publish(conn, channel, queue, message, "application/json", options)
+}
 
-	// Assert queue
-	_, err = channel.QueueDeclare(
-		queue,
-		options.QueueOptions.Durable,
-		options.QueueOptions.AutoDelete,
-		options.QueueOptions.Exclusive,
-		options.QueueOptions.NoWait,
-		options.QueueOptions.Args,
-	)
+// PublishIdempotent publishes a JSON message whose MessageID is derived from the payload hash
+//
+// Publishing the same payload twice (e.g. a retried publish) produces the same
+// MessageID, so consumers can drop duplicates. An explicit options.MessageID is
+// overwritten; use PublishToQueue to control the ID yourself.
+func PublishIdempotent(
+	conn *Connection,
+	queue string,
+	payload interface{},
+	options *PublishOptions,
+) error {
+	channel, err := conn.GetChannel()
 	if err != nil {
-		logger.Error("Failed to declare queue", map[string]interface{}{
-			"error": err.Error(),
-			"queue": queue,
-		})
-		return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+		return err
 	}
 
-	// Marshal payload to JSON
-	message, err := json.Marshal(payload)
+	message, err := marshalPayload(conn, queue, payload)
 	if err != nil {
-		logger.Error("Failed to marshal payload", map[string]interface{}{
-			"error": err.Error(),
-			"queue": queue,
-		})
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	// Prepare publishing options
-	publishing := amqp.Publishing{
-		ContentType:  "application/json",
-		Body:         message,
-		DeliveryMode: amqp.Transient,
-		Priority:     options.Priority,
-		Headers:      options.Headers,
-	}
-
-	if options.Persistent {
-		publishing.DeliveryMode = amqp.Persistent
-	}
-
-	if options.Expiration != "" {
-		publishing.Expiration = options.Expiration
+		return err
 	}
 
-	// Publish message
-	err = channel.PublishWithContext(
-		context.Background(),
-		"",    // exchange
-		queue, // routing key
-		false, // mandatory
-		false, // immediate
-		publishing,
-	)
-
-	if err != nil {
-		logger.Error("Failed to publish message to queue", map[string]interface{}{
-			"error": err.Error(),
-			"queue": queue,
-		})
-		return fmt.Errorf("failed to publish message to queue %s: %w", queue, err)
-	}
+	opts := idempotentOptions(message, options)
+	return publish(conn, channel, queue, message, "application/json", &opts)
+}
 
-	logger.Debug("Message published to queue", map[string]interface{}{
-		"queue":       queue,
-		"payloadSize": len(message),
-	})
+// idempotentOptions returns the options with MessageID set from the message hash
+func idempotentOptions(message []byte, options *PublishOptions) PublishOptions {
+	opts := resolvePublishOptions(options)
+	opts.MessageID = PayloadMessageID(message)
+	return opts
+}
 
-	return nil
+// PayloadMessageID returns a deterministic message ID (hex SHA-256) for a message body
+func PayloadMessageID(message []byte) string {
+	sum := sha256.Sum256(message)
+	return hex.EncodeToString(sum[:])
 }
 
 // PublishToQueueRaw publishes raw bytes to a queue without JSON marshaling
@@ -116,56 +86,91 @@ func PublishToQueueRaw(
 		return err
 	}
 
-	logger := conn.GetLogger()
+	return publish(conn, channel, queue, message, "application/octet-stream", options)
+}
+
+// marshalPayload marshals the payload to JSON
+func marshalPayload(conn *Connection, queue string, payload interface{}) ([]byte, error) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		conn.GetLogger().Error("Failed to marshal payload", map[string]interface{}{
+			"error": err.Error(),
+			"queue": queue,
+		})
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return message, nil
+}
 
+// resolvePublishOptions returns a copy of the options with defaults filled in
+func resolvePublishOptions(options *PublishOptions) PublishOptions {
 	// Use default options if not provided
 	if options == nil {
-		defaultOpts := DefaultPublishOptions()
-		options = &defaultOpts
+		return DefaultPublishOptions()
 	}
 
+	opts := *options
 	// Use default queue options if not provided
-	if options.QueueOptions == nil {
+	if opts.QueueOptions == nil {
 		defaultQueueOpts := DefaultQueueOptions()
-		options.QueueOptions = &defaultQueueOpts
+		opts.QueueOptions = &defaultQueueOpts
 	}
+	return opts
+}
 
-	// Assert queue
-	_, err = channel.QueueDeclare(
-		queue,
-		options.QueueOptions.Durable,
-		options.QueueOptions.AutoDelete,
-		options.QueueOptions.Exclusive,
-		options.QueueOptions.NoWait,
-		options.QueueOptions.Args,
-	)
-	if err != nil {
-		logger.Error("Failed to declare queue", map[string]interface{}{
-			"error": err.Error(),
-			"queue": queue,
-		})
-		return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+// publish declares the queue (once per channel) and publishes the message
+func publish(
+	conn *Connection,
+	channel publishChannel,
+	queue string,
+	message []byte,
+	contentType string,
+	options *PublishOptions,
+) error {
+	logger := conn.GetLogger()
+	opts := resolvePublishOptions(options)
+
+	// Assert queue (skipped when already declared on this channel)
+	if !conn.isQueueDeclared(queue) {
+		_, err := channel.QueueDeclare(
+			queue,
+			opts.QueueOptions.Durable,
+			opts.QueueOptions.AutoDelete,
+			opts.QueueOptions.Exclusive,
+			opts.QueueOptions.NoWait,
+			opts.QueueOptions.Args,
+		)
+		if err != nil {
+			logger.Error("Failed to declare queue", map[string]interface{}{
+				"error": err.Error(),
+				"queue": queue,
+			})
+			return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+		}
+		conn.markQueueDeclared(queue)
 	}
 
 	// Prepare publishing options
 	publishing := amqp.Publishing{
-		ContentType:  "application/octet-stream",
-		Body:         message,
-		DeliveryMode: amqp.Transient,
-		Priority:     options.Priority,
-		Headers:      options.Headers,
+		ContentType:   contentType,
+		Body:          message,
+		DeliveryMode:  amqp.Transient,
+		Priority:      opts.Priority,
+		Headers:       opts.Headers,
+		MessageId:     opts.MessageID,
+		CorrelationId: opts.CorrelationID,
 	}
 
-	if options.Persistent {
+	if opts.Persistent {
 		publishing.DeliveryMode = amqp.Persistent
 	}
 
-	if options.Expiration != "" {
-		publishing.Expiration = options.Expiration
+	if opts.Expiration != "" {
+		publishing.Expiration = opts.Expiration
 	}
 
 	// Publish message
-	err = channel.PublishWithContext(
+	err := channel.PublishWithContext(
 		context.Background(),
 		"",    // exchange
 		queue, // routing key
@@ -175,16 +180,17 @@ func PublishToQueueRaw(
 	)
 
 	if err != nil {
-		logger.Error("Failed to publish raw message to queue", map[string]interface{}{
+		logger.Error("Failed to publish message to queue", map[string]interface{}{
 			"error": err.Error(),
 			"queue": queue,
 		})
-		return fmt.Errorf("failed to publish raw message to queue %s: %w", queue, err)
+		return fmt.Errorf("failed to publish message to queue %s: %w", queue, err)
 	}
 
-	logger.Debug("Raw message published to queue", map[string]interface{}{
+	logger.Debug("Message published to queue", map[string]interface{}{
 		"queue":       queue,
 		"payloadSize": len(message),
+		"messageId":   opts.MessageID,
 	})
 
 	return nil
@@ -0,0 +1,117 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// nopLogger discards all log output
+type nopLogger struct{}
+
+func (nopLogger) Info(string, map[string]interface{})  {}
+func (nopLogger) Debug(string, map[string]interface{}) {}
+func (nopLogger) Error(string, map[string]interface{}) {}
+func (nopLogger) Warn(string, map[string]interface{})  {}
+
+// fakeChannel records queue declarations and published messages
+type fakeChannel struct {
+	declares  map[string]int
+	published []amqp.Publishing
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{declares: make(map[string]int)}
+}
+
+func (f *fakeChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	f.declares[name]++
+	return amqp.Queue{Name: name}, nil
+}
+
+func (f *fakeChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	f.published = append(f.published, msg)
+	return nil
+}
+
+func TestPublish_DeclaresQueueOnce(t *testing.T) {
+	conn := NewConnection(Config{}, nopLogger{})
+	channel := newFakeChannel()
+
+	for i := 0; i < 3; i++ {
+		if err := publish(conn, channel, "orders", []byte(`{}`), "application/json", nil); err != nil {
+			t.Fatalf("publish %d failed: %v", i, err)
+		}
+	}
+	if err := publish(conn, channel, "fills", []byte(`{}`), "application/json", nil); err != nil {
+		t.Fatalf("publish to second queue failed: %v", err)
+	}
+
+	if channel.declares["orders"] != 1 {
+		t.Errorf("Expected orders queue declared once, got %d", channel.declares["orders"])
+	}
+	if channel.declares["fills"] != 1 {
+		t.Errorf("Expected fills queue declared once, got %d", channel.declares["fills"])
+	}
+	if len(channel.published) != 4 {
+		t.Errorf("Expected 4 published messages, got %d", len(channel.published))
+	}
+}
+
+func TestPublish_SetsMessageAndCorrelationID(t *testing.T) {
+	conn := NewConnection(Config{}, nopLogger{})
+	channel := newFakeChannel()
+
+	opts := DefaultPublishOptions()
+	opts.MessageID = "msg-1"
+	opts.CorrelationID = "advice-42"
+	if err := publish(conn, channel, "orders", []byte(`{"side":"buy"}`), "application/json", &opts); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	msg := channel.published[0]
+	if msg.MessageId != "msg-1" || msg.CorrelationId != "advice-42" {
+		t.Errorf("Expected message/correlation ID to be set, got %q / %q", msg.MessageId, msg.CorrelationId)
+	}
+	if msg.DeliveryMode != amqp.Persistent {
+		t.Errorf("Expected persistent delivery, got %d", msg.DeliveryMode)
+	}
+}
+
+func TestPayloadMessageID_Deterministic(t *testing.T) {
+	a := PayloadMessageID([]byte(`{"instId":"ETH-USDT-SWAP","price":"3889.94"}`))
+	b := PayloadMessageID([]byte(`{"instId":"ETH-USDT-SWAP","price":"3889.94"}`))
+	c := PayloadMessageID([]byte(`{"instId":"ETH-USDT-SWAP","price":"3889.95"}`))
+
+	if a != b {
+		t.Errorf("Expected identical payloads to share a message ID, got %s and %s", a, b)
+	}
+	if a == c {
+		t.Error("Expected different payloads to have different message IDs")
+	}
+	if len(a) != 64 {
+		t.Errorf("Expected hex SHA-256 message ID, got %q", a)
+	}
+}
+
+func TestIdempotentOptions_RetriedPublishSharesMessageID(t *testing.T) {
+	conn := NewConnection(Config{}, nopLogger{})
+	channel := newFakeChannel()
+	message := []byte(`{"instId":"ETH-USDT-SWAP","openPrice":"3889.94"}`)
+
+	for i := 0; i < 2; i++ {
+		opts := idempotentOptions(message, nil)
+		if err := publish(conn, channel, "orders", message, "application/json", &opts); err != nil {
+			t.Fatalf("publish %d failed: %v", i, err)
+		}
+	}
+
+	first, retry := channel.published[0], channel.published[1]
+	if first.MessageId == "" || first.MessageId != retry.MessageId {
+		t.Errorf("Expected retried publish to reuse the message ID, got %q and %q", first.MessageId, retry.MessageId)
+	}
+	if first.MessageId != PayloadMessageID(message) {
+		t.Errorf("Expected message ID from payload hash, got %q", first.MessageId)
+	}
+}
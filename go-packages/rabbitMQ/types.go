@@ -38,11 +38,13 @@ func DefaultQueueOptions() QueueOptions {
 
 // PublishOptions represents message publishing options
 type PublishOptions struct {
-	Persistent   bool
-	Priority     uint8
-	Expiration   string
-	Headers      amqp.Table
-	QueueOptions *QueueOptions
+	Persistent    bool
+	Priority      uint8
+	Expiration    string
+	Headers       amqp.Table
+	QueueOptions  *QueueOptions
+	MessageID     string // Set as amqp MessageId so consumers can detect duplicates
+	CorrelationID string // Set as amqp CorrelationId to link related messages
 }
 
 // DefaultPublishOptions returns default publish options
@@ -1,10 +1,22 @@
 package value_objects
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrInvalidOHLC K線價格不滿足 low <= open, close <= high
+var ErrInvalidOHLC = errors.New("invalid OHLC")
+
+// OHLCValidation K線 OHLC 校驗模式（由數據加載器選擇）⭐
+type OHLCValidation int
+
+const (
+	OHLCStrict OHLCValidation = iota // 嚴格：拒絕不滿足 low <= open, close <= high 的K線（默認）
+	OHLCClamp                        // 容錯：high/low 顛倒時交換，open/close 夾到 [low, high]
+)
+
 // Candle K線值對象
 // 包含開高低收及時間信息
 type Candle struct {
@@ -13,9 +25,10 @@ type Candle struct {
 	low       Price
 	close     Price
 	timestamp time.Time
+	confirmed bool // 是否已完成（OKX confirm=1）⭐
 }
 
-// NewCandle 創建K線（工廠方法）
+// NewCandle 創建K線（工廠方法，默認為已完成K線）
 func NewCandle(open, high, low, close float64, timestamp time.Time) (Candle, error) {
 	openPrice, err := NewPrice(open)
 	if err != nil {
@@ -39,7 +52,7 @@ func NewCandle(open, high, low, close float64, timestamp time.Time) (Candle, err
 
 	// 驗證業務規則：high >= low
 	if !highPrice.IsAboveOrEqual(lowPrice) {
-		return Candle{}, fmt.Errorf("high price must be >= low price")
+		return Candle{}, fmt.Errorf("%w: high price must be >= low price", ErrInvalidOHLC)
 	}
 
 	return Candle{
@@ -48,15 +61,69 @@ func NewCandle(open, high, low, close float64, timestamp time.Time) (Candle, err
 		low:       lowPrice,
 		close:     closePrice,
 		timestamp: timestamp,
+		confirmed: true,
 	}, nil
 }
 
+// NewCandleWithValidation 按校驗模式創建K線 ⭐
+//
+// NewCandle 只檢查 high >= low，開盤價或收盤價超出 [low, high] 的壞數據會扭曲指標計算：
+//   - OHLCStrict: 違反 low <= open, close <= high 時返回 ErrInvalidOHLC
+//   - OHLCClamp: 修正價格後創建K線，adjusted = true 表示有修正（調用方據此告警）
+func NewCandleWithValidation(open, high, low, close float64, timestamp time.Time, mode OHLCValidation) (candle Candle, adjusted bool, err error) {
+	if mode == OHLCClamp {
+		open, high, low, close, adjusted = clampOHLC(open, high, low, close)
+	}
+
+	candle, err = NewCandle(open, high, low, close, timestamp)
+	if err != nil {
+		return Candle{}, false, err
+	}
+	if open < low || open > high {
+		return Candle{}, false, fmt.Errorf("%w: open %v outside [%v, %v]", ErrInvalidOHLC, open, low, high)
+	}
+	if close < low || close > high {
+		return Candle{}, false, fmt.Errorf("%w: close %v outside [%v, %v]", ErrInvalidOHLC, close, low, high)
+	}
+	return candle, adjusted, nil
+}
+
+// clampOHLC 交換顛倒的 high/low，並把 open/close 夾到 [low, high]
+func clampOHLC(open, high, low, close float64) (float64, float64, float64, float64, bool) {
+	adjusted := false
+	if high < low {
+		high, low = low, high
+		adjusted = true
+	}
+	clamp := func(v float64) float64 {
+		switch {
+		case v < low:
+			adjusted = true
+			return low
+		case v > high:
+			adjusted = true
+			return high
+		}
+		return v
+	}
+	open = clamp(open)
+	close = clamp(close)
+	return open, high, low, close, adjusted
+}
+
+// WithConfirmed 返回設置了完成狀態的K線副本（用於標記未完成的K線）
+func (c Candle) WithConfirmed(confirmed bool) Candle {
+	c.confirmed = confirmed
+	return c
+}
+
 // Getters
 func (c Candle) Open() Price          { return c.open }
 func (c Candle) High() Price          { return c.high }
 func (c Candle) Low() Price           { return c.low }
 func (c Candle) Close() Price         { return c.close }
 func (c Candle) Timestamp() time.Time { return c.timestamp }
+func (c Candle) IsConfirmed() bool    { return c.confirmed }
 
 // BodyLow 返回實體低點（開盤價和收盤價中較小的）
 func (c Candle) BodyLow() Price {
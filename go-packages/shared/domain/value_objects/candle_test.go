@@ -0,0 +1,94 @@
+package value_objects
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestNewPrice_RejectsNonFinite 測試價格拒絕 NaN/Inf
+func TestNewPrice_RejectsNonFinite(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := NewPrice(v); err == nil {
+			t.Errorf("Expected NewPrice(%v) to fail", v)
+		}
+	}
+	if _, err := NewPrice(2500); err != nil {
+		t.Errorf("Expected NewPrice(2500) to succeed, got %v", err)
+	}
+}
+
+// TestNewCandle_RejectsNonFinite 測試K線的任一價格為 NaN/Inf 時構造失敗
+func TestNewCandle_RejectsNonFinite(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, bad := range []float64{math.NaN(), math.Inf(1)} {
+		cases := [][4]float64{
+			{bad, 2510, 2490, 2505},
+			{2500, bad, 2490, 2505},
+			{2500, 2510, bad, 2505},
+			{2500, 2510, 2490, bad},
+		}
+		for _, c := range cases {
+			if _, err := NewCandle(c[0], c[1], c[2], c[3], ts); err == nil {
+				t.Errorf("Expected NewCandle(%v) to fail", c)
+			}
+		}
+	}
+	if _, err := NewCandle(2500, 2510, 2490, 2505, ts); err != nil {
+		t.Errorf("Expected valid candle, got %v", err)
+	}
+}
+
+// TestNewCandleWithValidation_InvertedHighLow 測試 high/low 顛倒的K線在兩種模式下的處理 ⭐
+func TestNewCandleWithValidation_InvertedHighLow(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, _, err := NewCandleWithValidation(2500, 2490, 2510, 2505, ts, OHLCStrict); !errors.Is(err, ErrInvalidOHLC) {
+		t.Errorf("Strict: expected ErrInvalidOHLC for inverted high/low, got %v", err)
+	}
+
+	candle, adjusted, err := NewCandleWithValidation(2500, 2490, 2510, 2505, ts, OHLCClamp)
+	if err != nil {
+		t.Fatalf("Clamp: expected inverted candle to be repaired, got %v", err)
+	}
+	if !adjusted {
+		t.Error("Clamp: expected adjusted = true")
+	}
+	if candle.High().Value() != 2510 || candle.Low().Value() != 2490 {
+		t.Errorf("Clamp: expected high/low swapped to 2510/2490, got %v/%v", candle.High().Value(), candle.Low().Value())
+	}
+	if candle.Open().Value() != 2500 || candle.Close().Value() != 2505 {
+		t.Errorf("Clamp: expected open/close unchanged, got %v/%v", candle.Open().Value(), candle.Close().Value())
+	}
+}
+
+// TestNewCandleWithValidation_BodyOutsideRange 測試開盤價/收盤價超出 [low, high]
+func TestNewCandleWithValidation_BodyOutsideRange(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := [][4]float64{
+		{2520, 2510, 2490, 2505}, // open > high
+		{2500, 2510, 2490, 2480}, // close < low
+	}
+	for _, c := range cases {
+		if _, _, err := NewCandleWithValidation(c[0], c[1], c[2], c[3], ts, OHLCStrict); !errors.Is(err, ErrInvalidOHLC) {
+			t.Errorf("Strict %v: expected ErrInvalidOHLC, got %v", c, err)
+		}
+		candle, adjusted, err := NewCandleWithValidation(c[0], c[1], c[2], c[3], ts, OHLCClamp)
+		if err != nil || !adjusted {
+			t.Fatalf("Clamp %v: expected repaired candle, got adjusted=%v err=%v", c, adjusted, err)
+		}
+		for _, p := range []float64{candle.Open().Value(), candle.Close().Value()} {
+			if p < candle.Low().Value() || p > candle.High().Value() {
+				t.Errorf("Clamp %v: price %v outside [%v, %v]", c, p, candle.Low().Value(), candle.High().Value())
+			}
+		}
+	}
+
+	// 合法K線：兩種模式都不修正
+	for _, mode := range []OHLCValidation{OHLCStrict, OHLCClamp} {
+		if _, adjusted, err := NewCandleWithValidation(2500, 2510, 2490, 2505, ts, mode); err != nil || adjusted {
+			t.Errorf("Mode %d: expected valid candle unchanged, got adjusted=%v err=%v", mode, adjusted, err)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package value_objects
 
+import "math"
+
 // PositionSummary 倉位摘要（用於策略輸入）
 //
 // 設計目的：
@@ -11,13 +13,15 @@ package value_objects
 // - 決定是否需要調整策略參數
 // - 風險控制判斷
 type PositionSummary struct {
-	Count                   int     // 持倉數量（未平倉的倉位數）
-	TotalSize               float64 // 總倉位大小（所有未平倉的本金總和，單位：USDT）
-	AvgPrice                float64 // 平均開倉價格（加權平均）
-	FeesPaid                float64 // 已支付的總手續費（包含開倉和已平倉的手續費）
-	CurrentRoundRealizedPnL float64 // 當前交易輪次的已實現盈虧（扣除手續費）⭐
-	CurrentRoundClosedValue float64 // 當前交易輪次累積關倉價值（本金 + 盈虧）⭐
-	UnrealizedPnL           float64 // 未實現盈虧（外部計算，已包含預估平倉費）⭐ 用於 ShouldBreakEven2
+	Count                   int       // 持倉數量（未平倉的倉位數）
+	TotalSize               float64   // 總倉位大小（所有未平倉的本金總和，單位：USDT）
+	AvgPrice                float64   // 平均開倉價格（加權平均）
+	FeesPaid                float64   // 已支付的總手續費（包含開倉和已平倉的手續費）
+	CurrentRoundRealizedPnL float64   // 當前交易輪次的已實現盈虧（扣除手續費）⭐
+	CurrentRoundClosedValue float64   // 當前交易輪次累積關倉價值（本金 + 盈虧）⭐
+	UnrealizedPnL           float64   // 未實現盈虧（外部計算，已包含預估平倉費）⭐ 用於 ShouldBreakEven2
+	EntryPrices             []float64 // 本輪未平倉位的開倉價（可選，通過 WithEntryPrices 設置，用於價位去重）⭐
+	CurrentRoundFundingFee  float64   // 當前交易輪次已支付的永續合約資金費（可選，通過 WithFundingFee 設置，打平目標扣除此值）⭐
 }
 
 // NewPositionSummary 創建倉位摘要
@@ -59,34 +63,41 @@ func (ps PositionSummary) TotalCost() float64 {
 //
 // 盈虧平衡價格 = 需要讓所有倉位以某個價格平倉後，收益剛好抵銷手續費
 //
-// 公式：
+// 公式（精確解）⭐：
 //
-//	未實現盈虧 = TotalSize × (closePrice - avgPrice) / avgPrice
-//	平倉手續費 = (TotalSize + 未實現盈虧) × feeRate
+//	幣數 coins = TotalSize / avgPrice
+//	未實現盈虧 = coins × (closePrice - avgPrice)
+//	平倉手續費 = coins × closePrice × feeRate（手續費按「平倉價值」收取，包含盈利部分）
 //	盈虧平衡條件：未實現盈虧 - 平倉手續費 - 已支付手續費 = 0
 //
+//	→ closePrice = (TotalSize + FeesPaid) / (coins × (1 - feeRate))
+//	             = avgPrice × (1 + FeesPaid / TotalSize) / (1 - feeRate)
+//
+// 近似解（舊版）：
+//
+//	closePrice ≈ avgPrice × (1 + (FeesPaid + TotalSize × feeRate) / TotalSize)
+//
+// 近似解假設平倉手續費按本金收取，忽略了盈利部分的手續費，因此略低於精確解。
+//
 // 參數：
 //   - feeRate: 手續費率（例如：0.0005 = 0.05%）
 //
 // 返回：
-//   - breakEvenPrice: 盈虧平衡價格
-func (ps PositionSummary) CalculateBreakEvenPrice(feeRate float64) float64 {
-	if ps.Count == 0 || ps.AvgPrice == 0 {
-		return 0
+//   - approxPrice: 近似盈虧平衡價格（用於對比）
+//   - exactPrice: 精確盈虧平衡價格
+func (ps PositionSummary) CalculateBreakEvenPrice(feeRate float64) (approxPrice float64, exactPrice float64) {
+	if ps.Count == 0 || ps.AvgPrice == 0 || ps.TotalSize == 0 || feeRate >= 1 {
+		return 0, 0
 	}
 
-	// 簡化計算：
-	// breakEvenPrice = avgPrice × (1 + totalFees / totalSize)
-	//
-	// 其中：
-	// - totalFees = 已支付手續費 + 預期平倉手續費
-	// - 預期平倉手續費 ≈ totalSize × feeRate（簡化假設，實際會稍高）
-	//
-	// 更精確的計算需要解方程式，這裡使用近似值
+	// 近似解：平倉手續費 ≈ totalSize × feeRate
 	totalFeesNeeded := ps.FeesPaid + (ps.TotalSize * feeRate)
-	breakEvenPriceRatio := 1 + (totalFeesNeeded / ps.TotalSize)
+	approxPrice = ps.AvgPrice * (1 + (totalFeesNeeded / ps.TotalSize))
 
-	return ps.AvgPrice * breakEvenPriceRatio
+	// 精確解：平倉手續費按平倉價值（本金 + 盈利）收取
+	exactPrice = ps.AvgPrice * (1 + ps.FeesPaid/ps.TotalSize) / (1 - feeRate)
+
+	return approxPrice, exactPrice
 }
 
 // ShouldBreakEven 判斷是否應該盈虧平衡退出
@@ -151,7 +162,10 @@ func (ps PositionSummary) ShouldBreakEven(
 	//
 	// ⭐ 重要：ps.UnrealizedPnL 是外部（BacktestEngine/OrderService）通過 PositionTracker 計算好的
 	//         已經包含了預估平倉手續費，這裡不需要重複計算
-	expectedProfit = ps.CurrentRoundRealizedPnL + ps.UnrealizedPnL
+	//
+	// ⭐ 資金費：本輪持倉期間支付的資金費也要賺回來才算真正打平，
+	//    否則交易盈虧剛好打平時退出，扣除資金費後實際虧損
+	expectedProfit = ps.CurrentRoundRealizedPnL + ps.UnrealizedPnL - ps.CurrentRoundFundingFee
 
 	// 判斷是否應該觸發打平機制
 	// 條件：expectedProfit >= targetProfitMin（例如 >= 0）
@@ -161,3 +175,56 @@ func (ps PositionSummary) ShouldBreakEven(
 
 	return false, expectedProfit
 }
+
+// ShouldTakeRoundProfit 判斷是否應該整輪止盈退出 ⭐
+//
+// 與 ShouldBreakEven 對稱：打平是虧損輪次的防守機制，整輪止盈則在本輪總盈虧
+// （已實現 + 未實現，ps.UnrealizedPnL 已包含預估平倉費）達到目標時平掉所有倉位鎖定利潤，
+// 不再等待每個倉位各自的止盈價
+//
+// 參數：
+//   - targetProfit: 整輪目標盈利（USDT，<= 0 表示不啟用）
+//
+// 返回：
+//   - shouldExit: 是否應該退出
+//   - expectedProfit: 預期盈利（USDT）
+func (ps PositionSummary) ShouldTakeRoundProfit(targetProfit float64) (shouldExit bool, expectedProfit float64) {
+	if ps.Count == 0 || targetProfit <= 0 {
+		return false, 0
+	}
+
+	expectedProfit = ps.CurrentRoundRealizedPnL + ps.UnrealizedPnL
+	return expectedProfit >= targetProfit, expectedProfit
+}
+
+// WithEntryPrices 返回設置了未平倉位開倉價的摘要副本（切片會被複製）
+func (ps PositionSummary) WithEntryPrices(entryPrices []float64) PositionSummary {
+	ps.EntryPrices = append([]float64(nil), entryPrices...)
+	return ps
+}
+
+// WithFundingFee 返回設置了本輪已支付資金費的摘要副本（打平目標按扣除資金費後的淨利潤判斷）
+func (ps PositionSummary) WithFundingFee(fundingFee float64) PositionSummary {
+	ps.CurrentRoundFundingFee = fundingFee
+	return ps
+}
+
+// NearestEntryDistance 價格與最近一個未平倉位開倉價的相對距離 ⭐
+//
+// 距離 = |price - entry| / entry，取所有開倉價中的最小值
+//
+// 返回：
+//   - distance: 最小相對距離（例: 0.001 = 0.1%）
+//   - ok: 沒有開倉價信息時為 false
+func (ps PositionSummary) NearestEntryDistance(price float64) (distance float64, ok bool) {
+	for _, entry := range ps.EntryPrices {
+		if entry <= 0 {
+			continue
+		}
+		d := math.Abs(price-entry) / entry
+		if !ok || d < distance {
+			distance, ok = d, true
+		}
+	}
+	return distance, ok
+}
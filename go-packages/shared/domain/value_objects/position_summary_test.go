@@ -1,8 +1,9 @@
 package value_objects
 
-// import (
-// 	"testing"
-// )
+import (
+	"math"
+	"testing"
+)
 
 // // TestShouldBreakEven_NoPositions 測試沒有持倉時不觸發
 // func TestShouldBreakEven_NoPositions(t *testing.T) {
@@ -233,3 +234,149 @@ package value_objects
 
 // 	t.Logf("✅ Edge case: ExpectedProfit=%.2f (≈ 20)", expectedProfit)
 // }
+
+// TestCalculateBreakEvenPrice_MatchesBruteForce 測試精確盈虧平衡價格與數值解一致（多倉位）
+func TestCalculateBreakEvenPrice_MatchesBruteForce(t *testing.T) {
+	const feeRate = 0.0005
+
+	// 三筆攤平開倉（單位：USDT 本金 / 開倉價）
+	entries := []struct {
+		size  float64
+		price float64
+	}{
+		{200, 2500},
+		{200, 2450},
+		{200, 2400},
+	}
+
+	totalSize, totalCoins, feesPaid := 0.0, 0.0, 0.0
+	for _, e := range entries {
+		totalSize += e.size
+		totalCoins += e.size / e.price
+		feesPaid += e.size * feeRate // 開倉手續費
+	}
+	avgPrice := totalSize / totalCoins
+
+	ps := NewPositionSummary(len(entries), totalSize, avgPrice, feesPaid, 0, 0, 0)
+	approx, exact := ps.CalculateBreakEvenPrice(feeRate)
+
+	// 以平倉價計算的淨盈虧（逐倉位計算，手續費按平倉價值收取）
+	netPnL := func(closePrice float64) float64 {
+		pnl := -feesPaid
+		for _, e := range entries {
+			coins := e.size / e.price
+			closeValue := coins * closePrice
+			pnl += closeValue - e.size - closeValue*feeRate
+		}
+		return pnl
+	}
+
+	// 暴力搜索：從平均成本開始以 0.0001 步進，找到第一個淨盈虧 >= 0 的價格
+	bruteForce := 0.0
+	for p := avgPrice; p < avgPrice*1.05; p += 0.0001 {
+		if netPnL(p) >= 0 {
+			bruteForce = p
+			break
+		}
+	}
+	if bruteForce == 0 {
+		t.Fatal("Brute-force solver did not find a break-even price")
+	}
+
+	if math.Abs(exact-bruteForce) > 0.001 {
+		t.Errorf("Expected exact %.4f to match brute force %.4f", exact, bruteForce)
+	}
+	if math.Abs(netPnL(exact)) > 1e-9 {
+		t.Errorf("Expected zero net PnL at exact price, got %.12f", netPnL(exact))
+	}
+
+	// 近似解忽略盈利部分的手續費，應略低於精確解（且在該價格仍為虧損）
+	if approx >= exact {
+		t.Errorf("Expected approx %.4f < exact %.4f", approx, exact)
+	}
+	if netPnL(approx) >= 0 {
+		t.Errorf("Expected net loss at approx price, got %.6f", netPnL(approx))
+	}
+
+	t.Logf("✅ avg=%.4f approx=%.4f exact=%.4f brute=%.4f", avgPrice, approx, exact, bruteForce)
+}
+
+// TestCalculateBreakEvenPrice_NoPositions 測試沒有持倉時返回 0
+func TestCalculateBreakEvenPrice_NoPositions(t *testing.T) {
+	approx, exact := PositionSummary{}.CalculateBreakEvenPrice(0.0005)
+	if approx != 0 || exact != 0 {
+		t.Errorf("Expected 0, 0 for empty summary, got %.4f, %.4f", approx, exact)
+	}
+}
+
+// TestShouldTakeRoundProfit 測試整輪止盈：本輪總盈虧（已實現 + 未實現）達到目標才觸發
+func TestShouldTakeRoundProfit(t *testing.T) {
+	tests := []struct {
+		name       string
+		ps         PositionSummary
+		target     float64
+		wantExit   bool
+		wantProfit float64
+	}{
+		{"no positions", PositionSummary{CurrentRoundRealizedPnL: 50}, 10, false, 0},
+		{"disabled", PositionSummary{Count: 2, CurrentRoundRealizedPnL: 50}, 0, false, 0},
+		{"below target", PositionSummary{Count: 2, CurrentRoundRealizedPnL: 6, UnrealizedPnL: 3}, 10, false, 9},
+		{"reaches target", PositionSummary{Count: 2, CurrentRoundRealizedPnL: 6, UnrealizedPnL: 4}, 10, true, 10},
+		{"unrealized only", PositionSummary{Count: 3, UnrealizedPnL: 12}, 10, true, 12},
+		{"realized gain offset by loss", PositionSummary{Count: 3, CurrentRoundRealizedPnL: 15, UnrealizedPnL: -8}, 10, false, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exit, profit := tt.ps.ShouldTakeRoundProfit(tt.target)
+			if exit != tt.wantExit || math.Abs(profit-tt.wantProfit) > 1e-9 {
+				t.Errorf("ShouldTakeRoundProfit(%v) = (%v, %v), want (%v, %v)", tt.target, exit, profit, tt.wantExit, tt.wantProfit)
+			}
+		})
+	}
+}
+
+// TestShouldBreakEven_NetOfFundingFee 測試打平目標扣除本輪資金費 ⭐
+func TestShouldBreakEven_NetOfFundingFee(t *testing.T) {
+	// 本輪已實現 -5，未實現 +6：交易盈虧 +1，達到打平目標 0
+	ps := PositionSummary{
+		Count:                   2,
+		TotalSize:               400,
+		AvgPrice:                2500,
+		CurrentRoundRealizedPnL: -5,
+		CurrentRoundClosedValue: 195,
+		UnrealizedPnL:           6,
+	}
+	if exit, profit := ps.ShouldBreakEven(0, 20); !exit || math.Abs(profit-1) > 1e-9 {
+		t.Fatalf("Without funding fee: expected break-even with profit 1, got (%v, %v)", exit, profit)
+	}
+
+	// 本輪支付了 1.5 USDT 資金費：扣除後實際虧損 0.5，不應打平退出
+	exit, profit := ps.WithFundingFee(1.5).ShouldBreakEven(0, 20)
+	if exit {
+		t.Errorf("With funding fee: expected no break-even exit, got expected profit %v", profit)
+	}
+	if math.Abs(profit-(-0.5)) > 1e-9 {
+		t.Errorf("With funding fee: expected profit -0.5, got %v", profit)
+	}
+}
+
+// TestNearestEntryDistance 測試價格與最近開倉價的相對距離
+func TestNearestEntryDistance(t *testing.T) {
+	ps := NewPositionSummary(2, 400, 2475, 0, 0, 0, 0)
+	if _, ok := ps.NearestEntryDistance(2500); ok {
+		t.Error("Expected no distance without entry prices")
+	}
+
+	entries := []float64{2500, 2450}
+	ps = ps.WithEntryPrices(entries)
+	entries[0] = 1 // 副本不受調用方修改影響
+
+	distance, ok := ps.NearestEntryDistance(2455)
+	if !ok || math.Abs(distance-5.0/2450) > 1e-12 {
+		t.Errorf("Expected distance %.6f to 2450, got %.6f (ok=%v)", 5.0/2450, distance, ok)
+	}
+	if distance, _ := ps.NearestEntryDistance(2500); distance != 0 {
+		t.Errorf("Expected zero distance at an entry price, got %.6f", distance)
+	}
+}
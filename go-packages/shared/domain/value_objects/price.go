@@ -3,6 +3,7 @@ package value_objects
 import (
 	"errors"
 	"fmt"
+	"math"
 )
 
 // Price 價格值對象
@@ -13,6 +14,10 @@ type Price struct {
 
 // NewPrice 創建價格值對象（工廠方法）
 func NewPrice(value float64) (Price, error) {
+	// ⭐ NaN 與任何數比較都為 false，必須單獨拒絕，否則會污染後續所有計算
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Price{}, errors.New("price must be finite")
+	}
 	if value <= 0 {
 		return Price{}, errors.New("price must be positive")
 	}
@@ -1,3 +1,12 @@
 module dizzycode.xyz/shared
 
-go 1.23.0
+go 1.25.1
+
+require dizzycode.xyz/logger v0.0.0
+
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+)
+
+replace dizzycode.xyz/logger => ../logger
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"dizzycode.xyz/logger"
+)
+
+// DefaultCheckTimeout 單次就緒檢查的默認超時
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check 依賴檢查（返回 nil 表示依賴可用）
+type Check func(ctx context.Context) error
+
+// Checker 具名的就緒檢查
+type Checker struct {
+	Name  string
+	Check Check
+}
+
+// Response /readyz 的響應內容
+type Response struct {
+	Status string            `json:"status"`           // "ok" / "unavailable"
+	Checks map[string]string `json:"checks,omitempty"` // 檢查名 -> "ok" 或錯誤信息
+}
+
+// Server 健康檢查 HTTP 服務 ⭐
+//
+// 端點：
+// - /healthz: 存活檢查（進程在運行即返回 200）
+// - /readyz:  就緒檢查（所有依賴檢查通過返回 200，否則返回 503）
+type Server struct {
+	server  *http.Server
+	checks  []Checker
+	timeout time.Duration
+	logger  logger.Logger
+}
+
+// NewServer 創建健康檢查服務（port 例如 "8080"、"50052"）
+func NewServer(port string, checks []Checker, log logger.Logger) *Server {
+	s := &Server{
+		checks:  checks,
+		timeout: DefaultCheckTimeout,
+		logger:  log,
+	}
+	s.server = &http.Server{
+		Addr:              net.JoinHostPort("", port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler 返回包含 /healthz 和 /readyz 的 HTTP handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	return mux
+}
+
+// Start 在背景啟動 HTTP 服務
+func (s *Server) Start() {
+	go func() {
+		s.logger.Info("Health server listening", map[string]any{"addr": s.server.Addr})
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Health server stopped unexpectedly", map[string]any{"error": err})
+		}
+	}()
+}
+
+// Shutdown 優雅關閉 HTTP 服務
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Response{Status: "ok"})
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	resp := Response{Status: "ok", Checks: make(map[string]string, len(s.checks))}
+	code := http.StatusOK
+	for _, c := range s.checks {
+		if err := c.Check(ctx); err != nil {
+			resp.Checks[c.Name] = err.Error()
+			resp.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[c.Name] = "ok"
+	}
+
+	if code != http.StatusOK {
+		s.logger.Warn("Readiness check failed", map[string]any{"checks": resp.Checks})
+	}
+	writeJSON(w, code, resp)
+}
+
+func writeJSON(w http.ResponseWriter, code int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dizzycode.xyz/logger"
+)
+
+// fakeDependency 模擬可切換連線狀態的依賴
+type fakeDependency struct {
+	connected bool
+}
+
+func (f *fakeDependency) check(ctx context.Context) error {
+	if !f.connected {
+		return errors.New("disconnected")
+	}
+	return nil
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, Response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode %s response: %v", path, err)
+	}
+	return rec.Code, resp
+}
+
+func TestReadyz_ReflectsDependencyState(t *testing.T) {
+	ws := &fakeDependency{connected: true}
+	redis := &fakeDependency{connected: true}
+	h := NewServer("0", []Checker{
+		{Name: "websocket", Check: ws.check},
+		{Name: "redis", Check: redis.check},
+	}, logger.NewMulti()).Handler()
+
+	code, resp := get(t, h, "/readyz")
+	if code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("expected 200 ok when connected, got %d %+v", code, resp)
+	}
+
+	ws.connected = false
+	code, resp = get(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Fatalf("expected 503 when websocket disconnected, got %d %+v", code, resp)
+	}
+	if resp.Checks["websocket"] != "disconnected" || resp.Checks["redis"] != "ok" {
+		t.Errorf("unexpected check details: %+v", resp.Checks)
+	}
+
+	// 就緒失敗不影響存活檢查
+	code, resp = get(t, h, "/healthz")
+	if code != http.StatusOK || resp.Status != "ok" {
+		t.Errorf("expected /healthz 200 while not ready, got %d %+v", code, resp)
+	}
+}
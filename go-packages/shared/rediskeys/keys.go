@@ -0,0 +1,92 @@
+package rediskeys
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Separator Redis key 各段之間的分隔符（所有服務統一使用）⭐
+const Separator = "."
+
+// Keys 市場數據的 Redis key 格式（跨專案共用）⭐
+//
+// 用途：
+//   - Market Data Service: 寫入 KV 和發布 Pub/Sub 頻道
+//   - Trading Strategy Server: 讀取最新價格和 K 線
+//
+// 兩邊必須使用相同的命名空間，否則策略服務讀不到行情。
+// 命名空間非空時作為所有 key 的前綴，例如 "prod" → prod.price.latest.ETH-USDT，
+// 讓多個環境（dev / staging / prod）共用同一個 Redis 而不互相覆蓋。
+type Keys struct {
+	namespace string
+}
+
+// Default 無命名空間的 key 格式（與引入命名空間前的 key 完全一致）
+var Default = Keys{}
+
+// New 創建指定命名空間的 key 格式（空字符串 = 無前綴）
+//
+// 命名空間不能包含空白或 glob 字符（* ? [ ]），否則清理時的 SCAN pattern 會匹配到其他環境的 key
+func New(namespace string) (Keys, error) {
+	if strings.ContainsAny(namespace, " \t\r\n*?[]") {
+		return Keys{}, fmt.Errorf("invalid redis key namespace %q: must not contain whitespace or glob characters", namespace)
+	}
+	return Keys{namespace: strings.Trim(namespace, Separator)}, nil
+}
+
+// Namespace 返回命名空間（空 = 無前綴）
+func (k Keys) Namespace() string {
+	return k.namespace
+}
+
+// ========== KV 存儲 Key（Pull 模式）==========
+
+// TickerLatest 最新價格: price.latest.{instId}
+func (k Keys) TickerLatest(instID string) string {
+	return k.join("price", "latest", instID)
+}
+
+// CandleLatest 最新 K 線: candle.latest.{bar}.{instId}
+func (k Keys) CandleLatest(bar, instID string) string {
+	return k.join("candle", "latest", bar, instID)
+}
+
+// CandleHistory 歷史 K 線列表（最新的在前）: candle.history.{bar}.{instId}
+func (k Keys) CandleHistory(bar, instID string) string {
+	return k.join("candle", "history", bar, instID)
+}
+
+// TradesRecent 最近成交列表（最新的在前）: trades.recent.{instId}
+func (k Keys) TradesRecent(instID string) string {
+	return k.join("trades", "recent", instID)
+}
+
+// ========== Pub/Sub Channel（Push 模式）==========
+
+// TickerChannel Ticker 頻道: market.ticker.{instId}
+func (k Keys) TickerChannel(instID string) string {
+	return k.join("market", "ticker", instID)
+}
+
+// CandleChannel K 線頻道: market.candle.{bar}.{instId}
+func (k Keys) CandleChannel(bar, instID string) string {
+	return k.join("market", "candle", bar, instID)
+}
+
+// CleanupPatterns 返回本命名空間下所有需要清理的 KV key pattern（SCAN 使用）
+func (k Keys) CleanupPatterns() []string {
+	return []string{
+		k.join("price", "latest", "*"),
+		k.join("candle", "latest", "*"),
+		k.join("candle", "history", "*"),
+		k.join("trades", "recent", "*"),
+	}
+}
+
+// join 用統一分隔符拼接各段，命名空間非空時加在最前面
+func (k Keys) join(parts ...string) string {
+	if k.namespace != "" {
+		parts = append([]string{k.namespace}, parts...)
+	}
+	return strings.Join(parts, Separator)
+}
@@ -0,0 +1,56 @@
+package rediskeys
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDefault_MatchesLegacyKeys 測試無命名空間時 key 與原有格式一致（升級後不需要遷移數據）
+func TestDefault_MatchesLegacyKeys(t *testing.T) {
+	cases := map[string]string{
+		Default.TickerLatest("ETH-USDT"):        "price.latest.ETH-USDT",
+		Default.CandleLatest("5m", "ETH-USDT"):  "candle.latest.5m.ETH-USDT",
+		Default.CandleHistory("5m", "ETH-USDT"): "candle.history.5m.ETH-USDT",
+		Default.TradesRecent("ETH-USDT"):        "trades.recent.ETH-USDT",
+		Default.TickerChannel("ETH-USDT"):       "market.ticker.ETH-USDT",
+		Default.CandleChannel("1H", "ETH-USDT"): "market.candle.1H.ETH-USDT",
+	}
+	for got, want := range cases {
+		if got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+// TestNew_Namespace 測試命名空間作為前綴，清理 pattern 只匹配本命名空間
+func TestNew_Namespace(t *testing.T) {
+	keys, err := New("staging.")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if keys.Namespace() != "staging" {
+		t.Errorf("Expected trailing separator to be trimmed, got %q", keys.Namespace())
+	}
+	if got := keys.CandleHistory("5m", "BTC-USDT"); got != "staging.candle.history.5m.BTC-USDT" {
+		t.Errorf("Unexpected namespaced key: %s", got)
+	}
+
+	want := []string{"staging.price.latest.*", "staging.candle.latest.*", "staging.candle.history.*", "staging.trades.recent.*"}
+	if got := keys.CleanupPatterns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected cleanup patterns %v, got %v", want, got)
+	}
+
+	empty, err := New("")
+	if err != nil || empty != Default {
+		t.Errorf("Expected empty namespace to equal Default, got %+v / %v", empty, err)
+	}
+}
+
+// TestNew_RejectsGlobCharacters 測試命名空間不能包含會影響 SCAN pattern 的字符
+func TestNew_RejectsGlobCharacters(t *testing.T) {
+	for _, namespace := range []string{"prod*", "a?b", "[dev]", "my env"} {
+		if _, err := New(namespace); err == nil {
+			t.Errorf("Expected error for namespace %q", namespace)
+		}
+	}
+}
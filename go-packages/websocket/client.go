@@ -8,22 +8,31 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// 默認配置
+//
+// OKX 在 30 秒內沒有收到任何消息時會斷開連接，官方建議 N 秒（N < 30）內沒有收到推送就發 ping；
+// 因此默認每 20 秒 ping 一次，並在 30 秒內沒有收到任何數據（含 pong）時判定連接已失效
 const (
-	// 默認配置
 	DefaultPingInterval = 20 * time.Second
 	DefaultPongWait     = 30 * time.Second
 	DefaultWriteWait    = 10 * time.Second
 )
 
+// rttSmoothing 往返延遲移動平均的平滑係數（指數移動平均，新樣本佔 20%）
+const rttSmoothing = 0.2
+
 // MessageHandler 處理接收到的消息
 type MessageHandler func(messageType int, data []byte) error
 
+// RTTHandler 每次收到 pong 時回調：本次 ping-pong 往返延遲和更新後的移動平均 ⭐
+type RTTHandler func(rtt, avg time.Duration)
+
 // Config WebSocket 客戶端配置
 type Config struct {
 	URL          string
-	PingInterval time.Duration
-	PongWait     time.Duration
-	WriteWait    time.Duration
+	PingInterval time.Duration // ping 間隔（0 = DefaultPingInterval，應小於 PongWait）
+	PongWait     time.Duration // 讀超時：超過該時間沒有收到任何數據則斷開（0 = DefaultPongWait）
+	WriteWait    time.Duration // 單次寫入超時（0 = DefaultWriteWait）
 }
 
 // Client 通用 WebSocket 客戶端
@@ -33,10 +42,16 @@ type Client struct {
 	logger         Logger
 	mu             sync.RWMutex
 	messageHandler MessageHandler
+	rttHandler     RTTHandler
 	ctx            context.Context
 	cancel         context.CancelFunc
 	done           chan struct{}
 	isConnected    bool
+
+	// ping-pong 往返延遲（受 mu 保護）⭐
+	pingSentAt time.Time     // 最近一次未收到 pong 的 ping 發送時間（零值 = 沒有等待中的 ping）
+	lastRTT    time.Duration // 最近一次往返延遲
+	avgRTT     time.Duration // 往返延遲的指數移動平均
 }
 
 // NewClient 創建新的 WebSocket 客戶端
@@ -76,6 +91,53 @@ func (c *Client) SetMessageHandler(handler MessageHandler) {
 	c.messageHandler = handler
 }
 
+// SetRTTHandler 設置往返延遲回調（在讀取協程中調用，不應阻塞）
+func (c *Client) SetRTTHandler(handler RTTHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rttHandler = handler
+}
+
+// LastPingRTT 返回最近一次 ping-pong 往返延遲（尚未收到 pong 時為 0）⭐
+func (c *Client) LastPingRTT() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastRTT
+}
+
+// AvgPingRTT 返回往返延遲的指數移動平均（尚未收到 pong 時為 0）
+func (c *Client) AvgPingRTT() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.avgRTT
+}
+
+// recordPong 以 ping 發送時間計算往返延遲並更新移動平均
+//
+// 沒有等待中的 ping 時（例如服務器主動發送的 pong）不記錄
+func (c *Client) recordPong(receivedAt time.Time) {
+	c.mu.Lock()
+	if c.pingSentAt.IsZero() {
+		c.mu.Unlock()
+		return
+	}
+	rtt := receivedAt.Sub(c.pingSentAt)
+	c.pingSentAt = time.Time{}
+	c.lastRTT = rtt
+	if c.avgRTT == 0 {
+		c.avgRTT = rtt
+	} else {
+		c.avgRTT += time.Duration(rttSmoothing * float64(rtt-c.avgRTT))
+	}
+	avg := c.avgRTT
+	handler := c.rttHandler
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(rtt, avg)
+	}
+}
+
 // Connect 連接到 WebSocket 服務器
 func (c *Client) Connect() error {
 	c.logger.Info("Connecting to WebSocket", "url", c.config.URL)
@@ -88,6 +150,7 @@ func (c *Client) Connect() error {
 	c.mu.Lock()
 	c.conn = conn
 	c.isConnected = true
+	c.pingSentAt = time.Time{}
 	c.mu.Unlock()
 
 	c.logger.Info("Successfully connected to WebSocket")
@@ -147,7 +210,9 @@ func (c *Client) readPump() {
 
 	conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+		now := time.Now()
+		conn.SetReadDeadline(now.Add(c.config.PongWait))
+		c.recordPong(now)
 		return nil
 	})
 
@@ -177,7 +242,7 @@ func (c *Client) readPump() {
 	}
 }
 
-// pingPump 定期發送 ping 保持連接
+// pingPump 定期發送 ping 保持連接（並記錄發送時間，用於計算往返延遲）
 func (c *Client) pingPump() {
 	ticker := time.NewTicker(c.config.PingInterval)
 	defer ticker.Stop()
@@ -191,12 +256,17 @@ func (c *Client) pingPump() {
 		case <-ticker.C:
 			c.mu.Lock()
 			if c.conn != nil && c.isConnected {
-				c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+				sentAt := time.Now()
+				c.conn.SetWriteDeadline(sentAt.Add(c.config.WriteWait))
 				if err := c.conn.WriteMessage(websocket.PingMessage, []byte("ping")); err != nil {
 					c.logger.Error("Failed to send ping", "error", err)
 					c.mu.Unlock()
 					return
 				}
+				// 上一個 ping 尚未收到 pong 時保留其發送時間，延遲按最早未回應的 ping 計算
+				if c.pingSentAt.IsZero() {
+					c.pingSentAt = sentAt
+				}
 			}
 			c.mu.Unlock()
 		}
@@ -228,6 +298,11 @@ func (c *Client) IsConnected() bool {
 	return c.isConnected
 }
 
+// Config 返回生效的配置（零值已替換為默認值）
+func (c *Client) Config() Config {
+	return c.config
+}
+
 // Wait 等待連接關閉
 func (c *Client) Wait() {
 	<-c.done
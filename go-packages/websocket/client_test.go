@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer 啟動本地 WebSocket 服務器：回應 ping（gorilla 默認 ping 處理器回覆 pong）並回顯消息
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestClient_PingRTT 測試 pong 回應後記錄非零的往返延遲和移動平均 ⭐
+func TestClient_PingRTT(t *testing.T) {
+	server := newEchoServer(t)
+	client := NewClient(Config{
+		URL:          "ws" + strings.TrimPrefix(server.URL, "http"),
+		PingInterval: 20 * time.Millisecond,
+	}, nil)
+
+	samples := make(chan time.Duration, 16)
+	client.SetRTTHandler(func(rtt, avg time.Duration) {
+		select {
+		case samples <- rtt:
+		default:
+		}
+	})
+
+	if client.LastPingRTT() != 0 || client.AvgPingRTT() != 0 {
+		t.Fatal("Expected zero RTT before any pong")
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case rtt := <-samples:
+			if rtt <= 0 {
+				t.Errorf("Expected positive RTT, got %s", rtt)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for pong #%d", i+1)
+		}
+	}
+
+	if client.LastPingRTT() <= 0 || client.AvgPingRTT() <= 0 {
+		t.Errorf("Expected nonzero RTT metrics, got last=%s avg=%s", client.LastPingRTT(), client.AvgPingRTT())
+	}
+}
+
+// TestClient_RecordPongWithoutPing 測試沒有等待中的 ping 時不記錄延遲，移動平均按平滑係數更新
+func TestClient_RecordPongWithoutPing(t *testing.T) {
+	client := NewClient(Config{URL: "ws://localhost:0"}, nil)
+	now := time.Now()
+
+	client.recordPong(now)
+	if client.LastPingRTT() != 0 {
+		t.Errorf("Expected unsolicited pong to be ignored, got %s", client.LastPingRTT())
+	}
+
+	client.pingSentAt = now
+	client.recordPong(now.Add(100 * time.Millisecond))
+	client.pingSentAt = now
+	client.recordPong(now.Add(200 * time.Millisecond))
+
+	if client.LastPingRTT() != 200*time.Millisecond {
+		t.Errorf("Expected last RTT 200ms, got %s", client.LastPingRTT())
+	}
+	// 100ms + 0.2 × (200ms - 100ms) = 120ms
+	if client.AvgPingRTT() != 120*time.Millisecond {
+		t.Errorf("Expected average RTT 120ms, got %s", client.AvgPingRTT())
+	}
+}